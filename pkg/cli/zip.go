@@ -39,12 +39,12 @@ var debugZipCmd = &cobra.Command{
 	Long: `
 
 Gather cluster debug data into a zip file. Data includes cluster events, node
-liveness, node status, range status, node stack traces, node engine stats, log
-files, and SQL schema.
+liveness, node status, range status, node stack traces, node engine stats, node
+latch state, log files, and SQL schema.
 
-Retrieval of per-node details (status, stack traces, range status, engine stats)
-requires the node to be live and operating properly. Retrieval of SQL data
-requires the cluster to be live.
+Retrieval of per-node details (status, stack traces, range status, engine stats,
+latch state) requires the node to be live and operating properly. Retrieval of
+SQL data requires the cluster to be live.
 `,
 	Args: cobra.ExactArgs(1),
 	RunE: MaybeDecorateGRPCError(runDebugZip),
@@ -357,6 +357,19 @@ func runDebugZip(cmd *cobra.Command, args []string) error {
 					}
 				}
 
+				var latchesData []byte
+				err = contextutil.RunWithTimeout(baseCtx, "request latches", timeout,
+					func(ctx context.Context) error {
+						latches, err := status.Latches(ctx, &serverpb.LatchesRequest{NodeId: id})
+						if err == nil {
+							latchesData = latches.Data
+						}
+						return err
+					})
+				if err := z.createRawOrError(prefix+"/latches.json", latchesData, err); err != nil {
+					return err
+				}
+
 				var stacksData []byte
 				err = contextutil.RunWithTimeout(baseCtx, "request stacks", timeout,
 					func(ctx context.Context) error {
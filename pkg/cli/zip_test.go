@@ -139,6 +139,7 @@ writing ` + os.DevNull + `
   debug/nodes/1/details.json
   debug/nodes/1/gossip.json
   debug/nodes/1/enginestats.json
+  debug/nodes/1/latches.json
   debug/nodes/1/stacks.txt
   debug/nodes/1/heap.pprof
   debug/nodes/1/ranges/1.json
@@ -272,6 +273,7 @@ writing /dev/null
   debug/nodes/1/details.json
   debug/nodes/1/gossip.json
   debug/nodes/1/enginestats.json
+  debug/nodes/1/latches.json
   debug/nodes/1/stacks.txt
   debug/nodes/1/heap.pprof
   debug/nodes/1/ranges/1.json
@@ -335,6 +337,8 @@ writing /dev/null
   ^- resulted in ...
   debug/nodes/2/enginestats.json
   ^- resulted in ...
+  debug/nodes/2/latches.json
+  ^- resulted in ...
   debug/nodes/2/stacks.txt
   ^- resulted in ...
   debug/nodes/2/heap.pprof
@@ -364,6 +368,7 @@ writing /dev/null
   debug/nodes/3/details.json
   debug/nodes/3/gossip.json
   debug/nodes/3/enginestats.json
+  debug/nodes/3/latches.json
   debug/nodes/3/stacks.txt
   debug/nodes/3/heap.pprof
   debug/nodes/3/ranges/1.json
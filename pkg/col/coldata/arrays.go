@@ -0,0 +1,353 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+)
+
+// Arrays is the representation of a column of arrays. The elements of all
+// arrays are stored contiguously in a single child Vec, and the ith array
+// consists of the elements of the child in [offsets[i], offsets[i+1]) range
+// (similarly to the List type of the Arrow format). Just like with Bytes, the
+// arrays can only be set in the non-decreasing order of their indices.
+type Arrays struct {
+	// elemType is the type of the elements of the arrays. It is
+	// coltypes.Unhandled until child is created.
+	elemType coltypes.T
+	// child contains the elements of all arrays. It is nil until the element
+	// type is known (see SetElemType).
+	child Vec
+	// offsets contains the offsets into child for each array. Note that the
+	// last offset contains the total number of elements. We maintain the same
+	// invariant of non-decreasing offsets as Bytes.
+	offsets []int32
+
+	// maxSetIndex specifies the last index set by the user of this struct.
+	maxSetIndex int
+
+	// isWindow indicates whether this Arrays is a "window" into another Arrays.
+	// If it is, no modifications are allowed (all of them will panic).
+	isWindow bool
+}
+
+// ArraysInitialAllocationFactor is an estimate of how many elements each array
+// contains. It is used when the child vector is created.
+const ArraysInitialAllocationFactor = 4
+
+// NewArrays returns an Arrays struct with n empty arrays.
+func NewArrays(n int) *Arrays {
+	return &Arrays{
+		elemType: coltypes.Unhandled,
+		offsets:  make([]int32, n+1),
+	}
+}
+
+// ElemType returns the type of the elements of the arrays, or
+// coltypes.Unhandled if it is not known yet.
+func (a *Arrays) ElemType() coltypes.T {
+	return a.elemType
+}
+
+// SetElemType sets the type of the elements of the arrays and creates the
+// child vector. It is a noop if the receiver already has the same element
+// type.
+func (a *Arrays) SetElemType(t coltypes.T) {
+	if a.child != nil {
+		if a.elemType != t {
+			panic(fmt.Sprintf("element type %s of Arrays cannot be changed to %s", a.elemType, t))
+		}
+		return
+	}
+	a.elemType = t
+	a.child = NewMemColumn(t, a.Len()*ArraysInitialAllocationFactor)
+}
+
+// Child returns the vector that contains the elements of all arrays. Note that
+// it is nil until the element type is set.
+func (a *Arrays) Child() Vec {
+	return a.child
+}
+
+// Bounds returns the start (inclusive) and the end (exclusive) indices of the
+// elements of the ith array in the child vector.
+// NOTE: if ith element was never set in any way, the behavior of Bounds is
+// undefined.
+func (a *Arrays) Bounds(i int) (start, end int) {
+	return int(a.offsets[i]), int(a.offsets[i+1])
+}
+
+// maybeBackfillOffsets fills in the offsets of the arrays that were skipped
+// (which can happen if they are NULL) up to index i. See the comment on
+// Bytes.maybeBackfillOffsets for more details.
+func (a *Arrays) maybeBackfillOffsets(i int) {
+	for j := a.maxSetIndex + 2; j <= i; j++ {
+		a.offsets[j] = a.offsets[a.maxSetIndex+1]
+	}
+}
+
+// UpdateOffsetsToBeNonDecreasing makes sure that a.offsets[:n+1] are
+// non-decreasing. See the comment on Bytes.UpdateOffsetsToBeNonDecreasing for
+// more details.
+func (a *Arrays) UpdateOffsetsToBeNonDecreasing(n uint64) {
+	prev := a.offsets[0]
+	for j := uint64(1); j <= n; j++ {
+		if a.offsets[j] > prev {
+			prev = a.offsets[j]
+		} else {
+			a.offsets[j] = prev
+		}
+	}
+}
+
+// growChild makes sure that the child vector can contain at least n elements
+// preserving the first numUsed of them.
+func (a *Arrays) growChild(numUsed, n int) {
+	oldLen := a.child.Length()
+	if n <= oldLen {
+		return
+	}
+	newLen := 2 * oldLen
+	if newLen < n {
+		newLen = n
+	}
+	newChild := NewMemColumn(a.elemType, newLen)
+	newChild.Copy(
+		CopySliceArgs{
+			SliceArgs: SliceArgs{
+				ColType:   a.elemType,
+				Src:       a.child,
+				SrcEndIdx: uint64(numUsed),
+			},
+		},
+	)
+	a.child = newChild
+}
+
+// Set makes the ith array consist of numElems elements and returns the index
+// of the first of them in the child vector. The caller is expected to set all
+// of these elements (the child vector is guaranteed to be large enough) which
+// are initially non-NULL. The element type must have been set unless the array
+// is empty. Overwriting an array that is not at the end of the Arrays is not
+// allowed.
+func (a *Arrays) Set(i int, numElems int) int {
+	if a.isWindow {
+		panic("Set is called on a window into Arrays")
+	}
+	if a.child == nil && numElems > 0 {
+		panic("Set is called on Arrays with unknown element type")
+	}
+	if i < a.maxSetIndex {
+		panic(
+			fmt.Sprintf(
+				"cannot overwrite value on Arrays: maxSetIndex=%d, setIndex=%d, consider using Reset",
+				a.maxSetIndex,
+				i,
+			),
+		)
+	}
+	if i > a.maxSetIndex {
+		a.maybeBackfillOffsets(i)
+	}
+	start := a.offsets[i]
+	end := start + int32(numElems)
+	if a.elemType == coltypes.Bytes {
+		// The elements of the array being overwritten might have already been
+		// set, so we need to truncate the flat Bytes in order to be able to set
+		// them again.
+		if b := a.child.Bytes(); b.maxSetIndex > int(start) {
+			b.data = b.data[:b.offsets[start]]
+			b.maxSetIndex = int(start)
+		}
+	}
+	if numElems > 0 {
+		a.growChild(int(start), int(end))
+		a.child.Nulls().UnsetNullRange(uint64(start), uint64(end))
+	}
+	a.offsets[i+1] = end
+	a.maxSetIndex = i
+	return int(start)
+}
+
+// Window creates a "window" into the receiver. It behaves similarly to
+// Golang's slice, but the returned object is *not* allowed to be modified - it
+// is read-only. The window shares the child vector with the receiver.
+func (a *Arrays) Window(start, end int) *Arrays {
+	if start < 0 || start > end || end > a.Len() {
+		panic(
+			fmt.Sprintf(
+				"invalid window arguments: start=%d end=%d when Arrays.Len()=%d",
+				start, end, a.Len(),
+			),
+		)
+	}
+	a.maybeBackfillOffsets(end)
+	return &Arrays{
+		elemType:    a.elemType,
+		child:       a.child,
+		offsets:     a.offsets[start : end+1],
+		maxSetIndex: (end - start) - 1,
+		isWindow:    true,
+	}
+}
+
+// AppendSlice appends srcStartIdx inclusive and srcEndIdx exclusive arrays
+// from src into the receiver starting at destIdx, truncating the receiver.
+func (a *Arrays) AppendSlice(src *Arrays, destIdx, srcStartIdx, srcEndIdx int) {
+	if a.isWindow {
+		panic("AppendSlice is called on a window into Arrays")
+	}
+	if destIdx < 0 || destIdx > a.Len() {
+		panic(fmt.Sprintf("dest index %d out of range (len=%d)", destIdx, a.Len()))
+	} else if srcStartIdx < 0 || srcStartIdx > src.Len() ||
+		srcEndIdx > src.Len() || srcStartIdx > srcEndIdx {
+		panic(
+			fmt.Sprintf(
+				"source index start %d or end %d invalid (len=%d)",
+				srcStartIdx, srcEndIdx, src.Len(),
+			),
+		)
+	}
+	// See the comments in Bytes.AppendSlice about the order of the updates
+	// below.
+	a.maybeBackfillOffsets(destIdx)
+	src.maybeBackfillOffsets(srcEndIdx)
+	toAppend := srcEndIdx - srcStartIdx
+	srcStartElemIdx, srcEndElemIdx := src.offsets[srcStartIdx], src.offsets[srcEndIdx]
+	destElemIdx := a.offsets[destIdx]
+	if srcEndElemIdx > srcStartElemIdx {
+		a.SetElemType(src.elemType)
+		a.child.Append(
+			SliceArgs{
+				ColType:     a.elemType,
+				Src:         src.child,
+				DestIdx:     uint64(destElemIdx),
+				SrcStartIdx: uint64(srcStartElemIdx),
+				SrcEndIdx:   uint64(srcEndElemIdx),
+			},
+		)
+	}
+	a.maxSetIndex = destIdx + (toAppend - 1)
+	translateBy := destElemIdx - srcStartElemIdx
+	a.offsets = append(a.offsets[:destIdx], src.offsets[srcStartIdx:srcEndIdx+1]...)
+	if translateBy != 0 {
+		destOffsets := a.offsets[destIdx:]
+		for i := range destOffsets {
+			destOffsets[i] += translateBy
+		}
+	}
+}
+
+// CopySlice copies srcStartIdx inclusive and srcEndIdx exclusive arrays from
+// src into the receiver starting at destIdx. Similar to the copy builtin,
+// min(dest.Len(), src.Len()) arrays will be copied. Note that the elements of
+// the arrays that follow the copied ones might have to be moved in the child
+// vector.
+func (a *Arrays) CopySlice(src *Arrays, destIdx, srcStartIdx, srcEndIdx int) {
+	if a.isWindow {
+		panic("CopySlice is called on a window into Arrays")
+	}
+	n := a.Len()
+	toCopy := srcEndIdx - srcStartIdx
+	if destIdx+toCopy > n {
+		toCopy = n - destIdx
+	}
+	if toCopy <= 0 {
+		return
+	}
+	var leftover *Arrays
+	if destIdx+toCopy <= a.maxSetIndex {
+		// There are arrays after the copied ones that need to be preserved.
+		leftover = NewArrays(0 /* n */)
+		leftover.AppendSlice(a, 0 /* destIdx */, destIdx+toCopy, a.maxSetIndex+1)
+	}
+	a.AppendSlice(src, destIdx, srcStartIdx, srcStartIdx+toCopy)
+	if leftover != nil {
+		a.AppendSlice(leftover, a.Len(), 0 /* srcStartIdx */, leftover.Len())
+	}
+	// AppendSlice truncates the receiver, so we need to restore the length.
+	for last := a.offsets[a.Len()]; a.Len() < n; {
+		a.offsets = append(a.offsets, last)
+	}
+}
+
+// SetLength sets the length of this Arrays. Note that it will panic if there
+// is not enough capacity.
+func (a *Arrays) SetLength(l int) {
+	if a.isWindow {
+		panic("SetLength is called on a window into Arrays")
+	}
+	a.offsets = a.offsets[:l+1]
+}
+
+// Len returns how many arrays the receiver contains.
+func (a *Arrays) Len() int {
+	return len(a.offsets) - 1
+}
+
+// ArraysOverhead is the overhead of Arrays in bytes.
+const ArraysOverhead = unsafe.Sizeof(Arrays{})
+
+// Size returns the size of the receiver in bytes without taking into account
+// the child vector.
+func (a *Arrays) Size() uintptr {
+	return ArraysOverhead + uintptr(cap(a.offsets))*sizeOfInt32
+}
+
+// Reset resets the receiver for reuse. Similarly to Bytes.Reset, the length
+// is not changed.
+func (a *Arrays) Reset() {
+	if a.isWindow {
+		panic("Reset is called on a window into Arrays")
+	}
+	for n := 0; n < len(a.offsets); n += copy(a.offsets[n:], zeroInt32Slice) {
+	}
+	a.maxSetIndex = 0
+	if a.elemType == coltypes.Bytes {
+		a.child.Bytes().Reset()
+	}
+}
+
+// String is used for debugging purposes.
+func (a *Arrays) String() string {
+	var builder strings.Builder
+	for i := range a.offsets[:a.maxSetIndex+1] {
+		builder.WriteString(fmt.Sprintf("%d: %s\n", i, a.arrayString(i)))
+	}
+	return builder.String()
+}
+
+// arrayString returns the string representation of the ith array.
+func (a *Arrays) arrayString(i int) string {
+	var builder strings.Builder
+	builder.WriteByte('{')
+	start, end := a.Bounds(i)
+	for j := start; j < end; j++ {
+		if j > start {
+			builder.WriteByte(',')
+		}
+		switch {
+		case a.child.Nulls().NullAt64(uint64(j)):
+			builder.WriteString("NULL")
+		case a.elemType == coltypes.Bytes:
+			builder.WriteString(fmt.Sprintf("%v", a.child.Bytes().Get(j)))
+		default:
+			builder.WriteString(fmt.Sprintf("%v", reflect.ValueOf(a.child.Col()).Index(j)))
+		}
+	}
+	builder.WriteByte('}')
+	return builder.String()
+}
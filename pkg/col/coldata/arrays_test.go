@@ -0,0 +1,194 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+// setInt64Array sets the ith array of a to vals where nil values are NULL.
+func setInt64Array(a *Arrays, i int, vals ...interface{}) {
+	start := a.Set(i, len(vals))
+	for j, v := range vals {
+		if v == nil {
+			a.Child().Nulls().SetNull64(uint64(start + j))
+		} else {
+			a.Child().Int64()[start+j] = int64(v.(int))
+		}
+	}
+}
+
+// int64Arrays returns the first n arrays of a as slices of their elements
+// with nil denoting a NULL element.
+func int64Arrays(a *Arrays, n int) [][]interface{} {
+	res := make([][]interface{}, n)
+	for i := range res {
+		start, end := a.Bounds(i)
+		res[i] = []interface{}{}
+		for j := start; j < end; j++ {
+			if a.Child().Nulls().NullAt64(uint64(j)) {
+				res[i] = append(res[i], nil)
+			} else {
+				res[i] = append(res[i], int(a.Child().Int64()[j]))
+			}
+		}
+	}
+	return res
+}
+
+func TestArrays(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	t.Run("Set", func(t *testing.T) {
+		a := NewArrays(4)
+		a.SetElemType(coltypes.Int64)
+		setInt64Array(a, 0, 1, 2)
+		setInt64Array(a, 1)
+		// The array at index 2 is skipped (as if it was NULL).
+		setInt64Array(a, 3, 3, nil, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18)
+		a.UpdateOffsetsToBeNonDecreasing(4)
+		require.Equal(t, [][]interface{}{
+			{1, 2}, {}, {}, {3, nil, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18},
+		}, int64Arrays(a, 4))
+		// Overwriting the last array is allowed.
+		setInt64Array(a, 3, 5)
+		require.Equal(t, [][]interface{}{{1, 2}, {}, {}, {5}}, int64Arrays(a, 4))
+		require.Panics(t, func() { setInt64Array(a, 0, 1) })
+	})
+
+	t.Run("EmptyArraysWithUnknownElemType", func(t *testing.T) {
+		a := NewArrays(2)
+		a.Set(0, 0)
+		a.Set(1, 0)
+		require.Nil(t, a.Child())
+		require.Panics(t, func() { a.Set(1, 1) })
+	})
+
+	t.Run("AppendSlice", func(t *testing.T) {
+		src := NewArrays(3)
+		src.SetElemType(coltypes.Int64)
+		setInt64Array(src, 0, 1)
+		setInt64Array(src, 1, 2, nil)
+		setInt64Array(src, 2, 3, 4, 5)
+		dest := NewArrays(3)
+		dest.SetElemType(coltypes.Int64)
+		setInt64Array(dest, 0, 6, 7)
+		setInt64Array(dest, 1, 8)
+		setInt64Array(dest, 2, 9)
+		dest.AppendSlice(src, 1 /* destIdx */, 1 /* srcStartIdx */, 3 /* srcEndIdx */)
+		require.Equal(t, 3, dest.Len())
+		require.Equal(t, [][]interface{}{{6, 7}, {2, nil}, {3, 4, 5}}, int64Arrays(dest, 3))
+		// The appended arrays can be followed by the new ones.
+		dest.AppendSlice(src, 3 /* destIdx */, 0 /* srcStartIdx */, 1 /* srcEndIdx */)
+		require.Equal(t, [][]interface{}{{6, 7}, {2, nil}, {3, 4, 5}, {1}}, int64Arrays(dest, 4))
+	})
+
+	t.Run("CopySlice", func(t *testing.T) {
+		src := NewArrays(2)
+		src.SetElemType(coltypes.Int64)
+		setInt64Array(src, 0, 1, 2, 3)
+		setInt64Array(src, 1, nil)
+		dest := NewArrays(4)
+		dest.SetElemType(coltypes.Int64)
+		setInt64Array(dest, 0, 4)
+		setInt64Array(dest, 1, 5)
+		setInt64Array(dest, 2, 6)
+		setInt64Array(dest, 3, 7, 8)
+		// The arrays after the copied ones must be preserved.
+		dest.CopySlice(src, 1 /* destIdx */, 0 /* srcStartIdx */, 1 /* srcEndIdx */)
+		require.Equal(t, 4, dest.Len())
+		require.Equal(t, [][]interface{}{{4}, {1, 2, 3}, {6}, {7, 8}}, int64Arrays(dest, 4))
+		// Only the arrays that fit into the receiver are copied.
+		dest.CopySlice(src, 3 /* destIdx */, 0 /* srcStartIdx */, 2 /* srcEndIdx */)
+		require.Equal(t, 4, dest.Len())
+		require.Equal(t, [][]interface{}{{4}, {1, 2, 3}, {6}, {1, 2, 3}}, int64Arrays(dest, 4))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		a := NewArrays(3)
+		a.SetElemType(coltypes.Int64)
+		setInt64Array(a, 0, 1)
+		setInt64Array(a, 1, 2, 3)
+		setInt64Array(a, 2, 4)
+		w := a.Window(1, 3)
+		require.Equal(t, 2, w.Len())
+		require.Equal(t, [][]interface{}{{2, 3}, {4}}, int64Arrays(w, 2))
+		require.Panics(t, func() { w.Set(1, 1) })
+	})
+
+	t.Run("ResetWithBytes", func(t *testing.T) {
+		a := NewArrays(2)
+		a.SetElemType(coltypes.Bytes)
+		for iteration := 0; iteration < 2; iteration++ {
+			start := a.Set(0, 2)
+			a.Child().Bytes().Set(start, []byte("a"))
+			a.Child().Bytes().Set(start+1, []byte("bc"))
+			start = a.Set(1, 1)
+			a.Child().Bytes().Set(start, []byte("d"))
+			require.Equal(t, "{[97],[98 99]}", a.arrayString(0))
+			require.Equal(t, "{[100]}", a.arrayString(1))
+			a.Reset()
+		}
+	})
+}
+
+func TestArraysVec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	src := NewMemColumn(coltypes.Array, 4)
+	srcArrays := src.Array()
+	srcArrays.SetElemType(coltypes.Int64)
+	setInt64Array(srcArrays, 0, 1)
+	setInt64Array(srcArrays, 1, 2, 3)
+	src.Nulls().SetNull(2)
+	setInt64Array(srcArrays, 3, 4, nil)
+	srcArrays.UpdateOffsetsToBeNonDecreasing(4)
+
+	t.Run("AppendWithSel", func(t *testing.T) {
+		dest := NewMemColumn(coltypes.Array, 0)
+		dest.Append(SliceArgs{
+			ColType:   coltypes.Array,
+			Src:       src,
+			Sel:       []int{3, 2, 0},
+			SrcEndIdx: 3,
+		})
+		require.Equal(t, 3, dest.Length())
+		require.Equal(t, "{4,NULL}", dest.PrettyValueAt(0, coltypes.Array))
+		require.Equal(t, "NULL", dest.PrettyValueAt(1, coltypes.Array))
+		require.Equal(t, "{1}", dest.PrettyValueAt(2, coltypes.Array))
+	})
+
+	t.Run("CopyWithSel", func(t *testing.T) {
+		dest := NewMemColumn(coltypes.Array, 3)
+		dest.Copy(CopySliceArgs{
+			SliceArgs: SliceArgs{
+				ColType:   coltypes.Array,
+				Src:       src,
+				Sel:       []int{1, 2, 3},
+				SrcEndIdx: 3,
+			},
+		})
+		require.Equal(t, "{2,3}", dest.PrettyValueAt(0, coltypes.Array))
+		require.Equal(t, "NULL", dest.PrettyValueAt(1, coltypes.Array))
+		require.Equal(t, "{4,NULL}", dest.PrettyValueAt(2, coltypes.Array))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		w := src.Window(1, 3)
+		require.Equal(t, 2, w.Length())
+		require.Equal(t, "{2,3}", w.PrettyValueAt(0, coltypes.Array))
+		require.Equal(t, "NULL", w.PrettyValueAt(1, coltypes.Array))
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"math"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/errors"
 )
 
 // Batch is the type that columnar operators receive and produce. It
@@ -35,7 +36,7 @@ type Batch interface {
 	// Selection, if not nil, returns the selection vector on this batch: a
 	// densely-packed list of the indices in each column that have not been
 	// filtered out by a previous step.
-	Selection() []uint16
+	Selection() []int
 	// SetSelection sets whether this batch is using its selection vector or not.
 	SetSelection(bool)
 	// AppendCol appends the given Vec to this batch.
@@ -57,6 +58,13 @@ type Batch interface {
 	// batches that they reuse as not doing this could result in correctness
 	// or memory blowup issues.
 	ResetInternalBatch()
+	// Clone returns a deep copy of the batch that doesn't share any memory with
+	// it. Only the selected tuples are copied, so the clone has the same length
+	// but no selection vector, and its capacity equals its length.
+	//
+	// NOTE: consider using colexec.Allocator.CloneBatch from the vectorized
+	// engine so that the memory of the copy is accounted for.
+	Clone() Batch
 }
 
 var _ Batch = &MemBatch{}
@@ -66,31 +74,41 @@ const (
 	MinBatchSize = 3
 	// MaxBatchSize is the maximum acceptable size of batches.
 	MaxBatchSize = 4096
+	// DefaultBatchSize is the size of batches unless it is changed by the
+	// tests.
+	DefaultBatchSize = 1024
 )
 
 // TODO(jordan): tune.
-var batchSize = uint16(1024)
+var batchSize = uint16(DefaultBatchSize)
 
 // BatchSize is the maximum number of tuples that fit in a column batch.
 func BatchSize() uint16 {
 	return batchSize
 }
 
-// SetBatchSizeForTests modifies batchSize variable. It should only be used in
-// tests.
-func SetBatchSizeForTests(newBatchSize uint16) {
+// ValidateBatchSize returns an error if newBatchSize is not an acceptable
+// size of batches.
+func ValidateBatchSize(newBatchSize int64) error {
 	if newBatchSize > MaxBatchSize {
-		panic(
-			fmt.Sprintf("requested batch size %d is greater than MaxBatchSize %d",
-				newBatchSize, MaxBatchSize),
+		return errors.Errorf(
+			"requested batch size %d is greater than MaxBatchSize %d", newBatchSize, MaxBatchSize,
 		)
 	}
 	if newBatchSize < MinBatchSize {
-		panic(
-			fmt.Sprintf("requested batch size %d is smaller than MinBatchSize %d",
-				newBatchSize, MinBatchSize),
+		return errors.Errorf(
+			"requested batch size %d is smaller than MinBatchSize %d", newBatchSize, MinBatchSize,
 		)
 	}
+	return nil
+}
+
+// SetBatchSizeForTests modifies batchSize variable. It should only be used in
+// tests.
+func SetBatchSizeForTests(newBatchSize uint16) {
+	if err := ValidateBatchSize(int64(newBatchSize)); err != nil {
+		panic(err.Error())
+	}
 	batchSize = newBatchSize
 }
 
@@ -104,6 +122,12 @@ func NewMemBatch(types []coltypes.T) Batch {
 // NewMemBatchWithSize allocates a new in-memory Batch with the given column
 // size. Use for operators that have a precisely-sized output batch.
 func NewMemBatchWithSize(types []coltypes.T, size int) Batch {
+	return NewMemBatchWithArena(types, size, nil /* arena */)
+}
+
+// NewMemBatchWithArena is like NewMemBatchWithSize, but the vectors of Bytes
+// type allocate their buffers from arena (see BytesArena). arena can be nil.
+func NewMemBatchWithArena(types []coltypes.T, size int, arena *BytesArena) Batch {
 	if max := math.MaxUint16; size > max {
 		panic(fmt.Sprintf(`batches cannot have length larger than %d; requested %d`, max, size))
 	}
@@ -111,13 +135,35 @@ func NewMemBatchWithSize(types []coltypes.T, size int) Batch {
 	b.b = make([]Vec, len(types))
 
 	for i, t := range types {
-		b.b[i] = NewMemColumn(t, size)
+		b.b[i] = NewMemColumnWithArena(t, size, arena)
 	}
-	b.sel = make([]uint16, size)
+	b.sel = make([]int, size)
 
 	return b
 }
 
+// NewMemBatchWithCapacity allocates a new in-memory Batch that can hold up to
+// capacity tuples. Unlike NewMemBatchWithSize, capacity is only a hint of the
+// number of tuples the batch will need to hold, and it is clamped to
+// [1, BatchSize()]. Use for operators that have a known small output (e.g. a
+// limit or an aggregation with a single group) to avoid allocating full-sized
+// vectors.
+func NewMemBatchWithCapacity(types []coltypes.T, capacity int) Batch {
+	return NewMemBatchWithSize(types, CapacityFromHint(capacity))
+}
+
+// CapacityFromHint returns the capacity of a batch that will need to hold up
+// to hint tuples, i.e. hint clamped to [1, BatchSize()].
+func CapacityFromHint(hint int) int {
+	if maxCapacity := int(BatchSize()); hint > maxCapacity {
+		return maxCapacity
+	}
+	if hint < 1 {
+		return 1
+	}
+	return hint
+}
+
 // ZeroBatch is a schema-less Batch of length 0.
 var ZeroBatch = &zeroBatch{MemBatch: NewMemBatchWithSize(nil /* types */, 0 /* size */).(*MemBatch)}
 
@@ -162,7 +208,7 @@ type MemBatch struct {
 	useSel bool
 	// if useSel is true, a selection vector from upstream. a selection vector is
 	// a list of selected column indexes in this memBatch's columns.
-	sel []uint16
+	sel []int
 }
 
 // Length implements the Batch interface.
@@ -186,13 +232,21 @@ func (m *MemBatch) ColVecs() []Vec {
 }
 
 // Selection implements the Batch interface.
-func (m *MemBatch) Selection() []uint16 {
+func (m *MemBatch) Selection() []int {
 	if !m.useSel {
 		return nil
 	}
 	return m.sel
 }
 
+// Capacity returns the maximum number of tuples the batch can hold without
+// reallocating its columns.
+func (m *MemBatch) Capacity() int {
+	// The columns are always sized the same as the selection vector (see
+	// Reset).
+	return len(m.sel)
+}
+
 // SetSelection implements the Batch interface.
 func (m *MemBatch) SetSelection(b bool) {
 	m.useSel = b
@@ -202,8 +256,11 @@ func (m *MemBatch) SetSelection(b bool) {
 func (m *MemBatch) SetLength(n uint16) {
 	m.n = n
 	for _, v := range m.b {
-		if v.Type() == coltypes.Bytes {
+		switch v.Type() {
+		case coltypes.Bytes:
 			v.Bytes().UpdateOffsetsToBeNonDecreasing(uint64(n))
+		case coltypes.Array:
+			v.Array().UpdateOffsetsToBeNonDecreasing(uint64(n))
 		}
 	}
 }
@@ -245,12 +302,28 @@ func (m *MemBatch) Reset(types []coltypes.T, length int) {
 	m.b = m.b[:len(types)]
 	for _, col := range m.ColVecs() {
 		col.Nulls().UnsetNulls()
-		if col.Type() == coltypes.Bytes {
+		switch col.Type() {
+		case coltypes.Bytes:
 			col.Bytes().Reset()
+		case coltypes.Array:
+			col.Array().Reset()
+		case coltypes.Datum:
+			col.Datum().Reset()
 		}
 	}
 }
 
+// Clone implements the Batch interface.
+func (m *MemBatch) Clone() Batch {
+	b := &MemBatch{n: m.n}
+	b.b = make([]Vec, len(m.b))
+	for i, vec := range m.b {
+		b.b[i] = vec.Clone(m.Selection(), int(m.n))
+	}
+	b.sel = make([]int, m.n)
+	return b
+}
+
 // ResetInternalBatch implements the Batch interface.
 func (m *MemBatch) ResetInternalBatch() {
 	m.SetSelection(false)
@@ -258,8 +331,13 @@ func (m *MemBatch) ResetInternalBatch() {
 		if v.Type() != coltypes.Unhandled {
 			v.Nulls().UnsetNulls()
 		}
-		if v.Type() == coltypes.Bytes {
+		switch v.Type() {
+		case coltypes.Bytes:
 			v.Bytes().Reset()
+		case coltypes.Array:
+			v.Array().Reset()
+		case coltypes.Datum:
+			v.Datum().Reset()
 		}
 	}
 }
@@ -108,3 +108,21 @@ func TestBatchReset(t *testing.T) {
 	b = coldata.NewMemBatch(typsBytes)
 	resetAndCheck(b, typsBytes, 1, true)
 }
+
+func TestNewMemBatchWithCapacity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	for _, tc := range []struct {
+		capacity, expected int
+	}{
+		{capacity: 0, expected: 1},
+		{capacity: 5, expected: 5},
+		{capacity: int(coldata.BatchSize()) + 1, expected: int(coldata.BatchSize())},
+	} {
+		b := coldata.NewMemBatchWithCapacity(typs, tc.capacity)
+		assert.Equal(t, tc.expected, b.(*coldata.MemBatch).Capacity())
+		assert.Equal(t, tc.expected, len(b.ColVec(0).Int64()))
+		assert.Equal(t, tc.expected, b.ColVec(1).Bytes().Len())
+	}
+}
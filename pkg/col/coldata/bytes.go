@@ -18,7 +18,10 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
-// Bytes is a wrapper type for a two-dimensional byte slice ([][]byte).
+// Bytes is a flat representation of a vector of []byte values. All values are
+// stored contiguously in a single buffer, and their boundaries are described by
+// the offsets (the same layout as the one used by Arrow), so that a vector
+// doesn't allocate a separate slice for every value.
 type Bytes struct {
 	// data is the slice of all bytes.
 	data []byte
@@ -36,6 +39,16 @@ type Bytes struct {
 	// isWindow indicates whether this Bytes is a "window" into another Bytes.
 	// If it is, no modifications are allowed (all of them will panic).
 	isWindow bool
+
+	// dict is the dictionary-encoded representation of the values of this
+	// Bytes. It is only valid if hasDict is true, and it is invalidated by any
+	// modification of the values (see BuildDictionary).
+	dict    *BytesDictionary
+	hasDict bool
+
+	// arena, if set, is the arena that data is allocated from (see
+	// NewBytesWithArena).
+	arena *BytesArena
 }
 
 // BytesInitialAllocationFactor is an estimate of how many bytes each []byte
@@ -55,6 +68,32 @@ func NewBytes(n int) *Bytes {
 	}
 }
 
+// NewBytesWithArena is like NewBytes, but the buffer of the returned Bytes (as
+// well as the buffers it grows into) is allocated from arena.
+func NewBytesWithArena(n int, arena *BytesArena) *Bytes {
+	return &Bytes{
+		data:    arena.alloc(n * BytesInitialAllocationFactor),
+		offsets: make([]int32, n+1),
+		arena:   arena,
+	}
+}
+
+// maybeGrowData makes sure that b.data has the capacity for at least n bytes
+// (keeping the current contents of b.data) when b is allocated from an arena.
+// Otherwise, it is a noop since b.data grows on append.
+func (b *Bytes) maybeGrowData(n int) {
+	if b.arena == nil || n <= cap(b.data) {
+		return
+	}
+	newCap := 2 * cap(b.data)
+	if newCap < n {
+		newCap = n
+	}
+	newData := b.arena.alloc(newCap)
+	b.arena.abandon(cap(b.data))
+	b.data = append(newData, b.data...)
+}
+
 // AssertOffsetsAreNonDecreasing asserts that all b.offsets[:n+1] are
 // non-decreasing.
 func (b *Bytes) AssertOffsetsAreNonDecreasing(n uint64) {
@@ -114,6 +153,7 @@ func (b *Bytes) Set(i int, v []byte) {
 	if b.isWindow {
 		panic("Set is called on a window into Bytes")
 	}
+	b.hasDict = false
 	if i < b.maxSetIndex {
 		panic(
 			fmt.Sprintf(
@@ -135,6 +175,7 @@ func (b *Bytes) Set(i int, v []byte) {
 		b.maybeBackfillOffsets(i)
 	}
 	b.offsets[i] = int32(len(b.data))
+	b.maybeGrowData(len(b.data) + len(v))
 	b.data = append(b.data, v...)
 	b.offsets[i+1] = int32(len(b.data))
 	b.maxSetIndex = i
@@ -158,7 +199,7 @@ func (b *Bytes) Window(start, end int) *Bytes {
 	if end == 0 {
 		data = b.data[:0]
 	}
-	return &Bytes{
+	window := &Bytes{
 		data: data,
 		// We use 'end+1' because of the extra offset to know the length of the
 		// last element of the newly created window.
@@ -167,6 +208,16 @@ func (b *Bytes) Window(start, end int) *Bytes {
 		maxSetIndex: (end - start) - 1,
 		isWindow:    true,
 	}
+	if b.hasDict && end <= len(b.dict.codes) {
+		// The window shares the distinct values with the receiver.
+		window.dict = &BytesDictionary{
+			codes:  b.dict.codes[start:end],
+			values: b.dict.values,
+			index:  b.dict.index,
+		}
+		window.hasDict = true
+	}
+	return window
 }
 
 // CopySlice copies srcStartIdx inclusive and srcEndIdx exclusive []byte values
@@ -185,6 +236,7 @@ func (b *Bytes) CopySlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	if b.isWindow {
 		panic("CopySlice is called on a window into Bytes")
 	}
+	b.hasDict = false
 	if destIdx < 0 || destIdx > b.Len() {
 		panic(
 			fmt.Sprintf(
@@ -247,6 +299,7 @@ func (b *Bytes) CopySlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	// b.data has enough capacity. Note that the "capacity" was checked
 	// beforehand, but the number of elements to copy does not correlate with the
 	// number of bytes.
+	b.maybeGrowData(int(destDataIdx) + len(srcDataToCopy) + len(leftoverDestBytes))
 	if destIdx != 0 {
 		b.data = append(b.data[:b.offsets[destIdx]], srcDataToCopy...)
 	} else {
@@ -284,6 +337,7 @@ func (b *Bytes) AppendSlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	if b.isWindow {
 		panic("AppendSlice is called on a window into Bytes")
 	}
+	b.hasDict = false
 	if destIdx < 0 || destIdx > b.Len() {
 		panic(
 			fmt.Sprintf(
@@ -327,6 +381,7 @@ func (b *Bytes) AppendSlice(src *Bytes, destIdx, srcStartIdx, srcEndIdx int) {
 	// destination offsets since we might be appending from the same Bytes (and
 	// might be overwriting information).
 	translateBy := destDataIdx - src.offsets[srcStartIdx]
+	b.maybeGrowData(int(destDataIdx) + len(srcDataToAppend))
 	b.data = append(b.data[:destDataIdx], srcDataToAppend...)
 	b.offsets = append(b.offsets[:destIdx], src.offsets[srcStartIdx:srcEndIdx+1]...)
 	if translateBy == 0 {
@@ -348,9 +403,11 @@ func (b *Bytes) AppendVal(v []byte) {
 	if b.isWindow {
 		panic("AppendVal is called on a window into Bytes")
 	}
+	b.hasDict = false
 	b.maybeBackfillOffsets(b.Len())
 	b.maxSetIndex = b.Len()
 	b.offsets[b.Len()] = int32(len(b.data))
+	b.maybeGrowData(len(b.data) + len(v))
 	b.data = append(b.data, v...)
 	b.offsets = append(b.offsets, int32(len(b.data)))
 }
@@ -361,6 +418,7 @@ func (b *Bytes) SetLength(l int) {
 	if b.isWindow {
 		panic("SetLength is called on a window into Bytes")
 	}
+	b.hasDict = false
 	// We need +1 for an extra offset at the end.
 	b.offsets = b.offsets[:l+1]
 }
@@ -376,9 +434,27 @@ const sizeOfInt32 = unsafe.Sizeof(int32(0))
 
 // Size returns the total size of the receiver in bytes.
 func (b *Bytes) Size() uintptr {
-	return FlatBytesOverhead +
+	size := FlatBytesOverhead +
 		uintptr(cap(b.data)) +
 		uintptr(cap(b.offsets))*sizeOfInt32
+	if b.dict != nil {
+		size += b.dict.size()
+	}
+	return size
+}
+
+// ProportionalSize returns the size in bytes of the first n values of the
+// receiver, i.e. the size of the values themselves and of their offsets. Unlike
+// Size, the unused capacity is not included, so it can be used to estimate the
+// memory footprint of a part of the vector. n must not be greater than the
+// length of the receiver.
+func (b *Bytes) ProportionalSize(n int64) uintptr {
+	if n == 0 {
+		return 0
+	}
+	return FlatBytesOverhead +
+		uintptr(b.offsets[n]-b.offsets[0]) +
+		uintptr(n+1)*sizeOfInt32
 }
 
 var zeroInt32Slice = make([]int32, BatchSize())
@@ -393,6 +469,7 @@ func (b *Bytes) Reset() {
 	if b.isWindow {
 		panic("Reset is called on a window into Bytes")
 	}
+	b.hasDict = false
 	b.data = b.data[:0]
 	for n := 0; n < len(b.offsets); n += copy(b.offsets[n:], zeroInt32Slice) {
 	}
@@ -416,6 +493,7 @@ func BytesFromArrowSerializationFormat(b *Bytes, data []byte, offsets []int32) {
 	b.data = data
 	b.offsets = offsets
 	b.maxSetIndex = len(offsets) - 2
+	b.hasDict = false
 }
 
 // ToArrowSerializationFormat returns a bytes slice and offsets that are
@@ -0,0 +1,96 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+// DefaultBytesArenaChunkSize is the default size of the chunks of BytesArena.
+const DefaultBytesArenaChunkSize = 256 << 10 /* 256 KiB */
+
+// BytesArena is a byte arena that the Bytes vectors can allocate their buffers
+// from (see NewBytesWithArena). The buffers are carved out of large chunks, so
+// an operator that creates many string vectors (for example, while buffering
+// its input) doesn't perform a separate allocation for each of them.
+//
+// The arena never reuses the memory it has handed out: Reset simply drops the
+// references to the chunks, so the memory is freed wholesale once the vectors
+// allocated from the arena are no longer referenced. This makes it safe to
+// keep using such vectors after the arena is reset. Note that BytesArena is
+// not safe for concurrent use.
+type BytesArena struct {
+	chunkSize int
+	// chunk is the current chunk. The buffers are carved out of its unused
+	// capacity.
+	chunk []byte
+	// allocated is the total number of bytes allocated by the arena since the
+	// last reset.
+	allocated int64
+	// wasted is the number of bytes that were handed out to Bytes vectors
+	// which have since outgrown them (see Wasted).
+	wasted int64
+}
+
+// NewBytesArena returns a new BytesArena that allocates chunks of the given
+// size.
+func NewBytesArena(chunkSize int) *BytesArena {
+	return &BytesArena{chunkSize: chunkSize}
+}
+
+// alloc returns a zero-length buffer with the capacity of exactly n bytes.
+func (a *BytesArena) alloc(n int) []byte {
+	if n > a.chunkSize/4 {
+		// Large buffers are allocated separately, so that they don't leave a lot
+		// of unused capacity in the chunks.
+		a.allocated += int64(n)
+		return make([]byte, 0, n)
+	}
+	if cap(a.chunk)-len(a.chunk) < n {
+		a.chunk = make([]byte, 0, a.chunkSize)
+		a.allocated += int64(a.chunkSize)
+	}
+	start := len(a.chunk)
+	a.chunk = a.chunk[:start+n]
+	// The capacity of the buffer is limited, so that appending to it beyond n
+	// bytes doesn't overwrite the buffers carved out after it.
+	return a.chunk[start : start : start+n]
+}
+
+// abandon notifies the arena that a buffer of the given capacity is no longer
+// used by its Bytes.
+func (a *BytesArena) abandon(capacity int) {
+	if capacity > a.chunkSize/4 {
+		// The buffers that were allocated separately are simply freed.
+		return
+	}
+	a.wasted += int64(capacity)
+}
+
+// Allocated returns the total number of bytes allocated by the arena since
+// the last reset (including the unused capacity of the current chunk).
+func (a *BytesArena) Allocated() int64 {
+	return a.allocated
+}
+
+// Wasted returns the number of bytes of the arena that were handed out to the
+// Bytes vectors which have since outgrown them. Unlike with the buffers that
+// are not allocated from an arena, this memory is not freed until the arena is
+// reset, so it should be accounted for separately from the footprint of the
+// vectors.
+func (a *BytesArena) Wasted() int64 {
+	return a.wasted
+}
+
+// Reset releases all the chunks of the arena (as well as the buffers that
+// were allocated separately). The Bytes vectors allocated from the arena
+// remain valid, and they will continue to allocate from it.
+func (a *BytesArena) Reset() {
+	a.chunk = nil
+	a.allocated = 0
+	a.wasted = 0
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesArena(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const chunkSize = 16 * BytesInitialAllocationFactor
+	arena := NewBytesArena(chunkSize)
+
+	// The buffers of both vectors are carved out of the same chunk.
+	b1 := NewBytesWithArena(1, arena)
+	b2 := NewBytesWithArena(1, arena)
+	require.Equal(t, int64(chunkSize), arena.Allocated())
+	require.Equal(t, BytesInitialAllocationFactor, cap(b1.data))
+
+	// Filling up the first vector beyond its buffer doesn't overwrite the
+	// buffer of the second one.
+	b2.Set(0, []byte("b"))
+	v := make([]byte, BytesInitialAllocationFactor+1)
+	b1.Set(0, v)
+	require.Equal(t, v, b1.Get(0))
+	require.Equal(t, []byte("b"), b2.Get(0))
+	// The outgrown buffer is wasted until the arena is reset.
+	require.Equal(t, int64(BytesInitialAllocationFactor), arena.Wasted())
+
+	// Large buffers are allocated separately, so they are not wasted.
+	b3 := NewBytesWithArena(chunkSize/BytesInitialAllocationFactor, arena)
+	allocated := arena.Allocated()
+	require.Equal(t, int64(chunkSize+chunkSize), allocated)
+	b3.AppendVal(make([]byte, 2*chunkSize))
+	require.Equal(t, int64(BytesInitialAllocationFactor), arena.Wasted())
+	require.True(t, arena.Allocated() > allocated)
+
+	// The vectors remain valid after the arena is reset.
+	arena.Reset()
+	require.Equal(t, int64(0), arena.Allocated())
+	require.Equal(t, int64(0), arena.Wasted())
+	require.Equal(t, v, b1.Get(0))
+	b2.AppendVal([]byte("c"))
+	require.Equal(t, []byte("b"), b2.Get(0))
+	require.Equal(t, []byte("c"), b2.Get(1))
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+import "unsafe"
+
+// BytesDictionary is a dictionary-encoded representation of the values of a
+// Bytes: the ith value of the Bytes is Value(Codes()[i]). It is meant for the
+// columns with a small number of distinct values (like enum-like string
+// columns), so that the operations on the values can be performed once per
+// distinct value or on the integer codes instead.
+type BytesDictionary struct {
+	// codes contains the code of every value of the Bytes.
+	codes []int32
+	// values contains the distinct values in the order of their codes. The
+	// slices point into the data of the Bytes.
+	values [][]byte
+	// index maps every distinct value to its code.
+	index map[string]int32
+}
+
+// Codes returns the codes of the values of the Bytes. The codes of two values
+// are equal if and only if the values are equal.
+func (d *BytesDictionary) Codes() []int32 {
+	return d.codes
+}
+
+// NumValues returns the number of distinct values in the dictionary.
+func (d *BytesDictionary) NumValues() int {
+	return len(d.values)
+}
+
+// Value returns the value with the given code.
+func (d *BytesDictionary) Value(code int32) []byte {
+	return d.values[code]
+}
+
+// Lookup returns the code of v if v is present in the dictionary.
+func (d *BytesDictionary) Lookup(v []byte) (int32, bool) {
+	code, ok := d.index[string(v)]
+	return code, ok
+}
+
+const sizeOfByteSlice = unsafe.Sizeof([]byte(nil))
+
+// size returns the approximate size of the dictionary in bytes. The values
+// themselves are not included since they point into the data of the Bytes.
+func (d *BytesDictionary) size() uintptr {
+	return uintptr(cap(d.codes))*sizeOfInt32 +
+		uintptr(cap(d.values))*sizeOfByteSlice +
+		uintptr(len(d.index))*(unsafe.Sizeof("")+unsafe.Sizeof(int32(0)))
+}
+
+// Dictionary returns the dictionary-encoded representation of the receiver
+// built by the last call to BuildDictionary, or nil if there is none or if the
+// receiver has been modified since then. Only the values at indices less than
+// len(Codes()) are encoded.
+func (b *Bytes) Dictionary() *BytesDictionary {
+	if !b.hasDict {
+		return nil
+	}
+	return b.dict
+}
+
+// BuildDictionary builds the dictionary-encoded representation of the first n
+// values of the receiver if there are at most maxSize distinct values among
+// them, and returns whether it did. The memory of the previous dictionary is
+// reused. Note that the values at the indices that were never set are encoded
+// too, so UpdateOffsetsToBeNonDecreasing must have been called beforehand
+// (which is done by Batch.SetLength).
+func (b *Bytes) BuildDictionary(n int, maxSize int) bool {
+	b.hasDict = false
+	if b.dict == nil || b.isWindow {
+		// The dictionary of a window shares the index with the dictionary of
+		// the original Bytes, so it can't be reused.
+		b.dict = &BytesDictionary{index: make(map[string]int32)}
+	}
+	d := b.dict
+	for v := range d.index {
+		delete(d.index, v)
+	}
+	d.codes = d.codes[:0]
+	d.values = d.values[:0]
+	for i := 0; i < n; i++ {
+		v := b.Get(i)
+		code, ok := d.index[string(v)]
+		if !ok {
+			if len(d.values) == maxSize {
+				return false
+			}
+			code = int32(len(d.values))
+			d.index[string(v)] = code
+			d.values = append(d.values, v)
+		}
+		d.codes = append(d.codes, code)
+	}
+	b.hasDict = true
+	return true
+}
@@ -198,7 +198,16 @@ func TestBytesRefImpl(t *testing.T) {
 	for nRun := 0; nRun < nRuns; nRun++ {
 		n := 1 + rng.Intn(maxLength)
 
-		flat := NewBytes(n)
+		newBytes := NewBytes
+		if rng.Float64() < 0.5 {
+			// Use a small arena, so that both the buffers carved out of its
+			// chunks and the separately allocated ones are exercised.
+			arena := NewBytesArena(16 * BytesInitialAllocationFactor)
+			newBytes = func(n int) *Bytes {
+				return NewBytesWithArena(n, arena)
+			}
+		}
+		flat := newBytes(n)
 		reference := make([][]byte, n)
 		for i := 0; i < n; i++ {
 			v := make([]byte, rng.Intn(16))
@@ -216,7 +225,7 @@ func TestBytesRefImpl(t *testing.T) {
 		if rng.Float64() < 0.5 {
 			selfReferencingSources = false
 			sourceN = 1 + rng.Intn(maxLength)
-			flatSource = NewBytes(sourceN)
+			flatSource = newBytes(sourceN)
 			referenceSource = make([][]byte, sourceN)
 			for i := 0; i < sourceN; i++ {
 				v := make([]byte, rng.Intn(16))
@@ -399,6 +408,21 @@ func TestBytes(t *testing.T) {
 		)
 	})
 
+	t.Run("ProportionalSize", func(t *testing.T) {
+		b1 := NewBytes(0)
+		b1.AppendVal([]byte("one"))
+		b1.AppendVal([]byte("two"))
+		b1.AppendVal([]byte("three"))
+
+		require.Equal(t, uintptr(0), b1.ProportionalSize(0))
+		require.Equal(t, FlatBytesOverhead+3+2*sizeOfInt32, b1.ProportionalSize(1))
+		require.Equal(t, FlatBytesOverhead+11+4*sizeOfInt32, b1.ProportionalSize(3))
+		// Only the values in the window are taken into account.
+		require.Equal(t, FlatBytesOverhead+8+3*sizeOfInt32, b1.Window(1, 3).ProportionalSize(2))
+		// The unused capacity is not included.
+		require.True(t, b1.ProportionalSize(3) <= b1.Size())
+	})
+
 	t.Run("InvariantSimple", func(t *testing.T) {
 		b1 := NewBytes(8)
 		b1.Set(0, []byte("zero"))
@@ -412,3 +436,62 @@ func TestBytes(t *testing.T) {
 		other.AssertOffsetsAreNonDecreasing(4)
 	})
 }
+
+func TestBytesDictionary(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	const n = 100
+	values := []string{"", "a", "bb", "ccc"}
+	b := NewBytes(n)
+	for i := 0; i < n; i++ {
+		b.Set(i, []byte(values[rng.Intn(len(values))]))
+	}
+	require.Nil(t, b.Dictionary())
+
+	// The values don't fit into a dictionary of a smaller size.
+	require.False(t, b.BuildDictionary(n, 1))
+	require.Nil(t, b.Dictionary())
+
+	checkDictionary := func(t *testing.T, b *Bytes, n int) {
+		d := b.Dictionary()
+		require.NotNil(t, d)
+		require.Equal(t, n, len(d.Codes()))
+		require.True(t, d.NumValues() <= len(values))
+		for i, code := range d.Codes() {
+			require.Equal(t, b.Get(i), d.Value(code))
+			found, ok := d.Lookup(b.Get(i))
+			require.True(t, ok)
+			require.Equal(t, code, found)
+		}
+		_, ok := d.Lookup([]byte("d"))
+		require.False(t, ok)
+	}
+	require.True(t, b.BuildDictionary(n, len(values)))
+	checkDictionary(t, b, n)
+
+	t.Run("Window", func(t *testing.T) {
+		w := b.Window(10, 20)
+		checkDictionary(t, w, 10)
+		// Building a dictionary of the window doesn't affect the receiver.
+		require.True(t, w.BuildDictionary(5, len(values)))
+		checkDictionary(t, w, 5)
+		checkDictionary(t, b, n)
+	})
+
+	t.Run("Invalidation", func(t *testing.T) {
+		for _, modify := range []func(b *Bytes){
+			func(b *Bytes) { b.Set(n-1, []byte("d")) },
+			func(b *Bytes) { b.AppendVal([]byte("d")) },
+			func(b *Bytes) { b.CopySlice(NewBytes(0), 0, 0, 0) },
+			func(b *Bytes) { b.AppendSlice(NewBytes(0), n, 0, 0) },
+			func(b *Bytes) { b.SetLength(n) },
+			func(b *Bytes) { b.Reset() },
+		} {
+			// One more value is allowed since "d" might have been added.
+			require.True(t, b.BuildDictionary(n, len(values)+1))
+			modify(b)
+			require.Nil(t, b.Dictionary())
+		}
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Datums is the representation of a column of values that don't have a native
+// columnar representation (see coltypes.Datum). The values are tree.Datums,
+// but since coldata cannot depend on the SQL packages, they are stored as
+// interface{} and are opaque to this package. Unlike Bytes and Arrays, the
+// values can be set in any order. NULL values are tracked by the Nulls of the
+// vector as usual, and the corresponding elements of Datums are undefined.
+type Datums struct {
+	data []interface{}
+}
+
+// NewDatums returns a Datums struct with n undefined values.
+func NewDatums(n int) *Datums {
+	return &Datums{data: make([]interface{}, n)}
+}
+
+// Get returns the ith value.
+func (d *Datums) Get(i int) interface{} {
+	return d.data[i]
+}
+
+// Set sets the ith value to v.
+func (d *Datums) Set(i int, v interface{}) {
+	d.data[i] = v
+}
+
+// Window creates a "window" into the receiver. It behaves similarly to
+// Golang's slice, and, just like with the windows into the other vectors, the
+// returned object is not supposed to be modified.
+func (d *Datums) Window(start, end int) *Datums {
+	if start < 0 || start > end || end > d.Len() {
+		panic(
+			fmt.Sprintf(
+				"invalid window arguments: start=%d end=%d when Datums.Len()=%d",
+				start, end, d.Len(),
+			),
+		)
+	}
+	return &Datums{data: d.data[start:end]}
+}
+
+// AppendSlice appends srcStartIdx inclusive and srcEndIdx exclusive values
+// from src into the receiver starting at destIdx, truncating the receiver.
+func (d *Datums) AppendSlice(src *Datums, destIdx, srcStartIdx, srcEndIdx int) {
+	d.data = append(d.data[:destIdx], src.data[srcStartIdx:srcEndIdx]...)
+}
+
+// AppendVal appends v to the receiver.
+func (d *Datums) AppendVal(v interface{}) {
+	d.data = append(d.data, v)
+}
+
+// CopySlice copies srcStartIdx inclusive and srcEndIdx exclusive values from
+// src into the receiver starting at destIdx. Similar to the copy builtin,
+// min(dest.Len(), src.Len()) values will be copied.
+func (d *Datums) CopySlice(src *Datums, destIdx, srcStartIdx, srcEndIdx int) {
+	copy(d.data[destIdx:], src.data[srcStartIdx:srcEndIdx])
+}
+
+// SetLength sets the length of this Datums. Note that it will panic if there
+// is not enough capacity.
+func (d *Datums) SetLength(l int) {
+	d.data = d.data[:l]
+}
+
+// Len returns how many values the receiver contains.
+func (d *Datums) Len() int {
+	return len(d.data)
+}
+
+// Cap returns how many values the receiver can contain without reallocating.
+func (d *Datums) Cap() int {
+	return cap(d.data)
+}
+
+// DatumsOverhead is the overhead of Datums in bytes.
+const DatumsOverhead = unsafe.Sizeof(Datums{})
+
+// SizeOfDatumsElement is the size of a single element of Datums in bytes not
+// including the size of the value it references.
+const SizeOfDatumsElement = unsafe.Sizeof(interface{}(nil))
+
+// Reset resets the receiver for reuse. The references to the values are
+// dropped (so that they can be garbage collected), but the length is not
+// changed.
+func (d *Datums) Reset() {
+	for i := range d.data {
+		d.data[i] = nil
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package coldata
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatums(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	d := NewDatums(3)
+	require.Equal(t, 3, d.Len())
+	// The values can be set in any order.
+	d.Set(2, "c")
+	d.Set(0, "a")
+	d.Set(1, "b")
+	require.Equal(t, "b", d.Get(1))
+
+	w := d.Window(1, 3)
+	require.Equal(t, 2, w.Len())
+	require.Equal(t, "b", w.Get(0))
+	require.Equal(t, "c", w.Get(1))
+	require.Panics(t, func() { d.Window(2, 4) })
+
+	other := NewDatums(0)
+	other.AppendVal("x")
+	other.AppendSlice(d, 1 /* destIdx */, 0 /* srcStartIdx */, 2 /* srcEndIdx */)
+	require.Equal(t, 3, other.Len())
+	require.Equal(t, []interface{}{"x", "a", "b"}, other.data)
+
+	// CopySlice doesn't change the length of the receiver.
+	other.CopySlice(d, 2 /* destIdx */, 1 /* srcStartIdx */, 3 /* srcEndIdx */)
+	require.Equal(t, []interface{}{"x", "a", "b"}, other.data)
+	other.CopySlice(d, 1 /* destIdx */, 1 /* srcStartIdx */, 3 /* srcEndIdx */)
+	require.Equal(t, []interface{}{"x", "b", "c"}, other.data)
+
+	other.Reset()
+	require.Equal(t, 3, other.Len())
+	require.Nil(t, other.Get(0))
+}
+
+func TestDatumsVec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	src := NewMemColumn(coltypes.Datum, 4)
+	for i, v := range []interface{}{"a", "b", nil, "d"} {
+		if v == nil {
+			src.Nulls().SetNull(uint16(i))
+		} else {
+			src.Datum().Set(i, v)
+		}
+	}
+
+	t.Run("AppendWithSel", func(t *testing.T) {
+		dest := NewMemColumn(coltypes.Datum, 0)
+		dest.Append(SliceArgs{
+			ColType:   coltypes.Datum,
+			Src:       src,
+			Sel:       []int{3, 2, 0},
+			SrcEndIdx: 3,
+		})
+		require.Equal(t, 3, dest.Length())
+		require.Equal(t, "d", dest.PrettyValueAt(0, coltypes.Datum))
+		require.True(t, dest.Nulls().NullAt(1))
+		require.Equal(t, "a", dest.PrettyValueAt(2, coltypes.Datum))
+	})
+
+	t.Run("CopyWithSel", func(t *testing.T) {
+		dest := NewMemColumn(coltypes.Datum, 3)
+		dest.Copy(CopySliceArgs{
+			SliceArgs: SliceArgs{
+				ColType:   coltypes.Datum,
+				Src:       src,
+				Sel:       []int{1, 2, 3},
+				SrcEndIdx: 3,
+			},
+		})
+		require.Equal(t, "b", dest.PrettyValueAt(0, coltypes.Datum))
+		require.True(t, dest.Nulls().NullAt(1))
+		require.Equal(t, "d", dest.PrettyValueAt(2, coltypes.Datum))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		w := src.Window(1, 3)
+		require.Equal(t, coltypes.Datum, w.Type())
+		require.Equal(t, 2, w.Length())
+		require.Equal(t, "b", w.PrettyValueAt(0, coltypes.Datum))
+		require.True(t, w.Nulls().NullAt(1))
+	})
+}
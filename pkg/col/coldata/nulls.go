@@ -240,29 +240,127 @@ func (n *Nulls) set(args SliceArgs) {
 	needed := (outputLen-1)/8 + 1
 	current := uint64(len(n.nulls))
 	if current < needed {
+		if uint64(cap(n.nulls)) < needed {
+			// Grow the bitmap by at least doubling its capacity, similar to how
+			// the column itself is grown by Vec.Append.
+			capToAllocate := needed
+			if c := 2 * uint64(cap(n.nulls)); capToAllocate < c {
+				capToAllocate = c
+			}
+			newNulls := make([]byte, current, capToAllocate)
+			copy(newNulls, n.nulls)
+			n.nulls = newNulls
+		}
 		n.nulls = append(n.nulls, filledNulls[:needed-current]...)
 	}
+	src := args.Src.Nulls()
+	if args.Sel == nil {
+		n.CopyRange(src, args.DestIdx, args.SrcStartIdx, args.SrcEndIdx)
+		return
+	}
 	// First, we unset the whole range that is overwritten. If there are any NULL
 	// values in the source, those will be copied over below, one at a time.
 	n.UnsetNullRange(args.DestIdx, args.DestIdx+toDuplicate)
-	if args.Src.MaybeHasNulls() {
-		src := args.Src.Nulls()
-		if args.Sel != nil {
-			for i := uint64(0); i < toDuplicate; i++ {
-				if src.NullAt(args.Sel[args.SrcStartIdx+i]) {
-					n.SetNull64(args.DestIdx + i)
-				}
+	if src.MaybeHasNulls() {
+		for i := uint64(0); i < toDuplicate; i++ {
+			if src.NullAt64(uint64(args.Sel[args.SrcStartIdx+i])) {
+				n.SetNull64(args.DestIdx + i)
 			}
+		}
+	}
+}
+
+// Union sets all values that are null in other to null in n as well (the
+// values past the end of other are left unchanged). Unlike Or, it modifies n in
+// place and operates on the whole bytes of the bitmaps.
+func (n *Nulls) Union(other *Nulls) {
+	if !other.maybeHasNulls {
+		return
+	}
+	n.maybeHasNulls = true
+	l := len(n.nulls)
+	if len(other.nulls) < l {
+		l = len(other.nulls)
+	}
+	for i := 0; i < l; i++ {
+		n.nulls[i] &= other.nulls[i]
+	}
+}
+
+// Intersect unsets all values in n that are not null in other (the values
+// past the end of other are treated as not null). It operates on the whole
+// bytes of the bitmaps.
+func (n *Nulls) Intersect(other *Nulls) {
+	if !n.maybeHasNulls {
+		return
+	}
+	if !other.maybeHasNulls {
+		n.UnsetNulls()
+		return
+	}
+	l := len(n.nulls)
+	if len(other.nulls) < l {
+		l = len(other.nulls)
+	}
+	for i := 0; i < l; i++ {
+		n.nulls[i] |= other.nulls[i]
+	}
+	for i := l; i < len(n.nulls); {
+		i += copy(n.nulls[i:], filledNulls[:])
+	}
+}
+
+// CopyRange copies the values in [srcStartIdx, srcEndIdx) of src into n
+// starting at destIdx, overwriting the old values. n must already be long
+// enough to hold them. Unless the destination range starts or ends in the
+// middle of a byte, the values are copied a whole byte at a time (shifted if
+// the source range is not aligned the same way). src can be the same as n only
+// if srcStartIdx is not less than destIdx, which allows for shifting the values
+// of n towards the beginning. The destination is marked as having nulls only
+// if a null value is actually copied, so copying a null-free range out of a
+// vector that might have nulls keeps the fast paths for n available.
+func (n *Nulls) CopyRange(src *Nulls, destIdx, srcStartIdx, srcEndIdx uint64) {
+	if srcStartIdx >= srcEndIdx {
+		return
+	}
+	length := srcEndIdx - srcStartIdx
+	if !src.maybeHasNulls {
+		n.UnsetNullRange(destIdx, destIdx+length)
+		return
+	}
+	copyOne := func(i uint64) {
+		if src.NullAt64(srcStartIdx + i) {
+			n.SetNull64(destIdx + i)
 		} else {
-			for i := uint64(0); i < toDuplicate; i++ {
-				// TODO(yuzefovich): this can be done more efficiently with a bitwise OR:
-				// like n.nulls[i] |= vec.nulls[i].
-				if src.NullAt64(args.SrcStartIdx + i) {
-					n.SetNull64(args.DestIdx + i)
-				}
-			}
+			n.UnsetNull64(destIdx + i)
+		}
+	}
+	i := uint64(0)
+	// Copy the values one at a time until the destination is byte-aligned.
+	for ; i < length && (destIdx+i)%8 != 0; i++ {
+		copyOne(i)
+	}
+	for ; i+8 <= length; i += 8 {
+		b := src.shiftedByte(srcStartIdx + i)
+		if b != onesMask {
+			n.maybeHasNulls = true
 		}
+		n.nulls[(destIdx+i)/8] = b
+	}
+	for ; i < length; i++ {
+		copyOne(i)
+	}
+}
+
+// shiftedByte returns the byte of the bitmap that starts at the ith value
+// (which doesn't have to be byte-aligned). All 8 values must be within the
+// bitmap.
+func (n *Nulls) shiftedByte(i uint64) byte {
+	idx, mod := i/8, i%8
+	if mod == 0 {
+		return n.nulls[idx]
 	}
+	return n.nulls[idx]>>mod | n.nulls[idx+1]<<(8-mod)
 }
 
 // Slice returns a new Nulls representing a slice of the current Nulls from
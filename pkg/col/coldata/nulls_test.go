@@ -230,11 +230,11 @@ func TestNullsSet(t *testing.T) {
 		t.Run(fmt.Sprintf("WithSel=%t", withSel), func(t *testing.T) {
 			var srcNulls *Nulls
 			if withSel {
-				args.Sel = make([]uint16, BatchSize())
+				args.Sel = make([]int, BatchSize())
 				// Make a selection vector with every even index. (This turns nulls10 into
 				// nulls5.)
 				for i := range args.Sel {
-					args.Sel[i] = uint16(i) * 2
+					args.Sel[i] = i * 2
 				}
 				srcNulls = &nulls10
 			} else {
@@ -308,3 +308,67 @@ func TestNullsOr(t *testing.T) {
 		}
 	}
 }
+
+func TestNullsUnion(t *testing.T) {
+	n := nulls3.Copy()
+	other := nulls5.Slice(0, 300)
+	n.Union(&other)
+	require.True(t, n.MaybeHasNulls())
+	for i := uint64(0); i < uint64(BatchSize()); i++ {
+		expected := nulls3.NullAt64(i) || i < 300 && nulls5.NullAt64(i)
+		require.Equal(t, expected, n.NullAt64(i), "NullAt(%d) should be %t after Union", i, expected)
+	}
+	// Union with a bitmap without nulls doesn't change anything.
+	noNulls := NewNulls(int(BatchSize()))
+	n = nulls3.Copy()
+	n.Union(&noNulls)
+	for i := uint64(0); i < uint64(BatchSize()); i++ {
+		require.Equal(t, nulls3.NullAt64(i), n.NullAt64(i))
+	}
+}
+
+func TestNullsIntersect(t *testing.T) {
+	n := nulls3.Copy()
+	other := nulls5.Slice(0, 300)
+	n.Intersect(&other)
+	for i := uint64(0); i < uint64(BatchSize()); i++ {
+		expected := nulls3.NullAt64(i) && i < 300 && nulls5.NullAt64(i)
+		require.Equal(t, expected, n.NullAt64(i), "NullAt(%d) should be %t after Intersect", i, expected)
+	}
+	// Intersect with a bitmap without nulls unsets all nulls.
+	noNulls := NewNulls(int(BatchSize()))
+	n = nulls3.Copy()
+	n.Intersect(&noNulls)
+	require.False(t, n.MaybeHasNulls())
+}
+
+func TestNullsCopyRange(t *testing.T) {
+	for _, destStartIdx := range pos {
+		for _, srcStartIdx := range pos {
+			for _, srcEndIdx := range pos {
+				if srcStartIdx > srcEndIdx || destStartIdx+srcEndIdx-srcStartIdx > uint64(BatchSize()) {
+					continue
+				}
+				length := srcEndIdx - srcStartIdx
+				n := nulls3.Copy()
+				n.CopyRange(&nulls5, destStartIdx, srcStartIdx, srcEndIdx)
+				for i := uint64(0); i < uint64(BatchSize()); i++ {
+					expected := nulls3.NullAt64(i)
+					if i >= destStartIdx && i < destStartIdx+length {
+						expected = nulls5.NullAt64(srcStartIdx + i - destStartIdx)
+					}
+					require.Equal(t, expected, n.NullAt64(i),
+						"NullAt(%d) should be %t after CopyRange(%d, %d, %d)", i, expected, destStartIdx, srcStartIdx, srcEndIdx)
+				}
+			}
+		}
+	}
+	// Shift the values within the same bitmap.
+	for _, shift := range []uint64{1, 7, 8, 13} {
+		n := nulls3.Copy()
+		n.CopyRange(&n, 0, shift, uint64(BatchSize()))
+		for i := uint64(0); i < uint64(BatchSize())-shift; i++ {
+			require.Equal(t, nulls3.NullAt64(i+shift), n.NullAt64(i), "shift=%d, i=%d", shift, i)
+		}
+	}
+}
@@ -63,6 +63,14 @@ func (u unknown) Interval() []duration.Duration {
 	panic("Vec is of unknown type and should not be accessed")
 }
 
+func (u unknown) Array() *Arrays {
+	panic("Vec is of unknown type and should not be accessed")
+}
+
+func (u unknown) Datum() *Datums {
+	panic("Vec is of unknown type and should not be accessed")
+}
+
 func (u unknown) Col() interface{} {
 	panic("Vec is of unknown type and should not be accessed")
 }
@@ -83,10 +91,16 @@ func (u unknown) Copy(CopySliceArgs) {
 	panic("Vec is of unknown type and should not be accessed")
 }
 
-func (u unknown) Window(colType coltypes.T, start uint64, end uint64) Vec {
+func (u unknown) Window(start uint64, end uint64) Vec {
 	panic("Vec is of unknown type and should not be accessed")
 }
 
+// Clone returns the receiver since there is nothing to copy, so the batches
+// with the placeholder Vecs can be cloned.
+func (u unknown) Clone([]int, int) Vec {
+	return u
+}
+
 func (u unknown) PrettyValueAt(idx uint16, colType coltypes.T) string {
 	panic("Vec is of unknown type and should not be accessed")
 }
@@ -30,7 +30,7 @@ type SliceArgs struct {
 	Src Vec
 	// Sel is an optional slice specifying indices to append to the destination
 	// slice. Note that Src{Start,End}Idx apply to Sel.
-	Sel []uint16
+	Sel []int
 	// DestIdx is the first index that Append will append to.
 	DestIdx uint64
 	// SrcStartIdx is the index of the first element in Src that Append will
@@ -46,9 +46,6 @@ type SliceArgs struct {
 // Vec.Copy.
 type CopySliceArgs struct {
 	SliceArgs
-	// Sel64 overrides Sel. Used when the amount of data being copied exceeds the
-	// representation capabilities of a []uint16.
-	Sel64 []uint64
 	// SelOnDest, if true, uses the selection vector as a lens into the
 	// destination as well as the source. Normally, when SelOnDest is false, the
 	// selection vector is applied to the source vector, but the results are
@@ -75,13 +72,17 @@ type Vec interface {
 	Float64() []float64
 	// Bytes returns a flat Bytes representation.
 	Bytes() *Bytes
-	// TODO(jordan): should this be [][]byte?
 	// Decimal returns an apd.Decimal slice.
 	Decimal() []apd.Decimal
 	// Timestamp returns a time.Time slice.
 	Timestamp() []time.Time
 	// Interval returns a duration.Duration slice.
 	Interval() []duration.Duration
+	// Array returns an Arrays representation of a column of arrays.
+	Array() *Arrays
+	// Datum returns a Datums representation of a column of values that don't
+	// have a native columnar representation.
+	Datum() *Datums
 
 	// Col returns the raw, typeless backing storage for this Vec.
 	Col() interface{}
@@ -110,10 +111,16 @@ type Vec interface {
 	Copy(CopySliceArgs)
 
 	// Window returns a "window" into the Vec. A "window" is similar to Golang's
-	// slice of the current Vec from [start, end), but the returned object is NOT
-	// allowed to be modified (the modification might result in an undefined
+	// slice of the current Vec from [start, end): it shares the underlying
+	// storage with the Vec, so no values are copied, but the returned object is
+	// NOT allowed to be modified (the modification might result in an undefined
 	// behavior).
-	Window(colType coltypes.T, start uint64, end uint64) Vec
+	Window(start uint64, end uint64) Vec
+
+	// Clone returns a deep copy of the first n values of the Vec (selected by
+	// sel if it is non-nil). Unlike with Window, the returned Vec doesn't share
+	// any memory with the receiver, so both can be modified independently.
+	Clone(sel []int, n int) Vec
 
 	// PrettyValueAt returns a "pretty"value for the idx'th value in this Vec.
 	// It uses the reflect package and is not suitable for calling in hot paths.
@@ -176,6 +183,10 @@ func NewMemColumn(t coltypes.T, n int) Vec {
 		return &memColumn{t: t, col: make([]time.Time, n), nulls: nulls}
 	case coltypes.Interval:
 		return &memColumn{t: t, col: make([]duration.Duration, n), nulls: nulls}
+	case coltypes.Array:
+		return &memColumn{t: t, col: NewArrays(n), nulls: nulls}
+	case coltypes.Datum:
+		return &memColumn{t: t, col: NewDatums(n), nulls: nulls}
 	case coltypes.Unhandled:
 		return unknown{}
 	default:
@@ -183,6 +194,15 @@ func NewMemColumn(t coltypes.T, n int) Vec {
 	}
 }
 
+// NewMemColumnWithArena is like NewMemColumn, but a vector of Bytes type
+// allocates its buffers from arena (see BytesArena). arena can be nil.
+func NewMemColumnWithArena(t coltypes.T, n int, arena *BytesArena) Vec {
+	if t == coltypes.Bytes && arena != nil {
+		return &memColumn{t: t, col: NewBytesWithArena(n, arena), nulls: NewNulls(n)}
+	}
+	return NewMemColumn(t, n)
+}
+
 func (m *memColumn) Type() coltypes.T {
 	return m.t
 }
@@ -227,6 +247,14 @@ func (m *memColumn) Interval() []duration.Duration {
 	return m.col.([]duration.Duration)
 }
 
+func (m *memColumn) Array() *Arrays {
+	return m.col.(*Arrays)
+}
+
+func (m *memColumn) Datum() *Datums {
+	return m.col.(*Datums)
+}
+
 func (m *memColumn) Col() interface{} {
 	return m.col
 }
@@ -267,6 +295,10 @@ func (m *memColumn) Length() int {
 		return len(m.col.([]time.Time))
 	case coltypes.Interval:
 		return len(m.col.([]duration.Duration))
+	case coltypes.Array:
+		return m.Array().Len()
+	case coltypes.Datum:
+		return m.Datum().Len()
 	default:
 		panic(fmt.Sprintf("unhandled type %s", m.t))
 	}
@@ -292,6 +324,10 @@ func (m *memColumn) SetLength(l int) {
 		m.col = m.col.([]time.Time)[:l]
 	case coltypes.Interval:
 		m.col = m.col.([]duration.Duration)[:l]
+	case coltypes.Array:
+		m.Array().SetLength(l)
+	case coltypes.Datum:
+		m.Datum().SetLength(l)
 	default:
 		panic(fmt.Sprintf("unhandled type %s", m.t))
 	}
@@ -317,7 +353,26 @@ func (m *memColumn) Capacity() int {
 		return cap(m.col.([]time.Time))
 	case coltypes.Interval:
 		return cap(m.col.([]duration.Duration))
+	case coltypes.Array:
+		panic("Capacity should not be called on Vec of Array type")
+	case coltypes.Datum:
+		return m.Datum().Cap()
 	default:
 		panic(fmt.Sprintf("unhandled type %s", m.t))
 	}
 }
+
+func (m *memColumn) Clone(sel []int, n int) Vec {
+	clone := NewMemColumn(m.t, n)
+	clone.Copy(
+		CopySliceArgs{
+			SliceArgs: SliceArgs{
+				ColType:   m.t,
+				Src:       m,
+				Sel:       sel,
+				SrcEndIdx: uint64(n),
+			},
+		},
+	)
+	return clone
+}
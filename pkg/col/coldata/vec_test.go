@@ -43,7 +43,7 @@ func TestMemColumnWindow(t *testing.T) {
 		endWindow = uint16(1 + rng.Intn(int(BatchSize())))
 	}
 
-	window := c.Window(coltypes.Int64, uint64(startWindow), uint64(endWindow))
+	window := c.Window(uint64(startWindow), uint64(endWindow))
 	windowInts := window.Int64()
 	// Verify that every other value is null.
 	for i, j := startWindow, uint16(0); i < endWindow; i, j = i+1, j+1 {
@@ -57,6 +57,10 @@ func TestMemColumnWindow(t *testing.T) {
 			t.Fatalf("unexected value at index %d (original index: %d): expected %d got %d", j, i, ints[i], windowInts[j])
 		}
 	}
+	require.Equal(t, coltypes.Int64, window.Type())
+	// The window shares the underlying storage with the original column.
+	ints[startWindow]++
+	require.Equal(t, ints[startWindow], windowInts[0])
 }
 
 func TestNullRanges(t *testing.T) {
@@ -137,9 +141,9 @@ func TestAppend(t *testing.T) {
 	const typ = coltypes.Int64
 
 	src := NewMemColumn(typ, int(BatchSize()))
-	sel := make([]uint16, len(src.Int64()))
+	sel := make([]int, len(src.Int64()))
 	for i := range sel {
-		sel[i] = uint16(i)
+		sel[i] = i
 	}
 
 	testCases := []struct {
@@ -216,11 +220,48 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+// TestAppendAmortizedGrowth makes sure that appending to the same Vec
+// repeatedly grows its capacity geometrically rather than reallocating the
+// column on every Append.
+func TestAppendAmortizedGrowth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAppends = 1000
+	for _, typ := range []coltypes.T{coltypes.Int64, coltypes.Decimal} {
+		t.Run(typ.String(), func(t *testing.T) {
+			src := NewMemColumn(typ, 3)
+			src.Nulls().SetNull(1)
+			dest := NewMemColumn(typ, 0)
+			numReallocations := 0
+			for i := 0; i < numAppends; i++ {
+				prevCap := dest.Capacity()
+				dest.Append(SliceArgs{
+					ColType:   typ,
+					Src:       src,
+					DestIdx:   uint64(dest.Length()),
+					SrcEndIdx: uint64(src.Length()),
+				})
+				if dest.Capacity() != prevCap {
+					// The capacity is at least doubled on every reallocation.
+					require.True(t, dest.Capacity() >= 2*prevCap)
+					numReallocations++
+				}
+			}
+			require.Equal(t, numAppends*src.Length(), dest.Length())
+			require.True(t, dest.Capacity() < 2*dest.Length())
+			require.True(t, numReallocations <= 12, "%d reallocations", numReallocations)
+			for i := 0; i < dest.Length(); i++ {
+				require.Equal(t, i%3 == 1, dest.Nulls().NullAt64(uint64(i)))
+			}
+		})
+	}
+}
+
 // TestAppendBytesWithLastNull makes sure that Append handles correctly the
 // case when the last element of Bytes vector is NULL.
 func TestAppendBytesWithLastNull(t *testing.T) {
 	src := NewMemColumn(coltypes.Bytes, 4)
-	sel := []uint16{0, 2, 3}
+	sel := []int{0, 2, 3}
 	src.Bytes().Set(0, []byte("zero"))
 	src.Nulls().SetNull(1)
 	src.Bytes().Set(2, []byte("two"))
@@ -241,10 +282,10 @@ func TestAppendBytesWithLastNull(t *testing.T) {
 			if withSel {
 				sliceArgs.Sel = sel
 				for expIdx, srcIdx := range sel {
-					if src.Nulls().NullAt(srcIdx) {
+					if src.Nulls().NullAt(uint16(srcIdx)) {
 						expected.Nulls().SetNull(uint16(expIdx))
 					} else {
-						expected.Bytes().Set(expIdx, src.Bytes().Get(int(srcIdx)))
+						expected.Bytes().Set(expIdx, src.Bytes().Get(srcIdx))
 					}
 				}
 			} else {
@@ -278,13 +319,9 @@ func TestCopy(t *testing.T) {
 	for i := range srcInts {
 		srcInts[i] = int64(i + 1)
 	}
-	sel := make([]uint16, len(src.Int64()))
+	sel := make([]int, len(src.Int64()))
 	for i := range sel {
-		sel[i] = uint16(i)
-	}
-	sel64 := make([]uint64, len(src.Int64()))
-	for i := range sel64 {
-		sel64[i] = uint64(i)
+		sel[i] = i
 	}
 
 	sum := func(ints []int64) int {
@@ -321,15 +358,13 @@ func TestCopy(t *testing.T) {
 			name: "CopyWithSel",
 			args: CopySliceArgs{
 				SliceArgs: SliceArgs{
-					// Set sel, but this should be ignored in favor of Sel64.
-					Sel:         sel,
+					// Since sel refers to the same indices as the source, slice it to
+					// be able to tell that it was used.
+					Sel:         sel[1:],
 					DestIdx:     25,
 					SrcStartIdx: 1,
 					SrcEndIdx:   2,
 				},
-				// Since sel64 and sel refer to the same indices, slice sel64 to be able
-				// to tell which sel was used.
-				Sel64: sel64[1:],
 			},
 			// We'll have just the third element in the resulting slice.
 			expectedSum: 3,
@@ -444,7 +479,7 @@ func TestCopySelOnDestDoesNotUnsetOldNulls(t *testing.T) {
 			Src:         src,
 			SrcStartIdx: 1,
 			SrcEndIdx:   3,
-			Sel:         []uint16{0, 1, 3},
+			Sel:         []int{0, 1, 3},
 		},
 	}
 
@@ -464,10 +499,7 @@ func TestCopySelOnDestDoesNotUnsetOldNulls(t *testing.T) {
 
 func BenchmarkAppend(b *testing.B) {
 	rng, _ := randutil.NewPseudoRand()
-	sel := make([]uint16, BatchSize())
-	for i, selIdx := range rng.Perm(len(sel)) {
-		sel[i] = uint16(selIdx)
-	}
+	sel := rng.Perm(int(BatchSize()))
 
 	benchCases := []struct {
 		name string
@@ -509,10 +541,7 @@ func BenchmarkAppend(b *testing.B) {
 
 func BenchmarkCopy(b *testing.B) {
 	rng, _ := randutil.NewPseudoRand()
-	sel := make([]uint16, BatchSize())
-	for i, selIdx := range rng.Perm(len(sel)) {
-		sel[i] = uint16(selIdx)
-	}
+	sel := rng.Perm(int(BatchSize()))
 
 	benchCases := []struct {
 		name string
@@ -557,3 +586,123 @@ func BenchmarkCopy(b *testing.B) {
 		}
 	}
 }
+
+func TestMaybeHasNullsIsMaintained(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const typ = coltypes.Int64
+	n := int(BatchSize())
+	// src has nulls only in its first half.
+	src := NewMemColumn(typ, n)
+	for i := 0; i < n/2; i += 3 {
+		src.Nulls().SetNull(uint16(i))
+	}
+
+	t.Run("CopyNullFreeRange", func(t *testing.T) {
+		for _, destIdx := range []uint64{0, 3, 8} {
+			dest := NewMemColumn(typ, n)
+			dest.Copy(CopySliceArgs{
+				SliceArgs: SliceArgs{
+					ColType:     typ,
+					Src:         src,
+					DestIdx:     destIdx,
+					SrcStartIdx: uint64(n / 2),
+					SrcEndIdx:   uint64(n),
+				},
+			})
+			require.False(t, dest.MaybeHasNulls(), "destIdx=%d", destIdx)
+		}
+	})
+
+	t.Run("CopyRangeWithNulls", func(t *testing.T) {
+		dest := NewMemColumn(typ, n)
+		dest.Copy(CopySliceArgs{
+			SliceArgs: SliceArgs{
+				ColType:     typ,
+				Src:         src,
+				DestIdx:     1,
+				SrcStartIdx: 0,
+				SrcEndIdx:   uint64(n / 2),
+			},
+		})
+		require.True(t, dest.MaybeHasNulls())
+		require.True(t, dest.Nulls().NullAt(1))
+	})
+
+	t.Run("AppendOverwrite", func(t *testing.T) {
+		dest := NewMemColumn(typ, 0)
+		dest.Append(SliceArgs{ColType: typ, Src: src, SrcEndIdx: uint64(n)})
+		require.True(t, dest.MaybeHasNulls())
+		// Appending a null-free range at the beginning truncates all of the old
+		// values, including the nulls.
+		dest.Append(SliceArgs{
+			ColType:     typ,
+			Src:         src,
+			SrcStartIdx: uint64(n / 2),
+			SrcEndIdx:   uint64(n),
+		})
+		require.False(t, dest.MaybeHasNulls())
+		for i := 0; i < dest.Length(); i++ {
+			require.False(t, dest.Nulls().NullAt(uint16(i)))
+		}
+		// Appending at a non-zero index keeps the old nulls.
+		dest.Append(SliceArgs{ColType: typ, Src: src, DestIdx: 1, SrcEndIdx: uint64(n)})
+		require.True(t, dest.MaybeHasNulls())
+		require.True(t, dest.Nulls().NullAt(1))
+	})
+
+	t.Run("AppendToItself", func(t *testing.T) {
+		vec := NewMemColumn(typ, 0)
+		vec.Append(SliceArgs{ColType: typ, Src: src, SrcEndIdx: uint64(n)})
+		// Shift the values of the vector by one towards the beginning.
+		vec.Append(SliceArgs{ColType: typ, Src: vec, SrcStartIdx: 1, SrcEndIdx: uint64(n)})
+		require.True(t, vec.MaybeHasNulls())
+		require.True(t, vec.Nulls().NullAt(2))
+		require.False(t, vec.Nulls().NullAt(0))
+	})
+}
+
+func TestClone(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	src := NewMemColumn(coltypes.Bytes, 4)
+	for i, v := range []string{"a", "bb", "", "dddd"} {
+		src.Bytes().Set(i, []byte(v))
+	}
+	src.Nulls().SetNull(2)
+
+	t.Run("NoSel", func(t *testing.T) {
+		clone := src.Clone(nil /* sel */, 3 /* n */)
+		require.Equal(t, 3, clone.Length())
+		require.Equal(t, []byte("bb"), clone.Bytes().Get(1))
+		require.True(t, clone.Nulls().NullAt(2))
+		// The clone doesn't share the memory with the source.
+		clone.Bytes().Set(0, []byte("x"))
+		require.Equal(t, []byte("a"), src.Bytes().Get(0))
+		clone.Nulls().SetNull(0)
+		require.False(t, src.Nulls().NullAt(0))
+	})
+
+	t.Run("WithSel", func(t *testing.T) {
+		clone := src.Clone([]int{3, 2, 0}, 3 /* n */)
+		require.Equal(t, 3, clone.Length())
+		require.Equal(t, []byte("dddd"), clone.Bytes().Get(0))
+		require.True(t, clone.Nulls().NullAt(1))
+		require.Equal(t, []byte("a"), clone.Bytes().Get(2))
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		b := NewMemBatch([]coltypes.T{coltypes.Int64, coltypes.Unhandled})
+		copy(b.ColVec(0).Int64(), []int64{0, 1, 2, 3})
+		b.SetSelection(true)
+		copy(b.Selection(), []int{1, 3})
+		b.SetLength(2)
+
+		clone := b.Clone()
+		require.Equal(t, uint16(2), clone.Length())
+		require.Nil(t, clone.Selection())
+		require.Equal(t, 2, clone.(*MemBatch).Capacity())
+		require.Equal(t, []int64{1, 3}, clone.ColVec(0).Int64())
+		require.Equal(t, coltypes.Unhandled, clone.ColVec(1).Type())
+	})
+}
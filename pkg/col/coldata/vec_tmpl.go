@@ -52,6 +52,13 @@ var _ duration.Duration
 // */}}
 
 func (m *memColumn) Append(args SliceArgs) {
+	if args.DestIdx == 0 && m.nulls.maybeHasNulls && args.Src != Vec(m) {
+		// All of the old values are truncated, so the nulls among them must not
+		// keep the vector marked as possibly having nulls (which would disable
+		// the null-free fast paths of the operators). Note that the vector can be
+		// appended to itself, in which case the nulls are still needed.
+		m.nulls.UnsetNulls()
+	}
 	switch args.ColType {
 	// {{range .}}
 	case _TYPES_T:
@@ -73,7 +80,7 @@ func (m *memColumn) Append(args SliceArgs) {
 			// whether the value is NULL. It is possible that Bytes' invariant of
 			// non-decreasing offsets on the source is currently not maintained, so
 			// we explicitly enforce it.
-			maxIdx := uint16(0)
+			maxIdx := 0
 			for _, selIdx := range sel {
 				if selIdx > maxIdx {
 					maxIdx = selIdx
@@ -84,13 +91,38 @@ func (m *memColumn) Append(args SliceArgs) {
 			toCol = execgen.SLICE(toCol, 0, int(args.DestIdx))
 			// {{end}}
 			for _, selIdx := range sel {
-				val := execgen.UNSAFEGET(fromCol, int(selIdx))
+				val := execgen.UNSAFEGET(fromCol, selIdx)
 				execgen.APPENDVAL(toCol, val)
 			}
 		}
 		m.nulls.set(args)
 		m.col = toCol
 	// {{end}}
+	case coltypes.Array:
+		fromCol := args.Src.Array()
+		toCol := m.Array()
+		if args.Sel == nil {
+			toCol.AppendSlice(fromCol, int(args.DestIdx), int(args.SrcStartIdx), int(args.SrcEndIdx))
+		} else {
+			// We need to truncate toCol before appending to it.
+			toCol.AppendSlice(toCol, int(args.DestIdx), 0, 0)
+			for _, selIdx := range args.Sel[args.SrcStartIdx:args.SrcEndIdx] {
+				toCol.AppendSlice(fromCol, toCol.Len(), selIdx, selIdx+1)
+			}
+		}
+		m.nulls.set(args)
+	case coltypes.Datum:
+		fromCol := args.Src.Datum()
+		toCol := m.Datum()
+		if args.Sel == nil {
+			toCol.AppendSlice(fromCol, int(args.DestIdx), int(args.SrcStartIdx), int(args.SrcEndIdx))
+		} else {
+			toCol.SetLength(int(args.DestIdx))
+			for _, selIdx := range args.Sel[args.SrcStartIdx:args.SrcEndIdx] {
+				toCol.AppendVal(fromCol.Get(selIdx))
+			}
+		}
+		m.nulls.set(args)
 	default:
 		panic(fmt.Sprintf("unhandled type %s", args.ColType))
 	}
@@ -98,7 +130,7 @@ func (m *memColumn) Append(args SliceArgs) {
 
 // {{/*
 func _COPY_WITH_SEL(
-	m *memColumn, args CopySliceArgs, fromCol, toCol _GOTYPESLICE, sel interface{}, _SEL_ON_DEST bool,
+	m *memColumn, args CopySliceArgs, fromCol, toCol _GOTYPESLICE, sel []int, _SEL_ON_DEST bool,
 ) { // */}}
 	// {{define "copyWithSel" -}}
 	if args.Src.MaybeHasNulls() {
@@ -113,10 +145,10 @@ func _COPY_WITH_SEL(
 				m.nulls.SetNull64(uint64(i) + args.DestIdx)
 				// {{end}}
 			} else {
-				v := execgen.UNSAFEGET(fromCol, int(selIdx))
+				v := execgen.UNSAFEGET(fromCol, selIdx)
 				// {{if .SelOnDest}}
 				m.nulls.UnsetNull64(uint64(selIdx))
-				execgen.SET(toCol, int(selIdx), v)
+				execgen.SET(toCol, selIdx, v)
 				// {{else}}
 				execgen.SET(toCol, i+int(args.DestIdx), v)
 				// {{end}}
@@ -127,9 +159,9 @@ func _COPY_WITH_SEL(
 	// No Nulls.
 	for i := range sel[args.SrcStartIdx:args.SrcEndIdx] {
 		selIdx := sel[int(args.SrcStartIdx)+i]
-		v := execgen.UNSAFEGET(fromCol, int(selIdx))
+		v := execgen.UNSAFEGET(fromCol, selIdx)
 		// {{if .SelOnDest}}
-		execgen.SET(toCol, int(selIdx), v)
+		execgen.SET(toCol, selIdx, v)
 		// {{else}}
 		execgen.SET(toCol, i+int(args.DestIdx), v)
 		// {{end}}
@@ -155,15 +187,7 @@ func (m *memColumn) Copy(args CopySliceArgs) {
 	case _TYPES_T:
 		fromCol := args.Src._TemplateType()
 		toCol := m._TemplateType()
-		if args.Sel64 != nil {
-			sel := args.Sel64
-			if args.SelOnDest {
-				_COPY_WITH_SEL(m, args, sel, toCol, fromCol, true)
-			} else {
-				_COPY_WITH_SEL(m, args, sel, toCol, fromCol, false)
-			}
-			return
-		} else if args.Sel != nil {
+		if args.Sel != nil {
 			sel := args.Sel
 			if args.SelOnDest {
 				_COPY_WITH_SEL(m, args, sel, toCol, fromCol, true)
@@ -172,28 +196,86 @@ func (m *memColumn) Copy(args CopySliceArgs) {
 			}
 			return
 		}
-		// No Sel or Sel64.
+		// No Sel.
 		execgen.COPYSLICE(toCol, fromCol, int(args.DestIdx), int(args.SrcStartIdx), int(args.SrcEndIdx))
 		m.nulls.set(args.SliceArgs)
 	// {{end}}
+	case coltypes.Array:
+		fromCol := args.Src.Array()
+		toCol := m.Array()
+		if args.Sel == nil {
+			toCol.CopySlice(fromCol, int(args.DestIdx), int(args.SrcStartIdx), int(args.SrcEndIdx))
+			m.nulls.set(args.SliceArgs)
+			return
+		}
+		// Arrays are copied one at a time since the selection vector might not
+		// be increasing.
+		srcNulls := args.Src.Nulls()
+		for i := 0; i < int(args.SrcEndIdx-args.SrcStartIdx); i++ {
+			selIdx := args.Sel[int(args.SrcStartIdx)+i]
+			destIdx := i + int(args.DestIdx)
+			if args.SelOnDest {
+				destIdx = selIdx
+			}
+			if srcNulls.NullAt64(uint64(selIdx)) {
+				m.nulls.SetNull64(uint64(destIdx))
+				continue
+			}
+			m.nulls.UnsetNull64(uint64(destIdx))
+			toCol.CopySlice(fromCol, destIdx, selIdx, selIdx+1)
+		}
+	case coltypes.Datum:
+		fromCol := args.Src.Datum()
+		toCol := m.Datum()
+		if args.Sel == nil {
+			toCol.CopySlice(fromCol, int(args.DestIdx), int(args.SrcStartIdx), int(args.SrcEndIdx))
+			m.nulls.set(args.SliceArgs)
+			return
+		}
+		srcNulls := args.Src.Nulls()
+		for i := 0; i < int(args.SrcEndIdx-args.SrcStartIdx); i++ {
+			selIdx := args.Sel[int(args.SrcStartIdx)+i]
+			destIdx := i + int(args.DestIdx)
+			if args.SelOnDest {
+				destIdx = selIdx
+			}
+			if srcNulls.NullAt64(uint64(selIdx)) {
+				m.nulls.SetNull64(uint64(destIdx))
+				continue
+			}
+			m.nulls.UnsetNull64(uint64(destIdx))
+			toCol.Set(destIdx, fromCol.Get(selIdx))
+		}
 	default:
 		panic(fmt.Sprintf("unhandled type %s", args.ColType))
 	}
 }
 
-func (m *memColumn) Window(colType coltypes.T, start uint64, end uint64) Vec {
-	switch colType {
+func (m *memColumn) Window(start uint64, end uint64) Vec {
+	switch m.t {
 	// {{range .}}
 	case _TYPES_T:
 		col := m._TemplateType()
 		return &memColumn{
-			t:     colType,
+			t:     m.t,
 			col:   execgen.WINDOW(col, int(start), int(end)),
 			nulls: m.nulls.Slice(start, end),
 		}
 	// {{end}}
+	case coltypes.Array:
+		return &memColumn{
+			t:     m.t,
+			col:   m.Array().Window(int(start), int(end)),
+			nulls: m.nulls.Slice(start, end),
+		}
+	case coltypes.Datum:
+		return &memColumn{
+			t:     m.t,
+			col:   m.Datum().Window(int(start), int(end)),
+			nulls: m.nulls.Slice(start, end),
+		}
 	default:
-		panic(fmt.Sprintf("unhandled type %d", colType))
+		panic(fmt.Sprintf("unhandled type %d", m.t))
 	}
 }
 
@@ -208,19 +290,23 @@ func (m *memColumn) PrettyValueAt(colIdx uint16, colType coltypes.T) string {
 		v := execgen.UNSAFEGET(col, int(colIdx))
 		return fmt.Sprintf("%v", v)
 	// {{end}}
+	case coltypes.Array:
+		return m.Array().arrayString(int(colIdx))
+	case coltypes.Datum:
+		return fmt.Sprintf("%v", m.Datum().Get(int(colIdx)))
 	default:
 		panic(fmt.Sprintf("unhandled type %d", colType))
 	}
 }
 
 // Helper to set the value in a Vec when the type is unknown.
-func SetValueAt(v Vec, elem interface{}, rowIdx uint16, colType coltypes.T) {
+func SetValueAt(v Vec, elem interface{}, rowIdx int, colType coltypes.T) {
 	switch colType {
 	// {{range .}}
 	case _TYPES_T:
 		target := v._TemplateType()
 		newVal := elem.(_GOTYPE)
-		execgen.SET(target, int(rowIdx), newVal)
+		execgen.SET(target, rowIdx, newVal)
 		// {{end}}
 	default:
 		panic(fmt.Sprintf("unhandled type %d", colType))
@@ -11,7 +11,9 @@
 package colserde
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math/bits"
 	"reflect"
 	"unsafe"
 
@@ -20,6 +22,7 @@ import (
 	"github.com/apache/arrow/go/arrow/memory"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/errors"
 )
 
@@ -46,6 +49,8 @@ type ArrowBatchConverter struct {
 		// buffers is scratch space for exactly two buffers per element in
 		// arrowData.
 		buffers [][]*memory.Buffer
+		// intervalBuf is scratch space for marshaling a single interval.
+		intervalBuf []byte
 	}
 }
 
@@ -89,6 +94,7 @@ var supportedTypes = func() map[coltypes.T]struct{} {
 		coltypes.Int16,
 		coltypes.Int32,
 		coltypes.Int64,
+		coltypes.Interval,
 		coltypes.Timestamp,
 	} {
 		typs[t] = struct{}{}
@@ -116,9 +122,9 @@ func (c *ArrowBatchConverter) BatchToArrow(batch coldata.Batch) ([]*array.Data,
 			arrowBitmap = n.NullBitmap()
 		}
 
-		if typ == coltypes.Bool || typ == coltypes.Decimal || typ == coltypes.Timestamp {
-			// Bools, Decimals, and Timestamps are handled differently from other
-			// coltypes. Refer to the comment on ArrowBatchConverter.builders for
+		if typ == coltypes.Bool || typ == coltypes.Decimal || typ == coltypes.Timestamp || typ == coltypes.Interval {
+			// Bools, Decimals, Timestamps, and Intervals are handled differently
+			// from other coltypes. Refer to the comment on ArrowBatchConverter.builders for
 			// more information.
 			var data *array.Data
 			switch typ {
@@ -145,6 +151,13 @@ func (c *ArrowBatchConverter) BatchToArrow(batch coldata.Batch) ([]*array.Data,
 					c.builders.binaryBuilder.Append(marshaled)
 				}
 				data = c.builders.binaryBuilder.NewBinaryArray().Data()
+			case coltypes.Interval:
+				intervals := vec.Interval()[:n]
+				for _, d := range intervals {
+					c.scratch.intervalBuf = marshalInterval(c.scratch.intervalBuf[:0], d)
+					c.builders.binaryBuilder.Append(c.scratch.intervalBuf)
+				}
+				data = c.builders.binaryBuilder.NewBinaryArray().Data()
 			default:
 				panic(fmt.Sprintf("unexpected type %s", typ))
 			}
@@ -314,6 +327,18 @@ func (c *ArrowBatchConverter) ArrowToBatch(data []*array.Data, b coldata.Batch)
 				}
 			}
 			arr = bytesArr
+		case coltypes.Interval:
+			bytesArr := array.NewBinaryData(d)
+			bytes := bytesArr.ValueBytes()
+			offsets := bytesArr.ValueOffsets()
+			vecArr := vec.Interval()
+			for i := 0; i < len(offsets)-1; i++ {
+				var err error
+				if vecArr[i], err = unmarshalInterval(bytes[offsets[i]:offsets[i+1]]); err != nil {
+					return err
+				}
+			}
+			arr = bytesArr
 		default:
 			var col interface{}
 			switch typ {
@@ -347,3 +372,161 @@ func (c *ArrowBatchConverter) ArrowToBatch(data []*array.Data, b coldata.Batch)
 	}
 	return nil
 }
+
+// ArrowType returns the arrow data type of the arrays that columns of type t
+// are converted to. Note that Decimals, Timestamps, and Intervals are stored as
+// binary arrays of their marshaled representations.
+func ArrowType(t coltypes.T) (arrow.DataType, error) {
+	switch t {
+	case coltypes.Bool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case coltypes.Bytes, coltypes.Decimal, coltypes.Timestamp, coltypes.Interval:
+		return arrow.BinaryTypes.Binary, nil
+	case coltypes.Int16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case coltypes.Int32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case coltypes.Int64:
+		return arrow.PrimitiveTypes.Int64, nil
+	case coltypes.Float64:
+		return arrow.PrimitiveTypes.Float64, nil
+	default:
+		return nil, errors.Errorf("unsupported type %v", t.String())
+	}
+}
+
+// ArrowSchema returns the arrow schema of the records produced by
+// BatchToArrowRecord. The columns are named by their ordinals.
+func (c *ArrowBatchConverter) ArrowSchema() (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(c.typs))
+	for i, t := range c.typs {
+		dtype, err := ArrowType(t)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = arrow.Field{Name: fmt.Sprintf("col%d", i), Type: dtype, Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil /* metadata */), nil
+}
+
+// BatchToArrowRecord converts the first batch.Length elements of the batch
+// into an arrow record that can be consumed by external Arrow-based tools.
+// Unlike the data returned by BatchToArrow, the arrays of the record have
+// their types and null counts set.
+//
+// The arrays share the underlying memory with the batch whenever the layouts
+// match (that is the case for Bytes, Float64, and all integer types), so the
+// record must not be used after the batch is modified.
+func (c *ArrowBatchConverter) BatchToArrowRecord(batch coldata.Batch) (array.Record, error) {
+	schema, err := c.ArrowSchema()
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.BatchToArrow(batch)
+	if err != nil {
+		return nil, err
+	}
+	n := int(batch.Length())
+	cols := make([]array.Interface, len(data))
+	for i, d := range data {
+		// The buffers slice is scratch space of the converter, so it is copied.
+		buffers := append([]*memory.Buffer(nil), d.Buffers()...)
+		var nullCount int
+		if len(buffers) > 0 && buffers[0] != nil {
+			nullCount = arrowNullCount(buffers[0].Bytes(), n)
+		}
+		cols[i] = array.MakeFromData(array.NewData(
+			schema.Field(i).Type, n, buffers, nil /* childData */, nullCount, 0, /* offset */
+		))
+	}
+	record := array.NewRecord(schema, cols, int64(n))
+	// The record holds its own references to the arrays.
+	for _, col := range cols {
+		col.Release()
+	}
+	return record, nil
+}
+
+// ArrowRecordToBatch converts an arrow record (for example, the one produced
+// by an external Arrow-based tool) into a coldata.Batch. The columns of the
+// record must have the types returned by ArrowType for the schema of the
+// converter, must not be sliced, and there must not be more than
+// coldata.BatchSize() rows in the record.
+//
+// Like ArrowToBatch, the batch shares the underlying memory with the record
+// whenever the layouts match, and the null bitmaps of the record are modified
+// in place.
+func (c *ArrowBatchConverter) ArrowRecordToBatch(r array.Record, b coldata.Batch) error {
+	if int(r.NumCols()) != len(c.typs) {
+		return errors.Errorf("mismatched record width and schema length: %d != %d", r.NumCols(), len(c.typs))
+	}
+	if r.NumRows() > int64(coldata.BatchSize()) {
+		return errors.Errorf("too many rows in the record: %d > %d", r.NumRows(), coldata.BatchSize())
+	}
+	data := make([]*array.Data, len(c.typs))
+	for i, t := range c.typs {
+		col := r.Column(i)
+		expected, err := ArrowType(t)
+		if err != nil {
+			return err
+		}
+		if col.DataType().ID() != expected.ID() {
+			return errors.Errorf(
+				"unexpected arrow type of column %d: %s, expected %s", i, col.DataType().Name(), expected.Name(),
+			)
+		}
+		if col.Data().Offset() != 0 {
+			return errors.Errorf("sliced arrow arrays are not supported (column %d)", i)
+		}
+		data[i] = col.Data()
+	}
+	if len(data) == 0 {
+		b.SetLength(uint16(r.NumRows()))
+		return nil
+	}
+	return c.ArrowToBatch(data, b)
+}
+
+// arrowNullCount returns the number of null values among the first n values
+// of the arrow validity bitmap.
+func arrowNullCount(bitmap []byte, n int) int {
+	if len(bitmap) == 0 {
+		return 0
+	}
+	valid := 0
+	for i := 0; i < n/8; i++ {
+		valid += bits.OnesCount8(bitmap[i])
+	}
+	if mod := n % 8; mod != 0 {
+		valid += bits.OnesCount8(bitmap[n/8] & (1<<uint(mod) - 1))
+	}
+	return n - valid
+}
+
+// sizeOfMarshaledInterval is the number of bytes that a marshaled
+// duration.Duration occupies: its months, days, and nanoseconds are each
+// stored as a little-endian int64.
+const sizeOfMarshaledInterval = 24
+
+// marshalInterval appends the fixed-width representation of d to b.
+func marshalInterval(b []byte, d duration.Duration) []byte {
+	var buf [sizeOfMarshaledInterval]byte
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(d.Months))
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(d.Days))
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(d.Nanos()))
+	return append(b, buf[:]...)
+}
+
+// unmarshalInterval decodes an interval that was marshaled by
+// marshalInterval.
+func unmarshalInterval(b []byte) (duration.Duration, error) {
+	if len(b) != sizeOfMarshaledInterval {
+		return duration.Duration{}, errors.Errorf(
+			"unexpected length of marshaled interval: %d != %d", len(b), sizeOfMarshaledInterval,
+		)
+	}
+	months := int64(binary.LittleEndian.Uint64(b[0:8]))
+	days := int64(binary.LittleEndian.Uint64(b[8:16]))
+	nanos := int64(binary.LittleEndian.Uint64(b[16:24]))
+	return duration.DecodeDuration(months, days, nanos), nil
+}
@@ -15,7 +15,9 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/apache/arrow/go/arrow"
 	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/colserde"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
@@ -29,17 +31,9 @@ func randomBatch(allocator *colexec.Allocator) ([]coltypes.T, coldata.Batch) {
 	const maxTyps = 16
 	rng, _ := randutil.NewPseudoRand()
 
-	availableTyps := make([]coltypes.T, 0, len(coltypes.AllTypes))
-	for _, typ := range coltypes.AllTypes {
-		// TODO(yuzefovich): We do not support interval conversion yet.
-		if typ == coltypes.Interval {
-			continue
-		}
-		availableTyps = append(availableTyps, typ)
-	}
 	typs := make([]coltypes.T, rng.Intn(maxTyps)+1)
 	for i := range typs {
-		typs[i] = availableTyps[rng.Intn(len(availableTyps))]
+		typs[i] = coltypes.AllTypes[rng.Intn(len(coltypes.AllTypes))]
 	}
 
 	capacity := rng.Intn(int(coldata.BatchSize())) + 1
@@ -51,7 +45,7 @@ func randomBatch(allocator *colexec.Allocator) ([]coltypes.T, coldata.Batch) {
 func TestArrowBatchConverterRejectsUnsupportedTypes(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
-	unsupportedTypes := []coltypes.T{coltypes.Interval}
+	unsupportedTypes := []coltypes.T{coltypes.Array, coltypes.Datum}
 	for _, typ := range unsupportedTypes {
 		_, err := colserde.NewArrowBatchConverter([]coltypes.T{typ})
 		require.Error(t, err)
@@ -67,7 +61,7 @@ func TestArrowBatchConverterRandom(t *testing.T) {
 
 	// Make a copy of the original batch because the converter modifies and casts
 	// data without copying for performance reasons.
-	expected := colexec.CopyBatch(testAllocator, b)
+	expected := testAllocator.CloneBatch(b)
 
 	arrowData, err := c.BatchToArrow(b)
 	require.NoError(t, err)
@@ -77,6 +71,66 @@ func TestArrowBatchConverterRandom(t *testing.T) {
 	coldata.AssertEquivalentBatches(t, expected, actual)
 }
 
+func TestArrowBatchConverterRecord(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs, b := randomBatch(testAllocator)
+	c, err := colserde.NewArrowBatchConverter(typs)
+	require.NoError(t, err)
+
+	// Make a copy of the original batch because the converter modifies and casts
+	// data without copying for performance reasons.
+	expected := testAllocator.CloneBatch(b)
+
+	record, err := c.BatchToArrowRecord(b)
+	require.NoError(t, err)
+	defer record.Release()
+	require.Equal(t, int64(len(typs)), record.NumCols())
+	require.Equal(t, int64(expected.Length()), record.NumRows())
+	for i, typ := range typs {
+		dtype, err := colserde.ArrowType(typ)
+		require.NoError(t, err)
+		col := record.Column(i)
+		require.Equal(t, dtype.ID(), col.DataType().ID())
+		expectedNulls := 0
+		if nulls := expected.ColVec(i).Nulls(); nulls.MaybeHasNulls() {
+			for j := uint16(0); j < expected.Length(); j++ {
+				if nulls.NullAt(j) {
+					expectedNulls++
+				}
+			}
+		}
+		require.Equal(t, expectedNulls, col.NullN(), "column %d", i)
+	}
+
+	actual := coldata.NewMemBatchWithSize(nil, 0)
+	require.NoError(t, c.ArrowRecordToBatch(record, actual))
+	coldata.AssertEquivalentBatches(t, expected, actual)
+}
+
+func TestArrowRecordToBatchRejectsMismatchedTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	builder := array.NewInt64Builder(memory.DefaultAllocator)
+	builder.AppendValues([]int64{1, 2, 3}, nil /* valid */)
+	col := builder.NewInt64Array()
+	defer col.Release()
+	schema := arrow.NewSchema([]arrow.Field{{Name: "col0", Type: arrow.PrimitiveTypes.Int64}}, nil /* metadata */)
+	record := array.NewRecord(schema, []array.Interface{col}, int64(col.Len()))
+	defer record.Release()
+
+	c, err := colserde.NewArrowBatchConverter([]coltypes.T{coltypes.Int16})
+	require.NoError(t, err)
+	require.Error(t, c.ArrowRecordToBatch(record, coldata.NewMemBatchWithSize(nil, 0)))
+
+	c, err = colserde.NewArrowBatchConverter([]coltypes.T{coltypes.Int64})
+	require.NoError(t, err)
+	actual := coldata.NewMemBatchWithSize(nil, 0)
+	require.NoError(t, c.ArrowRecordToBatch(record, actual))
+	require.Equal(t, uint16(3), actual.Length())
+	require.Equal(t, []int64{1, 2, 3}, actual.ColVec(0).Int64()[:3])
+}
+
 // roundTripBatch is a helper function that round trips a batch through the
 // ArrowBatchConverter and RecordBatchSerializer and asserts that the output
 // batch is equal to the input batch. Make sure to copy the input batch before
@@ -117,7 +171,7 @@ func TestRecordBatchRoundtripThroughBytes(t *testing.T) {
 
 		// Make a copy of the original batch because the converter modifies and
 		// casts data without copying for performance reasons.
-		expected := colexec.CopyBatch(testAllocator, b)
+		expected := testAllocator.CloneBatch(b)
 		actual, err := roundTripBatch(b, c, r)
 		require.NoError(t, err)
 
@@ -137,6 +191,7 @@ func BenchmarkArrowBatchConverter(b *testing.B) {
 		coltypes.Bytes,
 		coltypes.Decimal,
 		coltypes.Int64,
+		coltypes.Interval,
 		coltypes.Timestamp,
 	}
 	// numBytes corresponds 1:1 to typs and specifies how many bytes we are
@@ -148,6 +203,7 @@ func BenchmarkArrowBatchConverter(b *testing.B) {
 		0, // The number of bytes for decimals will be set below.
 		8 * int64(coldata.BatchSize()),
 		3 * 8 * int64(coldata.BatchSize()),
+		3 * 8 * int64(coldata.BatchSize()),
 	}
 	// Run a benchmark on every type we care about.
 	for typIdx, typ := range typs {
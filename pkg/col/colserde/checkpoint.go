@@ -0,0 +1,371 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde/arrowserde"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/pkg/errors"
+)
+
+// The checkpoint files store a stream of batches in a self-describing format
+// that can be written and read sequentially (unlike the arrow file format,
+// which requires the footer to be read first). The layout of a file is:
+//
+//	file   := magic schema batch* footer
+//	schema := uint32(len(message)) message
+//	batch  := batchMarker column*
+//	column := blockType uint32(len(block)) block
+//	footer := footerMarker uint64(numBatches) magic
+//
+// where message is an arrow Schema message and each block is an arrow
+// RecordBatch message with a single column, compressed if the column is of a
+// compressible type and the compression is worthwhile. Storing the columns in
+// separate blocks allows for only compressing the columns that benefit from
+// it. All integers are little-endian.
+const checkpointMagic = `CRCKPT01`
+
+const (
+	checkpointBatchMarker  byte = 1
+	checkpointFooterMarker byte = 2
+
+	checkpointUncompressedBlock byte = 0
+	checkpointCompressedBlock   byte = 1
+)
+
+// CheckpointWriter writes a stream of batches to a checkpoint file (see
+// checkpointMagic for the format), for example, to spill them to disk or to
+// capture the intermediate results of a query. All batches written to a file
+// must have the same schema.
+type CheckpointWriter struct {
+	w    io.Writer
+	typs []coltypes.T
+	a    *ArrowBatchConverter
+	// rbs contains a single-column RecordBatchSerializer for each column.
+	rbs []*RecordBatchSerializer
+	// compress indicates whether it is worth trying to compress each column.
+	compress   []bool
+	numBatches uint64
+
+	scratch struct {
+		header     [9]byte
+		buf        bytes.Buffer
+		compressed []byte
+		// batch is used to densify the batches with a selection vector.
+		batch coldata.Batch
+	}
+}
+
+// NewCheckpointWriter creates a CheckpointWriter for the given coltypes and
+// writes the header of the file to w. The caller is responsible for closing
+// the given writer.
+func NewCheckpointWriter(w io.Writer, typs []coltypes.T) (*CheckpointWriter, error) {
+	if len(typs) == 0 {
+		return nil, errors.Errorf("zero length schema unsupported")
+	}
+	a, err := NewArrowBatchConverter(typs)
+	if err != nil {
+		return nil, err
+	}
+	c := &CheckpointWriter{
+		w:        w,
+		typs:     typs,
+		a:        a,
+		rbs:      make([]*RecordBatchSerializer, len(typs)),
+		compress: make([]bool, len(typs)),
+	}
+	for i := range typs {
+		if c.rbs[i], err = NewRecordBatchSerializer(typs[i : i+1]); err != nil {
+			return nil, err
+		}
+		c.compress[i] = ShouldCompress(typs[i : i+1])
+	}
+
+	if _, err := io.WriteString(w, checkpointMagic); err != nil {
+		return nil, err
+	}
+	fb := flatbuffers.NewBuilder(flatbufferBuilderInitialCapacity)
+	fb.Finish(schemaMessage(fb, typs))
+	schemaBytes := fb.FinishedBytes()
+	binary.LittleEndian.PutUint32(c.scratch.header[:4], uint32(len(schemaBytes)))
+	if _, err := w.Write(c.scratch.header[:4]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(schemaBytes); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteBatch appends the batch to the file. Zero-length batches are ignored
+// since they don't carry any data. Note that the null bitmaps of the batch
+// might be modified (see ArrowBatchConverter.BatchToArrow).
+func (c *CheckpointWriter) WriteBatch(batch coldata.Batch) error {
+	n := batch.Length()
+	if n == 0 {
+		return nil
+	}
+	if sel := batch.Selection(); sel != nil {
+		batch = c.densify(batch, sel[:n])
+	}
+	data, err := c.a.BatchToArrow(batch)
+	if err != nil {
+		return err
+	}
+	c.scratch.header[0] = checkpointBatchMarker
+	if _, err := c.w.Write(c.scratch.header[:1]); err != nil {
+		return err
+	}
+	for i := range data {
+		c.scratch.buf.Reset()
+		if _, _, err := c.rbs[i].Serialize(&c.scratch.buf, data[i:i+1]); err != nil {
+			return err
+		}
+		block := c.scratch.buf.Bytes()
+		blockType := checkpointUncompressedBlock
+		if c.compress[i] {
+			c.scratch.compressed = Compress(c.scratch.compressed, block)
+			if CompressionWorthwhile(len(c.scratch.compressed), len(block)) {
+				blockType = checkpointCompressedBlock
+				block = c.scratch.compressed
+			}
+		}
+		c.scratch.header[0] = blockType
+		binary.LittleEndian.PutUint32(c.scratch.header[1:5], uint32(len(block)))
+		if _, err := c.w.Write(c.scratch.header[:5]); err != nil {
+			return err
+		}
+		if _, err := c.w.Write(block); err != nil {
+			return err
+		}
+	}
+	c.numBatches++
+	return nil
+}
+
+// densify copies the selected tuples of the batch into a scratch batch without
+// a selection vector.
+func (c *CheckpointWriter) densify(batch coldata.Batch, sel []int) coldata.Batch {
+	if c.scratch.batch == nil {
+		c.scratch.batch = coldata.NewMemBatch(c.typs)
+	}
+	c.scratch.batch.ResetInternalBatch()
+	for i, typ := range c.typs {
+		c.scratch.batch.ColVec(i).Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					ColType:   typ,
+					Src:       batch.ColVec(i),
+					Sel:       sel,
+					SrcEndIdx: uint64(len(sel)),
+				},
+			},
+		)
+	}
+	c.scratch.batch.SetLength(uint16(len(sel)))
+	return c.scratch.batch
+}
+
+// Finish writes the footer of the file. Nothing can be written after Finish.
+func (c *CheckpointWriter) Finish() error {
+	c.scratch.header[0] = checkpointFooterMarker
+	binary.LittleEndian.PutUint64(c.scratch.header[1:9], c.numBatches)
+	if _, err := c.w.Write(c.scratch.header[:9]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(c.w, checkpointMagic)
+	return err
+}
+
+// CheckpointReader reads the batches from a checkpoint file written by a
+// CheckpointWriter.
+type CheckpointReader struct {
+	r    io.Reader
+	typs []coltypes.T
+	a    *ArrowBatchConverter
+	rbs  []*RecordBatchSerializer
+	// numBatches is the number of batches read so far.
+	numBatches uint64
+	done       bool
+
+	scratch struct {
+		header     [9]byte
+		compressed []byte
+		// columns contains the serialized representation of each column of the
+		// last batch read. The batches returned by Next reference this memory.
+		columns [][]byte
+		data    []*array.Data
+	}
+}
+
+// NewCheckpointReader creates a CheckpointReader that reads the file from r,
+// verifying its header.
+func NewCheckpointReader(r io.Reader) (*CheckpointReader, error) {
+	c := &CheckpointReader{r: r}
+	magic := make([]byte, len(checkpointMagic))
+	if err := c.readFull(magic); err != nil {
+		return nil, pgerror.Wrap(err, pgcode.DataException, `verifying checkpoint file header magic`)
+	} else if !bytes.Equal([]byte(checkpointMagic), magic) {
+		return nil, errors.New(`checkpoint file header magic mismatch`)
+	}
+	if err := c.readFull(c.scratch.header[:4]); err != nil {
+		return nil, pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file schema`)
+	}
+	schemaBytes := make([]byte, binary.LittleEndian.Uint32(c.scratch.header[:4]))
+	if err := c.readFull(schemaBytes); err != nil {
+		return nil, pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file schema`)
+	}
+	message := arrowserde.GetRootAsMessage(schemaBytes, 0)
+	if message.HeaderType() != arrowserde.MessageHeaderSchema {
+		return nil, errors.Errorf(
+			`cannot decode Schema from %s message`,
+			arrowserde.EnumNamesMessageHeader[message.HeaderType()],
+		)
+	}
+	var (
+		schemaTab flatbuffers.Table
+		schema    arrowserde.Schema
+	)
+	if !message.Header(&schemaTab) {
+		return nil, errors.New(`unable to decode schema table`)
+	}
+	schema.Init(schemaTab.Bytes, schemaTab.Pos)
+	typs, err := typsFromSchema(&schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(typs) == 0 {
+		return nil, errors.Errorf("zero length schema unsupported")
+	}
+
+	c.typs = typs
+	if c.a, err = NewArrowBatchConverter(typs); err != nil {
+		return nil, err
+	}
+	c.rbs = make([]*RecordBatchSerializer, len(typs))
+	for i := range typs {
+		if c.rbs[i], err = NewRecordBatchSerializer(typs[i : i+1]); err != nil {
+			return nil, err
+		}
+	}
+	c.scratch.columns = make([][]byte, len(typs))
+	c.scratch.data = make([]*array.Data, 0, len(typs))
+	return c, nil
+}
+
+// Typs returns the in-memory columnar types for the data stored in the file.
+func (c *CheckpointReader) Typs() []coltypes.T {
+	return c.typs
+}
+
+// Next reads the next batch of the file into b. The boolean returned
+// specifies whether there was a batch to read; false is returned once the
+// footer of the file has been read and verified. The batch stays valid only
+// until the next call to Next. If an error is returned, the batch and boolean
+// returned are meaningless.
+func (c *CheckpointReader) Next(b coldata.Batch) (bool, error) {
+	if c.done {
+		return false, nil
+	}
+	if err := c.readFull(c.scratch.header[:1]); err != nil {
+		return false, pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file`)
+	}
+	switch c.scratch.header[0] {
+	case checkpointBatchMarker:
+	case checkpointFooterMarker:
+		return false, c.readFooter()
+	default:
+		return false, errors.Errorf(`unexpected checkpoint file marker %d`, c.scratch.header[0])
+	}
+
+	c.scratch.data = c.scratch.data[:0]
+	for i := range c.typs {
+		if err := c.readFull(c.scratch.header[:5]); err != nil {
+			return false, pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file column`)
+		}
+		blockType := c.scratch.header[0]
+		blockLen := int(binary.LittleEndian.Uint32(c.scratch.header[1:5]))
+		switch blockType {
+		case checkpointUncompressedBlock:
+			c.scratch.columns[i] = ensureLen(c.scratch.columns[i], blockLen)
+			if err := c.readFull(c.scratch.columns[i]); err != nil {
+				return false, pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file column`)
+			}
+		case checkpointCompressedBlock:
+			c.scratch.compressed = ensureLen(c.scratch.compressed, blockLen)
+			if err := c.readFull(c.scratch.compressed); err != nil {
+				return false, pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file column`)
+			}
+			decompressed, err := Decompress(c.scratch.columns[i], c.scratch.compressed)
+			if err != nil {
+				return false, pgerror.Wrap(err, pgcode.DataException, `decompressing checkpoint file column`)
+			}
+			c.scratch.columns[i] = decompressed
+		default:
+			return false, errors.Errorf(`unexpected checkpoint file block type %d`, blockType)
+		}
+		if err := c.rbs[i].Deserialize(&c.scratch.data, c.scratch.columns[i]); err != nil {
+			return false, err
+		}
+	}
+	if err := c.a.ArrowToBatch(c.scratch.data, b); err != nil {
+		return false, err
+	}
+	c.numBatches++
+	return true, nil
+}
+
+// readFooter reads the rest of the footer after the footer marker, verifying
+// that no batches are missing.
+func (c *CheckpointReader) readFooter() error {
+	if err := c.readFull(c.scratch.header[1:9]); err != nil {
+		return pgerror.Wrap(err, pgcode.DataException, `reading checkpoint file footer`)
+	}
+	if numBatches := binary.LittleEndian.Uint64(c.scratch.header[1:9]); numBatches != c.numBatches {
+		return errors.Errorf(`checkpoint file footer expects %d batches, read %d`, numBatches, c.numBatches)
+	}
+	magic := make([]byte, len(checkpointMagic))
+	if err := c.readFull(magic); err != nil {
+		return pgerror.Wrap(err, pgcode.DataException, `verifying checkpoint file footer magic`)
+	} else if !bytes.Equal([]byte(checkpointMagic), magic) {
+		return errors.New(`checkpoint file footer magic mismatch`)
+	}
+	c.done = true
+	return nil
+}
+
+// readFull reads exactly len(buf) bytes. Since the format describes how many
+// bytes follow, running out of input anywhere is an unexpected EOF.
+func (c *CheckpointReader) readFull(buf []byte) error {
+	_, err := io.ReadFull(c.r, buf)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ensureLen returns buf resliced to have length n, reallocating it if it
+// doesn't have enough capacity.
+func ensureLen(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+	return buf[:n]
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointRoundtrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	typs := make([]coltypes.T, rng.Intn(16)+1)
+	for i := range typs {
+		typs[i] = coltypes.AllTypes[rng.Intn(len(coltypes.AllTypes))]
+	}
+
+	var buf bytes.Buffer
+	w, err := colserde.NewCheckpointWriter(&buf, typs)
+	require.NoError(t, err)
+	numBatches := rng.Intn(5) + 1
+	expected := make([]coldata.Batch, 0, numBatches)
+	for i := 0; i < numBatches; i++ {
+		capacity := rng.Intn(int(coldata.BatchSize())) + 1
+		b := colexec.RandomBatch(testAllocator, rng, typs, capacity, 0 /* length */, rng.Float64())
+		if rng.Intn(2) == 0 {
+			// Select a random subset of the tuples.
+			b.SetSelection(true)
+			sel := b.Selection()[:0]
+			for j := 0; j < capacity; j++ {
+				if rng.Intn(2) == 0 {
+					sel = append(sel, j)
+				}
+			}
+			b.SetLength(uint16(len(sel)))
+		}
+		// The writer might modify the batch, so we make a dense copy of it
+		// beforehand.
+		if b.Length() > 0 {
+			expected = append(expected, testAllocator.CloneBatch(b))
+		}
+		require.NoError(t, w.WriteBatch(b))
+	}
+	require.NoError(t, w.Finish())
+
+	r, err := colserde.NewCheckpointReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, typs, r.Typs())
+	actual := coldata.NewMemBatchWithSize(nil, 0)
+	for _, b := range expected {
+		ok, err := r.Next(actual)
+		require.NoError(t, err)
+		require.True(t, ok)
+		coldata.AssertEquivalentBatches(t, b, actual)
+	}
+	// Reading past the end of the file keeps returning false.
+	for i := 0; i < 2; i++ {
+		ok, err := r.Next(actual)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+}
+
+func TestCheckpointCompression(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	n := int(coldata.BatchSize())
+	value := bytes.Repeat([]byte("cockroach"), 10)
+	b := testAllocator.NewMemBatchWithSize(typs, n)
+	for i := 0; i < n; i++ {
+		b.ColVec(0).Int64()[i] = int64(i)
+		b.ColVec(1).Bytes().Set(i, value)
+	}
+	b.SetLength(uint16(n))
+	expected := testAllocator.CloneBatch(b)
+
+	var buf bytes.Buffer
+	w, err := colserde.NewCheckpointWriter(&buf, typs)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteBatch(b))
+	require.NoError(t, w.Finish())
+	// The integers are stored uncompressed, but the strings should take up
+	// much less space than they do in memory.
+	require.True(t, buf.Len() < n*8+n*len(value)/2, "file size %d", buf.Len())
+
+	r, err := colserde.NewCheckpointReader(&buf)
+	require.NoError(t, err)
+	actual := coldata.NewMemBatchWithSize(nil, 0)
+	ok, err := r.Next(actual)
+	require.NoError(t, err)
+	require.True(t, ok)
+	coldata.AssertEquivalentBatches(t, expected, actual)
+}
+
+func TestCheckpointCorrupted(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	rng, _ := randutil.NewPseudoRand()
+	var buf bytes.Buffer
+	w, err := colserde.NewCheckpointWriter(&buf, typs)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		b := colexec.RandomBatch(testAllocator, rng, typs, 4 /* capacity */, 0 /* length */, 0.5 /* nullProbability */)
+		require.NoError(t, w.WriteBatch(b))
+	}
+	require.NoError(t, w.Finish())
+	file := buf.Bytes()
+
+	// readAll reads all the batches of the file and returns the first error.
+	readAll := func(file []byte) error {
+		r, err := colserde.NewCheckpointReader(bytes.NewReader(file))
+		if err != nil {
+			return err
+		}
+		b := coldata.NewMemBatchWithSize(nil, 0)
+		for {
+			if ok, err := r.Next(b); err != nil || !ok {
+				return err
+			}
+		}
+	}
+	require.NoError(t, readAll(file))
+
+	t.Run("Truncated", func(t *testing.T) {
+		for i := 0; i < len(file); i++ {
+			require.Error(t, readAll(file[:i]), "truncated to %d bytes", i)
+		}
+	})
+
+	t.Run("BadMagic", func(t *testing.T) {
+		corrupted := append([]byte(nil), file...)
+		corrupted[0] = 'X'
+		require.Error(t, readAll(corrupted))
+		corrupted = append([]byte(nil), file...)
+		corrupted[len(corrupted)-1] = 'X'
+		require.Error(t, readAll(corrupted))
+	})
+}
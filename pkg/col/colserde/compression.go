@@ -0,0 +1,69 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/golang/snappy"
+)
+
+// CompressionSizeReductionThreshold is the factor used to determine whether to
+// use the compressed bytes or not. If the compressed bytes are larger than
+// 1-1/CompressionSizeReductionThreshold of the original size, compression is
+// not used. This is to avoid paying the cost of decompression if the space
+// savings are not sufficient. All code that uses snappy compression (including
+// pebble and the higher-level snappy implementation) has this threshold in
+// place.
+const CompressionSizeReductionThreshold = 8
+
+// compressibleTypes are the types whose serialized representation usually
+// compresses well. These are the variable-width types (the values of which
+// are commonly strings with a lot of repetition), whereas the serialized
+// fixed-width values are mostly incompressible, so compressing them is not
+// worth the CPU cost.
+var compressibleTypes = map[coltypes.T]struct{}{
+	coltypes.Bytes:     {},
+	coltypes.Decimal:   {},
+	coltypes.Timestamp: {},
+	coltypes.Interval:  {},
+}
+
+// ShouldCompress returns whether it is worth trying to compress the serialized
+// batches with the schema typs, i.e. whether typs contains a compressible
+// type.
+func ShouldCompress(typs []coltypes.T) bool {
+	for _, t := range typs {
+		if _, ok := compressibleTypes[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress compresses src using dst as the scratch space (which is reused if
+// it has enough capacity). Whether the compressed bytes should be used
+// instead of src is determined by CompressionWorthwhile.
+func Compress(dst, src []byte) []byte {
+	return snappy.Encode(dst[:cap(dst)], src)
+}
+
+// CompressionWorthwhile returns whether the compression of uncompressedLen
+// bytes into compressedLen bytes reduces the size sufficiently (see
+// CompressionSizeReductionThreshold).
+func CompressionWorthwhile(compressedLen, uncompressedLen int) bool {
+	return compressedLen < uncompressedLen-uncompressedLen/CompressionSizeReductionThreshold
+}
+
+// Decompress decompresses src that was compressed by Compress using dst as
+// the scratch space (which is reused if it has enough capacity).
+func Decompress(dst, src []byte) ([]byte, error) {
+	return snappy.Decode(dst[:cap(dst)], src)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colserde_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldCompress(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	require.False(t, colserde.ShouldCompress(nil))
+	require.False(t, colserde.ShouldCompress([]coltypes.T{coltypes.Int64, coltypes.Float64, coltypes.Bool}))
+	require.True(t, colserde.ShouldCompress([]coltypes.T{coltypes.Int64, coltypes.Bytes}))
+	require.True(t, colserde.ShouldCompress([]coltypes.T{coltypes.Decimal}))
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The serialized batches with wide string columns are usually very
+	// repetitive.
+	src := bytes.Repeat([]byte("cockroach"), 1000)
+	var scratch []byte
+	for i := 0; i < 2; i++ {
+		compressed := colserde.Compress(scratch, src)
+		require.True(t, colserde.CompressionWorthwhile(len(compressed), len(src)))
+		decompressed, err := colserde.Decompress(nil /* dst */, compressed)
+		require.NoError(t, err)
+		require.Equal(t, src, decompressed)
+		// Reuse the scratch space on the next iteration.
+		scratch = compressed
+	}
+
+	require.False(t, colserde.CompressionWorthwhile(95 /* compressedLen */, 100 /* uncompressedLen */))
+	_, err := colserde.Decompress(nil /* dst */, []byte("not compressed"))
+	require.Error(t, err)
+}
@@ -297,13 +297,9 @@ func (d *FileDeserializer) init() ([]coltypes.T, error) {
 
 	var schema arrowserde.Schema
 	footer.Schema(&schema)
-	typs := make([]coltypes.T, schema.FieldsLength())
-	var field arrowserde.Field
-	for i := range typs {
-		schema.Fields(&field, i)
-		if typs[i], err = typeFromField(&field); err != nil {
-			return nil, err
-		}
+	typs, err := typsFromSchema(&schema)
+	if err != nil {
+		return nil, err
 	}
 
 	var block arrowserde.Block
@@ -378,6 +374,11 @@ func schema(fb *flatbuffers.Builder, typs []coltypes.T) flatbuffers.UOffsetT {
 			arrowserde.BinaryStart(fb)
 			fbTypOffset = arrowserde.BinaryEnd(fb)
 			fbTyp = arrowserde.TypeTimestamp
+		case coltypes.Interval:
+			// Intervals are marshaled into bytes, so we use binary headers.
+			arrowserde.BinaryStart(fb)
+			fbTypOffset = arrowserde.BinaryEnd(fb)
+			fbTyp = arrowserde.TypeInterval
 		default:
 			panic(errors.Errorf(`don't know how to map %s`, typ))
 		}
@@ -428,6 +429,21 @@ func fileFooter(
 	return arrowserde.FooterEnd(fb)
 }
 
+// typsFromSchema returns the in-memory columnar types of the fields of the
+// given schema.
+func typsFromSchema(schema *arrowserde.Schema) ([]coltypes.T, error) {
+	typs := make([]coltypes.T, schema.FieldsLength())
+	var field arrowserde.Field
+	for i := range typs {
+		schema.Fields(&field, i)
+		var err error
+		if typs[i], err = typeFromField(&field); err != nil {
+			return nil, err
+		}
+	}
+	return typs, nil
+}
+
 func typeFromField(field *arrowserde.Field) (coltypes.T, error) {
 	var typeTab flatbuffers.Table
 	field.Type(&typeTab)
@@ -465,6 +481,8 @@ func typeFromField(field *arrowserde.Field) (coltypes.T, error) {
 		return coltypes.Decimal, nil
 	case arrowserde.TypeTimestamp:
 		return coltypes.Timestamp, nil
+	case arrowserde.TypeInterval:
+		return coltypes.Interval, nil
 	}
 	// It'd be nice if this error could include more details, but flatbuffers
 	// doesn't make a String method or anything like that.
@@ -12,6 +12,7 @@ package colserde_test
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"math"
 	"math/rand"
@@ -163,6 +164,18 @@ func randomDataFromType(rng *rand.Rand, t coltypes.T, n int, nullProbability flo
 			}
 		}
 		builder.(*array.BinaryBuilder).AppendValues(data, valid)
+	case coltypes.Interval:
+		builder = array.NewBinaryBuilder(memory.DefaultAllocator, arrow.BinaryTypes.Binary)
+		data := make([][]byte, n)
+		for i := range data {
+			// Intervals are marshaled as their months, days, and nanoseconds, each
+			// as a little-endian int64.
+			data[i] = make([]byte, 24)
+			for j := 0; j < 3; j++ {
+				binary.LittleEndian.PutUint64(data[i][8*j:8*(j+1)], rng.Uint64())
+			}
+		}
+		builder.(*array.BinaryBuilder).AppendValues(data, valid)
 	default:
 		panic(fmt.Sprintf("unsupported type %s", t))
 	}
@@ -208,19 +221,10 @@ func TestRecordBatchSerializerSerializeDeserializeRandom(t *testing.T) {
 		dataLen         = rng.Intn(maxDataLen) + 1
 		nullProbability = rng.Float64()
 		buf             = bytes.Buffer{}
-		supportedTypes  = make([]coltypes.T, 0, len(coltypes.AllTypes))
 	)
 
-	// We do not support intervals.
-	for _, t := range coltypes.AllTypes {
-		if t == coltypes.Interval {
-			continue
-		}
-		supportedTypes = append(supportedTypes, t)
-	}
-
 	for i := range typs {
-		typs[i] = supportedTypes[rng.Intn(len(supportedTypes))]
+		typs[i] = coltypes.AllTypes[rng.Intn(len(coltypes.AllTypes))]
 		data[i] = randomDataFromType(rng, typs[i], dataLen, nullProbability)
 	}
 
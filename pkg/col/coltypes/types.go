@@ -44,6 +44,14 @@ const (
 	Timestamp
 	// Interval is a column of type duration.Duration
 	Interval
+	// Array is a column of arrays (see coldata.Arrays). Unlike the other types,
+	// it is not included into AllTypes since the templated operators don't
+	// support it.
+	Array
+	// Datum is a column of values that don't have a native columnar
+	// representation (see coldata.Datums). Like Array, it is not included into
+	// AllTypes, and only a few operators support it.
+	Datum
 
 	// Unhandled is a temporary value that represents an unhandled type.
 	// TODO(jordan): this should be replaced by a panic once all types are
@@ -69,6 +77,9 @@ var FloatTypes = []T{Float64}
 
 func init() {
 	for i := Bool; i < Unhandled; i++ {
+		if i == Array || i == Datum {
+			continue
+		}
 		AllTypes = append(AllTypes, i)
 	}
 
@@ -236,14 +247,17 @@ func (t T) CopySlice(target, src, destIdx, srcStartIdx, srcEndIdx string) string
 	return buf.String()
 }
 
-// AppendSlice is a function that should only be used in templates.
+// AppendSlice is a function that should only be used in templates. The
+// target slice (unless it is Bytes) is grown by at least doubling its capacity
+// whenever it doesn't have enough space, so that appending to the same slice
+// repeatedly (like when buffering the whole input) takes amortized linear time.
 func (t T) AppendSlice(target, src, destIdx, srcStartIdx, srcEndIdx string) string {
-	var tmpl string
-	switch t {
-	case Bytes:
-		tmpl = `{{.Tgt}}.AppendSlice({{.Src}}, {{.TgtIdx}}, {{.SrcStart}}, {{.SrcEnd}})`
-	case Decimal:
-		tmpl = `{
+	if t == Bytes {
+		return fmt.Sprintf(
+			"%s.AppendSlice(%s, %s, %s, %s)", target, src, destIdx, srcStartIdx, srcEndIdx,
+		)
+	}
+	tmpl := `{
   __desiredCap := {{.TgtIdx}} + {{.SrcEnd}} - {{.SrcStart}}
   if cap({{.Tgt}}) >= __desiredCap {
   	{{.Tgt}} = {{.Tgt}}[:__desiredCap]
@@ -253,25 +267,28 @@ func (t T) AppendSlice(target, src, destIdx, srcStartIdx, srcEndIdx string) stri
     if __capToAllocate < 2 * __prevCap {
       __capToAllocate = 2 * __prevCap
     }
-    __new_slice := make([]apd.Decimal, __desiredCap, __capToAllocate)
+    __new_slice := make({{.SliceType}}, __desiredCap, __capToAllocate)
     copy(__new_slice, {{.Tgt}}[:{{.TgtIdx}}])
     {{.Tgt}} = __new_slice
   }
   __src_slice := {{.Src}}[{{.SrcStart}}:{{.SrcEnd}}]
   __dst_slice := {{.Tgt}}[{{.TgtIdx}}:]
+  {{- if .IsDecimal}}
   for __i := range __src_slice {
     __dst_slice[__i].Set(&__src_slice[__i])
   }
+  {{- else}}
+  copy(__dst_slice, __src_slice)
+  {{- end}}
 }`
-	default:
-		tmpl = `{{.Tgt}} = append({{.Tgt}}[:{{.TgtIdx}}], {{.Src}}[{{.SrcStart}}:{{.SrcEnd}}]...)`
-	}
-	args := map[string]string{
-		"Tgt":      target,
-		"Src":      src,
-		"TgtIdx":   destIdx,
-		"SrcStart": srcStartIdx,
-		"SrcEnd":   srcEndIdx,
+	args := map[string]interface{}{
+		"Tgt":       target,
+		"Src":       src,
+		"TgtIdx":    destIdx,
+		"SrcStart":  srcStartIdx,
+		"SrcEnd":    srcEndIdx,
+		"SliceType": t.GoTypeSliceName(),
+		"IsDecimal": t == Decimal,
 	}
 	var buf strings.Builder
 	if err := template.Must(template.New("").Parse(tmpl)).Execute(&buf, args); err != nil {
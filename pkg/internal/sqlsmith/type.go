@@ -66,8 +66,10 @@ func (s *Smither) randType() *types.T {
 // vectorization).
 func (s *Smither) allowedType(types ...*types.T) bool {
 	for _, t := range types {
-		if s.vectorizable && typeconv.FromColumnType(t) == coltypes.Unhandled {
-			return false
+		if s.vectorizable {
+			if typ := typeconv.FromColumnType(t); typ == coltypes.Unhandled || typ == coltypes.Datum {
+				return false
+			}
 		}
 	}
 	return true
@@ -84,7 +84,9 @@ type Server struct {
 }
 
 // NewServer sets up a debug server.
-func NewServer(st *cluster.Settings, hbaConfDebugFn http.HandlerFunc) *Server {
+func NewServer(
+	st *cluster.Settings, hbaConfDebugFn http.HandlerFunc, latchesDebugFn http.HandlerFunc,
+) *Server {
 	mux := http.NewServeMux()
 
 	// Install a redirect to the UI's collection of debug tools.
@@ -117,6 +119,12 @@ func NewServer(st *cluster.Settings, hbaConfDebugFn http.HandlerFunc) *Server {
 		mux.HandleFunc("/debug/hba_conf", hbaConfDebugFn)
 	}
 
+	if latchesDebugFn != nil {
+		// Expose the latches held or waited on by requests, which helps
+		// diagnose stuck requests.
+		mux.HandleFunc("/debug/latches", latchesDebugFn)
+	}
+
 	// Register the stopper endpoint, which lists all active tasks.
 	mux.HandleFunc("/debug/stopper", stop.HandleDebug)
 
@@ -412,7 +412,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	// Set up the DistSQL temp engine.
 
 	useStoreSpec := cfg.Stores.Specs[s.cfg.TempStorageConfig.SpecIdx]
-	tempEngine, err := engine.NewTempEngine(s.cfg.StorageEngine, s.cfg.TempStorageConfig, useStoreSpec)
+	tempEngine, tempFS, err := engine.NewTempEngine(s.cfg.StorageEngine, s.cfg.TempStorageConfig, useStoreSpec)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create temp storage")
 	}
@@ -610,6 +610,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		ClusterName:    s.cfg.ClusterName,
 
 		TempStorage: tempEngine,
+		TempFS:      tempFS,
 		DiskMonitor: s.cfg.TempStorageConfig.Mon,
 
 		ParentMemoryMonitor: &rootSQLMemoryMonitor,
@@ -825,7 +826,9 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	s.node.InitLogger(&execCfg)
 	s.cfg.DefaultZoneConfig = cfg.DefaultZoneConfig
 
-	s.debug = debug.NewServer(s.ClusterSettings(), s.pgServer.HBADebugFn())
+	s.debug = debug.NewServer(
+		s.ClusterSettings(), s.pgServer.HBADebugFn(), s.status.handleDebugLatches,
+	)
 
 	return s, nil
 }
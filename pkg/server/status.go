@@ -1230,6 +1230,69 @@ func (s *statusServer) handleVars(w http.ResponseWriter, r *http.Request) {
 	telemetry.Inc(telemetryPrometheusVars)
 }
 
+// latchDumps returns the latches held or waited on in the latch managers of
+// the replicas of the node's stores.
+func (s *statusServer) latchDumps() ([]storage.StoreLatchDump, error) {
+	var dumps []storage.StoreLatchDump
+	err := s.stores.VisitStores(func(store *storage.Store) error {
+		dumps = append(dumps, store.LatchDump())
+		return nil
+	})
+	return dumps, err
+}
+
+// handleDebugLatches serves a JSON dump of the latches held or waited on in the
+// latch managers of the replicas of the node's stores, which helps diagnose
+// stuck requests.
+func (s *statusServer) handleDebugLatches(w http.ResponseWriter, r *http.Request) {
+	dumps, err := s.latchDumps()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httputil.ContentTypeHeader, httputil.JSONContentType)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(dumps); err != nil {
+		log.Error(r.Context(), err)
+	}
+}
+
+// Latches returns a JSON dump of the latches held or waited on in the latch
+// managers of the replicas of the specified node's stores.
+func (s *statusServer) Latches(
+	ctx context.Context, req *serverpb.LatchesRequest,
+) (*serverpb.JSONResponse, error) {
+	if _, err := s.admin.requireAdminUser(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.Latches(ctx, req)
+	}
+
+	dumps, err := s.latchDumps()
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.Internal, err.Error())
+	}
+	data, err := json.MarshalIndent(dumps, "", "  ")
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.Internal, err.Error())
+	}
+	return &serverpb.JSONResponse{Data: data}, nil
+}
+
 // Ranges returns range info for the specified node.
 func (s *statusServer) Ranges(
 	ctx context.Context, req *serverpb.RangesRequest,
@@ -13,6 +13,7 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math"
@@ -90,6 +91,28 @@ func TestStatusLocalStacks(t *testing.T) {
 	}
 }
 
+// TestStatusLocalLatches verifies that the latch dump is available via the
+// /_status/latches/local endpoint.
+func TestStatusLocalLatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	var latches serverpb.JSONResponse
+	for _, nodeID := range []string{"local", "1"} {
+		if err := getStatusJSONProto(s, "latches/"+nodeID, &latches); err != nil {
+			t.Fatal(err)
+		}
+		var dumps []storage.StoreLatchDump
+		if err := json.Unmarshal(latches.Data, &dumps); err != nil {
+			t.Fatal(err)
+		}
+		if len(dumps) != 1 {
+			t.Errorf("expected a latch dump for 1 store, got %d", len(dumps))
+		}
+	}
+}
+
 // TestStatusJson verifies that status endpoints return expected Json results.
 // The content type of the responses is always httputil.JSONContentType.
 func TestStatusJson(t *testing.T) {
@@ -52,6 +52,7 @@ const (
 	VersionPrimaryKeyColumnsOutOfFamilyZero
 	VersionRootPassword
 	VersionNoExplicitForeignKeyIndexIDs
+	VersionVectorizedStreamCompression
 
 	// Add new versions here (step one of two).
 )
@@ -398,6 +399,13 @@ var versionsSingleton = keyedVersions([]keyedVersion{
 		Key:     VersionNoExplicitForeignKeyIndexIDs,
 		Version: roachpb.Version{Major: 19, Minor: 2, Unstable: 11},
 	},
+	{
+		// VersionVectorizedStreamCompression enables the outboxes of the
+		// vectorized engine to send batches compressed with snappy, which the
+		// inboxes of older nodes cannot decode.
+		Key:     VersionVectorizedStreamCompression,
+		Version: roachpb.Version{Major: 19, Minor: 2, Unstable: 12},
+	},
 
 	// Add new versions here (step two of two).
 
@@ -22,20 +22,11 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
-	"github.com/golang/snappy"
 )
 
-const (
-	// compressionSizeReductionThreshold is the factor used to determine whether
-	// to write compressed bytes or not. If the compressed bytes are larger than
-	// 1-1/compressionSizeReductionThreshold of the original size, compression is
-	// not used. This is to avoid paying the cost of decompression if the space
-	// savings are not sufficient.
-	compressionSizeReductionThreshold = 8
-	// bytesPerSync is the amount of bytes written to a file before Sync is
-	// called (implemented by using a vfs.SyncingFile).
-	bytesPerSync = 512 << 10 /* 512 KiB */
-)
+// bytesPerSync is the amount of bytes written to a file before Sync is called
+// (implemented by using a vfs.SyncingFile).
+const bytesPerSync = 512 << 10 /* 512 KiB */
 
 // file represents in-memory state used by a diskQueue to keep track of the
 // state of a file.
@@ -63,9 +54,13 @@ type diskQueueWriter struct {
 	// compress writes (i.e. don't bother measuring whether compression passes
 	// a certain threshold of size improvement before writing compressed bytes).
 	testingKnobAlwaysCompress bool
-	buffer                    bytes.Buffer
-	wrapped                   io.Writer
-	scratch                   struct {
+	// compress specifies whether the writer should try to compress writes at
+	// all. It is false if the schema of the queue doesn't contain any types that
+	// usually compress well (see colserde.ShouldCompress).
+	compress bool
+	buffer   bytes.Buffer
+	wrapped  io.Writer
+	scratch  struct {
 		// blockType is a single byte that specifies whether the following block on
 		// disk (i.e. compressedBuf in memory) is compressed or not. It is an array
 		// due to having to pass this byte in as a slice to Write.
@@ -95,16 +90,15 @@ func (w *diskQueueWriter) reset(wrapped io.Writer) {
 // returned if no error occurred, otherwise 0, err is returned.
 func (w *diskQueueWriter) compressAndFlush() (int, error) {
 	b := w.buffer.Bytes()
-	compressed := snappy.Encode(w.scratch.compressedBuf, b)
-	w.scratch.compressedBuf = compressed[:cap(compressed)]
-
 	blockType := snappyUncompressedBlock
-	// Discard result if < 12.5% size reduction. All code that uses snappy
-	// compression (including pebble and the higher-level snappy implementation)
-	// has this threshold in place.
-	if w.testingKnobAlwaysCompress || len(compressed) < len(b)-len(b)/compressionSizeReductionThreshold {
-		blockType = snappyCompressedBlock
-		b = compressed
+	if w.compress || w.testingKnobAlwaysCompress {
+		compressed := colserde.Compress(w.scratch.compressedBuf, b)
+		w.scratch.compressedBuf = compressed[:cap(compressed)]
+		// Discard result if < 12.5% size reduction.
+		if w.testingKnobAlwaysCompress || colserde.CompressionWorthwhile(len(compressed), len(b)) {
+			blockType = snappyCompressedBlock
+			b = compressed
+		}
 	}
 
 	// Write whether this data is compressed or not.
@@ -167,7 +161,10 @@ type diskQueue struct {
 	writer             *diskQueueWriter
 	writeFileIdx       int
 	writeFile          engine.File
-	deserializerState  struct {
+	// numBytesWritten is the total number of bytes that have been written to
+	// all of the files of this queue.
+	numBytesWritten   int
+	deserializerState struct {
 		*colserde.FileDeserializer
 		curBatch int
 	}
@@ -247,6 +244,16 @@ func NewDiskQueue(typs []coltypes.T, cfg DiskQueueCfg) (Queue, error) {
 	if err := cfg.EnsureDefaults(); err != nil {
 		return nil, err
 	}
+	d, err := newDiskQueue(typs, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// newDiskQueue creates a diskQueue. It is assumed that the defaults of cfg
+// have already been ensured.
+func newDiskQueue(typs []coltypes.T, cfg DiskQueueCfg) (*diskQueue, error) {
 	d := &diskQueue{
 		dirName: uuid.FastMakeV4().String(),
 		typs:    typs,
@@ -307,7 +314,11 @@ func (d *diskQueue) rotateFile() error {
 	d.seqNo++
 
 	if d.serializer == nil {
-		writer := &diskQueueWriter{testingKnobAlwaysCompress: d.cfg.TestingKnobs.AlwaysCompress, wrapped: f}
+		writer := &diskQueueWriter{
+			testingKnobAlwaysCompress: d.cfg.TestingKnobs.AlwaysCompress,
+			compress:                  colserde.ShouldCompress(d.typs),
+			wrapped:                   f,
+		}
 		d.serializer, err = colserde.NewFileSerializer(writer, d.typs)
 		if err != nil {
 			return err
@@ -350,6 +361,7 @@ func (d *diskQueue) writeFooterAndFlush() error {
 		return err
 	}
 	d.numBufferedBatches = 0
+	d.numBytesWritten += written
 	// Append offset for the readers.
 	d.files[d.writeFileIdx].totalSize += written
 	d.files[d.writeFileIdx].offsets = append(d.files[d.writeFileIdx].offsets, d.files[d.writeFileIdx].totalSize)
@@ -457,7 +469,7 @@ func (d *diskQueue) maybeInitDeserializer() (bool, error) {
 	compressedBytes := d.writer.scratch.compressedBuf[1:]
 	var decompressedBytes []byte
 	if blockType == snappyCompressedBlock {
-		decompressedBytes, err = snappy.Decode(d.scratchDecompressedReadBytes, compressedBytes)
+		decompressedBytes, err = colserde.Decompress(d.scratchDecompressedReadBytes, compressedBytes)
 		if err != nil {
 			return false, err
 		}
@@ -53,7 +53,7 @@ func TestDiskQueue(t *testing.T) {
 					BatchSize:     1 + rng.Intn(int(coldata.BatchSize())),
 					Nulls:         true,
 					BatchAccumulator: func(b coldata.Batch) {
-						batches = append(batches, colexec.CopyBatch(testAllocator, b))
+						batches = append(batches, testAllocator.CloneBatch(b))
 					},
 				})
 				typs := op.Typs()
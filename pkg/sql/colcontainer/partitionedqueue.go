@@ -0,0 +1,195 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colcontainer
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+)
+
+// PartitionedQueue is the abstraction for on-disk storage of several
+// partitions of coldata.Batches that is shared by the operators that spill to
+// disk (e.g. the external sorter and the external hash-based operators).
+type PartitionedQueue interface {
+	// Enqueue adds the batch to the end of the partitionIdx'th partition. A
+	// zero-length batch is a no-op.
+	Enqueue(partitionIdx int, batch coldata.Batch) error
+	// Dequeue removes and returns the batch from the front of the
+	// partitionIdx'th partition. A zero-length batch is returned once all of
+	// the batches of the partition have been dequeued. A partition cannot be
+	// enqueued into once it has been dequeued from.
+	Dequeue(partitionIdx int, batch coldata.Batch) error
+	// Close closes all of the partitions and releases the resources associated
+	// with them.
+	Close() error
+}
+
+// PartitionedDiskQueue is an implementation of PartitionedQueue that stores
+// every partition in a separate diskQueue, so the batches are compressed
+// before being written to disk. The bytes written to disk are registered with
+// a disk account. It is the on-disk storage of all of the vectorized operators
+// that spill to disk.
+// Note that every partition buffers up to cfg.BufferSizeBytes in memory, so
+// the number of partitions should be kept small.
+type PartitionedDiskQueue struct {
+	ctx     context.Context
+	typs    []coltypes.T
+	cfg     DiskQueueCfg
+	diskAcc *mon.BoundAccount
+
+	partitions []*partition
+	// scratch is used to remove the selection vector from the batches before
+	// they are enqueued since the diskQueue ignores selection vectors.
+	scratch coldata.Batch
+}
+
+var _ PartitionedQueue = &PartitionedDiskQueue{}
+
+// partition is a single partition of the PartitionedDiskQueue.
+type partition struct {
+	queue *diskQueue
+	// numBytesAccounted is the number of bytes written by queue that have been
+	// registered with the disk account.
+	numBytesAccounted int
+	// dequeuing is true once the partition has been dequeued from.
+	dequeuing bool
+}
+
+// NewPartitionedDiskQueue returns a new PartitionedDiskQueue that stores the
+// batches of typs according to cfg. All of the bytes written to disk are
+// registered with diskAcc, which is closed when the queue is closed.
+func NewPartitionedDiskQueue(
+	ctx context.Context, typs []coltypes.T, cfg DiskQueueCfg, diskAcc *mon.BoundAccount,
+) (*PartitionedDiskQueue, error) {
+	if err := cfg.EnsureDefaults(); err != nil {
+		return nil, err
+	}
+	return &PartitionedDiskQueue{
+		ctx:     ctx,
+		typs:    typs,
+		cfg:     cfg,
+		diskAcc: diskAcc,
+	}, nil
+}
+
+// Enqueue is part of the PartitionedQueue interface.
+func (p *PartitionedDiskQueue) Enqueue(partitionIdx int, batch coldata.Batch) error {
+	if batch.Length() == 0 {
+		return nil
+	}
+	if len(p.partitions) <= partitionIdx {
+		p.partitions = append(p.partitions, make([]*partition, partitionIdx-len(p.partitions)+1)...)
+	}
+	part := p.partitions[partitionIdx]
+	if part == nil {
+		q, err := newDiskQueue(p.typs, p.cfg)
+		if err != nil {
+			return err
+		}
+		part = &partition{queue: q}
+		p.partitions[partitionIdx] = part
+	}
+	if part.dequeuing {
+		return errors.AssertionFailedf("partition %d is enqueued into after it has been dequeued from", partitionIdx)
+	}
+	if sel := batch.Selection(); sel != nil {
+		if p.scratch == nil {
+			p.scratch = coldata.NewMemBatch(p.typs)
+		}
+		p.scratch.ResetInternalBatch()
+		for i, vec := range p.scratch.ColVecs() {
+			vec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						ColType:     p.typs[i],
+						Src:         batch.ColVec(i),
+						Sel:         sel,
+						SrcStartIdx: 0,
+						SrcEndIdx:   uint64(batch.Length()),
+					},
+				},
+			)
+		}
+		p.scratch.SetLength(batch.Length())
+		batch = p.scratch
+	}
+	if err := part.queue.Enqueue(batch); err != nil {
+		return err
+	}
+	return p.accountForWrites(part)
+}
+
+// Dequeue is part of the PartitionedQueue interface.
+func (p *PartitionedDiskQueue) Dequeue(partitionIdx int, batch coldata.Batch) error {
+	var part *partition
+	if partitionIdx < len(p.partitions) {
+		part = p.partitions[partitionIdx]
+	}
+	if part == nil {
+		return errors.Newf("partition %d not found (len(partitions) = %d)", partitionIdx, len(p.partitions))
+	}
+	if !part.dequeuing {
+		// This is the first read from the partition, so we need to tell the
+		// queue that no more batches will be enqueued. This flushes all of the
+		// buffered batches to disk.
+		if err := part.queue.Enqueue(coldata.ZeroBatch); err != nil {
+			return err
+		}
+		if err := p.accountForWrites(part); err != nil {
+			return err
+		}
+		part.dequeuing = true
+	}
+	ok, err := part.queue.Dequeue(batch)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.AssertionFailedf("partition %d is unexpectedly empty after having been finalized", partitionIdx)
+	}
+	return nil
+}
+
+// accountForWrites registers the bytes written to disk by the queue of part
+// since the last call with the disk account.
+func (p *PartitionedDiskQueue) accountForWrites(part *partition) error {
+	delta := part.queue.numBytesWritten - part.numBytesAccounted
+	if delta == 0 {
+		return nil
+	}
+	if err := p.diskAcc.Grow(p.ctx, int64(delta)); err != nil {
+		return err
+	}
+	part.numBytesAccounted = part.queue.numBytesWritten
+	return nil
+}
+
+// Close is part of the PartitionedQueue interface. It closes all of the
+// partitions, which removes their files, and releases all of the disk space
+// registered with the disk account.
+func (p *PartitionedDiskQueue) Close() error {
+	var retErr error
+	for _, part := range p.partitions {
+		if part == nil {
+			continue
+		}
+		if err := part.queue.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}
+	p.partitions = nil
+	p.diskAcc.Close(p.ctx)
+	return retErr
+}
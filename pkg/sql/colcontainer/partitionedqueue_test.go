@@ -0,0 +1,111 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colcontainer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedDiskQueue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, cluster.MakeTestingClusterSettings())
+	defer diskMonitor.Stop(ctx)
+
+	queueCfg, cleanup := colcontainer.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	rng, _ := randutil.NewPseudoRand()
+	queueCfg.TestingKnobs.AlwaysCompress = rng.Float64() < 0.5
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	diskAcc := diskMonitor.MakeBoundAccount()
+	p, err := colcontainer.NewPartitionedDiskQueue(ctx, typs, queueCfg, &diskAcc)
+	require.NoError(t, err)
+
+	const numPartitions = 3
+	// expected contains the values of the first column of the tuples that have
+	// been enqueued into each of the partitions (the second column is derived
+	// from the first one).
+	expected := make([][]int64, numPartitions)
+	for i := 0; i < 10; i++ {
+		partitionIdx := rng.Intn(numPartitions)
+		batch := testAllocator.NewMemBatch(typs)
+		n := 1 + rng.Intn(int(coldata.BatchSize()))
+		ints, bytes := batch.ColVec(0).Int64(), batch.ColVec(1).Bytes()
+		for j := 0; j < n; j++ {
+			ints[j] = rng.Int63()
+			bytes.Set(j, []byte(fmt.Sprintf("%d", ints[j])))
+		}
+		batch.SetLength(uint16(n))
+		if rng.Float64() < 0.5 {
+			// Select every other tuple.
+			batch.SetSelection(true)
+			sel := batch.Selection()
+			selLen := 0
+			for j := 0; j < n; j += 2 {
+				sel[selLen] = j
+				selLen++
+			}
+			batch.SetLength(uint16(selLen))
+		}
+		for j := 0; j < int(batch.Length()); j++ {
+			idx := j
+			if sel := batch.Selection(); sel != nil {
+				idx = sel[j]
+			}
+			expected[partitionIdx] = append(expected[partitionIdx], ints[idx])
+		}
+		require.NoError(t, p.Enqueue(partitionIdx, batch))
+	}
+
+	for partitionIdx := 0; partitionIdx < numPartitions; partitionIdx++ {
+		if len(expected[partitionIdx]) == 0 {
+			require.Error(t, p.Dequeue(partitionIdx, coldata.NewMemBatch(typs)))
+			continue
+		}
+		var actual []int64
+		batch := coldata.NewMemBatch(typs)
+		for {
+			require.NoError(t, p.Dequeue(partitionIdx, batch))
+			if batch.Length() == 0 {
+				break
+			}
+			ints, bytes := batch.ColVec(0).Int64(), batch.ColVec(1).Bytes()
+			for j := 0; j < int(batch.Length()); j++ {
+				require.Equal(t, fmt.Sprintf("%d", ints[j]), string(bytes.Get(j)))
+				actual = append(actual, ints[j])
+			}
+		}
+		require.Equal(t, expected[partitionIdx], actual)
+		// The partition cannot be enqueued into once it has been dequeued from.
+		batch.SetLength(1)
+		require.Error(t, p.Enqueue(partitionIdx, batch))
+	}
+	require.True(t, diskAcc.Used() > 0)
+
+	require.NoError(t, p.Close())
+	require.Equal(t, int64(0), diskAcc.Used())
+	// Verify no directories are left over.
+	directories, err := queueCfg.FS.ListDir(queueCfg.Path)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(directories))
+}
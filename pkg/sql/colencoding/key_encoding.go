@@ -15,7 +15,9 @@ import (
 
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
@@ -33,6 +35,7 @@ import (
 // for. The input key will also be mutated if matches is false.
 // See the analog in sqlbase/index_encoding.go.
 func DecodeIndexKeyToCols(
+	da *sqlbase.DatumAlloc,
 	vecs []coldata.Vec,
 	idx uint16,
 	desc *sqlbase.ImmutableTableDescriptor,
@@ -70,8 +73,8 @@ func DecodeIndexKeyToCols(
 			// We don't care about whether this call to DecodeKeyVals found a null or not, because
 			// it is a interleaving ancestor.
 			var isNull bool
-			key, isNull, err = DecodeKeyValsToCols(vecs, idx, indexColIdx[:length], types[:length], colDirs[:length],
-				nil /* unseen */, key)
+			key, isNull, err = DecodeKeyValsToCols(da, vecs, idx, indexColIdx[:length], types[:length],
+				colDirs[:length], nil /* unseen */, key)
 			if err != nil {
 				return nil, false, false, err
 			}
@@ -104,7 +107,7 @@ func DecodeIndexKeyToCols(
 	}
 
 	var isNull bool
-	key, isNull, err = DecodeKeyValsToCols(vecs, idx, indexColIdx, types, colDirs, nil /* unseen */, key)
+	key, isNull, err = DecodeKeyValsToCols(da, vecs, idx, indexColIdx, types, colDirs, nil /* unseen */, key)
 	if err != nil {
 		return nil, false, false, err
 	}
@@ -132,6 +135,7 @@ func DecodeIndexKeyToCols(
 // See the analog in sqlbase/index_encoding.go.
 // DecodeKeyValsToCols additionally returns whether a NULL was encountered when decoding.
 func DecodeKeyValsToCols(
+	da *sqlbase.DatumAlloc,
 	vecs []coldata.Vec,
 	idx uint16,
 	indexColIdx []int,
@@ -156,7 +160,7 @@ func DecodeKeyValsToCols(
 				unseen.Remove(i)
 			}
 			var isNull bool
-			key, isNull, err = decodeTableKeyToCol(vecs[i], idx, &types[j], key, enc)
+			key, isNull, err = decodeTableKeyToCol(da, vecs[i], idx, &types[j], key, enc)
 			foundNull = isNull || foundNull
 		}
 		if err != nil {
@@ -171,7 +175,12 @@ func DecodeKeyValsToCols(
 // See the analog, DecodeTableKey, in sqlbase/column_type_encoding.go.
 // decodeTableKeyToCol also returns whether or not the decoded value was NULL.
 func decodeTableKeyToCol(
-	vec coldata.Vec, idx uint16, valType *types.T, key []byte, dir sqlbase.IndexDescriptor_Direction,
+	da *sqlbase.DatumAlloc,
+	vec coldata.Vec,
+	idx uint16,
+	valType *types.T,
+	key []byte,
+	dir sqlbase.IndexDescriptor_Direction,
 ) ([]byte, bool, error) {
 	if (dir != sqlbase.IndexDescriptor_ASC) && (dir != sqlbase.IndexDescriptor_DESC) {
 		return nil, false, errors.AssertionFailedf("invalid direction: %d", log.Safe(dir))
@@ -181,6 +190,17 @@ func decodeTableKeyToCol(
 		vec.Nulls().SetNull(idx)
 		return key, true, nil
 	}
+	if vec.Type() == coltypes.Datum {
+		encDir, err := dir.ToEncodingDirection()
+		if err != nil {
+			return nil, false, err
+		}
+		d, rkey, err := sqlbase.DecodeTableKey(da, valType, key, encDir)
+		if err == nil {
+			vec.Datum().Set(int(idx), d)
+		}
+		return rkey, false, err
+	}
 	var rkey []byte
 	var err error
 	switch valType.Family() {
@@ -223,7 +243,7 @@ func decodeTableKeyToCol(
 			rkey, d, err = encoding.DecodeDecimalDescending(key, nil)
 		}
 		vec.Decimal()[idx] = d
-	case types.BytesFamily, types.StringFamily, types.UuidFamily:
+	case types.BytesFamily, types.StringFamily, types.UuidFamily, types.INetFamily:
 		var r []byte
 		if dir == sqlbase.IndexDescriptor_ASC {
 			rkey, r, err = encoding.DecodeBytesAscending(key, nil)
@@ -231,6 +251,15 @@ func decodeTableKeyToCol(
 			rkey, r, err = encoding.DecodeBytesDescending(key, nil)
 		}
 		vec.Bytes().Set(int(idx), r)
+	case types.CollatedStringFamily:
+		// The key contains only the collation key which can't be decoded, so
+		// the contents of the collated strings are always decoded from the
+		// value since such columns are composite.
+		if dir == sqlbase.IndexDescriptor_ASC {
+			rkey, _, err = encoding.DecodeBytesAscending(key, nil)
+		} else {
+			rkey, _, err = encoding.DecodeBytesDescending(key, nil)
+		}
 	case types.DateFamily, types.OidFamily:
 		var t int64
 		if dir == sqlbase.IndexDescriptor_ASC {
@@ -264,14 +293,22 @@ func decodeTableKeyToCol(
 // UnmarshalColumnValueToCol decodes the value from a roachpb.Value using the
 // type expected by the column, writing into the input Vec at the given row
 // idx. An error is returned if the value's type does
-// not match the column's type.
+// not match the column's type. da is only used for the types without a native
+// columnar representation.
 // See the analog, UnmarshalColumnValue, in sqlbase/column_type_encoding.go
 func UnmarshalColumnValueToCol(
-	vec coldata.Vec, idx uint16, typ *types.T, value roachpb.Value,
+	da *sqlbase.DatumAlloc, vec coldata.Vec, idx uint16, typ *types.T, value roachpb.Value,
 ) error {
 	if value.RawBytes == nil {
 		vec.Nulls().SetNull(idx)
 	}
+	if vec.Type() == coltypes.Datum {
+		d, err := sqlbase.UnmarshalColumnValue(da, typ, value)
+		if err == nil && d != tree.DNull {
+			vec.Datum().Set(int(idx), d)
+		}
+		return err
+	}
 
 	var err error
 	switch typ.Family() {
@@ -298,7 +335,8 @@ func UnmarshalColumnValueToCol(
 		vec.Float64()[idx] = v
 	case types.DecimalFamily:
 		err = value.GetDecimalInto(&vec.Decimal()[idx])
-	case types.BytesFamily, types.StringFamily, types.UuidFamily:
+	case types.BytesFamily, types.StringFamily, types.UuidFamily, types.INetFamily,
+		types.JsonFamily, types.CollatedStringFamily:
 		var v []byte
 		v, err = value.GetBytes()
 		vec.Bytes().Set(int(idx), v)
@@ -314,6 +352,12 @@ func UnmarshalColumnValueToCol(
 		var v duration.Duration
 		v, err = value.GetDuration()
 		vec.Interval()[idx] = v
+	case types.ArrayFamily:
+		var v []byte
+		v, err = value.GetBytes()
+		if err == nil {
+			_, err = decodeArrayToCol(vec, int(idx), typ.ArrayContents(), v)
+		}
 	default:
 		return errors.AssertionFailedf("unsupported column type: %s", log.Safe(typ.Family()))
 	}
@@ -14,30 +14,48 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
 // DecodeTableValueToCol decodes a value encoded by EncodeTableValue, writing
-// the result to the idx'th position of the input exec.Vec.
+// the result to the idx'th position of the input exec.Vec. da is only used for
+// the types without a native columnar representation.
 // See the analog in sqlbase/column_type_encoding.go.
 func DecodeTableValueToCol(
-	vec coldata.Vec, idx uint16, typ encoding.Type, dataOffset int, valTyp *types.T, b []byte,
+	da *sqlbase.DatumAlloc,
+	vec coldata.Vec,
+	idx uint16,
+	typ encoding.Type,
+	dataOffset int,
+	valTyp *types.T,
+	b []byte,
 ) ([]byte, error) {
 	// NULL is special because it is a valid value for any type.
 	if typ == encoding.Null {
 		vec.Nulls().SetNull(idx)
 		return b[dataOffset:], nil
 	}
+	if vec.Type() == coltypes.Datum {
+		d, rem, err := sqlbase.DecodeTableValue(da, valTyp, b)
+		if err == nil {
+			vec.Datum().Set(int(idx), d)
+		}
+		return rem, err
+	}
 	// Bool is special because the value is stored in the value tag.
 	if valTyp.Family() != types.BoolFamily {
 		b = b[dataOffset:]
 	}
-	return decodeUntaggedDatumToCol(vec, idx, valTyp, b)
+	return decodeUntaggedDatumToCol(vec, int(idx), valTyp, b)
 }
 
 // decodeUntaggedDatum is used to decode a Datum whose type is known,
@@ -50,7 +68,7 @@ func DecodeTableValueToCol(
 // If t is types.Bool, the value tag must be present, as its value is encoded in
 // the tag directly.
 // See the analog in sqlbase/column_type_encoding.go.
-func decodeUntaggedDatumToCol(vec coldata.Vec, idx uint16, t *types.T, buf []byte) ([]byte, error) {
+func decodeUntaggedDatumToCol(vec coldata.Vec, idx int, t *types.T, buf []byte) ([]byte, error) {
 	var err error
 	switch t.Family() {
 	case types.BoolFamily:
@@ -59,10 +77,13 @@ func decodeUntaggedDatumToCol(vec coldata.Vec, idx uint16, t *types.T, buf []byt
 		// "Untagged" version of this function.
 		buf, b, err = encoding.DecodeBoolValue(buf)
 		vec.Bool()[idx] = b
-	case types.BytesFamily, types.StringFamily:
+	case types.BytesFamily, types.StringFamily, types.JsonFamily, types.CollatedStringFamily:
+		// JSONB values are value-encoded as bytes containing the binary
+		// encoding of the JSON, which is exactly how they're stored in the
+		// vector. Collated strings are value-encoded as their contents.
 		var data []byte
 		buf, data, err = encoding.DecodeUntaggedBytesValue(buf)
-		vec.Bytes().Set(int(idx), data)
+		vec.Bytes().Set(idx, data)
 	case types.DateFamily, types.OidFamily:
 		var i int64
 		buf, i, err = encoding.DecodeUntaggedIntValue(buf)
@@ -92,7 +113,17 @@ func decodeUntaggedDatumToCol(vec coldata.Vec, idx uint16, t *types.T, buf []byt
 		// TODO(yuzefovich): we could peek inside the encoding package to skip a
 		// couple of conversions.
 		if err == nil {
-			vec.Bytes().Set(int(idx), data.GetBytes())
+			vec.Bytes().Set(idx, data.GetBytes())
+		}
+	case types.INetFamily:
+		// The untagged value encoding of an IP address is the same as its
+		// physical representation, so we only need to find out its length.
+		var ipAddr ipaddr.IPAddr
+		var remaining []byte
+		remaining, err = ipAddr.FromBuffer(buf)
+		if err == nil {
+			vec.Bytes().Set(idx, buf[:len(buf)-len(remaining)])
+			buf = remaining
 		}
 	case types.TimestampFamily, types.TimestampTZFamily:
 		var t time.Time
@@ -102,9 +133,44 @@ func decodeUntaggedDatumToCol(vec coldata.Vec, idx uint16, t *types.T, buf []byt
 		var d duration.Duration
 		buf, d, err = encoding.DecodeUntaggedDurationValue(buf)
 		vec.Interval()[idx] = d
+	case types.ArrayFamily:
+		// Skip the length of the encoded array.
+		buf, _, _, err = encoding.DecodeNonsortingUvarint(buf)
+		if err == nil {
+			buf, err = decodeArrayToCol(vec, idx, t.ArrayContents(), buf)
+		}
 	default:
 		return buf, errors.AssertionFailedf(
 			"couldn't decode type: %s", log.Safe(t))
 	}
 	return buf, err
 }
+
+// decodeArrayToCol decodes the value encoding of an array (without the
+// MarshalColumnValue prefix) with elements of type elemTyp, writing the result
+// to the idx'th position of the input Vec of arrays.
+// See the analog, decodeArrayNoMarshalColumnValue, in
+// sqlbase/column_type_encoding.go.
+func decodeArrayToCol(vec coldata.Vec, idx int, elemTyp *types.T, buf []byte) ([]byte, error) {
+	length, isNull, buf, err := sqlbase.DecodeArrayHeader(buf)
+	if err != nil {
+		return buf, err
+	}
+	arrays := vec.Array()
+	if length > 0 {
+		arrays.SetElemType(typeconv.FromColumnType(elemTyp))
+	}
+	start := arrays.Set(idx, int(length))
+	child := arrays.Child()
+	for i := uint64(0); i < length; i++ {
+		if isNull(i) {
+			child.Nulls().SetNull64(uint64(start) + i)
+			continue
+		}
+		buf, err = decodeUntaggedDatumToCol(child, start+int(i), elemTyp, buf)
+		if err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
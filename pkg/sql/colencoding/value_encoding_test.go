@@ -51,13 +51,14 @@ func TestDecodeTableValueToCol(t *testing.T) {
 		}
 	}
 	batch := coldata.NewMemBatchWithSize(typs, 1)
+	var da sqlbase.DatumAlloc
 	for i := 0; i < nCols; i++ {
 		typeOffset, dataOffset, _, typ, err := encoding.DecodeValueTag(buf)
 		fmt.Println(typ)
 		if err != nil {
 			t.Fatal(err)
 		}
-		buf, err = DecodeTableValueToCol(batch.ColVec(i), 0 /* rowIdx */, typ,
+		buf, err = DecodeTableValueToCol(&da, batch.ColVec(i), 0 /* rowIdx */, typ,
 			dataOffset, colTyps[i], buf[typeOffset:])
 		if err != nil {
 			t.Fatal(err)
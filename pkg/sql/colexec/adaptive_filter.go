@@ -0,0 +1,227 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// adaptiveFilterOrderingEnabled determines whether the conjuncts of a filter
+// are evaluated by an adaptiveAndSelOp instead of a static chain of selection
+// operators.
+var adaptiveFilterOrderingEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.vectorize_adaptive_filter_ordering.enabled",
+	"if set, the vectorized engine reorders the conjuncts of filters based on "+
+		"their observed selectivity and cost",
+	false,
+)
+
+// adaptiveFilterReorderInterval is the number of input batches after which
+// adaptiveAndSelOp reconsiders the order of its conjuncts.
+const adaptiveFilterReorderInterval = 8
+
+// flattenAndExpr appends the conjuncts of expr to conjuncts and returns the
+// result.
+func flattenAndExpr(expr tree.TypedExpr, conjuncts []tree.TypedExpr) []tree.TypedExpr {
+	if and, ok := expr.(*tree.AndExpr); ok {
+		conjuncts = flattenAndExpr(and.TypedLeft(), conjuncts)
+		return flattenAndExpr(and.TypedRight(), conjuncts)
+	}
+	return append(conjuncts, expr)
+}
+
+// planAdaptiveAndSelection plans the selection operators for every conjunct
+// separately and returns an adaptiveAndSelOp that evaluates them on the
+// batches from input.
+func planAdaptiveAndSelection(
+	ctx context.Context,
+	evalCtx *tree.EvalContext,
+	conjuncts []tree.TypedExpr,
+	columnTypes []types.T,
+	input Operator,
+	acc *mon.BoundAccount,
+) (op Operator, resultIdx int, ct []types.T, internalMemUsed int, err error) {
+	ct = columnTypes
+	feeds := make([]*selProjFeedOp, len(conjuncts))
+	ops := make([]Operator, len(conjuncts))
+	for i, conjunct := range conjuncts {
+		var conjunctInternalMem int
+		feeds[i] = newSelProjFeedOp(input)
+		// Every conjunct is planned with the column types that include the
+		// projections of the previous ones, so that the projections of all of
+		// the conjuncts use different columns regardless of the order in which
+		// the conjuncts are evaluated.
+		ops[i], _, ct, conjunctInternalMem, err = planSelectionOperators(
+			ctx, evalCtx, conjunct, ct, feeds[i], acc,
+		)
+		if err != nil {
+			return nil, -1, ct, internalMemUsed, err
+		}
+		internalMemUsed += conjunctInternalMem
+	}
+	return newAdaptiveAndSelOp(input, feeds, ops), -1, ct, internalMemUsed, nil
+}
+
+// adaptiveAndSelOp selects the tuples for which all of its conjuncts are true.
+// Every conjunct is a chain of selection operators planned on top of its own
+// selProjFeedOp, and the conjuncts are applied one after another to every
+// input batch. The operator keeps track of the fraction of the tuples that
+// every conjunct lets through and of the time it takes per tuple, and
+// periodically reorders the conjuncts so that the ones that filter out the
+// most tuples per unit of time are evaluated first. The statistics decay over
+// time, so the order adapts to the changes in the data.
+type adaptiveAndSelOp struct {
+	OneInputNode
+
+	conjuncts []adaptiveConjunct
+	// order contains the indices of the conjuncts in the order in which they
+	// are evaluated.
+	order       []int
+	batchesSeen int
+}
+
+var _ Operator = &adaptiveAndSelOp{}
+var _ Closer = &adaptiveAndSelOp{}
+
+// adaptiveConjunct is a single conjunct of an adaptiveAndSelOp along with the
+// statistics of its evaluation.
+type adaptiveConjunct struct {
+	feed *selProjFeedOp
+	op   Operator
+
+	tuplesIn  float64
+	tuplesOut float64
+	elapsed   float64
+}
+
+// rank returns the expected cost of the conjunct per tuple that it filters
+// out. The conjuncts with a smaller rank should be evaluated first.
+func (c *adaptiveConjunct) rank() float64 {
+	if c.tuplesIn == 0 {
+		// The conjunct hasn't been evaluated yet, so we optimistically put it
+		// first in order to measure it.
+		return 0
+	}
+	filtered := c.tuplesIn - c.tuplesOut
+	if filtered == 0 {
+		return math.Inf(1)
+	}
+	return c.elapsed / filtered
+}
+
+// newAdaptiveAndSelOp returns a new adaptiveAndSelOp that evaluates the
+// conjuncts ops, the ith of which has been planned on top of feeds[i], on the
+// batches from input.
+func newAdaptiveAndSelOp(input Operator, feeds []*selProjFeedOp, ops []Operator) Operator {
+	op := &adaptiveAndSelOp{
+		OneInputNode: NewOneInputNode(input),
+		conjuncts:    make([]adaptiveConjunct, len(ops)),
+		order:        make([]int, len(ops)),
+	}
+	for i := range ops {
+		op.conjuncts[i] = adaptiveConjunct{feed: feeds[i], op: ops[i]}
+		op.order[i] = i
+	}
+	return op
+}
+
+// ChildCount is part of the execinfra.OpNode interface. The conjuncts are
+// exposed as the children of the operator in the order in which they were
+// planned (the feeds expose the input as their child).
+func (a *adaptiveAndSelOp) ChildCount(verbose bool) int {
+	return len(a.conjuncts)
+}
+
+// Child is part of the execinfra.OpNode interface.
+func (a *adaptiveAndSelOp) Child(nth int, verbose bool) execinfra.OpNode {
+	if nth < len(a.conjuncts) {
+		return a.conjuncts[nth].op
+	}
+	execerror.VectorizedInternalPanic(fmt.Sprintf("invalid index %d", nth))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func (a *adaptiveAndSelOp) Init() {
+	a.input.Init()
+	for i := range a.conjuncts {
+		a.conjuncts[i].op.Init()
+	}
+}
+
+func (a *adaptiveAndSelOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := a.input.Next(ctx)
+		if batch.Length() == 0 {
+			return coldata.ZeroBatch
+		}
+		a.batchesSeen++
+		if a.batchesSeen%adaptiveFilterReorderInterval == 0 {
+			a.reorder()
+		}
+		for _, i := range a.order {
+			c := &a.conjuncts[i]
+			c.feed.batch = batch
+			c.feed.nexted = false
+			tuplesIn := batch.Length()
+			start := timeutil.Now()
+			batch = c.op.Next(ctx)
+			c.elapsed += float64(timeutil.Since(start))
+			c.tuplesIn += float64(tuplesIn)
+			c.tuplesOut += float64(batch.Length())
+			if batch.Length() == 0 {
+				if !c.feed.nexted {
+					// The conjunct returned a zero-length batch without reading
+					// the input batch (e.g. it is always false), so no tuples will
+					// ever be selected.
+					return coldata.ZeroBatch
+				}
+				break
+			}
+		}
+		if batch.Length() > 0 {
+			return batch
+		}
+		// All of the tuples of the input batch have been filtered out, so we
+		// move onto the next one.
+	}
+}
+
+// reorder sorts the conjuncts by their rank and decays their statistics so
+// that the recent batches have more influence on the next order.
+func (a *adaptiveAndSelOp) reorder() {
+	sort.SliceStable(a.order, func(i, j int) bool {
+		return a.conjuncts[a.order[i]].rank() < a.conjuncts[a.order[j]].rank()
+	})
+	for i := range a.conjuncts {
+		c := &a.conjuncts[i]
+		c.tuplesIn /= 2
+		c.tuplesOut /= 2
+		c.elapsed /= 2
+	}
+}
+
+// Close is part of the Closer interface.
+func (a *adaptiveAndSelOp) Close() error {
+	return closeInput(a.input)
+}
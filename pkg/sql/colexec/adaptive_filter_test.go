@@ -0,0 +1,109 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveAndSelOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	adaptiveFilterOrderingEnabled.Override(&st.SV, true)
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	col := func(idx int) tree.TypedExpr {
+		return tree.NewTypedOrdinalReference(idx, types.Int)
+	}
+	cmp := func(op tree.ComparisonOperator, left tree.TypedExpr, right int) tree.TypedExpr {
+		return tree.NewTypedComparisonExpr(op, left, tree.NewDInt(tree.DInt(right)))
+	}
+	// @1 >= 0 AND (@2 = 0 OR @3 = 1) AND @3 < 3
+	expr := tree.NewTypedAndExpr(
+		tree.NewTypedAndExpr(
+			cmp(tree.GE, col(0), 0),
+			tree.NewTypedOrExpr(cmp(tree.EQ, col(1), 0), cmp(tree.EQ, col(2), 1)),
+		),
+		cmp(tree.LT, col(2), 3),
+	)
+	typs := []types.T{*types.Int, *types.Int, *types.Int}
+
+	t.Run("Correctness", func(t *testing.T) {
+		runTests(
+			t,
+			[]tuples{{
+				{0, 0, 0},
+				{1, 1, 1},
+				{2, 1, 2},
+				{-1, 0, 0},
+				{3, 0, 5},
+				{4, nil, 1},
+				{5, 0, nil},
+				{6, 0, 2},
+			}},
+			tuples{{0, 0, 0}, {1, 1, 1}, {4, nil, 1}, {6, 0, 2}},
+			orderedVerifier,
+			func(input []Operator) (Operator, error) {
+				op, _, ct, _, err := planSelectionOperators(
+					ctx, &evalCtx, expr, typs, input[0], testMemAcc,
+				)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := op.(*adaptiveAndSelOp); !ok {
+					t.Fatalf("expected an adaptiveAndSelOp, found %T", op)
+				}
+				return NewSimpleProjectOp(op, len(ct), []uint32{0, 1, 2}), nil
+			},
+		)
+	})
+
+	t.Run("Reordering", func(t *testing.T) {
+		// The first conjunct is true for all of the tuples while the second one
+		// is true only for a tenth of them, so the second one should be moved
+		// first.
+		n := int(coldata.BatchSize())
+		batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64, coltypes.Int64})
+		for i := 0; i < n; i++ {
+			batch.ColVec(0).Int64()[i] = int64(i)
+			batch.ColVec(1).Int64()[i] = int64(i % 10)
+		}
+		batch.SetLength(uint16(n))
+		source := NewRepeatableBatchSource(batch)
+		source.ResetBatchesToReturn(4 * adaptiveFilterReorderInterval)
+		op, _, _, _, err := planAdaptiveAndSelection(
+			ctx, &evalCtx,
+			[]tree.TypedExpr{cmp(tree.GE, col(0), 0), cmp(tree.EQ, col(1), 0)},
+			typs[:2], source, testMemAcc,
+		)
+		require.NoError(t, err)
+		adaptiveOp := op.(*adaptiveAndSelOp)
+		require.Equal(t, []int{0, 1}, adaptiveOp.order)
+		op.Init()
+		numTuples := 0
+		for b := op.Next(ctx); b.Length() > 0; b = op.Next(ctx) {
+			numTuples += int(b.Length())
+		}
+		require.Equal(t, 4*adaptiveFilterReorderInterval*((n+9)/10), numTuples)
+		require.Equal(t, []int{1, 0}, adaptiveOp.order)
+	})
+}
@@ -34,6 +34,8 @@ var SupportedAggFns = []execinfrapb.AggregatorSpec_Func{
 	execinfrapb.AggregatorSpec_MAX,
 	execinfrapb.AggregatorSpec_BOOL_AND,
 	execinfrapb.AggregatorSpec_BOOL_OR,
+	execinfrapb.AggregatorSpec_CONCAT_AGG,
+	execinfrapb.AggregatorSpec_STRING_AGG,
 }
 
 // aggregateFunc is an aggregate function that performs computation on a batch
@@ -144,6 +146,9 @@ type orderedAggregator struct {
 	aggregateFuncs []aggregateFunc
 	// isScalar indicates whether an aggregator is in scalar context.
 	isScalar bool
+	// singleGroup indicates whether there are no grouping columns, so at most
+	// one group is output.
+	singleGroup bool
 	// seenNonEmptyBatch indicates whether a non-empty input batch has been
 	// observed.
 	seenNonEmptyBatch bool
@@ -209,6 +214,8 @@ func NewOrderedAggregator(
 		aggTypes:  aggTypes,
 		groupCol:  groupCol,
 		isScalar:  isScalar,
+
+		singleGroup: len(groupCols) == 0,
 	}
 
 	a.aggregateFuncs, a.outputTypes, err = makeAggregateFuncs(a.allocator, aggTypes, aggFns)
@@ -249,6 +256,8 @@ func makeAggregateFuncs(
 			funcs[i] = newBoolAndAgg()
 		case execinfrapb.AggregatorSpec_BOOL_OR:
 			funcs[i] = newBoolOrAgg()
+		case execinfrapb.AggregatorSpec_CONCAT_AGG, execinfrapb.AggregatorSpec_STRING_AGG:
+			funcs[i], err = newConcatAgg(allocator, aggTyps[i][0])
 		default:
 			return nil, nil, errors.Errorf("unsupported columnar aggregate function %s", aggFns[i].String())
 		}
@@ -292,6 +301,12 @@ func (a *orderedAggregator) initWithInputAndOutputBatchSize(inputSize, outputSiz
 }
 
 func (a *orderedAggregator) Init() {
+	if a.singleGroup {
+		// Every input batch contributes to at most one (the same) group, so
+		// full-sized batches are not needed.
+		a.initWithInputAndOutputBatchSize(1 /* inputSize */, 1 /* outputSize */)
+		return
+	}
 	a.initWithInputAndOutputBatchSize(int(coldata.BatchSize()), int(coldata.BatchSize()))
 }
 
@@ -464,6 +479,17 @@ func isAggregateSupported(
 			// issues, at first, we could plan SUM for all types besides Int64.
 			return false, errors.Newf("sum on int cols not supported (use sum_int)")
 		}
+	case execinfrapb.AggregatorSpec_MIN, execinfrapb.AggregatorSpec_MAX:
+		if inputTypes[0].Family() == types.JsonFamily {
+			// JSONB values are stored using their binary encoding which doesn't
+			// preserve the ordering.
+			return false, errors.Newf("%s on JSONB is not supported", aggFn)
+		}
+		if inputTypes[0].Family() == types.CollatedStringFamily {
+			// Collated strings are stored using their contents which don't
+			// preserve the ordering defined by the collation.
+			return false, errors.Newf("%s on collated strings is not supported", aggFn)
+		}
 	case execinfrapb.AggregatorSpec_SUM_INT:
 		// TODO(yuzefovich): support this case through vectorize.
 		if inputTypes[0].Width() != 64 {
@@ -19,7 +19,11 @@ import (
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
@@ -899,6 +903,153 @@ func TestHashAggregator(t *testing.T) {
 	}
 }
 
+func TestPartialHashAggregator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+	const (
+		numGroups = 10
+		numRows   = 200
+	)
+	input := make(tuples, numRows)
+	sums := make([]int64, numGroups)
+	counts := make([]int64, numGroups)
+	for i := range input {
+		group, val := rng.Intn(numGroups), rng.Int63n(100)
+		input[i] = tuple{group, val}
+		sums[group] += val
+		counts[group]++
+	}
+	var expected tuples
+	for group := range sums {
+		if counts[group] > 0 {
+			expected = append(expected, tuple{group, sums[group], counts[group]})
+		}
+	}
+	typs := []coltypes.T{coltypes.Int64, coltypes.Int64}
+	for _, maxBufferedTuples := range []int{1, 1 + rng.Intn(numRows), numRows} {
+		t.Run(fmt.Sprintf("maxBufferedTuples=%d", maxBufferedTuples), func(t *testing.T) {
+			runTestsWithoutAllNullsInjection(t, []tuples{input}, nil /* typs */, expected, unorderedVerifier,
+				func(sources []Operator) (Operator, error) {
+					// The partial results are merged by a regular hash aggregator just
+					// like the final stage of a distributed aggregation does.
+					partial, err := NewPartialHashAggregator(
+						testAllocator, sources[0], typs,
+						[]execinfrapb.AggregatorSpec_Func{
+							execinfrapb.AggregatorSpec_ANY_NOT_NULL,
+							execinfrapb.AggregatorSpec_SUM_INT,
+							execinfrapb.AggregatorSpec_COUNT_ROWS,
+						},
+						[]uint32{0}, [][]uint32{{0}, {1}, {}}, maxBufferedTuples,
+					)
+					if err != nil {
+						return nil, err
+					}
+					return NewHashAggregator(
+						testAllocator, partial, []coltypes.T{coltypes.Int64, coltypes.Int64, coltypes.Int64},
+						[]execinfrapb.AggregatorSpec_Func{
+							execinfrapb.AggregatorSpec_ANY_NOT_NULL,
+							execinfrapb.AggregatorSpec_SUM_INT,
+							execinfrapb.AggregatorSpec_SUM_INT,
+						},
+						[]uint32{0}, [][]uint32{{0}, {1}, {2}}, false, /* isScalar */
+					)
+				})
+		})
+	}
+}
+
+func TestAggregatorConcat(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := aggregatorTestCase{
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AggregatorSpec_CONCAT_AGG,
+			execinfrapb.AggregatorSpec_STRING_AGG,
+		},
+		// The delimiter of STRING_AGG is taken from the row of the value that
+		// follows it.
+		aggCols:  [][]uint32{{1}, {1, 2}},
+		colTypes: []coltypes.T{coltypes.Int64, coltypes.Bytes, coltypes.Bytes},
+		input: tuples{
+			{0, "a", ","},
+			{0, nil, ","},
+			{0, "b", ";"},
+			{1, nil, ","},
+			{2, "c", ","},
+			{2, "d", nil},
+			{2, "", "-"},
+			{2, "e", "-"},
+			{3, "f", ","},
+		},
+		expected: tuples{
+			{"ab", "a;b"},
+			{nil, nil},
+			{"cde", "cd--e"},
+			{"f", "f"},
+		},
+	}
+	if err := tc.init(); err != nil {
+		t.Fatal(err)
+	}
+	// The result depends on the order of the values within the groups, so only
+	// the ordered aggregator is used.
+	runTests(t, []tuples{tc.input}, tc.expected, orderedVerifier, func(input []Operator) (Operator, error) {
+		return NewOrderedAggregator(
+			testAllocator, input[0], tc.colTypes, tc.aggFns, tc.groupCols, tc.aggCols, false, /* isScalar */
+		)
+	})
+
+	t.Run("Arguments", func(t *testing.T) {
+		ctx := context.Background()
+		st := cluster.MakeTestingClusterSettings()
+		evalCtx := tree.MakeTestingEvalContext(st)
+		defer evalCtx.Stop(ctx)
+		flowCtx := &execinfra.FlowCtx{
+			EvalCtx: &evalCtx,
+			Cfg: &execinfra.ServerConfig{
+				Settings: st,
+			},
+		}
+		// The constant delimiter is passed as an argument of the aggregation,
+		// and a NULL delimiter is equivalent to an empty one.
+		for _, tc := range []struct {
+			delimiter string
+			expected  tuples
+		}{
+			{delimiter: "', '", expected: tuples{{"a, b"}, {nil}, {"c, d, e"}}},
+			{delimiter: "NULL", expected: tuples{{"ab"}, {nil}, {"cde"}}},
+		} {
+			input := tuples{{0, "a"}, {0, "b"}, {1, nil}, {2, "c"}, {2, nil}, {2, "d"}, {2, "e"}}
+			runTests(t, []tuples{input}, tc.expected, orderedVerifier, func(inputs []Operator) (Operator, error) {
+				spec := &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int, *types.String}}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Aggregator: &execinfrapb.AggregatorSpec{
+							GroupCols:        []uint32{0},
+							OrderedGroupCols: []uint32{0},
+							Aggregations: []execinfrapb.AggregatorSpec_Aggregation{{
+								Func:      execinfrapb.AggregatorSpec_STRING_AGG,
+								ColIdx:    []uint32{1},
+								Arguments: []execinfrapb.Expression{{Expr: tc.delimiter}},
+							}},
+						},
+					},
+				}
+				args := NewColOperatorArgs{
+					Spec:                spec,
+					Inputs:              inputs,
+					StreamingMemAccount: testMemAcc,
+				}
+				args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+				result, err := NewColOperator(ctx, flowCtx, args)
+				if err != nil {
+					return nil, err
+				}
+				return result.Op, nil
+			})
+		}
+	})
+}
+
 func min64(a, b float64) float64 {
 	if a < b {
 		return a
@@ -13,24 +13,49 @@ package colexec
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"time"
 	"unsafe"
 
+	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
 )
 
 // Allocator is a memory management tool for vectorized components. It provides
 // new batches (and appends to existing ones) within a fixed memory budget. If
 // the budget is exceeded, it will panic with an error.
 //
-// In the future this can also be used to pool coldata.Vec allocations.
+// The Allocator also pools the batches that are no longer needed by their
+// owners (see ReleaseBatch), so that the operators that are short-lived or
+// reset repeatedly don't allocate new batches of the same schema every time.
+// Note that the Allocator is not safe for concurrent use.
 type Allocator struct {
 	ctx context.Context
 	acc *mon.BoundAccount
+	// name is the name of the operator that uses the allocator (if known). The
+	// errors of exceeding the memory budget are annotated with it.
+	name string
+
+	// pool contains the released batches keyed by their schema and capacity
+	// (see batchPoolKey). The memory of the pooled batches remains registered
+	// with acc, so the memory account of a batch is transferred to the new
+	// owner once the batch is reused.
+	pool map[string][]*coldata.MemBatch
+	// poolKeyScratch is a scratch buffer for the keys of pool.
+	poolKeyScratch []byte
+
+	// bytesArena, if set, is the arena that the Bytes vectors allocated by the
+	// allocator use for their buffers (see EnableBytesArena).
+	bytesArena *coldata.BytesArena
+	// bytesArenaWasted is the number of wasted bytes of bytesArena that are
+	// registered with acc.
+	bytesArenaWasted int64
 }
 
 // NewAllocator constructs a new Allocator instance.
@@ -38,6 +63,25 @@ func NewAllocator(ctx context.Context, acc *mon.BoundAccount) *Allocator {
 	return &Allocator{ctx: ctx, acc: acc}
 }
 
+// NewNamedAllocator constructs a new Allocator instance for the operator named
+// name. acc is usually the account of the operator's own monitor which is a
+// child of the flow's monitor, so that the memory used by the operator shows up
+// in the memory budget hierarchy, and the errors of exceeding the budget
+// (which might come from any of the monitors up the hierarchy) are annotated
+// with name.
+func NewNamedAllocator(ctx context.Context, acc *mon.BoundAccount, name string) *Allocator {
+	return &Allocator{ctx: ctx, acc: acc, name: name}
+}
+
+// EnableBytesArena makes the Bytes vectors that are allocated by a from now on
+// allocate their buffers from a byte arena (see coldata.BytesArena) rather
+// than separately. The arena is reset by Clear, so it should be enabled for
+// the operators that buffer a lot of strings and release all of their memory
+// at once.
+func (a *Allocator) EnableBytesArena() {
+	a.bytesArena = coldata.NewBytesArena(coldata.DefaultBytesArenaChunkSize)
+}
+
 // NewMemBatch allocates a new in-memory coldata.Batch.
 func (a *Allocator) NewMemBatch(types []coltypes.T) coldata.Batch {
 	return a.NewMemBatchWithSize(types, int(coldata.BatchSize()))
@@ -46,21 +90,135 @@ func (a *Allocator) NewMemBatch(types []coltypes.T) coldata.Batch {
 // NewMemBatchWithSize allocates a new in-memory coldata.Batch with the given
 // column size.
 func (a *Allocator) NewMemBatchWithSize(types []coltypes.T, size int) coldata.Batch {
-	selVectorSize := size * sizeOfUint16
+	if b := a.getPooledBatch(types, size); b != nil {
+		return b
+	}
+	selVectorSize := size * sizeOfInt
 	estimatedStaticMemoryUsage := int64(estimateBatchSizeBytes(types, size) + selVectorSize)
-	if err := a.acc.Grow(a.ctx, estimatedStaticMemoryUsage); err != nil {
-		execerror.VectorizedInternalPanic(err)
+	a.grow(estimatedStaticMemoryUsage)
+	return coldata.NewMemBatchWithArena(types, size, a.bytesArena)
+}
+
+// NewMemBatchWithCapacity allocates a new in-memory coldata.Batch that can
+// hold up to capacity tuples (see coldata.NewMemBatchWithCapacity).
+func (a *Allocator) NewMemBatchWithCapacity(types []coltypes.T, capacity int) coldata.Batch {
+	return a.NewMemBatchWithSize(types, coldata.CapacityFromHint(capacity))
+}
+
+// CloneBatch returns a deep copy of b (see coldata.Batch.Clone) and registers
+// its memory with the allocator. Note that, similar to PerformOperation, the
+// memory is accounted for after the copy has been made, since the footprint of
+// the variable width vectors is not known upfront.
+func (a *Allocator) CloneBatch(b coldata.Batch) coldata.Batch {
+	clone := b.Clone()
+	a.grow(getVecsMemoryFootprint(clone.ColVecs()) + int64(int(clone.Length())*sizeOfInt))
+	return clone
+}
+
+// CloneVec returns a deep copy of the first n values of vec (selected by sel
+// if it is non-nil, see coldata.Vec.Clone) and registers its memory with the
+// allocator.
+func (a *Allocator) CloneVec(vec coldata.Vec, sel []int, n int) coldata.Vec {
+	clone := vec.Clone(sel, n)
+	a.grow(getVecMemoryFootprint(clone))
+	return clone
+}
+
+// ReleaseBatch returns b to the pool of the allocator, so that it can be
+// reused by subsequent calls to NewMemBatch and NewMemBatchWithSize with the
+// same types and size. b must have been allocated by this allocator, and the
+// caller must not use b (or its columns) after it has been released. The
+// memory of b remains registered with the memory account of the allocator
+// until Clear is called.
+// NOTE: only coldata.MemBatches are pooled, the other batches are ignored.
+func (a *Allocator) ReleaseBatch(b coldata.Batch) {
+	memBatch, ok := b.(*coldata.MemBatch)
+	if !ok {
+		return
+	}
+	// The types of the columns are appended to the key the same way
+	// batchPoolKey does it, without materializing them into a slice.
+	a.poolKeyScratch = batchPoolKey(a.poolKeyScratch, nil /* types */, memBatch.Capacity())
+	for _, vec := range memBatch.ColVecs() {
+		a.poolKeyScratch = append(a.poolKeyScratch, byte(vec.Type()))
+	}
+	if a.pool == nil {
+		a.pool = make(map[string][]*coldata.MemBatch)
 	}
-	return coldata.NewMemBatchWithSize(types, size)
+	key := string(a.poolKeyScratch)
+	a.pool[key] = append(a.pool[key], memBatch)
+}
+
+// getPooledBatch returns a batch with the given types and size from the pool
+// of the allocator (or nil if there is no such batch). The batch is reset, and
+// the memory account is updated with the change of its footprint.
+func (a *Allocator) getPooledBatch(types []coltypes.T, size int) coldata.Batch {
+	if len(a.pool) == 0 {
+		return nil
+	}
+	a.poolKeyScratch = batchPoolKey(a.poolKeyScratch, types, size)
+	batches := a.pool[string(a.poolKeyScratch)]
+	if len(batches) == 0 {
+		return nil
+	}
+	b := batches[len(batches)-1]
+	batches[len(batches)-1] = nil
+	a.pool[string(a.poolKeyScratch)] = batches[:len(batches)-1]
+	// Resetting might change the footprint of the variable width columns, so
+	// the memory account is updated accordingly.
+	a.PerformOperation(b.ColVecs(), func() {
+		b.ResetInternalBatch()
+		b.SetLength(0)
+	})
+	return b
+}
+
+// ResetMaybeReallocate returns a batch of the given types that can hold at
+// least minCapacity tuples (capped at coldata.BatchSize()). oldBatch (which can
+// be nil) is reset and returned if it is big enough; otherwise, its memory is
+// released, and a new batch with exactly the requested capacity is allocated.
+// In the latter case, the caller must not use oldBatch (or its columns)
+// afterwards.
+func (a *Allocator) ResetMaybeReallocate(
+	types []coltypes.T, oldBatch coldata.Batch, minCapacity int,
+) coldata.Batch {
+	if maxCapacity := int(coldata.BatchSize()); minCapacity > maxCapacity {
+		minCapacity = maxCapacity
+	}
+	memBatch, ok := oldBatch.(*coldata.MemBatch)
+	if ok && memBatch.Capacity() >= minCapacity {
+		a.PerformOperation(memBatch.ColVecs(), func() {
+			memBatch.ResetInternalBatch()
+			memBatch.SetLength(0)
+		})
+		return memBatch
+	}
+	if oldBatch != nil {
+		a.ReleaseVecs(oldBatch.ColVecs())
+		if ok {
+			// The selection vector was registered when the batch was allocated.
+			a.ReleaseMemory(int64(memBatch.Capacity() * sizeOfInt))
+		}
+	}
+	return a.NewMemBatchWithSize(types, minCapacity)
+}
+
+// batchPoolKey returns the key of the pool of released batches for the
+// batches of the given types and size (using buf as the scratch space).
+func batchPoolKey(buf []byte, types []coltypes.T, size int) []byte {
+	// The size of a batch fits into two bytes (see coldata.NewMemBatchWithSize).
+	buf = append(buf[:0], byte(size>>8), byte(size))
+	for _, t := range types {
+		buf = append(buf, byte(t))
+	}
+	return buf
 }
 
 // NewMemColumn returns a new coldata.Vec, initialized with a length.
 func (a *Allocator) NewMemColumn(t coltypes.T, n int) coldata.Vec {
 	estimatedStaticMemoryUsage := int64(estimateBatchSizeBytes([]coltypes.T{t}, n))
-	if err := a.acc.Grow(a.ctx, estimatedStaticMemoryUsage); err != nil {
-		execerror.VectorizedInternalPanic(err)
-	}
-	return coldata.NewMemColumn(t, n)
+	a.grow(estimatedStaticMemoryUsage)
+	return coldata.NewMemColumnWithArena(t, n, a.bytesArena)
 }
 
 // MaybeAddColumn might add a newly allocated coldata.Vec of the given type to
@@ -85,9 +243,7 @@ func (a *Allocator) MaybeAddColumn(b coldata.Batch, t coltypes.T, colIdx int) {
 		b.AppendCol(a.NewMemColumn(coltypes.Unhandled, 0))
 	}
 	estimatedStaticMemoryUsage := int64(estimateBatchSizeBytes([]coltypes.T{t}, int(coldata.BatchSize())))
-	if err := a.acc.Grow(a.ctx, estimatedStaticMemoryUsage); err != nil {
-		execerror.VectorizedInternalPanic(err)
-	}
+	a.grow(estimatedStaticMemoryUsage)
 	col := a.NewMemColumn(t, int(coldata.BatchSize()))
 	if b.Width() == colIdx {
 		b.AppendCol(col)
@@ -101,35 +257,164 @@ func (a *Allocator) MaybeAddColumn(b coldata.Batch, t coltypes.T, colIdx int) {
 // NOTE: if some columnar vectors are not modified, they should not be included
 // in 'destVecs' to reduce the performance hit of memory accounting.
 func (a *Allocator) PerformOperation(destVecs []coldata.Vec, operation func()) {
-	var before, after, delta int64
+	var before, after int64
 	for _, dest := range destVecs {
 		// To simplify the accounting, we perform the operation first and then will
 		// update the memory account. The minor "drift" in accounting that is
 		// caused by this approach is ok.
-		if dest.Type() == coltypes.Bytes {
-			before += int64(dest.Bytes().Size())
-		} else {
-			before += int64(estimateBatchSizeBytes([]coltypes.T{dest.Type()}, dest.Capacity()))
-		}
+		before += getVecMemoryFootprint(dest)
 	}
 
 	operation()
 
 	for _, dest := range destVecs {
-		if dest.Type() == coltypes.Bytes {
-			after += int64(dest.Bytes().Size())
-		} else {
-			after += int64(estimateBatchSizeBytes([]coltypes.T{dest.Type()}, dest.Capacity()))
-		}
+		after += getVecMemoryFootprint(dest)
 	}
-	delta = after - before
+	if a.bytesArena != nil {
+		// The buffers that the vectors outgrew are not freed until the arena
+		// is reset, so they still use the memory.
+		wasted := a.bytesArena.Wasted()
+		after += wasted - a.bytesArenaWasted
+		a.bytesArenaWasted = wasted
+	}
+	a.AdjustMemoryUsage(after - before)
+}
+
+// AdjustMemoryUsage adjusts the number of bytes currently allocated through
+// this allocator by delta bytes (which can be negative). It should be used for
+// the memory that is not part of any coldata.Vec (for example, the scratch
+// buffers of the operators), and it panics if the budget is exceeded.
+func (a *Allocator) AdjustMemoryUsage(delta int64) {
 	if delta >= 0 {
-		if err := a.acc.Grow(a.ctx, delta); err != nil {
-			execerror.VectorizedInternalPanic(err)
-		}
+		a.grow(delta)
 	} else {
-		a.acc.Shrink(a.ctx, -delta)
+		a.ReleaseMemory(-delta)
+	}
+}
+
+// ReleaseMemory returns size bytes that are no longer used to the memory
+// account of the allocator. It should be used by the operators that discard
+// some of their state in the middle of the flow (for example, the hash table
+// once the hash join is done) so that the budget is returned to the monitor
+// before the operator is closed. Since the memory usage of the vectors is
+// partially estimated, size is capped at the number of bytes currently
+// registered with the account.
+func (a *Allocator) ReleaseMemory(size int64) {
+	if used := a.acc.Used(); size > used {
+		size = used
 	}
+	a.acc.Shrink(a.ctx, size)
+}
+
+// ReleaseVecs is a convenience wrapper around ReleaseMemory that releases the
+// memory footprint of vecs. The vectors must not be used afterwards.
+func (a *Allocator) ReleaseVecs(vecs []coldata.Vec) {
+	a.ReleaseMemory(getVecsMemoryFootprint(vecs))
+}
+
+// grow registers delta bytes with the memory account of the allocator. It
+// panics if the budget is exceeded.
+func (a *Allocator) grow(delta int64) {
+	if err := a.acc.Grow(a.ctx, delta); err != nil {
+		if a.name != "" {
+			err = errors.Wrapf(err, "%s", a.name)
+		}
+		execerror.VectorizedInternalPanic(err)
+	}
+}
+
+// getVecMemoryFootprint returns the memory footprint of vec. It is exact for
+// the variable width types and is an estimate for the other ones.
+func getVecMemoryFootprint(vec coldata.Vec) int64 {
+	switch vec.Type() {
+	case coltypes.Bytes:
+		return int64(vec.Bytes().Size())
+	case coltypes.Decimal:
+		return sizeOfDecimals(vec.Decimal()[:vec.Capacity()])
+	case coltypes.Array:
+		arrays := vec.Array()
+		size := int64(arrays.Size())
+		if child := arrays.Child(); child != nil {
+			size += getVecMemoryFootprint(child)
+		}
+		return size
+	case coltypes.Datum:
+		datums := vec.Datum()
+		return int64(coldata.DatumsOverhead) + sizeOfDatums(datums, datums.Cap())
+	case coltypes.Unhandled:
+		// The placeholder vectors don't have any memory.
+		return 0
+	}
+	return int64(estimateBatchSizeBytes([]coltypes.T{vec.Type()}, vec.Capacity()))
+}
+
+// getVecsMemoryFootprint returns the total memory footprint of vecs.
+func getVecsMemoryFootprint(vecs []coldata.Vec) int64 {
+	var size int64
+	for _, vec := range vecs {
+		size += getVecMemoryFootprint(vec)
+	}
+	return size
+}
+
+// GetProportionalBatchMemSize returns the memory footprint of the first length
+// tuples of b (for example, of the tuples that are buffered by an operator).
+// Unlike the footprint of the whole batch, it doesn't include the unused
+// capacity of the vectors. The sizes of the variable width vectors are computed
+// from the values that are actually stored in them rather than from flat
+// per-type estimates, so the result reflects the reality for the string-heavy
+// workloads.
+// NOTE: the selection vector of b is ignored.
+func GetProportionalBatchMemSize(b coldata.Batch, length int64) int64 {
+	var size int64
+	for _, vec := range b.ColVecs() {
+		size += getProportionalVecMemSize(vec, length)
+	}
+	return size
+}
+
+// getProportionalVecMemSize returns the memory footprint of the first length
+// values of vec.
+func getProportionalVecMemSize(vec coldata.Vec, length int64) int64 {
+	switch vec.Type() {
+	case coltypes.Bytes:
+		return int64(vec.Bytes().ProportionalSize(length))
+	case coltypes.Decimal:
+		return sizeOfDecimals(vec.Decimal()[:length])
+	case coltypes.Array:
+		// The elements of the arrays are stored in the child vector, so the whole
+		// vector is taken into account.
+		return getVecMemoryFootprint(vec)
+	case coltypes.Datum:
+		return sizeOfDatums(vec.Datum(), int(length))
+	}
+	return int64(estimateBatchSizeBytes([]coltypes.T{vec.Type()}, int(length)))
+}
+
+// sizeOfDecimals returns the memory footprint of decimals, including the
+// words of their coefficients that are allocated separately.
+func sizeOfDecimals(decimals []apd.Decimal) int64 {
+	size := int64(len(decimals) * sizeOfDecimal)
+	for i := range decimals {
+		size += int64(cap(decimals[i].Coeff.Bits()) * sizeOfWord)
+	}
+	return size
+}
+
+// sizeOfDatums returns the memory footprint of the first n elements of datums
+// (n can exceed the length of datums, up to its capacity), including the sizes
+// of the datums they reference.
+func sizeOfDatums(datums *coldata.Datums, n int) int64 {
+	size := int64(n) * int64(coldata.SizeOfDatumsElement)
+	if l := datums.Len(); n > l {
+		n = l
+	}
+	for i := 0; i < n; i++ {
+		if d, ok := datums.Get(i).(tree.Datum); ok {
+			size += int64(d.Size())
+		}
+	}
+	return size
 }
 
 // Used returns the number of bytes currently allocated through this allocator.
@@ -137,25 +422,33 @@ func (a *Allocator) Used() int64 {
 	return a.acc.Used()
 }
 
-// Clear clears up the memory account of the allocator.
+// Clear clears up the memory account of the allocator. The pooled batches are
+// released to GC as well, and the byte arena (if enabled) is reset.
 func (a *Allocator) Clear() {
+	a.pool = nil
+	if a.bytesArena != nil {
+		a.bytesArena.Reset()
+		a.bytesArenaWasted = 0
+	}
 	a.acc.Clear(a.ctx)
 }
 
 const (
 	sizeOfBool     = int(unsafe.Sizeof(true))
+	sizeOfInt      = int(unsafe.Sizeof(int(0)))
 	sizeOfInt16    = int(unsafe.Sizeof(int16(0)))
 	sizeOfInt32    = int(unsafe.Sizeof(int32(0)))
 	sizeOfInt64    = int(unsafe.Sizeof(int64(0)))
 	sizeOfFloat64  = int(unsafe.Sizeof(float64(0)))
 	sizeOfTime     = int(unsafe.Sizeof(time.Time{}))
 	sizeOfDuration = int(unsafe.Sizeof(duration.Duration{}))
-	sizeOfUint16   = int(unsafe.Sizeof(uint16(0)))
+	sizeOfDecimal  = int(unsafe.Sizeof(apd.Decimal{}))
+	sizeOfWord     = int(unsafe.Sizeof(big.Word(0)))
 )
 
 // sizeOfBatchSizeSelVector is the size (in bytes) of a selection vector of
 // coldata.BatchSize() length.
-var sizeOfBatchSizeSelVector = int(coldata.BatchSize()) * sizeOfUint16
+var sizeOfBatchSizeSelVector = int(coldata.BatchSize()) * sizeOfInt
 
 // estimateBatchSizeBytes returns an estimated amount of bytes needed to
 // store a batch in memory that has column types vecTypes.
@@ -186,9 +479,12 @@ func estimateBatchSizeBytes(vecTypes []coltypes.T, batchLength int) int {
 		case coltypes.Float64:
 			acc += sizeOfFloat64
 		case coltypes.Decimal:
-			// Similar to byte arrays, we can't tell how much space is used
-			// to hold the arbitrary precision decimal objects.
-			acc += 50
+			// Similar to byte arrays, we can't tell how much space is used to
+			// hold the coefficients of the arbitrary precision decimal objects
+			// until they are set, so we account only for the decimals themselves
+			// here. The exact memory footprint will be used once the decimals
+			// are modified (see getVecMemoryFootprint).
+			acc += sizeOfDecimal
 		case coltypes.Timestamp:
 			// time.Time consists of two 64 bit integers and a pointer to
 			// time.Location. We will only account for this 3 bytes without paying
@@ -200,6 +496,17 @@ func estimateBatchSizeBytes(vecTypes []coltypes.T, batchLength int) int {
 			acc += sizeOfTime
 		case coltypes.Interval:
 			acc += sizeOfDuration
+		case coltypes.Array:
+			// The vector with the elements of the arrays is allocated only once
+			// their type is known, so we account only for the offsets here. The
+			// elements will be accounted for when they are set (see
+			// PerformOperation).
+			acc += sizeOfInt32
+		case coltypes.Datum:
+			// The datums themselves are accounted for once they are set (see
+			// getVecMemoryFootprint), so we account only for the references to
+			// them here.
+			acc += int(coldata.SizeOfDatumsElement)
 		case coltypes.Unhandled:
 			// Placeholder coldata.Vecs of unknown types are allowed.
 		default:
@@ -0,0 +1,244 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocatorBatchPool(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewAllocator(ctx, &memAcc)
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	b := allocator.NewMemBatch(typs)
+	allocator.PerformOperation(b.ColVecs(), func() {
+		b.ColVec(0).Int64()[0] = 1
+		b.ColVec(1).Bytes().Set(0, []byte("a"))
+		b.ColVec(1).Nulls().SetNull(1)
+	})
+	b.SetLength(2)
+	b.SetSelection(true)
+	memUsed := allocator.Used()
+
+	allocator.ReleaseBatch(b)
+	// The memory of the released batch remains accounted for.
+	require.Equal(t, memUsed, allocator.Used())
+
+	// The batches of a different schema or size are not taken from the pool.
+	other := allocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	require.True(t, b != other)
+	other = allocator.NewMemBatchWithSize(typs, 1 /* size */)
+	require.True(t, b != other)
+	memUsed = allocator.Used()
+
+	// The released batch is reused (in the reset state) without growing the
+	// memory account.
+	reused := allocator.NewMemBatch(typs)
+	require.True(t, b == reused)
+	require.Equal(t, uint16(0), reused.Length())
+	require.Nil(t, reused.Selection())
+	require.False(t, reused.ColVec(1).Nulls().MaybeHasNulls())
+	require.True(t, allocator.Used() <= memUsed)
+
+	// The pool is empty now, so a new batch is allocated.
+	require.True(t, b != allocator.NewMemBatch(typs))
+
+	// Clear releases the pooled batches.
+	allocator.ReleaseBatch(reused)
+	allocator.Clear()
+	require.Equal(t, int64(0), allocator.Used())
+	require.True(t, b != allocator.NewMemBatch(typs))
+
+	// Only coldata.MemBatches are pooled, so the zero batch is ignored.
+	allocator.ReleaseBatch(coldata.ZeroBatch)
+	require.True(t, allocator.NewMemBatchWithSize(nil /* types */, 0 /* size */) != coldata.ZeroBatch)
+}
+
+func TestResetMaybeReallocate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewAllocator(ctx, &memAcc)
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	capacity := func(b coldata.Batch) int {
+		return b.(*coldata.MemBatch).Capacity()
+	}
+	b := allocator.ResetMaybeReallocate(typs, nil /* oldBatch */, 1 /* minCapacity */)
+	require.Equal(t, 1, capacity(b))
+	allocator.PerformOperation(b.ColVecs(), func() {
+		b.ColVec(0).Int64()[0] = 1
+		b.ColVec(1).Bytes().Set(0, []byte("a"))
+	})
+	b.SetLength(1)
+
+	// The batch is reused (in the reset state) if it is big enough.
+	reused := allocator.ResetMaybeReallocate(typs, b, 1 /* minCapacity */)
+	require.True(t, b == reused)
+	require.Equal(t, uint16(0), reused.Length())
+
+	// Otherwise, the memory of the old batch is released, so only the new
+	// batch remains accounted for.
+	bigger := allocator.ResetMaybeReallocate(typs, reused, 2 /* minCapacity */)
+	require.True(t, reused != bigger)
+	require.Equal(t, 2, capacity(bigger))
+	otherMemAcc := testMemMonitor.MakeBoundAccount()
+	defer otherMemAcc.Close(ctx)
+	otherAllocator := NewAllocator(ctx, &otherMemAcc)
+	otherAllocator.NewMemBatchWithSize(typs, 2 /* size */)
+	require.Equal(t, otherAllocator.Used(), allocator.Used())
+
+	// The capacity is capped at coldata.BatchSize().
+	maxBatch := allocator.ResetMaybeReallocate(typs, bigger, 2*int(coldata.BatchSize()))
+	require.Equal(t, int(coldata.BatchSize()), capacity(maxBatch))
+}
+
+func TestAllocatorCloneBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewAllocator(ctx, &memAcc)
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	b := testAllocator.NewMemBatch(typs)
+	testAllocator.PerformOperation(b.ColVecs(), func() {
+		for i := 0; i < 3; i++ {
+			b.ColVec(0).Int64()[i] = int64(i)
+			b.ColVec(1).Bytes().Set(i, make([]byte, 100*i))
+		}
+	})
+	b.SetLength(3)
+
+	clone := allocator.CloneBatch(b)
+	require.Equal(t, uint16(3), clone.Length())
+	require.Equal(t, []int64{0, 1, 2}, clone.ColVec(0).Int64())
+	// Only the memory of the copy (which is sized according to its length) is
+	// registered with the allocator.
+	require.Equal(
+		t,
+		getVecsMemoryFootprint(clone.ColVecs())+int64(int(clone.Length())*sizeOfInt),
+		allocator.Used(),
+	)
+
+	// The clone can be modified without affecting the original batch.
+	allocator.PerformOperation(clone.ColVecs(), func() {
+		clone.ColVec(0).Int64()[0] = 42
+	})
+	require.Equal(t, int64(0), b.ColVec(0).Int64()[0])
+
+	used := allocator.Used()
+	vec := allocator.CloneVec(b.ColVec(1), []int{2} /* sel */, 1 /* n */)
+	require.Equal(t, 200, len(vec.Bytes().Get(0)))
+	require.Equal(t, used+getVecMemoryFootprint(vec), allocator.Used())
+}
+
+func TestAllocatorBytesArena(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewAllocator(ctx, &memAcc)
+	allocator.EnableBytesArena()
+
+	typs := []coltypes.T{coltypes.Bytes}
+	b := allocator.NewMemBatchWithSize(typs, 1 /* size */)
+	vec := allocator.NewMemColumn(coltypes.Bytes, 1 /* n */)
+	usedBefore := allocator.Used()
+	footprintBefore := getVecMemoryFootprint(b.ColVec(0))
+	allocator.PerformOperation(b.ColVecs(), func() {
+		b.ColVec(0).Bytes().Set(0, make([]byte, 2*coldata.BytesInitialAllocationFactor))
+	})
+	// Both the grown vector and the buffer that it outgrew (which is not
+	// freed until the arena is reset) are accounted for.
+	require.Equal(
+		t,
+		usedBefore+getVecMemoryFootprint(b.ColVec(0))-footprintBefore+coldata.BytesInitialAllocationFactor,
+		allocator.Used(),
+	)
+
+	// The vectors remain usable after the allocator is cleared.
+	allocator.Clear()
+	require.Equal(t, int64(0), allocator.Used())
+	allocator.PerformOperation([]coldata.Vec{vec}, func() {
+		vec.Bytes().Set(0, []byte("a"))
+	})
+	require.Equal(t, []byte("a"), vec.Bytes().Get(0))
+	require.Equal(t, 2*coldata.BytesInitialAllocationFactor, len(b.ColVec(0).Bytes().Get(0)))
+}
+
+func TestNamedAllocatorBudgetError(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	limitedMon := mon.MakeMonitorInheritWithLimit("test-limited", 1 /* limit */, testMemMonitor)
+	limitedMon.Start(ctx, testMemMonitor, mon.BoundAccount{})
+	defer limitedMon.Stop(ctx)
+	memAcc := limitedMon.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewNamedAllocator(ctx, &memAcc, "test-op")
+
+	err := execerror.CatchVectorizedRuntimeError(func() {
+		allocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	})
+	require.Error(t, err)
+	// The error is annotated with the name of the operator, and it is still
+	// recognized as a budget error (so that the disk spillers can catch it).
+	require.Contains(t, err.Error(), "test-op")
+	require.True(t, sqlbase.IsOutOfMemoryError(err), "unexpected error %v", err)
+}
+
+func TestGetProportionalBatchMemSize(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes, coltypes.Decimal}
+	b := testAllocator.NewMemBatch(typs)
+	require.Equal(t, int64(0), GetProportionalBatchMemSize(b, 0 /* length */))
+
+	shortString, longString := []byte("a"), make([]byte, 10*coldata.BytesInitialAllocationFactor)
+	testAllocator.PerformOperation(b.ColVecs(), func() {
+		b.ColVec(1).Bytes().Set(0, shortString)
+		b.ColVec(1).Bytes().Set(1, longString)
+		b.ColVec(2).Decimal()[1].SetFinite(math.MaxInt64, 0 /* exponent */)
+	})
+	b.SetLength(2)
+
+	// The size of the variable width vectors is proportional to the values
+	// stored in them rather than to the number of tuples.
+	oneTupleSize := GetProportionalBatchMemSize(b, 1 /* length */)
+	twoTuplesSize := GetProportionalBatchMemSize(b, 2 /* length */)
+	require.True(t, twoTuplesSize-oneTupleSize > int64(len(longString)))
+	require.Equal(
+		t,
+		int64(sizeOfInt64+sizeOfDecimal)+int64(b.ColVec(1).Bytes().ProportionalSize(1)),
+		oneTupleSize,
+	)
+	require.Equal(
+		t,
+		int64(2*(sizeOfInt64+sizeOfDecimal))+int64(b.ColVec(1).Bytes().ProportionalSize(2))+
+			int64(cap(b.ColVec(2).Decimal()[1].Coeff.Bits())*sizeOfWord),
+		twoTuplesSize,
+	)
+}
@@ -263,7 +263,7 @@ func benchmarkLogicalProjOp(
 		batch.SetSelection(true)
 		sel := batch.Selection()
 		for i := 0; i < int(coldata.BatchSize()); i++ {
-			sel[i] = uint16(i)
+			sel[i] = i
 		}
 	}
 	input := NewRepeatableBatchSource(batch)
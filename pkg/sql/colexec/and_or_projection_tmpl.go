@@ -47,7 +47,7 @@ type _OP_LOWERProjOp struct {
 	// origSel is a buffer used to keep track of the original selection vector of
 	// the input batch. We need to do this because we're going to modify the
 	// selection vector in order to do the short-circuiting of logical operators.
-	origSel []uint16
+	origSel []int
 }
 
 // New_OP_TITLEProjOp returns a new projection operator that logical-_OP_TITLE's
@@ -69,7 +69,7 @@ func New_OP_TITLEProjOp(
 		leftIdx:          leftIdx,
 		rightIdx:         rightIdx,
 		outputIdx:        outputIdx,
-		origSel:          make([]uint16, coldata.BatchSize()),
+		origSel:          make([]int, coldata.BatchSize()),
 	}
 }
 
@@ -157,11 +157,11 @@ func (o *_OP_LOWERProjOp) Next(ctx context.Context) coldata.Batch {
 		sel := batch.Selection()
 		if leftCol.MaybeHasNulls() {
 			leftNulls := leftCol.Nulls()
-			for i := uint16(0); i < origLen; i++ {
+			for i := 0; i < int(origLen); i++ {
 				_ADD_TUPLE_FOR_RIGHT(true)
 			}
 		} else {
-			for i := uint16(0); i < origLen; i++ {
+			for i := 0; i < int(origLen); i++ {
 				_ADD_TUPLE_FOR_RIGHT(false)
 			}
 		}
@@ -229,7 +229,7 @@ func (o *_OP_LOWERProjOp) Next(ctx context.Context) coldata.Batch {
 func _ADD_TUPLE_FOR_RIGHT(_L_HAS_NULLS bool) { // */}}
 	// {{define "addTupleForRight" -}}
 	// {{if _L_HAS_NULLS}}
-	isLeftNull := leftNulls.NullAt(i)
+	isLeftNull := leftNulls.NullAt(uint16(i))
 	// {{else}}
 	isLeftNull := false
 	// {{end}}
@@ -252,7 +252,7 @@ func _SET_VALUES(_IS_OR_OP bool, _L_HAS_NULLS bool, _R_HAS_NULLS bool) { // */}}
 	// {{ define "setValues" -}}
 	if sel := batch.Selection(); sel != nil {
 		for _, i := range sel[:origLen] {
-			_SET_SINGLE_VALUE(_IS_OR_OP, true, _L_HAS_NULLS, _R_HAS_NULLS)
+			_SET_SINGLE_VALUE(_IS_OR_OP, _L_HAS_NULLS, _R_HAS_NULLS)
 		}
 	} else {
 		if ranRightSide {
@@ -260,7 +260,7 @@ func _SET_VALUES(_IS_OR_OP bool, _L_HAS_NULLS bool, _R_HAS_NULLS bool) { // */}}
 		}
 		_ = outputColVals[origLen-1]
 		for i := range leftColVals[:origLen] {
-			_SET_SINGLE_VALUE(_IS_OR_OP, false, _L_HAS_NULLS, _R_HAS_NULLS)
+			_SET_SINGLE_VALUE(_IS_OR_OP, _L_HAS_NULLS, _R_HAS_NULLS)
 		}
 	}
 	// {{ end }}
@@ -272,15 +272,11 @@ func _SET_VALUES(_IS_OR_OP bool, _L_HAS_NULLS bool, _R_HAS_NULLS bool) { // */}}
 // {{/*
 // This code snippet sets the result of applying a logical operation AND or OR
 // to two boolean values which can be null.
-func _SET_SINGLE_VALUE(_IS_OR_OP bool, _USES_SEL bool, _L_HAS_NULLS bool, _R_HAS_NULLS bool) { // */}}
+func _SET_SINGLE_VALUE(_IS_OR_OP bool, _L_HAS_NULLS bool, _R_HAS_NULLS bool) { // */}}
 	// {{ define "setSingleValue" -}}
-	// {{ if _USES_SEL }}
 	idx := i
-	// {{ else }}
-	idx := uint16(i)
-	// {{ end }}
 	// {{ if _L_HAS_NULLS }}
-	isLeftNull := leftNulls.NullAt(idx)
+	isLeftNull := leftNulls.NullAt(uint16(idx))
 	// {{ else }}
 	isLeftNull := false
 	// {{ end }}
@@ -289,7 +285,7 @@ func _SET_SINGLE_VALUE(_IS_OR_OP bool, _USES_SEL bool, _L_HAS_NULLS bool, _R_HAS
 		outputColVals[idx] = leftVal
 	} else {
 		// {{ if _R_HAS_NULLS }}
-		isRightNull := rightNulls.NullAt(idx)
+		isRightNull := rightNulls.NullAt(uint16(idx))
 		// {{ else }}
 		isRightNull := false
 		// {{ end }}
@@ -308,7 +304,7 @@ func _SET_SINGLE_VALUE(_IS_OR_OP bool, _USES_SEL bool, _L_HAS_NULLS bool, _R_HAS
 			outputColVals[idx] = false
 		} else {
 			// Rule 3.
-			outputNulls.SetNull(idx)
+			outputNulls.SetNull(uint16(idx))
 		}
 		// {{ else }}
 		// The rules for AND'ing two booleans are:
@@ -324,7 +320,7 @@ func _SET_SINGLE_VALUE(_IS_OR_OP bool, _USES_SEL bool, _L_HAS_NULLS bool, _R_HAS
 			outputColVals[idx] = true
 		} else {
 			// Rule 3.
-			outputNulls.SetNull(idx)
+			outputNulls.SetNull(uint16(idx))
 		}
 		// {{ end }}
 	}
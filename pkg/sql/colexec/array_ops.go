@@ -0,0 +1,381 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ARRAY values are stored in coldata.Arrays vectors: the elements of all
+// arrays of a vector are stored in a single child vector of the physical type
+// of the elements, and each array is represented by a range of that child.
+
+// setArrayDatum sets the idx'th array of vec to the elements of the array
+// datum d which have the type elemTyp. Note that the arrays must be set in the
+// increasing order of idx.
+func setArrayDatum(vec coldata.Vec, idx int, d tree.Datum, elemTyp *types.T) error {
+	arr, ok := d.(*tree.DArray)
+	if !ok {
+		return errors.Errorf("expected *tree.DArray, found %s", reflect.TypeOf(d))
+	}
+	arrays := vec.Array()
+	if len(arr.Array) > 0 {
+		arrays.SetElemType(typeconv.FromColumnType(elemTyp))
+	}
+	start := arrays.Set(idx, len(arr.Array))
+	child := arrays.Child()
+	datumToPhysicalFn := typeconv.GetDatumToPhysicalFn(elemTyp)
+	for i, elem := range arr.Array {
+		if elem == tree.DNull {
+			child.Nulls().SetNull64(uint64(start + i))
+			continue
+		}
+		v, err := datumToPhysicalFn(elem)
+		if err != nil {
+			return err
+		}
+		coldata.SetValueAt(child, v, start+i, child.Type())
+	}
+	return nil
+}
+
+// arrayRowsToColVec converts the columnIdx'th column of ARRAY type columnType
+// from rows to vec. It is the analog of EncDatumRowsToColVec for arrays.
+func arrayRowsToColVec(
+	rows sqlbase.EncDatumRows,
+	vec coldata.Vec,
+	columnIdx int,
+	columnType *types.T,
+	alloc *sqlbase.DatumAlloc,
+) error {
+	for i := range rows {
+		row := rows[i]
+		if row[columnIdx].Datum == nil {
+			if err := row[columnIdx].EnsureDecoded(columnType, alloc); err != nil {
+				return err
+			}
+		}
+		datum := row[columnIdx].Datum
+		if datum == tree.DNull {
+			vec.Nulls().SetNull(uint16(i))
+			continue
+		}
+		if err := setArrayDatum(vec, i, datum, columnType.ArrayContents()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewArrayProjectionOp returns a projection operator which projects into
+// outputIdx Vec the arrays that consist of the values of inputCols columns
+// (the ARRAY[...] constructor). All of the input columns must be of elemType
+// physical type.
+func NewArrayProjectionOp(
+	allocator *Allocator, input Operator, inputCols []int, elemType coltypes.T, outputIdx int,
+) Operator {
+	return &projArrayOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		inputCols:    inputCols,
+		elemType:     elemType,
+		outputIdx:    outputIdx,
+	}
+}
+
+// projArrayOp is an Operator that constructs an array out of the values of
+// the input columns for every tuple. Note that the constructed array is never
+// NULL (although its elements can be).
+type projArrayOp struct {
+	OneInputNode
+	allocator *Allocator
+	inputCols []int
+	elemType  coltypes.T
+	outputIdx int
+}
+
+var _ Operator = &projArrayOp{}
+
+func (o *projArrayOp) Init() {
+	o.input.Init()
+}
+
+func (o *projArrayOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, coltypes.Array, o.outputIdx)
+	projVec := batch.ColVec(o.outputIdx)
+	arrays := projVec.Array()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if len(o.inputCols) > 0 {
+			arrays.SetElemType(o.elemType)
+		}
+		sel := batch.Selection()
+		for i := 0; i < int(n); i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			start := arrays.Set(rowIdx, len(o.inputCols))
+			for j, colIdx := range o.inputCols {
+				// Copy takes care of the NULL elements as well.
+				arrays.Child().Copy(
+					coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							ColType:     o.elemType,
+							Src:         batch.ColVec(colIdx),
+							DestIdx:     uint64(start + j),
+							SrcStartIdx: uint64(rowIdx),
+							SrcEndIdx:   uint64(rowIdx + 1),
+						},
+					},
+				)
+			}
+		}
+	})
+	return batch
+}
+
+// GetArrayIndexProjectionOperator returns a projection operator which projects
+// into resultIdx Vec the element with the constant index of the arrays in
+// colIdx Vec (the arr[index] expression). The arrays are indexed starting from
+// 1, and if the index is out of bounds of an array, the result is NULL.
+func GetArrayIndexProjectionOperator(
+	allocator *Allocator,
+	input Operator,
+	colIdx int,
+	resultIdx int,
+	elemType coltypes.T,
+	index tree.Datum,
+) (Operator, error) {
+	d, ok := index.(*tree.DInt)
+	if !ok {
+		return nil, errors.Errorf("unsupported array index of type %s", index.ResolvedType())
+	}
+	return &projArrayIndexOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    resultIdx,
+		elemType:     elemType,
+		index:        int(*d),
+	}, nil
+}
+
+// projArrayIndexOp is an Operator that projects into outputIdx Vec the index'th
+// (1-based) elements of the arrays in colIdx Vec.
+type projArrayIndexOp struct {
+	OneInputNode
+	allocator *Allocator
+	colIdx    int
+	outputIdx int
+	elemType  coltypes.T
+	index     int
+}
+
+var _ Operator = &projArrayIndexOp{}
+
+func (o *projArrayIndexOp) Init() {
+	o.input.Init()
+}
+
+func (o *projArrayIndexOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, o.elemType, o.outputIdx)
+	vec := batch.ColVec(o.colIdx)
+	arrays := vec.Array()
+	projVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < int(n); i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if vec.Nulls().NullAt(uint16(rowIdx)) {
+				projVec.Nulls().SetNull(uint16(rowIdx))
+				continue
+			}
+			start, end := arrays.Bounds(rowIdx)
+			elemIdx := start + o.index - 1
+			if o.index < 1 || elemIdx >= end {
+				projVec.Nulls().SetNull(uint16(rowIdx))
+				continue
+			}
+			// Copy takes care of the NULL elements as well.
+			projVec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						ColType:     o.elemType,
+						Src:         arrays.Child(),
+						DestIdx:     uint64(rowIdx),
+						SrcStartIdx: uint64(elemIdx),
+						SrcEndIdx:   uint64(elemIdx + 1),
+					},
+				},
+			)
+		}
+	})
+	return batch
+}
+
+// NewUnnestOperator returns an operator which expands every tuple of its input
+// into as many tuples as there are elements in the array in arrayColIdx column
+// (the unnest generator). The output consists of all of the input columns
+// followed by a column of elemType type with the elements of the arrays.
+// Tuples with NULL or empty arrays don't produce any output.
+func NewUnnestOperator(
+	allocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	arrayColIdx int,
+	elemType coltypes.T,
+) Operator {
+	outputTypes := make([]coltypes.T, len(inputTypes)+1)
+	copy(outputTypes, inputTypes)
+	outputTypes[len(inputTypes)] = elemType
+	return &unnestOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		inputTypes:   inputTypes,
+		arrayColIdx:  arrayColIdx,
+		outputTypes:  outputTypes,
+		repeatedSel:  make([]int, coldata.BatchSize()),
+	}
+}
+
+// unnestOp is an Operator that expands the arrays of its input. It emits the
+// elements of the arrays of one input batch over possibly multiple output
+// batches, so it keeps track of the position it stopped at.
+type unnestOp struct {
+	OneInputNode
+	allocator   *Allocator
+	inputTypes  []coltypes.T
+	arrayColIdx int
+	outputTypes []coltypes.T
+
+	output coldata.Batch
+	// batch is the input batch which arrays are currently being expanded.
+	batch coldata.Batch
+	// curIdx is the index (into the selection vector if there is one) of the
+	// tuple of batch which array is currently being expanded, and curElemIdx is
+	// the index of the next element of that array to emit.
+	curIdx     int
+	curElemIdx int
+	// repeatedSel is a scratch selection vector that is used to repeat the
+	// values of the input columns.
+	repeatedSel []int
+}
+
+var _ Operator = &unnestOp{}
+
+func (o *unnestOp) Init() {
+	o.input.Init()
+	o.output = o.allocator.NewMemBatch(o.outputTypes)
+}
+
+func (o *unnestOp) Next(ctx context.Context) coldata.Batch {
+	o.output.ResetInternalBatch()
+	outputIdx := 0
+	maxOutputIdx := int(coldata.BatchSize())
+	o.allocator.PerformOperation(o.output.ColVecs(), func() {
+		for outputIdx < maxOutputIdx {
+			if o.batch == nil || o.curIdx == int(o.batch.Length()) {
+				if o.batch != nil && o.batch.Length() == 0 {
+					// The input has been exhausted.
+					return
+				}
+				o.batch = o.input.Next(ctx)
+				o.curIdx, o.curElemIdx = 0, 0
+				if o.batch.Length() == 0 {
+					return
+				}
+			}
+			rowIdx := o.curIdx
+			if sel := o.batch.Selection(); sel != nil {
+				rowIdx = sel[o.curIdx]
+			}
+			vec := o.batch.ColVec(o.arrayColIdx)
+			if vec.Nulls().NullAt(uint16(rowIdx)) {
+				o.curIdx++
+				continue
+			}
+			arrays := vec.Array()
+			start, end := arrays.Bounds(rowIdx)
+			toEmit := end - start - o.curElemIdx
+			if toEmit > maxOutputIdx-outputIdx {
+				toEmit = maxOutputIdx - outputIdx
+			}
+			if toEmit > 0 {
+				o.emit(rowIdx, arrays, start+o.curElemIdx, toEmit, outputIdx)
+				outputIdx += toEmit
+				o.curElemIdx += toEmit
+			}
+			if start+o.curElemIdx == end {
+				o.curIdx++
+				o.curElemIdx = 0
+			}
+		}
+	})
+	o.output.SetLength(uint16(outputIdx))
+	return o.output
+}
+
+// emit writes toEmit tuples starting at outputIdx position of the output. The
+// tuples consist of the values of rowIdx tuple of the current input batch and
+// of the elements of the arrays starting at elemIdx.
+func (o *unnestOp) emit(rowIdx int, arrays *coldata.Arrays, elemIdx, toEmit, outputIdx int) {
+	repeatedSel := o.repeatedSel[:toEmit]
+	for i := range repeatedSel {
+		repeatedSel[i] = rowIdx
+	}
+	for i, t := range o.inputTypes {
+		o.output.ColVec(i).Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					ColType:   t,
+					Src:       o.batch.ColVec(i),
+					DestIdx:   uint64(outputIdx),
+					SrcEndIdx: uint64(toEmit),
+					Sel:       repeatedSel,
+				},
+			},
+		)
+	}
+	elemType := o.outputTypes[len(o.inputTypes)]
+	o.output.ColVec(len(o.inputTypes)).Copy(
+		coldata.CopySliceArgs{
+			SliceArgs: coldata.SliceArgs{
+				ColType:     elemType,
+				Src:         arrays.Child(),
+				DestIdx:     uint64(outputIdx),
+				SrcStartIdx: uint64(elemIdx),
+				SrcEndIdx:   uint64(elemIdx + toEmit),
+			},
+		},
+	)
+}
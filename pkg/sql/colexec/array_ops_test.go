@@ -0,0 +1,111 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestArrayIndexProjectionOperator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tups := tuples{{1, 2}, {3, nil}, {nil, 4}}
+	for _, tc := range []struct {
+		index    int
+		expected tuples
+	}{
+		{
+			index:    1,
+			expected: tuples{{1, 2, 1}, {3, nil, 3}, {nil, 4, nil}},
+		},
+		{
+			index:    2,
+			expected: tuples{{1, 2, 2}, {3, nil, nil}, {nil, 4, 4}},
+		},
+		{
+			// Out of bounds indices result in NULLs.
+			index:    3,
+			expected: tuples{{1, 2, nil}, {3, nil, nil}, {nil, 4, nil}},
+		},
+		{
+			index:    0,
+			expected: tuples{{1, 2, nil}, {3, nil, nil}, {nil, 4, nil}},
+		},
+	} {
+		t.Run(fmt.Sprintf("index=%d", tc.index), func(t *testing.T) {
+			runTests(t, []tuples{tups}, tc.expected, orderedVerifier,
+				func(input []Operator) (Operator, error) {
+					// ARRAY[@1, @2][index]
+					arrayOp := NewArrayProjectionOp(
+						testAllocator, input[0], []int{0, 1}, coltypes.Int64, 2, /* outputIdx */
+					)
+					indexOp, err := GetArrayIndexProjectionOperator(
+						testAllocator, arrayOp, 2 /* colIdx */, 3 /* resultIdx */, coltypes.Int64,
+						tree.NewDInt(tree.DInt(tc.index)),
+					)
+					if err != nil {
+						return nil, err
+					}
+					return NewSimpleProjectOp(indexOp, 4 /* numInputCols */, []uint32{0, 1, 3}), nil
+				})
+		})
+	}
+}
+
+func TestUnnestOperator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	// The input of the last test case is large enough so that the elements of
+	// the arrays of a single input batch don't fit into one output batch.
+	var largeTups, largeExpected tuples
+	for i := 0; i < int(coldata.BatchSize())+3; i++ {
+		largeTups = append(largeTups, tuple{i, -i})
+		largeExpected = append(largeExpected, tuple{i, -i, i}, tuple{i, -i, -i})
+	}
+	for _, tc := range []struct {
+		desc     string
+		tups     tuples
+		expected tuples
+	}{
+		{
+			desc: "small",
+			tups: tuples{{1, 2}, {3, nil}, {nil, nil}},
+			expected: tuples{
+				{1, 2, 1}, {1, 2, 2}, {3, nil, 3}, {3, nil, nil}, {nil, nil, nil}, {nil, nil, nil},
+			},
+		},
+		{
+			desc:     "large",
+			tups:     largeTups,
+			expected: largeExpected,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			runTests(t, []tuples{tc.tups}, tc.expected, orderedVerifier,
+				func(input []Operator) (Operator, error) {
+					// unnest(ARRAY[@1, @2])
+					arrayOp := NewArrayProjectionOp(
+						testAllocator, input[0], []int{0, 1}, coltypes.Int64, 2, /* outputIdx */
+					)
+					unnestOp := NewUnnestOperator(
+						testAllocator, arrayOp,
+						[]coltypes.T{coltypes.Int64, coltypes.Int64, coltypes.Array},
+						2 /* arrayColIdx */, coltypes.Int64,
+					)
+					return NewSimpleProjectOp(unnestOp, 4 /* numInputCols */, []uint32{0, 1, 3}), nil
+				})
+		})
+	}
+}
@@ -67,7 +67,7 @@ func (p *boolVecToSelOp) Next(ctx context.Context) coldata.Batch {
 				if outputCol[i] {
 					inc = 1
 				}
-				sel[idx] = uint16(i)
+				sel[idx] = i
 				idx += inc
 			}
 		}
@@ -85,9 +85,8 @@ func (p *boolVecToSelOp) Init() {
 	p.input.Init()
 }
 
-func boolVecToSel64(vec []bool, sel []uint64) []uint64 {
-	l := uint64(len(vec))
-	for i := uint64(0); i < l; i++ {
+func boolVecToSel(vec []bool, sel []int) []int {
+	for i := range vec {
 		if vec[i] {
 			sel = append(sel, i)
 		}
@@ -143,7 +142,7 @@ func (d selBoolOp) Next(ctx context.Context) coldata.Batch {
 		if sel != nil {
 			sel = sel[:n]
 			for _, i := range sel {
-				if nulls.NullAt(i) {
+				if nulls.NullAt(uint16(i)) {
 					outputCol[i] = false
 				}
 			}
@@ -67,7 +67,7 @@ func (b *bufferedBatch) ColVecs() []coldata.Vec {
 
 // Selection is not implemented because the tuples should only be appended to
 // bufferedBatch, and Append does the deselection step.
-func (b *bufferedBatch) Selection() []uint16 {
+func (b *bufferedBatch) Selection() []int {
 	return nil
 }
 
@@ -102,6 +102,14 @@ func (b *bufferedBatch) ResetInternalBatch() {
 	execerror.VectorizedInternalPanic("ResetInternalBatch() should not be called on bufferedBatch")
 }
 
+// Clone is not implemented because bufferedBatch is not meant to be used by
+// any operator other than its "owner".
+func (b *bufferedBatch) Clone() coldata.Batch {
+	execerror.VectorizedInternalPanic("Clone() should not be called on bufferedBatch")
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
 // reset resets the state of the buffered group so that we can reuse the
 // underlying memory. Note that the underlying memory is not released, so there
 // is no need to update memory account while performing this operation.
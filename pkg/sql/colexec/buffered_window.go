@@ -0,0 +1,344 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/errors"
+)
+
+// bufferedWindower is the interface that window functions which need to see
+// the whole partition before being able to produce any output need to
+// implement. Such functions are driven by bufferedWindowOp which takes care of
+// buffering up the tuples of a single partition.
+type bufferedWindower interface {
+	// startPartition is called once all tuples of a partition have been
+	// buffered into partition. peerGroupEnd[i] contains the index of the first
+	// tuple that is not a peer of the ith tuple (i.e. the first tuple of the
+	// next peer group or the partition length if the ith tuple is in the last
+	// peer group).
+	startPartition(partition *bufferedBatch, peerGroupEnd []uint64)
+	// compute populates outputVec at positions [0, endIdx-startIdx) with the
+	// results of the window function for tuples [startIdx, endIdx) of the
+	// partition that was last passed to startPartition.
+	compute(outputVec coldata.Vec, startIdx, endIdx uint64)
+}
+
+// bufferedWindowState represents the state of the bufferedWindowOp.
+type bufferedWindowState int
+
+const (
+	// windowBuffering is the state in which bufferedWindowOp reads the tuples
+	// from its input until the current partition is complete.
+	windowBuffering bufferedWindowState = iota
+	// windowEmitting is the state in which bufferedWindowOp emits the tuples of
+	// the fully buffered partition along with the window function output.
+	windowEmitting
+	// windowFinished is the state in which bufferedWindowOp has emitted all
+	// partitions.
+	windowFinished
+)
+
+// newBufferedWindowOp returns an operator that buffers all tuples of each
+// partition in memory before handing them over to windower. input *must*
+// already be ordered on the partitioning columns (as marked by true values in
+// the partitionColIdx'th column, -1 if there is no partitioning) and then on
+// orderingCols. inputTypes must describe all columns of the batches produced
+// by input, and the output of windower (of type outputType) will be put at
+// position outputColIdx which must be equal to len(inputTypes).
+func newBufferedWindowOp(
+	allocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	outputType coltypes.T,
+	orderingCols []uint32,
+	outputColIdx int,
+	partitionColIdx int,
+	windower bufferedWindower,
+) (Operator, error) {
+	if outputColIdx != len(inputTypes) {
+		return nil, errors.AssertionFailedf(
+			"buffered window function output column %d should be appended to %d input columns",
+			outputColIdx, len(inputTypes),
+		)
+	}
+	var peersCol []bool
+	if len(orderingCols) > 0 {
+		var err error
+		input, peersCol, err = OrderedDistinctColsToOperators(input, orderingCols, inputTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	outputTypes := make([]coltypes.T, len(inputTypes)+1)
+	copy(outputTypes, inputTypes)
+	outputTypes[outputColIdx] = outputType
+	return &bufferedWindowOp{
+		OneInputNode:    NewOneInputNode(input),
+		allocator:       allocator,
+		inputTypes:      inputTypes,
+		outputTypes:     outputTypes,
+		outputColIdx:    outputColIdx,
+		partitionColIdx: partitionColIdx,
+		peersCol:        peersCol,
+		windower:        windower,
+	}, nil
+}
+
+// bufferedWindowOp is an operator that buffers up a single partition at a time
+// and uses a bufferedWindower to compute the output of a window function. The
+// buffering happens across batch boundaries: a partition can span multiple
+// input batches, and a single input batch can contain multiple partitions. In
+// the latter case the remainder of the input batch is held on to (without
+// calling Next on the input) until the current partition has been emitted.
+type bufferedWindowOp struct {
+	OneInputNode
+
+	allocator       *Allocator
+	inputTypes      []coltypes.T
+	outputTypes     []coltypes.T
+	outputColIdx    int
+	partitionColIdx int
+	// peersCol, if non-nil, is the output column of the chain of ordered
+	// distinct operators on the ordering columns in which true indicates that a
+	// new peer group begins with the corresponding tuple. If nil, all tuples
+	// within a partition are peers.
+	peersCol []bool
+	windower bufferedWindower
+
+	state bufferedWindowState
+	// partition contains the tuples of the current partition.
+	partition *bufferedBatch
+	// peerGroupStart marks the tuples in partition which begin a new peer
+	// group.
+	peerGroupStart []bool
+	// peerGroupEnd is computed once the partition is complete, see the comment
+	// on bufferedWindower.startPartition.
+	peerGroupEnd []uint64
+	// pendingBatch, if not nil, is the input batch which contains the tuples
+	// following the current partition. pendingIdx is the index of the first
+	// tuple of pendingBatch that has not been buffered yet.
+	pendingBatch coldata.Batch
+	pendingIdx   uint16
+	// emitIdx is the index of the first tuple in partition that hasn't been
+	// emitted yet.
+	emitIdx uint64
+	// output is the batch that is returned by Next. Its input columns are
+	// windows into partition (so the buffered tuples are not copied), and its
+	// output column is the only column of windowerOutput into which the
+	// windower writes its output.
+	output         coldata.Batch
+	windowerOutput coldata.Batch
+
+	// exportIdx is the index of the first tuple in partition that hasn't been
+	// exported yet, and exportBatch is the batch that the exported tuples are
+	// returned in. exportedEmitted is the number of tuples of the exported
+	// partition that had already been emitted when the exporting started.
+	exportIdx       uint64
+	exportBatch     coldata.Batch
+	exportedEmitted uint64
+}
+
+var _ InternalMemoryOperator = &bufferedWindowOp{}
+var _ bufferingInMemoryOperator = &bufferedWindowOp{}
+
+func (w *bufferedWindowOp) Init() {
+	w.input.Init()
+	w.partition = newBufferedBatch(w.allocator, w.inputTypes, 0 /* initialSize */)
+	w.output = w.allocator.NewMemBatchWithSize(w.outputTypes, 0 /* size */)
+	w.windowerOutput = w.allocator.NewMemBatch([]coltypes.T{w.outputTypes[w.outputColIdx]})
+	w.output.ReplaceCol(w.windowerOutput.ColVec(0), w.outputColIdx)
+	w.exportBatch = w.allocator.NewMemBatchWithSize(w.inputTypes, 0 /* size */)
+}
+
+// ExportBuffered is part of the bufferingInMemoryOperator interface. All of
+// the tuples of the current partition are exported, including the ones that
+// have already been emitted (see numExportedEmitted), so that the disk-backed
+// operator sees the whole partition, followed by the tuples that have been
+// read from the input but haven't been buffered yet.
+func (w *bufferedWindowOp) ExportBuffered() coldata.Batch {
+	if w.exportIdx == 0 && w.state == windowEmitting {
+		w.exportedEmitted = w.emitIdx
+	}
+	if w.exportIdx < w.partition.length {
+		endIdx := w.exportIdx + uint64(coldata.BatchSize())
+		if endIdx > w.partition.length {
+			endIdx = w.partition.length
+		}
+		for i := range w.inputTypes {
+			w.exportBatch.ReplaceCol(w.partition.colVecs[i].Window(w.exportIdx, endIdx), i)
+		}
+		w.exportBatch.SetLength(uint16(endIdx - w.exportIdx))
+		w.exportIdx = endIdx
+		return w.exportBatch
+	}
+	if w.pendingBatch != nil {
+		batch := w.pendingBatch
+		w.pendingBatch = nil
+		n := batch.Length()
+		if sel := batch.Selection(); sel != nil {
+			copy(sel, sel[w.pendingIdx:n])
+			batch.SetLength(n - w.pendingIdx)
+			return batch
+		}
+		for i := range w.inputTypes {
+			w.exportBatch.ReplaceCol(batch.ColVec(i).Window(uint64(w.pendingIdx), uint64(n)), i)
+		}
+		w.exportBatch.SetLength(n - w.pendingIdx)
+		return w.exportBatch
+	}
+	return coldata.ZeroBatch
+}
+
+// numExportedEmitted returns the number of the exported tuples that had
+// already been emitted by the operator before the exporting started. The
+// disk-backed operator must not emit them again.
+func (w *bufferedWindowOp) numExportedEmitted() uint64 {
+	return w.exportedEmitted
+}
+
+// InternalMemoryUsage is part of the InternalMemoryOperator interface.
+func (w *bufferedWindowOp) InternalMemoryUsage() int {
+	// The peers columns grow with the partition, but we account only for their
+	// initial size here.
+	return int(coldata.BatchSize()) * (sizeOfBool + sizeOfInt64)
+}
+
+func (w *bufferedWindowOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch w.state {
+		case windowBuffering:
+			if w.pendingBatch == nil {
+				batch := w.input.Next(ctx)
+				if batch.Length() == 0 {
+					if w.partition.length == 0 {
+						w.state = windowFinished
+						continue
+					}
+					w.startEmitting()
+					continue
+				}
+				w.pendingBatch, w.pendingIdx = batch, 0
+			}
+			if w.bufferPending() {
+				w.startEmitting()
+			}
+		case windowEmitting:
+			if w.emitIdx == w.partition.length {
+				// The batch returned by the previous call to Next contained windows
+				// into the partition, so the partition is reset only now that the
+				// batch has been consumed.
+				w.partition.reset()
+				w.peerGroupStart = w.peerGroupStart[:0]
+				w.emitIdx = 0
+				w.state = windowBuffering
+				continue
+			}
+			n := w.partition.length - w.emitIdx
+			if n > uint64(coldata.BatchSize()) {
+				n = uint64(coldata.BatchSize())
+			}
+			w.windowerOutput.ResetInternalBatch()
+			w.allocator.PerformOperation(w.windowerOutput.ColVecs(), func() {
+				w.windower.compute(w.windowerOutput.ColVec(0), w.emitIdx, w.emitIdx+n)
+			})
+			w.output.SetSelection(false)
+			for i := range w.inputTypes {
+				w.output.ReplaceCol(w.partition.colVecs[i].Window(w.emitIdx, w.emitIdx+n), i)
+			}
+			w.emitIdx += n
+			w.output.SetLength(uint16(n))
+			return w.output
+		case windowFinished:
+			return coldata.ZeroBatch
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected bufferedWindowState %d", w.state))
+		}
+	}
+}
+
+// bufferPending appends the tuples of pendingBatch that belong to the current
+// partition to the buffer and returns whether the current partition is
+// complete (i.e. a tuple of the next partition has been encountered).
+func (w *bufferedWindowOp) bufferPending() bool {
+	batch := w.pendingBatch
+	n := batch.Length()
+	sel := batch.Selection()
+	var partitionCol []bool
+	if w.partitionColIdx != -1 {
+		partitionCol = batch.ColVec(w.partitionColIdx).Bool()
+	}
+	startIdx := w.pendingIdx
+	endIdx := startIdx
+	for ; endIdx < n; endIdx++ {
+		idx := int(endIdx)
+		if sel != nil {
+			idx = sel[endIdx]
+		}
+		newPartition := partitionCol != nil && partitionCol[idx]
+		if newPartition && (w.partition.length > 0 || endIdx > startIdx) {
+			break
+		}
+		// The first tuple of the partition always begins a new peer group. If
+		// there are no ordering columns, all tuples within the partition are
+		// peers.
+		newPeerGroup := w.partition.length == 0 && endIdx == startIdx
+		if w.peersCol != nil && w.peersCol[idx] {
+			newPeerGroup = true
+		}
+		w.peerGroupStart = append(w.peerGroupStart, newPeerGroup)
+	}
+	w.allocator.PerformOperation(w.partition.colVecs, func() {
+		for i, t := range w.inputTypes {
+			w.partition.colVecs[i].Append(
+				coldata.SliceArgs{
+					ColType:     t,
+					Src:         batch.ColVec(i),
+					Sel:         sel,
+					DestIdx:     w.partition.length,
+					SrcStartIdx: uint64(startIdx),
+					SrcEndIdx:   uint64(endIdx),
+				},
+			)
+		}
+	})
+	w.partition.length += uint64(endIdx - startIdx)
+	if endIdx == n {
+		w.pendingBatch = nil
+		return false
+	}
+	w.pendingIdx = endIdx
+	return true
+}
+
+// startEmitting prepares the peer groups information of the fully buffered
+// partition and transitions the operator into emitting state.
+func (w *bufferedWindowOp) startEmitting() {
+	length := w.partition.length
+	if uint64(cap(w.peerGroupEnd)) < length {
+		w.peerGroupEnd = make([]uint64, length)
+	}
+	w.peerGroupEnd = w.peerGroupEnd[:length]
+	end := length
+	for i := int64(length) - 1; i >= 0; i-- {
+		w.peerGroupEnd[i] = end
+		if w.peerGroupStart[i] {
+			end = uint64(i)
+		}
+	}
+	w.windower.startPartition(w.partition, w.peerGroupEnd)
+	w.state = windowEmitting
+}
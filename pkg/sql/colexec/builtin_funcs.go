@@ -61,14 +61,14 @@ func (b *defaultBuiltinFuncOperator) Next(ctx context.Context) coldata.Batch {
 			for i := uint16(0); i < n; i++ {
 				rowIdx := i
 				if sel != nil {
-					rowIdx = sel[i]
+					rowIdx = uint16(sel[i])
 				}
 
 				hasNulls := false
 
 				for j := range b.argumentCols {
 					col := batch.ColVec(b.argumentCols[j])
-					b.row[j] = PhysicalTypeColElemToDatum(col, rowIdx, b.da, &b.columnTypes[b.argumentCols[j]])
+					b.row[j] = PhysicalTypeColElemToDatum(col, rowIdx, &b.da, &b.columnTypes[b.argumentCols[j]])
 					hasNulls = hasNulls || b.row[j] == tree.DNull
 				}
 
@@ -87,15 +87,7 @@ func (b *defaultBuiltinFuncOperator) Next(ctx context.Context) coldata.Batch {
 				}
 
 				// Convert the datum into a physical type and write it out.
-				if res == tree.DNull {
-					batch.ColVec(b.outputIdx).Nulls().SetNull(rowIdx)
-				} else {
-					converted, err := b.converter(res)
-					if err != nil {
-						execerror.VectorizedInternalPanic(err)
-					}
-					coldata.SetValueAt(output, converted, rowIdx, b.outputPhysType)
-				}
+				setDatumInVec(output, rowIdx, res, b.outputType, b.outputPhysType, b.converter)
 			}
 		},
 	)
@@ -138,7 +130,7 @@ func (s *substringFunctionOperator) Next(ctx context.Context) coldata.Batch {
 			for i := uint16(0); i < n; i++ {
 				rowIdx := i
 				if sel != nil {
-					rowIdx = sel[i]
+					rowIdx = uint16(sel[i])
 				}
 
 				// The substring operator does not support nulls. If any of the arguments
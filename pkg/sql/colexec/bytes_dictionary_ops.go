@@ -0,0 +1,109 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+)
+
+// bytesDictionaryCovers returns whether dict contains the codes of the first n
+// tuples of a batch with the selection vector sel (which can be nil).
+func bytesDictionaryCovers(dict *coldata.BytesDictionary, n uint64, sel []int) bool {
+	numCodes := len(dict.Codes())
+	if sel == nil {
+		return uint64(numCodes) >= n
+	}
+	for _, i := range sel[:n] {
+		if i >= numCodes {
+			return false
+		}
+	}
+	return true
+}
+
+// newSelBytesEqualConstOp returns a selection operator which selects the
+// tuples for which the Bytes value in colIdx Vec is equal (or not equal, if
+// negate is true) to constArg. If the Vec is dictionary-encoded (see
+// coldata.BytesDictionary), the constant is looked up in the dictionary once,
+// and only the codes are compared.
+func newSelBytesEqualConstOp(input Operator, colIdx int, constArg []byte, negate bool) Operator {
+	return &selBytesEqualConstOp{
+		selConstOpBase: selConstOpBase{
+			OneInputNode: NewOneInputNode(input),
+			colIdx:       colIdx,
+		},
+		constArg: constArg,
+		negate:   negate,
+	}
+}
+
+type selBytesEqualConstOp struct {
+	selConstOpBase
+	constArg []byte
+	negate   bool
+}
+
+var _ Operator = &selBytesEqualConstOp{}
+
+func (p *selBytesEqualConstOp) Init() {
+	p.input.Init()
+}
+
+func (p *selBytesEqualConstOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := p.input.Next(ctx)
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec := batch.ColVec(p.colIdx)
+		col := vec.Bytes()
+		nulls := vec.Nulls()
+		hasNulls := vec.MaybeHasNulls()
+		sel := batch.Selection()
+		if sel == nil {
+			batch.SetSelection(true)
+			sel = batch.Selection()[:n]
+			for i := range sel {
+				sel[i] = i
+			}
+		} else {
+			sel = sel[:n]
+		}
+		var idx uint16
+		if dict := col.Dictionary(); dict != nil && bytesDictionaryCovers(dict, uint64(n), sel) {
+			codes := dict.Codes()
+			constCode, found := dict.Lookup(p.constArg)
+			for _, i := range sel {
+				eq := found && codes[i] == constCode
+				if eq != p.negate && !(hasNulls && nulls.NullAt(uint16(i))) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			for _, i := range sel {
+				eq := bytes.Equal(col.Get(i), p.constArg)
+				if eq != p.negate && !(hasNulls && nulls.NullAt(uint16(i))) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
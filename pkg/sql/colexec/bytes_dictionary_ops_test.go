@@ -0,0 +1,117 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// dictionaryEncodingOp is a test utility Operator that dictionary-encodes the
+// Bytes Vec in colIdx of every batch it outputs.
+type dictionaryEncodingOp struct {
+	OneInputNode
+	colIdx int
+}
+
+var _ Operator = &dictionaryEncodingOp{}
+
+func (e *dictionaryEncodingOp) Init() {
+	e.input.Init()
+}
+
+func (e *dictionaryEncodingOp) Next(ctx context.Context) coldata.Batch {
+	batch := e.input.Next(ctx)
+	batch.ColVec(e.colIdx).Bytes().BuildDictionary(int(batch.Length()), coldata.MaxBatchSize)
+	return batch
+}
+
+func TestSelBytesEqualConstOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tups := tuples{{"a"}, {"b"}, {nil}, {"a"}, {""}, {"ab"}}
+	for _, dictionaryEncoded := range []bool{false, true} {
+		for _, tc := range []struct {
+			constArg string
+			negate   bool
+			expected tuples
+		}{
+			{constArg: "a", expected: tuples{{"a"}, {"a"}}},
+			{constArg: "a", negate: true, expected: tuples{{"b"}, {""}, {"ab"}}},
+			{constArg: "", expected: tuples{{""}}},
+			{constArg: "c", expected: tuples{}},
+			{constArg: "c", negate: true, expected: tuples{{"a"}, {"b"}, {"a"}, {""}, {"ab"}}},
+		} {
+			name := fmt.Sprintf("dictionaryEncoded=%t/const=%q/negate=%t", dictionaryEncoded, tc.constArg, tc.negate)
+			t.Run(name, func(t *testing.T) {
+				runTests(t, []tuples{tups}, tc.expected, orderedVerifier, func(input []Operator) (Operator, error) {
+					if dictionaryEncoded {
+						input[0] = &dictionaryEncodingOp{OneInputNode: NewOneInputNode(input[0])}
+					}
+					return newSelBytesEqualConstOp(input[0], 0 /* colIdx */, []byte(tc.constArg), tc.negate), nil
+				})
+			})
+		}
+	}
+}
+
+// TestHashBytesDictionary verifies that the hash values of a dictionary-encoded
+// key column are the same as of the one that isn't dictionary-encoded.
+func TestHashBytesDictionary(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+
+	typs := []coltypes.T{coltypes.Bytes, coltypes.Int64}
+	n := int(coldata.BatchSize())
+	batch := testAllocator.NewMemBatch(typs)
+	keys := batch.ColVecs()
+	for i := 0; i < n; i++ {
+		if rng.Intn(10) == 0 {
+			keys[0].Nulls().SetNull(uint16(i))
+		} else {
+			keys[0].Bytes().Set(i, []byte(fmt.Sprintf("value%d", rng.Intn(5))))
+		}
+		keys[1].Int64()[i] = rng.Int63n(3)
+	}
+	batch.SetLength(uint16(n))
+	var sel []int
+	for i := 0; i < n; i += 1 + rng.Intn(3) {
+		sel = append(sel, i)
+	}
+
+	ht := newHashTable(
+		testAllocator, hashTableBucketSize, typs, []uint32{0, 1}, []uint32{0, 1}, false, /* allowNullEquality */
+	)
+	for _, tc := range []struct {
+		sel   []int
+		nKeys uint64
+	}{
+		{nKeys: uint64(n)},
+		{sel: sel, nKeys: uint64(len(sel))},
+	} {
+		expected := make([]uint64, n)
+		ht.computeBuckets(ctx, expected, keys, tc.nKeys, tc.sel)
+		require.True(t, keys[0].Bytes().BuildDictionary(n, 6 /* maxSize */))
+		actual := make([]uint64, n)
+		ht.computeBuckets(ctx, actual, keys, tc.nKeys, tc.sel)
+		require.Equal(t, expected, actual)
+		// Setting any value invalidates the dictionary.
+		keys[0].Bytes().Set(n-1, keys[0].Bytes().Get(n-1))
+		require.Nil(t, keys[0].Bytes().Dictionary())
+	}
+}
@@ -12,18 +12,44 @@ package colexec
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // CancelChecker is an Operator that checks whether query cancellation has
-// occurred. The check happens on every batch.
+// occurred. By default, the check happens on every batch.
+//
+// A CancelChecker can also be embedded into other operators that use check()
+// during long-running operations, in which case the zero value checks the
+// context every defaultCancelCheckInterval calls. Additionally, the time
+// between the consecutive checks is bounded by maxDelay: if fewer calls than
+// the configured interval already take longer than that, the interval is
+// reduced, so that the operators that spend a lot of time on every call (like
+// sorting or building a hash table on big inputs) still respond to
+// cancellation quickly.
 type CancelChecker struct {
 	OneInputNode
 	NonExplainable
 
+	// interval is the maximum number of check() calls between the checks for
+	// context cancellation. It must be a power of 2. Zero means
+	// defaultCancelCheckInterval.
+	interval uint32
+	// maxDelay is the desired maximum time between the checks. Zero means
+	// defaultCancelCheckMaxDelay.
+	maxDelay time.Duration
+
+	// curInterval is the current number of check() calls between the checks.
+	// It is a power of 2 that doesn't exceed interval.
+	curInterval uint32
+	// lastCheck is the time of the last check for context cancellation.
+	lastCheck time.Time
+
 	// Number of times check() has been called since last context cancellation
 	// check.
 	callsSinceLastCheck uint32
@@ -36,35 +62,91 @@ func (c *CancelChecker) Init() {
 
 var _ Operator = &CancelChecker{}
 
-// NewCancelChecker creates a new CancelChecker.
+// NewCancelChecker creates a new CancelChecker that checks for cancellation on
+// every batch.
 func NewCancelChecker(op Operator) *CancelChecker {
-	return &CancelChecker{OneInputNode: NewOneInputNode(op)}
+	return NewCancelCheckerWithInterval(op, 1 /* interval */, 0 /* maxDelay */)
+}
+
+// NewCancelCheckerWithInterval creates a new CancelChecker that checks for
+// cancellation every interval batches (interval must be a power of 2) but no
+// less frequently than every maxDelay (zero means the default delay).
+func NewCancelCheckerWithInterval(
+	op Operator, interval uint32, maxDelay time.Duration,
+) *CancelChecker {
+	c := &CancelChecker{OneInputNode: NewOneInputNode(op)}
+	c.setInterval(interval, maxDelay)
+	return c
+}
+
+// setInterval configures the CancelChecker to check for cancellation every
+// interval calls to check() (interval must be a power of 2) but no less
+// frequently than every maxDelay (zero means the default delay).
+func (c *CancelChecker) setInterval(interval uint32, maxDelay time.Duration) {
+	if interval == 0 || interval&(interval-1) != 0 {
+		execerror.VectorizedInternalPanic(fmt.Sprintf("cancel check interval %d is not a power of 2", interval))
+	}
+	c.interval = interval
+	c.maxDelay = maxDelay
+	c.curInterval = interval
 }
 
 // Next is part of Operator interface.
 func (c *CancelChecker) Next(ctx context.Context) coldata.Batch {
-	c.checkEveryCall(ctx)
+	c.check(ctx)
 	return c.input.Next(ctx)
 }
 
-// Interval of check() calls to wait between checks for context cancellation.
-// The value is a power of 2 to allow the compiler to use bitwise AND instead
-// of division.
-const cancelCheckInterval = 1024
+// defaultCancelCheckInterval is the default interval of check() calls to wait
+// between checks for context cancellation. The value is a power of 2 to allow
+// the compiler to use bitwise AND instead of division.
+const defaultCancelCheckInterval = 1024
+
+// defaultCancelCheckMaxDelay is the default desired maximum time between the
+// checks for context cancellation.
+const defaultCancelCheckMaxDelay = 100 * time.Millisecond
 
 // check panics with a query canceled error if the associated query has been
-// canceled. The check is performed on every cancelCheckInterval'th call. This
+// canceled. The check is performed on every curInterval'th call. This
 // should be used only during long-running operations.
 func (c *CancelChecker) check(ctx context.Context) {
-	if c.callsSinceLastCheck%cancelCheckInterval == 0 {
+	if c.curInterval == 0 {
+		c.setInterval(defaultCancelCheckInterval, c.maxDelay)
+	}
+	if c.callsSinceLastCheck&(c.curInterval-1) == 0 {
 		c.checkEveryCall(ctx)
+		if c.interval > 1 {
+			c.adjustInterval()
+		}
+		c.callsSinceLastCheck = 0
 	}
-
-	// Increment. This may rollover when the 32-bit capacity is reached, but
-	// that's all right.
 	c.callsSinceLastCheck++
 }
 
+// adjustInterval adjusts the current interval between the checks according
+// to the time that has passed since the last check: the interval is reduced if
+// it took longer than maxDelay and is increased (up to the configured
+// interval) if it took much less.
+func (c *CancelChecker) adjustInterval() {
+	now := timeutil.Now()
+	if !c.lastCheck.IsZero() {
+		maxDelay := c.maxDelay
+		if maxDelay == 0 {
+			maxDelay = defaultCancelCheckMaxDelay
+		}
+		elapsed := now.Sub(c.lastCheck)
+		if elapsed > maxDelay {
+			for c.curInterval > 1 && elapsed > maxDelay {
+				c.curInterval /= 2
+				elapsed /= 2
+			}
+		} else if elapsed < maxDelay/4 && c.curInterval < c.interval {
+			c.curInterval *= 2
+		}
+	}
+	c.lastCheck = now
+}
+
 // checkEveryCall panics with query canceled error (which will be caught at the
 // materializer level and will be propagated forward as metadata) if the
 // associated query has been canceled. The check is performed on every call.
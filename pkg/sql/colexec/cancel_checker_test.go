@@ -13,6 +13,7 @@ package colexec
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
@@ -34,3 +35,53 @@ func TestCancelChecker(t *testing.T) {
 	})
 	require.Equal(t, sqlbase.QueryCanceledError, err)
 }
+
+func TestCancelCheckerInterval(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+
+	t.Run("Interval", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		op := NewCancelCheckerWithInterval(
+			NewNoop(NewRepeatableBatchSource(batch)), 4 /* interval */, time.Hour, /* maxDelay */
+		)
+		op.Next(ctx)
+		cancel()
+		// The cancellation is noticed only on the fourth batch after the last
+		// check.
+		for i := 0; i < 3; i++ {
+			require.NoError(t, execerror.CatchVectorizedRuntimeError(func() {
+				op.Next(ctx)
+			}))
+		}
+		err := execerror.CatchVectorizedRuntimeError(func() {
+			op.Next(ctx)
+		})
+		require.Equal(t, sqlbase.QueryCanceledError, err)
+	})
+
+	t.Run("MaxDelay", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var c CancelChecker
+		c.setInterval(defaultCancelCheckInterval, time.Nanosecond /* maxDelay */)
+		c.check(ctx)
+		// Every call takes longer than maxDelay, so after the next check the
+		// context is checked on every call.
+		time.Sleep(time.Millisecond)
+		for i := 0; i < defaultCancelCheckInterval; i++ {
+			c.check(ctx)
+		}
+		require.Equal(t, uint32(1), c.curInterval)
+		cancel()
+		err := execerror.CatchVectorizedRuntimeError(func() {
+			c.check(ctx)
+		})
+		require.Equal(t, sqlbase.QueryCanceledError, err)
+	})
+
+	t.Run("InvalidInterval", func(t *testing.T) {
+		require.Error(t, execerror.CatchVectorizedRuntimeError(func() {
+			NewCancelCheckerWithInterval(NewNoop(NewRepeatableBatchSource(batch)), 3 /* interval */, 0 /* maxDelay */)
+		}))
+	})
+}
@@ -34,14 +34,19 @@ type caseOp struct {
 	// origSel is a buffer used to keep track of the original selection vector of
 	// the input batch. We need to do this because we're going to destructively
 	// modify the selection vector in order to do the work of the case statement.
-	origSel []uint16
+	origSel []int
 	// prevSel is a buffer used to keep track of the selection vector before
 	// running a case arm (i.e. "previous to the current case arm"). We need to
 	// keep track of it because case arm will modify the selection vector of the
 	// batch, and then we need to figure out which tuples have not been matched
 	// by the current case arm (those present in the "previous" sel and not
 	// present in the "current" sel).
-	prevSel []uint16
+	prevSel []int
+	// armIdxs is only used when the output type is Bytes, and it contains the
+	// index of the arm (len(caseOps) for the ELSE arm) that matched each tuple.
+	// Flat bytes prohibit sets in arbitrary order, so the output is assembled
+	// only once all of the arms have been evaluated.
+	armIdxs []int
 }
 
 var _ InternalMemoryOperator = &caseOp{}
@@ -64,8 +69,9 @@ func (c *caseOp) Child(nth int, verbose bool) execinfra.OpNode {
 }
 
 func (c *caseOp) InternalMemoryUsage() int {
-	// We internally use two selection vectors, origSel and prevSel.
-	return 2 * sizeOfBatchSizeSelVector
+	// We internally use two selection vectors, origSel and prevSel, and
+	// possibly armIdxs.
+	return 2*sizeOfBatchSizeSelVector + len(c.armIdxs)*sizeOfInt
 }
 
 // NewCaseOp returns an operator that runs a case statement.
@@ -87,7 +93,7 @@ func NewCaseOp(
 	outputIdx int,
 	typ coltypes.T,
 ) Operator {
-	return &caseOp{
+	c := &caseOp{
 		allocator: allocator,
 		buffer:    buffer.(*bufferOp),
 		caseOps:   caseOps,
@@ -95,9 +101,13 @@ func NewCaseOp(
 		thenIdxs:  thenIdxs,
 		outputIdx: outputIdx,
 		typ:       typ,
-		origSel:   make([]uint16, coldata.BatchSize()),
-		prevSel:   make([]uint16, coldata.BatchSize()),
+		origSel:   make([]int, coldata.BatchSize()),
+		prevSel:   make([]int, coldata.BatchSize()),
 	}
+	if typ == coltypes.Bytes {
+		c.armIdxs = make([]int, coldata.BatchSize())
+	}
+	return c
 }
 
 func (c *caseOp) Init() {
@@ -159,21 +169,27 @@ func (c *caseOp) Next(ctx context.Context) coldata.Batch {
 			var subtractIdx int
 			var curIdx uint16
 			if batch.Length() > 0 {
-				inputCol := batch.ColVec(c.thenIdxs[i])
-				// Copy the results into the output vector, using the toSubtract selection
-				// vector to copy only the elements that we actually wrote according to the
-				// current case arm.
-				outputCol.Copy(
-					coldata.CopySliceArgs{
-						SliceArgs: coldata.SliceArgs{
-							ColType:     c.typ,
-							Src:         inputCol,
-							Sel:         toSubtract,
-							SrcStartIdx: 0,
-							SrcEndIdx:   uint64(len(toSubtract)),
-						},
-						SelOnDest: true,
-					})
+				if c.armIdxs != nil {
+					for _, idx := range toSubtract {
+						c.armIdxs[idx] = i
+					}
+				} else {
+					inputCol := batch.ColVec(c.thenIdxs[i])
+					// Copy the results into the output vector, using the toSubtract
+					// selection vector to copy only the elements that we actually
+					// wrote according to the current case arm.
+					outputCol.Copy(
+						coldata.CopySliceArgs{
+							SliceArgs: coldata.SliceArgs{
+								ColType:     c.typ,
+								Src:         inputCol,
+								Sel:         toSubtract,
+								SrcStartIdx: 0,
+								SrcEndIdx:   uint64(len(toSubtract)),
+							},
+							SelOnDest: true,
+						})
+				}
 				if prevHasSel {
 					// We have a previous selection vector, which represents the tuples
 					// that haven't yet been matched. Remove the ones that just matched
@@ -194,7 +210,7 @@ func (c *caseOp) Next(ctx context.Context) coldata.Batch {
 					// considering the entire batch of tuples for this case arm. Make a new
 					// selection vector with all of the tuples but the ones that just matched.
 					c.prevSel = c.prevSel[:cap(c.prevSel)]
-					for i := uint16(0); i < origLen; i++ {
+					for i := 0; i < int(origLen); i++ {
 						if subtractIdx < len(toSubtract) && toSubtract[subtractIdx] == i {
 							subtractIdx++
 							continue
@@ -229,18 +245,33 @@ func (c *caseOp) Next(ctx context.Context) coldata.Batch {
 		// that's done, restore the original selection vector and return the batch.
 		batch := c.elseOp.Next(ctx)
 		if batch.Length() > 0 {
-			inputCol := batch.ColVec(c.thenIdxs[len(c.thenIdxs)-1])
-			outputCol.Copy(
-				coldata.CopySliceArgs{
-					SliceArgs: coldata.SliceArgs{
-						ColType:     c.typ,
-						Src:         inputCol,
-						Sel:         batch.Selection(),
-						SrcStartIdx: 0,
-						SrcEndIdx:   uint64(batch.Length()),
-					},
-					SelOnDest: true,
-				})
+			if c.armIdxs != nil {
+				if sel := batch.Selection(); sel != nil {
+					for _, idx := range sel[:batch.Length()] {
+						c.armIdxs[idx] = len(c.caseOps)
+					}
+				} else {
+					for idx := range c.armIdxs[:batch.Length()] {
+						c.armIdxs[idx] = len(c.caseOps)
+					}
+				}
+			} else {
+				inputCol := batch.ColVec(c.thenIdxs[len(c.thenIdxs)-1])
+				outputCol.Copy(
+					coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							ColType:     c.typ,
+							Src:         inputCol,
+							Sel:         batch.Selection(),
+							SrcStartIdx: 0,
+							SrcEndIdx:   uint64(batch.Length()),
+						},
+						SelOnDest: true,
+					})
+			}
+		}
+		if c.armIdxs != nil {
+			c.copyBytesInOrder(outputCol, origLen, origHasSel)
 		}
 	})
 	// Restore the original state of the buffered batch.
@@ -251,3 +282,25 @@ func (c *caseOp) Next(ctx context.Context) coldata.Batch {
 	}
 	return c.buffer.batch
 }
+
+// copyBytesInOrder populates the Bytes output column with the results of the
+// arms that matched each tuple. The tuples are processed in the increasing
+// order of their indices as required by the flat bytes.
+func (c *caseOp) copyBytesInOrder(outputCol coldata.Vec, origLen uint16, origHasSel bool) {
+	for i := 0; i < int(origLen); i++ {
+		idx := i
+		if origHasSel {
+			idx = c.origSel[i]
+		}
+		outputCol.Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					ColType:     c.typ,
+					Src:         c.buffer.batch.ColVec(c.thenIdxs[c.armIdxs[idx]]),
+					DestIdx:     uint64(idx),
+					SrcStartIdx: uint64(idx),
+					SrcEndIdx:   uint64(idx) + 1,
+				},
+			})
+	}
+}
@@ -78,6 +78,21 @@ func TestCaseOp(t *testing.T) {
 			expected:   tuples{{nil}, {zero}, {nil}, {one}},
 			inputTypes: []types.T{*types.Int, *types.Int},
 		},
+		{
+			// Test the "simple" form of CASE.
+			tuples:     tuples{{1}, {2}, {nil}, {3}},
+			renderExpr: "CASE @1 WHEN 2 THEN 20 WHEN 3 THEN 30 ELSE 0 END",
+			expected:   tuples{{0}, {20}, {0}, {30}},
+			inputTypes: []types.T{*types.Int},
+		},
+		{
+			// Test the Bytes output type with the arms matching the tuples in
+			// arbitrary order.
+			tuples:     tuples{{1}, {2}, {nil}, {3}, {1}},
+			renderExpr: "CASE WHEN @1 = 3 THEN 'three' WHEN @1 = 1 THEN 'one' WHEN @1 > 1 THEN 'many' END",
+			expected:   tuples{{"one"}, {"many"}, {nil}, {"three"}, {"one"}},
+			inputTypes: []types.T{*types.Int},
+		},
 	} {
 		runTests(t, []tuples{tc.tuples}, tc.expected, orderedVerifier, func(inputs []Operator) (Operator, error) {
 			spec.Input[0].ColumnTypes = tc.inputTypes
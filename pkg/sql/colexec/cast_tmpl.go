@@ -142,8 +142,8 @@ func (c *castOpNullAny) Next(ctx context.Context) coldata.Batch {
 	if sel := batch.Selection(); sel != nil {
 		sel = sel[:n]
 		for _, i := range sel {
-			if vecNulls.NullAt(i) {
-				projNulls.SetNull(i)
+			if vecNulls.NullAt(uint16(i)) {
+				projNulls.SetNull(uint16(i))
 			} else {
 				execerror.VectorizedInternalPanic(errors.Errorf("unexpected non-null at index %d", i))
 			}
@@ -199,8 +199,8 @@ func (c *castOp_FROMTYPE_TOTYPE) Next(ctx context.Context) coldata.Batch {
 				if sel := batch.Selection(); sel != nil {
 					sel = sel[:n]
 					for _, i := range sel {
-						if vecNulls.NullAt(i) {
-							projNulls.SetNull(i)
+						if vecNulls.NullAt(uint16(i)) {
+							projNulls.SetNull(uint16(i))
 						} else {
 							v := _FROM_TYPE_UNSAFEGET(col, int(i))
 							var r _GOTYPE
@@ -84,9 +84,18 @@ type cTableInfo struct {
 	// does not contain any -1's. It is meant to be used only in logging.
 	allExtraValColOrdinals []int
 
+	// stringColOrdinals are the ordinals of the needed columns of the STRING
+	// family. The output vectors of these columns are dictionary-encoded when
+	// they have a small number of distinct values.
+	stringColOrdinals []int
+
 	// maxColumnFamilyID is the maximum possible family id for the configured
 	// table.
 	maxColumnFamilyID sqlbase.FamilyID
+	// singleKeyPerRow indicates whether every row of the configured index is
+	// stored in a single key. Note that this is the case for a secondary index
+	// without stored columns even if the table has multiple column families.
+	singleKeyPerRow bool
 
 	// knownPrefixLength is the number of bytes in the index key prefix this
 	// Fetcher is configured for. The index key prefix is the table id, index
@@ -194,9 +203,20 @@ type cFetcher struct {
 	// when beginning a new scan.
 	traceKV bool
 
+	// onlyCountRows is true if none of the columns are needed and every row is
+	// stored in a single key, in which case the fetcher only needs to count the
+	// kvs without decoding any of them. It is set when beginning a new scan.
+	onlyCountRows bool
+
 	// fetcher is the underlying fetcher that provides KVs.
 	fetcher *row.KVFetcher
 
+	// typs are the physical types of the columns of the table.
+	typs []coltypes.T
+	// maxCapacity is the maximum number of rows in the output batch, which is
+	// the batch size of the flow.
+	maxCapacity uint16
+
 	// machine contains fields that get updated during the run of the fetcher.
 	machine struct {
 		// state is the queue of next states of the state machine. The 0th entry
@@ -225,6 +245,13 @@ type cFetcher struct {
 
 		// batch is the output batch the fetcher writes to.
 		batch coldata.Batch
+		// capacity is the number of rows that fit into batch. The batches start
+		// small (so that the point lookups don't allocate and fill full-sized
+		// batches) and grow as long as they are filled up, up to maxCapacity.
+		capacity uint16
+		// lastBatchFull is set if the last emitted batch was filled up to
+		// capacity, so the next batch should be larger.
+		lastBatchFull bool
 
 		// colvecs is a slice of the ColVecs within batch, pulled out to avoid
 		// having to call batch.Vec too often in the tight loop.
@@ -245,6 +272,7 @@ type cFetcher struct {
 // index.
 func (rf *cFetcher) Init(
 	allocator *Allocator,
+	batchSize uint16,
 	reverse bool,
 	lockStr sqlbase.ScanLockingStrength,
 	returnRangeInfo bool,
@@ -291,6 +319,7 @@ func (rf *cFetcher) Init(
 		extraValColOrdinals:    oldTable.extraValColOrdinals[:0],
 		allIndexColOrdinals:    oldTable.allIndexColOrdinals[:0],
 		allExtraValColOrdinals: oldTable.allExtraValColOrdinals[:0],
+		stringColOrdinals:      oldTable.stringColOrdinals[:0],
 	}
 
 	typs := make([]coltypes.T, len(colDescriptors))
@@ -301,10 +330,20 @@ func (rf *cFetcher) Init(
 			// a placeholder Vec will be created.
 			return errors.Errorf("unhandled type %+v", &colDescriptors[i].Type)
 		}
+		if colDescriptors[i].Type.Family() == types.StringFamily && tableArgs.ValNeededForCol.Contains(i) {
+			table.stringColOrdinals = append(table.stringColOrdinals, i)
+		}
 	}
 
-	rf.machine.batch = allocator.NewMemBatch(typs)
+	rf.typs = typs
+	rf.maxCapacity = batchSize
+	rf.machine.capacity = cFetcherMinBatchCapacity
+	if rf.machine.capacity > rf.maxCapacity {
+		rf.machine.capacity = rf.maxCapacity
+	}
+	rf.machine.batch = allocator.NewMemBatchWithSize(typs, int(rf.machine.capacity))
 	rf.machine.colvecs = rf.machine.batch.ColVecs()
+	rf.machine.lastBatchFull = false
 
 	var err error
 
@@ -426,6 +465,12 @@ func (rf *cFetcher) Init(
 	if err != nil {
 		return err
 	}
+	table.singleKeyPerRow = keysPerRow == 1
+	if table.index.EncodingType == sqlbase.PrimaryIndexEncoding {
+		// A secondary index that uses the primary index encoding stores a key
+		// per column family regardless of its stored columns.
+		table.singleKeyPerRow = len(table.desc.Families) == 1
+	}
 	if keysPerRow > rf.maxKeysPerRow {
 		rf.maxKeysPerRow = keysPerRow
 	}
@@ -457,6 +502,8 @@ func (rf *cFetcher) StartScan(
 	}
 
 	rf.traceKV = traceKV
+	rf.onlyCountRows = !traceKV && !rf.mustDecodeIndexKey &&
+		len(rf.table.neededColsList) == 0 && rf.table.singleKeyPerRow
 
 	// If we have a limit hint, we limit the first batch size. Subsequent
 	// batches get larger to avoid making things too slow (e.g. in case we have
@@ -481,9 +528,35 @@ func (rf *cFetcher) StartScan(
 	rf.fetcher = f
 	rf.machine.lastRowPrefix = nil
 	rf.machine.state[0] = stateInitFetch
+	if limitHint > 0 {
+		// The scan is expected to produce limitHint rows, so we size the batch
+		// accordingly right away.
+		rf.growBatch(int(limitHint))
+	}
 	return nil
 }
 
+// cFetcherMinBatchCapacity is the capacity of the first batch of the cFetcher.
+// It is small enough for the point lookups not to pay for full-sized batches
+// while sparing the scans from emitting a long series of tiny batches before
+// their batches reach full size.
+const cFetcherMinBatchCapacity = 64
+
+// growBatch makes sure that the output batch can hold at least minCapacity
+// rows (capped at maxCapacity). It must only be called in between the
+// batches.
+func (rf *cFetcher) growBatch(minCapacity int) {
+	if maxCapacity := int(rf.maxCapacity); minCapacity > maxCapacity {
+		minCapacity = maxCapacity
+	}
+	if minCapacity <= int(rf.machine.capacity) {
+		return
+	}
+	rf.machine.batch = rf.adapter.allocator.ResetMaybeReallocate(rf.typs, rf.machine.batch, minCapacity)
+	rf.machine.colvecs = rf.machine.batch.ColVecs()
+	rf.machine.capacity = uint16(minCapacity)
+}
+
 // fetcherState is the state enum for nextBatch.
 type fetcherState int
 
@@ -570,11 +643,17 @@ const debugState = false
 
 // NextBatch is nextBatch with the addition of memory accounting.
 func (rf *cFetcher) NextBatch(ctx context.Context) (coldata.Batch, error) {
+	if rf.machine.lastBatchFull {
+		// The scan has produced a full batch, so it is likely to produce many
+		// more rows, and we use larger batches for them.
+		rf.growBatch(2 * int(rf.machine.capacity))
+	}
 	rf.adapter.ctx = ctx
 	rf.adapter.allocator.PerformOperation(
 		rf.machine.colvecs,
 		rf.nextAdapter,
 	)
+	rf.machine.lastBatchFull = rf.adapter.err == nil && rf.adapter.batch.Length() == rf.machine.capacity
 	return rf.adapter.batch, rf.adapter.err
 }
 
@@ -582,13 +661,16 @@ func (rf *cFetcher) nextAdapter() {
 	rf.adapter.batch, rf.adapter.err = rf.nextBatch(rf.adapter.ctx)
 }
 
-// nextBatch processes keys until we complete one batch of rows,
-// coldata.BatchSize() in length, which are returned in columnar format as a
-// coldata.Batch. The batch contains one Vec per table column, regardless of
+// nextBatch processes keys until we complete one batch of rows (the length of
+// which is determined by the capacity of the current batch), which are
+// returned in columnar format as a coldata.Batch. The batch contains one Vec per table column, regardless of
 // the index used; columns that are not needed (as per neededCols) are empty.
 // The Batch should not be modified and is only valid until the next call.
 // When there are no more rows, the Batch.Length is 0.
 func (rf *cFetcher) nextBatch(ctx context.Context) (coldata.Batch, error) {
+	if rf.onlyCountRows {
+		return rf.nextCountOnlyBatch(ctx)
+	}
 	for {
 		if debugState {
 			log.Infof(ctx, "State %s", rf.machine.state[0])
@@ -653,6 +735,7 @@ func (rf *cFetcher) nextBatch(ctx context.Context) (coldata.Batch, error) {
 					indexOrds = rf.table.allIndexColOrdinals
 				}
 				key, matches, foundNull, err = colencoding.DecodeIndexKeyToCols(
+					&rf.table.da,
 					rf.machine.colvecs,
 					rf.machine.rowIdx,
 					rf.table.desc,
@@ -747,7 +830,9 @@ func (rf *cFetcher) nextBatch(ctx context.Context) (coldata.Batch, error) {
 			if rf.traceKV {
 				log.VEventf(ctx, 2, "fetched: %s -> %s", prettyKey, prettyVal)
 			}
-			if len(rf.table.desc.Families) == 1 {
+			if rf.table.singleKeyPerRow {
+				// There can be no more keys for this row, so we don't need to
+				// fetch the next key to find out that it belongs to another row.
 				rf.machine.state[0] = stateFinalizeRow
 				rf.machine.state[1] = stateInitFetch
 				continue
@@ -847,9 +932,10 @@ func (rf *cFetcher) nextBatch(ctx context.Context) (coldata.Batch, error) {
 			}
 			rf.machine.rowIdx++
 			rf.shiftState()
-			if rf.machine.rowIdx >= coldata.BatchSize() {
+			if rf.machine.rowIdx >= rf.machine.capacity {
 				rf.pushState(stateResetBatch)
 				rf.machine.batch.SetLength(rf.machine.rowIdx)
+				rf.buildBytesDictionaries()
 				rf.machine.rowIdx = 0
 				return rf.machine.batch, nil
 			}
@@ -857,6 +943,7 @@ func (rf *cFetcher) nextBatch(ctx context.Context) (coldata.Batch, error) {
 		case stateEmitLastBatch:
 			rf.machine.state[0] = stateFinished
 			rf.machine.batch.SetLength(rf.machine.rowIdx)
+			rf.buildBytesDictionaries()
 			rf.machine.rowIdx = 0
 			return rf.machine.batch, nil
 
@@ -866,6 +953,43 @@ func (rf *cFetcher) nextBatch(ctx context.Context) (coldata.Batch, error) {
 	}
 }
 
+// nextCountOnlyBatch is the fast path of nextBatch for the scans that don't
+// need any of the columns (like the ones for SELECT count(*)) of an index with
+// a single key per row. Every kv is a separate row, so the rows are counted
+// from the length prefixes of the kvs without decoding the keys or the values,
+// and the returned batch has the right length but no data in its Vecs.
+func (rf *cFetcher) nextCountOnlyBatch(ctx context.Context) (coldata.Batch, error) {
+	if rf.machine.state[0] == stateFinished {
+		return coldata.ZeroBatch, nil
+	}
+	n, err := rf.fetcher.SkipKVs(ctx, int(rf.machine.capacity))
+	if err != nil {
+		return nil, execerror.NewStorageError(err)
+	}
+	if n == 0 {
+		rf.machine.state[0] = stateFinished
+		return coldata.ZeroBatch, nil
+	}
+	rf.machine.batch.ResetInternalBatch()
+	rf.machine.batch.SetLength(uint16(n))
+	return rf.machine.batch, nil
+}
+
+// maxBytesDictionarySize is the maximum number of distinct values in the
+// dictionary-encoded output vectors of the cFetcher.
+const maxBytesDictionarySize = 64
+
+// buildBytesDictionaries dictionary-encodes the output vectors of the STRING
+// columns of the current batch that have at most maxBytesDictionarySize
+// distinct values, which allows for the operations on enum-like columns to be
+// performed on the dictionary codes (see coldata.BytesDictionary).
+func (rf *cFetcher) buildBytesDictionaries() {
+	n := int(rf.machine.batch.Length())
+	for _, colIdx := range rf.table.stringColOrdinals {
+		rf.machine.colvecs[colIdx].Bytes().BuildDictionary(n, maxBytesDictionarySize)
+	}
+}
+
 // shiftState shifts the state queue to the left, removing the first element and
 // clearing the last element.
 func (rf *cFetcher) shiftState() {
@@ -881,7 +1005,7 @@ func (rf *cFetcher) pushState(state fetcherState) {
 // getDatumAt returns the converted datum object at the given (colIdx, rowIdx).
 // This function is meant for tracing and should not be used in hot paths.
 func (rf *cFetcher) getDatumAt(colIdx int, rowIdx uint16, typ types.T) tree.Datum {
-	return PhysicalTypeColElemToDatum(rf.machine.colvecs[colIdx], rowIdx, rf.table.da, &typ)
+	return PhysicalTypeColElemToDatum(rf.machine.colvecs[colIdx], rowIdx, &rf.table.da, &typ)
 }
 
 // processValue processes the state machine's current value component, setting
@@ -981,6 +1105,7 @@ func (rf *cFetcher) processValue(
 					extraColOrds = table.allExtraValColOrdinals
 				}
 				valueBytes, _, err = colencoding.DecodeKeyValsToCols(
+					&table.da,
 					rf.machine.colvecs,
 					rf.machine.rowIdx,
 					extraColOrds,
@@ -1069,7 +1194,9 @@ func (rf *cFetcher) processValueSingle(
 				return prettyKey, "", nil
 			}
 			typ := &table.cols[idx].Type
-			err := colencoding.UnmarshalColumnValueToCol(rf.machine.colvecs[idx], rf.machine.rowIdx, typ, val)
+			err := colencoding.UnmarshalColumnValueToCol(
+				&table.da, rf.machine.colvecs[idx], rf.machine.rowIdx, typ, val,
+			)
 			if err != nil {
 				return "", "", err
 			}
@@ -1159,8 +1286,9 @@ func (rf *cFetcher) processValueBytes(
 		vec := rf.machine.colvecs[idx]
 
 		valTyp := &table.cols[idx].Type
-		valueBytes, err = colencoding.DecodeTableValueToCol(vec, rf.machine.rowIdx, typ, dataOffset, valTyp,
-			valueBytes)
+		valueBytes, err = colencoding.DecodeTableValueToCol(
+			&table.da, vec, rf.machine.rowIdx, typ, dataOffset, valTyp, valueBytes,
+		)
 		if err != nil {
 			return "", "", err
 		}
@@ -126,7 +126,7 @@ func newColBatchScan(
 	columnIdxMap := spec.Table.ColumnIdxMapWithMutations(returnMutations)
 	fetcher := cFetcher{}
 	if _, _, err := initCRowFetcher(
-		allocator, &fetcher, &spec.Table, int(spec.IndexIdx), columnIdxMap, spec.Reverse,
+		allocator, &fetcher, scanBatchSize(flowCtx), &spec.Table, int(spec.IndexIdx), columnIdxMap, spec.Reverse,
 		neededColumns, spec.IsCheck, spec.Visibility, spec.LockingStrength,
 	); err != nil {
 		return nil, err
@@ -146,10 +146,20 @@ func newColBatchScan(
 	}, nil
 }
 
+// scanBatchSize returns the maximum number of tuples in the batches produced
+// by the scans of the flow.
+func scanBatchSize(flowCtx *execinfra.FlowCtx) uint16 {
+	if flowCtx.ScanBatchSize == 0 || flowCtx.ScanBatchSize > coldata.BatchSize() {
+		return coldata.BatchSize()
+	}
+	return flowCtx.ScanBatchSize
+}
+
 // initCRowFetcher initializes a row.cFetcher. See initRowFetcher.
 func initCRowFetcher(
 	allocator *Allocator,
 	fetcher *cFetcher,
+	batchSize uint16,
 	desc *sqlbase.TableDescriptor,
 	indexIdx int,
 	colIdxMap map[sqlbase.ColumnID]int,
@@ -178,7 +188,7 @@ func initCRowFetcher(
 		ValNeededForCol:  valNeededForCol,
 	}
 	if err := fetcher.Init(
-		allocator, reverseScan, lockStr, true /* returnRangeInfo */, isCheck, tableArgs,
+		allocator, batchSize, reverseScan, lockStr, true /* returnRangeInfo */, isCheck, tableArgs,
 	); err != nil {
 		return nil, false, err
 	}
@@ -0,0 +1,193 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Collated strings are stored in coldata.Bytes vectors using their contents,
+// so the conversion to and from datums doesn't lose any information. However,
+// the contents can't be compared or hashed directly since the collation
+// defines the equality and the ordering of the strings. Instead, the operators
+// that need to compare collated strings are planned on top of the collation
+// keys (which are compared bytewise, see tree.DCollatedString) of such
+// strings that are projected by collationKeyOp.
+
+// collatedStringRowsToColVec converts the collated strings in columnIdx column
+// of rows to their contents in vec.
+func collatedStringRowsToColVec(
+	rows sqlbase.EncDatumRows,
+	vec coldata.Vec,
+	columnIdx int,
+	columnType *types.T,
+	alloc *sqlbase.DatumAlloc,
+) error {
+	col := vec.Bytes()
+	for i := range rows {
+		row := rows[i]
+		if row[columnIdx].Datum == nil {
+			if err := row[columnIdx].EnsureDecoded(columnType, alloc); err != nil {
+				return err
+			}
+		}
+		datum := row[columnIdx].Datum
+		if datum == tree.DNull {
+			vec.Nulls().SetNull(uint16(i))
+			continue
+		}
+		d, ok := datum.(*tree.DCollatedString)
+		if !ok {
+			return errors.AssertionFailedf("expected *tree.DCollatedString, found %T", datum)
+		}
+		col.Set(i, []byte(d.Contents))
+	}
+	return nil
+}
+
+// collationKeyDatum returns the collation key of the collated string d as
+// DBytes. Tuples of collated strings are converted element-wise, and all other
+// datums are returned unchanged.
+func collationKeyDatum(d tree.Datum) tree.Datum {
+	switch t := d.(type) {
+	case *tree.DCollatedString:
+		return tree.NewDBytes(tree.DBytes(t.Key))
+	case *tree.DTuple:
+		keys := make(tree.Datums, len(t.D))
+		contents := make([]types.T, len(t.D))
+		for i := range t.D {
+			keys[i] = collationKeyDatum(t.D[i])
+			contents[i] = *keys[i].ResolvedType()
+		}
+		return tree.NewDTuple(types.MakeTuple(contents), keys...)
+	}
+	return d
+}
+
+// NewCollationKeyOp returns a projection operator which projects into
+// outputIdx Vec the collation keys of the collated strings with the given
+// locale in colIdx Vec.
+func NewCollationKeyOp(
+	allocator *Allocator, input Operator, colIdx int, outputIdx int, locale string,
+) (Operator, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse locale %q", locale)
+	}
+	return &collationKeyOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    outputIdx,
+		collator:     collate.New(tag),
+	}, nil
+}
+
+// collationKeyOp is an Operator that projects into outputIdx Vec the collation
+// keys of the collated strings in colIdx Vec.
+type collationKeyOp struct {
+	OneInputNode
+	allocator *Allocator
+	colIdx    int
+	outputIdx int
+
+	collator *collate.Collator
+	buf      collate.Buffer
+}
+
+var _ Operator = &collationKeyOp{}
+
+func (o *collationKeyOp) Init() {
+	o.input.Init()
+}
+
+func (o *collationKeyOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, coltypes.Bytes, o.outputIdx)
+	vec := batch.ColVec(o.colIdx)
+	col := vec.Bytes()
+	projVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		// Note that the tuples are processed in the increasing order of the
+		// indices since projVec is a flat Bytes vector.
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				o.setKey(vec, col, projVec, i)
+			}
+		} else {
+			for i := 0; i < int(n); i++ {
+				o.setKey(vec, col, projVec, i)
+			}
+		}
+	})
+	return batch
+}
+
+// setKey sets the ith element of projVec to the collation key of the ith
+// collated string of col.
+func (o *collationKeyOp) setKey(vec coldata.Vec, col *coldata.Bytes, projVec coldata.Vec, i int) {
+	if vec.Nulls().NullAt(uint16(i)) {
+		projVec.Nulls().SetNull(uint16(i))
+		return
+	}
+	projVec.Bytes().Set(i, o.collator.Key(&o.buf, col.Get(i)))
+	o.buf.Reset()
+}
+
+// PlanOrderedSynchronizer returns an operator that merges inputs, with the
+// columns of columnTypes, which are ordered according to ordering. Unlike
+// NewOrderedSynchronizer, it supports the orderings on collated string
+// columns: the collation keys of such columns are projected on every input,
+// the inputs are merged according to the keys, and then the keys are
+// projected out.
+func PlanOrderedSynchronizer(
+	allocator *Allocator, inputs []Operator, columnTypes []types.T, ordering execinfrapb.Ordering,
+) (Operator, error) {
+	typs, err := typeconv.FromColumnTypes(columnTypes)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		keyedInputs   = make([]Operator, len(inputs))
+		keyedTyps     []coltypes.T
+		keyedOrdering execinfrapb.Ordering
+	)
+	for i := range inputs {
+		keyedInputs[i], keyedTyps, keyedOrdering, err = planCollationKeysForOrdering(
+			allocator, inputs[i], columnTypes, typs, ordering,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	op := Operator(NewOrderedSynchronizer(
+		allocator, keyedInputs, keyedTyps, execinfrapb.ConvertToColumnOrdering(keyedOrdering),
+	))
+	if len(keyedTyps) != len(typs) {
+		op = NewSimpleProjectOp(op, len(keyedTyps), firstColumns(len(typs)))
+	}
+	return op, nil
+}
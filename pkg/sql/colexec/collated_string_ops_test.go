@@ -0,0 +1,99 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// Note that in the German collation "ä" is ordered between "a" and "b" while
+// its contents are greater than "b" bytewise.
+
+func TestCollationKeyOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	runTestsWithTyps(
+		t,
+		[]tuples{{{"b"}, {"ä"}, {nil}, {"a"}}},
+		[][]coltypes.T{{coltypes.Bytes}},
+		tuples{{nil}, {"a"}, {"ä"}, {"b"}},
+		orderedVerifier,
+		func(input []Operator) (Operator, error) {
+			keyOp, err := NewCollationKeyOp(testAllocator, input[0], 0 /* colIdx */, 1 /* outputIdx */, "de")
+			if err != nil {
+				return nil, err
+			}
+			sorter, err := NewSorter(
+				testAllocator, keyOp, []coltypes.T{coltypes.Bytes, coltypes.Bytes},
+				[]execinfrapb.Ordering_Column{{ColIdx: 1}},
+			)
+			if err != nil {
+				return nil, err
+			}
+			return NewSimpleProjectOp(sorter, 2 /* numInputCols */, []uint32{0}), nil
+		},
+	)
+}
+
+func TestCollatedStringComparison(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+	typ := types.MakeCollatedString(types.String, "de")
+	b, err := tree.NewDCollatedString("b", "de", &evalCtx.CollationEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// @1 < 'b' COLLATE de
+	expr := tree.NewTypedComparisonExpr(tree.LT, tree.NewTypedOrdinalReference(0, typ), b)
+	runTestsWithTyps(
+		t,
+		[]tuples{{{"b"}, {"ä"}, {nil}, {"a"}}},
+		[][]coltypes.T{{coltypes.Bytes}},
+		tuples{{"ä"}, {"a"}},
+		orderedVerifier,
+		func(input []Operator) (Operator, error) {
+			op, _, ct, _, err := planSelectionOperators(
+				ctx, &evalCtx, expr, []types.T{*typ}, input[0], testMemAcc,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return NewSimpleProjectOp(op, len(ct), []uint32{0}), nil
+		},
+	)
+}
+
+func TestOrderedSynchronizerOnCollatedStrings(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	typ := types.MakeCollatedString(types.String, "de")
+	runTestsWithTyps(
+		t,
+		[]tuples{{{"a", 1}, {"b", 2}}, {{"ä", 3}}},
+		[][]coltypes.T{{coltypes.Bytes, coltypes.Int64}, {coltypes.Bytes, coltypes.Int64}},
+		tuples{{"a", 1}, {"ä", 3}, {"b", 2}},
+		orderedVerifier,
+		func(inputs []Operator) (Operator, error) {
+			return PlanOrderedSynchronizer(
+				testAllocator, inputs, []types.T{*typ, *types.Int},
+				execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+			)
+		},
+	)
+}
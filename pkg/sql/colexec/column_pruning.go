@@ -0,0 +1,68 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import "github.com/cockroachdb/cockroach/pkg/util"
+
+// columnPruner is an Operator that can stop populating some of its output
+// columns.
+type columnPruner interface {
+	Operator
+
+	// pruneOutputColumns removes the output columns that are not in needed
+	// from the output of the operator. It returns the slice that maps the old
+	// index of every output column to its new index (-1 is used for the
+	// removed columns). It must be called before the operator is initialized.
+	pruneOutputColumns(needed util.FastIntSet) []int
+}
+
+// pruneColumns is a post-planning pass over the simple projections at the
+// root of op. The adjacent simple projections are merged into one, and if the
+// operator below them is a columnPruner, the columns that are discarded by
+// the projection are pruned from the output of that operator, so that they
+// aren't copied in the first place (for example, the hash joiner always
+// outputs all columns of both inputs). The output of the returned operator is
+// the same as the output of op.
+func pruneColumns(op Operator) Operator {
+	p, ok := op.(*simpleProjectOp)
+	if !ok {
+		return op
+	}
+	// The projection slice might be shared with the spec, so we need to make a
+	// copy before modifying it.
+	projection := append([]uint32(nil), p.batch.projection...)
+	for {
+		q, ok := p.input.(*simpleProjectOp)
+		if !ok {
+			break
+		}
+		for i := range projection {
+			projection[i] = q.batch.projection[projection[i]]
+		}
+		p.input = q.input
+	}
+	pruner, ok := p.input.(columnPruner)
+	if !ok {
+		p.batch.projection = projection
+		return p
+	}
+	var needed util.FastIntSet
+	for _, col := range projection {
+		needed.Add(int(col))
+	}
+	newIdxs := pruner.pruneOutputColumns(needed)
+	for i := range projection {
+		projection[i] = uint32(newIdxs[projection[i]])
+	}
+	// The projection might have become redundant, in which case it is not
+	// planned at all.
+	return NewSimpleProjectOp(pruner, needed.Len(), projection)
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Int64, coltypes.Bytes}
+	leftTuples := tuples{{1, 10, "a"}, {2, 20, "b"}, {3, 30, "c"}}
+	rightTuples := tuples{{1, 100, "x"}, {3, 300, nil}, {4, 400, "z"}}
+
+	for _, tc := range []struct {
+		joinType sqlbase.JoinType
+		// projections are applied on top of the hash joiner, bottom up.
+		projections    [][]uint32
+		expected       tuples
+		leftOutCols    []uint32
+		rightOutCols   []uint32
+		projectionKept bool
+	}{
+		{
+			joinType:    sqlbase.JoinType_INNER,
+			projections: [][]uint32{{5, 4, 3, 1}, {2, 1}},
+			expected:    tuples{{1, 100}, {3, 300}},
+			// None of the columns of the left source are needed.
+			leftOutCols:    []uint32{},
+			rightOutCols:   []uint32{0, 1},
+			projectionKept: false,
+		},
+		{
+			joinType:       sqlbase.JoinType_FULL_OUTER,
+			projections:    [][]uint32{{0, 1, 2, 3, 5}, {4, 2}},
+			expected:       tuples{{"x", "a"}, {nil, "b"}, {nil, "c"}, {"z", nil}},
+			leftOutCols:    []uint32{2},
+			rightOutCols:   []uint32{2},
+			projectionKept: true,
+		},
+		{
+			joinType:       sqlbase.JoinType_LEFT_SEMI,
+			projections:    [][]uint32{{1}},
+			expected:       tuples{{10}, {30}},
+			leftOutCols:    []uint32{1},
+			rightOutCols:   []uint32{},
+			projectionKept: false,
+		},
+	} {
+		t.Run(tc.joinType.String(), func(t *testing.T) {
+			runTests(t, []tuples{leftTuples, rightTuples}, tc.expected, unorderedVerifier, func(inputs []Operator) (Operator, error) {
+				op, err := NewEqHashJoinerOp(
+					testAllocator, inputs[0], inputs[1], []uint32{0}, []uint32{0},
+					typs, typs, true /* rightDistinct */, tc.joinType,
+				)
+				if err != nil {
+					return nil, err
+				}
+				hj := op.(*hashJoinEqOp)
+				numCols := len(hj.spec.left.outCols) + len(hj.spec.right.outCols)
+				for _, projection := range tc.projections {
+					op = NewSimpleProjectOp(op, numCols, projection)
+					numCols = len(projection)
+				}
+				op = pruneColumns(op)
+				require.Equal(t, tc.leftOutCols, hj.spec.left.outCols)
+				require.Equal(t, tc.rightOutCols, hj.spec.right.outCols)
+				if tc.projectionKept {
+					// The projections have been merged into one.
+					require.IsType(t, &simpleProjectOp{}, op)
+					require.Equal(t, hj, op.(*simpleProjectOp).input)
+				} else {
+					require.Equal(t, hj, op)
+				}
+				return op, nil
+			})
+		})
+	}
+}
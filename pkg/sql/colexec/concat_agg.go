@@ -0,0 +1,168 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/pkg/errors"
+)
+
+func newConcatAgg(allocator *Allocator, t coltypes.T) (aggregateFunc, error) {
+	if t != coltypes.Bytes {
+		return nil, errors.Errorf("unsupported concat agg type %s", t)
+	}
+	return &concatAgg{allocator: allocator}, nil
+}
+
+// concatAgg implements the CONCAT_AGG and STRING_AGG aggregates. It
+// concatenates all non-null values of the first input column in a group. If
+// the second input column is present (the delimiter of STRING_AGG), its value
+// in the row of every non-null value except for the first one is inserted
+// before that value (NULL delimiters are treated as empty strings).
+type concatAgg struct {
+	allocator *Allocator
+	done      bool
+	groups    []bool
+	vec       coldata.Vec
+	col       *coldata.Bytes
+	nulls     *coldata.Nulls
+	curIdx    int
+	// curAgg is the arena in which the values of the current group are
+	// concatenated. It is reused for all groups, so only its growth results in
+	// allocations, and it is copied into the output vector once the group is
+	// complete.
+	curAgg []byte
+	// curAggAccountedFor is the capacity of curAgg that has been registered
+	// with the allocator.
+	curAggAccountedFor          int64
+	foundNonNullForCurrentGroup bool
+}
+
+var _ aggregateFunc = &concatAgg{}
+
+func (a *concatAgg) Init(groups []bool, vec coldata.Vec) {
+	a.groups = groups
+	a.vec = vec
+	a.col = vec.Bytes()
+	a.nulls = vec.Nulls()
+	a.Reset()
+}
+
+func (a *concatAgg) Reset() {
+	a.curIdx = -1
+	a.done = false
+	a.foundNonNullForCurrentGroup = false
+	a.curAgg = a.curAgg[:0]
+	a.col.Reset()
+	a.nulls.UnsetNulls()
+}
+
+func (a *concatAgg) CurrentOutputIndex() int {
+	return a.curIdx
+}
+
+func (a *concatAgg) SetOutputIndex(idx int) {
+	if a.curIdx != -1 {
+		a.curIdx = idx
+		a.nulls.UnsetNullsAfter(uint16(idx + 1))
+	}
+}
+
+func (a *concatAgg) Compute(b coldata.Batch, inputIdxs []uint32) {
+	if a.done {
+		return
+	}
+	inputLen := b.Length()
+	if inputLen == 0 {
+		a.allocator.PerformOperation([]coldata.Vec{a.vec}, a.flushCurrentGroup)
+		a.curIdx++
+		a.done = true
+		return
+	}
+	vec, sel := b.ColVec(int(inputIdxs[0])), b.Selection()
+	col, nulls := vec.Bytes(), vec.Nulls()
+	var (
+		delimiters     *coldata.Bytes
+		delimiterNulls *coldata.Nulls
+	)
+	if len(inputIdxs) > 1 {
+		delimiterVec := b.ColVec(int(inputIdxs[1]))
+		delimiters = delimiterVec.Bytes()
+		if delimiterVec.MaybeHasNulls() {
+			delimiterNulls = delimiterVec.Nulls()
+		}
+	}
+	if !nulls.MaybeHasNulls() {
+		nulls = nil
+	}
+
+	a.allocator.PerformOperation(
+		[]coldata.Vec{a.vec},
+		func() {
+			if sel != nil {
+				for _, i := range sel[:inputLen] {
+					a.add(i, col, nulls, delimiters, delimiterNulls)
+				}
+			} else {
+				for i := 0; i < int(inputLen); i++ {
+					a.add(i, col, nulls, delimiters, delimiterNulls)
+				}
+			}
+		},
+	)
+	if c := int64(cap(a.curAgg)); c > a.curAggAccountedFor {
+		a.allocator.AdjustMemoryUsage(c - a.curAggAccountedFor)
+		a.curAggAccountedFor = c
+	}
+}
+
+// add adds the ith value of col to the current group (starting a new group
+// first if the ith row begins one). nulls and delimiterNulls are nil if the
+// corresponding vectors don't have any nulls, and delimiters is nil if there is
+// no delimiter column.
+func (a *concatAgg) add(
+	i int, col *coldata.Bytes, nulls *coldata.Nulls, delimiters *coldata.Bytes, delimiterNulls *coldata.Nulls,
+) {
+	if a.groups[i] {
+		// The `a.curIdx` check is necessary because for the first group in the
+		// result set there is no "current group."
+		if a.curIdx >= 0 {
+			a.flushCurrentGroup()
+		}
+		a.curIdx++
+		a.foundNonNullForCurrentGroup = false
+		a.curAgg = a.curAgg[:0]
+	}
+	if nulls != nil && nulls.NullAt(uint16(i)) {
+		return
+	}
+	if a.foundNonNullForCurrentGroup && delimiters != nil &&
+		(delimiterNulls == nil || !delimiterNulls.NullAt(uint16(i))) {
+		a.curAgg = append(a.curAgg, delimiters.Get(i)...)
+	}
+	a.curAgg = append(a.curAgg, col.Get(i)...)
+	a.foundNonNullForCurrentGroup = true
+}
+
+// flushCurrentGroup writes the result of the current group into the output
+// vector. The output is null if the group doesn't have any non-null values.
+func (a *concatAgg) flushCurrentGroup() {
+	if !a.foundNonNullForCurrentGroup {
+		a.nulls.SetNull(uint16(a.curIdx))
+	} else {
+		a.col.Set(a.curIdx, a.curAgg)
+	}
+}
+
+func (a *concatAgg) HandleEmptyInputScalar() {
+	a.nulls.SetNull(0)
+}
@@ -108,7 +108,7 @@ func (c const_TYPEOp) Next(ctx context.Context) coldata.Batch {
 		func() {
 			if sel := batch.Selection(); sel != nil {
 				for _, i := range sel[:n] {
-					execgen.SET(col, int(i), c.constVal)
+					execgen.SET(col, i, c.constVal)
 				}
 			} else {
 				col = execgen.SLICE(col, 0, int(n))
@@ -159,7 +159,7 @@ func (c constNullOp) Next(ctx context.Context) coldata.Batch {
 	nulls := col.Nulls()
 	if sel := batch.Selection(); sel != nil {
 		for _, i := range sel[:n] {
-			nulls.SetNull(i)
+			nulls.SetNull(uint16(i))
 		}
 	} else {
 		nulls.SetNulls()
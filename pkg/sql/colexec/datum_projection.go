@@ -0,0 +1,214 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// datumProjectionOp is an Operator that projects into outputIdx Vec the result
+// of evaluating an arbitrary scalar expression using the row-by-row engine's
+// evaluation. For every tuple, the columns referenced by the expression are
+// converted to datums, the expression is evaluated, and the resulting datum is
+// converted back into the physical representation. This is much slower than
+// the native vectorized operators, but it allows for the expressions that the
+// vectorized engine doesn't support (for example, most builtin functions) to
+// not force the whole query off the vectorized engine.
+type datumProjectionOp struct {
+	OneInputNode
+	allocator      *Allocator
+	evalCtx        *tree.EvalContext
+	expr           tree.TypedExpr
+	columnTypes    []types.T
+	neededCols     []int
+	outputIdx      int
+	outputType     *types.T
+	outputPhysType coltypes.T
+	converter      func(tree.Datum) (interface{}, error)
+
+	// row contains the datums of the current tuple. Only the elements that
+	// correspond to neededCols are populated.
+	row tree.Datums
+	da  sqlbase.DatumAlloc
+}
+
+var _ Operator = &datumProjectionOp{}
+var _ tree.IndexedVarContainer = &datumProjectionOp{}
+
+// NewDatumProjectionOp returns an operator that projects into outputIdx Vec
+// the result of evaluating expr in which the IndexedVars refer to the columns
+// of the batches (described by columnTypes) coming from input.
+func NewDatumProjectionOp(
+	allocator *Allocator,
+	evalCtx *tree.EvalContext,
+	expr tree.TypedExpr,
+	columnTypes []types.T,
+	outputIdx int,
+	input Operator,
+) (Operator, error) {
+	outputType := expr.ResolvedType()
+	outputPhysType := typeconv.FromColumnType(outputType)
+	if outputPhysType == coltypes.Unhandled {
+		return nil, errors.Errorf("unsupported output type %q of %s", outputType.String(), expr.String())
+	}
+	visitor := ivarExpressionVisitor{ivarSeen: make([]bool, len(columnTypes))}
+	_, _ = tree.WalkExpr(visitor, expr)
+	var neededCols []int
+	for i, seen := range visitor.ivarSeen {
+		if !seen {
+			continue
+		}
+		if typeconv.FromColumnType(&columnTypes[i]) == coltypes.Unhandled {
+			return nil, errors.Errorf("unsupported type %q of column %d", columnTypes[i].String(), i)
+		}
+		neededCols = append(neededCols, i)
+	}
+	return &datumProjectionOp{
+		OneInputNode:   NewOneInputNode(input),
+		allocator:      allocator,
+		evalCtx:        evalCtx,
+		expr:           expr,
+		columnTypes:    columnTypes,
+		neededCols:     neededCols,
+		outputIdx:      outputIdx,
+		outputType:     outputType,
+		outputPhysType: outputPhysType,
+		converter:      typeconv.GetDatumToPhysicalFn(outputType),
+		row:            make(tree.Datums, len(columnTypes)),
+	}, nil
+}
+
+func (o *datumProjectionOp) Init() {
+	o.input.Init()
+}
+
+// IndexedVarEval is part of the tree.IndexedVarContainer interface.
+func (o *datumProjectionOp) IndexedVarEval(idx int, ctx *tree.EvalContext) (tree.Datum, error) {
+	return o.row[idx].Eval(ctx)
+}
+
+// IndexedVarResolvedType is part of the tree.IndexedVarContainer interface.
+func (o *datumProjectionOp) IndexedVarResolvedType(idx int) *types.T {
+	return &o.columnTypes[idx]
+}
+
+// IndexedVarNodeFormatter is part of the tree.IndexedVarContainer interface.
+func (o *datumProjectionOp) IndexedVarNodeFormatter(idx int) tree.NodeFormatter {
+	return nil
+}
+
+func (o *datumProjectionOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, o.outputPhysType, o.outputIdx)
+
+	sel := batch.Selection()
+	output := batch.ColVec(o.outputIdx)
+	o.evalCtx.PushIVarContainer(o)
+	defer o.evalCtx.PopIVarContainer()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{output},
+		func() {
+			for i := uint16(0); i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = uint16(sel[i])
+				}
+				for _, colIdx := range o.neededCols {
+					o.row[colIdx] = PhysicalTypeColElemToDatum(
+						batch.ColVec(colIdx), rowIdx, &o.da, &o.columnTypes[colIdx],
+					)
+				}
+				res, err := o.expr.Eval(o.evalCtx)
+				if err != nil {
+					execerror.NonVectorizedPanic(err)
+				}
+				setDatumInVec(output, rowIdx, res, o.outputType, o.outputPhysType, o.converter)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// setDatumInVec sets the rowIdx'th element of vec to the physical
+// representation of d which is of outputType (with the physical type physType)
+// using converter (see typeconv.GetDatumToPhysicalFn).
+func setDatumInVec(
+	vec coldata.Vec,
+	rowIdx uint16,
+	d tree.Datum,
+	outputType *types.T,
+	physType coltypes.T,
+	converter func(tree.Datum) (interface{}, error),
+) {
+	if d == tree.DNull {
+		vec.Nulls().SetNull(rowIdx)
+		return
+	}
+	if physType == coltypes.Datum {
+		vec.Datum().Set(int(rowIdx), d)
+		return
+	}
+	if physType == coltypes.Array {
+		if err := setArrayDatum(vec, int(rowIdx), d, outputType.ArrayContents()); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		return
+	}
+	converted, err := converter(d)
+	if err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	coldata.SetValueAt(vec, converted, int(rowIdx), physType)
+}
+
+// datumRowsToColVec converts the columnIdx'th column of columnType (which must
+// be of coltypes.Datum physical type) from rows to vec. It is the analog of
+// EncDatumRowsToColVec for the types without a native columnar representation.
+func datumRowsToColVec(
+	rows sqlbase.EncDatumRows,
+	vec coldata.Vec,
+	columnIdx int,
+	columnType *types.T,
+	alloc *sqlbase.DatumAlloc,
+) error {
+	datums := vec.Datum()
+	for i := range rows {
+		row := rows[i]
+		if row[columnIdx].Datum == nil {
+			if err := row[columnIdx].EnsureDecoded(columnType, alloc); err != nil {
+				return err
+			}
+		}
+		datum := row[columnIdx].Datum
+		if datum == tree.DNull {
+			vec.Nulls().SetNull(uint16(i))
+			continue
+		}
+		datums.Set(i, datum)
+	}
+	return nil
+}
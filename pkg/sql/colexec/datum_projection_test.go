@@ -0,0 +1,132 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+)
+
+func TestDatumProjectionOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+
+	t.Run("Projection", func(t *testing.T) {
+		// COALESCE(@1, @2) is not supported natively, so it is evaluated using
+		// datums.
+		expr := tree.NewTypedCoalesceExpr(tree.TypedExprs{
+			tree.NewTypedOrdinalReference(0, types.Int),
+			tree.NewTypedOrdinalReference(1, types.Int),
+		}, types.Int)
+		runTestsWithTyps(
+			t,
+			[]tuples{{{nil, 2}, {1, nil}, {nil, nil}, {3, 4}}},
+			[][]coltypes.T{{coltypes.Int64, coltypes.Int64}},
+			tuples{{nil, 2, 2}, {1, nil, 1}, {nil, nil, nil}, {3, 4, 3}},
+			orderedVerifier,
+			func(input []Operator) (Operator, error) {
+				op, _, _, _, err := planProjectionOperators(
+					ctx, &evalCtx, expr, []types.T{*types.Int, *types.Int}, input[0], testMemAcc,
+				)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := op.(*datumProjectionOp); !ok {
+					t.Fatalf("expected datumProjectionOp, found %T", op)
+				}
+				return op, nil
+			},
+		)
+	})
+
+	t.Run("Selection", func(t *testing.T) {
+		// NOT @1 doesn't have a selection form, so it is planned as a datum
+		// projection followed by the conversion to a selection vector.
+		expr := tree.NewTypedNotExpr(tree.NewTypedOrdinalReference(0, types.Bool))
+		runTestsWithTyps(
+			t,
+			[]tuples{{{true}, {false}, {nil}, {false}}},
+			[][]coltypes.T{{coltypes.Bool}},
+			tuples{{false}, {false}},
+			orderedVerifier,
+			func(input []Operator) (Operator, error) {
+				op, _, ct, _, err := planSelectionOperators(
+					ctx, &evalCtx, expr, []types.T{*types.Bool}, input[0], testMemAcc,
+				)
+				if err != nil {
+					return nil, err
+				}
+				return NewSimpleProjectOp(op, len(ct), []uint32{0}), nil
+			},
+		)
+	})
+
+	t.Run("DatumColumns", func(t *testing.T) {
+		// TIME values don't have a native columnar representation, so any
+		// expression that references or produces them is evaluated using datums.
+		columnTypes := []types.T{*types.Time}
+		batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Datum})
+		noon := tree.MakeDTime(timeofday.New(12, 0, 0, 0))
+		batch.ColVec(0).Datum().Set(0, noon)
+		batch.ColVec(0).Nulls().SetNull(1)
+		batch.SetLength(2)
+
+		ivar := tree.NewTypedOrdinalReference(0, types.Time)
+		asString, err := tree.NewTypedCastExpr(ivar, types.String)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			expr     tree.TypedExpr
+			expected []string
+		}{
+			{
+				expr:     asString,
+				expected: []string{"'12:00:00'", "NULL"},
+			},
+			{
+				expr:     tree.NewTypedCoalesceExpr(tree.TypedExprs{ivar, noon}, types.Time),
+				expected: []string{"'12:00:00'", "'12:00:00'"},
+			},
+		} {
+			op, resultIdx, _, _, err := planProjectionOperators(
+				ctx, &evalCtx, tc.expr, columnTypes, NewRepeatableBatchSource(batch), testMemAcc,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := op.(*datumProjectionOp); !ok {
+				t.Fatalf("expected datumProjectionOp, found %T", op)
+			}
+			op.Init()
+			out := op.Next(ctx)
+			var da sqlbase.DatumAlloc
+			for i, expected := range tc.expected {
+				actual := PhysicalTypeColElemToDatum(
+					out.ColVec(resultIdx), uint16(i), &da, tc.expr.ResolvedType(),
+				)
+				if actual.String() != expected {
+					t.Fatalf("expected %s, found %s", expected, actual)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/arith"
+)
+
+// The values of a DECIMAL column with a fixed scale usually have the same
+// exponent and coefficients that fit into an int64. The functions in this
+// file have fast paths for such "small" decimals that operate on the int64
+// coefficients directly, which avoids the generic apd arithmetic (that
+// allocates when the operands need to be rescaled and computes the number of
+// digits of the result in order to round it). The results are the same as of
+// the generic code paths.
+
+// smallDecimalCoeff returns the coefficient of d as a signed int64 if d is a
+// finite decimal whose coefficient fits into an int64.
+func smallDecimalCoeff(d *apd.Decimal) (int64, bool) {
+	if d.Form != apd.Finite || !d.Coeff.IsInt64() {
+		return 0, false
+	}
+	c := d.Coeff.Int64()
+	if d.Negative {
+		c = -c
+	}
+	return c, true
+}
+
+// addDecimals sets target to l + r. It is equivalent to
+// tree.ExactCtx.Add(target, l, r). target is allowed to alias l or r.
+func addDecimals(target, l, r *apd.Decimal) error {
+	if l.Exponent == r.Exponent {
+		if lc, ok := smallDecimalCoeff(l); ok {
+			if rc, ok := smallDecimalCoeff(r); ok {
+				if sum, ok := arith.AddWithOverflow(lc, rc); ok {
+					// The sum of zeroes is negative only if both of them are
+					// negative.
+					negative := sum < 0 || (sum == 0 && l.Negative && r.Negative)
+					// Note that the conversion is correct for math.MinInt64 too.
+					abs := uint64(sum)
+					if sum < 0 {
+						abs = uint64(-sum)
+					}
+					target.Form = apd.Finite
+					target.Negative = negative
+					target.Exponent = l.Exponent
+					target.Coeff.SetUint64(abs)
+					return nil
+				}
+			}
+		}
+	}
+	_, err := tree.ExactCtx.Add(target, l, r)
+	return err
+}
+
+// compareDecimals is equivalent to tree.CompareDecimals(l, r).
+func compareDecimals(l, r *apd.Decimal) int {
+	if l.Exponent == r.Exponent {
+		if lc, ok := smallDecimalCoeff(l); ok {
+			if rc, ok := smallDecimalCoeff(r); ok {
+				if lc < rc {
+					return -1
+				} else if lc > rc {
+					return 1
+				}
+				return 0
+			}
+		}
+	}
+	return tree.CompareDecimals(l, r)
+}
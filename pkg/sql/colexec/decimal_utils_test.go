@@ -0,0 +1,78 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSmallDecimalFastPaths verifies that the results of addDecimals and
+// compareDecimals are the same as of the generic apd code paths.
+func TestSmallDecimalFastPaths(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rng, _ := randutil.NewPseudoRand()
+
+	var decs []*apd.Decimal
+	for _, s := range []string{
+		"0", "-0", "0.00", "-0.00", "1", "-1", "1.00", "-1.00", "12.34", "-12.34",
+		"9223372036854775807", "-9223372036854775807", "-9223372036854775808",
+		"92233720368547758.07", "-92233720368547758.08", "18446744073709551616",
+		"1E+10", "NaN", "Infinity", "-Infinity",
+	} {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		decs = append(decs, d)
+	}
+	for i := 0; i < 100; i++ {
+		// The random decimals have coefficients of different magnitudes (that
+		// might overflow when added together) and one of a few exponents.
+		coeff := rng.Int63() >> uint(rng.Intn(63))
+		if rng.Intn(2) == 0 {
+			coeff = -coeff
+		}
+		if rng.Intn(10) == 0 {
+			coeff = math.MinInt64
+		}
+		decs = append(decs, apd.New(coeff, -int32(rng.Intn(3))))
+	}
+
+	for _, l := range decs {
+		for _, r := range decs {
+			msg := fmt.Sprintf("%s, %s", l, r)
+			var expected, actual apd.Decimal
+			_, expectedErr := tree.ExactCtx.Add(&expected, l, r)
+			actualErr := addDecimals(&actual, l, r)
+			if expectedErr != nil {
+				require.Error(t, actualErr, msg)
+			} else {
+				require.NoError(t, actualErr, msg)
+				require.Equal(t, expected.String(), actual.String(), msg)
+				require.Equal(t, expected.Negative, actual.Negative, msg)
+
+				// The result is the same when the target aliases an argument.
+				var aliased apd.Decimal
+				aliased.Set(l)
+				require.NoError(t, addDecimals(&aliased, &aliased, r), msg)
+				require.Equal(t, expected.String(), aliased.String(), msg)
+			}
+
+			require.Equal(t, tree.CompareDecimals(l, r), compareDecimals(l, r), msg)
+		}
+	}
+}
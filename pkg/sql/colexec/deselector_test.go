@@ -26,7 +26,7 @@ func TestDeselector(t *testing.T) {
 	tcs := []struct {
 		colTypes []coltypes.T
 		tuples   []tuple
-		sel      []uint16
+		sel      []int
 		expected []tuple
 	}{
 		{
@@ -38,25 +38,25 @@ func TestDeselector(t *testing.T) {
 		{
 			colTypes: []coltypes.T{coltypes.Int64},
 			tuples:   tuples{{0}, {1}, {2}},
-			sel:      []uint16{},
+			sel:      []int{},
 			expected: tuples{},
 		},
 		{
 			colTypes: []coltypes.T{coltypes.Int64},
 			tuples:   tuples{{0}, {1}, {2}},
-			sel:      []uint16{1},
+			sel:      []int{1},
 			expected: tuples{{1}},
 		},
 		{
 			colTypes: []coltypes.T{coltypes.Int64},
 			tuples:   tuples{{0}, {1}, {2}},
-			sel:      []uint16{0, 2},
+			sel:      []int{0, 2},
 			expected: tuples{{0}, {2}},
 		},
 		{
 			colTypes: []coltypes.T{coltypes.Int64},
 			tuples:   tuples{{0}, {1}, {2}},
-			sel:      []uint16{0, 1, 2},
+			sel:      []int{0, 1, 2},
 			expected: tuples{{0}, {1}, {2}},
 		},
 	}
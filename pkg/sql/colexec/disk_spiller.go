@@ -86,6 +86,7 @@ type oneInputDiskSpiller struct {
 }
 
 var _ Operator = &oneInputDiskSpiller{}
+var _ Closer = &oneInputDiskSpiller{}
 
 // newOneInputDiskSpiller returns a new oneInputDiskSpiller. It takes the
 // following arguments:
@@ -152,11 +153,19 @@ func (d *oneInputDiskSpiller) Next(ctx context.Context) coldata.Batch {
 		}
 		// Either not an out of memory error or an OOM error coming from a
 		// different operator, so we propagate it further.
-		execerror.VectorizedInternalPanic(err)
+		execerror.RethrowCaughtError(err)
 	}
 	return batch
 }
 
+// Close is part of the Closer interface.
+func (d *oneInputDiskSpiller) Close() error {
+	if !d.spilled {
+		return nil
+	}
+	return closeInput(d.diskBackedOp)
+}
+
 func (d *oneInputDiskSpiller) ChildCount(verbose bool) int {
 	if verbose {
 		return 3
@@ -166,7 +166,7 @@ type partitioner interface {
 	// implies a reordered input vector [b,b,a], the resultant outputCol would be
 	// [true, false, true], indicating a distinct value at the 0th and 2nd
 	// elements.
-	partitionWithOrder(colVec coldata.Vec, order []uint64, outputCol []bool, n uint64)
+	partitionWithOrder(colVec coldata.Vec, order []int, outputCol []bool, n uint64)
 }
 
 // newPartitioner returns a new partitioner on type t.
@@ -239,7 +239,7 @@ func (p *sortedDistinct_TYPEOp) Next(ctx context.Context) coldata.Batch {
 	lastVal := p.lastVal
 	lastValNull := p.lastValNull
 	sel := batch.Selection()
-	firstIdx := uint16(0)
+	firstIdx := 0
 	if sel != nil {
 		firstIdx = sel[0]
 	}
@@ -260,12 +260,12 @@ func (p *sortedDistinct_TYPEOp) Next(ctx context.Context) coldata.Batch {
 		if nulls != nil {
 			for _, checkIdx := range sel {
 				outputIdx := checkIdx
-				_CHECK_DISTINCT_WITH_NULLS(int(checkIdx), outputIdx, lastVal, nulls, lastValNull, col, outputCol)
+				_CHECK_DISTINCT_WITH_NULLS(checkIdx, outputIdx, lastVal, nulls, lastValNull, col, outputCol)
 			}
 		} else {
 			for _, checkIdx := range sel {
 				outputIdx := checkIdx
-				_CHECK_DISTINCT(int(checkIdx), outputIdx, lastVal, col, outputCol)
+				_CHECK_DISTINCT(checkIdx, outputIdx, lastVal, col, outputCol)
 			}
 		}
 	} else {
@@ -299,7 +299,7 @@ func (p *sortedDistinct_TYPEOp) Next(ctx context.Context) coldata.Batch {
 type partitioner_TYPE struct{}
 
 func (p partitioner_TYPE) partitionWithOrder(
-	colVec coldata.Vec, order []uint64, outputCol []bool, n uint64,
+	colVec coldata.Vec, order []int, outputCol []bool, n uint64,
 ) {
 	var lastVal _GOTYPE
 	var lastValNull bool
@@ -387,7 +387,7 @@ func _CHECK_DISTINCT_WITH_NULLS(
 ) { // */}}
 
 	// {{define "checkDistinctWithNulls" -}}
-	null := nulls.NullAt(uint16(checkIdx))
+	null := nulls.NullAt64(uint64(checkIdx))
 	if null {
 		if !lastValNull {
 			// The current value is null while the previous was not.
@@ -68,6 +68,7 @@ func CatchVectorizedRuntimeError(operation func()) (retErr error) {
 								// needs to be annotated to indicate that it was
 								// unexpected.
 								e = errors.AssertionFailedf("unexpected error from the vectorized runtime: %+v", e)
+								e = withEmitter(e, scanner)
 							}
 						}
 						retErr = e
@@ -75,6 +76,7 @@ func CatchVectorizedRuntimeError(operation func()) (retErr error) {
 						// Not an error object. Definitely unexpected.
 						surprisingObject := err
 						retErr = errors.AssertionFailedf("unexpected error from the vectorized runtime: %+v", surprisingObject)
+						retErr = withEmitter(retErr, scanner)
 					}
 				} else {
 					// Do not recover from the panic not related to the vectorized
@@ -92,7 +94,29 @@ func CatchVectorizedRuntimeError(operation func()) (retErr error) {
 	return retErr
 }
 
+// withEmitter annotates err with the name of the function that emitted the
+// panic (usually, the method of the operator that encountered err). scanner
+// must be positioned at the line below the panic line in the stack trace.
+// The functions of this package (like VectorizedInternalPanic) are skipped.
+func withEmitter(err error, scanner *bufio.Scanner) error {
+	for line := scanner.Text(); ; line = scanner.Text() {
+		// The lines with the function names are not indented, and they are
+		// followed by the indented lines with the file names.
+		if line != "" && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, execerrorPackagePrefix) {
+			if idx := strings.LastIndex(line, "("); idx > 0 {
+				// Strip the arguments.
+				line = line[:idx]
+			}
+			return errors.WithDetailf(err, "the error was emitted from %s", line)
+		}
+		if !scanner.Scan() {
+			return err
+		}
+	}
+}
+
 const (
+	execerrorPackagePrefix    = "github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror."
 	colPackagePrefix          = "github.com/cockroachdb/cockroach/pkg/col"
 	colexecPackagePrefix      = "github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	colflowsetupPackagePrefix = "github.com/cockroachdb/cockroach/pkg/sql/colflow"
@@ -174,3 +198,36 @@ func VectorizedExpectedInternalPanic(err error) {
 func NonVectorizedPanic(err error) {
 	panic(newNotVectorizedInternalError(err))
 }
+
+// RethrowCaughtError panics with err that has been returned by
+// CatchVectorizedRuntimeError. Such error has already been annotated (if
+// necessary) when it was caught, so it will be propagated unchanged once it is
+// caught again. This method should be called by the components that catch the
+// errors of other operators (for example, when running them in separate
+// goroutines) in order to propagate those errors further.
+func RethrowCaughtError(err error) {
+	panic(newNotVectorizedInternalError(err))
+}
+
+// WithOperatorContext annotates err, which must have been returned by
+// CatchVectorizedRuntimeError, with the context in which err occurred: the
+// type of root (the root of the operator tree that was being executed), the
+// ID of the processor root belongs to (negative if unknown), and the
+// dimensions of the last batch returned by root (batchLength is negative if
+// there is no such batch). Only the unexpected errors are annotated since all
+// other errors are returned to the client as is.
+func WithOperatorContext(
+	err error, root interface{}, processorID int32, batchLength int, batchWidth int,
+) error {
+	if err == nil || !errors.HasAssertionFailure(err) {
+		return err
+	}
+	var processor, batch string
+	if processorID >= 0 {
+		processor = fmt.Sprintf(" of processor %d", processorID)
+	}
+	if batchLength >= 0 {
+		batch = fmt.Sprintf(" (last batch had %d tuples and %d columns)", batchLength, batchWidth)
+	}
+	return errors.WithDetailf(err, "while executing %T%s%s", root, processor, batch)
+}
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 )
 
@@ -42,6 +43,9 @@ func genHashTable(wr io.Writer) error {
 	rehash := makeFunctionRegex("_REHASH_BODY", 9)
 	s = rehash.ReplaceAllString(s, `{{template "rehashBody" buildDict "Global" . "HasSel" $8 "HasNulls" $9}}`)
 
+	rehashFixedWidth := makeFunctionRegex("_REHASH_FIXED_WIDTH_BODY", 5)
+	s = rehashFixedWidth.ReplaceAllString(s, `{{template "rehashFixedWidthBody" buildDict "Global" .}}`)
+
 	checkCol := makeFunctionRegex("_CHECK_COL_WITH_NULLS", 7)
 	s = checkCol.ReplaceAllString(s, `{{template "checkColWithNulls" buildDict "Global" . "UseSel" $7}}`)
 
@@ -52,7 +56,10 @@ func genHashTable(wr io.Writer) error {
 
 	s = replaceManipulationFuncs(".Global.LTyp", s)
 
-	tmpl, err := template.New("hashtable").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	tmpl, err := template.New("hashtable").Funcs(template.FuncMap{
+		"buildDict":    buildDict,
+		"isFixedWidth": isFixedWidth,
+	}).Parse(s)
 	if err != nil {
 		return err
 	}
@@ -68,6 +75,17 @@ func genHashTable(wr io.Writer) error {
 	})
 }
 
+// isFixedWidth returns whether the values of the type are stored in a flat
+// slice of fixed-width elements that the hash table can hash in an unrolled
+// loop.
+func isFixedWidth(t coltypes.T) bool {
+	switch t {
+	case coltypes.Int16, coltypes.Int32, coltypes.Int64, coltypes.Float64:
+		return true
+	}
+	return false
+}
+
 func init() {
 	registerGenerator(genHashTable, "hashtable.eg.go")
 }
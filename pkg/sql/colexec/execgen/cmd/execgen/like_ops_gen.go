@@ -21,7 +21,8 @@ import (
 // likeTemplate depends on the selConstOp template from selection_ops_gen. We
 // handle LIKE operators separately from the other selection operators because
 // there are several different implementations which may be chosen depending on
-// the complexity of the LIKE pattern.
+// the complexity of the LIKE pattern. The regexp operators are also used for
+// the ~ and ~* comparisons.
 const likeTemplate = `
 package colexec
 
@@ -76,6 +77,15 @@ func genLikeOps(wr io.Writer) error {
 				return fmt.Sprintf("%s = bytes.HasSuffix(%s, %s)", target, l, r)
 			},
 		},
+		{
+			Name:    "Contains",
+			LTyp:    coltypes.Bytes,
+			RTyp:    coltypes.Bytes,
+			RGoType: "[]byte",
+			AssignFunc: func(_ overload, target, l, r string) string {
+				return fmt.Sprintf("%s = bytes.Contains(%s, %s)", target, l, r)
+			},
+		},
 		{
 			Name:    "Regexp",
 			LTyp:    coltypes.Bytes,
@@ -103,6 +113,15 @@ func genLikeOps(wr io.Writer) error {
 				return fmt.Sprintf("%s = !bytes.HasSuffix(%s, %s)", target, l, r)
 			},
 		},
+		{
+			Name:    "NotContains",
+			LTyp:    coltypes.Bytes,
+			RTyp:    coltypes.Bytes,
+			RGoType: "[]byte",
+			AssignFunc: func(_ overload, target, l, r string) string {
+				return fmt.Sprintf("%s = !bytes.Contains(%s, %s)", target, l, r)
+			},
+		},
 		{
 			Name:    "NotRegexp",
 			LTyp:    coltypes.Bytes,
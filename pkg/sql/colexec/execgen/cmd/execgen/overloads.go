@@ -186,6 +186,15 @@ func intToDecimal(to, from string) string {
 	return fmt.Sprintf(convStr, to, from)
 }
 
+// intToInt returns an AssignFunc that widens an integer to an integer of
+// intSize bits. Narrowing conversions are not supported since they can
+// overflow.
+func intToInt(intSize int) func(string, string) string {
+	return func(to, from string) string {
+		return fmt.Sprintf("%[1]s = int%[3]d(%[2]s)", to, from, intSize)
+	}
+}
+
 func intToFloat(floatSize int) func(string, string) string {
 	return func(to, from string) string {
 		convStr := `
@@ -395,6 +404,10 @@ func init() {
 					ov.AssignFunc = intToDecimal
 				case coltypes.Int16:
 					ov.AssignFunc = castIdentity
+				case coltypes.Int32:
+					ov.AssignFunc = intToInt(32)
+				case coltypes.Int64:
+					ov.AssignFunc = intToInt(64)
 				case coltypes.Float64:
 					ov.AssignFunc = intToFloat(64)
 				}
@@ -410,6 +423,8 @@ func init() {
 					ov.AssignFunc = intToDecimal
 				case coltypes.Int32:
 					ov.AssignFunc = castIdentity
+				case coltypes.Int64:
+					ov.AssignFunc = intToInt(64)
 				case coltypes.Float64:
 					ov.AssignFunc = intToFloat(64)
 				}
@@ -601,7 +616,7 @@ func (bytesCustomizer) getHashAssignFunc() assignFunc {
 
 func (decimalCustomizer) getCmpOpCompareFunc() compareFunc {
 	return func(target, l, r string) string {
-		return fmt.Sprintf("%s = tree.CompareDecimals(&%s, &%s)", target, l, r)
+		return fmt.Sprintf("%s = compareDecimals(&%s, &%s)", target, l, r)
 	}
 }
 
@@ -620,6 +635,12 @@ func (decimalCustomizer) getBinOpAssignFunc() assignFunc {
 			}
 			`, binaryOpDecCtx[op.BinOp], binaryOpDecMethod[op.BinOp], target, l, r)
 		}
+		if op.BinOp == tree.Plus {
+			// Additions of small decimals (which are the most common ones in
+			// sum and avg aggregates) are performed on int64 coefficients.
+			return fmt.Sprintf("if err := addDecimals(&%s, &%s, &%s); err != nil { execerror.NonVectorizedPanic(err) }",
+				target, l, r)
+		}
 		return fmt.Sprintf("if _, err := tree.%s.%s(&%s, &%s, &%s); err != nil { execerror.NonVectorizedPanic(err) }",
 			binaryOpDecCtx[op.BinOp], binaryOpDecMethod[op.BinOp], target, l, r)
 	}
@@ -62,10 +62,11 @@ func replaceProjTmplVariables(tmpl string) string {
 	tmpl = assignRe.ReplaceAllString(tmpl, "{{.Assign $1 $2 $3}}")
 
 	tmpl = strings.Replace(tmpl, "_HAS_NULLS", "$hasNulls", -1)
+	tmpl = strings.Replace(tmpl, "_USES_SEL", "$usesSel", -1)
 	setProjectionRe := makeFunctionRegex("_SET_PROJECTION", 1)
 	tmpl = setProjectionRe.ReplaceAllString(tmpl, `{{template "setProjection" buildDict "Global" $ "HasNulls" $1 "Overload" .}}`)
-	setSingleTupleProjectionRe := makeFunctionRegex("_SET_SINGLE_TUPLE_PROJECTION", 1)
-	tmpl = setSingleTupleProjectionRe.ReplaceAllString(tmpl, `{{template "setSingleTupleProjection" buildDict "Global" $ "HasNulls" $1 "Overload" .}}`)
+	setSingleTupleProjectionRe := makeFunctionRegex("_SET_SINGLE_TUPLE_PROJECTION", 2)
+	tmpl = setSingleTupleProjectionRe.ReplaceAllString(tmpl, `{{template "setSingleTupleProjection" buildDict "Global" $ "HasNulls" $1 "UsesSel" $2 "Overload" .}}`)
 
 	return tmpl
 }
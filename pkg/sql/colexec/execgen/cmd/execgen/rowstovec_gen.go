@@ -68,7 +68,9 @@ func genRowsToVec(wr io.Writer) error {
 	conversionsMap := make(map[types.Family]*columnConversion)
 	for _, ct := range types.OidToType {
 		t := typeconv.FromColumnType(ct)
-		if t == coltypes.Unhandled {
+		if t == coltypes.Unhandled || t == coltypes.Array || t == coltypes.Datum {
+			// Arrays and datum-backed columns are converted by the hand-written
+			// code in the template.
 			continue
 		}
 
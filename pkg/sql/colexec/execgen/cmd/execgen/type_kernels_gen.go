@@ -0,0 +1,69 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// typeKernelsTmplInfo contains the overloads that the kernels of a single type
+// are generated from.
+type typeKernelsTmplInfo struct {
+	LTyp    coltypes.T
+	Compare *overload
+	Hash    *overload
+}
+
+func genTypeKernels(wr io.Writer) error {
+	d, err := ioutil.ReadFile("pkg/sql/colexec/type_kernels_tmpl.go")
+	if err != nil {
+		return err
+	}
+	s := string(d)
+	s = strings.Replace(s, "_TYPE", "{{.LTyp}}", -1)
+	compareRe := regexp.MustCompile(`_COMPARE\((.*),(.*),(.*)\)`)
+	s = compareRe.ReplaceAllString(s, "{{.Compare.Compare $1 $2 $3}}")
+	assignHash := makeFunctionRegex("_ASSIGN_HASH", 2)
+	s = assignHash.ReplaceAllString(s, `{{.Hash.UnaryAssign "$1" "$2"}}`)
+
+	s = replaceManipulationFuncs(".LTyp", s)
+
+	tmpl, err := template.New("type_kernels").Parse(s)
+	if err != nil {
+		return err
+	}
+
+	overloads := intersectOverloads(sameTypeComparisonOpToOverloads[tree.LT], hashOverloads)
+	hashOverloadsByType := make(map[coltypes.T]*overload, len(overloads[1]))
+	for _, ov := range overloads[1] {
+		hashOverloadsByType[ov.LTyp] = ov
+	}
+	infos := make([]typeKernelsTmplInfo, 0, len(overloads[0]))
+	for _, ov := range overloads[0] {
+		infos = append(infos, typeKernelsTmplInfo{
+			LTyp:    ov.LTyp,
+			Compare: ov,
+			Hash:    hashOverloadsByType[ov.LTyp],
+		})
+	}
+	return tmpl.Execute(wr, infos)
+}
+
+func init() {
+	registerGenerator(genTypeKernels, "type_kernels.eg.go")
+}
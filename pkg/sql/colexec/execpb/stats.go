@@ -16,6 +16,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
 
@@ -28,6 +29,7 @@ const (
 	selectivityTagSuffix   = "selectivity"
 	stallTimeTagSuffix     = "time.stall"
 	executionTimeTagSuffix = "time.execution"
+	maxMemoryTagSuffix     = "mem.max"
 )
 
 // Stats is part of SpanStats interface.
@@ -42,12 +44,16 @@ func (vs *VectorizedStats) Stats() map[string]string {
 	if vs.NumBatches > 0 {
 		selectivity = float64(vs.NumTuples) / float64(int64(coldata.BatchSize())*vs.NumBatches)
 	}
-	return map[string]string{
+	stats := map[string]string{
 		batchesOutputTagSuffix: fmt.Sprintf("%d", vs.NumBatches),
 		tuplesOutputTagSuffix:  fmt.Sprintf("%d", vs.NumTuples),
 		selectivityTagSuffix:   fmt.Sprintf("%.2f", selectivity),
 		timeSuffix:             fmt.Sprintf("%v", vs.Time.Round(time.Microsecond)),
 	}
+	if vs.MaxAllocatedMem != 0 {
+		stats[maxMemoryTagSuffix] = humanizeutil.IBytes(vs.MaxAllocatedMem)
+	}
+	return stats
 }
 
 const (
@@ -56,6 +62,7 @@ const (
 	selectivityQueryPlanSuffix   = "selectivity"
 	stallTimeQueryPlanSuffix     = "stall time"
 	executionTimeQueryPlanSuffix = "execution time"
+	maxMemoryQueryPlanSuffix     = "max memory used"
 )
 
 // StatsForQueryPlan is part of DistSQLSpanStats interface.
@@ -70,10 +77,14 @@ func (vs *VectorizedStats) StatsForQueryPlan() []string {
 	if vs.NumBatches > 0 {
 		selectivity = float64(vs.NumTuples) / float64(int64(coldata.BatchSize())*vs.NumBatches)
 	}
-	return []string{
+	stats := []string{
 		fmt.Sprintf("%s: %d", batchesOutputQueryPlanSuffix, vs.NumBatches),
 		fmt.Sprintf("%s: %d", tuplesOutputQueryPlanSuffix, vs.NumTuples),
 		fmt.Sprintf("%s: %.2f", selectivityQueryPlanSuffix, selectivity),
 		fmt.Sprintf("%s: %v", timeSuffix, vs.Time.Round(time.Microsecond)),
 	}
+	if vs.MaxAllocatedMem != 0 {
+		stats = append(stats, fmt.Sprintf("%s: %s", maxMemoryQueryPlanSuffix, humanizeutil.IBytes(vs.MaxAllocatedMem)))
+	}
+	return stats
 }
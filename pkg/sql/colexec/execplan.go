@@ -13,10 +13,11 @@ package colexec
 import (
 	"context"
 	"fmt"
-	"math"
 	"reflect"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
@@ -114,7 +115,7 @@ type NewColOperatorResult struct {
 	Op                     Operator
 	ColumnTypes            []types.T
 	InternalMemUsage       int
-	MetadataSources        []execinfrapb.MetadataSource
+	MetadataSources        execinfrapb.MetadataSources
 	IsStreaming            bool
 	BufferingOpMemMonitors []*mon.BytesMonitor
 	BufferingOpMemAccounts []*mon.BoundAccount
@@ -169,12 +170,222 @@ func createJoiner(
 	return err
 }
 
+// checkNoJSONColumns returns an error if any of the columns in cols is of JSONB
+// type. JSONB values are stored using their binary encoding which preserves
+// neither the ordering nor the equality of the values (for example, 1 and 1.0
+// are equal but have different encodings), so such columns can't be used as
+// the equality or the ordering columns.
+func checkNoJSONColumns(colTypes []types.T, cols []uint32) error {
+	for _, col := range cols {
+		if colTypes[col].Family() == types.JsonFamily {
+			return errors.Newf("JSONB equality and ordering columns are not supported")
+		}
+	}
+	return nil
+}
+
+// checkNoCollatedStringColumns returns an error if any of the columns in cols
+// is of a collated string type. Collated strings are stored using their
+// contents, so only the operators that are planned on top of the collation
+// keys (see planCollationKeys) can use such columns as the equality or the
+// ordering columns.
+func checkNoCollatedStringColumns(colTypes []types.T, cols []uint32) error {
+	for _, col := range cols {
+		if colTypes[col].Family() == types.CollatedStringFamily {
+			return errors.Newf("collated string equality and ordering columns are not supported")
+		}
+	}
+	return nil
+}
+
+// planCollationKeys plans the operators that append to the batches produced by
+// input the collation keys of the collated string columns among cols. It
+// returns the resulting operator and the types of its output as well as cols
+// in which every collated string column is replaced with the column of its
+// collation key. If none of cols is a collated string, the arguments are
+// returned unchanged.
+func planCollationKeys(
+	allocator *Allocator, input Operator, colTypes []types.T, typs []coltypes.T, cols []uint32,
+) (Operator, []coltypes.T, []uint32, error) {
+	var (
+		newCols []uint32
+		keyCols map[uint32]uint32
+	)
+	for i, col := range cols {
+		if colTypes[col].Family() != types.CollatedStringFamily {
+			continue
+		}
+		if newCols == nil {
+			newCols = append([]uint32(nil), cols...)
+			typs = append([]coltypes.T(nil), typs...)
+			keyCols = make(map[uint32]uint32)
+		}
+		keyCol, ok := keyCols[col]
+		if !ok {
+			keyCol = uint32(len(typs))
+			var err error
+			input, err = NewCollationKeyOp(
+				allocator, input, int(col), int(keyCol), colTypes[col].Locale(),
+			)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			typs = append(typs, coltypes.Bytes)
+			keyCols[col] = keyCol
+		}
+		newCols[i] = keyCol
+	}
+	if newCols == nil {
+		return input, typs, cols, nil
+	}
+	return input, typs, newCols, nil
+}
+
+// planAggregateArguments plans the operators that append the constant
+// arguments of agg (the delimiter of STRING_AGG) to the batches produced by
+// input, so that the aggregate function gets them as regular input columns.
+// It returns the resulting operator and the types of its output as well as the
+// columns that the aggregate function should operate on. NULL delimiters are
+// omitted since they are equivalent to empty ones.
+func planAggregateArguments(
+	allocator *Allocator,
+	evalCtx *tree.EvalContext,
+	input Operator,
+	typs []coltypes.T,
+	agg execinfrapb.AggregatorSpec_Aggregation,
+) (Operator, []coltypes.T, []uint32, error) {
+	if agg.Func != execinfrapb.AggregatorSpec_STRING_AGG || len(agg.Arguments) != 1 {
+		return nil, nil, nil, errors.AssertionFailedf("unexpected arguments of %s", agg.Func)
+	}
+	var helper execinfra.ExprHelper
+	// There are no variables in the constant arguments.
+	if err := helper.Init(agg.Arguments[0], nil /* types */, evalCtx); err != nil {
+		return nil, nil, nil, err
+	}
+	d, err := helper.Eval(nil /* row */)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cols := agg.ColIdx
+	if d == tree.DNull {
+		return input, typs, cols, nil
+	}
+	var delimiter []byte
+	switch t := d.(type) {
+	case *tree.DString:
+		delimiter = []byte(*t)
+	case *tree.DBytes:
+		delimiter = []byte(*t)
+	default:
+		return nil, nil, nil, errors.AssertionFailedf("unexpected delimiter %s of type %T", d, d)
+	}
+	delimiterIdx := len(typs)
+	input, err = NewConstOp(allocator, input, coltypes.Bytes, delimiter, delimiterIdx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	typs = append(typs[:len(typs):len(typs)], coltypes.Bytes)
+	cols = append(cols[:len(cols):len(cols)], uint32(delimiterIdx))
+	return input, typs, cols, nil
+}
+
+// planCollationKeysForOrdering is the same as planCollationKeys but for the
+// columns of an ordering.
+func planCollationKeysForOrdering(
+	allocator *Allocator,
+	input Operator,
+	colTypes []types.T,
+	typs []coltypes.T,
+	ordering execinfrapb.Ordering,
+) (Operator, []coltypes.T, execinfrapb.Ordering, error) {
+	input, typs, cols, err := planCollationKeys(
+		allocator, input, colTypes, typs, orderingColumns(ordering),
+	)
+	if err != nil {
+		return nil, nil, ordering, err
+	}
+	newOrdering := execinfrapb.Ordering{
+		Columns: make([]execinfrapb.Ordering_Column, len(ordering.Columns)),
+	}
+	for i, col := range ordering.Columns {
+		col.ColIdx = cols[i]
+		newOrdering.Columns[i] = col
+	}
+	return input, typs, newOrdering, nil
+}
+
+// orderingColumns returns the indices of the columns of the ordering.
+func orderingColumns(ordering execinfrapb.Ordering) []uint32 {
+	cols := make([]uint32, len(ordering.Columns))
+	for i := range ordering.Columns {
+		cols[i] = ordering.Columns[i].ColIdx
+	}
+	return cols
+}
+
+// orderingCoversCols returns whether the first len(cols) columns of ordering
+// are exactly the columns cols (in any order), i.e. whether the tuples that
+// are equal on cols are adjacent in a stream with that ordering.
+func orderingCoversCols(ordering execinfrapb.Ordering, cols []uint32) bool {
+	if len(ordering.Columns) < len(cols) {
+		return false
+	}
+	for _, ordCol := range ordering.Columns[:len(cols)] {
+		found := false
+		for _, col := range cols {
+			if ordCol.ColIdx == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// checkNoArrayColumns returns an error if any of colTypes is of ARRAY type.
+// Only a few operators support ARRAY columns since most of them have
+// per-type logic for all of their columns.
+func checkNoArrayColumns(colTypes []types.T) error {
+	for i := range colTypes {
+		if colTypes[i].Family() == types.ArrayFamily {
+			return errors.Newf("ARRAY columns are not supported by this processor")
+		}
+	}
+	return nil
+}
+
+// checkNoDatumColumns returns an error if any of colTypes doesn't have a native
+// columnar representation (i.e. it is represented by coltypes.Datum). Similarly
+// to ARRAY columns, such columns can only be passed through by a few operators.
+func checkNoDatumColumns(colTypes []types.T) error {
+	for i := range colTypes {
+		if typeconv.FromColumnType(&colTypes[i]) == coltypes.Datum {
+			return errors.Newf("%s columns are not supported by this processor", colTypes[i].String())
+		}
+	}
+	return nil
+}
+
 // isSupported checks whether we have a columnar operator equivalent to a
 // processor described by spec. Note that it doesn't perform any other checks
 // (like validity of the number of inputs).
 func isSupported(spec *execinfrapb.ProcessorSpec) (bool, error) {
 	core := spec.Core
 
+	if core.Noop == nil && core.TableReader == nil && core.Ordinality == nil && core.ProjectSet == nil {
+		for i := range spec.Input {
+			if err := checkNoArrayColumns(spec.Input[i].ColumnTypes); err != nil {
+				return false, err
+			}
+			if err := checkNoDatumColumns(spec.Input[i].ColumnTypes); err != nil {
+				return false, err
+			}
+		}
+	}
+
 	switch {
 	case core.Noop != nil:
 		return true, nil
@@ -185,8 +396,19 @@ func isSupported(spec *execinfrapb.ProcessorSpec) (bool, error) {
 		}
 		return true, nil
 
+	case core.Values != nil:
+		for i := range core.Values.Columns {
+			if typ := &core.Values.Columns[i].Type; typeconv.FromColumnType(typ) == coltypes.Unhandled {
+				return false, errors.Newf("values with column of unsupported type %s", typ)
+			}
+		}
+		return true, nil
+
 	case core.Aggregator != nil:
 		aggSpec := core.Aggregator
+		if err := checkNoJSONColumns(spec.Input[0].ColumnTypes, aggSpec.GroupCols); err != nil {
+			return false, err
+		}
 		for _, agg := range aggSpec.Aggregations {
 			if agg.Distinct {
 				return false, errors.Newf("distinct aggregation not supported")
@@ -194,13 +416,18 @@ func isSupported(spec *execinfrapb.ProcessorSpec) (bool, error) {
 			if agg.FilterColIdx != nil {
 				return false, errors.Newf("filtering aggregation not supported")
 			}
-			if len(agg.Arguments) > 0 {
-				return false, errors.Newf("aggregates with arguments not supported")
-			}
 			var inputTypes []types.T
 			for _, colIdx := range agg.ColIdx {
 				inputTypes = append(inputTypes, spec.Input[0].ColumnTypes[colIdx])
 			}
+			if len(agg.Arguments) > 0 {
+				if agg.Func != execinfrapb.AggregatorSpec_STRING_AGG || len(agg.Arguments) != 1 || len(inputTypes) != 1 {
+					return false, errors.Newf("aggregates with arguments not supported")
+				}
+				// The delimiter of STRING_AGG is a constant of the same type as the
+				// values, and it is planned as an additional input column.
+				inputTypes = append(inputTypes, inputTypes[0])
+			}
 			if supported, err := isAggregateSupported(agg.Func, inputTypes); !supported {
 				return false, err
 			}
@@ -208,19 +435,52 @@ func isSupported(spec *execinfrapb.ProcessorSpec) (bool, error) {
 		return true, nil
 
 	case core.Distinct != nil:
+		if err := checkNoJSONColumns(spec.Input[0].ColumnTypes, core.Distinct.DistinctColumns); err != nil {
+			return false, err
+		}
 		return true, nil
 
 	case core.Ordinality != nil:
 		return true, nil
 
+	case core.ProjectSet != nil:
+		if len(core.ProjectSet.Exprs) != 1 {
+			return false, errors.Newf("project set with multiple generators is not supported")
+		}
+		colIdx, ok, err := getUnnestColumn(core.ProjectSet.Exprs[0])
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, errors.Newf("only unnest of a column is supported in project set")
+		}
+		inputTypes := spec.Input[0].ColumnTypes
+		if colIdx >= len(inputTypes) || typeconv.FromColumnType(&inputTypes[colIdx]) != coltypes.Array {
+			return false, errors.Newf("unnest of an unsupported type")
+		}
+		return true, nil
+
 	case core.HashJoiner != nil:
 		if !core.HashJoiner.OnExpr.Empty() &&
 			core.HashJoiner.Type != sqlbase.JoinType_INNER {
 			return false, errors.Newf("can't plan non-inner hash join with on expressions")
 		}
+		if err := checkNoJSONColumns(spec.Input[0].ColumnTypes, core.HashJoiner.LeftEqColumns); err != nil {
+			return false, err
+		}
+		if err := checkNoJSONColumns(spec.Input[1].ColumnTypes, core.HashJoiner.RightEqColumns); err != nil {
+			return false, err
+		}
 		return true, nil
 
 	case core.MergeJoiner != nil:
+		if core.MergeJoiner.Type.IsSetOpJoin() {
+			return false, errors.Newf("%s merge join is unsupported", core.MergeJoiner.Type)
+		}
+		if core.MergeJoiner.NullEquality {
+			// The vectorized merge joiner never considers NULL keys equal.
+			return false, errors.Newf("merge join with NULL equality is unsupported")
+		}
 		if !core.MergeJoiner.OnExpr.Empty() {
 			switch core.MergeJoiner.Type {
 			case sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_SEMI, sqlbase.JoinType_LEFT_ANTI:
@@ -228,32 +488,133 @@ func isSupported(spec *execinfrapb.ProcessorSpec) (bool, error) {
 				return false, errors.Errorf("can only plan INNER, LEFT SEMI, and LEFT ANTI merge joins with ON expressions")
 			}
 		}
+		if err := checkNoJSONColumns(
+			spec.Input[0].ColumnTypes, orderingColumns(core.MergeJoiner.LeftOrdering),
+		); err != nil {
+			return false, err
+		}
+		if err := checkNoJSONColumns(
+			spec.Input[1].ColumnTypes, orderingColumns(core.MergeJoiner.RightOrdering),
+		); err != nil {
+			return false, err
+		}
+		if err := checkNoCollatedStringColumns(
+			spec.Input[0].ColumnTypes, orderingColumns(core.MergeJoiner.LeftOrdering),
+		); err != nil {
+			return false, err
+		}
+		if err := checkNoCollatedStringColumns(
+			spec.Input[1].ColumnTypes, orderingColumns(core.MergeJoiner.RightOrdering),
+		); err != nil {
+			return false, err
+		}
+		return true, nil
+
+	case core.Sampler != nil:
+		if err := checkSamplerSpec(core.Sampler); err != nil {
+			return false, err
+		}
+		return true, nil
+
+	case core.JoinReader != nil:
+		if len(core.JoinReader.LookupColumns) == 0 {
+			if core.JoinReader.IndexIdx != 0 {
+				return false, errors.Newf("index join against a secondary index is not supported")
+			}
+			return true, nil
+		}
+		if !core.JoinReader.OnExpr.Empty() {
+			return false, errors.Newf("lookup join with ON expression is not supported")
+		}
+		switch core.JoinReader.Type {
+		case sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_OUTER:
+		default:
+			return false, errors.Newf("%s lookup join is not supported", core.JoinReader.Type)
+		}
+		if !core.JoinReader.LookupColumnsAreKey {
+			// The lookup join buffers all of the rows looked up for an input
+			// batch, which is only bounded if every input tuple has at most one
+			// match. Otherwise, the JoinReader processor is used since it limits
+			// the size of its lookup batches.
+			return false, errors.Newf("lookup join on non-key columns is not supported")
+		}
 		return true, nil
 
 	case core.Sorter != nil:
+		if err := checkNoJSONColumns(
+			spec.Input[0].ColumnTypes, orderingColumns(core.Sorter.OutputOrdering),
+		); err != nil {
+			return false, err
+		}
 		return true, nil
 
 	case core.Windower != nil:
-		if len(core.Windower.WindowFns) != 1 {
-			return false, errors.Newf("only a single window function is currently supported")
+		if len(core.Windower.WindowFns) == 0 {
+			return false, errors.Newf("windower without window functions is not supported")
+		}
+		if err := checkNoJSONColumns(spec.Input[0].ColumnTypes, core.Windower.PartitionBy); err != nil {
+			return false, err
+		}
+		if err := checkNoJSONColumns(
+			spec.Input[0].ColumnTypes, orderingColumns(core.Windower.WindowFns[0].Ordering),
+		); err != nil {
+			return false, err
 		}
-		wf := core.Windower.WindowFns[0]
-		if wf.Frame != nil &&
-			(wf.Frame.Mode != execinfrapb.WindowerSpec_Frame_RANGE ||
-				wf.Frame.Bounds.Start.BoundType != execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING ||
-				(wf.Frame.Bounds.End != nil && wf.Frame.Bounds.End.BoundType != execinfrapb.WindowerSpec_Frame_CURRENT_ROW)) {
-			return false, errors.Newf("window functions with non-default window frames are not supported")
+		if err := checkNoCollatedStringColumns(spec.Input[0].ColumnTypes, core.Windower.PartitionBy); err != nil {
+			return false, err
 		}
-		if wf.Func.AggregateFunc != nil {
-			return false, errors.Newf("aggregate functions used as window functions are not supported")
+		if err := checkNoCollatedStringColumns(
+			spec.Input[0].ColumnTypes, orderingColumns(core.Windower.WindowFns[0].Ordering),
+		); err != nil {
+			return false, err
 		}
+		for i := range core.Windower.WindowFns {
+			wf := &core.Windower.WindowFns[i]
+			// All window functions share the same sort of the input, so they have
+			// to agree on the ordering.
+			if !wf.Ordering.Equal(core.Windower.WindowFns[0].Ordering) {
+				return false, errors.Newf("window functions with different orderings are not supported")
+			}
+			if wf.Func.AggregateFunc != nil {
+				if wf.FilterColIdx != -1 {
+					return false, errors.Newf("filtering aggregate functions used as window functions are not supported")
+				}
+				if len(wf.ArgsIdxs) != 1 {
+					return false, errors.Newf("aggregate functions used as window functions with %d arguments are not supported", len(wf.ArgsIdxs))
+				}
+				if _, err := isWindowAggregateSupported(
+					*wf.Func.AggregateFunc, &spec.Input[0].ColumnTypes[wf.ArgsIdxs[0]], wf.Frame,
+				); err != nil {
+					return false, err
+				}
+				continue
+			}
+			if wf.Frame != nil &&
+				(wf.Frame.Mode != execinfrapb.WindowerSpec_Frame_RANGE ||
+					wf.Frame.Bounds.Start.BoundType != execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING ||
+					(wf.Frame.Bounds.End != nil && wf.Frame.Bounds.End.BoundType != execinfrapb.WindowerSpec_Frame_CURRENT_ROW)) {
+				return false, errors.Newf("window functions with non-default window frames are not supported")
+			}
 
-		switch *wf.Func.WindowFunc {
-		case execinfrapb.WindowerSpec_ROW_NUMBER:
-		case execinfrapb.WindowerSpec_RANK:
-		case execinfrapb.WindowerSpec_DENSE_RANK:
-		default:
-			return false, errors.Newf("window function %s is not supported", wf.String())
+			switch *wf.Func.WindowFunc {
+			case execinfrapb.WindowerSpec_ROW_NUMBER:
+			case execinfrapb.WindowerSpec_RANK:
+			case execinfrapb.WindowerSpec_DENSE_RANK:
+			case execinfrapb.WindowerSpec_PERCENT_RANK, execinfrapb.WindowerSpec_CUME_DIST:
+			case execinfrapb.WindowerSpec_NTILE:
+				if len(wf.ArgsIdxs) != 1 || typeconv.FromColumnType(&spec.Input[0].ColumnTypes[wf.ArgsIdxs[0]]) != coltypes.Int64 {
+					return false, errors.Newf("window function %s with arguments %v is not supported", wf.String(), wf.ArgsIdxs)
+				}
+			case execinfrapb.WindowerSpec_LAG, execinfrapb.WindowerSpec_LEAD,
+				execinfrapb.WindowerSpec_FIRST_VALUE, execinfrapb.WindowerSpec_LAST_VALUE:
+				for _, argIdx := range wf.ArgsIdxs {
+					if typ := &spec.Input[0].ColumnTypes[argIdx]; typeconv.FromColumnType(typ) == coltypes.Unhandled {
+						return false, errors.Newf("window function %s with argument of type %s is not supported", wf.String(), typ)
+					}
+				}
+			default:
+				return false, errors.Newf("window function %s is not supported", wf.String())
+			}
 		}
 		return true, nil
 
@@ -407,6 +768,19 @@ func NewColOperator(
 			result.Op = NewCancelChecker(result.Op)
 			returnMutations := core.TableReader.Visibility == execinfrapb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
 			result.ColumnTypes = core.TableReader.Table.ColumnTypesWithMutations(returnMutations)
+		case core.Values != nil:
+			if err := checkNumIn(inputs, 0); err != nil {
+				return result, err
+			}
+			result.Op, err = newValuesOp(NewAllocator(ctx, streamingMemAccount), core.Values)
+			if err != nil {
+				return result, err
+			}
+			result.IsStreaming = true
+			result.ColumnTypes = make([]types.T, len(core.Values.Columns))
+			for i := range core.Values.Columns {
+				result.ColumnTypes[i] = core.Values.Columns[i].Type
+			}
 		case core.Aggregator != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
 				return result, err
@@ -454,6 +828,12 @@ func NewColOperator(
 				return result, errors.AssertionFailedf("ordered cols must be a subset of grouping cols")
 			}
 
+			var typs []coltypes.T
+			typs, err = typeconv.FromColumnTypes(spec.Input[0].ColumnTypes)
+			if err != nil {
+				return result, err
+			}
+			input := inputs[0]
 			aggTyps := make([][]types.T, len(aggSpec.Aggregations))
 			aggCols := make([][]uint32, len(aggSpec.Aggregations))
 			aggFns := make([]execinfrapb.AggregatorSpec_Func, len(aggSpec.Aggregations))
@@ -465,14 +845,30 @@ func NewColOperator(
 				}
 				aggCols[i] = agg.ColIdx
 				aggFns[i] = agg.Func
+				if len(agg.Arguments) > 0 {
+					// The constant arguments (the delimiter of STRING_AGG) are appended
+					// to the input as columns.
+					input, typs, aggCols[i], err = planAggregateArguments(
+						NewAllocator(ctx, streamingMemAccount), flowCtx.NewEvalCtx(), input, typs, agg,
+					)
+					if err != nil {
+						return result, err
+					}
+					aggTyps[i] = append(aggTyps[i], aggTyps[i][0])
+				}
 				_, retType, err := execinfrapb.GetAggregateInfo(agg.Func, aggTyps[i]...)
 				if err != nil {
 					return result, err
 				}
 				result.ColumnTypes[i] = *retType
 			}
-			var typs []coltypes.T
-			typs, err = typeconv.FromColumnTypes(spec.Input[0].ColumnTypes)
+			// Collated strings are grouped by their collation keys. Note that the
+			// aggregator outputs only the results of the aggregate functions, so
+			// the collation keys don't need to be projected out.
+			keyedGroupCols := aggSpec.GroupCols
+			input, typs, keyedGroupCols, err = planCollationKeys(
+				NewAllocator(ctx, streamingMemAccount), input, spec.Input[0].ColumnTypes, typs, keyedGroupCols,
+			)
 			if err != nil {
 				return result, err
 			}
@@ -480,15 +876,26 @@ func NewColOperator(
 				hashAggregatorMemAccount := streamingMemAccount
 				if !useStreamingMemAccountForBuffering {
 					hashAggregatorMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "hash-aggregator")
+					setOpMemMetrics(hashAggregatorMemAccount, distSQLMetrics(flowCtx).VecAggregatorMem)
+				}
+				if aggSpec.Partial && len(aggSpec.GroupCols) > 0 {
+					// The results of the local stage of a multi-stage aggregation are
+					// merged by the final stage, so we can bound the size of the hash
+					// table by emitting the partial results early.
+					result.Op, err = NewPartialHashAggregator(
+						NewNamedAllocator(ctx, hashAggregatorMemAccount, "hash-aggregator"), input, typs, aggFns,
+						keyedGroupCols, aggCols, partialHashAggregatorMaxBufferedTuples,
+					)
+				} else {
+					result.Op, err = NewHashAggregator(
+						NewNamedAllocator(ctx, hashAggregatorMemAccount, "hash-aggregator"), input, typs, aggFns,
+						keyedGroupCols, aggCols, execinfrapb.IsScalarAggregate(aggSpec),
+					)
 				}
-				result.Op, err = NewHashAggregator(
-					NewAllocator(ctx, hashAggregatorMemAccount), inputs[0], typs, aggFns,
-					aggSpec.GroupCols, aggCols, execinfrapb.IsScalarAggregate(aggSpec),
-				)
 			} else {
 				result.Op, err = NewOrderedAggregator(
-					NewAllocator(ctx, streamingMemAccount), inputs[0], typs, aggFns,
-					aggSpec.GroupCols, aggCols, execinfrapb.IsScalarAggregate(aggSpec),
+					NewAllocator(ctx, streamingMemAccount), input, typs, aggFns,
+					keyedGroupCols, aggCols, execinfrapb.IsScalarAggregate(aggSpec),
 				)
 				result.IsStreaming = true
 			}
@@ -520,16 +927,67 @@ func NewColOperator(
 			if err != nil {
 				return result, err
 			}
+			numInputCols := len(typs)
+			// Collated strings are deduplicated using their collation keys. The
+			// ordered columns are a subset of the distinct columns, so we plan the
+			// collation keys for both at once.
+			input := inputs[0]
+			numDistinctCols := len(core.Distinct.DistinctColumns)
+			keyedCols := append(
+				append([]uint32(nil), core.Distinct.DistinctColumns...), core.Distinct.OrderedColumns...,
+			)
+			input, typs, keyedCols, err = planCollationKeys(
+				NewAllocator(ctx, streamingMemAccount), input, result.ColumnTypes, typs, keyedCols,
+			)
+			if err != nil {
+				return result, err
+			}
+			distinctColumns, orderedColumns := keyedCols[:numDistinctCols], keyedCols[numDistinctCols:]
 			// TODO(yuzefovich): implement the distinct on partially ordered columns.
 			if allSorted {
-				result.Op, err = NewOrderedDistinct(inputs[0], core.Distinct.OrderedColumns, typs)
+				result.Op, err = NewOrderedDistinct(input, orderedColumns, typs)
 				result.IsStreaming = true
 			} else {
-				result.Op = NewUnorderedDistinct(
-					NewAllocator(ctx, streamingMemAccount), inputs[0],
-					core.Distinct.DistinctColumns, typs,
+				distinctMemMonitorName := fmt.Sprintf("unordered-distinct-%d", spec.ProcessorID)
+				var distinctMemAccount *mon.BoundAccount
+				if useStreamingMemAccountForBuffering {
+					distinctMemAccount = streamingMemAccount
+				} else {
+					distinctMemAccount = result.createBufferingMemAccount(
+						ctx, flowCtx, distinctMemMonitorName,
+					)
+				}
+				inMemoryDistinct := NewUnorderedDistinct(
+					NewNamedAllocator(ctx, distinctMemAccount, distinctMemMonitorName), input, distinctColumns, typs,
+				)
+				result.Op = newOneInputDiskSpiller(
+					input, inMemoryDistinct.(bufferingInMemoryOperator),
+					distinctMemMonitorName,
+					func(input Operator) Operator {
+						monitorNamePrefix := "external-distinct-"
+						unlimitedAllocator := NewAllocator(
+							ctx, result.createBufferingUnlimitedMemAccount(
+								ctx, flowCtx, monitorNamePrefix,
+							))
+						diskQueuesUnlimitedAllocator := NewAllocator(
+							ctx, result.createBufferingUnlimitedMemAccount(
+								ctx, flowCtx, monitorNamePrefix+"disk-queues",
+							))
+						partitioner := result.createPartitioner(
+							ctx, flowCtx, diskQueuesUnlimitedAllocator, typs, monitorNamePrefix,
+						)
+						return newExternalDistinct(
+							unlimitedAllocator, input, distinctColumns, typs,
+							partitioner, diskQueuesUnlimitedAllocator,
+						)
+					},
+					args.TestingKnobs.SpillingCallbackFn,
 				)
 			}
+			if err != nil {
+				return result, err
+			}
+			result.projectOutCollationKeys(len(typs), firstColumns(numInputCols))
 		case core.Ordinality != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
 				return result, err
@@ -538,6 +996,25 @@ func NewColOperator(
 			result.Op, result.IsStreaming = NewOrdinalityOp(NewAllocator(ctx, streamingMemAccount), inputs[0], outputIdx), true
 			result.ColumnTypes = append(spec.Input[0].ColumnTypes, *types.Int)
 
+		case core.ProjectSet != nil:
+			if err := checkNumIn(inputs, 1); err != nil {
+				return result, err
+			}
+			colIdx, _, err := getUnnestColumn(core.ProjectSet.Exprs[0])
+			if err != nil {
+				return result, err
+			}
+			inputTypes, err := typeconv.FromColumnTypes(spec.Input[0].ColumnTypes)
+			if err != nil {
+				return result, err
+			}
+			elemType := &core.ProjectSet.GeneratedColumns[0]
+			result.Op, result.IsStreaming = NewUnnestOperator(
+				NewAllocator(ctx, streamingMemAccount), inputs[0], inputTypes, colIdx,
+				typeconv.FromColumnType(elemType),
+			), true
+			result.ColumnTypes = append(spec.Input[0].ColumnTypes, *elemType)
+
 		case core.HashJoiner != nil:
 			createHashJoiner := func(
 				result *NewColOperatorResult,
@@ -556,24 +1033,56 @@ func NewColOperator(
 				hashJoinerMemAccount := streamingMemAccount
 				if !useStreamingMemAccountForBuffering {
 					hashJoinerMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "hash-joiner")
+					setOpMemMetrics(hashJoinerMemAccount, distSQLMetrics(flowCtx).VecHashJoinerMem)
 				}
 				// It is valid for empty set of equality columns to be considered as
 				// "key" (for example, the input has at most 1 row). However, hash
 				// joiner, in order to handle NULL values correctly, needs to think
 				// that an empty set of equality columns doesn't form a key.
 				rightEqColsAreKey := core.HashJoiner.RightEqColumnsAreKey && len(core.HashJoiner.RightEqColumns) > 0
+				// Collated strings are joined on their collation keys.
+				leftInput, keyedLeftTypes, leftEqCols, err := planCollationKeys(
+					NewAllocator(ctx, streamingMemAccount), inputs[0], spec.Input[0].ColumnTypes,
+					leftTypes, core.HashJoiner.LeftEqColumns,
+				)
+				if err != nil {
+					return onExpr, err
+				}
+				rightInput, keyedRightTypes, rightEqCols, err := planCollationKeys(
+					NewAllocator(ctx, streamingMemAccount), inputs[1], spec.Input[1].ColumnTypes,
+					rightTypes, core.HashJoiner.RightEqColumns,
+				)
+				if err != nil {
+					return onExpr, err
+				}
 				result.Op, err = NewEqHashJoinerOp(
-					NewAllocator(ctx, hashJoinerMemAccount),
-					inputs[0],
-					inputs[1],
-					core.HashJoiner.LeftEqColumns,
-					core.HashJoiner.RightEqColumns,
-					leftTypes,
-					rightTypes,
+					NewNamedAllocator(ctx, hashJoinerMemAccount, "hash-joiner"),
+					leftInput,
+					rightInput,
+					leftEqCols,
+					rightEqCols,
+					keyedLeftTypes,
+					keyedRightTypes,
 					rightEqColsAreKey,
 					core.HashJoiner.Type,
 				)
-				return onExpr, err
+				if err != nil {
+					return onExpr, err
+				}
+				// The collation keys are appended to the inputs, so they need to be
+				// projected out of the output of the joiner.
+				numOutputCols := len(keyedLeftTypes)
+				projection := firstColumns(len(leftTypes))
+				switch core.HashJoiner.Type {
+				case sqlbase.JoinType_LEFT_SEMI, sqlbase.JoinType_LEFT_ANTI:
+				default:
+					numOutputCols += len(keyedRightTypes)
+					for i := range rightTypes {
+						projection = append(projection, uint32(len(keyedLeftTypes)+i))
+					}
+				}
+				result.projectOutCollationKeys(numOutputCols, projection)
+				return onExpr, nil
 			}
 
 			err = createJoiner(
@@ -631,7 +1140,7 @@ func NewColOperator(
 					mergeJoinerMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "merge-joiner")
 				}
 				result.Op, err = NewMergeJoinOp(
-					NewAllocator(ctx, mergeJoinerMemAccount),
+					NewNamedAllocator(ctx, mergeJoinerMemAccount, "merge-joiner"),
 					core.MergeJoiner.Type,
 					inputs[0],
 					inputs[1],
@@ -649,6 +1158,70 @@ func NewColOperator(
 				ctx, &result, flowCtx, args, core.MergeJoiner.Type, createMergeJoiner,
 			)
 
+		case core.JoinReader != nil:
+			if err := checkNumIn(inputs, 1); err != nil {
+				return result, err
+			}
+			returnMutations := core.JoinReader.Visibility == execinfrapb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
+			tableTypes := core.JoinReader.Table.ColumnTypesWithMutations(returnMutations)
+			if len(core.JoinReader.LookupColumns) == 0 {
+				// The index join buffers only the spans of the input tuples, so it is
+				// streaming and uses the streaming memory account.
+				var indexJoinOp *indexJoinOp
+				indexJoinOp, err = newIndexJoinOp(
+					NewAllocator(ctx, streamingMemAccount), flowCtx, inputs[0], spec.Input[0].ColumnTypes,
+					core.JoinReader, post,
+				)
+				if err != nil {
+					return result, err
+				}
+				// Similar to colBatchScan, the index join is wrapped with a cancel
+				// checker since it performs KV lookups.
+				result.Op, result.IsStreaming = NewCancelChecker(indexJoinOp), true
+				result.MetadataSources = append(result.MetadataSources, indexJoinOp)
+				result.ColumnTypes = tableTypes
+				break
+			}
+			// The lookup join buffers the rows looked up for a single input batch.
+			// The lookup columns form a key, so there is at most one such row for
+			// every input tuple, which makes the lookup join streaming.
+			var lookupJoinOp *lookupJoinOp
+			lookupJoinOp, err = newLookupJoinOp(
+				NewNamedAllocator(ctx, streamingMemAccount, "lookup-joiner"), flowCtx, inputs[0], spec.Input[0].ColumnTypes,
+				core.JoinReader, post,
+			)
+			if err != nil {
+				return result, err
+			}
+			result.Op, result.IsStreaming = lookupJoinOp, true
+			result.MetadataSources = append(result.MetadataSources, lookupJoinOp)
+			// The lookup join performs KV lookups for every input batch, so, similar
+			// to colBatchScan, it is wrapped with a cancel checker.
+			result.Op = NewCancelChecker(result.Op)
+			result.ColumnTypes = append(append([]types.T(nil), spec.Input[0].ColumnTypes...), tableTypes...)
+
+		case core.Sampler != nil:
+			if err := checkNumIn(inputs, 1); err != nil {
+				return result, err
+			}
+			// The sampler buffers the sampled rows, and once the limit of its memory
+			// account is reached, it disables the histogram collection.
+			samplerMemAccount := streamingMemAccount
+			if !useStreamingMemAccountForBuffering {
+				samplerMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "sampler")
+			}
+			var sampler *samplerOp
+			sampler, err = newSamplerOp(
+				NewAllocator(ctx, streamingMemAccount), samplerMemAccount, flowCtx,
+				core.Sampler, inputs[0], spec.Input[0].ColumnTypes,
+			)
+			if err != nil {
+				return result, err
+			}
+			result.Op = sampler
+			result.MetadataSources = append(result.MetadataSources, sampler)
+			result.ColumnTypes = sampler.outputTypes
+
 		case core.Sorter != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
 				return result, err
@@ -659,7 +1232,17 @@ func NewColOperator(
 			if err != nil {
 				return result, err
 			}
-			orderingCols := core.Sorter.OutputOrdering.Columns
+			numInputCols := len(inputTypes)
+			// Collated strings are sorted by their collation keys.
+			var ordering execinfrapb.Ordering
+			input, inputTypes, ordering, err = planCollationKeysForOrdering(
+				NewAllocator(ctx, streamingMemAccount), input, spec.Input[0].ColumnTypes, inputTypes,
+				core.Sorter.OutputOrdering,
+			)
+			if err != nil {
+				return result, err
+			}
+			orderingCols := ordering.Columns
 			matchLen := core.Sorter.OrderingMatchLen
 			if matchLen > 0 {
 				// The input is already partially ordered. Use a chunks sorter to avoid
@@ -669,18 +1252,29 @@ func NewColOperator(
 					sortChunksMemAccount = streamingMemAccount
 				} else {
 					sortChunksMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "sort-chunks")
+					setOpMemMetrics(sortChunksMemAccount, distSQLMetrics(flowCtx).VecSorterMem)
 				}
 				result.Op, err = NewSortChunks(
-					NewAllocator(ctx, sortChunksMemAccount), input, inputTypes,
+					NewNamedAllocator(ctx, sortChunksMemAccount, "sort-chunks"), input, inputTypes,
 					orderingCols, int(matchLen),
 				)
-			} else if post.Limit != 0 && post.Filter.Empty() && post.Limit+post.Offset < math.MaxUint16 {
+			} else if post.Limit != 0 && post.Filter.Empty() {
 				// There is a limit specified with no post-process filter, so we know
 				// exactly how many rows the sorter should output. Choose a top K sorter,
 				// which uses a heap to avoid storing more rows than necessary.
-				k := uint16(post.Limit + post.Offset)
+				k := post.Limit + post.Offset
+				topKSorterMemMonitorName := fmt.Sprintf("topk-sorter-%d", spec.ProcessorID)
+				topKSorterMemAccount := streamingMemAccount
+				if k > uint64(coldata.BatchSize()) && !useStreamingMemAccountForBuffering {
+					// The top K sorter needs to buffer up more than a single batch, so we
+					// give it a limited memory account.
+					topKSorterMemAccount = result.createBufferingMemAccount(
+						ctx, flowCtx, topKSorterMemMonitorName,
+					)
+					setOpMemMetrics(topKSorterMemAccount, distSQLMetrics(flowCtx).VecSorterMem)
+				}
 				result.Op = NewTopKSorter(
-					NewAllocator(ctx, streamingMemAccount), input, inputTypes,
+					NewNamedAllocator(ctx, topKSorterMemAccount, topKSorterMemMonitorName), input, inputTypes,
 					orderingCols, k,
 				)
 				result.IsStreaming = true
@@ -694,9 +1288,10 @@ func NewColOperator(
 					sorterMemAccount = result.createBufferingMemAccount(
 						ctx, flowCtx, sorterMemMonitorName,
 					)
+					setOpMemMetrics(sorterMemAccount, distSQLMetrics(flowCtx).VecSorterMem)
 				}
 				inMemorySorter, err := NewSorter(
-					NewAllocator(ctx, sorterMemAccount), input, inputTypes, orderingCols,
+					NewNamedAllocator(ctx, sorterMemAccount, sorterMemMonitorName), input, inputTypes, orderingCols,
 				)
 				if err != nil {
 					return result, err
@@ -709,31 +1304,47 @@ func NewColOperator(
 						// We are using an unlimited memory monitor here because external
 						// sort itself is responsible for making sure that we stay within
 						// the memory limit.
-						unlimitedAllocator := NewAllocator(
-							ctx, result.createBufferingUnlimitedMemAccount(
-								ctx, flowCtx, monitorNamePrefix,
-							))
+						unlimitedMemAccount := result.createBufferingUnlimitedMemAccount(
+							ctx, flowCtx, monitorNamePrefix,
+						)
+						setOpMemMetrics(unlimitedMemAccount, distSQLMetrics(flowCtx).VecSorterMem)
+						unlimitedAllocator := NewAllocator(ctx, unlimitedMemAccount)
+						// The in-memory sorter of each partition buffers its input and
+						// releases all of its memory once the partition is sorted, so
+						// the strings are allocated from an arena.
+						unlimitedAllocator.EnableBytesArena()
 						diskQueuesUnlimitedAllocator := NewAllocator(
 							ctx, result.createBufferingUnlimitedMemAccount(
 								ctx, flowCtx, monitorNamePrefix+"disk-queues",
 							))
+						partitioner := result.createPartitioner(
+							ctx, flowCtx, diskQueuesUnlimitedAllocator, inputTypes, monitorNamePrefix,
+						)
 						return newExternalSorter(
 							unlimitedAllocator,
-							input, inputTypes, core.Sorter.OutputOrdering,
+							input, inputTypes, ordering,
 							execinfra.GetWorkMemLimit(flowCtx.Cfg),
+							partitioner,
 							diskQueuesUnlimitedAllocator,
 						)
 					},
 					args.TestingKnobs.SpillingCallbackFn,
 				)
 			}
+			if err != nil {
+				return result, err
+			}
+			result.projectOutCollationKeys(len(inputTypes), firstColumns(numInputCols))
 			result.ColumnTypes = spec.Input[0].ColumnTypes
 
 		case core.Windower != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
 				return result, err
 			}
-			wf := core.Windower.WindowFns[0]
+			// All window functions have the same ordering (this has been checked
+			// in isSupported), so we only need to sort the input once, using the
+			// ordering of the first function.
+			firstWF := core.Windower.WindowFns[0]
 			input := inputs[0]
 			var typs []coltypes.T
 			typs, err = typeconv.FromColumnTypes(spec.Input[0].ColumnTypes)
@@ -742,27 +1353,84 @@ func NewColOperator(
 			}
 			tempPartitionColOffset, partitionColIdx := 0, -1
 			if len(core.Windower.PartitionBy) > 0 {
-				// TODO(yuzefovich): add support for hashing partitioner (probably by
-				// leveraging hash routers once we can distribute). The decision about
-				// which kind of partitioner to use should come from the optimizer.
-				windowSortingPartitionerMemAccount := streamingMemAccount
-				if !useStreamingMemAccountForBuffering {
-					windowSortingPartitionerMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "window-sorting-partitioner")
+				partitionBy := core.Windower.PartitionBy
+				tempPartitionColOffset, partitionColIdx = 1, int(firstWF.OutputColIdx)
+				if orderingCoversCols(spec.Input[0].Ordering, partitionBy) {
+					// The input is already ordered on the PARTITION BY columns, so we
+					// only need to buffer up a single partition at a time in order to
+					// sort it on the ORDER BY columns.
+					windowPartitionerMemAccount := streamingMemAccount
+					if !useStreamingMemAccountForBuffering {
+						windowPartitionerMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "window-streaming-partitioner")
+					}
+					input, err = NewWindowStreamingPartitioner(
+						NewNamedAllocator(ctx, windowPartitionerMemAccount, "window-streaming-partitioner"), input, typs,
+						partitionBy, firstWF.Ordering.Columns, partitionColIdx,
+					)
+				} else {
+					// We sort the input in memory, and if it doesn't fit, we fall back
+					// to the external window partitioner that hashes the tuples into
+					// buckets on disk and sorts one bucket at a time.
+					// TODO: the decision about which kind of partitioner to use should
+					// come from the optimizer.
+					sorterMemMonitorName := fmt.Sprintf("window-sorter-%d", spec.ProcessorID)
+					var sorterMemAccount *mon.BoundAccount
+					if useStreamingMemAccountForBuffering {
+						sorterMemAccount = streamingMemAccount
+					} else {
+						sorterMemAccount = result.createBufferingMemAccount(
+							ctx, flowCtx, sorterMemMonitorName,
+						)
+					}
+					var inMemorySorter Operator
+					inMemorySorter, err = NewSorter(
+						NewNamedAllocator(ctx, sorterMemAccount, sorterMemMonitorName), input, typs,
+						windowPartitionerOrdering(partitionBy, firstWF.Ordering.Columns),
+					)
+					if err != nil {
+						return result, err
+					}
+					input = newOneInputDiskSpiller(
+						input, inMemorySorter.(bufferingInMemoryOperator),
+						sorterMemMonitorName,
+						func(input Operator) Operator {
+							monitorNamePrefix := "external-window-partitioner-"
+							unlimitedAllocator := NewAllocator(
+								ctx, result.createBufferingUnlimitedMemAccount(
+									ctx, flowCtx, monitorNamePrefix,
+								))
+							diskQueuesUnlimitedAllocator := NewAllocator(
+								ctx, result.createBufferingUnlimitedMemAccount(
+									ctx, flowCtx, monitorNamePrefix+"disk-queues",
+								))
+							partitioner := result.createPartitioner(
+								ctx, flowCtx, diskQueuesUnlimitedAllocator, typs, monitorNamePrefix,
+							)
+							var externalPartitioner Operator
+							externalPartitioner, err = newExternalWindowPartitioner(
+								unlimitedAllocator, input, typs, partitionBy,
+								firstWF.Ordering.Columns, partitioner, diskQueuesUnlimitedAllocator,
+							)
+							return externalPartitioner
+						},
+						args.TestingKnobs.SpillingCallbackFn,
+					)
+					if err != nil {
+						return result, err
+					}
+					input, err = newWindowPartitionMarker(
+						NewAllocator(ctx, streamingMemAccount), input, typs, partitionBy, partitionColIdx,
+					)
 				}
-				input, err = NewWindowSortingPartitioner(
-					NewAllocator(ctx, windowSortingPartitionerMemAccount), input, typs,
-					core.Windower.PartitionBy, wf.Ordering.Columns, int(wf.OutputColIdx),
-				)
-				tempPartitionColOffset, partitionColIdx = 1, int(wf.OutputColIdx)
 			} else {
-				if len(wf.Ordering.Columns) > 0 {
+				if len(firstWF.Ordering.Columns) > 0 {
 					windowSorterMemAccount := streamingMemAccount
 					if !useStreamingMemAccountForBuffering {
 						windowSorterMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "window-sorter")
 					}
 					input, err = NewSorter(
-						NewAllocator(ctx, windowSorterMemAccount), input, typs,
-						wf.Ordering.Columns,
+						NewNamedAllocator(ctx, windowSorterMemAccount, "window-sorter"), input, typs,
+						firstWF.Ordering.Columns,
 					)
 				}
 				// TODO(yuzefovich): when both PARTITION BY and ORDER BY clauses are
@@ -772,32 +1440,143 @@ func NewColOperator(
 				return result, err
 			}
 
-			orderingCols := make([]uint32, len(wf.Ordering.Columns))
-			for i, col := range wf.Ordering.Columns {
+			orderingCols := make([]uint32, len(firstWF.Ordering.Columns))
+			for i, col := range firstWF.Ordering.Columns {
 				orderingCols[i] = col.ColIdx
 			}
-			switch *wf.Func.WindowFunc {
-			case execinfrapb.WindowerSpec_ROW_NUMBER:
-				result.Op = NewRowNumberOperator(NewAllocator(ctx, streamingMemAccount), input, int(wf.OutputColIdx)+tempPartitionColOffset, partitionColIdx)
-			case execinfrapb.WindowerSpec_RANK:
-				result.Op, err = NewRankOperator(NewAllocator(ctx, streamingMemAccount), input, typs, false /* dense */, orderingCols, int(wf.OutputColIdx)+tempPartitionColOffset, partitionColIdx)
-			case execinfrapb.WindowerSpec_DENSE_RANK:
-				result.Op, err = NewRankOperator(NewAllocator(ctx, streamingMemAccount), input, typs, true /* dense */, orderingCols, int(wf.OutputColIdx)+tempPartitionColOffset, partitionColIdx)
+			result.ColumnTypes = make([]types.T, len(spec.Input[0].ColumnTypes), len(spec.Input[0].ColumnTypes)+len(core.Windower.WindowFns))
+			copy(result.ColumnTypes, spec.Input[0].ColumnTypes)
+			// wfInputTypes describes all of the columns of the batches that are
+			// fed into the next window function operator.
+			wfInputTypes := append([]coltypes.T(nil), typs...)
+			if partitionColIdx != -1 {
+				wfInputTypes = append(wfInputTypes, coltypes.Bool)
+			}
+			for wfIdx, wf := range core.Windower.WindowFns {
+				// Every window function appends its output column to the batches
+				// produced by the previous one.
+				outputColIdx := int(wf.OutputColIdx) + tempPartitionColOffset
+				outputType := *types.Int
+				if wf.Func.AggregateFunc != nil {
+					// Aggregate functions used as window functions buffer up the whole
+					// partition.
+					windowAggMemAccount := streamingMemAccount
+					if !useStreamingMemAccountForBuffering {
+						windowAggMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "window-aggregate")
+					}
+					var retType *types.T
+					_, retType, err = execinfrapb.GetAggregateInfo(*wf.Func.AggregateFunc, spec.Input[0].ColumnTypes[wf.ArgsIdxs[0]])
+					if err != nil {
+						return result, err
+					}
+					input, err = NewWindowAggregateOperator(
+						NewNamedAllocator(ctx, windowAggMemAccount, "window-aggregate"), input, wfInputTypes, *wf.Func.AggregateFunc,
+						wf.ArgsIdxs, wf.Frame, orderingCols, outputColIdx, partitionColIdx,
+					)
+					outputType = *retType
+				} else {
+					switch windowFn := *wf.Func.WindowFunc; windowFn {
+					case execinfrapb.WindowerSpec_ROW_NUMBER:
+						input = NewRowNumberOperator(NewAllocator(ctx, streamingMemAccount), input, outputColIdx, partitionColIdx)
+					case execinfrapb.WindowerSpec_RANK:
+						input, err = NewRankOperator(NewAllocator(ctx, streamingMemAccount), input, typs, false /* dense */, orderingCols, outputColIdx, partitionColIdx)
+					case execinfrapb.WindowerSpec_DENSE_RANK:
+						input, err = NewRankOperator(NewAllocator(ctx, streamingMemAccount), input, typs, true /* dense */, orderingCols, outputColIdx, partitionColIdx)
+					case execinfrapb.WindowerSpec_LAG, execinfrapb.WindowerSpec_LEAD,
+						execinfrapb.WindowerSpec_FIRST_VALUE, execinfrapb.WindowerSpec_LAST_VALUE:
+						// These functions buffer up the whole partition.
+						valueWindowMemAccount := streamingMemAccount
+						if !useStreamingMemAccountForBuffering {
+							valueWindowMemAccount = result.createBufferingMemAccount(ctx, flowCtx, "window-value-func")
+						}
+						input, err = NewValueWindowFuncOperator(
+							NewNamedAllocator(ctx, valueWindowMemAccount, "window-value-func"), input, wfInputTypes, windowFn,
+							wf.ArgsIdxs, orderingCols, outputColIdx, partitionColIdx,
+						)
+						outputType = spec.Input[0].ColumnTypes[wf.ArgsIdxs[0]]
+					case execinfrapb.WindowerSpec_PERCENT_RANK, execinfrapb.WindowerSpec_CUME_DIST,
+						execinfrapb.WindowerSpec_NTILE:
+						// These functions need to know the number of tuples in the
+						// partition, so they buffer up the whole partition, and if it
+						// doesn't fit in memory, they fall back to the external operator
+						// that buffers up the whole input on disk.
+						relativeRankMemMonitorName := fmt.Sprintf("window-relative-rank-%d-%d", spec.ProcessorID, wfIdx)
+						var relativeRankMemAccount *mon.BoundAccount
+						if useStreamingMemAccountForBuffering {
+							relativeRankMemAccount = streamingMemAccount
+						} else {
+							relativeRankMemAccount = result.createBufferingMemAccount(
+								ctx, flowCtx, relativeRankMemMonitorName,
+							)
+						}
+						var inMemoryRelativeRank Operator
+						inMemoryRelativeRank, err = NewRelativeRankOperator(
+							NewNamedAllocator(ctx, relativeRankMemAccount, relativeRankMemMonitorName), input, wfInputTypes, windowFn,
+							wf.ArgsIdxs, orderingCols, outputColIdx, partitionColIdx,
+						)
+						if err != nil {
+							return result, err
+						}
+						// wfInputTypes is modified below, so the fallback gets its own
+						// copy.
+						inputTypes := append([]coltypes.T(nil), wfInputTypes...)
+						input = newOneInputDiskSpiller(
+							input, inMemoryRelativeRank.(bufferingInMemoryOperator),
+							relativeRankMemMonitorName,
+							func(input Operator) Operator {
+								monitorNamePrefix := "external-relative-rank-"
+								unlimitedAllocator := NewAllocator(
+									ctx, result.createBufferingUnlimitedMemAccount(
+										ctx, flowCtx, monitorNamePrefix,
+									))
+								diskQueuesUnlimitedAllocator := NewAllocator(
+									ctx, result.createBufferingUnlimitedMemAccount(
+										ctx, flowCtx, monitorNamePrefix+"disk-queues",
+									))
+								sizesTypes := []coltypes.T{coltypes.Int64}
+								tuplesPartitioner := result.createPartitioner(
+									ctx, flowCtx, diskQueuesUnlimitedAllocator, inputTypes, monitorNamePrefix,
+								)
+								sizesPartitioner := result.createPartitioner(
+									ctx, flowCtx, diskQueuesUnlimitedAllocator, sizesTypes, monitorNamePrefix+"sizes",
+								)
+								var externalRelativeRank Operator
+								externalRelativeRank, err = newExternalRelativeRankOp(
+									unlimitedAllocator, input, inputTypes, windowFn, wf.ArgsIdxs,
+									orderingCols, outputColIdx, partitionColIdx, tuplesPartitioner,
+									sizesPartitioner, inMemoryRelativeRank.(*bufferedWindowOp).numExportedEmitted,
+									diskQueuesUnlimitedAllocator,
+								)
+								return externalRelativeRank
+							},
+							args.TestingKnobs.SpillingCallbackFn,
+						)
+						if windowFn != execinfrapb.WindowerSpec_NTILE {
+							outputType = *types.Float
+						}
+					}
+				}
+				if err != nil {
+					return result, err
+				}
+				wfInputTypes = append(wfInputTypes, typeconv.FromColumnType(&outputType))
+				result.ColumnTypes = append(result.ColumnTypes, outputType)
 			}
+			result.Op = input
 
 			if partitionColIdx != -1 {
 				// Window partitioner will append a temporary column to the batch which
 				// we want to project out.
-				projection := make([]uint32, 0, wf.OutputColIdx+1)
-				for i := uint32(0); i < wf.OutputColIdx; i++ {
-					projection = append(projection, i)
+				numOutputCols := len(result.ColumnTypes)
+				projection := make([]uint32, 0, numOutputCols)
+				for i := 0; i < numOutputCols+1; i++ {
+					if i != partitionColIdx {
+						projection = append(projection, uint32(i))
+					}
 				}
-				projection = append(projection, wf.OutputColIdx+1)
-				result.Op = NewSimpleProjectOp(result.Op, int(wf.OutputColIdx+1), projection)
+				result.Op = NewSimpleProjectOp(result.Op, numOutputCols+1, projection)
 			}
 
-			result.ColumnTypes = append(spec.Input[0].ColumnTypes, *types.Int)
-
 		default:
 			return result, errors.Newf("unsupported processor core %q", core)
 		}
@@ -816,6 +1595,15 @@ func NewColOperator(
 	// Note: at this point, it is legal for ColumnTypes to be empty (it is
 	// legal for empty rows to be passed between processors).
 
+	// The operators for the filter and the render expressions are planned on
+	// top of a feed so that they can be fused into a single operator.
+	var postInput Operator
+	var postFeed *selProjFeedOp
+	if !post.Filter.Empty() || post.RenderExprs != nil {
+		postInput = result.Op
+		postFeed = newSelProjFeedOp(postInput)
+		result.Op = postFeed
+	}
 	if !post.Filter.Empty() {
 		if err = result.planFilterExpr(
 			ctx, flowCtx.NewEvalCtx(), post.Filter, streamingMemAccount,
@@ -828,28 +1616,27 @@ func NewColOperator(
 	} else if post.RenderExprs != nil {
 		log.VEventf(ctx, 2, "planning render expressions %+v", post.RenderExprs)
 		var renderedCols []uint32
+		// All render expressions are compiled into a single program, so the
+		// subexpressions that they share are computed only once.
+		compiler := newExprCompiler(flowCtx.NewEvalCtx(), result.Op, result.ColumnTypes, streamingMemAccount)
 		for _, expr := range post.RenderExprs {
-			var (
-				helper            execinfra.ExprHelper
-				renderInternalMem int
-			)
+			var helper execinfra.ExprHelper
 			err := helper.Init(expr, result.ColumnTypes, flowCtx.EvalCtx)
 			if err != nil {
 				return result, err
 			}
-			var outputIdx int
-			result.Op, outputIdx, result.ColumnTypes, renderInternalMem, err = planProjectionOperators(
-				ctx, flowCtx.NewEvalCtx(), helper.Expr, result.ColumnTypes, result.Op, streamingMemAccount,
-			)
+			outputIdx, err := compiler.compile(ctx, helper.Expr)
 			if err != nil {
 				return result, errors.Wrapf(err, "unable to columnarize render expression %q", expr)
 			}
 			if outputIdx < 0 {
 				return result, errors.AssertionFailedf("missing outputIdx")
 			}
-			result.InternalMemUsage += renderInternalMem
 			renderedCols = append(renderedCols, uint32(outputIdx))
 		}
+		log.VEventf(ctx, 2, "compiled render expressions into %d steps", len(compiler.program))
+		result.Op, result.ColumnTypes = compiler.op, compiler.typs
+		result.InternalMemUsage += compiler.internalMemUsed
 		result.Op = NewSimpleProjectOp(result.Op, len(result.ColumnTypes), renderedCols)
 		newTypes := make([]types.T, 0, len(renderedCols))
 		for _, j := range renderedCols {
@@ -857,12 +1644,44 @@ func NewColOperator(
 		}
 		result.ColumnTypes = newTypes
 	}
+	// Now that the projection is planned, we know which columns of the output
+	// of the core are needed.
+	result.Op = pruneColumns(result.Op)
+	if postFeed != nil {
+		result.Op = newFusedSelProjOp(postInput, postFeed, result.Op)
+	}
 	if post.Offset != 0 {
 		result.Op = NewOffsetOp(result.Op, post.Offset)
 	}
 	if post.Limit != 0 {
 		result.Op = NewLimitOp(result.Op, post.Limit)
 	}
+	if core.TableReader != nil && supported && processorConstructor != nil {
+		if useHybridScan, threshold := shouldUseHybridScan(flowCtx, core.TableReader); useHybridScan {
+			// The row engine processor is given the original post-processing spec
+			// so that it produces the same output as the columnar operators.
+			proc, err := processorConstructor(
+				ctx, flowCtx, spec.ProcessorID, core, &spec.Post, nil, /* inputs */
+				[]execinfra.RowReceiver{nil}, /* outputs */
+				nil,                          /* localProcessors */
+			)
+			if err != nil {
+				return result, err
+			}
+			rs, ok := proc.(execinfra.RowSource)
+			if !ok {
+				return result, errors.Newf("processor %s is not an execinfra.RowSource", core.String())
+			}
+			hybridOp, err := newHybridScanOp(
+				ctx, NewAllocator(ctx, streamingMemAccount), rs, result.Op, result.ColumnTypes, threshold,
+			)
+			if err != nil {
+				return result, err
+			}
+			result.Op = hybridOp
+			result.MetadataSources = append(result.MetadataSources, hybridOp)
+		}
+	}
 	return result, err
 }
 
@@ -921,6 +1740,67 @@ func (r *NewColOperatorResult) createBufferingUnlimitedMemAccount(
 	return &bufferingMemAccount
 }
 
+// distSQLMetrics returns the DistSQL metrics of the server (or empty metrics if
+// they are not set up, which is the case in some tests).
+func distSQLMetrics(flowCtx *execinfra.FlowCtx) execinfra.DistSQLMetrics {
+	if flowCtx.Cfg.Metrics == nil {
+		return execinfra.DistSQLMetrics{}
+	}
+	return *flowCtx.Cfg.Metrics
+}
+
+// setOpMemMetrics makes the monitor of the buffering memory account acc
+// (created by createBufferingMemAccount or createBufferingUnlimitedMemAccount)
+// report its memory usage to the metrics of the class of the operator that
+// uses it. It is a noop if the metrics are not set up.
+func setOpMemMetrics(acc *mon.BoundAccount, metrics execinfra.OpMemMetrics) {
+	if metrics.CurBytesCount == nil {
+		return
+	}
+	acc.Monitor().SetMetrics(metrics.CurBytesCount, metrics.MaxBytesHist)
+}
+
+// createDiskAccount instantiates an unlimited disk monitor and a disk account
+// to be used for disk spilling infrastructure in vectorized engine. The
+// monitor and the account are released together with the buffering memory
+// monitors and accounts, so the receiver is updated to have references to
+// both objects.
+func (r *NewColOperatorResult) createDiskAccount(
+	ctx context.Context, flowCtx *execinfra.FlowCtx, name string,
+) *mon.BoundAccount {
+	opDiskMonitor := execinfra.NewMonitor(ctx, flowCtx.Cfg.DiskMonitor, name+"-disk")
+	r.BufferingOpMemMonitors = append(r.BufferingOpMemMonitors, opDiskMonitor)
+	opDiskAccount := opDiskMonitor.MakeBoundAccount()
+	r.BufferingOpMemAccounts = append(r.BufferingOpMemAccounts, &opDiskAccount)
+	return &opDiskAccount
+}
+
+// createPartitioner returns the Partitioner in which a disk-backed Operator
+// stores its partitions of tuples of the provided types. The partitions are
+// stored in disk queues in the temporary storage, and the bytes written to
+// disk are registered with a disk account created with the provided name. If
+// there is no temporary storage FS (which is the case in some tests), the
+// partitions are kept in memory instead.
+func (r *NewColOperatorResult) createPartitioner(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	allocator *Allocator,
+	typs []coltypes.T,
+	name string,
+) Partitioner {
+	if flowCtx.Cfg.TempFS == nil {
+		return newDummyPartitioner(allocator, typs)
+	}
+	partitioner, err := colcontainer.NewPartitionedDiskQueue(
+		ctx, typs, colcontainer.DiskQueueCfg{FS: flowCtx.Cfg.TempFS},
+		r.createDiskAccount(ctx, flowCtx, name),
+	)
+	if err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	return partitioner
+}
+
 func (r *NewColOperatorResult) planFilterExpr(
 	ctx context.Context,
 	evalCtx *tree.EvalContext,
@@ -973,6 +1853,31 @@ func (r *NewColOperatorResult) addProjection(projection []uint32) {
 	r.ColumnTypes = newTypes
 }
 
+// projectOutCollationKeys projects out of the output of r.Op with numCols
+// columns the collation keys that were appended to the input of the operator
+// by planCollationKeys. projection specifies the columns to keep, and no
+// operator is planned if there is nothing to project out.
+func (r *NewColOperatorResult) projectOutCollationKeys(numCols int, projection []uint32) {
+	if len(projection) == numCols {
+		return
+	}
+	// The projection hides the internal memory usage of the operator, so we
+	// need to account for it here.
+	if internalMemOp, ok := r.Op.(InternalMemoryOperator); ok {
+		r.InternalMemUsage += internalMemOp.InternalMemoryUsage()
+	}
+	r.Op = NewSimpleProjectOp(r.Op, numCols, projection)
+}
+
+// firstColumns returns the projection of the first n columns.
+func firstColumns(n int) []uint32 {
+	projection := make([]uint32, n)
+	for i := range projection {
+		projection[i] = uint32(i)
+	}
+	return projection
+}
+
 func planSelectionOperators(
 	ctx context.Context,
 	evalCtx *tree.EvalContext,
@@ -981,10 +1886,26 @@ func planSelectionOperators(
 	input Operator,
 	acc *mon.BoundAccount,
 ) (op Operator, resultIdx int, ct []types.T, internalMemUsed int, err error) {
+	if _, ok := expr.(*tree.IndexedVar); !ok && usesDatumColumns(expr, columnTypes) {
+		op, resultIdx, ct, internalMemUsed, err = planDatumProjection(
+			ctx, evalCtx, expr, columnTypes, input, acc,
+		)
+		if err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		return NewBoolVecToSelOp(op, resultIdx), resultIdx, ct, internalMemUsed, nil
+	}
 	switch t := expr.(type) {
 	case *tree.IndexedVar:
 		return NewBoolVecToSelOp(input, t.Idx), -1, columnTypes, internalMemUsed, nil
 	case *tree.AndExpr:
+		if evalCtx.Settings != nil && adaptiveFilterOrderingEnabled.Get(&evalCtx.Settings.SV) {
+			// The order in which the conjuncts are evaluated is chosen at runtime
+			// based on their observed selectivity.
+			return planAdaptiveAndSelection(
+				ctx, evalCtx, flattenAndExpr(t, nil /* conjuncts */), columnTypes, input, acc,
+			)
+		}
 		// AND expressions are handled by an implicit AND'ing of selection vectors.
 		// First we select out the tuples that are true on the left side, and then,
 		// only among the matched tuples, we select out the tuples that are true on
@@ -1033,6 +1954,49 @@ func planSelectionOperators(
 		op = NewBoolVecToSelOp(op, resultIdx)
 		return op, resultIdx, ct, internalMemUsed, err
 	case *tree.ComparisonExpr:
+		if isTupleComparison(t.Operator, t.TypedLeft(), t.TypedRight()) {
+			var expanded tree.TypedExpr
+			if expanded, err = expandTupleComparison(t.Operator, t.TypedLeft(), t.TypedRight()); err != nil {
+				return nil, resultIdx, ct, internalMemUsed, err
+			}
+			return planSelectionOperators(ctx, evalCtx, expanded, columnTypes, input, acc)
+		}
+		if isCollatedStringComparison(t.TypedLeft(), t.TypedRight()) {
+			var left, right tree.TypedExpr
+			left, right, op, ct, internalMemUsed, err = planCollationKeyArgs(
+				ctx, evalCtx, t.Operator, t.TypedLeft(), t.TypedRight(), columnTypes, input, acc,
+			)
+			if err != nil {
+				return nil, resultIdx, ct, internalMemUsed, err
+			}
+			var internalMemUsedCmp int
+			op, resultIdx, ct, internalMemUsedCmp, err = planSelectionOperators(
+				ctx, evalCtx, tree.NewTypedComparisonExpr(t.Operator, left, right), ct, op, acc,
+			)
+			return op, resultIdx, ct, internalMemUsed + internalMemUsedCmp, err
+		}
+		if isMixedTimestampComparison(t.Operator, t.TypedLeft(), t.TypedRight()) {
+			var left, right tree.TypedExpr
+			if left, err = timestampTZArg(evalCtx, t.TypedLeft()); err != nil {
+				return nil, resultIdx, ct, internalMemUsed, err
+			}
+			if right, err = timestampTZArg(evalCtx, t.TypedRight()); err != nil {
+				return nil, resultIdx, ct, internalMemUsed, err
+			}
+			return planSelectionOperators(
+				ctx, evalCtx, tree.NewTypedComparisonExpr(t.Operator, left, right), columnTypes, input, acc,
+			)
+		}
+		if (t.Operator == tree.IsDistinctFrom || t.Operator == tree.IsNotDistinctFrom) && t.Right != tree.DNull {
+			// IS [NOT] DISTINCT FROM with a non-NULL argument doesn't have a
+			// selection form, so we plan a projection and then convert the
+			// resulting boolean to a selection vector.
+			op, resultIdx, ct, internalMemUsed, err = planProjectionOperators(
+				ctx, evalCtx, expr, columnTypes, input, acc,
+			)
+			op = NewBoolVecToSelOp(op, resultIdx)
+			return op, resultIdx, ct, internalMemUsed, err
+		}
 		cmpOp := t.Operator
 		leftOp, leftIdx, ct, internalMemUsedLeft, err := planProjectionOperators(
 			ctx, evalCtx, t.TypedLeft(), columnTypes, input, acc,
@@ -1042,10 +2006,18 @@ func planSelectionOperators(
 		}
 		lTyp := &ct[leftIdx]
 		if constArg, ok := t.Right.(tree.Datum); ok {
-			if t.Operator == tree.Like || t.Operator == tree.NotLike {
-				negate := t.Operator == tree.NotLike
+			switch t.Operator {
+			case tree.Like, tree.NotLike, tree.ILike, tree.NotILike:
+				caseInsensitive := t.Operator == tree.ILike || t.Operator == tree.NotILike
+				negate := t.Operator == tree.NotLike || t.Operator == tree.NotILike
 				op, err = GetLikeOperator(
-					evalCtx, leftOp, leftIdx, string(tree.MustBeDString(constArg)), negate)
+					evalCtx, leftOp, leftIdx, string(tree.MustBeDString(constArg)), caseInsensitive, negate)
+				return op, resultIdx, ct, internalMemUsedLeft, err
+			case tree.RegMatch, tree.NotRegMatch, tree.RegIMatch, tree.NotRegIMatch:
+				caseInsensitive := t.Operator == tree.RegIMatch || t.Operator == tree.NotRegIMatch
+				negate := t.Operator == tree.NotRegMatch || t.Operator == tree.NotRegIMatch
+				op, err = GetRegexpOperator(
+					evalCtx, leftOp, leftIdx, string(tree.MustBeDString(constArg)), caseInsensitive, negate)
 				return op, resultIdx, ct, internalMemUsedLeft, err
 			}
 			if t.Operator == tree.In || t.Operator == tree.NotIn {
@@ -1059,16 +2031,23 @@ func planSelectionOperators(
 				return op, resultIdx, ct, internalMemUsedLeft, err
 			}
 			if t.Operator == tree.IsDistinctFrom || t.Operator == tree.IsNotDistinctFrom {
-				if t.Right != tree.DNull {
-					err = errors.Errorf("IS DISTINCT FROM and IS NOT DISTINCT FROM are supported only with NULL argument")
-					return nil, resultIdx, ct, internalMemUsed, err
-				}
 				// IS NULL is replaced with IS NOT DISTINCT FROM NULL, so we want to
 				// negate when IS DISTINCT FROM is used.
 				negate := t.Operator == tree.IsDistinctFrom
 				op = newIsNullSelOp(leftOp, leftIdx, negate)
 				return op, resultIdx, ct, internalMemUsedLeft, err
 			}
+			if lTyp.Family() == types.JsonFamily {
+				op, err = GetJSONSelectionOperator(leftOp, leftIdx, cmpOp, constArg)
+				return op, resultIdx, ct, internalMemUsedLeft, err
+			}
+			if s, ok := constArg.(*tree.DString); ok && lTyp.Family() == types.StringFamily &&
+				(cmpOp == tree.EQ || cmpOp == tree.NE) {
+				// The equality comparisons of strings are performed on the
+				// dictionary codes of the dictionary-encoded vectors.
+				op = newSelBytesEqualConstOp(leftOp, leftIdx, []byte(*s), cmpOp == tree.NE)
+				return op, resultIdx, ct, internalMemUsedLeft, err
+			}
 			op, err := GetSelectionConstOperator(lTyp, t.TypedRight().ResolvedType(), cmpOp, leftOp, leftIdx, constArg)
 			return op, resultIdx, ct, internalMemUsedLeft, err
 		}
@@ -1078,10 +2057,22 @@ func planSelectionOperators(
 		if err != nil {
 			return nil, resultIdx, ct, internalMemUsed, err
 		}
+		if lTyp.Family() == types.JsonFamily || ct[rightIdx].Family() == types.JsonFamily {
+			err = errors.Errorf("comparison %s on JSON is only supported with a constant argument", cmpOp)
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
 		op, err := GetSelectionOperator(lTyp, &ct[rightIdx], cmpOp, rightOp, leftIdx, rightIdx)
 		return op, resultIdx, ct, internalMemUsedLeft + internalMemUsedRight, err
 	default:
-		return nil, resultIdx, nil, internalMemUsed, errors.Errorf("unhandled selection expression type: %s", reflect.TypeOf(t))
+		// The expression doesn't have a selection form, so we plan a projection
+		// and then convert the resulting boolean to a selection vector.
+		op, resultIdx, ct, internalMemUsed, err = planProjectionOperators(
+			ctx, evalCtx, expr, columnTypes, input, acc,
+		)
+		if err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		return NewBoolVecToSelOp(op, resultIdx), resultIdx, ct, internalMemUsed, nil
 	}
 }
 
@@ -1111,6 +2102,7 @@ func planTypedMaybeNullProjectionOperators(
 // 'toType' that will be output at index 'resultIdx'.
 func planCastOperator(
 	ctx context.Context,
+	evalCtx *tree.EvalContext,
 	acc *mon.BoundAccount,
 	columnTypes []types.T,
 	input Operator,
@@ -1119,7 +2111,15 @@ func planCastOperator(
 	toType *types.T,
 ) (op Operator, resultIdx int, ct []types.T, err error) {
 	outputIdx := len(columnTypes)
-	op, err = GetCastOperator(NewAllocator(ctx, acc), input, inputIdx, outputIdx, fromType, toType)
+	if isTimestampType(fromType) && isTimestampType(toType) {
+		// The casts between TIMESTAMP and TIMESTAMPTZ depend on the session
+		// timezone, so they are handled separately.
+		op, err = NewTimestampCastOp(
+			NewAllocator(ctx, acc), evalCtx, input, inputIdx, outputIdx, fromType, toType,
+		)
+	} else {
+		op, err = GetCastOperator(NewAllocator(ctx, acc), input, inputIdx, outputIdx, fromType, toType)
+	}
 	ct = append(columnTypes, *toType)
 	return op, outputIdx, ct, err
 }
@@ -1137,6 +2137,9 @@ func planProjectionOperators(
 	acc *mon.BoundAccount,
 ) (op Operator, resultIdx int, ct []types.T, internalMemUsed int, err error) {
 	resultIdx = -1
+	if _, ok := expr.(*tree.IndexedVar); !ok && usesDatumColumns(expr, columnTypes) {
+		return planDatumProjection(ctx, evalCtx, expr, columnTypes, input, acc)
+	}
 	switch t := expr.(type) {
 	case *tree.IndexedVar:
 		return input, t.Idx, columnTypes, internalMemUsed, nil
@@ -1158,7 +2161,7 @@ func planProjectionOperators(
 		if err != nil {
 			return nil, 0, nil, internalMemUsed, err
 		}
-		op, resultIdx, ct, err = planCastOperator(ctx, acc, ct, op, resultIdx, expr.ResolvedType(), t.Type)
+		op, resultIdx, ct, err = planCastOperator(ctx, evalCtx, acc, ct, op, resultIdx, expr.ResolvedType(), t.Type)
 		return op, resultIdx, ct, internalMemUsed, err
 	case *tree.FuncExpr:
 		var (
@@ -1176,7 +2179,10 @@ func planProjectionOperators(
 				ctx, evalCtx, e.(tree.TypedExpr), ct, op, acc,
 			)
 			if err != nil {
-				return nil, resultIdx, nil, internalMemUsed, err
+				// The argument is not supported natively, so we evaluate the whole
+				// function using datums.
+				log.VEventf(ctx, 2, "planning datum projection for %s because %s", t, err)
+				return planDatumProjection(ctx, evalCtx, t, columnTypes, input, acc)
 			}
 			inputCols = append(inputCols, resultIdx)
 			internalMemUsed += projectionInternalMem
@@ -1195,6 +2201,18 @@ func planProjectionOperators(
 			return nil, resultIdx, ct, internalMemUsed, errors.New("cannot plan null type unknown")
 		}
 		typ := typeconv.FromColumnType(datumType)
+		if typ == coltypes.Array {
+			// Constant arrays are planned as array constructors of constant
+			// elements.
+			arr := tree.MustBeDArray(t)
+			elems := make(tree.TypedExprs, len(arr.Array))
+			for i := range arr.Array {
+				elems[i] = arr.Array[i]
+			}
+			return planProjectionOperators(
+				ctx, evalCtx, tree.NewTypedArray(elems, datumType), columnTypes, input, acc,
+			)
+		}
 		constVal, err := typeconv.GetDatumToPhysicalFn(datumType)(t)
 		if err != nil {
 			return nil, resultIdx, ct, internalMemUsed, err
@@ -1205,22 +2223,10 @@ func planProjectionOperators(
 		}
 		return op, resultIdx, ct, internalMemUsed, nil
 	case *tree.CaseExpr:
-		if t.Expr != nil {
-			return nil, resultIdx, ct, internalMemUsed, errors.New("CASE <expr> WHEN expressions unsupported")
-		}
-
 		buffer := NewBufferOp(input)
 		caseOps := make([]Operator, len(t.Whens))
 		caseOutputType := typeconv.FromColumnType(t.ResolvedType())
-		switch caseOutputType {
-		case coltypes.Bytes:
-			// Currently, there is a contradiction between the way CASE operator
-			// works (which populates its output in arbitrary order) and the flat
-			// bytes implementation of Bytes type (which prohibits sets in arbitrary
-			// order), so we reject such scenario to fall back to row-by-row engine.
-			return nil, resultIdx, ct, internalMemUsed, errors.Newf(
-				"unsupported type %s in CASE operator", t.ResolvedType().String())
-		case coltypes.Unhandled:
+		if caseOutputType == coltypes.Unhandled {
 			return nil, resultIdx, ct, internalMemUsed, errors.Newf(
 				"unsupported type %s", t.ResolvedType().String())
 		}
@@ -1242,8 +2248,13 @@ func planProjectionOperators(
 			// results of the WHEN into a single output vector, assembling the final
 			// result of the case projection.
 			var whenInternalMemUsed, thenInternalMemUsed int
+			cond := when.Cond.(tree.TypedExpr)
+			if t.Expr != nil {
+				// CASE <expr> WHEN <val> is equivalent to CASE WHEN <expr> = <val>.
+				cond = tree.NewTypedComparisonExpr(tree.EQ, t.Expr.(tree.TypedExpr), cond)
+			}
 			caseOps[i], resultIdx, ct, whenInternalMemUsed, err = planTypedMaybeNullProjectionOperators(
-				ctx, evalCtx, when.Cond.(tree.TypedExpr), t.ResolvedType(), ct, buffer, acc,
+				ctx, evalCtx, cond, t.ResolvedType(), ct, buffer, acc,
 			)
 			if err != nil {
 				return nil, resultIdx, ct, internalMemUsed, err
@@ -1265,7 +2276,7 @@ func planProjectionOperators(
 				// such case, we need to plan a cast.
 				fromType, toType := &ct[thenIdxs[i]], &ct[caseOutputIdx]
 				caseOps[i], thenIdxs[i], ct, err = planCastOperator(
-					ctx, acc, ct, caseOps[i], thenIdxs[i], fromType, toType,
+					ctx, evalCtx, acc, ct, caseOps[i], thenIdxs[i], fromType, toType,
 				)
 				if err != nil {
 					return nil, resultIdx, ct, internalMemUsed, err
@@ -1293,7 +2304,7 @@ func planProjectionOperators(
 			elseIdx := thenIdxs[len(t.Whens)]
 			fromType, toType := &ct[elseIdx], &ct[caseOutputIdx]
 			elseOp, thenIdxs[len(t.Whens)], ct, err = planCastOperator(
-				ctx, acc, ct, elseOp, elseIdx, fromType, toType,
+				ctx, evalCtx, acc, ct, elseOp, elseIdx, fromType, toType,
 			)
 			if err != nil {
 				return nil, resultIdx, ct, internalMemUsed, err
@@ -1305,9 +2316,108 @@ func planProjectionOperators(
 		return op, caseOutputIdx, ct, internalMemUsed, err
 	case *tree.AndExpr, *tree.OrExpr:
 		return planLogicalProjectionOp(ctx, evalCtx, expr, columnTypes, input, acc)
+	case *tree.Array:
+		elemType := t.ResolvedType().ArrayContents()
+		physElemType := typeconv.FromColumnType(elemType)
+		if typeconv.FromColumnType(t.ResolvedType()) != coltypes.Array {
+			return nil, resultIdx, ct, internalMemUsed, errors.Newf(
+				"unsupported type %s", t.ResolvedType().String())
+		}
+		var (
+			inputCols             []int
+			projectionInternalMem int
+		)
+		ct = columnTypes
+		op = input
+		for _, e := range t.Exprs {
+			var elemIdx int
+			op, elemIdx, ct, projectionInternalMem, err = planTypedMaybeNullProjectionOperators(
+				ctx, evalCtx, e.(tree.TypedExpr), elemType, ct, op, acc,
+			)
+			if err != nil {
+				return nil, resultIdx, ct, internalMemUsed, err
+			}
+			internalMemUsed += projectionInternalMem
+			if !ct[elemIdx].Equal(*elemType) {
+				// The elements might have a different type of the same family (for
+				// example, INT2 instead of INT8), so we need to plan a cast.
+				op, elemIdx, ct, err = planCastOperator(ctx, evalCtx, acc, ct, op, elemIdx, &ct[elemIdx], elemType)
+				if err != nil {
+					return nil, resultIdx, ct, internalMemUsed, err
+				}
+			}
+			inputCols = append(inputCols, elemIdx)
+		}
+		resultIdx = len(ct)
+		ct = append(ct, *t.ResolvedType())
+		op = NewArrayProjectionOp(NewAllocator(ctx, acc), op, inputCols, physElemType, resultIdx)
+		return op, resultIdx, ct, internalMemUsed, nil
+	case *tree.IndirectionExpr:
+		if len(t.Indirection) != 1 || t.Indirection[0].Slice {
+			return nil, resultIdx, ct, internalMemUsed, errors.Newf("array slicing is not supported")
+		}
+		index, ok := t.Indirection[0].Begin.(tree.Datum)
+		if !ok {
+			return nil, resultIdx, ct, internalMemUsed, errors.Newf(
+				"only constant array indices are supported")
+		}
+		arrTyp := t.Expr.(tree.TypedExpr).ResolvedType()
+		if typeconv.FromColumnType(arrTyp) != coltypes.Array {
+			return nil, resultIdx, ct, internalMemUsed, errors.Newf(
+				"unsupported type %s", arrTyp.String())
+		}
+		var arrIdx int
+		op, arrIdx, ct, internalMemUsed, err = planProjectionOperators(
+			ctx, evalCtx, t.Expr.(tree.TypedExpr), columnTypes, input, acc,
+		)
+		if err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		resultIdx = len(ct)
+		ct = append(ct, *t.ResolvedType())
+		op, err = GetArrayIndexProjectionOperator(
+			NewAllocator(ctx, acc), op, arrIdx, resultIdx,
+			typeconv.FromColumnType(t.ResolvedType()), index,
+		)
+		return op, resultIdx, ct, internalMemUsed, err
 	default:
-		return nil, resultIdx, nil, internalMemUsed, errors.Errorf("unhandled projection expression type: %s", reflect.TypeOf(t))
+		log.VEventf(ctx, 2, "planning datum projection for unhandled expression type %s", reflect.TypeOf(t))
+		return planDatumProjection(ctx, evalCtx, t, columnTypes, input, acc)
+	}
+}
+
+// planDatumProjection plans an operator that evaluates expr using datums (see
+// datumProjectionOp). It is used for the expressions that the vectorized
+// engine doesn't support natively.
+func planDatumProjection(
+	ctx context.Context,
+	evalCtx *tree.EvalContext,
+	expr tree.TypedExpr,
+	columnTypes []types.T,
+	input Operator,
+	acc *mon.BoundAccount,
+) (op Operator, resultIdx int, ct []types.T, internalMemUsed int, err error) {
+	resultIdx = len(columnTypes)
+	op, err = NewDatumProjectionOp(NewAllocator(ctx, acc), evalCtx, expr, columnTypes, resultIdx, input)
+	ct = append(columnTypes, *expr.ResolvedType())
+	return op, resultIdx, ct, internalMemUsed, err
+}
+
+// usesDatumColumns returns whether expr either evaluates to or references a
+// column (described by columnTypes) of a type that is represented by
+// coltypes.Datum. Such expressions can only be evaluated using datums.
+func usesDatumColumns(expr tree.TypedExpr, columnTypes []types.T) bool {
+	if typeconv.FromColumnType(expr.ResolvedType()) == coltypes.Datum {
+		return true
+	}
+	visitor := ivarExpressionVisitor{ivarSeen: make([]bool, len(columnTypes))}
+	_, _ = tree.WalkExpr(visitor, expr)
+	for i, seen := range visitor.ivarSeen {
+		if seen && typeconv.FromColumnType(&columnTypes[i]) == coltypes.Datum {
+			return true
+		}
 	}
+	return false
 }
 
 func planProjectionExpr(
@@ -1321,6 +2431,56 @@ func planProjectionExpr(
 	acc *mon.BoundAccount,
 ) (op Operator, resultIdx int, ct []types.T, internalMemUsed int, err error) {
 	resultIdx = -1
+	if isTupleComparison(binOp, left, right) {
+		var expanded tree.TypedExpr
+		if expanded, err = expandTupleComparison(binOp.(tree.ComparisonOperator), left, right); err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		return planProjectionOperators(ctx, evalCtx, expanded, columnTypes, input, acc)
+	}
+	if isCollatedStringComparison(left, right) {
+		cmpOp, ok := binOp.(tree.ComparisonOperator)
+		if !ok {
+			err = errors.Errorf("unsupported operator %s on collated strings", binOp)
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		left, right, op, ct, internalMemUsed, err = planCollationKeyArgs(
+			ctx, evalCtx, cmpOp, left, right, columnTypes, input, acc,
+		)
+		if err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		var internalMemUsedCmp int
+		op, resultIdx, ct, internalMemUsedCmp, err = planProjectionExpr(
+			ctx, evalCtx, binOp, outputType, left, right, ct, op, acc,
+		)
+		return op, resultIdx, ct, internalMemUsed + internalMemUsedCmp, err
+	}
+	if isMixedTimestampComparison(binOp, left, right) {
+		if left, err = timestampTZArg(evalCtx, left); err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		if right, err = timestampTZArg(evalCtx, right); err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		return planProjectionExpr(ctx, evalCtx, binOp, outputType, left, right, columnTypes, input, acc)
+	}
+	if left.ResolvedType().Family() == types.JsonFamily || right.ResolvedType().Family() == types.JsonFamily {
+		// The operations on JSON values are only supported with a JSON
+		// expression on the left and a constant argument on the right.
+		_, lConst := left.(tree.Datum)
+		_, rConst := right.(tree.Datum)
+		if lConst || !rConst || left.ResolvedType().Family() != types.JsonFamily {
+			err = errors.Errorf("operator %s on JSON is only supported with a constant right argument", binOp)
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+	}
+	if (binOp == tree.IsDistinctFrom || binOp == tree.IsNotDistinctFrom) &&
+		left != tree.DNull && right != tree.DNull {
+		return planIsNotDistinctFromProjection(
+			ctx, evalCtx, binOp == tree.IsDistinctFrom, left, right, columnTypes, input, acc,
+		)
+	}
 	// There are 3 cases. Either the left is constant, the right is constant,
 	// or neither are constant.
 	lConstArg, lConst := left.(tree.Datum)
@@ -1362,11 +2522,20 @@ func planProjectionExpr(
 		// The projection result will be outputted to a new column which is appended
 		// to the input batch.
 		resultIdx = len(ct)
-		if binOp == tree.Like || binOp == tree.NotLike {
-			negate := binOp == tree.NotLike
+		if binOp == tree.Like || binOp == tree.NotLike || binOp == tree.ILike || binOp == tree.NotILike {
+			caseInsensitive := binOp == tree.ILike || binOp == tree.NotILike
+			negate := binOp == tree.NotLike || binOp == tree.NotILike
 			op, err = GetLikeProjectionOperator(
 				NewAllocator(ctx, acc), evalCtx, leftOp, leftIdx, resultIdx,
-				string(tree.MustBeDString(rConstArg)), negate,
+				string(tree.MustBeDString(rConstArg)), caseInsensitive, negate,
+			)
+		} else if binOp == tree.RegMatch || binOp == tree.NotRegMatch ||
+			binOp == tree.RegIMatch || binOp == tree.NotRegIMatch {
+			caseInsensitive := binOp == tree.RegIMatch || binOp == tree.NotRegIMatch
+			negate := binOp == tree.NotRegMatch || binOp == tree.NotRegIMatch
+			op, err = GetRegexpProjectionOperator(
+				NewAllocator(ctx, acc), evalCtx, leftOp, leftIdx, resultIdx,
+				string(tree.MustBeDString(rConstArg)), caseInsensitive, negate,
 			)
 		} else if binOp == tree.In || binOp == tree.NotIn {
 			negate := binOp == tree.NotIn
@@ -1388,6 +2557,19 @@ func planProjectionExpr(
 			// negate when IS DISTINCT FROM is used.
 			negate := binOp == tree.IsDistinctFrom
 			op = newIsNullProjOp(NewAllocator(ctx, acc), leftOp, leftIdx, resultIdx, negate)
+		} else if ct[leftIdx].Family() == types.JsonFamily {
+			if binOp == tree.JSONFetchVal || binOp == tree.JSONFetchText {
+				op, err = GetJSONFetchProjectionOperator(
+					NewAllocator(ctx, acc), leftOp, leftIdx, resultIdx, rConstArg,
+					binOp == tree.JSONFetchText, /* asText */
+				)
+			} else if cmpOp, ok := binOp.(tree.ComparisonOperator); ok {
+				op, err = GetJSONProjectionOperator(
+					NewAllocator(ctx, acc), leftOp, leftIdx, resultIdx, cmpOp, rConstArg,
+				)
+			} else {
+				err = errors.Errorf("unsupported JSON operator %s", binOp)
+			}
 		} else {
 			op, err = GetProjectionRConstOperator(
 				NewAllocator(ctx, acc), &ct[leftIdx], right.ResolvedType(), binOp,
@@ -1420,6 +2602,125 @@ func planProjectionExpr(
 	return op, resultIdx, ct, internalMemUsed, err
 }
 
+// planIsNotDistinctFromProjection plans the operators that project the result
+// of left IS NOT DISTINCT FROM right (or IS DISTINCT FROM, if negate is true)
+// where neither of the arguments is NULL. The result is derived from the
+// equality comparison of the arguments and their nullity (see
+// isNotDistinctFromProjOp).
+func planIsNotDistinctFromProjection(
+	ctx context.Context,
+	evalCtx *tree.EvalContext,
+	negate bool,
+	left, right tree.TypedExpr,
+	columnTypes []types.T,
+	input Operator,
+	acc *mon.BoundAccount,
+) (op Operator, resultIdx int, ct []types.T, internalMemUsed int, err error) {
+	resultIdx = -1
+	var leftIdx, eqIdx, internalMemUsedArg int
+	op, leftIdx, ct, internalMemUsed, err = planProjectionOperators(
+		ctx, evalCtx, left, columnTypes, input, acc,
+	)
+	if err != nil {
+		return nil, resultIdx, ct, internalMemUsed, err
+	}
+	// rightIdx remains -1 if the right argument is a (non-NULL) constant.
+	rightIdx, rightArg := -1, right
+	if _, rConst := right.(tree.Datum); !rConst {
+		op, rightIdx, ct, internalMemUsedArg, err = planProjectionOperators(
+			ctx, evalCtx, right, ct, op, acc,
+		)
+		internalMemUsed += internalMemUsedArg
+		if err != nil {
+			return nil, resultIdx, ct, internalMemUsed, err
+		}
+		rightArg = tree.NewTypedOrdinalReference(rightIdx, &ct[rightIdx])
+	}
+	op, eqIdx, ct, internalMemUsedArg, err = planProjectionExpr(
+		ctx, evalCtx, tree.EQ, types.Bool, tree.NewTypedOrdinalReference(leftIdx, &ct[leftIdx]),
+		rightArg, ct, op, acc,
+	)
+	internalMemUsed += internalMemUsedArg
+	if err != nil {
+		return nil, resultIdx, ct, internalMemUsed, err
+	}
+	resultIdx = len(ct)
+	op = newIsNotDistinctFromProjOp(NewAllocator(ctx, acc), op, eqIdx, leftIdx, rightIdx, resultIdx, negate)
+	ct = append(ct, *types.Bool)
+	return op, resultIdx, ct, internalMemUsed, nil
+}
+
+// isCollatedStringComparison returns whether any of the arguments of a binary
+// operation is a collated string.
+func isCollatedStringComparison(left, right tree.TypedExpr) bool {
+	return left.ResolvedType().Family() == types.CollatedStringFamily ||
+		right.ResolvedType().Family() == types.CollatedStringFamily
+}
+
+// planCollationKeyArgs plans the operators that project the collation keys of
+// the collated string arguments of the comparison cmpOp. The non-constant
+// arguments are replaced with the references to the columns with their
+// collation keys while the constant arguments are replaced with their keys
+// (see collationKeyDatum), so the comparison of the returned arguments is the
+// bytewise comparison of the collation keys.
+func planCollationKeyArgs(
+	ctx context.Context,
+	evalCtx *tree.EvalContext,
+	cmpOp tree.ComparisonOperator,
+	left, right tree.TypedExpr,
+	columnTypes []types.T,
+	input Operator,
+	acc *mon.BoundAccount,
+) (
+	newLeft, newRight tree.TypedExpr,
+	op Operator,
+	ct []types.T,
+	internalMemUsed int,
+	err error,
+) {
+	switch cmpOp {
+	case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE, tree.In, tree.NotIn,
+		tree.IsDistinctFrom, tree.IsNotDistinctFrom:
+	default:
+		err = errors.Errorf("unsupported comparison %s on collated strings", cmpOp)
+		return nil, nil, nil, columnTypes, internalMemUsed, err
+	}
+	op, ct = input, columnTypes
+	planArg := func(arg tree.TypedExpr) (tree.TypedExpr, error) {
+		if d, ok := arg.(tree.Datum); ok {
+			return collationKeyDatum(d), nil
+		}
+		var (
+			argIdx             int
+			internalMemUsedArg int
+			err                error
+		)
+		op, argIdx, ct, internalMemUsedArg, err = planProjectionOperators(
+			ctx, evalCtx, arg, ct, op, acc,
+		)
+		if err != nil {
+			return nil, err
+		}
+		internalMemUsed += internalMemUsedArg
+		if ct[argIdx].Family() != types.CollatedStringFamily {
+			return tree.NewTypedOrdinalReference(argIdx, &ct[argIdx]), nil
+		}
+		keyIdx := len(ct)
+		op, err = NewCollationKeyOp(
+			NewAllocator(ctx, acc), op, argIdx, keyIdx, ct[argIdx].Locale(),
+		)
+		ct = append(ct, *types.Bytes)
+		return tree.NewTypedOrdinalReference(keyIdx, types.Bytes), err
+	}
+	if newLeft, err = planArg(left); err != nil {
+		return nil, nil, nil, ct, internalMemUsed, err
+	}
+	if newRight, err = planArg(right); err != nil {
+		return nil, nil, nil, ct, internalMemUsed, err
+	}
+	return newLeft, newRight, op, ct, internalMemUsed, nil
+}
+
 // planLogicalProjectionOp plans all the needed operators for a projection of
 // a logical operation (either AND or OR).
 func planLogicalProjectionOp(
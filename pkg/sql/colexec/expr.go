@@ -11,6 +11,8 @@
 package colexec
 
 import (
+	"strings"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -43,6 +45,30 @@ func findIVarsInRange(expr execinfrapb.Expression, start int, end int) ([]uint32
 	return res, nil
 }
 
+// getUnnestColumn returns the index of the column that is the argument of the
+// unnest generator if expr is unnest applied to a single column. ok is false
+// for all other expressions.
+func getUnnestColumn(expr execinfrapb.Expression) (colIdx int, ok bool, _ error) {
+	var e tree.Expr
+	if expr.LocalExpr != nil {
+		e = expr.LocalExpr
+	} else {
+		var err error
+		if e, err = parser.ParseExpr(expr.Expr); err != nil {
+			return 0, false, err
+		}
+	}
+	f, ok := e.(*tree.FuncExpr)
+	if !ok || len(f.Exprs) != 1 || !strings.EqualFold(f.Func.String(), "unnest") {
+		return 0, false, nil
+	}
+	ivar, ok := f.Exprs[0].(*tree.IndexedVar)
+	if !ok {
+		return 0, false, nil
+	}
+	return ivar.Idx, true, nil
+}
+
 type ivarExpressionVisitor struct {
 	ivarSeen []bool
 }
@@ -0,0 +1,235 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+// exprCompiler compiles scalar expressions that are evaluated over the
+// batches of a single input into a flat program. Every step of the program is
+// an expression whose arguments are either constants or columns (of the input
+// or the ones computed by the previous steps), and every step is planned as a
+// single projection operator on top of the previous ones. While compiling, the
+// constant subexpressions are folded, and the subexpressions that have
+// already been computed (within the same expression or by another expression
+// compiled by the same exprCompiler) are not computed again.
+type exprCompiler struct {
+	evalCtx *tree.EvalContext
+	acc     *mon.BoundAccount
+
+	// op is the operator that outputs the input columns followed by the
+	// columns computed by the program, and typs are the types of those
+	// columns.
+	op              Operator
+	typs            []types.T
+	internalMemUsed int
+
+	// program contains the steps of the program in the order they are
+	// evaluated. It is used only for testing and logging.
+	program []tree.TypedExpr
+	// computed maps the key of every compiled subexpression (see
+	// exprCompilerKey) to the index of the column that contains its result.
+	computed map[string]int
+}
+
+func newExprCompiler(
+	evalCtx *tree.EvalContext, input Operator, inputTypes []types.T, acc *mon.BoundAccount,
+) *exprCompiler {
+	return &exprCompiler{
+		evalCtx:  evalCtx,
+		acc:      acc,
+		op:       input,
+		typs:     inputTypes,
+		computed: make(map[string]int),
+	}
+}
+
+// compile adds the steps that compute expr to the program and returns the
+// index of the column that will contain the result.
+func (c *exprCompiler) compile(ctx context.Context, expr tree.TypedExpr) (int, error) {
+	v := tree.MakeConstantEvalVisitor(c.evalCtx)
+	folded, _ := tree.WalkExpr(&v, expr)
+	if err := v.Err(); err != nil {
+		return -1, err
+	}
+	expr = folded.(tree.TypedExpr)
+	if isFlattenable(expr) {
+		compiled, err := c.compileFlattenable(ctx, expr)
+		if err != nil {
+			return -1, err
+		}
+		return compiled.(*tree.IndexedVar).Idx, nil
+	}
+	// The expression (an input column, a constant, or an expression that
+	// cannot be flattened) is planned as a whole.
+	return c.plan(ctx, expr)
+}
+
+// compileFlattenable compiles the flattenable expression expr and returns
+// the reference to the column that will contain its result.
+func (c *exprCompiler) compileFlattenable(
+	ctx context.Context, expr tree.TypedExpr,
+) (tree.TypedExpr, error) {
+	key, ok := exprCompilerKey(expr)
+	if ok {
+		if idx, ok := c.computed[key]; ok {
+			return tree.NewTypedOrdinalReference(idx, expr.ResolvedType()), nil
+		}
+	}
+	// The arguments of expr are compiled first, and then expr is planned with
+	// the references to the columns with their results. If some argument
+	// cannot be compiled, expr is planned as a whole (for example, a function
+	// with an argument that is not supported natively is evaluated using
+	// datums), so the steps of the arguments that have already been added are
+	// discarded.
+	checkpoint := *c
+	checkpoint.computed = nil
+	v := exprCompilerArgsVisitor{ctx: ctx, c: c, root: expr}
+	step, _ := tree.WalkExpr(&v, expr)
+	if v.err != nil {
+		log.VEventf(ctx, 2, "planning %s as a whole because %s", expr, v.err)
+		c.rollback(checkpoint)
+		step = expr
+	}
+	idx, err := c.plan(ctx, step.(tree.TypedExpr))
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		c.computed[key] = idx
+	}
+	return tree.NewTypedOrdinalReference(idx, expr.ResolvedType()), nil
+}
+
+// plan plans a single step of the program that evaluates expr.
+func (c *exprCompiler) plan(ctx context.Context, expr tree.TypedExpr) (int, error) {
+	op, resultIdx, typs, internalMemUsed, err := planProjectionOperators(
+		ctx, c.evalCtx, expr, c.typs, c.op, c.acc,
+	)
+	if err != nil {
+		return -1, err
+	}
+	c.op, c.typs = op, typs
+	c.internalMemUsed += internalMemUsed
+	c.program = append(c.program, expr)
+	return resultIdx, nil
+}
+
+// rollback discards all of the steps that have been added to the program
+// after checkpoint was made.
+func (c *exprCompiler) rollback(checkpoint exprCompiler) {
+	for key, idx := range c.computed {
+		if idx >= len(checkpoint.typs) {
+			delete(c.computed, key)
+		}
+	}
+	checkpoint.computed = c.computed
+	*c = checkpoint
+}
+
+// isFlattenable returns whether expr is planned as a separate step of the
+// program with its arguments computed by the previous steps. Note that the
+// arguments of the conditional expressions (like CASE or AND) are not
+// computed separately since they must not be evaluated for all tuples (for
+// example, the ELSE arm of CASE might result in an error for the tuples that
+// satisfy the WHEN condition), so these expressions are planned as a whole.
+func isFlattenable(expr tree.TypedExpr) bool {
+	switch expr.(type) {
+	case *tree.ComparisonExpr, *tree.BinaryExpr, *tree.CastExpr, *tree.FuncExpr,
+		*tree.IndirectionExpr:
+		return true
+	case *tree.CaseExpr, *tree.AndExpr, *tree.OrExpr:
+		// The conditional expressions are planned as separate steps too, but
+		// their arguments are left as is.
+		return true
+	default:
+		return false
+	}
+}
+
+// hasFlattenableArgs returns whether the arguments of expr can be computed
+// by the separate steps of the program.
+func hasFlattenableArgs(expr tree.TypedExpr) bool {
+	switch expr.(type) {
+	case *tree.CaseExpr, *tree.AndExpr, *tree.OrExpr:
+		return false
+	default:
+		return true
+	}
+}
+
+// exprCompilerKey returns the key that identifies the result of expr. Two
+// expressions with the same key always evaluate to the same values. The
+// second return value is false if the result of expr cannot be reused (when
+// expr contains impure functions).
+func exprCompilerKey(expr tree.TypedExpr) (string, bool) {
+	v := impureFuncVisitor{}
+	tree.WalkExprConst(&v, expr)
+	if v.impure {
+		return "", false
+	}
+	return tree.AsStringWithFlags(expr, tree.FmtCheckEquivalence) + ":" + expr.ResolvedType().SQLString(), true
+}
+
+// exprCompilerArgsVisitor compiles the flattenable arguments of root and
+// replaces them with the references to the columns with their results.
+type exprCompilerArgsVisitor struct {
+	ctx  context.Context
+	c    *exprCompiler
+	root tree.TypedExpr
+	err  error
+}
+
+var _ tree.Visitor = &exprCompilerArgsVisitor{}
+
+func (v *exprCompilerArgsVisitor) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if expr == v.root {
+		return hasFlattenableArgs(v.root), expr
+	}
+	if v.err != nil {
+		return false, expr
+	}
+	typedExpr, ok := expr.(tree.TypedExpr)
+	if !ok || !isFlattenable(typedExpr) {
+		return false, expr
+	}
+	compiled, err := v.c.compileFlattenable(v.ctx, typedExpr)
+	if err != nil {
+		v.err = err
+		return false, expr
+	}
+	return false, compiled
+}
+
+func (*exprCompilerArgsVisitor) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
+// impureFuncVisitor determines whether an expression contains impure
+// functions.
+type impureFuncVisitor struct {
+	impure bool
+}
+
+var _ tree.Visitor = &impureFuncVisitor{}
+
+func (v *impureFuncVisitor) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if f, ok := expr.(*tree.FuncExpr); ok && f.IsImpure() {
+		v.impure = true
+	}
+	return !v.impure, expr
+}
+
+func (*impureFuncVisitor) VisitPost(expr tree.Expr) tree.Expr { return expr }
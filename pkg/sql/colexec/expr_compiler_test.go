@@ -0,0 +1,109 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExprCompiler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	inputTypes := []types.T{*types.Int, *types.Int}
+	input := tuples{{1, 2}, {3, 4}, {0, 5}, {nil, 1}}
+	for _, tc := range []struct {
+		renders []string
+		// numSteps is the expected number of the steps of the compiled program.
+		numSteps int
+		expected tuples
+	}{
+		{
+			// The sum is computed only once.
+			renders:  []string{"(@1 + @2) * 2", "(@1 + @2) * 2 > 10", "@1 + @2"},
+			numSteps: 3,
+			expected: tuples{{6, false, 3}, {14, true, 7}, {10, false, 5}, {nil, nil, nil}},
+		},
+		{
+			// The constant subexpression is folded.
+			renders:  []string{"@1 + (1 + 2)", "@2 - @1 + (1 + 2)"},
+			numSteps: 3,
+			expected: tuples{{4, 4}, {6, 4}, {3, 8}, {nil, nil}},
+		},
+		{
+			// The arms of CASE are not computed separately since they must not be
+			// evaluated for all tuples, so the product is computed again by the
+			// second render.
+			renders:  []string{"CASE WHEN @1 = 0 THEN 0 ELSE @2 * @1 END", "@2 * @1 + 1"},
+			numSteps: 3,
+			expected: tuples{{2, 3}, {12, 13}, {0, 1}, {nil, nil}},
+		},
+	} {
+		var renders []execinfrapb.Expression
+		for _, render := range tc.renders {
+			renders = append(renders, execinfrapb.Expression{Expr: render})
+		}
+
+		t.Run("Program", func(t *testing.T) {
+			c := newExprCompiler(
+				&evalCtx, newOpTestInput(1 /* batchSize */, input, []coltypes.T{coltypes.Int64, coltypes.Int64}),
+				inputTypes, testMemAcc,
+			)
+			for _, render := range renders {
+				var helper execinfra.ExprHelper
+				require.NoError(t, helper.Init(render, inputTypes, &evalCtx))
+				_, err := c.compile(ctx, helper.Expr)
+				require.NoError(t, err)
+			}
+			require.Len(t, c.program, tc.numSteps, "program %v", c.program)
+		})
+
+		runTests(t, []tuples{input}, tc.expected, orderedVerifier, func(input []Operator) (Operator, error) {
+			spec := &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Noop: &execinfrapb.NoopCoreSpec{},
+				},
+				Post: execinfrapb.PostProcessSpec{RenderExprs: renders},
+			}
+			args := NewColOperatorArgs{
+				Spec:                spec,
+				Inputs:              input,
+				StreamingMemAccount: testMemAcc,
+			}
+			args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+			result, err := NewColOperator(ctx, flowCtx, args)
+			if err != nil {
+				return nil, err
+			}
+			return result.Op, nil
+		})
+	}
+}
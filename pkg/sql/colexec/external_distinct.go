@@ -0,0 +1,150 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+)
+
+// externalDistinctNumPartitions is the number of partitions that the input to
+// the external distinct is divided into.
+const externalDistinctNumPartitions = 16
+
+// externalDistinctState indicates the current state of the external distinct.
+type externalDistinctState int
+
+const (
+	// externalDistinctPartitioning indicates that the input is being divided
+	// into the partitions.
+	externalDistinctPartitioning externalDistinctState = iota
+	// externalDistinctEmitting indicates that the input has been fully
+	// consumed, and the distinct tuples of the partitions are being emitted
+	// one partition at a time.
+	externalDistinctEmitting
+	// externalDistinctFinished indicates that all partitions have been
+	// processed. This state is also responsible for closing the partitions.
+	externalDistinctFinished
+)
+
+// externalDistinct is a disk-backed unordered distinct operator. It divides
+// its input into several partitions by hashing the distinct columns, so that
+// all of the duplicates of a tuple end up in the same partition, and then
+// removes the duplicates within each partition separately using the in-memory
+// unordered distinct.
+//
+// TODO: a partition that doesn't fit into memory should be partitioned
+// further using a different seed.
+type externalDistinct struct {
+	OneInputNode
+	NonExplainable
+
+	state       externalDistinctState
+	partitioner tupleHashPartitioner
+
+	// partitionIdx is the index of the partition that is currently being
+	// emitted.
+	partitionIdx      int
+	partitionInput    *partitionerToOperator
+	partitionDistinct resettableOperator
+}
+
+var _ Operator = &externalDistinct{}
+var _ Closer = &externalDistinct{}
+
+// newExternalDistinct returns a disk-backed unordered distinct operator.
+// - unlimitedAllocator must have been created with a memory account derived
+// from an unlimited memory monitor. It is used by the in-memory distinct that
+// processes a single partition at a time.
+// - partitioner is used to store the partitions of the input. It is closed by
+// the external distinct once all tuples have been emitted or when it is
+// closed.
+// - diskQueuesUnlimitedAllocator is an unlimited allocator that is used for
+// the batches that the partitions are read into.
+func newExternalDistinct(
+	unlimitedAllocator *Allocator,
+	input Operator,
+	distinctCols []uint32,
+	inputTypes []coltypes.T,
+	partitioner Partitioner,
+	diskQueuesUnlimitedAllocator *Allocator,
+) Operator {
+	partitionInput := newPartitionerToOperator(
+		diskQueuesUnlimitedAllocator, inputTypes, partitioner, 0, /* partitionIdx */
+	).(*partitionerToOperator)
+	return &externalDistinct{
+		OneInputNode: NewOneInputNode(input),
+		partitioner: makeTupleHashPartitioner(
+			inputTypes, distinctCols, partitioner, externalDistinctNumPartitions,
+		),
+		partitionInput: partitionInput,
+		partitionDistinct: NewUnorderedDistinct(
+			unlimitedAllocator, partitionInput, distinctCols, inputTypes,
+		).(resettableOperator),
+	}
+}
+
+func (d *externalDistinct) Init() {
+	d.input.Init()
+	d.partitionDistinct.Init()
+}
+
+func (d *externalDistinct) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch d.state {
+		case externalDistinctPartitioning:
+			b := d.input.Next(ctx)
+			if b.Length() == 0 {
+				d.state = externalDistinctEmitting
+				d.partitionIdx = -1
+				d.nextPartition()
+				continue
+			}
+			d.partitioner.enqueue(ctx, b)
+		case externalDistinctEmitting:
+			b := d.partitionDistinct.Next(ctx)
+			if b.Length() == 0 {
+				d.nextPartition()
+				continue
+			}
+			return b
+		case externalDistinctFinished:
+			if err := d.Close(); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+			return coldata.ZeroBatch
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected externalDistinctState %d", d.state))
+		}
+	}
+}
+
+// Close is part of the Closer interface.
+func (d *externalDistinct) Close() error {
+	return d.partitioner.close()
+}
+
+// nextPartition advances to the next non-empty partition and resets the
+// in-memory distinct to process it. If there are no more partitions, the
+// external distinct transitions into the finished state.
+func (d *externalDistinct) nextPartition() {
+	d.partitionIdx = d.partitioner.nextNonEmptyPartition(d.partitionIdx)
+	if d.partitionIdx == -1 {
+		d.state = externalDistinctFinished
+		return
+	}
+	d.partitionDistinct.reset()
+	d.partitionInput.partitionIdx = d.partitionIdx
+}
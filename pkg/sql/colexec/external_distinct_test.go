@@ -0,0 +1,127 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalDistinct(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	defer diskMonitor.Stop(ctx)
+	flowCtx.Cfg.DiskMonitor = diskMonitor
+	queueCfg, cleanup := colcontainer.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	rng, _ := randutil.NewPseudoRand()
+	nTups := int(coldata.BatchSize()*4 + 1)
+	const nCols, maxVal = 2, 10
+	logTypes := make([]types.T, nCols)
+	for i := range logTypes {
+		logTypes[i] = *types.Int
+	}
+	tups := make(tuples, nTups)
+	seen := make(map[[nCols]int64]bool)
+	var expected tuples
+	for i := range tups {
+		var key [nCols]int64
+		tups[i] = make(tuple, nCols)
+		for j := range key {
+			key[j] = rng.Int63n(maxVal)
+			tups[i][j] = key[j]
+		}
+		if !seen[key] {
+			seen[key] = true
+			expected = append(expected, tups[i])
+		}
+	}
+
+	var (
+		memAccounts []*mon.BoundAccount
+		memMonitors []*mon.BytesMonitor
+	)
+	// Interesting memory limits:
+	// 0 - the default 64MiB value is used, so the in-memory distinct processes
+	//     the whole input.
+	// 1 - this will force the in-memory distinct to hit the memory limit right
+	//     after it buffers the first batch which will trigger the external
+	//     distinct.
+	for _, memoryLimit := range []int64{0, 1} {
+		flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = memoryLimit
+		// A nil temporary storage FS makes the external distinct keep the
+		// partitions in memory.
+		for _, tempFS := range []engine.FS{nil, queueCfg.FS} {
+			flowCtx.Cfg.TempFS = tempFS
+			t.Run(fmt.Sprintf("MemoryLimit=%d/OnDisk=%t", memoryLimit, tempFS != nil), func(t *testing.T) {
+				var spilled bool
+				runTests(
+					t,
+					[]tuples{tups},
+					expected,
+					unorderedVerifier,
+					func(input []Operator) (Operator, error) {
+						spec := &execinfrapb.ProcessorSpec{
+							Input: []execinfrapb.InputSyncSpec{{ColumnTypes: logTypes}},
+							Core: execinfrapb.ProcessorCoreUnion{
+								Distinct: &execinfrapb.DistinctSpec{
+									DistinctColumns: []uint32{0, 1},
+								},
+							},
+						}
+						args := NewColOperatorArgs{
+							Spec:                spec,
+							Inputs:              input,
+							StreamingMemAccount: testMemAcc,
+						}
+						args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+						result, err := NewColOperator(ctx, flowCtx, args)
+						memAccounts = append(memAccounts, result.BufferingOpMemAccounts...)
+						memMonitors = append(memMonitors, result.BufferingOpMemMonitors...)
+						return result.Op, err
+					})
+				require.Equal(t, memoryLimit == 1, spilled)
+			})
+		}
+	}
+	for _, account := range memAccounts {
+		account.Close(ctx)
+	}
+	for _, monitor := range memMonitors {
+		monitor.Stop(ctx)
+	}
+}
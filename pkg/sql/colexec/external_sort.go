@@ -16,6 +16,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/errors"
@@ -32,6 +33,10 @@ type Partitioner interface {
 	Close() error
 }
 
+// colcontainer.PartitionedDiskQueue is the general-purpose implementation of
+// the on-disk storage that is shared by all of the spilling operators.
+var _ Partitioner = &colcontainer.PartitionedDiskQueue{}
+
 // externalSorterState indicates the current state of the external sorter.
 type externalSorterState int
 
@@ -108,27 +113,30 @@ type externalSorter struct {
 	merger                Operator
 	singlePartitionOutput Operator
 
-	// TODO(yuzefovich): remove this once actual disk queues are in-place.
 	diskQueuesUnlimitedAllocator *Allocator
+	// closed is true if the partitioner has been closed.
+	closed bool
 }
 
 var _ Operator = &externalSorter{}
+var _ Closer = &externalSorter{}
 
 // newExternalSorter returns a disk-backed general sort operator.
 // - unlimitedAllocator must have been created with a memory account derived
 // from an unlimited memory monitor. It will be used by several internal
 // components of the external sort which is responsible for making sure that
 // the components stay within the memory limit.
-// - diskQueuesUnlimitedAllocator is a (temporary) unlimited allocator that
-// will be used to create dummy queues and will be removed once we have actual
-// disk-backed queues.
+// - partitioner is used to store the sorted partitions. It is closed by the
+// external sorter once all tuples have been emitted or when it is closed.
+// - diskQueuesUnlimitedAllocator is an unlimited allocator that is used for
+// the batches that the partitions are read into.
 func newExternalSorter(
 	unlimitedAllocator *Allocator,
 	input Operator,
 	inputTypes []coltypes.T,
 	ordering execinfrapb.Ordering,
 	memoryLimit int64,
-	// TODO(yuzefovich): remove this once actual disk queues are in-place.
+	partitioner Partitioner,
 	diskQueuesUnlimitedAllocator *Allocator,
 ) Operator {
 	inputPartitioner := newInputPartitioningOperator(unlimitedAllocator, input, memoryLimit)
@@ -144,7 +152,7 @@ func newExternalSorter(
 		diskQueuesUnlimitedAllocator: diskQueuesUnlimitedAllocator,
 		unlimitedAllocator:           unlimitedAllocator,
 		inMemSorter:                  inMemSorter,
-		partitioner:                  newDummyPartitioner(diskQueuesUnlimitedAllocator, inputTypes),
+		partitioner:                  partitioner,
 		inputTypes:                   inputTypes,
 		ordering:                     ordering,
 	}
@@ -229,7 +237,7 @@ func (s *externalSorter) Next(ctx context.Context) coldata.Batch {
 				return b
 			}
 		case externalSorterFinished:
-			if err := s.partitioner.Close(); err != nil {
+			if err := s.Close(); err != nil {
 				execerror.VectorizedInternalPanic(err)
 			}
 			return coldata.ZeroBatch
@@ -239,6 +247,15 @@ func (s *externalSorter) Next(ctx context.Context) coldata.Batch {
 	}
 }
 
+// Close is part of the Closer interface.
+func (s *externalSorter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.partitioner.Close()
+}
+
 func newInputPartitioningOperator(
 	unlimitedAllocator *Allocator, input Operator, memoryLimit int64,
 ) resettableOperator {
@@ -311,6 +328,8 @@ func (p *partitionerToOperator) Next(context.Context) coldata.Batch {
 	return p.batch
 }
 
+// newDummyPartitioner returns a Partitioner that keeps all of the partitions
+// in memory. It is used when there is no temporary storage FS.
 func newDummyPartitioner(allocator *Allocator, types []coltypes.T) Partitioner {
 	return &dummyPartitioner{allocator: allocator, types: types}
 }
@@ -370,21 +389,7 @@ func (d *dummyQueue) Enqueue(batch coldata.Batch) error {
 	if batch.Length() == 0 {
 		return nil
 	}
-	batchCopy := d.allocator.NewMemBatchWithSize(d.types, int(batch.Length()))
-	d.allocator.PerformOperation(batchCopy.ColVecs(), func() {
-		for i, vec := range batchCopy.ColVecs() {
-			vec.Append(
-				coldata.SliceArgs{
-					ColType:     d.types[i],
-					Src:         batch.ColVec(i),
-					Sel:         batch.Selection(),
-					DestIdx:     0,
-					SrcStartIdx: 0,
-					SrcEndIdx:   uint64(batch.Length()),
-				})
-		}
-	})
-	batchCopy.SetLength(batch.Length())
+	batchCopy := d.allocator.CloneBatch(batch)
 	d.queue = append(d.queue, batchCopy)
 	return nil
 }
@@ -17,11 +17,13 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
@@ -41,6 +43,12 @@ func TestExternalSort(t *testing.T) {
 		},
 	}
 
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	defer diskMonitor.Stop(ctx)
+	flowCtx.Cfg.DiskMonitor = diskMonitor
+	queueCfg, cleanup := colcontainer.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
 	var (
 		memAccounts []*mon.BoundAccount
 		memMonitors []*mon.BytesMonitor
@@ -53,23 +61,28 @@ func TestExternalSort(t *testing.T) {
 	//     and then will hit OOM) which will trigger the external sort.
 	for _, memoryLimit := range []int64{0, 1} {
 		flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = memoryLimit
-		t.Run(fmt.Sprintf("MemoryLimit=%d", memoryLimit), func(t *testing.T) {
-			for _, tc := range sortTestCases {
-				runTests(
-					t,
-					[]tuples{tc.tuples},
-					tc.expected,
-					orderedVerifier,
-					func(input []Operator) (Operator, error) {
-						sorter, accounts, monitors, err := createDiskBackedSorter(
-							ctx, flowCtx, input, tc.logTypes, tc.ordCols, func() {},
-						)
-						memAccounts = append(memAccounts, accounts...)
-						memMonitors = append(memMonitors, monitors...)
-						return sorter, err
-					})
-			}
-		})
+		// A nil temporary storage FS makes the external sorter keep the
+		// partitions in memory.
+		for _, tempFS := range []engine.FS{nil, queueCfg.FS} {
+			flowCtx.Cfg.TempFS = tempFS
+			t.Run(fmt.Sprintf("MemoryLimit=%d/OnDisk=%t", memoryLimit, tempFS != nil), func(t *testing.T) {
+				for _, tc := range sortTestCases {
+					runTests(
+						t,
+						[]tuples{tc.tuples},
+						tc.expected,
+						orderedVerifier,
+						func(input []Operator) (Operator, error) {
+							sorter, accounts, monitors, err := createDiskBackedSorter(
+								ctx, flowCtx, input, tc.logTypes, tc.ordCols, func() {},
+							)
+							memAccounts = append(memAccounts, accounts...)
+							memMonitors = append(memMonitors, monitors...)
+							return sorter, err
+						})
+				}
+			})
+		}
 	}
 	for _, account := range memAccounts {
 		account.Close(ctx)
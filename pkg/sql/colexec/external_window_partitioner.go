@@ -0,0 +1,161 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+)
+
+// externalWindowPartitionerNumBuckets is the number of buckets that the input
+// to the external window partitioner is divided into.
+const externalWindowPartitionerNumBuckets = 16
+
+// externalWindowPartitionerState indicates the current state of the external
+// window partitioner.
+type externalWindowPartitionerState int
+
+const (
+	// externalWindowPartitionerBucketing indicates that the input is being
+	// divided into the buckets.
+	externalWindowPartitionerBucketing externalWindowPartitionerState = iota
+	// externalWindowPartitionerEmitting indicates that the input has been fully
+	// consumed, and the sorted tuples of the buckets are being emitted one
+	// bucket at a time.
+	externalWindowPartitionerEmitting
+	// externalWindowPartitionerFinished indicates that all buckets have been
+	// processed. This state is also responsible for closing the buckets.
+	externalWindowPartitionerFinished
+)
+
+// externalWindowPartitioner is a disk-backed operator that reorders its input
+// so that all tuples of every window partition are adjacent and are ordered
+// on the ORDER BY columns of the window functions. It divides its input into
+// several buckets (the partitions of a Partitioner) by hashing the PARTITION
+// BY columns, so that all of the tuples of a window partition end up in the
+// same bucket, and then sorts the buckets one at a time using the in-memory
+// sorter. Unlike the external sorter, it never has to merge the sorted runs,
+// and only a single bucket at a time is held in memory.
+//
+// TODO: a bucket that doesn't fit into memory should be divided further
+// using a different seed.
+type externalWindowPartitioner struct {
+	OneInputNode
+	NonExplainable
+
+	state       externalWindowPartitionerState
+	partitioner tupleHashPartitioner
+
+	// bucketIdx is the index of the bucket that is currently being emitted.
+	bucketIdx    int
+	bucketInput  *partitionerToOperator
+	bucketSorter resettableOperator
+}
+
+var _ Operator = &externalWindowPartitioner{}
+var _ Closer = &externalWindowPartitioner{}
+
+// newExternalWindowPartitioner returns a disk-backed operator that orders its
+// input first on the partitionIdxs columns (the order of different window
+// partitions is arbitrary) and then on ordCols.
+// - unlimitedAllocator must have been created with a memory account derived
+// from an unlimited memory monitor. It is used by the in-memory sorter that
+// processes a single bucket at a time.
+// - partitioner is used to store the buckets. It is closed by the external
+// window partitioner once all tuples have been emitted or when it is closed.
+// - diskQueuesUnlimitedAllocator is an unlimited allocator that is used for
+// the batches that the buckets are read into.
+func newExternalWindowPartitioner(
+	unlimitedAllocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	partitionIdxs []uint32,
+	ordCols []execinfrapb.Ordering_Column,
+	partitioner Partitioner,
+	diskQueuesUnlimitedAllocator *Allocator,
+) (Operator, error) {
+	bucketInput := newPartitionerToOperator(
+		diskQueuesUnlimitedAllocator, inputTypes, partitioner, 0, /* partitionIdx */
+	).(*partitionerToOperator)
+	bucketSorter, err := newSorter(
+		unlimitedAllocator, newAllSpooler(unlimitedAllocator, bucketInput, inputTypes),
+		inputTypes, windowPartitionerOrdering(partitionIdxs, ordCols),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &externalWindowPartitioner{
+		OneInputNode: NewOneInputNode(input),
+		partitioner: makeTupleHashPartitioner(
+			inputTypes, partitionIdxs, partitioner, externalWindowPartitionerNumBuckets,
+		),
+		bucketInput:  bucketInput,
+		bucketSorter: bucketSorter,
+	}, nil
+}
+
+func (p *externalWindowPartitioner) Init() {
+	p.input.Init()
+	p.bucketSorter.Init()
+}
+
+func (p *externalWindowPartitioner) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch p.state {
+		case externalWindowPartitionerBucketing:
+			b := p.input.Next(ctx)
+			if b.Length() == 0 {
+				p.state = externalWindowPartitionerEmitting
+				p.bucketIdx = -1
+				p.nextBucket()
+				continue
+			}
+			p.partitioner.enqueue(ctx, b)
+		case externalWindowPartitionerEmitting:
+			b := p.bucketSorter.Next(ctx)
+			if b.Length() == 0 {
+				p.nextBucket()
+				continue
+			}
+			return b
+		case externalWindowPartitionerFinished:
+			if err := p.Close(); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+			return coldata.ZeroBatch
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected externalWindowPartitionerState %d", p.state))
+		}
+	}
+}
+
+// Close is part of the Closer interface.
+func (p *externalWindowPartitioner) Close() error {
+	return p.partitioner.close()
+}
+
+// nextBucket advances to the next non-empty bucket and resets the in-memory
+// sorter to process it. If there are no more buckets, the external window
+// partitioner transitions into the finished state.
+func (p *externalWindowPartitioner) nextBucket() {
+	p.bucketIdx = p.partitioner.nextNonEmptyPartition(p.bucketIdx)
+	if p.bucketIdx == -1 {
+		p.state = externalWindowPartitionerFinished
+		return
+	}
+	p.bucketSorter.reset()
+	p.bucketInput.partitionIdx = p.bucketIdx
+}
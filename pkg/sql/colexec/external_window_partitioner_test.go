@@ -0,0 +1,179 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowPartitioners(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	defer diskMonitor.Stop(ctx)
+	flowCtx.Cfg.DiskMonitor = diskMonitor
+	queueCfg, cleanup := colcontainer.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	// The input tuples are (a, b), and we compute
+	// RANK() OVER (PARTITION BY a ORDER BY b).
+	rng, _ := randutil.NewPseudoRand()
+	nTups := int(coldata.BatchSize()*4 + 1)
+	const maxPartitionVal, maxOrderVal = 20, 10
+	logTypes := []types.T{*types.Int, *types.Int}
+	tups := make(tuples, nTups)
+	for i := range tups {
+		tups[i] = tuple{rng.Int63n(maxPartitionVal), rng.Int63n(maxOrderVal)}
+	}
+	expected := make(tuples, nTups)
+	for i := range tups {
+		rank := int64(1)
+		for j := range tups {
+			if tups[j][0] == tups[i][0] && tups[j][1].(int64) < tups[i][1].(int64) {
+				rank++
+			}
+		}
+		expected[i] = tuple{tups[i][0], tups[i][1], rank}
+	}
+	rankFn := execinfrapb.WindowerSpec_RANK
+	windowerSpec := execinfrapb.WindowerSpec{
+		PartitionBy: []uint32{0},
+		WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+			{
+				Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &rankFn},
+				Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+				OutputColIdx: 2,
+			},
+		},
+	}
+
+	var (
+		memAccounts []*mon.BoundAccount
+		memMonitors []*mon.BytesMonitor
+	)
+	run := func(t *testing.T, tups tuples, inputOrdering execinfrapb.Ordering) bool {
+		var spilled bool
+		runTests(
+			t,
+			[]tuples{tups},
+			expected,
+			unorderedVerifier,
+			func(input []Operator) (Operator, error) {
+				spec := &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{
+						Ordering:    inputOrdering,
+						ColumnTypes: logTypes,
+					}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Windower: &windowerSpec,
+					},
+				}
+				args := NewColOperatorArgs{
+					Spec:                spec,
+					Inputs:              input,
+					StreamingMemAccount: testMemAcc,
+				}
+				args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+				result, err := NewColOperator(ctx, flowCtx, args)
+				memAccounts = append(memAccounts, result.BufferingOpMemAccounts...)
+				memMonitors = append(memMonitors, result.BufferingOpMemMonitors...)
+				return result.Op, err
+			})
+		return spilled
+	}
+
+	// Interesting memory limits:
+	// 0 - the default 64MiB value is used, so the in-memory sorter processes
+	//     the whole input.
+	// 1 - this will force the in-memory sorter to hit the memory limit right
+	//     after it buffers the first batch which will trigger the external
+	//     window partitioner.
+	for _, memoryLimit := range []int64{0, 1} {
+		flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = memoryLimit
+		// A nil temporary storage FS makes the external window partitioner
+		// keep the buckets in memory.
+		for _, tempFS := range []engine.FS{nil, queueCfg.FS} {
+			flowCtx.Cfg.TempFS = tempFS
+			t.Run(fmt.Sprintf("MemoryLimit=%d/OnDisk=%t", memoryLimit, tempFS != nil), func(t *testing.T) {
+				spilled := run(t, tups, execinfrapb.Ordering{})
+				require.Equal(t, memoryLimit == 1, spilled)
+			})
+		}
+	}
+
+	t.Run("Streaming", func(t *testing.T) {
+		// The input is ordered on the PARTITION BY column, so the streaming
+		// partitioner that buffers only a single partition at a time is used.
+		flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = 0
+		flowCtx.Cfg.TempFS = nil
+		sortedTups := append(tuples(nil), tups...)
+		sort.SliceStable(sortedTups, func(i, j int) bool {
+			return sortedTups[i][0].(int64) > sortedTups[j][0].(int64)
+		})
+		spilled := run(t, sortedTups, execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{
+			{ColIdx: 0, Direction: execinfrapb.Ordering_Column_DESC},
+		}})
+		require.False(t, spilled)
+	})
+
+	for _, account := range memAccounts {
+		account.Close(ctx)
+	}
+	for _, monitor := range memMonitors {
+		monitor.Stop(ctx)
+	}
+}
+
+func TestOrderingCoversCols(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ordering := execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{
+		{ColIdx: 2}, {ColIdx: 0, Direction: execinfrapb.Ordering_Column_DESC}, {ColIdx: 1},
+	}}
+	for _, tc := range []struct {
+		cols     []uint32
+		expected bool
+	}{
+		{cols: []uint32{2}, expected: true},
+		{cols: []uint32{0, 2}, expected: true},
+		{cols: []uint32{1, 2, 0}, expected: true},
+		{cols: []uint32{0}, expected: false},
+		{cols: []uint32{1, 2}, expected: false},
+		{cols: []uint32{0, 1, 2, 3}, expected: false},
+	} {
+		require.Equal(t, tc.expected, orderingCoversCols(ordering, tc.cols), "cols %v", tc.cols)
+	}
+}
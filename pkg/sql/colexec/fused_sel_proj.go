@@ -0,0 +1,138 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+)
+
+// fusedSelProjOp evaluates a chain of selection and projection operators
+// (planned for the filter and the render expressions of a processor) as a
+// single operator. The chain is planned on top of a selProjFeedOp instead of
+// the actual input, so every batch read from the input is pushed through the
+// whole chain at once. This way the batches that are fully filtered out are
+// discarded in a single place instead of every selection operator looping
+// over its own input, and the operators of the chain never read from the
+// input directly.
+// Note that the chain must contain only operators that produce at most one
+// output batch per input batch and that don't keep any state across batches.
+type fusedSelProjOp struct {
+	OneInputNode
+	NonExplainable
+
+	feed  *selProjFeedOp
+	chain Operator
+}
+
+var _ Operator = &fusedSelProjOp{}
+var _ Closer = &fusedSelProjOp{}
+
+// newSelProjFeedOp returns a new selProjFeedOp that should be used as the
+// input to the chain of operators that will be fused on top of input.
+func newSelProjFeedOp(input Operator) *selProjFeedOp {
+	return &selProjFeedOp{OneInputNode: NewOneInputNode(input)}
+}
+
+// newFusedSelProjOp returns a new fusedSelProjOp that evaluates chain, which
+// has been planned on top of feed, on the batches from input. If chain is
+// empty (i.e. it is feed itself), then input is returned.
+func newFusedSelProjOp(input Operator, feed *selProjFeedOp, chain Operator) Operator {
+	if chain == Operator(feed) {
+		return input
+	}
+	return &fusedSelProjOp{
+		OneInputNode: NewOneInputNode(input),
+		feed:         feed,
+		chain:        chain,
+	}
+}
+
+// ChildCount is part of the execinfra.OpNode interface. The chain is exposed
+// as the only child of the operator so that the fused operators are still
+// shown in EXPLAIN (VEC) (the feed exposes the input as its child).
+func (o *fusedSelProjOp) ChildCount(verbose bool) int {
+	return 1
+}
+
+// Child is part of the execinfra.OpNode interface.
+func (o *fusedSelProjOp) Child(nth int, verbose bool) execinfra.OpNode {
+	if nth == 0 {
+		return o.chain
+	}
+	execerror.VectorizedInternalPanic(fmt.Sprintf("invalid index %d", nth))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func (o *fusedSelProjOp) Init() {
+	o.input.Init()
+	o.chain.Init()
+}
+
+func (o *fusedSelProjOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := o.input.Next(ctx)
+		if batch.Length() == 0 {
+			return coldata.ZeroBatch
+		}
+		o.feed.batch = batch
+		o.feed.nexted = false
+		batch = o.chain.Next(ctx)
+		if batch.Length() > 0 {
+			return batch
+		}
+		if !o.feed.nexted {
+			// The chain returned a zero-length batch without reading the input
+			// batch (e.g. the filter is always false), so it will not produce any
+			// output.
+			return coldata.ZeroBatch
+		}
+		// All of the tuples of the input batch have been filtered out, so we
+		// move onto the next one.
+	}
+}
+
+// Close is part of the Closer interface.
+func (o *fusedSelProjOp) Close() error {
+	return closeInput(o.input)
+}
+
+// selProjFeedOp is the input to the chain of operators fused by a
+// fusedSelProjOp. It returns the batch pushed by the fusedSelProjOp only once,
+// and a zero-length batch on all of the subsequent calls, so that the
+// selection operators of the chain that filter out all of the tuples of the
+// batch stop asking for more input.
+type selProjFeedOp struct {
+	// OneInputNode is embedded only so that the input of the fusedSelProjOp is
+	// exposed as the child of the chain. The feed never reads from it.
+	OneInputNode
+	NonExplainable
+
+	batch  coldata.Batch
+	nexted bool
+}
+
+var _ Operator = &selProjFeedOp{}
+
+func (o *selProjFeedOp) Init() {}
+
+func (o *selProjFeedOp) Next(context.Context) coldata.Batch {
+	if o.nexted {
+		return coldata.ZeroBatch
+	}
+	o.nexted = true
+	return o.batch
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/errors"
+)
+
+func TestFusedSelProjOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	input := tuples{{1, 2}, {20, 1}, {3, 0}, {4, 5}, {30, 30}, {5, 1}}
+	testCases := []struct {
+		desc     string
+		post     execinfrapb.PostProcessSpec
+		expected tuples
+	}{
+		{
+			desc: "filter only",
+			post: execinfrapb.PostProcessSpec{
+				Filter: execinfrapb.Expression{Expr: "@1 < 10 AND @2 > 0"},
+			},
+			expected: tuples{{1, 2}, {4, 5}, {5, 1}},
+		},
+		{
+			desc: "filter and render",
+			post: execinfrapb.PostProcessSpec{
+				Filter:      execinfrapb.Expression{Expr: "@1 < 10 AND @2 > 0"},
+				RenderExprs: []execinfrapb.Expression{{Expr: "@1 + @2"}, {Expr: "@1"}},
+			},
+			expected: tuples{{3, 1}, {9, 4}, {6, 5}},
+		},
+		{
+			desc: "always false filter",
+			post: execinfrapb.PostProcessSpec{
+				Filter: execinfrapb.Expression{Expr: "NULL"},
+			},
+			expected: tuples{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			runTests(t, []tuples{input}, tc.expected, orderedVerifier, func(input []Operator) (Operator, error) {
+				spec := &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int, *types.Int}}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Noop: &execinfrapb.NoopCoreSpec{},
+					},
+					Post: tc.post,
+				}
+				args := NewColOperatorArgs{
+					Spec:                spec,
+					Inputs:              input,
+					StreamingMemAccount: testMemAcc,
+				}
+				args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+				result, err := NewColOperator(ctx, flowCtx, args)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := result.Op.(*fusedSelProjOp); !ok {
+					return nil, errors.Errorf("expected a fusedSelProjOp to be planned, got %T", result.Op)
+				}
+				return result.Op, nil
+			})
+		})
+	}
+}
@@ -149,7 +149,7 @@ type hashGrouper struct {
 	// sel is an ordered list of indices to select representing the input rows.
 	// This selection vector is much bigger than coldata.BatchSize() and should be
 	// batched with the hashGrouper operator.
-	sel []uint64
+	sel []int
 	// distinct represents whether each corresponding row is part of a new group.
 	distinct []bool
 
@@ -187,10 +187,10 @@ func (op *hashGrouper) Next(ctx context.Context) coldata.Batch {
 		// ordering of the bucket-grouped rows of input. The same linked list is
 		// traversed from each head to form this ordered list.
 
-		// Since next is no longer useful and pre-allocated to the appropriate size,
-		// we can use it as the selection vector. This way we don't have to
-		// reallocate a huge array.
-		op.sel = op.ht.next
+		// Since next is no longer useful, we release it before allocating the
+		// selection vector of the same size.
+		op.ht.next = nil
+		op.sel = make([]int, op.ht.vals.length)
 		// Since visited is no longer used and pre-allocated to the appropriate
 		// size, we can use it for the distinct vector.
 		op.distinct = op.ht.visited
@@ -204,12 +204,12 @@ func (op *hashGrouper) Next(ctx context.Context) coldata.Batch {
 				// are the same on the grouping columns, so we will include the "head"
 				// as the first tuple of the group and then will include all other
 				// tuples that are the "same."
-				op.sel[selIdx] = uint64(i)
+				op.sel[selIdx] = i
 				op.distinct[selIdx] = true
 				selIdx++
 				// curID value of 0 indicates the end of the linked list.
 				for curID := op.ht.same[i+1]; curID != 0; curID = op.ht.same[curID] {
-					op.sel[selIdx] = curID - 1
+					op.sel[selIdx] = int(curID - 1)
 					op.distinct[selIdx] = false
 					selIdx++
 				}
@@ -241,8 +241,8 @@ func (op *hashGrouper) Next(ctx context.Context) coldata.Batch {
 						Src:         fromCol,
 						SrcStartIdx: op.batchStart,
 						SrcEndIdx:   batchEnd,
+						Sel:         op.sel,
 					},
-					Sel64: op.sel,
 				},
 			)
 		}
@@ -257,9 +257,120 @@ func (op *hashGrouper) Next(ctx context.Context) coldata.Batch {
 // Reset resets the hashGrouper for another run. Primarily used for
 // benchmarks.
 func (op *hashGrouper) reset() {
+	if r, ok := op.input.(resetter); ok {
+		r.reset()
+	}
 	op.batchStart = 0
-	op.ht.vals.reset()
+	op.ht.reset()
+	op.sel = nil
+	op.distinct = nil
 	op.buildFinished = false
 }
 
 var _ Operator = &hashGrouper{}
+
+// partialHashAggregatorMaxBufferedTuples is the number of tuples after which
+// the partial hash aggregator emits its results and empties its hash table.
+const partialHashAggregatorMaxBufferedTuples = 64 << 10
+
+// NewPartialHashAggregator creates a hash aggregator that is meant to be used
+// as the local stage of a multi-stage aggregation (below an exchange). Unlike
+// the operator returned by NewHashAggregator, it doesn't buffer up its whole
+// input: once maxBufferedTuples tuples (or slightly more since whole batches
+// are consumed) have been put into the hash table, the partial results of the
+// groups seen so far are emitted, and the hash table is emptied before the
+// rest of the input is read. This means that the same group can appear in the
+// output several times, so the results must be merged by the final stage of
+// the aggregation. The rest of the arguments are the same as in
+// NewHashAggregator.
+func NewPartialHashAggregator(
+	allocator *Allocator,
+	input Operator,
+	colTypes []coltypes.T,
+	aggFns []execinfrapb.AggregatorSpec_Func,
+	groupCols []uint32,
+	aggCols [][]uint32,
+	maxBufferedTuples int,
+) (Operator, error) {
+	if len(groupCols) == 0 {
+		// A scalar aggregation must produce exactly one tuple, and it needs only
+		// a constant amount of memory anyway.
+		return nil, errors.AssertionFailedf("partial hash aggregation requires grouping columns")
+	}
+	limiter := &inputTupleLimitingOperator{
+		OneInputNode: NewOneInputNode(input),
+		maxTuples:    maxBufferedTuples,
+	}
+	agg, err := NewHashAggregator(
+		allocator, limiter, colTypes, aggFns, groupCols, aggCols, false, /* isScalar */
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &partialHashAggregator{
+		OneInputNode: NewOneInputNode(agg),
+		limiter:      limiter,
+	}, nil
+}
+
+// partialHashAggregator is a wrapper around the hash aggregator that resets
+// the latter every time it has emitted the results of the tuples let through
+// by limiter until the whole input has been consumed.
+type partialHashAggregator struct {
+	OneInputNode
+
+	limiter *inputTupleLimitingOperator
+}
+
+var _ Operator = &partialHashAggregator{}
+
+func (op *partialHashAggregator) Init() {
+	op.input.Init()
+}
+
+func (op *partialHashAggregator) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := op.input.Next(ctx)
+		if batch.Length() > 0 || op.limiter.inputDone {
+			return batch
+		}
+		// The hash table is full, so we reset the aggregator (which also resets
+		// the limiter) and continue consuming the input.
+		op.input.(resetter).reset()
+	}
+}
+
+// inputTupleLimitingOperator is an operator that returns the batches from its
+// input until at least maxTuples tuples have been returned. From that point,
+// the operator returns a zero-length batch (until it is reset).
+type inputTupleLimitingOperator struct {
+	OneInputNode
+	NonExplainable
+
+	maxTuples int
+	numTuples int
+	// inputDone indicates whether the input has been fully consumed.
+	inputDone bool
+}
+
+var _ resettableOperator = &inputTupleLimitingOperator{}
+
+func (o *inputTupleLimitingOperator) Init() {
+	o.input.Init()
+}
+
+func (o *inputTupleLimitingOperator) Next(ctx context.Context) coldata.Batch {
+	if o.inputDone || o.numTuples >= o.maxTuples {
+		return coldata.ZeroBatch
+	}
+	batch := o.input.Next(ctx)
+	if batch.Length() == 0 {
+		o.inputDone = true
+	}
+	o.numTuples += int(batch.Length())
+	return batch
+}
+
+func (o *inputTupleLimitingOperator) reset() {
+	o.numTuples = 0
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+)
+
+// hashPartitioningSeed is the seed of the hash function that is used by the
+// disk-backed operators to assign the tuples to the partitions. It is
+// different from the seed used by the hash routers so that all of the
+// partitions are used even if the input has already been hash-routed on the
+// same columns.
+const hashPartitioningSeed = defaultHashTableSeed + 1
+
+// tupleHashPartitioner divides the tuples into a fixed number of partitions
+// of a Partitioner according to the hash values of the hashCols columns, so
+// that all of the tuples that are equal on those columns end up in the same
+// partition.
+type tupleHashPartitioner struct {
+	inputTypes  []coltypes.T
+	hashCols    []uint32
+	partitioner Partitioner
+	// partitionNonEmpty indicates whether at least one tuple has been enqueued
+	// into the corresponding partition.
+	partitionNonEmpty []bool
+
+	// ht is not fully initialized to a hashTable, only the utility methods are
+	// used.
+	ht hashTable
+
+	scratch struct {
+		// buckets is scratch space for the hash values of the hashCols columns
+		// of the tuples in the current batch.
+		buckets []uint64
+		// selections is scratch space for the selection vectors of the tuples
+		// that belong to each partition.
+		selections [][]int
+		// batch shares the column vectors with the input batch and has its own
+		// selection vector which is used to enqueue the tuples of a single
+		// partition.
+		batch coldata.Batch
+	}
+}
+
+func makeTupleHashPartitioner(
+	inputTypes []coltypes.T, hashCols []uint32, partitioner Partitioner, numPartitions int,
+) tupleHashPartitioner {
+	p := tupleHashPartitioner{
+		inputTypes:        inputTypes,
+		hashCols:          hashCols,
+		partitioner:       partitioner,
+		partitionNonEmpty: make([]bool, numPartitions),
+	}
+	p.ht.seed = hashPartitioningSeed
+	p.scratch.buckets = make([]uint64, coldata.BatchSize())
+	p.scratch.batch = coldata.NewMemBatchWithSize(nil /* types */, int(coldata.BatchSize()))
+	p.scratch.selections = make([][]int, numPartitions)
+	for i := range p.scratch.selections {
+		p.scratch.selections[i] = make([]int, 0, coldata.BatchSize())
+	}
+	return p
+}
+
+// enqueue enqueues the tuples of b into the partitions according to the hash
+// values of their hashCols columns.
+func (p *tupleHashPartitioner) enqueue(ctx context.Context, b coldata.Batch) {
+	n := b.Length()
+	numPartitions := uint64(len(p.partitionNonEmpty))
+	buckets := p.scratch.buckets[:n]
+	p.ht.initHash(buckets, uint64(n))
+	for _, i := range p.hashCols {
+		p.ht.rehash(ctx, buckets, int(i), p.inputTypes[i], b.ColVec(int(i)), uint64(n), b.Selection())
+	}
+	for i := range p.scratch.selections {
+		p.scratch.selections[i] = p.scratch.selections[i][:0]
+	}
+	if sel := b.Selection(); sel != nil {
+		for i, hash := range buckets {
+			partitionIdx := hash % numPartitions
+			p.scratch.selections[partitionIdx] = append(p.scratch.selections[partitionIdx], sel[i])
+		}
+	} else {
+		for i, hash := range buckets {
+			partitionIdx := hash % numPartitions
+			p.scratch.selections[partitionIdx] = append(p.scratch.selections[partitionIdx], i)
+		}
+	}
+	batch := p.scratch.batch
+	for i := range p.inputTypes {
+		if i < batch.Width() {
+			batch.ReplaceCol(b.ColVec(i), i)
+		} else {
+			batch.AppendCol(b.ColVec(i))
+		}
+	}
+	batch.SetSelection(true)
+	for partitionIdx, sel := range p.scratch.selections {
+		if len(sel) == 0 {
+			continue
+		}
+		copy(batch.Selection(), sel)
+		batch.SetLength(uint16(len(sel)))
+		if err := p.partitioner.Enqueue(partitionIdx, batch); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		p.partitionNonEmpty[partitionIdx] = true
+	}
+}
+
+// nextNonEmptyPartition returns the index of the first non-empty partition
+// after the partitionIdx'th one or -1 if there is no such partition.
+func (p *tupleHashPartitioner) nextNonEmptyPartition(partitionIdx int) int {
+	for partitionIdx++; partitionIdx < len(p.partitionNonEmpty); partitionIdx++ {
+		if p.partitionNonEmpty[partitionIdx] {
+			return partitionIdx
+		}
+	}
+	return -1
+}
+
+// close closes the partitioner. It is safe to call it multiple times.
+func (p *tupleHashPartitioner) close() error {
+	if p.partitioner == nil {
+		return nil
+	}
+	err := p.partitioner.Close()
+	p.partitioner = nil
+	return err
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/pkg/errors"
 )
 
@@ -37,6 +38,10 @@ const (
 	// emitting unmatched rows from its build table after having consumed the
 	// probe table. This happens in the case of an outer join on the build side.
 	hjEmittingUnmatched
+
+	// hjDone represents the state the hashJoiner is in when it has emitted all
+	// of the output. The memory of the hash table has been released by then.
+	hjDone
 )
 
 // hashJoinerSpec is the specification for a hash joiner operator. The hash
@@ -188,6 +193,7 @@ type hashJoinEqOp struct {
 }
 
 var _ Operator = &hashJoinEqOp{}
+var _ columnPruner = &hashJoinEqOp{}
 
 func (hj *hashJoinEqOp) Init() {
 	hj.spec.left.source.Init()
@@ -212,6 +218,29 @@ func (hj *hashJoinEqOp) Init() {
 	hj.runningState = hjBuilding
 }
 
+// pruneOutputColumns is part of the columnPruner interface. The pruned
+// columns of the right source are also not stored in the hash table.
+func (hj *hashJoinEqOp) pruneOutputColumns(needed util.FastIntSet) []int {
+	numLeftOutCols := len(hj.spec.left.outCols)
+	newIdxs := make([]int, numLeftOutCols+len(hj.spec.right.outCols))
+	numNeeded := 0
+	prune := func(outCols []uint32, offset int) []uint32 {
+		prunedOutCols := make([]uint32, 0, len(outCols))
+		for i, col := range outCols {
+			newIdxs[offset+i] = -1
+			if needed.Contains(offset + i) {
+				newIdxs[offset+i] = numNeeded
+				numNeeded++
+				prunedOutCols = append(prunedOutCols, col)
+			}
+		}
+		return prunedOutCols
+	}
+	hj.spec.left.outCols = prune(hj.spec.left.outCols, 0 /* offset */)
+	hj.spec.right.outCols = prune(hj.spec.right.outCols, numLeftOutCols)
+	return newIdxs
+}
+
 func (hj *hashJoinEqOp) Next(ctx context.Context) coldata.Batch {
 	hj.prober.batch.ResetInternalBatch()
 	for {
@@ -222,13 +251,22 @@ func (hj *hashJoinEqOp) Next(ctx context.Context) coldata.Batch {
 		case hjProbing:
 			hj.prober.exec(ctx)
 
-			if hj.prober.batch.Length() == 0 && hj.spec.right.outer {
-				hj.runningState = hjEmittingUnmatched
-				continue
+			if hj.prober.batch.Length() == 0 {
+				if hj.spec.right.outer {
+					hj.runningState = hjEmittingUnmatched
+					continue
+				}
+				hj.finish()
 			}
 			return hj.prober.batch
 		case hjEmittingUnmatched:
 			hj.emitUnmatched()
+			if hj.prober.batch.Length() == 0 {
+				hj.finish()
+			}
+			return hj.prober.batch
+		case hjDone:
+			hj.prober.batch.SetLength(0)
 			return hj.prober.batch
 		default:
 			execerror.VectorizedInternalPanic("hash joiner in unhandled state")
@@ -253,6 +291,14 @@ func (hj *hashJoinEqOp) build(ctx context.Context) {
 	hj.runningState = hjProbing
 }
 
+// finish releases the memory used by the hash table, which is no longer needed
+// once all of the output has been emitted, and transitions to hjDone state.
+func (hj *hashJoinEqOp) finish() {
+	hj.ht.release()
+	hj.prober.buildRowMatched = nil
+	hj.runningState = hjDone
+}
+
 func (hj *hashJoinEqOp) emitUnmatched() {
 	// Set all elements in the probe columns of the output batch to null.
 	for i := range hj.prober.spec.left.outCols {
@@ -264,7 +310,7 @@ func (hj *hashJoinEqOp) emitUnmatched() {
 
 	for nResults < hj.outputBatchSize && hj.emittingUnmatchedState.rowIdx < hj.ht.vals.length {
 		if !hj.prober.buildRowMatched[hj.emittingUnmatchedState.rowIdx] {
-			hj.prober.buildIdx[nResults] = hj.emittingUnmatchedState.rowIdx
+			hj.prober.buildIdx[nResults] = int(hj.emittingUnmatchedState.rowIdx)
 			nResults++
 		}
 		hj.emittingUnmatchedState.rowIdx++
@@ -282,9 +328,9 @@ func (hj *hashJoinEqOp) emitUnmatched() {
 					SliceArgs: coldata.SliceArgs{
 						ColType:   colType,
 						Src:       valCol,
+						Sel:       hj.prober.buildIdx,
 						SrcEndIdx: uint64(nResults),
 					},
-					Sel64: hj.prober.buildIdx,
 				},
 			)
 		}
@@ -307,12 +353,10 @@ type hashJoinProber struct {
 	outputBatchSize uint16
 
 	// buildIdx and probeIdx represents the matching row indices that are used to
-	// stitch together the join results. Since probing is done on a per-batch
-	// basis, the indices will always fit within uint16. However, the matching
-	// build table row index should be an uint64 since it refers to the entirety
-	// of the build table.
-	buildIdx []uint64
-	probeIdx []uint16
+	// stitch together the join results. Note that the build table row indices
+	// refer to the entirety of the build table.
+	buildIdx []int
+	probeIdx []int
 
 	// probeRowUnmatched is used in the case that the prober.spec.outer is true.
 	// This means that an outer join is performed on the probe side and we use
@@ -320,6 +364,10 @@ type hashJoinProber struct {
 	// the build table. This indicates that the probe table row did not match any
 	// build table rows.
 	probeRowUnmatched []bool
+	// unmatchedNulls is a scratch bitmap that has the rows marked in
+	// probeRowUnmatched set to null. It is used to add the nulls on the build
+	// side columns a whole byte at a time.
+	unmatchedNulls coldata.Nulls
 	// buildRowMatched is used in the case that prober.buildOuter is true. This
 	// means that an outer join is performed on the build side and buildRowMatched
 	// marks all the build table rows that have been matched already. The rows
@@ -350,9 +398,13 @@ func newHashJoinProber(
 		outColTypes = append(outColTypes, spec.right.sourceTypes[buildOutCol])
 	}
 
-	var probeRowUnmatched []bool
+	var (
+		probeRowUnmatched []bool
+		unmatchedNulls    coldata.Nulls
+	)
 	if spec.left.outer {
 		probeRowUnmatched = make([]bool, coldata.BatchSize())
+		unmatchedNulls = coldata.NewNulls(int(coldata.BatchSize()))
 	}
 
 	return &hashJoinProber{
@@ -361,11 +413,12 @@ func newHashJoinProber(
 		batch:           allocator.NewMemBatch(outColTypes),
 		outputBatchSize: outputBatchSize,
 
-		buildIdx: make([]uint64, coldata.BatchSize()),
-		probeIdx: make([]uint16, coldata.BatchSize()),
+		buildIdx: make([]int, coldata.BatchSize()),
+		probeIdx: make([]int, coldata.BatchSize()),
 
 		spec:              spec,
 		probeRowUnmatched: probeRowUnmatched,
+		unmatchedNulls:    unmatchedNulls,
 	}
 }
 
@@ -425,7 +478,7 @@ func (prober *hashJoinProber) exec(ctx context.Context) {
 						// and we need to include the current tuple to check whether it is
 						// an actual match.
 						prober.ht.groupID[i] = prober.ht.first[prober.ht.buckets[i]]
-						prober.ht.toCheck[nToCheck] = i
+						prober.ht.toCheck[nToCheck] = int(i)
 						nToCheck++
 					}
 				}
@@ -500,25 +553,27 @@ func (prober *hashJoinProber) congregate(nResults uint16, batch coldata.Batch, b
 						SliceArgs: coldata.SliceArgs{
 							ColType:   colType,
 							Src:       valCol,
+							Sel:       prober.buildIdx,
 							SrcEndIdx: uint64(nResults),
 						},
-						Sel64: prober.buildIdx,
 					},
 				)
 			}
 		})
 	}
 	if prober.spec.left.outer {
-		// Add in the nulls we needed to set for the outer join.
-		for outColIdx := range prober.ht.outCols {
-			outCol := prober.batch.ColVec(outColIdx + rightColOffset)
-			nulls := outCol.Nulls()
-			for i, isNull := range prober.probeRowUnmatched {
-				if isNull {
-					nulls.SetNull(uint16(i))
-				}
+		// Add in the nulls we needed to set for the outer join. The unmatched rows
+		// are collected into a bitmap once, and then it is merged into every
+		// column.
+		prober.unmatchedNulls.UnsetNulls()
+		for i := uint16(0); i < nResults; i++ {
+			if prober.probeRowUnmatched[i] {
+				prober.unmatchedNulls.SetNull(i)
 			}
 		}
+		for outColIdx := range prober.ht.outCols {
+			prober.batch.ColVec(outColIdx + rightColOffset).Nulls().Union(&prober.unmatchedNulls)
+		}
 	}
 
 	outCols := prober.batch.ColVecs()[:len(prober.spec.left.outCols)]
@@ -13,6 +13,7 @@ package colexec
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"runtime"
 	"testing"
 	"unsafe"
@@ -29,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -1088,6 +1090,43 @@ func TestHashingDoesNotAllocate(t *testing.T) {
 	t.Log(sum)
 }
 
+// TestHashTableSeedIsDeterministic verifies that two hash tables built over
+// the same input with the same seed end up with identical bucket chains, which
+// is what allows collision-dependent failures to be reproduced.
+func TestHashTableSeedIsDeterministic(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	defer setHashTableSeedForTests(hashTableSeed)
+	_, seed := randutil.NewPseudoRand()
+	for _, htSeed := range []uint64{defaultHashTableSeed, uint64(seed)} {
+		setHashTableSeedForTests(htSeed)
+		var hts [2]*hashTable
+		for i := range hts {
+			rng := rand.New(rand.NewSource(seed))
+			source := NewRandomDataOp(testAllocator, rng, RandomDataOpArgs{
+				AvailableTyps: []coltypes.T{coltypes.Int64, coltypes.Bytes, coltypes.Decimal},
+				Selection:     true,
+				Nulls:         true,
+			})
+			typs := source.Typs()
+			outCols := make([]uint32, len(typs))
+			for j := range outCols {
+				outCols[j] = uint32(j)
+			}
+			// Use a small number of buckets in order to force collisions.
+			hts[i] = newHashTable(
+				testAllocator, 1<<4 /* bucketSize */, typs, []uint32{0}, outCols, false, /* allowNullEquality */
+			)
+			hts[i].build(ctx, source)
+			hts[i].findSameTuples(ctx)
+		}
+		require.Equal(t, hts[0].first, hts[1].first)
+		require.Equal(t, hts[0].next, hts[1].next)
+		require.Equal(t, hts[0].same, hts[1].same)
+	}
+}
+
 // TestHashJoinerProjection tests that planning of hash joiner correctly
 // handles the "post-joiner" projection. The test uses different types with a
 // projection in which output columns from both sides are intertwined so that
@@ -1161,3 +1200,43 @@ func TestHashJoinerProjection(t *testing.T) {
 		}
 	}
 }
+
+func TestHashJoinerReleasesMemory(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := NewAllocator(ctx, &memAcc)
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	var leftTuples, rightTuples tuples
+	for i := 0; i < 4*int(coldata.BatchSize()); i++ {
+		leftTuples = append(leftTuples, tuple{i, "a"})
+		rightTuples = append(rightTuples, tuple{i, fmt.Sprintf("%d", i)})
+	}
+	for _, joinType := range []sqlbase.JoinType{sqlbase.JoinType_INNER, sqlbase.JoinType_RIGHT_OUTER} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			hj, err := NewEqHashJoinerOp(
+				allocator, newOpTestInput(coldata.BatchSize(), leftTuples, typs),
+				newOpTestInput(coldata.BatchSize(), rightTuples, typs), []uint32{0}, []uint32{0},
+				typs, typs, true /* rightDistinct */, joinType,
+			)
+			require.NoError(t, err)
+			hj.Init()
+			// The first call to Next builds the hash table.
+			require.NotEqual(t, uint16(0), hj.Next(ctx).Length())
+			usedAfterBuild := allocator.Used()
+			for hj.Next(ctx).Length() > 0 {
+				// Drain the output.
+			}
+			// Once all of the output has been emitted, the memory of the hash table
+			// is returned to the account.
+			require.True(
+				t, allocator.Used() < usedAfterBuild,
+				"used %d after build, %d after done", usedAfterBuild, allocator.Used(),
+			)
+			require.Equal(t, uint16(0), hj.Next(ctx).Length())
+			allocator.Clear()
+		})
+	}
+}
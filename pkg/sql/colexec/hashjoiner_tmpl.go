@@ -47,7 +47,7 @@ func _COLLECT_PROBE_OUTER(
 
 			prober.probeRowUnmatched[nResults] = currentID == 0
 			if currentID > 0 {
-				prober.buildIdx[nResults] = currentID - 1
+				prober.buildIdx[nResults] = int(currentID - 1)
 			} else {
 				// If currentID == 0, then probeRowUnmatched will have been set - and
 				// we set the corresponding buildIdx to zero so that (as long as the
@@ -58,7 +58,7 @@ func _COLLECT_PROBE_OUTER(
 			// {{if .UseSel}}
 			prober.probeIdx[nResults] = sel[i]
 			// {{else}}
-			prober.probeIdx[nResults] = i
+			prober.probeIdx[nResults] = int(i)
 			// {{end}}
 			currentID = prober.ht.same[currentID]
 			prober.ht.headID[i] = currentID
@@ -93,11 +93,11 @@ func _COLLECT_PROBE_NO_OUTER(
 				return nResults
 			}
 
-			prober.buildIdx[nResults] = currentID - 1
+			prober.buildIdx[nResults] = int(currentID - 1)
 			// {{if .UseSel}}
 			prober.probeIdx[nResults] = sel[i]
 			// {{else}}
-			prober.probeIdx[nResults] = i
+			prober.probeIdx[nResults] = int(i)
 			// {{end}}
 			currentID = prober.ht.same[currentID]
 			prober.ht.headID[i] = currentID
@@ -127,7 +127,7 @@ func _COLLECT_LEFT_ANTI(
 			// {{if .UseSel}}
 			prober.probeIdx[nResults] = sel[i]
 			// {{else}}
-			prober.probeIdx[nResults] = i
+			prober.probeIdx[nResults] = int(i)
 			// {{end}}
 			nResults++
 		}
@@ -154,12 +154,12 @@ func _DISTINCT_COLLECT_PROBE_OUTER(prober *hashJoinProber, batchSize uint16, _US
 		rowUnmatched := id == 0
 		prober.probeRowUnmatched[i] = rowUnmatched
 		if !rowUnmatched {
-			prober.buildIdx[i] = id - 1
+			prober.buildIdx[i] = int(id - 1)
 		}
 		// {{if .UseSel}}
 		prober.probeIdx[i] = sel[i]
 		// {{else}}
-		prober.probeIdx[i] = i
+		prober.probeIdx[i] = int(i)
 		// {{end}}
 	}
 	// {{end}}
@@ -180,11 +180,11 @@ func _DISTINCT_COLLECT_PROBE_NO_OUTER(
 	for i := uint16(0); i < batchSize; i++ {
 		if prober.ht.groupID[i] != 0 {
 			// Index of keys and outputs in the hash table is calculated as ID - 1.
-			prober.buildIdx[nResults] = prober.ht.groupID[i] - 1
+			prober.buildIdx[nResults] = int(prober.ht.groupID[i] - 1)
 			// {{if .UseSel}}
 			prober.probeIdx[nResults] = sel[i]
 			// {{else}}
-			prober.probeIdx[nResults] = i
+			prober.probeIdx[nResults] = int(i)
 			// {{end}}
 			nResults++
 		}
@@ -198,7 +198,7 @@ func _DISTINCT_COLLECT_PROBE_NO_OUTER(
 // collect prepares the buildIdx and probeIdx arrays where the buildIdx and
 // probeIdx at each index are joined to make an output row. The total number of
 // resulting rows is returned.
-func (prober *hashJoinProber) collect(batch coldata.Batch, batchSize uint16, sel []uint16) uint16 {
+func (prober *hashJoinProber) collect(batch coldata.Batch, batchSize uint16, sel []int) uint16 {
 	nResults := uint16(0)
 
 	if prober.spec.left.outer {
@@ -232,7 +232,7 @@ func (prober *hashJoinProber) collect(batch coldata.Batch, batchSize uint16, sel
 // row index for each probe row is given in the groupID slice. This function
 // requires assumes a N-1 hash join.
 func (prober *hashJoinProber) distinctCollect(
-	batch coldata.Batch, batchSize uint16, sel []uint16,
+	batch coldata.Batch, batchSize uint16, sel []int,
 ) uint16 {
 	nResults := uint16(0)
 
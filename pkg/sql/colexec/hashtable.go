@@ -12,6 +12,8 @@ package colexec
 
 import (
 	"context"
+	"reflect"
+	"unsafe"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
@@ -20,6 +22,24 @@ import (
 // TODO(yuzefovich): support rehashing instead of large fixed bucket size.
 const hashTableBucketSize = 1 << 16
 
+// defaultHashTableSeed is the value that the hash of every key is initialized
+// to before the key columns are rehashed into it.
+const defaultHashTableSeed = 1
+
+// hashTableSeed is the seed that newly created hashTables use. It is always
+// defaultHashTableSeed outside of tests, but tests can override it via
+// setHashTableSeedForTests in order to vary which keys collide within a bucket
+// (and, as a consequence, the order of the next and same chains) while still
+// being able to reproduce any particular run given the seed.
+var hashTableSeed uint64 = defaultHashTableSeed
+
+// setHashTableSeedForTests sets the seed that all hashTables created after
+// this call will use. It should only be called from tests and is not safe to
+// call concurrently with hashTable creation.
+func setHashTableSeedForTests(seed uint64) {
+	hashTableSeed = seed
+}
+
 // hashTable is a structure used by the hash joiner to store the build table
 // batches. Keys are stored according to the encoding of the equality column,
 // which point to the corresponding output keyID. The keyID is calculated
@@ -82,6 +102,10 @@ type hashTable struct {
 	// bucketSize returns the number of buckets the hashTable employs. This is
 	// equivalent to the size of first.
 	bucketSize uint64
+	// seed is the initial hash value of every key. It is fixed at construction
+	// time so that the hash values (and, therefore, bucket collisions) are the
+	// same for the lifetime of the hashTable.
+	seed uint64
 
 	// keys stores the equality columns on the probe table for a single batch.
 	keys []coldata.Vec
@@ -95,7 +119,7 @@ type hashTable struct {
 	groupID []uint64
 	// toCheck stores the indices of the eqCol rows that have yet to be found or
 	// rejected.
-	toCheck []uint16
+	toCheck []int
 
 	// headID stores the first build table keyID that matched with the probe batch
 	// key at any given index.
@@ -105,6 +129,10 @@ type hashTable struct {
 	// key.
 	differs []bool
 
+	// dictHashes stores the hash values of the distinct values of a
+	// dictionary-encoded key column (see hashBytesDictionary).
+	dictHashes []uint64
+
 	// allowNullEquality determines if NULL keys should be treated as equal to
 	// each other.
 	allowNullEquality bool
@@ -179,9 +207,10 @@ func newHashTable(
 		outTypes: outTypes,
 
 		bucketSize: bucketSize,
+		seed:       hashTableSeed,
 
 		groupID: make([]uint64, coldata.BatchSize()),
-		toCheck: make([]uint16, coldata.BatchSize()),
+		toCheck: make([]int, coldata.BatchSize()),
 		differs: make([]bool, coldata.BatchSize()),
 
 		headID: make([]uint64, coldata.BatchSize()),
@@ -217,6 +246,28 @@ func (ht *hashTable) build(ctx context.Context, input Operator) {
 	ht.buildNextChains(ctx)
 }
 
+// reset resets the hashTable so that it can be built from another input.
+func (ht *hashTable) reset() {
+	for i := range ht.first {
+		ht.first[i] = 0
+	}
+	ht.vals.reset()
+}
+
+// release returns the memory of the buffered tuples to the allocator and drops
+// the references to them as well as to the auxiliary slices of the hashTable.
+// The hashTable must not be used afterwards.
+func (ht *hashTable) release() {
+	ht.allocator.ReleaseVecs(ht.vals.colVecs)
+	ht.vals.colVecs = nil
+	ht.vals.length = 0
+	ht.first = nil
+	ht.next = nil
+	ht.same = nil
+	ht.visited = nil
+	ht.head = nil
+}
+
 // findSameTuples populates the hashTable's same array by probing the
 // hashTable with every single input key.
 // NOTE: the hashTable *must* have been already built.
@@ -236,7 +287,7 @@ func (ht *hashTable) findSameTuples(ctx context.Context) {
 		batchSize := uint16(batchEnd - batchStart)
 
 		for i := 0; i < nKeyCols; i++ {
-			ht.keys[i] = ht.vals.colVecs[ht.keyCols[i]].Window(ht.valTypes[ht.keyCols[i]], batchStart, batchEnd)
+			ht.keys[i] = ht.vals.colVecs[ht.keyCols[i]].Window(batchStart, batchEnd)
 		}
 
 		ht.lookupInitial(ctx, batchSize, nil)
@@ -300,7 +351,7 @@ func (ht *hashTable) loadBatch(batch coldata.Batch) {
 // rehashing purposes.
 func (ht *hashTable) initHash(buckets []uint64, nKeys uint64) {
 	for i := uint64(0); i < nKeys; i++ {
-		buckets[i] = 1
+		buckets[i] = ht.seed
 	}
 }
 
@@ -319,7 +370,7 @@ func (ht *hashTable) finalizeHash(buckets []uint64, nKeys uint64) {
 // computeBuckets computes the hash value of each key and stores the result in
 // buckets.
 func (ht *hashTable) computeBuckets(
-	ctx context.Context, buckets []uint64, keys []coldata.Vec, nKeys uint64, sel []uint16,
+	ctx context.Context, buckets []uint64, keys []coldata.Vec, nKeys uint64, sel []int,
 ) {
 	ht.initHash(buckets, nKeys)
 
@@ -329,12 +380,59 @@ func (ht *hashTable) computeBuckets(
 	}
 
 	for i, k := range ht.keyCols {
+		if i == 0 && ht.valTypes[k] == coltypes.Bytes {
+			if dict := keys[i].Bytes().Dictionary(); dict != nil &&
+				ht.hashBytesDictionary(ctx, buckets, dict, keys[i].Nulls(), nKeys, sel) {
+				continue
+			}
+		}
 		ht.rehash(ctx, buckets, i, ht.valTypes[k], keys[i], nKeys, sel)
 	}
 
 	ht.finalizeHash(buckets, nKeys)
 }
 
+// hashBytesDictionary is an optimized version of rehash for the first key
+// column when it is dictionary-encoded: since all of the hash values are
+// equal to the seed before the first column is hashed, the hash of every
+// distinct value is computed only once. It returns false if the dictionary
+// doesn't cover all of the keys, in which case rehash must be used instead.
+func (ht *hashTable) hashBytesDictionary(
+	ctx context.Context,
+	buckets []uint64,
+	dict *coldata.BytesDictionary,
+	nulls *coldata.Nulls,
+	nKeys uint64,
+	sel []int,
+) bool {
+	if !bytesDictionaryCovers(dict, nKeys, sel) {
+		return false
+	}
+	codes := dict.Codes()
+	ht.cancelChecker.check(ctx)
+	dictHashes := ht.dictHashes[:0]
+	for code := 0; code < dict.NumValues(); code++ {
+		v := dict.Value(int32(code))
+		p := uintptr(ht.seed)
+		sh := (*reflect.SliceHeader)(unsafe.Pointer(&v))
+		p = memhash(unsafe.Pointer(sh.Data), p, uintptr(len(v)))
+		dictHashes = append(dictHashes, uint64(p))
+	}
+	ht.dictHashes = dictHashes
+	hasNulls := nulls.MaybeHasNulls()
+	for i := uint64(0); i < nKeys; i++ {
+		selIdx := int(i)
+		if sel != nil {
+			selIdx = sel[i]
+		}
+		if hasNulls && nulls.NullAt(uint16(selIdx)) {
+			continue
+		}
+		buckets[i] = dictHashes[codes[selIdx]]
+	}
+	return true
+}
+
 // buildNextChains builds the hash map from the computed hash values.
 func (ht *hashTable) buildNextChains(ctx context.Context) {
 	for id := uint64(1); id <= ht.vals.length; id++ {
@@ -359,11 +457,11 @@ func (ht *hashTable) allocateVisited() {
 // lookupInitial finds the corresponding hash table buckets for the equality
 // column of the batch and stores the results in groupID. It also initializes
 // toCheck with all indices in the range [0, batchSize).
-func (ht *hashTable) lookupInitial(ctx context.Context, batchSize uint16, sel []uint16) {
+func (ht *hashTable) lookupInitial(ctx context.Context, batchSize uint16, sel []int) {
 	ht.computeBuckets(ctx, ht.buckets, ht.keys, uint64(batchSize), sel)
 	for i := uint16(0); i < batchSize; i++ {
 		ht.groupID[i] = ht.first[ht.buckets[i]]
-		ht.toCheck[i] = i
+		ht.toCheck[i] = int(i)
 	}
 }
 
@@ -376,7 +474,7 @@ func (ht *hashTable) findNext(nToCheck uint16) {
 }
 
 // checkCols performs a column by column checkCol on the key columns.
-func (ht *hashTable) checkCols(nToCheck uint16, sel []uint16) {
+func (ht *hashTable) checkCols(nToCheck uint16, sel []int) {
 	for i, k := range ht.keyCols {
 		ht.checkCol(ht.valTypes[k], i, nToCheck, sel)
 	}
@@ -389,7 +487,7 @@ func (ht *hashTable) checkCols(nToCheck uint16, sel []uint16) {
 // key is removed from toCheck if it has already been visited in a previous
 // probe, or the bucket has reached the end (key not found in build table). The
 // new length of toCheck is returned by this function.
-func (ht *hashTable) check(nToCheck uint16, sel []uint16) uint16 {
+func (ht *hashTable) check(nToCheck uint16, sel []int) uint16 {
 	ht.checkCols(nToCheck, sel)
 	nDiffers := uint16(0)
 	for i := uint16(0); i < nToCheck; i++ {
@@ -435,7 +533,7 @@ func (ht *hashTable) check(nToCheck uint16, sel []uint16) uint16 {
 // toCheck. If the bucket has reached the end, the key is rejected. The toCheck
 // list is reconstructed to only hold the indices of the eqCol keys that have
 // not been found. The new length of toCheck is returned by this function.
-func (ht *hashTable) distinctCheck(nToCheck uint16, sel []uint16) uint16 {
+func (ht *hashTable) distinctCheck(nToCheck uint16, sel []int) uint16 {
 	ht.checkCols(nToCheck, sel)
 
 	// Select the indices that differ and put them into toCheck.
@@ -0,0 +1,91 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashTableRehashFixedWidth verifies that the unrolled loop used to hash
+// the fixed-width keys without nulls and without a selection vector computes
+// the same hashes as the general loop (used by the hash kernels), including
+// when the number of keys is not a multiple of the unrolling factor.
+func TestHashTableRehashFixedWidth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	n := int(coldata.BatchSize())
+
+	for _, typ := range []coltypes.T{coltypes.Int16, coltypes.Int32, coltypes.Int64, coltypes.Float64} {
+		t.Run(typ.String(), func(t *testing.T) {
+			vec := testAllocator.NewMemColumn(typ, n)
+			coldata.RandomVec(rng, typ, 0 /* bytesFixedLength */, vec, n, 0 /* nullProbability */)
+			vec.Nulls().UnsetNulls()
+			kernels := getTypeKernels(typ)
+
+			var ht hashTable
+			ht.seed = defaultHashTableSeed
+			for _, nKeys := range []int{1, 3, 4, 5, 7, n - 1, n} {
+				expected, actual := make([]uint64, nKeys), make([]uint64, nKeys)
+				ht.initHash(expected, uint64(nKeys))
+				kernels.Hash(expected, vec, nil /* sel */)
+				ht.initHash(actual, uint64(nKeys))
+				ht.rehash(ctx, actual, 0 /* keyIdx */, typ, vec, uint64(nKeys), nil /* sel */)
+				require.Equal(t, expected, actual, "nKeys=%d", nKeys)
+			}
+		})
+	}
+}
+
+func BenchmarkHashTableRehash(b *testing.B) {
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	n := coldata.BatchSize()
+	sel := randomSel(rng, n, 0.5 /* probOfOmitting */)
+
+	for _, typ := range []coltypes.T{coltypes.Int64, coltypes.Float64} {
+		vec := testAllocator.NewMemColumn(typ, int(n))
+		coldata.RandomVec(rng, typ, 0 /* bytesFixedLength */, vec, int(n), 0 /* nullProbability */)
+		vec.Nulls().UnsetNulls()
+		for _, hasNulls := range []bool{false, true} {
+			if hasNulls {
+				vec.Nulls().SetNull(0)
+			}
+			for _, useSel := range []bool{false, true} {
+				b.Run(fmt.Sprintf("%s/hasNulls=%t/useSel=%t", typ, hasNulls, useSel), func(b *testing.B) {
+					var batchSel []int
+					nKeys := uint64(n)
+					if useSel {
+						batchSel = sel
+						nKeys = uint64(len(sel))
+					}
+					var ht hashTable
+					ht.seed = defaultHashTableSeed
+					buckets := make([]uint64, nKeys)
+					b.SetBytes(int64(8 * nKeys))
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						ht.initHash(buckets, nKeys)
+						ht.rehash(ctx, buckets, 0 /* keyIdx */, typ, vec, nKeys, batchSel)
+					}
+				})
+			}
+		}
+	}
+}
@@ -105,7 +105,7 @@ func _CHECK_COL_BODY(
 			selIdx := toCheck
 			// {{end}}
 			/* {{if .ProbeHasNulls }} */
-			probeIsNull = probeVec.Nulls().NullAt(selIdx)
+			probeIsNull = probeVec.Nulls().NullAt(uint16(selIdx))
 			/* {{end}} */
 
 			/* {{if .BuildHasNulls }} */
@@ -133,7 +133,7 @@ func _CHECK_COL_BODY(
 				ht.differs[toCheck] = true
 			} else {
 				buildVal := execgen.UNSAFEGET(buildKeys, int(keyID-1))
-				probeVal := execgen.UNSAFEGET(probeKeys, int(selIdx))
+				probeVal := execgen.UNSAFEGET(probeKeys, selIdx)
 				var unique bool
 				_ASSIGN_NE(unique, buildVal, probeVal)
 
@@ -183,7 +183,7 @@ func _REHASH_BODY(
 	keys _GOTYPESLICE,
 	nulls *coldata.Nulls,
 	nKeys uint64,
-	sel []uint16,
+	sel []int,
 	_HAS_SEL bool,
 	_HAS_NULLS bool,
 ) { // */}}
@@ -200,14 +200,71 @@ func _REHASH_BODY(
 		// {{ if .HasSel }}
 		selIdx := sel[i]
 		// {{ else }}
-		selIdx := i
+		selIdx := int(i)
 		// {{ end }}
 		// {{ if .HasNulls }}
 		if nulls.NullAt(uint16(selIdx)) {
 			continue
 		}
 		// {{ end }}
-		v := execgen.UNSAFEGET(keys, int(selIdx))
+		v := execgen.UNSAFEGET(keys, selIdx)
+		p := uintptr(buckets[i])
+		_ASSIGN_HASH(p, v)
+		buckets[i] = uint64(p)
+	}
+	// {{end}}
+
+	// {{/*
+}
+
+func _REHASH_FIXED_WIDTH_BODY(
+	ctx context.Context, ht *hashTable, buckets []uint64, keys _GOTYPESLICE, nKeys uint64,
+) { // */}}
+	// {{define "rehashFixedWidthBody" -}}
+	// The keys are of a fixed-width type, there are no nulls, and there is no
+	// selection vector, so the keys and the buckets are processed as contiguous
+	// slices in an unrolled loop. The loop has no branches nor bounds checks
+	// (other than the one per iteration), and the hashes of the keys don't
+	// depend on each other, which allows for them to be computed in parallel.
+	// It is enough to check for the cancellation once since the work is
+	// bounded by the batch size.
+	ht.cancelChecker.check(ctx)
+	keys = execgen.SLICE(keys, 0, int(nKeys))
+	buckets = buckets[:nKeys]
+	i := 0
+	for ; i+4 <= len(buckets); i += 4 {
+		b := buckets[i : i+4]
+		k := execgen.SLICE(keys, i, i+4)
+		// Early bounds checks.
+		_ = b[3]
+		_ = execgen.UNSAFEGET(k, 3)
+		{
+			v := execgen.UNSAFEGET(k, 0)
+			p := uintptr(b[0])
+			_ASSIGN_HASH(p, v)
+			b[0] = uint64(p)
+		}
+		{
+			v := execgen.UNSAFEGET(k, 1)
+			p := uintptr(b[1])
+			_ASSIGN_HASH(p, v)
+			b[1] = uint64(p)
+		}
+		{
+			v := execgen.UNSAFEGET(k, 2)
+			p := uintptr(b[2])
+			_ASSIGN_HASH(p, v)
+			b[2] = uint64(p)
+		}
+		{
+			v := execgen.UNSAFEGET(k, 3)
+			p := uintptr(b[3])
+			_ASSIGN_HASH(p, v)
+			b[3] = uint64(p)
+		}
+	}
+	for ; i < len(buckets); i++ {
+		v := execgen.UNSAFEGET(keys, i)
 		p := uintptr(buckets[i])
 		_ASSIGN_HASH(p, v)
 		buckets[i] = uint64(p)
@@ -229,7 +286,7 @@ func (ht *hashTable) rehash(
 	t coltypes.T,
 	col coldata.Vec,
 	nKeys uint64,
-	sel []uint16,
+	sel []int,
 ) {
 	switch t {
 	// {{range $hashType := .HashTemplate}}
@@ -245,13 +302,24 @@ func (ht *hashTable) rehash(
 			if sel != nil {
 				_REHASH_BODY(ctx, ht, buckets, keys, nulls, nKeys, sel, true, false)
 			} else {
+				// {{if isFixedWidth .LTyp}}
+				_REHASH_FIXED_WIDTH_BODY(ctx, ht, buckets, keys, nKeys)
+				// {{else}}
 				_REHASH_BODY(ctx, ht, buckets, keys, nulls, nKeys, sel, false, false)
+				// {{end}}
 			}
 		}
 
 	// {{end}}
 	default:
-		execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %d", t))
+		// The types without generated code might still be hashed by the kernels
+		// registered for them.
+		kernels := getTypeKernels(t)
+		if kernels.Hash == nil {
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %d", t))
+		}
+		ht.cancelChecker.check(ctx)
+		kernels.Hash(buckets[:nKeys], col, sel)
 	}
 }
 
@@ -260,7 +328,7 @@ func (ht *hashTable) rehash(
 // to differs. If the bucket has reached the end, the key is rejected. If the
 // hashTable disallows null equality, then if any element in the key is null,
 // there is no match.
-func (ht *hashTable) checkCol(t coltypes.T, keyColIdx int, nToCheck uint16, sel []uint16) {
+func (ht *hashTable) checkCol(t coltypes.T, keyColIdx int, nToCheck uint16, sel []int) {
 	switch t {
 	// {{range $neType := .NETemplate}}
 	case _TYPES_T:
@@ -0,0 +1,262 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// hybridScanState represents the state of the hybridScanOp.
+type hybridScanState int
+
+const (
+	// hybridScanBuffering is the initial state in which the rows produced by
+	// the row engine are buffered until either the row source is exhausted or
+	// the threshold is crossed.
+	hybridScanBuffering hybridScanState = iota
+	// hybridScanEmittingRows is the state in which the row source has been
+	// exhausted before crossing the threshold, so the buffered rows are
+	// emitted.
+	hybridScanEmittingRows
+	// hybridScanColumnar is the state in which the threshold has been crossed,
+	// so the buffered rows and the metadata of the row source have been
+	// discarded and the output is produced by the columnar operators.
+	hybridScanColumnar
+)
+
+// hybridScanOp runs a TableReader processor (together with its post-processing
+// stage) in the row engine first and switches to the equivalent chain of
+// columnar operators only once the number of rows exceeds the threshold. This
+// way the point lookups and the small queries don't pay the setup costs of the
+// columnar operators (like allocating full-sized batches), yet large scans
+// still benefit from the vectorized execution.
+// The rows produced by the row engine are not emitted until the row source is
+// exhausted, so when the threshold is crossed, they are simply discarded and
+// the columnar operators read the table from the very beginning. This relies
+// on the scan returning the same rows when it is executed for the second time
+// within the same transaction, which holds since nothing has been emitted to
+// the consumer (that could have modified the data) at that point. Reading the
+// first rows twice is only worth it if the threshold is rarely crossed, so the
+// operator is planned only for the scans that are estimated to be small.
+type hybridScanOp struct {
+	NonExplainable
+
+	allocator *Allocator
+	ctx       context.Context
+	threshold int
+	typs      []coltypes.T
+	colTypes  []types.T
+
+	// rowSource is the TableReader processor created by the row engine.
+	rowSource execinfra.RowSource
+	// colOp is the root of the chain of columnar operators that produce the
+	// same output as rowSource.
+	colOp Operator
+
+	initStatus      OperatorInitStatus
+	state           hybridScanState
+	buffered        sqlbase.EncDatumRows
+	rowAlloc        sqlbase.EncDatumRowAlloc
+	numEmitted      int
+	output          coldata.Batch
+	da              sqlbase.DatumAlloc
+	accumulatedMeta []execinfrapb.ProducerMetadata
+}
+
+var _ Operator = &hybridScanOp{}
+var _ execinfrapb.MetadataSource = &hybridScanOp{}
+var _ Closer = &hybridScanOp{}
+
+// shouldUseHybridScan returns whether a TableReader should be planned as a
+// hybridScanOp and the row count threshold to be used by it. We start in the
+// row engine only when the vectorized engine has been chosen automatically
+// (because the estimated row count of some part of the plan is above the
+// threshold) while the scan itself is estimated to stay below the threshold.
+// Scans without an estimate and the ones that are expected to be large go
+// straight to the columnar operators, so they don't read their first rows
+// twice.
+func shouldUseHybridScan(
+	flowCtx *execinfra.FlowCtx, spec *execinfrapb.TableReaderSpec,
+) (bool, int) {
+	sd := flowCtx.EvalCtx.SessionData
+	if sd == nil || sd.VectorizeMode != sessiondata.VectorizeAuto || sd.VectorizeRowCountThreshold == 0 {
+		return false, 0
+	}
+	if spec.EstimatedRowCount == 0 || spec.EstimatedRowCount >= sd.VectorizeRowCountThreshold {
+		return false, 0
+	}
+	return true, int(sd.VectorizeRowCountThreshold)
+}
+
+// newHybridScanOp returns a new hybridScanOp that starts by reading from
+// rowSource and switches over to colOp once more than threshold rows are
+// produced. The output of both must have colTypes.
+func newHybridScanOp(
+	ctx context.Context,
+	allocator *Allocator,
+	rowSource execinfra.RowSource,
+	colOp Operator,
+	colTypes []types.T,
+	threshold int,
+) (*hybridScanOp, error) {
+	typs, err := typeconv.FromColumnTypes(colTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &hybridScanOp{
+		allocator: allocator,
+		ctx:       ctx,
+		threshold: threshold,
+		typs:      typs,
+		colTypes:  colTypes,
+		rowSource: rowSource,
+		colOp:     colOp,
+	}, nil
+}
+
+// ChildCount is part of the execinfra.OpNode interface. Only the columnar
+// operators are exposed as the child so that EXPLAIN (VEC) shows the same
+// plan regardless of the engine that is used at runtime.
+func (o *hybridScanOp) ChildCount(verbose bool) int {
+	return 1
+}
+
+// Child is part of the execinfra.OpNode interface.
+func (o *hybridScanOp) Child(nth int, verbose bool) execinfra.OpNode {
+	if nth == 0 {
+		return o.colOp
+	}
+	execerror.VectorizedInternalPanic(fmt.Sprintf("invalid index %d", nth))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func (o *hybridScanOp) Init() {
+	o.rowSource.Start(o.ctx)
+	o.initStatus = OperatorInitialized
+}
+
+func (o *hybridScanOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch o.state {
+		case hybridScanBuffering:
+			row, meta := o.rowSource.Next()
+			if meta != nil {
+				o.accumulatedMeta = append(o.accumulatedMeta, *meta)
+				continue
+			}
+			if row == nil {
+				o.state = hybridScanEmittingRows
+				continue
+			}
+			o.buffered = append(o.buffered, o.rowAlloc.CopyRow(row))
+			if len(o.buffered) > o.threshold {
+				// The input is not tiny, so we discard everything read so far and
+				// switch over to the columnar operators.
+				o.drainRowSource()
+				o.discardRowSourceMeta()
+				o.buffered = nil
+				o.colOp.Init()
+				o.state = hybridScanColumnar
+			}
+
+		case hybridScanEmittingRows:
+			toEmit := len(o.buffered) - o.numEmitted
+			if toEmit == 0 {
+				return coldata.ZeroBatch
+			}
+			if toEmit > int(coldata.BatchSize()) {
+				toEmit = int(coldata.BatchSize())
+			}
+			if o.output == nil {
+				// The output batch is allocated with just enough capacity to hold
+				// all of the buffered rows (up to the batch size).
+				o.output = o.allocator.NewMemBatchWithSize(o.typs, toEmit)
+			}
+			o.output.ResetInternalBatch()
+			rows := o.buffered[o.numEmitted : o.numEmitted+toEmit]
+			for colIdx := range o.colTypes {
+				if err := EncDatumRowsToColVec(
+					o.allocator, rows, o.output.ColVec(colIdx), colIdx, &o.colTypes[colIdx], &o.da,
+				); err != nil {
+					execerror.VectorizedInternalPanic(err)
+				}
+			}
+			o.numEmitted += toEmit
+			o.output.SetLength(uint16(toEmit))
+			return o.output
+
+		case hybridScanColumnar:
+			return o.colOp.Next(ctx)
+
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected hybridScanState %d", o.state))
+		}
+	}
+}
+
+// drainRowSource asks the row source to stop producing rows and accumulates
+// all of its remaining metadata.
+func (o *hybridScanOp) drainRowSource() {
+	o.rowSource.ConsumerDone()
+	for {
+		row, meta := o.rowSource.Next()
+		if row == nil && meta == nil {
+			return
+		}
+		if meta != nil {
+			o.accumulatedMeta = append(o.accumulatedMeta, *meta)
+		}
+	}
+}
+
+// discardRowSourceMeta discards the metadata accumulated from the row source
+// except for the errors. The columnar operators scan the same spans again and
+// produce their own trailing metadata (like the range info, the leaf txn
+// state, and the number of rows read), so keeping the metadata of the row
+// source would make the consumer see it twice.
+func (o *hybridScanOp) discardRowSourceMeta() {
+	errs := o.accumulatedMeta[:0]
+	for _, meta := range o.accumulatedMeta {
+		if meta.Err != nil {
+			errs = append(errs, meta)
+		}
+	}
+	o.accumulatedMeta = errs
+}
+
+// DrainMeta is part of the MetadataSource interface. Note that the metadata of
+// the columnar operators is drained separately.
+func (o *hybridScanOp) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	if o.initStatus == OperatorNotInitialized {
+		return nil
+	}
+	if o.state == hybridScanBuffering {
+		o.drainRowSource()
+	}
+	return o.accumulatedMeta
+}
+
+// Close is part of the Closer interface.
+func (o *hybridScanOp) Close() error {
+	return closeInput(o.colOp)
+}
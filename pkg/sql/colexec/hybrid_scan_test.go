@@ -0,0 +1,105 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/distsqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHybridScanOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	const threshold = 5
+	for _, numRows := range []int{0, 3, threshold, threshold + 1, 3 * threshold} {
+		t.Run(fmt.Sprintf("rows=%d", numRows), func(t *testing.T) {
+			rows := sqlbase.MakeIntRows(numRows, 1 /* numCols */)
+			expected := make(tuples, numRows)
+			for i := range expected {
+				expected[i] = tuple{int64(i)}
+			}
+			rowSource := distsqlutils.NewRowBuffer([]types.T{*types.Int}, rows, distsqlutils.RowBufferArgs{})
+			// The row source emits the trailing metadata after all of the rows,
+			// like the TableReader does.
+			rowSource.Push(nil /* row */, &execinfrapb.ProducerMetadata{
+				Metrics: &execinfrapb.RemoteProducerMetadata_Metrics{RowsRead: int64(numRows)},
+			})
+			// The columnar operators produce the same output as the row source.
+			colOp := newOpTestInput(2 /* batchSize */, expected, []coltypes.T{coltypes.Int64})
+			op, err := newHybridScanOp(
+				ctx, testAllocator, rowSource, colOp, []types.T{*types.Int}, threshold,
+			)
+			require.NoError(t, err)
+			op.Init()
+			var actual tuples
+			for {
+				b := op.Next(ctx)
+				if b.Length() == 0 {
+					break
+				}
+				for i := uint16(0); i < b.Length(); i++ {
+					actual = append(actual, getTupleFromBatch(b, i))
+				}
+			}
+			require.NoError(t, assertTuplesOrderedEqual(expected, actual))
+			meta := op.DrainMeta(ctx)
+			if numRows > threshold {
+				require.Equal(t, hybridScanColumnar, op.state)
+				require.Equal(t, execinfra.DrainRequested, rowSource.ConsumerStatus)
+				// The metadata of the row source is superseded by the one of the
+				// columnar operators.
+				require.Empty(t, meta)
+			} else {
+				require.Equal(t, hybridScanEmittingRows, op.state)
+				require.Equal(t, execinfra.NeedMoreRows, rowSource.ConsumerStatus)
+				require.Len(t, meta, 1)
+			}
+		})
+	}
+}
+
+func TestShouldUseHybridScan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	const threshold = 1000
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	evalCtx.SessionData.VectorizeMode = sessiondata.VectorizeAuto
+	evalCtx.SessionData.VectorizeRowCountThreshold = threshold
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx}
+	for _, tc := range []struct {
+		estimatedRowCount uint64
+		expected          bool
+	}{
+		// No estimate is available.
+		{estimatedRowCount: 0, expected: false},
+		{estimatedRowCount: 1, expected: true},
+		{estimatedRowCount: threshold - 1, expected: true},
+		{estimatedRowCount: threshold, expected: false},
+		{estimatedRowCount: 10 * threshold, expected: false},
+	} {
+		spec := &execinfrapb.TableReaderSpec{EstimatedRowCount: tc.estimatedRowCount}
+		useHybridScan, _ := shouldUseHybridScan(flowCtx, spec)
+		require.Equal(t, tc.expected, useHybridScan, "estimated row count %d", tc.estimatedRowCount)
+	}
+}
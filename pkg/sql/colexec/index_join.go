@@ -0,0 +1,194 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/span"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// indexJoinOpBatchSize is the number of spans that the indexJoinOp accumulates
+// before performing a scan of the primary index.
+const indexJoinOpBatchSize = 10000
+
+// indexJoinOp is the Operator implementation of an index join (the JoinReader
+// processor with empty lookup columns) which retrieves the rows of the primary
+// index that correspond to the input tuples. The first columns of the input
+// must be the primary key columns of the table (for example, the output of a
+// scan of a secondary or an inverted index). Similar to the indexJoiner
+// processor, the spans of the input tuples are accumulated into large batches,
+// and the batches produced by the cFetcher are emitted as is, so the output
+// columns are the columns of the table (only the columns needed by the
+// post-processing are populated) and the order of the input is not preserved.
+type indexJoinOp struct {
+	OneInputNode
+
+	flowCtx    *execinfra.FlowCtx
+	inputTypes []types.T
+	numKeyCols int
+
+	fetcher      *cFetcher
+	spanBuilder  *span.Builder
+	init         bool
+	fetcherReady bool
+	// batchSize is the number of spans after which a scan is performed. It is
+	// modified by tests.
+	batchSize int
+
+	scratch struct {
+		spans  roachpb.Spans
+		keyRow sqlbase.EncDatumRow
+		da     sqlbase.DatumAlloc
+	}
+}
+
+var _ Operator = &indexJoinOp{}
+var _ execinfrapb.MetadataSource = &indexJoinOp{}
+
+// newIndexJoinOp returns a new indexJoinOp for the given spec. post is used
+// only to determine the table columns that need to be fetched.
+func newIndexJoinOp(
+	allocator *Allocator,
+	flowCtx *execinfra.FlowCtx,
+	input Operator,
+	inputTypes []types.T,
+	spec *execinfrapb.JoinReaderSpec,
+	post *execinfrapb.PostProcessSpec,
+) (*indexJoinOp, error) {
+	if len(spec.LookupColumns) != 0 {
+		return nil, errors.AssertionFailedf("index join with lookup columns")
+	}
+	if spec.IndexIdx != 0 {
+		return nil, errors.Errorf("index join must be against primary index")
+	}
+	numKeyCols := len(spec.Table.PrimaryIndex.ColumnIDs)
+	if len(inputTypes) < numKeyCols {
+		return nil, errors.Errorf(
+			"index join input has %d columns, expected at least %d", len(inputTypes), numKeyCols,
+		)
+	}
+	returnMutations := spec.Visibility == execinfrapb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
+	helper := execinfra.ProcOutputHelper{}
+	if err := helper.Init(
+		post, spec.Table.ColumnTypesWithMutations(returnMutations), flowCtx.NewEvalCtx(), nil, /* output */
+	); err != nil {
+		return nil, err
+	}
+	neededCols := helper.NeededColumns()
+
+	fetcher := cFetcher{}
+	if _, _, err := initCRowFetcher(
+		allocator, &fetcher, scanBatchSize(flowCtx), &spec.Table, 0 /* indexIdx */, spec.Table.ColumnIdxMapWithMutations(returnMutations),
+		false /* reverse */, neededCols, false /* isCheck */, spec.Visibility, spec.LockingStrength,
+	); err != nil {
+		return nil, err
+	}
+	spanBuilder := span.MakeBuilder(&spec.Table, &spec.Table.PrimaryIndex)
+	spanBuilder.SetNeededColumns(neededCols)
+
+	return &indexJoinOp{
+		OneInputNode: NewOneInputNode(input),
+		flowCtx:      flowCtx,
+		inputTypes:   inputTypes,
+		numKeyCols:   numKeyCols,
+		fetcher:      &fetcher,
+		spanBuilder:  spanBuilder,
+		batchSize:    indexJoinOpBatchSize,
+	}, nil
+}
+
+func (j *indexJoinOp) Init() {
+	j.init = true
+	j.input.Init()
+	j.scratch.keyRow = make(sqlbase.EncDatumRow, j.numKeyCols)
+}
+
+func (j *indexJoinOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		if !j.fetcherReady {
+			j.scratch.spans = j.scratch.spans[:0]
+			for len(j.scratch.spans) < j.batchSize {
+				batch := j.input.Next(ctx)
+				if batch.Length() == 0 {
+					break
+				}
+				j.appendSpans(batch)
+			}
+			if len(j.scratch.spans) == 0 {
+				return coldata.ZeroBatch
+			}
+			// The rows are looked up by the primary key, so the fetcher doesn't
+			// need to limit the number of results per batch.
+			if err := j.fetcher.StartScan(
+				ctx, j.flowCtx.Txn, j.scratch.spans, false /* limitBatches */, 0, /* limitHint */
+				j.flowCtx.TraceKV,
+			); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+			j.fetcherReady = true
+		}
+		batch, err := j.fetcher.NextBatch(ctx)
+		if err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		if batch.Length() == 0 {
+			// Done with the current spans.
+			j.fetcherReady = false
+			continue
+		}
+		return batch
+	}
+}
+
+// appendSpans appends the spans of the primary index that correspond to the
+// tuples of batch to j.scratch.spans.
+func (j *indexJoinOp) appendSpans(batch coldata.Batch) {
+	sel := batch.Selection()
+	for i := uint16(0); i < batch.Length(); i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = uint16(sel[i])
+		}
+		for colIdx := range j.scratch.keyRow {
+			j.scratch.keyRow[colIdx] = sqlbase.DatumToEncDatum(
+				&j.inputTypes[colIdx],
+				PhysicalTypeColElemToDatum(batch.ColVec(colIdx), rowIdx, &j.scratch.da, &j.inputTypes[colIdx]),
+			)
+		}
+		s, containsNull, err := j.spanBuilder.SpanFromEncDatums(j.scratch.keyRow, j.numKeyCols)
+		if err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		j.scratch.spans = j.spanBuilder.MaybeSplitSpanIntoSeparateFamilies(
+			j.scratch.spans, s, j.numKeyCols, containsNull,
+		)
+	}
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (j *indexJoinOp) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	if !j.init {
+		return nil
+	}
+	if tfs := execinfra.GetLeafTxnFinalState(ctx, j.flowCtx.Txn); tfs != nil {
+		return []execinfrapb.ProducerMetadata{{LeafTxnFinalState: tfs}}
+	}
+	return nil
+}
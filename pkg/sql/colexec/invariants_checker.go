@@ -20,7 +20,9 @@ import (
 
 // invariantsChecker is a helper Operator that will check that invariants that
 // are present in the vectorized engine are maintained on all batches. It
-// should be planned between other Operators in tests.
+// should be planned between other Operators in tests (see
+// execinfra.TestingKnobs.EnableVectorizedInvariantsChecker) and in race
+// builds.
 type invariantsChecker struct {
 	OneInputNode
 
@@ -43,8 +45,7 @@ func (i invariantsChecker) Init() {
 
 func (i invariantsChecker) Next(ctx context.Context) coldata.Batch {
 	b := i.input.Next(ctx)
-	n := b.Length()
-	if n == 0 {
+	if b.Length() == 0 {
 		return b
 	}
 	if i.expectedBatchWidth != b.Width() {
@@ -53,11 +54,47 @@ func (i invariantsChecker) Next(ctx context.Context) coldata.Batch {
 				i.expectedBatchWidth, b.Width(),
 			))
 	}
+	checkBatchInvariants(b)
+	return b
+}
+
+// checkBatchInvariants panics if the non-zero length batch b is not internally
+// consistent, i.e. if the selection vector points outside of the vectors or if
+// the vectors (or their null bitmaps) are shorter than the length of the batch.
+// Such batches are usually the result of an operator reusing its stale state.
+func checkBatchInvariants(b coldata.Batch) {
+	n := int(b.Length())
+	// requiredLength is the number of values that every vector must have.
+	requiredLength := n
+	if sel := b.Selection(); sel != nil {
+		if len(sel) < n {
+			panic(fmt.Sprintf("selection vector of length %d is shorter than the batch of length %d", len(sel), n))
+		}
+		requiredLength = 0
+		for _, idx := range sel[:n] {
+			if idx < 0 || idx >= coldata.MaxBatchSize {
+				panic(fmt.Sprintf("selection vector contains invalid index %d", idx))
+			}
+			if idx >= requiredLength {
+				requiredLength = idx + 1
+			}
+		}
+	}
 	for colIdx := 0; colIdx < b.Width(); colIdx++ {
 		v := b.ColVec(colIdx)
+		if v.Type() == coltypes.Unhandled {
+			continue
+		}
+		if length := v.Length(); length < requiredLength {
+			panic(fmt.Sprintf("vector %d of length %d cannot hold %d values", colIdx, length, requiredLength))
+		}
+		if v.MaybeHasNulls() {
+			if bitmapLength := len(v.Nulls().NullBitmap()) * 8; bitmapLength < requiredLength {
+				panic(fmt.Sprintf("null bitmap of vector %d of length %d cannot hold %d values", colIdx, bitmapLength, requiredLength))
+			}
+		}
 		if v.Type() == coltypes.Bytes {
 			v.Bytes().AssertOffsetsAreNonDecreasing(uint64(n))
 		}
 	}
-	return b
 }
@@ -0,0 +1,95 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvariantsChecker(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	for _, tc := range []struct {
+		name string
+		// corrupt modifies the valid batch of length 2.
+		corrupt     func(b coldata.Batch)
+		shouldPanic bool
+	}{
+		{
+			name:    "valid",
+			corrupt: func(coldata.Batch) {},
+		},
+		{
+			name: "validSelection",
+			corrupt: func(b coldata.Batch) {
+				b.SetSelection(true)
+				copy(b.Selection(), []int{1, 3})
+			},
+		},
+		{
+			name: "selectionOutOfBounds",
+			corrupt: func(b coldata.Batch) {
+				b.SetSelection(true)
+				copy(b.Selection(), []int{1, -1})
+			},
+			shouldPanic: true,
+		},
+		{
+			name: "selectionBeyondVectors",
+			corrupt: func(b coldata.Batch) {
+				b.ReplaceCol(b.ColVec(0).Window(0 /* start */, 2 /* end */), 0 /* colIdx */)
+				b.SetSelection(true)
+				copy(b.Selection(), []int{0, 2})
+			},
+			shouldPanic: true,
+		},
+		{
+			name: "shortVector",
+			corrupt: func(b coldata.Batch) {
+				b.ReplaceCol(b.ColVec(0).Window(0 /* start */, 1 /* end */), 0 /* colIdx */)
+			},
+			shouldPanic: true,
+		},
+		{
+			name: "shortNullBitmap",
+			corrupt: func(b coldata.Batch) {
+				// The bitmap can hold only 8 values.
+				nulls := coldata.NewNulls(1)
+				nulls.SetNull(0)
+				b.ColVec(0).SetNulls(&nulls)
+				b.SetSelection(true)
+				copy(b.Selection(), []int{0, 9})
+			},
+			shouldPanic: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := testAllocator.NewMemBatch(typs)
+			b.SetLength(2)
+			tc.corrupt(b)
+			checker := NewInvariantsChecker(NewRepeatableBatchSource(b), len(typs))
+			checker.Init()
+			next := func() { checker.Next(context.Background()) }
+			if tc.shouldPanic {
+				require.Panics(t, next)
+			} else {
+				require.NotPanics(t, next)
+			}
+		})
+	}
+}
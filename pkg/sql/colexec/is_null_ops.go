@@ -60,7 +60,7 @@ func (o *isNullProjOp) Next(ctx context.Context) coldata.Batch {
 		if sel := batch.Selection(); sel != nil {
 			sel = sel[:n]
 			for _, i := range sel {
-				projCol[i] = nulls.NullAt(i) != o.negate
+				projCol[i] = nulls.NullAt(uint16(i)) != o.negate
 			}
 		} else {
 			projCol = projCol[:n]
@@ -125,7 +125,7 @@ func (o *isNullSelOp) Next(ctx context.Context) coldata.Batch {
 			if sel := batch.Selection(); sel != nil {
 				sel = sel[:n]
 				for _, i := range sel {
-					if nulls.NullAt(i) != o.negate {
+					if nulls.NullAt(uint16(i)) != o.negate {
 						sel[idx] = i
 						idx++
 					}
@@ -135,7 +135,7 @@ func (o *isNullSelOp) Next(ctx context.Context) coldata.Batch {
 				sel := batch.Selection()[:n]
 				for i := range sel {
 					if nulls.NullAt(uint16(i)) != o.negate {
-						sel[idx] = uint16(i)
+						sel[idx] = i
 						idx++
 					}
 				}
@@ -156,3 +156,76 @@ func (o *isNullSelOp) Next(ctx context.Context) coldata.Batch {
 		}
 	}
 }
+
+// isNotDistinctFromProjOp is an Operator that projects into outputIdx Vec
+// whether the values in leftIdx and rightIdx Vecs are not distinct (i.e. it
+// performs IS NOT DISTINCT FROM check) given the result of their equality
+// comparison in eqIdx Vec: two NULLs are not distinct, a NULL is distinct from
+// a non-NULL value, and two non-NULL values are not distinct if they are
+// equal. rightIdx is -1 if the right argument is a non-NULL constant. If
+// negate is true, it does the opposite - it performs IS DISTINCT FROM check.
+type isNotDistinctFromProjOp struct {
+	OneInputNode
+	allocator *Allocator
+	eqIdx     int
+	leftIdx   int
+	rightIdx  int
+	outputIdx int
+	negate    bool
+}
+
+func newIsNotDistinctFromProjOp(
+	allocator *Allocator, input Operator, eqIdx, leftIdx, rightIdx, outputIdx int, negate bool,
+) Operator {
+	return &isNotDistinctFromProjOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		eqIdx:        eqIdx,
+		leftIdx:      leftIdx,
+		rightIdx:     rightIdx,
+		outputIdx:    outputIdx,
+		negate:       negate,
+	}
+}
+
+var _ Operator = &isNotDistinctFromProjOp{}
+
+func (o *isNotDistinctFromProjOp) Init() {
+	o.input.Init()
+}
+
+func (o *isNotDistinctFromProjOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, coltypes.Bool, o.outputIdx)
+	eqCol := batch.ColVec(o.eqIdx).Bool()
+	leftNulls := batch.ColVec(o.leftIdx).Nulls()
+	var rightNulls *coldata.Nulls
+	if o.rightIdx != -1 {
+		rightNulls = batch.ColVec(o.rightIdx).Nulls()
+	}
+	projCol := batch.ColVec(o.outputIdx).Bool()
+	eval := func(i int) bool {
+		leftNull := leftNulls.NullAt(uint16(i))
+		rightNull := rightNulls != nil && rightNulls.NullAt(uint16(i))
+		if leftNull || rightNull {
+			return (leftNull && rightNull) != o.negate
+		}
+		return eqCol[i] != o.negate
+	}
+	if sel := batch.Selection(); sel != nil {
+		sel = sel[:n]
+		for _, i := range sel {
+			projCol[i] = eval(i)
+		}
+	} else {
+		projCol = projCol[:n]
+		for i := range projCol {
+			projCol[i] = eval(i)
+		}
+	}
+	return batch
+}
@@ -0,0 +1,346 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// JSONB values are stored in coldata.Bytes vectors using their binary
+// encoding (see json.EncodeJSON). The operators in this file decode the values
+// lazily (see json.FromEncoding), so only the parts of a JSON object that are
+// needed to evaluate an expression are actually decoded.
+
+// decodeJSON decodes the encoded JSON value. The returned JSON may reference
+// b, so it must not be used once b is modified.
+func decodeJSON(b []byte) json.JSON {
+	j, err := json.FromEncoding(b)
+	if err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	return j
+}
+
+// GetJSONFetchProjectionOperator returns a projection operator which projects
+// the result of fetching the element of the JSON value in colIdx Vec that is
+// specified by the constant key (either a string key of an object or an
+// integer index of an array). The result is the JSON element itself (the ->
+// operator) or its text representation if asText is true (the ->> operator).
+// If the element doesn't exist (for example, because the JSON value is not an
+// object or an array), the result is NULL.
+func GetJSONFetchProjectionOperator(
+	allocator *Allocator,
+	input Operator,
+	colIdx int,
+	resultIdx int,
+	key tree.Datum,
+	asText bool,
+) (Operator, error) {
+	op := &projJSONFetchOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    resultIdx,
+		asText:       asText,
+	}
+	switch k := key.(type) {
+	case *tree.DString:
+		op.key = string(*k)
+	case *tree.DInt:
+		op.idx = int(*k)
+		op.fetchByIdx = true
+	default:
+		return nil, errors.Errorf("unsupported JSON fetch argument of type %s", key.ResolvedType())
+	}
+	return op, nil
+}
+
+// projJSONFetchOp is an Operator that projects into outputIdx Vec the element
+// of the JSON value in colIdx Vec with the given key or idx.
+type projJSONFetchOp struct {
+	OneInputNode
+	allocator *Allocator
+	colIdx    int
+	outputIdx int
+
+	key        string
+	idx        int
+	fetchByIdx bool
+	asText     bool
+
+	// scratch is used to encode the fetched JSON elements.
+	scratch []byte
+}
+
+var _ Operator = &projJSONFetchOp{}
+
+func (o *projJSONFetchOp) Init() {
+	o.input.Init()
+}
+
+func (o *projJSONFetchOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, coltypes.Bytes, o.outputIdx)
+	vec := batch.ColVec(o.colIdx)
+	col := vec.Bytes()
+	projVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				o.fetch(vec, col, projVec, i)
+			}
+		} else {
+			for i := 0; i < int(n); i++ {
+				o.fetch(vec, col, projVec, i)
+			}
+		}
+	})
+	return batch
+}
+
+// fetch sets the ith element of projVec to the result of the fetch from the
+// ith JSON value of col. Note that the tuples must be processed in the
+// increasing order of i since projVec is a flat Bytes vector.
+func (o *projJSONFetchOp) fetch(vec coldata.Vec, col *coldata.Bytes, projVec coldata.Vec, i int) {
+	if vec.Nulls().NullAt(uint16(i)) {
+		projVec.Nulls().SetNull(uint16(i))
+		return
+	}
+	j := decodeJSON(col.Get(i))
+	var (
+		res json.JSON
+		err error
+	)
+	if o.fetchByIdx {
+		res, err = j.FetchValIdx(o.idx)
+	} else {
+		res, err = j.FetchValKey(o.key)
+	}
+	if err != nil {
+		execerror.NonVectorizedPanic(err)
+	}
+	if res == nil {
+		projVec.Nulls().SetNull(uint16(i))
+		return
+	}
+	if o.asText {
+		text, err := res.AsText()
+		if err != nil {
+			execerror.NonVectorizedPanic(err)
+		}
+		if text == nil {
+			projVec.Nulls().SetNull(uint16(i))
+			return
+		}
+		o.scratch = append(o.scratch[:0], *text...)
+	} else {
+		o.scratch, err = json.EncodeJSON(o.scratch[:0], res)
+		if err != nil {
+			execerror.NonVectorizedPanic(err)
+		}
+	}
+	projVec.Bytes().Set(i, o.scratch)
+}
+
+// getJSONPredicate returns a function which evaluates the comparison cmpOp of
+// a JSON value against the constant constArg. The supported comparisons are
+// @>, <@ (with a JSON constArg) and ? (with a string constArg).
+func getJSONPredicate(
+	cmpOp tree.ComparisonOperator, constArg tree.Datum,
+) (func(json.JSON) (bool, error), error) {
+	switch cmpOp {
+	case tree.Contains, tree.ContainedBy:
+		d, ok := constArg.(*tree.DJSON)
+		if !ok {
+			return nil, errors.Errorf(
+				"unsupported argument of type %s for %s", constArg.ResolvedType(), cmpOp,
+			)
+		}
+		if cmpOp == tree.Contains {
+			return func(j json.JSON) (bool, error) { return json.Contains(j, d.JSON) }, nil
+		}
+		return func(j json.JSON) (bool, error) { return json.Contains(d.JSON, j) }, nil
+	case tree.JSONExists:
+		d, ok := constArg.(*tree.DString)
+		if !ok {
+			return nil, errors.Errorf(
+				"unsupported argument of type %s for %s", constArg.ResolvedType(), cmpOp,
+			)
+		}
+		key := string(*d)
+		return func(j json.JSON) (bool, error) { return j.Exists(key) }, nil
+	default:
+		return nil, errors.Errorf("unsupported JSON comparison operator %s", cmpOp)
+	}
+}
+
+// GetJSONSelectionOperator returns a selection operator which selects the
+// tuples for which the comparison cmpOp of the JSON value in colIdx Vec
+// against the constant constArg is true. See getJSONPredicate for the
+// supported comparisons.
+func GetJSONSelectionOperator(
+	input Operator, colIdx int, cmpOp tree.ComparisonOperator, constArg tree.Datum,
+) (Operator, error) {
+	pred, err := getJSONPredicate(cmpOp, constArg)
+	if err != nil {
+		return nil, err
+	}
+	return &selJSONOp{
+		OneInputNode: NewOneInputNode(input),
+		colIdx:       colIdx,
+		pred:         pred,
+	}, nil
+}
+
+// selJSONOp is an Operator that selects the tuples for which pred is true on
+// the JSON value in colIdx Vec. Tuples with NULL values are never selected.
+type selJSONOp struct {
+	OneInputNode
+	colIdx int
+	pred   func(json.JSON) (bool, error)
+}
+
+var _ Operator = &selJSONOp{}
+
+func (o *selJSONOp) Init() {
+	o.input.Init()
+}
+
+func (o *selJSONOp) eval(vec coldata.Vec, col *coldata.Bytes, i int) bool {
+	if vec.Nulls().NullAt(uint16(i)) {
+		return false
+	}
+	res, err := o.pred(decodeJSON(col.Get(i)))
+	if err != nil {
+		execerror.NonVectorizedPanic(err)
+	}
+	return res
+}
+
+func (o *selJSONOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		batch := o.input.Next(ctx)
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec := batch.ColVec(o.colIdx)
+		col := vec.Bytes()
+		var idx uint16
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if o.eval(vec, col, i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if o.eval(vec, col, i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
+
+// GetJSONProjectionOperator returns a projection operator which projects the
+// result of the comparison cmpOp of the JSON value in colIdx Vec against the
+// constant constArg. See getJSONPredicate for the supported comparisons.
+func GetJSONProjectionOperator(
+	allocator *Allocator,
+	input Operator,
+	colIdx int,
+	resultIdx int,
+	cmpOp tree.ComparisonOperator,
+	constArg tree.Datum,
+) (Operator, error) {
+	pred, err := getJSONPredicate(cmpOp, constArg)
+	if err != nil {
+		return nil, err
+	}
+	return &projJSONOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    resultIdx,
+		pred:         pred,
+	}, nil
+}
+
+// projJSONOp is an Operator that projects into outputIdx Vec the result of
+// pred on the JSON value in colIdx Vec. NULL values result in NULLs.
+type projJSONOp struct {
+	OneInputNode
+	allocator *Allocator
+	colIdx    int
+	outputIdx int
+	pred      func(json.JSON) (bool, error)
+}
+
+var _ Operator = &projJSONOp{}
+
+func (o *projJSONOp) Init() {
+	o.input.Init()
+}
+
+func (o *projJSONOp) eval(vec coldata.Vec, col *coldata.Bytes, projVec coldata.Vec, i int) {
+	if vec.Nulls().NullAt(uint16(i)) {
+		projVec.Nulls().SetNull(uint16(i))
+		return
+	}
+	res, err := o.pred(decodeJSON(col.Get(i)))
+	if err != nil {
+		execerror.NonVectorizedPanic(err)
+	}
+	projVec.Bool()[i] = res
+}
+
+func (o *projJSONOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, coltypes.Bool, o.outputIdx)
+	vec := batch.ColVec(o.colIdx)
+	col := vec.Bytes()
+	projVec := batch.ColVec(o.outputIdx)
+	if sel := batch.Selection(); sel != nil {
+		for _, i := range sel[:n] {
+			o.eval(vec, col, projVec, i)
+		}
+	} else {
+		for i := 0; i < int(n); i++ {
+			o.eval(vec, col, projVec, i)
+		}
+	}
+	return batch
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// encodeJSONForTest returns the binary encoding of the JSON value s which is
+// how the JSON values are stored in the vectors.
+func encodeJSONForTest(t *testing.T, s string) string {
+	j, err := json.ParseJSON(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.EncodeJSON(nil, j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestJSONFetchProjectionOperator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	obj := encodeJSONForTest(t, `{"a": 1, "b": "x"}`)
+	arr := encodeJSONForTest(t, `[null, "y"]`)
+	str := encodeJSONForTest(t, `"a"`)
+	for _, tc := range []struct {
+		desc     string
+		key      tree.Datum
+		asText   bool
+		expected tuples
+	}{
+		{
+			desc: "->'a'",
+			key:  tree.NewDString("a"),
+			expected: tuples{
+				{obj, encodeJSONForTest(t, `1`)}, {arr, nil}, {str, nil}, {nil, nil},
+			},
+		},
+		{
+			desc:   "->>'b'",
+			key:    tree.NewDString("b"),
+			asText: true,
+			expected: tuples{
+				{obj, "x"}, {arr, nil}, {str, nil}, {nil, nil},
+			},
+		},
+		{
+			desc:   "->>0",
+			key:    tree.NewDInt(0),
+			asText: true,
+			// JSON null results in NULL.
+			expected: tuples{
+				{obj, nil}, {arr, nil}, {str, nil}, {nil, nil},
+			},
+		},
+		{
+			desc: "->1",
+			key:  tree.NewDInt(1),
+			expected: tuples{
+				{obj, nil}, {arr, encodeJSONForTest(t, `"y"`)}, {str, nil}, {nil, nil},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			runTests(
+				t, []tuples{{{obj}, {arr}, {str}, {nil}}}, tc.expected, orderedVerifier,
+				func(input []Operator) (Operator, error) {
+					return GetJSONFetchProjectionOperator(
+						testAllocator, input[0], 0 /* colIdx */, 1 /* resultIdx */, tc.key, tc.asText,
+					)
+				})
+		})
+	}
+}
+
+func TestJSONComparisonOperators(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	obj := encodeJSONForTest(t, `{"a": 1, "b": [1, 2]}`)
+	arr := encodeJSONForTest(t, `["a", "c"]`)
+	num := encodeJSONForTest(t, `1`)
+	tups := tuples{{obj}, {arr}, {num}, {nil}}
+	parseJSON := func(s string) tree.Datum {
+		d, err := tree.ParseDJSON(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	for _, tc := range []struct {
+		desc     string
+		cmpOp    tree.ComparisonOperator
+		constArg tree.Datum
+		// projExpected is the expected output of the projection, and the
+		// expected output of the selection consists of the tuples for which the
+		// projection is true.
+		projExpected tuples
+	}{
+		{
+			desc:         `@> '{"b": [2]}'`,
+			cmpOp:        tree.Contains,
+			constArg:     parseJSON(`{"b": [2]}`),
+			projExpected: tuples{{obj, true}, {arr, false}, {num, false}, {nil, nil}},
+		},
+		{
+			desc:         `<@ '[1, "a", "c"]'`,
+			cmpOp:        tree.ContainedBy,
+			constArg:     parseJSON(`[1, "a", "c"]`),
+			projExpected: tuples{{obj, false}, {arr, true}, {num, true}, {nil, nil}},
+		},
+		{
+			desc:         `? 'a'`,
+			cmpOp:        tree.JSONExists,
+			constArg:     tree.NewDString("a"),
+			projExpected: tuples{{obj, true}, {arr, true}, {num, false}, {nil, nil}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var selExpected tuples
+			for _, tup := range tc.projExpected {
+				if tup[1] == true {
+					selExpected = append(selExpected, tuple{tup[0]})
+				}
+			}
+			runTests(t, []tuples{tups}, tc.projExpected, orderedVerifier,
+				func(input []Operator) (Operator, error) {
+					return GetJSONProjectionOperator(
+						testAllocator, input[0], 0 /* colIdx */, 1 /* resultIdx */, tc.cmpOp, tc.constArg,
+					)
+				})
+			runTests(t, []tuples{tups}, selExpected, orderedVerifier,
+				func(input []Operator) (Operator, error) {
+					return GetJSONSelectionOperator(input[0], 0 /* colIdx */, tc.cmpOp, tc.constArg)
+				})
+		})
+	}
+}
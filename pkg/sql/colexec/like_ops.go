@@ -30,11 +30,13 @@ const (
 	likeSuffixNegate
 	likePrefix
 	likePrefixNegate
+	likeContains
+	likeContainsNegate
 	likeRegexp
 	likeRegexpNegate
 )
 
-func getLikeOperatorType(pattern string, negate bool) (likeOpType, string, error) {
+func getLikeOperatorType(pattern string, caseInsensitive, negate bool) (likeOpType, string, error) {
 	if pattern == "" {
 		if negate {
 			return likeConstantNegate, "", nil
@@ -47,10 +49,12 @@ func getLikeOperatorType(pattern string, negate bool) (likeOpType, string, error
 		}
 		return likeAlwaysMatch, "", nil
 	}
-	if len(pattern) > 1 && !strings.ContainsAny(pattern[1:len(pattern)-1], "_%") {
-		// There are no wildcards in the middle of the string, so we only need to
-		// use a regular expression if both the first and last characters are
-		// wildcards.
+	// Case-insensitive matching as well as the patterns with escape sequences
+	// always go through the regular expression.
+	if !caseInsensitive && !strings.ContainsRune(pattern, '\\') &&
+		len(pattern) > 1 && !strings.ContainsAny(pattern[1:len(pattern)-1], "_%") {
+		// There are no wildcards in the middle of the string, so we don't need to
+		// use a regular expression.
 		firstChar := pattern[0]
 		lastChar := pattern[len(pattern)-1]
 		if !isWildcard(firstChar) && !isWildcard(lastChar) {
@@ -74,6 +78,13 @@ func getLikeOperatorType(pattern string, negate bool) (likeOpType, string, error
 			}
 			return likePrefix, prefix, nil
 		}
+		if firstChar == '%' && lastChar == '%' {
+			contains := pattern[1 : len(pattern)-1]
+			if negate {
+				return likeContainsNegate, contains, nil
+			}
+			return likeContains, contains, nil
+		}
 	}
 	// Default (slow) case: execute as a regular expression match.
 	if negate {
@@ -83,12 +94,18 @@ func getLikeOperatorType(pattern string, negate bool) (likeOpType, string, error
 }
 
 // GetLikeOperator returns a selection operator which applies the specified LIKE
-// pattern, or NOT LIKE if the negate argument is true. The implementation
-// varies depending on the complexity of the pattern.
+// pattern (ILIKE if caseInsensitive is true), or NOT LIKE if the negate
+// argument is true. The implementation varies depending on the complexity of
+// the pattern.
 func GetLikeOperator(
-	ctx *tree.EvalContext, input Operator, colIdx int, pattern string, negate bool,
+	ctx *tree.EvalContext,
+	input Operator,
+	colIdx int,
+	pattern string,
+	caseInsensitive bool,
+	negate bool,
 ) (Operator, error) {
-	likeOpType, pattern, err := getLikeOperatorType(pattern, negate)
+	likeOpType, pattern, err := getLikeOperatorType(pattern, caseInsensitive, negate)
 	if err != nil {
 		return nil, err
 	}
@@ -140,8 +157,18 @@ func GetLikeOperator(
 			selConstOpBase: base,
 			constArg:       pat,
 		}, nil
+	case likeContains:
+		return &selContainsBytesBytesConstOp{
+			selConstOpBase: base,
+			constArg:       pat,
+		}, nil
+	case likeContainsNegate:
+		return &selNotContainsBytesBytesConstOp{
+			selConstOpBase: base,
+			constArg:       pat,
+		}, nil
 	case likeRegexp:
-		re, err := tree.ConvertLikeToRegexp(ctx, pattern, false, '\\')
+		re, err := tree.ConvertLikeToRegexp(ctx, pattern, caseInsensitive, '\\')
 		if err != nil {
 			return nil, err
 		}
@@ -150,7 +177,7 @@ func GetLikeOperator(
 			constArg:       re,
 		}, nil
 	case likeRegexpNegate:
-		re, err := tree.ConvertLikeToRegexp(ctx, pattern, false, '\\')
+		re, err := tree.ConvertLikeToRegexp(ctx, pattern, caseInsensitive, '\\')
 		if err != nil {
 			return nil, err
 		}
@@ -168,8 +195,9 @@ func isWildcard(c byte) bool {
 }
 
 // GetLikeProjectionOperator returns a projection operator which projects the
-// result of the specified LIKE pattern, or NOT LIKE if the negate argument is
-// true. The implementation varies depending on the complexity of the pattern.
+// result of the specified LIKE pattern (ILIKE if caseInsensitive is true), or
+// NOT LIKE if the negate argument is true. The implementation varies depending
+// on the complexity of the pattern.
 func GetLikeProjectionOperator(
 	allocator *Allocator,
 	ctx *tree.EvalContext,
@@ -177,9 +205,10 @@ func GetLikeProjectionOperator(
 	colIdx int,
 	resultIdx int,
 	pattern string,
+	caseInsensitive bool,
 	negate bool,
 ) (Operator, error) {
-	likeOpType, pattern, err := getLikeOperatorType(pattern, negate)
+	likeOpType, pattern, err := getLikeOperatorType(pattern, caseInsensitive, negate)
 	if err != nil {
 		return nil, err
 	}
@@ -233,8 +262,18 @@ func GetLikeProjectionOperator(
 			projConstOpBase: base,
 			constArg:        pat,
 		}, nil
+	case likeContains:
+		return &projContainsBytesBytesConstOp{
+			projConstOpBase: base,
+			constArg:        pat,
+		}, nil
+	case likeContainsNegate:
+		return &projNotContainsBytesBytesConstOp{
+			projConstOpBase: base,
+			constArg:        pat,
+		}, nil
 	case likeRegexp:
-		re, err := tree.ConvertLikeToRegexp(ctx, pattern, false, '\\')
+		re, err := tree.ConvertLikeToRegexp(ctx, pattern, caseInsensitive, '\\')
 		if err != nil {
 			return nil, err
 		}
@@ -243,7 +282,7 @@ func GetLikeProjectionOperator(
 			constArg:        re,
 		}, nil
 	case likeRegexpNegate:
-		re, err := tree.ConvertLikeToRegexp(ctx, pattern, false, '\\')
+		re, err := tree.ConvertLikeToRegexp(ctx, pattern, caseInsensitive, '\\')
 		if err != nil {
 			return nil, err
 		}
@@ -255,3 +294,70 @@ func GetLikeProjectionOperator(
 		return nil, errors.AssertionFailedf("unsupported like op type %d", likeOpType)
 	}
 }
+
+// GetRegexpOperator returns a selection operator which applies the specified
+// POSIX regular expression (the ~ comparison, or ~* if caseInsensitive is
+// true), or its negation if the negate argument is true.
+func GetRegexpOperator(
+	ctx *tree.EvalContext,
+	input Operator,
+	colIdx int,
+	pattern string,
+	caseInsensitive bool,
+	negate bool,
+) (Operator, error) {
+	re, err := tree.CompileRegexp(ctx, pattern, caseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+	base := selConstOpBase{
+		OneInputNode: NewOneInputNode(input),
+		colIdx:       colIdx,
+	}
+	if negate {
+		return &selNotRegexpBytesBytesConstOp{
+			selConstOpBase: base,
+			constArg:       re,
+		}, nil
+	}
+	return &selRegexpBytesBytesConstOp{
+		selConstOpBase: base,
+		constArg:       re,
+	}, nil
+}
+
+// GetRegexpProjectionOperator returns a projection operator which projects the
+// result of matching against the specified POSIX regular expression (the ~
+// comparison, or ~* if caseInsensitive is true), or its negation if the negate
+// argument is true.
+func GetRegexpProjectionOperator(
+	allocator *Allocator,
+	ctx *tree.EvalContext,
+	input Operator,
+	colIdx int,
+	resultIdx int,
+	pattern string,
+	caseInsensitive bool,
+	negate bool,
+) (Operator, error) {
+	re, err := tree.CompileRegexp(ctx, pattern, caseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+	base := projConstOpBase{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    resultIdx,
+	}
+	if negate {
+		return &projNotRegexpBytesBytesConstOp{
+			projConstOpBase: base,
+			constArg:        re,
+		}, nil
+	}
+	return &projRegexpBytesBytesConstOp{
+		projConstOpBase: base,
+		constArg:        re,
+	}, nil
+}
@@ -27,10 +27,11 @@ import (
 func TestLikeOperators(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	for _, tc := range []struct {
-		pattern  string
-		negate   bool
-		tups     tuples
-		expected tuples
+		pattern         string
+		caseInsensitive bool
+		negate          bool
+		tups            tuples
+		expected        tuples
 	}{
 		{
 			pattern:  "def",
@@ -87,12 +88,66 @@ func TestLikeOperators(t *testing.T) {
 			tups:     tuples{{"abc"}, {"def"}, {"ghi"}},
 			expected: tuples{{"abc"}, {"ghi"}},
 		},
+		{
+			pattern:  `%\%%`,
+			tups:     tuples{{"abc"}, {"d%f"}, {"ghi"}},
+			expected: tuples{{"d%f"}},
+		},
+		{
+			pattern:         "DE%",
+			caseInsensitive: true,
+			tups:            tuples{{"abc"}, {"def"}, {"ghi"}},
+			expected:        tuples{{"def"}},
+		},
+		{
+			pattern:         "%E%",
+			caseInsensitive: true,
+			negate:          true,
+			tups:            tuples{{"abc"}, {"dEf"}, {"ghi"}},
+			expected:        tuples{{"abc"}, {"ghi"}},
+		},
+	} {
+		runTests(
+			t, []tuples{tc.tups}, tc.expected, orderedVerifier,
+			func(input []Operator) (Operator, error) {
+				ctx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+				return GetLikeOperator(&ctx, input[0], 0, tc.pattern, tc.caseInsensitive, tc.negate)
+			})
+	}
+}
+
+func TestRegexpOperators(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	for _, tc := range []struct {
+		pattern         string
+		caseInsensitive bool
+		negate          bool
+		tups            tuples
+		expected        tuples
+	}{
+		{
+			pattern:  "^d.f$",
+			tups:     tuples{{"abc"}, {"def"}, {"ghi"}},
+			expected: tuples{{"def"}},
+		},
+		{
+			pattern:  "[ai]",
+			negate:   true,
+			tups:     tuples{{"abc"}, {"def"}, {"ghi"}},
+			expected: tuples{{"def"}},
+		},
+		{
+			pattern:         "^D",
+			caseInsensitive: true,
+			tups:            tuples{{"abc"}, {"def"}, {"Dgh"}},
+			expected:        tuples{{"def"}, {"Dgh"}},
+		},
 	} {
 		runTests(
 			t, []tuples{tc.tups}, tc.expected, orderedVerifier,
 			func(input []Operator) (Operator, error) {
 				ctx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
-				return GetLikeOperator(&ctx, input[0], 0, tc.pattern, tc.negate)
+				return GetRegexpOperator(&ctx, input[0], 0, tc.pattern, tc.caseInsensitive, tc.negate)
 			})
 	}
 }
@@ -133,6 +188,10 @@ func BenchmarkLikeOps(b *testing.B) {
 		selConstOpBase: base,
 		constArg:       []byte(suffix),
 	}
+	containsOp := &selContainsBytesBytesConstOp{
+		selConstOpBase: base,
+		constArg:       []byte(suffix),
+	}
 	pattern := fmt.Sprintf("^%s.*%s$", prefix, suffix)
 	regexpOp := &selRegexpBytesBytesConstOp{
 		selConstOpBase: base,
@@ -145,6 +204,7 @@ func BenchmarkLikeOps(b *testing.B) {
 	}{
 		{name: "selPrefixBytesBytesConstOp", op: prefixOp},
 		{name: "selSuffixBytesBytesConstOp", op: suffixOp},
+		{name: "selContainsBytesBytesConstOp", op: containsOp},
 		{name: "selRegexpBytesBytesConstOp", op: regexpOp},
 	}
 	for _, tc := range testCases {
@@ -14,6 +14,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 )
 
 // limitOp is an operator that implements limit, returning only the first n
@@ -27,9 +28,12 @@ type limitOp struct {
 	seen uint64
 	// done is true if the limit has been reached.
 	done bool
+	// closed is true if the input has been closed.
+	closed bool
 }
 
 var _ Operator = &limitOp{}
+var _ Closer = &limitOp{}
 
 // NewLimitOp returns a new limit operator with the given limit.
 func NewLimitOp(input Operator, limit uint64) Operator {
@@ -46,6 +50,13 @@ func (c *limitOp) Init() {
 
 func (c *limitOp) Next(ctx context.Context) coldata.Batch {
 	if c.done {
+		// The input will not be read from anymore, so we release its resources
+		// right away. Note that we do so only on the call following the one that
+		// returned the last batch since that batch might be referencing the
+		// memory owned by the input.
+		if err := c.Close(); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
 		return coldata.ZeroBatch
 	}
 	bat := c.input.Next(ctx)
@@ -62,3 +73,12 @@ func (c *limitOp) Next(ctx context.Context) coldata.Batch {
 	c.seen = newSeen
 	return bat
 }
+
+// Close is part of the Closer interface.
+func (c *limitOp) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return closeInput(c.input)
+}
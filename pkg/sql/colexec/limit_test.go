@@ -11,8 +11,11 @@
 package colexec
 
 import (
+	"context"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
 
@@ -68,3 +71,54 @@ func TestLimit(t *testing.T) {
 		})
 	}
 }
+
+// closeTrackingOp is an Operator that counts the number of times Close has
+// been called on it.
+type closeTrackingOp struct {
+	OneInputNode
+	numCloses int
+}
+
+var _ Closer = &closeTrackingOp{}
+
+func (c *closeTrackingOp) Init() {
+	c.input.Init()
+}
+
+func (c *closeTrackingOp) Next(ctx context.Context) coldata.Batch {
+	return c.input.Next(ctx)
+}
+
+func (c *closeTrackingOp) Close() error {
+	c.numCloses++
+	return nil
+}
+
+func TestLimitClosesInput(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	batch.SetLength(coldata.BatchSize())
+	source := &closeTrackingOp{OneInputNode: NewOneInputNode(newFiniteBatchSource(batch, 4 /* usableCount */))}
+	limit := NewLimitOp(NewOrdinalityOp(testAllocator, source, 1 /* outputIdx */), uint64(coldata.BatchSize())+1)
+	limit.Init()
+
+	if b := limit.Next(ctx); b.Length() != coldata.BatchSize() {
+		t.Fatalf("expected %d tuples, found %d", coldata.BatchSize(), b.Length())
+	}
+	if b := limit.Next(ctx); b.Length() != 1 {
+		t.Fatalf("expected 1 tuple, found %d", b.Length())
+	}
+	// The input must not be closed while the last batch is still in use.
+	if source.numCloses != 0 {
+		t.Fatalf("expected the input to not be closed, closed %d times", source.numCloses)
+	}
+	for i := 0; i < 2; i++ {
+		if b := limit.Next(ctx); b.Length() != 0 {
+			t.Fatalf("expected a zero-length batch, found %d tuples", b.Length())
+		}
+	}
+	if source.numCloses != 1 {
+		t.Fatalf("expected the input to be closed once, closed %d times", source.numCloses)
+	}
+}
@@ -0,0 +1,452 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/span"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/errors"
+)
+
+// lookupJoinOp is the Operator implementation of a lookup join (the
+// JoinReader processor with non-empty lookup columns). It works as follows:
+// - every input batch is mapped onto the index spans (one span per distinct
+//   key of the lookup columns, the tuples with NULL lookup values are
+//   skipped),
+// - the spans are looked up using a cFetcher and all of the looked up rows
+//   are buffered (the lookup columns must form a key, so there is at most one
+//   looked up row for every input tuple),
+// - every looked up row is matched to the input tuples using the key of its
+//   values in the lookup columns of the index,
+// - the matches are stitched into the output batches in the order of the
+//   input tuples.
+// Only INNER and LEFT OUTER joins without an ON expression are supported.
+// The output columns are the input columns followed by the columns of the
+// table (only the columns needed by the post-processing are populated).
+type lookupJoinOp struct {
+	OneInputNode
+
+	allocator *Allocator
+	flowCtx   *execinfra.FlowCtx
+	joinType  sqlbase.JoinType
+
+	inputTypes     []types.T
+	inputPhysTypes []coltypes.T
+	tableTypes     []types.T
+	tablePhysTypes []coltypes.T
+	// neededTableCols are the indices of the table columns that are fetched.
+	neededTableCols []int
+	lookupCols      []uint32
+	// lookupIndexCols contains the indices of the table columns that
+	// correspond to the lookup columns.
+	lookupIndexCols []int
+
+	fetcher     *cFetcher
+	spanBuilder *span.Builder
+	init        bool
+
+	// Fields below describe the current input batch.
+
+	batch coldata.Batch
+	// keyToInputRowIdxs maps the key of every span to the indices of the input
+	// tuples that map onto that span. Note that the indices are positions in
+	// the selection vector if the batch has one.
+	keyToInputRowIdxs map[string][]int
+	// matches contains the indices of the matched looked up rows for every
+	// input tuple.
+	matches [][]uint64
+	// lookedUpRows buffers all of the rows looked up for the current batch.
+	lookedUpRows *bufferedBatch
+	// emitState describes the progress of emitting the current batch.
+	emitState struct {
+		inputRowIdx int
+		matchIdx    int
+	}
+
+	scratch struct {
+		spans    roachpb.Spans
+		keyRow   sqlbase.EncDatumRow
+		probeIdx []int
+		buildIdx []int
+		// unmatched[i] indicates whether ith output tuple doesn't have a match
+		// (for LEFT OUTER join).
+		unmatched []bool
+		da        sqlbase.DatumAlloc
+	}
+	output coldata.Batch
+}
+
+var _ Operator = &lookupJoinOp{}
+var _ execinfrapb.MetadataSource = &lookupJoinOp{}
+
+// newLookupJoinOp returns a new lookupJoinOp for the given spec. post is
+// used only to determine the table columns that need to be fetched.
+func newLookupJoinOp(
+	allocator *Allocator,
+	flowCtx *execinfra.FlowCtx,
+	input Operator,
+	inputTypes []types.T,
+	spec *execinfrapb.JoinReaderSpec,
+	post *execinfrapb.PostProcessSpec,
+) (*lookupJoinOp, error) {
+	if len(spec.LookupColumns) == 0 {
+		return nil, errors.AssertionFailedf("lookup join without lookup columns")
+	}
+	if !spec.OnExpr.Empty() {
+		return nil, errors.AssertionFailedf("lookup join with ON expression")
+	}
+	if !spec.LookupColumnsAreKey {
+		return nil, errors.AssertionFailedf("lookup join on non-key columns")
+	}
+	switch spec.Type {
+	case sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_OUTER:
+	default:
+		return nil, errors.AssertionFailedf("unsupported lookup join type %s", spec.Type)
+	}
+	inputPhysTypes, err := typeconv.FromColumnTypes(inputTypes)
+	if err != nil {
+		return nil, err
+	}
+	returnMutations := spec.Visibility == execinfrapb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
+	tableTypes := spec.Table.ColumnTypesWithMutations(returnMutations)
+	tablePhysTypes, err := typeconv.FromColumnTypes(tableTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	index, isSecondary, err := spec.Table.FindIndexByIndexIdx(int(spec.IndexIdx))
+	if err != nil {
+		return nil, err
+	}
+	columnIDs, _ := index.FullColumnIDs()
+	if len(spec.LookupColumns) > len(columnIDs) {
+		return nil, errors.Errorf(
+			"%d lookup columns specified, expecting at most %d", len(spec.LookupColumns), len(columnIDs),
+		)
+	}
+	colIdxMap := spec.Table.ColumnIdxMapWithMutations(returnMutations)
+	lookupIndexCols := make([]int, len(spec.LookupColumns))
+	for i := range lookupIndexCols {
+		lookupIndexCols[i] = colIdxMap[columnIDs[i]]
+	}
+
+	// Determine the table columns that are needed by the post-processing. The
+	// lookup columns of the index are always needed in order to match the
+	// looked up rows to the input tuples.
+	helper := execinfra.ProcOutputHelper{}
+	if err := helper.Init(
+		post, append(append([]types.T(nil), inputTypes...), tableTypes...), flowCtx.NewEvalCtx(), nil, /* output */
+	); err != nil {
+		return nil, err
+	}
+	neededCols := helper.NeededColumns()
+	neededTableColsSet := util.MakeFastIntSet()
+	for i, ok := neededCols.Next(len(inputTypes)); ok; i, ok = neededCols.Next(i + 1) {
+		neededTableColsSet.Add(i - len(inputTypes))
+	}
+	if isSecondary {
+		indexCols := util.MakeFastIntSet()
+		if err := index.RunOverAllColumns(func(id sqlbase.ColumnID) error {
+			indexCols.Add(colIdxMap[id])
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if !neededTableColsSet.SubsetOf(indexCols) {
+			return nil, errors.Errorf("joinreader index does not cover all columns")
+		}
+	}
+	for _, colIdx := range lookupIndexCols {
+		neededTableColsSet.Add(colIdx)
+	}
+
+	fetcher := cFetcher{}
+	if _, _, err := initCRowFetcher(
+		allocator, &fetcher, scanBatchSize(flowCtx), &spec.Table, int(spec.IndexIdx), colIdxMap, false, /* reverse */
+		neededTableColsSet, false /* isCheck */, spec.Visibility, spec.LockingStrength,
+	); err != nil {
+		return nil, err
+	}
+	spanBuilder := span.MakeBuilder(&spec.Table, index)
+	spanBuilder.SetNeededColumns(neededTableColsSet)
+
+	return &lookupJoinOp{
+		OneInputNode:      NewOneInputNode(input),
+		allocator:         allocator,
+		flowCtx:           flowCtx,
+		joinType:          spec.Type,
+		inputTypes:        inputTypes,
+		inputPhysTypes:    inputPhysTypes,
+		tableTypes:        tableTypes,
+		tablePhysTypes:    tablePhysTypes,
+		neededTableCols:   neededTableColsSet.Ordered(),
+		lookupCols:        spec.LookupColumns,
+		lookupIndexCols:   lookupIndexCols,
+		fetcher:           &fetcher,
+		spanBuilder:       spanBuilder,
+		keyToInputRowIdxs: make(map[string][]int),
+	}, nil
+}
+
+func (j *lookupJoinOp) Init() {
+	j.init = true
+	j.input.Init()
+	j.lookedUpRows = newBufferedBatch(j.allocator, j.tablePhysTypes, 0 /* initialSize */)
+	j.output = j.allocator.NewMemBatch(append(append([]coltypes.T(nil), j.inputPhysTypes...), j.tablePhysTypes...))
+	j.scratch.keyRow = make(sqlbase.EncDatumRow, len(j.lookupCols))
+	j.scratch.probeIdx = make([]int, coldata.BatchSize())
+	j.scratch.buildIdx = make([]int, coldata.BatchSize())
+	j.scratch.unmatched = make([]bool, coldata.BatchSize())
+}
+
+func (j *lookupJoinOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		if j.batch == nil || j.emitState.inputRowIdx == int(j.batch.Length()) {
+			j.batch = j.input.Next(ctx)
+			if j.batch.Length() == 0 {
+				return coldata.ZeroBatch
+			}
+			j.performLookup(ctx)
+		}
+		if n := j.emit(); n > 0 {
+			return j.output
+		}
+	}
+}
+
+// performLookup looks up all of the rows that match the tuples of the
+// current input batch.
+func (j *lookupJoinOp) performLookup(ctx context.Context) {
+	n := int(j.batch.Length())
+	sel := j.batch.Selection()
+	for key := range j.keyToInputRowIdxs {
+		delete(j.keyToInputRowIdxs, key)
+	}
+	if cap(j.matches) < n {
+		j.matches = make([][]uint64, n)
+	}
+	j.matches = j.matches[:n]
+	for i := range j.matches {
+		j.matches[i] = j.matches[i][:0]
+	}
+	j.emitState.inputRowIdx = 0
+	j.emitState.matchIdx = 0
+	j.allocator.PerformOperation(j.lookedUpRows.ColVecs(), j.lookedUpRows.reset)
+
+	spans := j.scratch.spans[:0]
+	for i := 0; i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		containsNull := false
+		for k, colIdx := range j.lookupCols {
+			vec := j.batch.ColVec(int(colIdx))
+			if vec.MaybeHasNulls() && vec.Nulls().NullAt(uint16(rowIdx)) {
+				containsNull = true
+				break
+			}
+			j.scratch.keyRow[k] = sqlbase.DatumToEncDatum(
+				&j.inputTypes[colIdx],
+				PhysicalTypeColElemToDatum(vec, uint16(rowIdx), &j.scratch.da, &j.inputTypes[colIdx]),
+			)
+		}
+		if containsNull {
+			// The tuples with NULL lookup values cannot have any matches.
+			continue
+		}
+		s, containsNull, err := j.spanBuilder.SpanFromEncDatums(j.scratch.keyRow, len(j.lookupCols))
+		if err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		key := string(s.Key)
+		inputRowIdxs, ok := j.keyToInputRowIdxs[key]
+		if !ok {
+			spans = j.spanBuilder.MaybeSplitSpanIntoSeparateFamilies(spans, s, len(j.lookupCols), containsNull)
+		}
+		j.keyToInputRowIdxs[key] = append(inputRowIdxs, i)
+	}
+	j.scratch.spans = spans
+	if len(spans) == 0 {
+		// None of the input tuples can have a match.
+		return
+	}
+	// The order of the looked up rows doesn't matter since the output is
+	// emitted in the order of the input tuples, so the spans are sorted in
+	// order to let the fetcher perform the lookups in a single pass. There is
+	// at most one result per lookup, so the fetcher doesn't limit the batches
+	// and parallelizes the key lookups it performs.
+	sort.Sort(spans)
+	if err := j.fetcher.StartScan(
+		ctx, j.flowCtx.Txn, spans, false /* limitBatches */, 0 /* limitHint */, j.flowCtx.TraceKV,
+	); err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	for {
+		fetched, err := j.fetcher.NextBatch(ctx)
+		if err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		fetchedLen := fetched.Length()
+		if fetchedLen == 0 {
+			return
+		}
+		for rowIdx := uint16(0); rowIdx < fetchedLen; rowIdx++ {
+			for k, colIdx := range j.lookupIndexCols {
+				j.scratch.keyRow[k] = sqlbase.DatumToEncDatum(
+					&j.tableTypes[colIdx],
+					PhysicalTypeColElemToDatum(fetched.ColVec(colIdx), rowIdx, &j.scratch.da, &j.tableTypes[colIdx]),
+				)
+			}
+			s, _, err := j.spanBuilder.SpanFromEncDatums(j.scratch.keyRow, len(j.lookupCols))
+			if err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+			lookedUpRowIdx := j.lookedUpRows.length + uint64(rowIdx)
+			for _, inputRowIdx := range j.keyToInputRowIdxs[string(s.Key)] {
+				j.matches[inputRowIdx] = append(j.matches[inputRowIdx], lookedUpRowIdx)
+			}
+		}
+		j.allocator.PerformOperation(j.lookedUpRows.ColVecs(), func() {
+			for _, colIdx := range j.neededTableCols {
+				j.lookedUpRows.ColVec(colIdx).Append(
+					coldata.SliceArgs{
+						ColType:   j.tablePhysTypes[colIdx],
+						Src:       fetched.ColVec(colIdx),
+						DestIdx:   j.lookedUpRows.length,
+						SrcEndIdx: uint64(fetchedLen),
+					},
+				)
+			}
+		})
+		j.lookedUpRows.length += uint64(fetchedLen)
+	}
+}
+
+// emit populates the output batch with the next joined tuples of the current
+// input batch and returns the number of tuples emitted.
+func (j *lookupJoinOp) emit() uint16 {
+	var (
+		sel        = j.batch.Selection()
+		n          = int(j.batch.Length())
+		outputSize uint16
+		hasUnmatch bool
+	)
+	for j.emitState.inputRowIdx < n && outputSize < coldata.BatchSize() {
+		rowIdx := j.emitState.inputRowIdx
+		if sel != nil {
+			rowIdx = sel[rowIdx]
+		}
+		matches := j.matches[j.emitState.inputRowIdx]
+		if len(matches) == 0 {
+			if j.joinType == sqlbase.JoinType_LEFT_OUTER {
+				j.scratch.probeIdx[outputSize] = rowIdx
+				j.scratch.buildIdx[outputSize] = 0
+				j.scratch.unmatched[outputSize] = true
+				hasUnmatch = true
+				outputSize++
+			}
+			j.emitState.inputRowIdx++
+			continue
+		}
+		for ; j.emitState.matchIdx < len(matches) && outputSize < coldata.BatchSize(); j.emitState.matchIdx++ {
+			j.scratch.probeIdx[outputSize] = rowIdx
+			j.scratch.buildIdx[outputSize] = int(matches[j.emitState.matchIdx])
+			j.scratch.unmatched[outputSize] = false
+			outputSize++
+		}
+		if j.emitState.matchIdx == len(matches) {
+			j.emitState.inputRowIdx++
+			j.emitState.matchIdx = 0
+		}
+	}
+	if outputSize == 0 {
+		return 0
+	}
+
+	j.output.ResetInternalBatch()
+	numInputCols := len(j.inputTypes)
+	j.allocator.PerformOperation(j.output.ColVecs(), func() {
+		for colIdx, typ := range j.inputPhysTypes {
+			j.output.ColVec(colIdx).Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						ColType:   typ,
+						Src:       j.batch.ColVec(colIdx),
+						Sel:       j.scratch.probeIdx,
+						SrcEndIdx: uint64(outputSize),
+					},
+				},
+			)
+		}
+		// If no rows have been looked up, then there is nothing to copy. The
+		// nulls will be set below.
+		if j.lookedUpRows.length > 0 {
+			for _, colIdx := range j.neededTableCols {
+				// Note that if for some index i, unmatched[i] is true, then
+				// buildIdx[i] == 0 which will copy the garbage zeroth looked up row,
+				// but we will set the NULL value below.
+				j.output.ColVec(numInputCols + colIdx).Copy(
+					coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							ColType:   j.tablePhysTypes[colIdx],
+							Src:       j.lookedUpRows.ColVec(colIdx),
+							Sel:       j.scratch.buildIdx,
+							SrcEndIdx: uint64(outputSize),
+						},
+					},
+				)
+			}
+		}
+	})
+	if hasUnmatch {
+		for _, colIdx := range j.neededTableCols {
+			nulls := j.output.ColVec(numInputCols + colIdx).Nulls()
+			for i, unmatched := range j.scratch.unmatched[:outputSize] {
+				if unmatched {
+					nulls.SetNull(uint16(i))
+				}
+			}
+		}
+	}
+	j.output.SetLength(outputSize)
+	return outputSize
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (j *lookupJoinOp) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	if !j.init {
+		return nil
+	}
+	var trailingMeta []execinfrapb.ProducerMetadata
+	if !j.flowCtx.Local {
+		ranges := execinfra.MisplannedRanges(ctx, j.fetcher.GetRangesInfo(), j.flowCtx.NodeID)
+		if ranges != nil {
+			trailingMeta = append(trailingMeta, execinfrapb.ProducerMetadata{Ranges: ranges})
+		}
+	}
+	if tfs := execinfra.GetLeafTxnFinalState(ctx, j.flowCtx.Txn); tfs != nil {
+		trailingMeta = append(trailingMeta, execinfrapb.ProducerMetadata{LeafTxnFinalState: tfs})
+	}
+	return trailingMeta
+}
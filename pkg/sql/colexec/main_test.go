@@ -13,6 +13,7 @@ package colexec
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"testing"
 
@@ -51,6 +52,12 @@ func TestMain(m *testing.M) {
 		randomBatchSize := generateBatchSize()
 		fmt.Printf("coldata.BatchSize() is set to %d\n", randomBatchSize)
 		coldata.SetBatchSizeForTests(randomBatchSize)
+		// Pick a random seed for the hash tables so that different runs exercise
+		// different bucket collisions. A particular run can be reproduced by
+		// setting COCKROACH_HASH_TABLE_SEED to the printed value.
+		seed := generateHashTableSeed()
+		fmt.Printf("hash table seed is set to %d\n", seed)
+		setHashTableSeedForTests(seed)
 		return m.Run()
 	}())
 }
@@ -65,3 +72,16 @@ func generateBatchSize() uint16 {
 	}
 	return coldata.BatchSize()
 }
+
+func generateHashTableSeed() uint64 {
+	if seed := envutil.EnvOrDefaultInt64("COCKROACH_HASH_TABLE_SEED", 0); seed != 0 {
+		return uint64(seed)
+	}
+	randomizeSeed := envutil.EnvOrDefaultBool("COCKROACH_RANDOMIZE_HASH_TABLE_SEED", true)
+	if randomizeSeed {
+		rng, _ := randutil.NewPseudoRand()
+		// Zero is reserved to indicate that no seed was specified above.
+		return uint64(rng.Int63n(math.MaxInt64-1) + 1)
+	}
+	return defaultHashTableSeed
+}
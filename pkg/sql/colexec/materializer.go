@@ -28,6 +28,9 @@ type Materializer struct {
 	NonExplainable
 
 	input Operator
+	// processorID is the ID of the processor that the Materializer corresponds
+	// to. It is used to annotate the errors.
+	processorID int32
 
 	da sqlbase.DatumAlloc
 
@@ -80,8 +83,9 @@ func NewMaterializer(
 	cancelFlow func() context.CancelFunc,
 ) (*Materializer, error) {
 	m := &Materializer{
-		input: input,
-		row:   make(sqlbase.EncDatumRow, len(typs)),
+		input:       input,
+		processorID: processorID,
+		row:         make(sqlbase.EncDatumRow, len(typs)),
 	}
 
 	if err := m.ProcessorBase.Init(
@@ -94,10 +98,7 @@ func NewMaterializer(
 		nil, /* memMonitor */
 		execinfra.ProcStateOpts{
 			TrailingMetaCallback: func(ctx context.Context) []execinfrapb.ProducerMetadata {
-				var trailingMeta []execinfrapb.ProducerMetadata
-				for _, src := range metadataSourcesQueue {
-					trailingMeta = append(trailingMeta, src.DrainMeta(ctx)...)
-				}
+				trailingMeta := execinfrapb.MetadataSources(metadataSourcesQueue).DrainMeta(ctx)
 				m.InternalClose()
 				return trailingMeta
 			},
@@ -158,14 +159,14 @@ func (m *Materializer) next() (sqlbase.EncDatumRow, *execinfrapb.ProducerMetadat
 
 		rowIdx := m.curIdx
 		if sel != nil {
-			rowIdx = sel[m.curIdx]
+			rowIdx = uint16(sel[m.curIdx])
 		}
 		m.curIdx++
 
 		typs := m.OutputTypes()
 		for colIdx := 0; colIdx < len(typs); colIdx++ {
 			col := m.batch.ColVec(colIdx)
-			m.row[colIdx].Datum = PhysicalTypeColElemToDatum(col, rowIdx, m.da, &typs[colIdx])
+			m.row[colIdx].Datum = PhysicalTypeColElemToDatum(col, rowIdx, &m.da, &typs[colIdx])
 		}
 		return m.ProcessRowHelper(m.row), nil
 	}
@@ -175,6 +176,11 @@ func (m *Materializer) next() (sqlbase.EncDatumRow, *execinfrapb.ProducerMetadat
 // Next is part of the execinfra.RowSource interface.
 func (m *Materializer) Next() (sqlbase.EncDatumRow, *execinfrapb.ProducerMetadata) {
 	if err := execerror.CatchVectorizedRuntimeError(m.nextAdapter); err != nil {
+		batchLength, batchWidth := -1, 0
+		if m.batch != nil {
+			batchLength, batchWidth = int(m.batch.Length()), m.batch.Width()
+		}
+		err = execerror.WithOperatorContext(err, m.input, m.processorID, batchLength, batchWidth)
 		m.MoveToDraining(err)
 		return nil, m.DrainHelper()
 	}
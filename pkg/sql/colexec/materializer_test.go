@@ -16,7 +16,10 @@ import (
 	"unsafe"
 
 	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -24,6 +27,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
 )
 
 func TestColumnarizeMaterialize(t *testing.T) {
@@ -88,6 +93,60 @@ func TestColumnarizeMaterialize(t *testing.T) {
 	}
 }
 
+// TestMaterializerAnnotatesInternalErrors verifies that the unexpected errors
+// that occur in the input of the Materializer are annotated with the context
+// in which they occurred.
+func TestMaterializerAnnotatesInternalErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	batch.SetLength(1)
+	returnedBatch := false
+	input := &CallbackOperator{NextCb: func(context.Context) coldata.Batch {
+		if !returnedBatch {
+			returnedBatch = true
+			return batch
+		}
+		execerror.VectorizedInternalPanic("boom")
+		// This code is unreachable, but the compiler cannot infer that.
+		return nil
+	}}
+	m, err := NewMaterializer(
+		flowCtx,
+		3, /* processorID */
+		input,
+		[]types.T{*types.Int},
+		&execinfrapb.PostProcessSpec{},
+		nil, /* output */
+		nil, /* metadataSourcesQueue */
+		nil, /* outputStatsToTrace */
+		nil, /* cancelFlow */
+	)
+	require.NoError(t, err)
+	m.Start(ctx)
+
+	row, meta := m.Next()
+	require.NotNil(t, row)
+	require.Nil(t, meta)
+	_, meta = m.Next()
+	require.NotNil(t, meta)
+	require.Error(t, meta.Err)
+	require.True(t, errors.HasAssertionFailure(meta.Err), meta.Err)
+	details := errors.FlattenDetails(meta.Err)
+	require.Contains(t, details, "while executing *colexec.CallbackOperator of processor 3")
+	require.Contains(t, details, "last batch had 1 tuples and 1 columns")
+	require.Contains(t, details, "TestMaterializerAnnotatesInternalErrors")
+}
+
 func TestMaterializeTypes(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -422,7 +422,7 @@ func (o *mergeJoinBase) resetBuilderCrossProductState() {
 // source. This needs to happen when a group starts at the end of an input
 // batch and can continue into the following batches.
 func (o *mergeJoinBase) appendToBufferedGroup(
-	input *mergeJoinInput, batch coldata.Batch, sel []uint16, groupStartIdx int, groupLength int,
+	input *mergeJoinInput, batch coldata.Batch, sel []int, groupStartIdx int, groupLength int,
 ) {
 	bufferedGroup := o.proberState.lBufferedGroup
 	if input == &o.right {
@@ -521,7 +521,7 @@ func (o *mergeJoinBase) completeBufferedGroup(
 	// know that we are in the same group and, thus, the row is not distinct,
 	// regardless of what the distincter outputs.
 	loopStartIndex := 1
-	var sel []uint16
+	var sel []int
 	for !isBufferedGroupComplete {
 		// Note that we're not resetting the distincter on every loop iteration
 		// because if we're doing the second, third, etc, iteration, then all the
@@ -2130,3 +2130,46 @@ func BenchmarkMergeJoiner(b *testing.B) {
 	}
 
 }
+
+// TestMergeJoinerSupportedSpecs verifies that only the merge joins that the
+// vectorized merge joiner can execute are planned natively (the other ones get
+// a wrapped row-by-row merge joiner).
+func TestMergeJoinerSupportedSpecs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	for _, tc := range []struct {
+		joinType     sqlbase.JoinType
+		nullEquality bool
+		supported    bool
+	}{
+		{joinType: sqlbase.InnerJoin, supported: true},
+		{joinType: sqlbase.FullOuterJoin, supported: true},
+		{joinType: sqlbase.LeftSemiJoin, supported: true},
+		{joinType: sqlbase.LeftAntiJoin, supported: true},
+		{joinType: sqlbase.IntersectAllJoin, nullEquality: true},
+		{joinType: sqlbase.ExceptAllJoin, nullEquality: true},
+		{joinType: sqlbase.LeftSemiJoin, nullEquality: true},
+	} {
+		t.Run(fmt.Sprintf("%s/nullEquality=%t", tc.joinType, tc.nullEquality), func(t *testing.T) {
+			ordering := execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}}
+			spec := &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{
+					{ColumnTypes: typeconv.ToColumnTypes([]coltypes.T{coltypes.Int64})},
+					{ColumnTypes: typeconv.ToColumnTypes([]coltypes.T{coltypes.Int64})},
+				},
+				Core: execinfrapb.ProcessorCoreUnion{
+					MergeJoiner: &execinfrapb.MergeJoinerSpec{
+						LeftOrdering:  ordering,
+						RightOrdering: ordering,
+						Type:          tc.joinType,
+						NullEquality:  tc.nullEquality,
+					},
+				},
+			}
+			supported, err := isSupported(spec)
+			require.Equal(t, tc.supported, supported)
+			if !tc.supported {
+				require.Error(t, err)
+			}
+		})
+	}
+}
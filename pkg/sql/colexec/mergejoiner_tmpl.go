@@ -652,7 +652,7 @@ func _LEFT_SWITCH(_JOIN_TYPE joinTypeInfo, _HAS_SELECTION bool, _HAS_NULLS bool)
 				// Repeat each row numRepeats times.
 				srcStartIdx = o.builderState.left.curSrcStartIdx
 				// {{ if _HAS_SELECTION }}
-				srcStartIdx = int(sel[srcStartIdx])
+				srcStartIdx = sel[srcStartIdx]
 				// {{ end }}
 
 				repeatsLeft := leftGroup.numRepeats - o.builderState.left.numRepeatsIdx
@@ -828,12 +828,12 @@ func _RIGHT_SWITCH(_JOIN_TYPE joinTypeInfo, _HAS_SELECTION bool, _HAS_NULLS bool
 					if toAppend == 1 {
 						// {{ if _HAS_SELECTION }}
 						// {{ if _HAS_NULLS }}
-						if src.Nulls().NullAt(sel[o.builderState.right.curSrcStartIdx]) {
+						if src.Nulls().NullAt(uint16(sel[o.builderState.right.curSrcStartIdx])) {
 							out.Nulls().SetNull(uint16(outStartIdx))
 						} else
 						// {{ end }}
 						{
-							v := execgen.UNSAFEGET(srcCol, int(sel[o.builderState.right.curSrcStartIdx]))
+							v := execgen.UNSAFEGET(srcCol, sel[o.builderState.right.curSrcStartIdx])
 							execgen.SET(outCol, outStartIdx, v)
 						}
 						// {{ else }}
@@ -0,0 +1,215 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+)
+
+// multiReaderBuffer materializes its input once and serves all of the tuples
+// to several readers, each of which reads the whole input independently of
+// the others. This allows for a subquery that is referenced several times
+// (for example, a CTE) to be executed only once.
+//
+// The input is read lazily when the reader that is the furthest ahead needs
+// more tuples, and the batches are buffered in memory until the memory limit
+// is reached. All of the remaining batches are spilled to the partitioner
+// with one partition per reader since a partition can be read only once.
+// Note that the partitions can be dequeued from only after all of the
+// batches have been enqueued, so once a reader reaches the spilled batches,
+// the input is fully consumed.
+type multiReaderBuffer struct {
+	allocator   *Allocator
+	input       Operator
+	typs        []coltypes.T
+	memoryLimit int64
+	partitioner Partitioner
+	initStatus  OperatorInitStatus
+
+	// batches are the batches buffered in memory in the order in which they
+	// were read from the input.
+	batches []coldata.Batch
+	// spilled indicates whether the memory limit has been reached, so all of
+	// the following batches from the input are enqueued into the partitioner.
+	spilled bool
+	// numSpilledBatches is the number of batches that have been enqueued into
+	// every partition.
+	numSpilledBatches int
+	// inputDone indicates whether the input has been fully consumed.
+	inputDone bool
+
+	numReaders       int
+	numClosedReaders int
+	closed           bool
+}
+
+// NewMultiReaderBuffer returns numReaders operators each of which returns all
+// of the tuples of input (with the columns of typs) in the same order. The
+// input is read only once. Once the memory registered with allocator exceeds
+// memoryLimit, the remaining tuples are spilled to partitioner, which is
+// closed once all of the readers have been fully consumed or closed.
+func NewMultiReaderBuffer(
+	allocator *Allocator,
+	input Operator,
+	typs []coltypes.T,
+	numReaders int,
+	memoryLimit int64,
+	partitioner Partitioner,
+) []Operator {
+	buf := &multiReaderBuffer{
+		allocator:   allocator,
+		input:       input,
+		typs:        typs,
+		memoryLimit: memoryLimit,
+		partitioner: partitioner,
+		numReaders:  numReaders,
+	}
+	readers := make([]Operator, numReaders)
+	for i := range readers {
+		readers[i] = &multiReaderBufferReader{
+			OneInputNode: NewOneInputNode(input),
+			buf:          buf,
+			readerIdx:    i,
+		}
+	}
+	return readers
+}
+
+func (b *multiReaderBuffer) init() {
+	// All of the readers share the input, so we need to make sure that Init is
+	// called on it only once.
+	if b.initStatus == OperatorNotInitialized {
+		b.input.Init()
+		b.initStatus = OperatorInitialized
+	}
+}
+
+// readInput reads the next batch from the input and either buffers it in
+// memory or enqueues it into all of the partitions.
+func (b *multiReaderBuffer) readInput(ctx context.Context) {
+	batch := b.input.Next(ctx)
+	if batch.Length() == 0 {
+		b.inputDone = true
+		return
+	}
+	if b.spilled {
+		for i := 0; i < b.numReaders; i++ {
+			if err := b.partitioner.Enqueue(i, batch); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+		}
+		b.numSpilledBatches++
+		return
+	}
+	batchCopy := b.allocator.CloneBatch(batch)
+	b.batches = append(b.batches, batchCopy)
+	if b.allocator.Used() >= b.memoryLimit {
+		b.spilled = true
+	}
+}
+
+// closeReader is called when one of the readers will not be read from
+// anymore. Once all of the readers are closed, the partitioner is closed.
+func (b *multiReaderBuffer) closeReader() error {
+	b.numClosedReaders++
+	if b.numClosedReaders < b.numReaders || b.closed {
+		return nil
+	}
+	b.closed = true
+	return b.partitioner.Close()
+}
+
+// multiReaderBufferReader is a single reader of the multiReaderBuffer.
+type multiReaderBufferReader struct {
+	OneInputNode
+	buf       *multiReaderBuffer
+	readerIdx int
+
+	// nextBatchIdx is the index of the next in-memory batch to be returned.
+	nextBatchIdx int
+	// numDequeuedBatches is the number of batches that have been dequeued
+	// from the partition of this reader.
+	numDequeuedBatches int
+	output             coldata.Batch
+	closed             bool
+}
+
+var _ Operator = &multiReaderBufferReader{}
+var _ Closer = &multiReaderBufferReader{}
+
+func (r *multiReaderBufferReader) Init() {
+	r.buf.init()
+	r.output = r.buf.allocator.NewMemBatch(r.buf.typs)
+}
+
+func (r *multiReaderBufferReader) Next(ctx context.Context) coldata.Batch {
+	if r.closed {
+		return coldata.ZeroBatch
+	}
+	for r.nextBatchIdx == len(r.buf.batches) && !r.buf.spilled && !r.buf.inputDone {
+		r.buf.readInput(ctx)
+	}
+	if r.nextBatchIdx < len(r.buf.batches) {
+		// The buffered batches are shared between all of the readers, so we
+		// need to copy the batch into the output since the downstream operators
+		// are allowed to modify it.
+		batchToCopy := r.buf.batches[r.nextBatchIdx]
+		r.nextBatchIdx++
+		r.output.ResetInternalBatch()
+		r.buf.allocator.PerformOperation(r.output.ColVecs(), func() {
+			for i, vec := range r.output.ColVecs() {
+				vec.Copy(
+					coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							ColType:     r.buf.typs[i],
+							Src:         batchToCopy.ColVec(i),
+							SrcStartIdx: 0,
+							SrcEndIdx:   uint64(batchToCopy.Length()),
+						},
+					},
+				)
+			}
+		})
+		r.output.SetLength(batchToCopy.Length())
+		return r.output
+	}
+	// All of the in-memory batches have been returned, so the remaining batches
+	// (if any) have been spilled. The partition can be dequeued from only after
+	// all of the batches have been enqueued into it.
+	for !r.buf.inputDone {
+		r.buf.readInput(ctx)
+	}
+	if r.numDequeuedBatches == r.buf.numSpilledBatches {
+		if err := r.Close(); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		return coldata.ZeroBatch
+	}
+	if err := r.buf.partitioner.Dequeue(r.readerIdx, r.output); err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	r.numDequeuedBatches++
+	return r.output
+}
+
+// Close is part of the Closer interface. The reader returns only zero-length
+// batches after it has been closed.
+func (r *multiReaderBufferReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.buf.closeReader()
+}
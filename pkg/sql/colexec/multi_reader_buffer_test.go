@@ -0,0 +1,83 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiReaderBuffer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	typs := []coltypes.T{coltypes.Int64, coltypes.Bytes}
+	var input tuples
+	for i := 0; i < 20; i++ {
+		input = append(input, tuple{int64(i), fmt.Sprintf("%d", i)})
+	}
+
+	const numReaders = 3
+	for _, spill := range []bool{false, true} {
+		t.Run(fmt.Sprintf("spill=%t", spill), func(t *testing.T) {
+			memAcc := testMemMonitor.MakeBoundAccount()
+			defer memAcc.Close(ctx)
+			allocator := NewAllocator(ctx, &memAcc)
+			memoryLimit := int64(math.MaxInt64)
+			if spill {
+				// Spill right after the first batch is buffered.
+				memoryLimit = 1
+			}
+			readers := NewMultiReaderBuffer(
+				allocator, newOpTestInput(3 /* batchSize */, input, typs), typs, numReaders,
+				memoryLimit, newDummyPartitioner(testAllocator, typs),
+			)
+			actual := make([]tuples, numReaders)
+			done := make([]bool, numReaders)
+			for _, r := range readers {
+				r.Init()
+			}
+			// Read from the readers in random order so that they are at different
+			// positions.
+			for numDone := 0; numDone < numReaders; {
+				readerIdx := rng.Intn(numReaders)
+				if done[readerIdx] {
+					continue
+				}
+				b := readers[readerIdx].Next(ctx)
+				if b.Length() == 0 {
+					done[readerIdx] = true
+					numDone++
+					continue
+				}
+				for i := uint16(0); i < b.Length(); i++ {
+					actual[readerIdx] = append(actual[readerIdx], getTupleFromBatch(b, i))
+				}
+				// Modifying the returned batch must not affect the other readers.
+				b.SetSelection(true)
+				b.SetLength(0)
+			}
+			for readerIdx := range actual {
+				require.NoError(t, assertTuplesOrderedEqual(input, actual[readerIdx]))
+			}
+			buf := readers[0].(*multiReaderBufferReader).buf
+			require.Equal(t, spill, buf.numSpilledBatches > 0)
+			require.True(t, buf.closed)
+		})
+	}
+}
@@ -28,6 +28,7 @@ type offsetOp struct {
 }
 
 var _ Operator = &offsetOp{}
+var _ Closer = &offsetOp{}
 
 // NewOffsetOp returns a new offset operator with the given offset.
 func NewOffsetOp(input Operator, offset uint64) Operator {
@@ -64,7 +65,7 @@ func (c *offsetOp) Next(ctx context.Context) coldata.Batch {
 				bat.SetSelection(true)
 				sel = bat.Selection()[:delta] // slice for bounds check elimination
 				for i := range sel {
-					sel[i] = outputStartIdx + uint16(i)
+					sel[i] = int(outputStartIdx) + i
 				}
 			}
 			bat.SetLength(uint16(delta))
@@ -78,6 +79,11 @@ func (c *offsetOp) Next(ctx context.Context) coldata.Batch {
 
 // Reset resets the offsetOp for another run. Primarily used for
 // benchmarks.
+// Close is part of the Closer interface.
+func (c *offsetOp) Close() error {
+	return closeInput(c.input)
+}
+
 func (c *offsetOp) Reset() {
 	c.seen = 0
 }
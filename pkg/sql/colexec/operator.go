@@ -162,6 +162,25 @@ type resettableOperator interface {
 	resetter
 }
 
+// Closer is an interface that operators which hold onto resources that are
+// not released by simply exhausting the operator (like files on disk) can
+// implement. Close might be called before the operator has been exhausted (for
+// example, once the limit above the operator has been reached), and it must be
+// safe to call it multiple times.
+type Closer interface {
+	Close() error
+}
+
+// closeInput closes input if it implements the Closer interface. Operators that
+// simply pass the batches from their input through can use it to implement the
+// Closer interface themselves.
+func closeInput(input Operator) error {
+	if c, ok := input.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 type noopOperator struct {
 	OneInputNode
 	NonExplainable
@@ -73,14 +73,14 @@ type OrderedSynchronizer struct {
 	// inputBatches stores the current batch for each input.
 	inputBatches []coldata.Batch
 	// inputIndices stores the current index into each input batch.
-	inputIndices []uint16
+	inputIndices []int
 	// heap is a min heap which stores indices into inputBatches. The "current
 	// value" of ith input batch is the tuple at inputIndices[i] position of
 	// inputBatches[i] batch. If an input is fully exhausted, it will be removed
 	// from heap.
 	heap []int
 	// comparators stores one comparator per ordering column.
-	comparators []vecComparator
+	comparators []*vecComparator
 	output      coldata.Batch
 	outNulls    []*coldata.Nulls
 	// In order to reduce the number of interface conversions, we will get access
@@ -88,6 +88,10 @@ type OrderedSynchronizer struct {
 	// {{range .}}
 	out_TYPECols []_GOTYPESLICE
 	// {{end}}
+	// outKernels contains the registered kernels of the output columns which
+	// are not of a type that the code above is generated for (nil for all other
+	// columns).
+	outKernels []*TypeKernels
 	// outColsMap contains the positions of the corresponding vectors in the
 	// slice for the same types. For example, if we have an output batch with
 	// types = [Int64, Int64, Bool, Bytes, Bool, Int64], then outColsMap will be
@@ -159,7 +163,7 @@ func (o *OrderedSynchronizer) Next(ctx context.Context) coldata.Batch {
 			}
 			for i, physType := range o.columnTypes {
 				vec := batch.ColVec(i)
-				if vec.Nulls().MaybeHasNulls() && vec.Nulls().NullAt(srcRowIdx) {
+				if vec.Nulls().MaybeHasNulls() && vec.Nulls().NullAt(uint16(srcRowIdx)) {
 					o.outNulls[i].SetNull(outputIdx)
 				} else {
 					switch physType {
@@ -167,17 +171,19 @@ func (o *OrderedSynchronizer) Next(ctx context.Context) coldata.Batch {
 					case _TYPES_T:
 						srcCol := vec._TYPE()
 						outCol := o.out_TYPECols[o.outColsMap[i]]
-						v := execgen.UNSAFEGET(srcCol, int(srcRowIdx))
+						v := execgen.UNSAFEGET(srcCol, srcRowIdx)
 						execgen.SET(outCol, int(outputIdx), v)
 					// {{end}}
 					default:
-						execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %d", physType))
+						// Note that the values are set in the increasing order of
+						// outputIdx as required by the variable-width types.
+						o.outKernels[i].Set(o.output.ColVec(i), int(outputIdx), vec, srcRowIdx)
 					}
 				}
 			}
 
 			// Advance the input batch, fetching a new batch if necessary.
-			if o.inputIndices[minBatch]+1 < o.inputBatches[minBatch].Length() {
+			if o.inputIndices[minBatch]+1 < int(o.inputBatches[minBatch].Length()) {
 				o.inputIndices[minBatch]++
 			} else {
 				o.inputBatches[minBatch] = o.inputs[minBatch].Next(ctx)
@@ -200,10 +206,11 @@ func (o *OrderedSynchronizer) Next(ctx context.Context) coldata.Batch {
 
 // Init is part of the Operator interface.
 func (o *OrderedSynchronizer) Init() {
-	o.inputIndices = make([]uint16, len(o.inputs))
+	o.inputIndices = make([]int, len(o.inputs))
 	o.output = o.allocator.NewMemBatch(o.columnTypes)
 	o.outNulls = make([]*coldata.Nulls, len(o.columnTypes))
 	o.outColsMap = make([]int, len(o.columnTypes))
+	o.outKernels = make([]*TypeKernels, len(o.columnTypes))
 	for i, outVec := range o.output.ColVecs() {
 		o.outNulls[i] = outVec.Nulls()
 		switch o.columnTypes[i] {
@@ -213,13 +220,17 @@ func (o *OrderedSynchronizer) Init() {
 			o.out_TYPECols = append(o.out_TYPECols, outVec._TYPE())
 		// {{end}}
 		default:
-			execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %d", o.columnTypes[i]))
+			kernels := getTypeKernels(o.columnTypes[i])
+			if kernels.Set == nil {
+				execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %d", o.columnTypes[i]))
+			}
+			o.outKernels[i] = kernels
 		}
 	}
 	for i := range o.inputs {
 		o.inputs[i].Init()
 	}
-	o.comparators = make([]vecComparator, len(o.ordering))
+	o.comparators = make([]*vecComparator, len(o.ordering))
 	for i := range o.ordering {
 		typ := o.columnTypes[o.ordering[i].ColIdx]
 		o.comparators[i] = GetVecComparator(typ, len(o.inputs))
@@ -239,7 +250,7 @@ func (o *OrderedSynchronizer) compareRow(batchIdx1 int, batchIdx2 int) int {
 	}
 	for i := range o.ordering {
 		info := o.ordering[i]
-		res := o.comparators[i].compare(batchIdx1, batchIdx2, valIdx1, valIdx2)
+		res := o.comparators[i].compare(batchIdx1, batchIdx2, uint16(valIdx1), uint16(valIdx2))
 		if res != 0 {
 			switch d := info.Direction; d {
 			case encoding.Ascending:
@@ -31,6 +31,7 @@ type ordinalityOp struct {
 }
 
 var _ Operator = &ordinalityOp{}
+var _ Closer = &ordinalityOp{}
 
 // NewOrdinalityOp returns a new WITH ORDINALITY operator.
 func NewOrdinalityOp(allocator *Allocator, input Operator, outputIdx int) Operator {
@@ -74,3 +75,8 @@ func (c *ordinalityOp) Next(ctx context.Context) coldata.Batch {
 
 	return bat
 }
+
+// Close is part of the Closer interface.
+func (c *ordinalityOp) Close() error {
+	return closeInput(c.input)
+}
@@ -213,10 +213,13 @@ func (s *ParallelUnorderedSynchronizer) Next(ctx context.Context) coldata.Batch
 	case err := <-s.errCh:
 		if err != nil {
 			// If we got an error from one of our inputs, cancel all inputs and
-			// propagate this error through a panic.
+			// propagate this error through a panic. Note that the error has
+			// already been caught by the input goroutine, so we propagate it
+			// unchanged (in particular, a context cancellation is not an
+			// internal error).
 			s.cancelFn()
 			s.internalWaitGroup.Wait()
-			execerror.VectorizedInternalPanic(err)
+			execerror.RethrowCaughtError(err)
 		}
 	case msg := <-s.batchCh:
 		if msg == nil {
@@ -226,7 +229,7 @@ func (s *ParallelUnorderedSynchronizer) Next(ctx context.Context) coldata.Batch
 			select {
 			case err := <-s.errCh:
 				if err != nil {
-					execerror.VectorizedInternalPanic(err)
+					execerror.RethrowCaughtError(err)
 				}
 			default:
 			}
@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -124,6 +125,35 @@ func TestUnorderedSynchronizerNoLeaksOnError(t *testing.T) {
 	require.True(t, testutils.IsError(err, expectedErr), err)
 }
 
+// TestUnorderedSynchronizerPropagatesCancellation verifies that the context
+// cancellation is propagated by the synchronizer as is and not as an internal
+// error.
+func TestUnorderedSynchronizerPropagatesCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []coltypes.T{coltypes.Int64}
+	inputs := make([]Operator, 4)
+	for i := range inputs {
+		batch := testAllocator.NewMemBatch(typs)
+		batch.SetLength(coldata.BatchSize())
+		inputs[i] = NewRepeatableBatchSource(batch)
+	}
+
+	var wg sync.WaitGroup
+	s := NewParallelUnorderedSynchronizer(inputs, typs, &wg)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	require.NotEqual(t, 0, int(s.Next(ctx).Length()))
+	cancelFn()
+	var err error
+	for err == nil {
+		err = execerror.CatchVectorizedRuntimeError(func() { _ = s.Next(ctx) })
+	}
+	require.True(t, errors.Is(err, context.Canceled), err)
+	require.False(t, errors.HasAssertionFailure(err), err)
+	require.Equal(t, len(inputs), int(atomic.LoadUint32(&s.numFinishedInputs)))
+	wg.Wait()
+}
+
 func BenchmarkParallelUnorderedSynchronizer(b *testing.B) {
 	const numInputs = 6
 
@@ -31,23 +31,67 @@ func NewWindowSortingPartitioner(
 	ordCols []execinfrapb.Ordering_Column,
 	partitionColIdx int,
 ) (op Operator, err error) {
+	input, err = NewSorter(
+		allocator, input, inputTyps, windowPartitionerOrdering(partitionIdxs, ordCols),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newWindowPartitionMarker(allocator, input, inputTyps, partitionIdxs, partitionColIdx)
+}
+
+// NewWindowStreamingPartitioner is like NewWindowSortingPartitioner, but input
+// must already be ordered on the partitionIdxs columns (in any order and
+// direction). Only a single partition at a time is buffered in order to sort
+// it on ordCols, and if ordCols is empty, the operator doesn't buffer anything.
+func NewWindowStreamingPartitioner(
+	allocator *Allocator,
+	input Operator,
+	inputTyps []coltypes.T,
+	partitionIdxs []uint32,
+	ordCols []execinfrapb.Ordering_Column,
+	partitionColIdx int,
+) (op Operator, err error) {
+	input, err = NewSortChunks(
+		allocator, input, inputTyps, windowPartitionerOrdering(partitionIdxs, ordCols),
+		len(partitionIdxs), /* matchLen */
+	)
+	if err != nil {
+		return nil, err
+	}
+	return newWindowPartitionMarker(allocator, input, inputTyps, partitionIdxs, partitionColIdx)
+}
+
+// windowPartitionerOrdering returns the ordering on the partitionIdxs columns
+// followed by ordCols.
+func windowPartitionerOrdering(
+	partitionIdxs []uint32, ordCols []execinfrapb.Ordering_Column,
+) []execinfrapb.Ordering_Column {
 	partitionAndOrderingCols := make([]execinfrapb.Ordering_Column, len(partitionIdxs)+len(ordCols))
 	for i, idx := range partitionIdxs {
 		partitionAndOrderingCols[i] = execinfrapb.Ordering_Column{ColIdx: idx}
 	}
 	copy(partitionAndOrderingCols[len(partitionIdxs):], ordCols)
-	input, err = NewSorter(allocator, input, inputTyps, partitionAndOrderingCols)
-	if err != nil {
-		return nil, err
-	}
+	return partitionAndOrderingCols
+}
 
-	var distinctCol []bool
-	input, distinctCol, err = OrderedDistinctColsToOperators(input, partitionIdxs, inputTyps)
+// newWindowPartitionMarker returns an Operator that puts true in
+// partitionColIdx'th column (which is appended if needed) for every tuple that
+// is the first within its partition. All tuples of every partition must be
+// adjacent in input.
+func newWindowPartitionMarker(
+	allocator *Allocator,
+	input Operator,
+	inputTyps []coltypes.T,
+	partitionIdxs []uint32,
+	partitionColIdx int,
+) (Operator, error) {
+	input, distinctCol, err := OrderedDistinctColsToOperators(input, partitionIdxs, inputTyps)
 	if err != nil {
 		return nil, err
 	}
 
-	return &windowSortingPartitioner{
+	return &windowPartitionMarker{
 		OneInputNode:    NewOneInputNode(input),
 		allocator:       allocator,
 		distinctCol:     distinctCol,
@@ -55,7 +99,7 @@ func NewWindowSortingPartitioner(
 	}, nil
 }
 
-type windowSortingPartitioner struct {
+type windowPartitionMarker struct {
 	OneInputNode
 
 	allocator *Allocator
@@ -66,11 +110,11 @@ type windowSortingPartitioner struct {
 	partitionColIdx int
 }
 
-func (p *windowSortingPartitioner) Init() {
+func (p *windowPartitionMarker) Init() {
 	p.input.Init()
 }
 
-func (p *windowSortingPartitioner) Next(ctx context.Context) coldata.Batch {
+func (p *windowPartitionMarker) Next(ctx context.Context) coldata.Batch {
 	b := p.input.Next(ctx)
 	if b.Length() == 0 {
 		return coldata.ZeroBatch
@@ -130,21 +130,26 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 	colNulls := vec.Nulls()
 	// {{end}}
 	if sel := batch.Selection(); sel != nil {
+		// {{if _HAS_NULLS}}
+		// Only the selected tuples are looked at, so we set the nulls of the
+		// output one tuple at a time rather than copy the whole null bitmap.
+		projNulls := projVec.Nulls()
+		// {{end}}
 		sel = sel[:n]
 		for _, i := range sel {
-			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS)
+			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS, true)
 		}
 	} else {
 		col = execgen.SLICE(col, 0, int(n))
 		_ = _RET_UNSAFEGET(projCol, int(n)-1)
 		for execgen.RANGE(i, col, 0, int(n)) {
-			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS)
+			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS, false)
 		}
+		// {{if _HAS_NULLS}}
+		colNullsCopy := colNulls.Copy()
+		projVec.SetNulls(&colNullsCopy)
+		// {{end}}
 	}
-	// {{if _HAS_NULLS}}
-	colNullsCopy := colNulls.Copy()
-	projVec.SetNulls(&colNullsCopy)
-	// {{end}}
 	// {{end}}
 	// {{end}}
 	// {{/*
@@ -153,23 +158,26 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 // */}}
 
 // {{/*
-func _SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS bool) { // */}}
+func _SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS bool, _USES_SEL bool) { // */}}
 	// {{define "setSingleTupleProjection" -}}
 	// {{$hasNulls := $.HasNulls}}
+	// {{$usesSel := $.UsesSel}}
 	// {{with $.Overload}}
 	// {{if _HAS_NULLS}}
-	if !colNulls.NullAt(uint16(i)) {
+	if colNulls.NullAt(uint16(i)) {
 		// We only want to perform the projection operation if the value is not null.
+		// {{if _USES_SEL}}
+		projNulls.SetNull(uint16(i))
 		// {{end}}
-		arg := execgen.UNSAFEGET(col, int(i))
-		// {{if _IS_CONST_LEFT}}
-		_ASSIGN("projCol[i]", "p.constArg", "arg")
-		// {{else}}
-		_ASSIGN("projCol[i]", "arg", "p.constArg")
-		// {{end}}
-		// {{if _HAS_NULLS }}
+		continue
 	}
 	// {{end}}
+	arg := execgen.UNSAFEGET(col, int(i))
+	// {{if _IS_CONST_LEFT}}
+	_ASSIGN("projCol[i]", "p.constArg", "arg")
+	// {{else}}
+	_ASSIGN("projCol[i]", "arg", "p.constArg")
+	// {{end}}
 	// {{end}}
 	// {{end}}
 	// {{/*
@@ -129,9 +129,15 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 	col2Nulls := vec2.Nulls()
 	// {{end}}
 	if sel := batch.Selection(); sel != nil {
+		// {{if _HAS_NULLS}}
+		// Only the selected tuples are looked at, so we set the nulls of the
+		// output one tuple at a time rather than build the union of the whole
+		// null bitmaps.
+		projNulls := projVec.Nulls()
+		// {{end}}
 		sel = sel[:n]
 		for _, i := range sel {
-			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS)
+			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS, true)
 		}
 	} else {
 		col1 = execgen.SLICE(col1, 0, int(n))
@@ -139,12 +145,12 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 		_ = _RET_UNSAFEGET(projCol, colLen-1)
 		_ = _R_UNSAFEGET(col2, colLen-1)
 		for execgen.RANGE(i, col1, 0, int(n)) {
-			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS)
+			_SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS, false)
 		}
+		// {{if _HAS_NULLS}}
+		projVec.SetNulls(col1Nulls.Or(col2Nulls))
+		// {{end}}
 	}
-	// {{if _HAS_NULLS}}
-	projVec.SetNulls(col1Nulls.Or(col2Nulls))
-	// {{end}}
 	// {{end}}
 	// {{end}}
 	// {{/*
@@ -153,21 +159,24 @@ func _SET_PROJECTION(_HAS_NULLS bool) {
 // */}}
 
 // {{/*
-func _SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS bool) { // */}}
+func _SET_SINGLE_TUPLE_PROJECTION(_HAS_NULLS bool, _USES_SEL bool) { // */}}
 	// {{define "setSingleTupleProjection" -}}
 	// {{$hasNulls := $.HasNulls}}
+	// {{$usesSel := $.UsesSel}}
 	// {{with $.Overload}}
 	// {{if _HAS_NULLS}}
-	if !col1Nulls.NullAt(uint16(i)) && !col2Nulls.NullAt(uint16(i)) {
+	if col1Nulls.NullAt(uint16(i)) || col2Nulls.NullAt(uint16(i)) {
 		// We only want to perform the projection operation if both values are not
 		// null.
+		// {{if _USES_SEL}}
+		projNulls.SetNull(uint16(i))
 		// {{end}}
-		arg1 := _L_UNSAFEGET(col1, int(i))
-		arg2 := _R_UNSAFEGET(col2, int(i))
-		_ASSIGN("projCol[i]", "arg1", "arg2")
-		// {{if _HAS_NULLS }}
+		continue
 	}
 	// {{end}}
+	arg1 := _L_UNSAFEGET(col1, int(i))
+	arg2 := _R_UNSAFEGET(col2, int(i))
+	_ASSIGN("projCol[i]", "arg1", "arg2")
 	// {{end}}
 	// {{end}}
 	// {{/*
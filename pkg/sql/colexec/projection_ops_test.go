@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/assert"
@@ -62,6 +63,84 @@ func TestProjPlusInt64Int64Op(t *testing.T) {
 		})
 }
 
+func TestProjOpsWithSelectionSetNullsInPlace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	// The batches have nulls both in the selected and in the unselected tuples.
+	makeBatch := func() coldata.Batch {
+		batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64, coltypes.Int64, coltypes.Int64})
+		for i := 0; i < 6; i++ {
+			batch.ColVec(0).Int64()[i] = int64(i)
+			batch.ColVec(1).Int64()[i] = int64(10 * i)
+		}
+		batch.ColVec(0).Nulls().SetNull(1)
+		batch.ColVec(0).Nulls().SetNull(2)
+		batch.ColVec(1).Nulls().SetNull(3)
+		batch.ColVec(1).Nulls().SetNull(5)
+		batch.SetSelection(true)
+		copy(batch.Selection(), []int{0, 1, 4, 5})
+		batch.SetLength(4)
+		return batch
+	}
+	for _, tc := range []struct {
+		name          string
+		op            func(input Operator) Operator
+		expectedNulls []bool
+		expected      []int64
+	}{
+		{
+			name: "Const",
+			op: func(input Operator) Operator {
+				return &projPlusInt64Int64ConstOp{
+					projConstOpBase: projConstOpBase{
+						OneInputNode: NewOneInputNode(input),
+						allocator:    testAllocator,
+						colIdx:       0,
+						outputIdx:    2,
+					},
+					constArg: 1,
+				}
+			},
+			expectedNulls: []bool{false, true, false, false},
+			expected:      []int64{1, 0, 5, 6},
+		},
+		{
+			name: "NonConst",
+			op: func(input Operator) Operator {
+				return &projPlusInt64Int64Op{
+					projOpBase: projOpBase{
+						OneInputNode: NewOneInputNode(input),
+						allocator:    testAllocator,
+						col1Idx:      0,
+						col2Idx:      1,
+						outputIdx:    2,
+					},
+				}
+			},
+			expectedNulls: []bool{false, true, false, true},
+			expected:      []int64{0, 0, 44, 0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			batch := makeBatch()
+			outputNulls := batch.ColVec(2).Nulls()
+			outputBitmap := outputNulls.NullBitmap()
+			op := tc.op(&feedOperator{batch: batch})
+			op.Init()
+			b := op.Next(ctx)
+			// The null bitmap of the output vector must have been updated in place
+			// rather than replaced with a copy.
+			assert.True(t, &outputBitmap[0] == &b.ColVec(2).Nulls().NullBitmap()[0])
+			for i, idx := range b.Selection()[:b.Length()] {
+				assert.Equal(t, tc.expectedNulls[i], outputNulls.NullAt(uint16(idx)))
+				if !tc.expectedNulls[i] {
+					assert.Equal(t, tc.expected[i], b.ColVec(2).Int64()[idx])
+				}
+			}
+		})
+	}
+}
+
 func TestProjDivFloat64Float64Op(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	runTests(t, []tuples{{{1.0, 2.0}, {3.0, 4.0}, {5.0, nil}}}, tuples{{1.0, 2.0, 0.5}, {3.0, 4.0, 0.75}, {5.0, nil, nil}},
@@ -99,7 +178,7 @@ func benchmarkProjPlusInt64Int64ConstOp(b *testing.B, useSelectionVector bool, h
 		batch.SetSelection(true)
 		sel := batch.Selection()
 		for i := 0; i < int(coldata.BatchSize()); i++ {
-			sel[i] = uint16(i)
+			sel[i] = i
 		}
 	}
 	source := NewRepeatableBatchSource(batch)
@@ -205,7 +284,7 @@ func TestRandomComparisons(t *testing.T) {
 			continue
 		}
 		typ := typeconv.FromColumnType(ct)
-		if typ == coltypes.Unhandled {
+		if typ == coltypes.Unhandled || typ == coltypes.Datum {
 			continue
 		}
 		typs := []coltypes.T{typ, typ, coltypes.Bool}
@@ -217,11 +296,21 @@ func TestRandomComparisons(t *testing.T) {
 		lVec := b.ColVec(0)
 		rVec := b.ColVec(1)
 		ret := b.ColVec(2)
-		coldata.RandomVec(rng, typ, bytesFixedLength, lVec, numTuples, 0)
-		coldata.RandomVec(rng, typ, bytesFixedLength, rVec, numTuples, 0)
+		if ct.Family() == types.INetFamily {
+			// Random bytes are not valid encodings of IP addresses.
+			for _, vec := range []coldata.Vec{lVec, rVec} {
+				for i := 0; i < numTuples; i++ {
+					ipAddr := ipaddr.RandIPAddr(rng)
+					vec.Bytes().Set(i, ipAddr.ToBuffer(nil))
+				}
+			}
+		} else {
+			coldata.RandomVec(rng, typ, bytesFixedLength, lVec, numTuples, 0)
+			coldata.RandomVec(rng, typ, bytesFixedLength, rVec, numTuples, 0)
+		}
 		for i := range lDatums {
-			lDatums[i] = PhysicalTypeColElemToDatum(lVec, uint16(i), da, ct)
-			rDatums[i] = PhysicalTypeColElemToDatum(rVec, uint16(i), da, ct)
+			lDatums[i] = PhysicalTypeColElemToDatum(lVec, uint16(i), &da, ct)
+			rDatums[i] = PhysicalTypeColElemToDatum(rVec, uint16(i), &da, ct)
 		}
 		for _, cmpOp := range []tree.ComparisonOperator{tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE} {
 			for i := range lDatums {
@@ -332,7 +421,7 @@ func benchmarkProjOp(
 	if useSelectionVector {
 		batch.SetSelection(true)
 		sel := batch.Selection()
-		for i := uint16(0); i < coldata.BatchSize(); i++ {
+		for i := 0; i < int(coldata.BatchSize()); i++ {
 			sel[i] = i
 		}
 	}
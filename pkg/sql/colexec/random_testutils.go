@@ -61,12 +61,12 @@ func RandomBatch(
 // is 0, then the selection vector will contain all rows, but if it is > 0, then
 // some rows might be omitted and the length of the selection vector might be
 // less than batchSize.
-func randomSel(rng *rand.Rand, batchSize uint16, probOfOmitting float64) []uint16 {
+func randomSel(rng *rand.Rand, batchSize uint16, probOfOmitting float64) []int {
 	if probOfOmitting < 0 || probOfOmitting > 1 {
 		execerror.VectorizedInternalPanic(fmt.Sprintf("probability of omitting a row is %f - outside of [0, 1] range", probOfOmitting))
 	}
-	sel := make([]uint16, 0, batchSize)
-	for i := uint16(0); i < batchSize; i++ {
+	sel := make([]int, 0, batchSize)
+	for i := 0; i < int(batchSize); i++ {
 		if rng.Float64() < probOfOmitting {
 			continue
 		}
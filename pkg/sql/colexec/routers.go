@@ -30,7 +30,7 @@ type routerOutput interface {
 	// addBatch adds the elements specified by the selection vector from batch to
 	// the output. It returns whether or not the output changed its state to
 	// blocked (see implementations).
-	addBatch(coldata.Batch, []uint16) bool
+	addBatch(coldata.Batch, []int) bool
 	// cancel tells the output to stop producing batches.
 	cancel()
 }
@@ -56,6 +56,8 @@ type routerOutputOp struct {
 
 	mu struct {
 		syncutil.Mutex
+		// allocator is used exclusively by this output, so its memory account
+		// reflects the memory used by the batches buffered by this output.
 		allocator *Allocator
 		cond      *sync.Cond
 		done      bool
@@ -63,6 +65,13 @@ type routerOutputOp struct {
 		data      []coldata.Batch
 		numUnread int
 		blocked   bool
+		// lastReturned is the batch returned by the last call to Next. It is
+		// owned by the consumer until the next call to Next, at which point it
+		// is released to the allocator to be reused by addBatch, so that the
+		// memory usage of the output is bounded by the maximum number of
+		// buffered batches rather than by the total number of rows routed to
+		// it.
+		lastReturned coldata.Batch
 	}
 
 	// These fields default to defaultRouterOutputBlockedThreshold and
@@ -129,6 +138,12 @@ func (o *routerOutputOp) Next(context.Context) coldata.Batch {
 	if o.mu.done {
 		return coldata.ZeroBatch
 	}
+	if o.mu.lastReturned != nil {
+		// The consumer is done with the batch returned previously, so it can be
+		// reused.
+		o.mu.allocator.ReleaseBatch(o.mu.lastReturned)
+		o.mu.lastReturned = nil
+	}
 	for len(o.mu.data) == 0 && !o.mu.done {
 		o.mu.cond.Wait()
 	}
@@ -146,6 +161,8 @@ func (o *routerOutputOp) Next(context.Context) coldata.Batch {
 		// This is the last batch. Set done to protect against further calls to
 		// Next since this is allowed by the interface.
 		o.mu.done = true
+	} else {
+		o.mu.lastReturned = b
 	}
 	return b
 }
@@ -158,6 +175,7 @@ func (o *routerOutputOp) cancel() {
 	o.mu.done = true
 	// Release o.mu.data to GC.
 	o.mu.data = nil
+	o.mu.lastReturned = nil
 	// Some goroutine might be waiting on the condition variable, so wake it up.
 	// Note that read goroutines check o.mu.done, so won't wait on the condition
 	// variable after we unlock the mutex.
@@ -173,7 +191,7 @@ func (o *routerOutputOp) cancel() {
 // same batch with different selection vectors to many different outputs.
 // True is returned if the the output changes state to blocked (note: if the
 // output is already blocked, false is returned).
-func (o *routerOutputOp) addBatch(batch coldata.Batch, selection []uint16) bool {
+func (o *routerOutputOp) addBatch(batch coldata.Batch, selection []int) bool {
 	if len(selection) > int(batch.Length()) {
 		selection = selection[:batch.Length()]
 	}
@@ -194,11 +212,11 @@ func (o *routerOutputOp) addBatch(batch coldata.Batch, selection []uint16) bool
 	writeIdx := 0
 	if len(o.mu.data) == 0 {
 		// New output batch.
-		o.mu.data = append(o.mu.data, o.mu.allocator.NewMemBatchWithSize(o.types, o.outputBatchSize))
+		o.mu.data = append(o.mu.data, o.newBatchLocked())
 	} else {
 		if int(o.mu.data[len(o.mu.data)-1].Length()) == o.outputBatchSize {
 			// No space in last batch, append new output batch.
-			o.mu.data = append(o.mu.data, o.mu.allocator.NewMemBatchWithSize(o.types, o.outputBatchSize))
+			o.mu.data = append(o.mu.data, o.newBatchLocked())
 		}
 		writeIdx = len(o.mu.data) - 1
 	}
@@ -219,7 +237,7 @@ func (o *routerOutputOp) addBatch(batch coldata.Batch, selection []uint16) bool
 			numAppended = available
 			// Need to create a new batch to append to in the next o.mu.data slot.
 			// This will be used in the next iteration.
-			o.mu.data = append(o.mu.data, o.mu.allocator.NewMemBatchWithSize(o.types, o.outputBatchSize))
+			o.mu.data = append(o.mu.data, o.newBatchLocked())
 		}
 
 		o.mu.allocator.PerformOperation(dst.ColVecs(), func() {
@@ -251,6 +269,13 @@ func (o *routerOutputOp) addBatch(batch coldata.Batch, selection []uint16) bool
 	return stateChanged
 }
 
+// newBatchLocked returns an empty batch to append the buffered rows to. A
+// batch that has already been consumed is reused by the allocator if there is
+// one, otherwise a new batch is allocated.
+func (o *routerOutputOp) newBatchLocked() coldata.Batch {
+	return o.mu.allocator.NewMemBatchWithSize(o.types, o.outputBatchSize)
+}
+
 // maybeUnblockLocked unblocks the router output if it is in a blocked state. If the
 // output was previously in a blocked state, an event will be sent on
 // routerOutputOp.unblockedEventsChan.
@@ -266,6 +291,7 @@ func (o *routerOutputOp) reset() {
 	o.mu.Lock()
 	o.mu.done = false
 	o.mu.data = o.mu.data[:0]
+	o.mu.lastReturned = nil
 	o.mu.numUnread = 0
 	o.mu.blocked = false
 	o.mu.Unlock()
@@ -303,16 +329,20 @@ type HashRouter struct {
 		// with the same index in the current coldata.Batch.
 		buckets []uint64
 		// selections is scratch space for selection vectors used by router outputs.
-		selections [][]uint16
+		selections [][]int
 	}
 }
 
 // NewHashRouter creates a new hash router that consumes coldata.Batches from
-// input and hashes each row according to hashCols to one of numOutputs outputs.
-// These outputs are exposed as Operators.
+// input and hashes each row according to hashCols to one of len(allocators)
+// outputs. These outputs are exposed as Operators. Each output buffers the
+// rows routed to it using its own allocator, so that the memory usage of every
+// output is accounted for separately. Note that all allocators are used only by
+// the goroutine that runs the HashRouter.
 func NewHashRouter(
-	allocator *Allocator, input Operator, types []coltypes.T, hashCols []int, numOutputs int,
+	allocators []*Allocator, input Operator, types []coltypes.T, hashCols []int,
 ) (*HashRouter, []Operator) {
+	numOutputs := len(allocators)
 	outputs := make([]routerOutput, numOutputs)
 	outputsAsOps := make([]Operator, numOutputs)
 	// unblockEventsChan is buffered to 2*numOutputs as we don't want the outputs
@@ -324,7 +354,7 @@ func NewHashRouter(
 	// all unblock events preceding it since these *must* be on the channel.
 	unblockEventsChan := make(chan struct{}, 2*numOutputs)
 	for i := 0; i < numOutputs; i++ {
-		op := newRouterOutputOp(allocator, types, unblockEventsChan)
+		op := newRouterOutputOp(allocators[i], types, unblockEventsChan)
 		outputs[i] = op
 		outputsAsOps[i] = op
 	}
@@ -349,10 +379,13 @@ func newHashRouterWithOutputs(
 		outputs:             outputs,
 		unblockedEventsChan: unblockEventsChan,
 	}
+	// The routing of tuples must not depend on any seed overridden in tests
+	// since all hash routers of a flow have to agree on it.
+	r.ht.seed = defaultHashTableSeed
 	r.scratch.buckets = make([]uint64, coldata.BatchSize())
-	r.scratch.selections = make([][]uint16, len(outputs))
+	r.scratch.selections = make([][]int, len(outputs))
 	for i := range r.scratch.selections {
-		r.scratch.selections[i] = make([]uint16, 0, coldata.BatchSize())
+		r.scratch.selections[i] = make([]int, 0, coldata.BatchSize())
 	}
 	return r
 }
@@ -457,7 +490,7 @@ func (r *HashRouter) processNextBatch(ctx context.Context) bool {
 	} else {
 		for i, hash := range r.scratch.buckets[:b.Length()] {
 			outputIdx := hash % uint64(len(r.outputs))
-			r.scratch.selections[outputIdx] = append(r.scratch.selections[outputIdx], uint16(i))
+			r.scratch.selections[outputIdx] = append(r.scratch.selections[outputIdx], i)
 		}
 	}
 
@@ -31,12 +31,12 @@ import (
 // getDataAndFullSelection is a test helper that generates tuples representing
 // a one-column coltypes.Int64 batch where each element is its ordinal and an
 // accompanying selection vector that selects every index in tuples.
-func getDataAndFullSelection() (tuples, []uint16) {
+func getDataAndFullSelection() (tuples, []int) {
 	data := make(tuples, coldata.BatchSize())
-	fullSelection := make([]uint16, coldata.BatchSize())
+	fullSelection := make([]int, coldata.BatchSize())
 	for i := range data {
 		data[i] = tuple{i}
-		fullSelection[i] = uint16(i)
+		fullSelection[i] = i
 	}
 	return data, fullSelection
 }
@@ -55,7 +55,7 @@ func TestRouterOutputAddBatch(t *testing.T) {
 		blockedThreshold int
 		// selection determines which indices to add to the router output as well
 		// as how many elements from data are compared to the output.
-		selection []uint16
+		selection []int
 		name      string
 	}{
 		{
@@ -304,6 +304,33 @@ func TestRouterOutputNext(t *testing.T) {
 		// with a timeout on a channel read if not.
 		<-ch
 	})
+
+	t.Run("ConsumedBatchesAreReused", func(t *testing.T) {
+		memAcc := testMemMonitor.MakeBoundAccount()
+		defer memAcc.Close(ctx)
+		allocator := NewAllocator(ctx, &memAcc)
+		o := newRouterOutputOp(allocator, []coltypes.T{coltypes.Int64}, unblockedEventsChan)
+		batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+		batch.SetLength(coldata.BatchSize())
+		var memUsed int64
+		for i := 0; i < 10; i++ {
+			o.addBatch(batch, fullSelection)
+			if b := o.Next(ctx); b.Length() != coldata.BatchSize() {
+				t.Fatalf("unexpected batch length %d", b.Length())
+			}
+			// The batch returned by Next is owned by the consumer until the next
+			// call to Next, so the first two addBatch calls allocate new batches,
+			// and these two batches are reused afterwards.
+			if i == 1 {
+				memUsed = allocator.Used()
+			}
+			if i > 1 {
+				require.Equal(t, memUsed, allocator.Used())
+			}
+		}
+		o.addBatch(coldata.ZeroBatch, nil /* selection */)
+		require.Equal(t, uint16(0), o.Next(ctx).Length())
+	})
 }
 
 func TestRouterOutputRandom(t *testing.T) {
@@ -425,13 +452,13 @@ func TestRouterOutputRandom(t *testing.T) {
 
 type callbackRouterOutput struct {
 	ZeroInputNode
-	addBatchCb func(coldata.Batch, []uint16) bool
+	addBatchCb func(coldata.Batch, []int) bool
 	cancelCb   func()
 }
 
 var _ routerOutput = callbackRouterOutput{}
 
-func (o callbackRouterOutput) addBatch(batch coldata.Batch, selection []uint16) bool {
+func (o callbackRouterOutput) addBatch(batch coldata.Batch, selection []int) bool {
 	if o.addBatchCb != nil {
 		return o.addBatchCb(batch, selection)
 	}
@@ -482,7 +509,7 @@ func TestHashRouterComputesDestination(t *testing.T) {
 		// Capture the index.
 		outputIdx := i
 		outputs[i] = callbackRouterOutput{
-			addBatchCb: func(batch coldata.Batch, sel []uint16) bool {
+			addBatchCb: func(batch coldata.Batch, sel []int) bool {
 				for _, j := range sel {
 					key := batch.ColVec(0).Int64()[j]
 					if _, ok := valsYetToSee[key]; !ok {
@@ -525,7 +552,7 @@ func TestHashRouterCancellation(t *testing.T) {
 	for i := range outputs {
 		// We'll just be checking canceled.
 		outputs[i] = callbackRouterOutput{
-			addBatchCb: func(_ coldata.Batch, _ []uint16) bool {
+			addBatchCb: func(_ coldata.Batch, _ []int) bool {
 				atomic.AddInt64(&numAddBatches, 1)
 				return false
 			},
@@ -630,7 +657,7 @@ func TestHashRouterOneOutput(t *testing.T) {
 	typs := []coltypes.T{coltypes.Int64}
 
 	r, routerOutputs := NewHashRouter(
-		testAllocator, newOpFixedSelTestInput(sel, uint16(len(sel)), data), typs, []int{0}, 1, /* numOutputs */
+		[]*Allocator{testAllocator}, newOpFixedSelTestInput(sel, uint16(len(sel)), data), typs, []int{0},
 	)
 
 	if len(routerOutputs) != 1 {
@@ -805,7 +832,11 @@ func BenchmarkHashRouter(b *testing.B) {
 	for _, numOutputs := range []int{2, 4, 8, 16} {
 		for _, numInputBatches := range []int{2, 4, 8, 16} {
 			b.Run(fmt.Sprintf("numOutputs=%d/numInputBatches=%d", numOutputs, numInputBatches), func(b *testing.B) {
-				r, outputs := NewHashRouter(testAllocator, input, types, []int{0}, numOutputs)
+				allocators := make([]*Allocator, numOutputs)
+				for i := range allocators {
+					allocators[i] = testAllocator
+				}
+				r, outputs := NewHashRouter(allocators, input, types, []int{0})
 				b.SetBytes(8 * int64(coldata.BatchSize()) * int64(numInputBatches))
 				// We expect distribution to not change. This is a sanity check that
 				// we're resetting properly.
@@ -89,7 +89,7 @@ func (r *_ROW_NUMBER_STRINGOp) Next(ctx context.Context) coldata.Batch {
 		for i := uint16(0); i < batch.Length(); i++ {
 			// {{ if .HasPartition }}
 			if partitionCol[sel[i]] {
-				r.rowNumber = 1
+				r.rowNumber = 0
 			}
 			// {{ end }}
 			r.rowNumber++
@@ -25,6 +25,7 @@ import (
 
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
@@ -101,6 +102,10 @@ func EncDatumRowsToColVec(
 	allocator.PerformOperation(
 		[]coldata.Vec{vec},
 		func() {
+			if typeconv.FromColumnType(columnType) == coltypes.Datum {
+				err = datumRowsToColVec(rows, vec, columnIdx, columnType, alloc)
+				return
+			}
 			switch columnType.Family() {
 			// {{range .}}
 			case _FAMILY:
@@ -117,6 +122,10 @@ func EncDatumRowsToColVec(
 				_ROWS_TO_COL_VEC(rows, vec, columnIdx, columnType, alloc)
 				// {{end}}
 			// {{end}}
+			case types.ArrayFamily:
+				err = arrayRowsToColVec(rows, vec, columnIdx, columnType, alloc)
+			case types.CollatedStringFamily:
+				err = collatedStringRowsToColVec(rows, vec, columnIdx, columnType, alloc)
 			default:
 				execerror.VectorizedInternalPanic(fmt.Sprintf("unsupported column type %s", columnType.String()))
 			}
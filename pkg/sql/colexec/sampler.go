@@ -0,0 +1,475 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// samplerSketch contains the specification and run-time state for each sketch
+// of the samplerOp.
+type samplerSketch struct {
+	spec     execinfrapb.SketchSpec
+	sketch   *hyperloglog.Sketch
+	numNulls int64
+	numRows  int64
+}
+
+// samplerState represents the state of the samplerOp.
+type samplerState int
+
+const (
+	// samplerSampling is the state in which the samplerOp consumes its input.
+	samplerSampling samplerState = iota
+	// samplerEmittingSamples is the state in which the samplerOp emits the
+	// sampled rows.
+	samplerEmittingSamples
+	// samplerEmittingSketches is the state in which the samplerOp emits one
+	// row per sketch.
+	samplerEmittingSketches
+	// samplerDone is the state in which all of the output has been emitted.
+	samplerDone
+)
+
+// samplerThrottleInterval is the number of input tuples after which the
+// samplerOp checks whether it needs to throttle itself.
+const samplerThrottleInterval = 10000
+
+// At 25% average CPU usage we start throttling automatic stats.
+const samplerCPUUsageMinThrottle = 0.25
+
+// At 75% average CPU usage we reach maximum throttling of automatic stats.
+const samplerCPUUsageMaxThrottle = 0.75
+
+// samplerMaxIdleSleepTime is the maximum amount of time we sleep for
+// throttling (we sleep at most once every samplerThrottleInterval tuples).
+const samplerMaxIdleSleepTime = 10 * time.Second
+
+// samplerOp is the Operator implementation of the sampler processor used by
+// CREATE STATISTICS. It returns a random sample of the tuples of its input
+// (the columns of the input followed by the rank of the sample) and then one
+// row per sketch with the sketch data (the sketch index, the number of rows,
+// the number of NULLs and the HLL sketch). The output has the same schema and
+// the same contents (up to the randomness of sampling) as the samplerProcessor,
+// so it is consumed by the same sample aggregator.
+// The sketches are computed directly on the vectors. The sampled tuples need
+// to be converted to rows for the reservoir, but only when they have a rank
+// small enough to get into the reservoir.
+// Note that, unlike the samplerProcessor, the progress is reported only once
+// all of the input has been consumed since the metadata is propagated only
+// when the vectorized flow is drained.
+type samplerOp struct {
+	OneInputNode
+
+	allocator       *Allocator
+	flowCtx         *execinfra.FlowCtx
+	sr              stats.SampleReservoir
+	sketches        []samplerSketch
+	maxFractionIdle float64
+
+	inputTypes  []types.T
+	outputTypes []types.T
+	// sampleCols contains the indices of the columns that need to be included
+	// into the sampled rows (the other columns are NULL).
+	sampleCols util.FastIntSet
+
+	// Output column indices for special columns.
+	rankCol      int
+	sketchIdxCol int
+	numRowsCol   int
+	numNullsCol  int
+	sketchCol    int
+
+	state          samplerState
+	rng            *rand.Rand
+	rowCount       int
+	lastWakeupTime time.Time
+	// emitIdx is the index of the next sample or sketch to be emitted.
+	emitIdx int
+	output  coldata.Batch
+
+	accumulatedMeta []execinfrapb.ProducerMetadata
+
+	scratch struct {
+		row     sqlbase.EncDatumRow
+		outRows sqlbase.EncDatumRows
+		buf     []byte
+		intBuf  [8]byte
+		da      sqlbase.DatumAlloc
+	}
+}
+
+var _ Operator = &samplerOp{}
+var _ execinfrapb.MetadataSource = &samplerOp{}
+
+// samplerSupportedSketchTypes are the types of sketches supported by the
+// samplerOp.
+var samplerSupportedSketchTypes = map[execinfrapb.SketchType]struct{}{
+	// The code currently hardcodes the use of this single type of sketch
+	// (which avoids the extra complexity until we actually have multiple types).
+	execinfrapb.SketchType_HLL_PLUS_PLUS_V1: {},
+}
+
+// checkSamplerSpec returns an error if the sampler spec is not supported.
+func checkSamplerSpec(spec *execinfrapb.SamplerSpec) error {
+	for _, s := range spec.Sketches {
+		if _, ok := samplerSupportedSketchTypes[s.SketchType]; !ok {
+			return errors.Errorf("unsupported sketch type %s", s.SketchType)
+		}
+		if len(s.Columns) != 1 {
+			return unimplemented.NewWithIssue(34422, "multi-column statistics are not supported yet.")
+		}
+	}
+	return nil
+}
+
+// newSamplerOp returns a new samplerOp. The memory used by the sampled rows is
+// registered with memAcc, and once its limit is reached, the histogram
+// collection is disabled.
+func newSamplerOp(
+	allocator *Allocator,
+	memAcc *mon.BoundAccount,
+	flowCtx *execinfra.FlowCtx,
+	spec *execinfrapb.SamplerSpec,
+	input Operator,
+	inputTypes []types.T,
+) (*samplerOp, error) {
+	if err := checkSamplerSpec(spec); err != nil {
+		return nil, err
+	}
+	s := &samplerOp{
+		OneInputNode:    NewOneInputNode(input),
+		allocator:       allocator,
+		flowCtx:         flowCtx,
+		sketches:        make([]samplerSketch, len(spec.Sketches)),
+		maxFractionIdle: spec.MaxFractionIdle,
+		inputTypes:      inputTypes,
+	}
+	for i := range spec.Sketches {
+		s.sketches[i] = samplerSketch{
+			spec:   spec.Sketches[i],
+			sketch: hyperloglog.New14(),
+		}
+		if spec.Sketches[i].GenerateHistogram {
+			s.sampleCols.Add(int(spec.Sketches[i].Columns[0]))
+		}
+	}
+	s.sr.Init(int(spec.SampleSize), inputTypes, memAcc, s.sampleCols)
+
+	outputTypes := make([]types.T, 0, len(inputTypes)+5)
+	// First columns are the same as the input.
+	outputTypes = append(outputTypes, inputTypes...)
+	// An INT column for the rank of each row.
+	s.rankCol = len(outputTypes)
+	outputTypes = append(outputTypes, *types.Int)
+	// An INT column indicating the sketch index.
+	s.sketchIdxCol = len(outputTypes)
+	outputTypes = append(outputTypes, *types.Int)
+	// An INT column indicating the number of rows processed.
+	s.numRowsCol = len(outputTypes)
+	outputTypes = append(outputTypes, *types.Int)
+	// An INT column indicating the number of rows that have a NULL in any sketch
+	// column.
+	s.numNullsCol = len(outputTypes)
+	outputTypes = append(outputTypes, *types.Int)
+	// A BYTES column with the sketch data.
+	s.sketchCol = len(outputTypes)
+	outputTypes = append(outputTypes, *types.Bytes)
+	s.outputTypes = outputTypes
+	if _, err := typeconv.FromColumnTypes(outputTypes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *samplerOp) Init() {
+	s.input.Init()
+	s.rng, _ = randutil.NewPseudoRand()
+	s.lastWakeupTime = timeutil.Now()
+	s.scratch.row = make(sqlbase.EncDatumRow, len(s.inputTypes))
+	// The conversion to the physical types has been checked in the
+	// constructor.
+	outputPhysTypes, _ := typeconv.FromColumnTypes(s.outputTypes)
+	s.output = s.allocator.NewMemBatch(outputPhysTypes)
+}
+
+func (s *samplerOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch s.state {
+		case samplerSampling:
+			batch := s.input.Next(ctx)
+			if batch.Length() == 0 {
+				// Report the number of rows processed to the sample aggregator.
+				s.accumulatedMeta = append(s.accumulatedMeta, execinfrapb.ProducerMetadata{
+					SamplerProgress: &execinfrapb.RemoteProducerMetadata_SamplerProgress{
+						RowsProcessed: uint64(s.rowCount),
+					},
+				})
+				s.state = samplerEmittingSamples
+				continue
+			}
+			s.sampleBatch(ctx, batch)
+
+		case samplerEmittingSamples:
+			samples := s.sr.Get()
+			if s.emitIdx == len(samples) {
+				// Release the memory for the sampled rows.
+				s.sr = stats.SampleReservoir{}
+				s.emitIdx = 0
+				s.state = samplerEmittingSketches
+				continue
+			}
+			toEmit := len(samples) - s.emitIdx
+			if toEmit > int(coldata.BatchSize()) {
+				toEmit = int(coldata.BatchSize())
+			}
+			outRows := s.prepareOutRows(toEmit)
+			for i, sample := range samples[s.emitIdx : s.emitIdx+toEmit] {
+				copy(outRows[i], sample.Row)
+				outRows[i][s.rankCol] = sqlbase.EncDatum{Datum: tree.NewDInt(tree.DInt(sample.Rank))}
+			}
+			s.emitIdx += toEmit
+			return s.emitOutRows(outRows)
+
+		case samplerEmittingSketches:
+			if s.emitIdx == len(s.sketches) {
+				s.state = samplerDone
+				continue
+			}
+			toEmit := len(s.sketches) - s.emitIdx
+			if toEmit > int(coldata.BatchSize()) {
+				toEmit = int(coldata.BatchSize())
+			}
+			outRows := s.prepareOutRows(toEmit)
+			for i := range outRows {
+				si := &s.sketches[s.emitIdx+i]
+				outRows[i][s.sketchIdxCol] = sqlbase.EncDatum{Datum: tree.NewDInt(tree.DInt(s.emitIdx + i))}
+				outRows[i][s.numRowsCol] = sqlbase.EncDatum{Datum: tree.NewDInt(tree.DInt(si.numRows))}
+				outRows[i][s.numNullsCol] = sqlbase.EncDatum{Datum: tree.NewDInt(tree.DInt(si.numNulls))}
+				data, err := si.sketch.MarshalBinary()
+				if err != nil {
+					execerror.VectorizedInternalPanic(err)
+				}
+				outRows[i][s.sketchCol] = sqlbase.EncDatum{Datum: tree.NewDBytes(tree.DBytes(data))}
+			}
+			s.emitIdx += toEmit
+			return s.emitOutRows(outRows)
+
+		case samplerDone:
+			return coldata.ZeroBatch
+
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected samplerState %d", s.state))
+		}
+	}
+}
+
+// sampleBatch updates the sketches and the reservoir with all of the tuples
+// of batch.
+func (s *samplerOp) sampleBatch(ctx context.Context, batch coldata.Batch) {
+	n := batch.Length()
+	sel := batch.Selection()
+	for i := range s.sketches {
+		si := &s.sketches[i]
+		// TODO(radu): for multi-column sketches, we will need to do this for all
+		// columns.
+		colIdx := int(si.spec.Columns[0])
+		vec := batch.ColVec(colIdx)
+		for j := uint16(0); j < n; j++ {
+			rowIdx := j
+			if sel != nil {
+				rowIdx = uint16(sel[j])
+			}
+			s.addToSketch(si, vec, rowIdx, &s.inputTypes[colIdx])
+		}
+	}
+
+	for j := uint16(0); j < n; j++ {
+		s.rowCount++
+		if s.rowCount%samplerThrottleInterval == 0 {
+			s.maybeThrottle(ctx)
+		}
+		// Use Int63 so we don't have headaches converting to DInt.
+		rank := uint64(s.rng.Int63())
+		if !s.sr.WouldSample(rank) {
+			continue
+		}
+		rowIdx := j
+		if sel != nil {
+			rowIdx = uint16(sel[j])
+		}
+		// Only the sampled columns are converted since the others are not
+		// stored by the reservoir.
+		for colIdx := range s.scratch.row {
+			datum := tree.DNull
+			if s.sampleCols.Contains(colIdx) {
+				vec := batch.ColVec(colIdx)
+				if !vec.MaybeHasNulls() || !vec.Nulls().NullAt(rowIdx) {
+					datum = PhysicalTypeColElemToDatum(vec, rowIdx, &s.scratch.da, &s.inputTypes[colIdx])
+				}
+			}
+			s.scratch.row[colIdx] = sqlbase.DatumToEncDatum(&s.inputTypes[colIdx], datum)
+		}
+		if err := s.sr.SampleRow(ctx, s.flowCtx.EvalCtx, s.scratch.row, rank); err != nil {
+			if code := pgerror.GetPGCode(err); code != pgcode.OutOfMemory {
+				execerror.VectorizedInternalPanic(err)
+			}
+			// We hit an out of memory error. Clear the sample reservoir and
+			// disable histogram sample collection.
+			s.sr.Disable()
+			log.Info(ctx, "disabling histogram collection due to excessive memory utilization")
+
+			// Send a metadata record so the sample aggregator will also disable
+			// histogram collection.
+			s.accumulatedMeta = append(s.accumulatedMeta, execinfrapb.ProducerMetadata{
+				SamplerProgress: &execinfrapb.RemoteProducerMetadata_SamplerProgress{
+					HistogramDisabled: true,
+				},
+			})
+		}
+	}
+}
+
+// addToSketch inserts the rowIdx'th value of vec (of type typ) into the sketch.
+// Note that the values must be encoded the same way as the samplerProcessor
+// does since the sketches from different samplers are merged.
+func (s *samplerOp) addToSketch(si *samplerSketch, vec coldata.Vec, rowIdx uint16, typ *types.T) {
+	si.numRows++
+	isNull := vec.MaybeHasNulls() && vec.Nulls().NullAt(rowIdx)
+	if isNull {
+		si.numNulls++
+	}
+	if typ.Family() == types.IntFamily && !isNull {
+		// Fast path for integers.
+		var val int64
+		switch vec.Type() {
+		case coltypes.Int16:
+			val = int64(vec.Int16()[rowIdx])
+		case coltypes.Int32:
+			val = int64(vec.Int32()[rowIdx])
+		default:
+			val = vec.Int64()[rowIdx]
+		}
+		binary.LittleEndian.PutUint64(s.scratch.intBuf[:], uint64(val))
+		si.sketch.Insert(s.scratch.intBuf[:])
+		return
+	}
+	// We need to use a KEY encoding because equal values should have the same
+	// encoding.
+	datum := tree.DNull
+	if !isNull {
+		datum = PhysicalTypeColElemToDatum(vec, rowIdx, &s.scratch.da, typ)
+	}
+	var err error
+	s.scratch.buf, err = sqlbase.EncodeTableKey(s.scratch.buf[:0], datum, encoding.Ascending)
+	if err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	si.sketch.Insert(s.scratch.buf)
+}
+
+// maybeThrottle sleeps for some time if the CPU usage is high.
+func (s *samplerOp) maybeThrottle(ctx context.Context) {
+	if s.maxFractionIdle <= 0 {
+		return
+	}
+	// Look at CRDB's average CPU usage in the last 10 seconds:
+	//  - if it is lower than samplerCPUUsageMinThrottle, we do not throttle;
+	//  - if it is higher than samplerCPUUsageMaxThrottle, we throttle all the
+	//    way;
+	//  - in-between, we scale the idle time proportionally.
+	usage := s.flowCtx.Cfg.RuntimeStats.GetCPUCombinedPercentNorm()
+	if usage > samplerCPUUsageMinThrottle {
+		fractionIdle := s.maxFractionIdle
+		if usage < samplerCPUUsageMaxThrottle {
+			fractionIdle *= (usage - samplerCPUUsageMinThrottle) /
+				(samplerCPUUsageMaxThrottle - samplerCPUUsageMinThrottle)
+		}
+		if log.V(1) {
+			log.Infof(
+				ctx, "throttling to fraction idle %.2f (based on usage %.2f)", fractionIdle, usage,
+			)
+		}
+		// Throttle the operator according to fractionIdle (see the
+		// samplerProcessor for the derivation).
+		elapsed := timeutil.Now().Sub(s.lastWakeupTime)
+		wait := time.Duration(float64(elapsed) * fractionIdle / (1 - fractionIdle))
+		if wait > samplerMaxIdleSleepTime {
+			wait = samplerMaxIdleSleepTime
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.flowCtx.Stopper().ShouldStop():
+		}
+		timer.Stop()
+	}
+	s.lastWakeupTime = timeutil.Now()
+}
+
+// prepareOutRows returns n output rows with all of the values set to NULL.
+func (s *samplerOp) prepareOutRows(n int) sqlbase.EncDatumRows {
+	for len(s.scratch.outRows) < n {
+		s.scratch.outRows = append(s.scratch.outRows, make(sqlbase.EncDatumRow, len(s.outputTypes)))
+	}
+	outRows := s.scratch.outRows[:n]
+	for _, row := range outRows {
+		for i := range row {
+			row[i] = sqlbase.DatumToEncDatum(&s.outputTypes[i], tree.DNull)
+		}
+	}
+	return outRows
+}
+
+// emitOutRows converts outRows into the output batch and returns it.
+func (s *samplerOp) emitOutRows(outRows sqlbase.EncDatumRows) coldata.Batch {
+	s.output.ResetInternalBatch()
+	for colIdx := range s.outputTypes {
+		if err := EncDatumRowsToColVec(
+			s.allocator, outRows, s.output.ColVec(colIdx), colIdx, &s.outputTypes[colIdx], &s.scratch.da,
+		); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+	}
+	s.output.SetLength(uint16(len(outRows)))
+	return s.output
+}
+
+// DrainMeta is part of the MetadataSource interface.
+func (s *samplerOp) DrainMeta(ctx context.Context) []execinfrapb.ProducerMetadata {
+	return s.accumulatedMeta
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplerOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+	}
+
+	// The first column contains 10 distinct integers and every seventh value is
+	// NULL, the second column contains strings.
+	const numRows = 100
+	var input tuples
+	// The expected sketches are computed the same way as the samplerProcessor
+	// does.
+	expectedSketches := []*hyperloglog.Sketch{hyperloglog.New14(), hyperloglog.New14()}
+	expectedNumNulls := []int64{0, 0}
+	var intBuf [8]byte
+	for i := 0; i < numRows; i++ {
+		var intVal interface{}
+		if i%7 == 0 {
+			key, err := sqlbase.EncodeTableKey(nil, tree.DNull, encoding.Ascending)
+			require.NoError(t, err)
+			expectedSketches[0].Insert(key)
+			expectedNumNulls[0]++
+		} else {
+			intVal = int64(i % 10)
+			binary.LittleEndian.PutUint64(intBuf[:], uint64(i%10))
+			expectedSketches[0].Insert(intBuf[:])
+		}
+		strVal := fmt.Sprintf("%d", i)
+		key, err := sqlbase.EncodeTableKey(nil, tree.NewDString(strVal), encoding.Ascending)
+		require.NoError(t, err)
+		expectedSketches[1].Insert(key)
+		input = append(input, tuple{intVal, strVal})
+	}
+
+	for _, sampleSize := range []int{10, 2 * numRows} {
+		t.Run(fmt.Sprintf("sampleSize=%d", sampleSize), func(t *testing.T) {
+			spec := &execinfrapb.SamplerSpec{
+				SampleSize: uint32(sampleSize),
+				Sketches: []execinfrapb.SketchSpec{
+					{
+						SketchType:        execinfrapb.SketchType_HLL_PLUS_PLUS_V1,
+						Columns:           []uint32{0},
+						GenerateHistogram: true,
+					},
+					{
+						SketchType: execinfrapb.SketchType_HLL_PLUS_PLUS_V1,
+						Columns:    []uint32{1},
+					},
+				},
+			}
+			sampler, err := newSamplerOp(
+				testAllocator, testMemAcc, flowCtx, spec,
+				newOpTestInput(7 /* batchSize */, input, []coltypes.T{coltypes.Int64, coltypes.Bytes}),
+				[]types.T{*types.Int, *types.String},
+			)
+			require.NoError(t, err)
+			sampler.Init()
+
+			var samples, sketches tuples
+			for {
+				b := sampler.Next(ctx)
+				if b.Length() == 0 {
+					break
+				}
+				for i := uint16(0); i < b.Length(); i++ {
+					tup := getTupleFromBatch(b, i)
+					if tup[sampler.rankCol] != nil {
+						samples = append(samples, tup)
+					} else {
+						sketches = append(sketches, tup)
+					}
+				}
+			}
+
+			expectedNumSamples := sampleSize
+			if expectedNumSamples > numRows {
+				expectedNumSamples = numRows
+			}
+			require.Equal(t, expectedNumSamples, len(samples))
+			for _, sample := range samples {
+				// Only the column with the histogram is sampled.
+				require.Nil(t, sample[1])
+				if sample[0] != nil {
+					require.True(t, sample[0].(int64) < 10)
+				}
+			}
+
+			require.Equal(t, len(spec.Sketches), len(sketches))
+			for i, sketch := range sketches {
+				require.Equal(t, int64(i), sketch[sampler.sketchIdxCol])
+				require.Equal(t, int64(numRows), sketch[sampler.numRowsCol])
+				require.Equal(t, expectedNumNulls[i], sketch[sampler.numNullsCol])
+				expected, err := expectedSketches[i].MarshalBinary()
+				require.NoError(t, err)
+				require.Equal(t, string(expected), string(sketch[sampler.sketchCol].([]byte)))
+			}
+
+			meta := sampler.DrainMeta(ctx)
+			require.Equal(t, 1, len(meta))
+			require.Equal(t, uint64(numRows), meta[0].SamplerProgress.RowsProcessed)
+		})
+	}
+}
@@ -220,9 +220,9 @@ func (si *selectInOp_TYPE) Next(ctx context.Context) coldata.Batch {
 			if sel := batch.Selection(); sel != nil {
 				sel = sel[:n]
 				for _, i := range sel {
-					v := execgen.UNSAFEGET(col, int(i))
+					v := execgen.UNSAFEGET(col, i)
 					if !nulls.NullAt(uint16(i)) && cmpIn_TYPE(v, si.filterRow, si.hasNulls) == compVal {
-						sel[idx] = uint16(i)
+						sel[idx] = i
 						idx++
 					}
 				}
@@ -233,7 +233,7 @@ func (si *selectInOp_TYPE) Next(ctx context.Context) coldata.Batch {
 				for execgen.RANGE(i, col, 0, int(n)) {
 					v := execgen.UNSAFEGET(col, i)
 					if !nulls.NullAt(uint16(i)) && cmpIn_TYPE(v, si.filterRow, si.hasNulls) == compVal {
-						sel[idx] = uint16(i)
+						sel[idx] = i
 						idx++
 					}
 				}
@@ -242,9 +242,9 @@ func (si *selectInOp_TYPE) Next(ctx context.Context) coldata.Batch {
 			if sel := batch.Selection(); sel != nil {
 				sel = sel[:n]
 				for _, i := range sel {
-					v := execgen.UNSAFEGET(col, int(i))
+					v := execgen.UNSAFEGET(col, i)
 					if cmpIn_TYPE(v, si.filterRow, si.hasNulls) == compVal {
-						sel[idx] = uint16(i)
+						sel[idx] = i
 						idx++
 					}
 				}
@@ -255,7 +255,7 @@ func (si *selectInOp_TYPE) Next(ctx context.Context) coldata.Batch {
 				for execgen.RANGE(i, col, 0, int(n)) {
 					v := execgen.UNSAFEGET(col, i)
 					if cmpIn_TYPE(v, si.filterRow, si.hasNulls) == compVal {
-						sel[idx] = uint16(i)
+						sel[idx] = i
 						idx++
 					}
 				}
@@ -298,7 +298,7 @@ func (pi *projectInOp_TYPE) Next(ctx context.Context) coldata.Batch {
 				if nulls.NullAt(uint16(i)) {
 					projNulls.SetNull(uint16(i))
 				} else {
-					v := execgen.UNSAFEGET(col, int(i))
+					v := execgen.UNSAFEGET(col, i)
 					cmpRes := cmpIn_TYPE(v, pi.filterRow, pi.hasNulls)
 					if cmpRes == siNull {
 						projNulls.SetNull(uint16(i))
@@ -327,7 +327,7 @@ func (pi *projectInOp_TYPE) Next(ctx context.Context) coldata.Batch {
 		if sel := batch.Selection(); sel != nil {
 			sel = sel[:n]
 			for _, i := range sel {
-				v := execgen.UNSAFEGET(col, int(i))
+				v := execgen.UNSAFEGET(col, i)
 				cmpRes := cmpIn_TYPE(v, pi.filterRow, pi.hasNulls)
 				if cmpRes == siNull {
 					projNulls.SetNull(uint16(i))
@@ -80,10 +80,10 @@ func _SEL_CONST_LOOP(_HAS_NULLS bool) { // */}}
 		sel = sel[:n]
 		for _, i := range sel {
 			var cmp bool
-			arg := execgen.UNSAFEGET(col, int(i))
+			arg := execgen.UNSAFEGET(col, i)
 			_ASSIGN_CMP("cmp", "arg", "p.constArg")
 			// {{if _HAS_NULLS}}
-			isNull := nulls.NullAt(i)
+			isNull := nulls.NullAt(uint16(i))
 			// {{else}}
 			isNull := false
 			// {{end}}
@@ -106,7 +106,7 @@ func _SEL_CONST_LOOP(_HAS_NULLS bool) { // */}}
 			isNull := false
 			// {{end}}
 			if cmp && !isNull {
-				sel[idx] = uint16(i)
+				sel[idx] = i
 				idx++
 			}
 		}
@@ -125,11 +125,11 @@ func _SEL_LOOP(_HAS_NULLS bool) { // */}}
 		sel = sel[:n]
 		for _, i := range sel {
 			var cmp bool
-			arg1 := execgen.UNSAFEGET(col1, int(i))
-			arg2 := _R_UNSAFEGET(col2, int(i))
+			arg1 := execgen.UNSAFEGET(col1, i)
+			arg2 := _R_UNSAFEGET(col2, i)
 			_ASSIGN_CMP("cmp", "arg1", "arg2")
 			// {{if _HAS_NULLS}}
-			isNull := nulls.NullAt(i)
+			isNull := nulls1.NullAt(uint16(i)) || nulls2.NullAt(uint16(i))
 			// {{else}}
 			isNull := false
 			// {{end}}
@@ -150,12 +150,12 @@ func _SEL_LOOP(_HAS_NULLS bool) { // */}}
 			arg2 := _R_UNSAFEGET(col2, i)
 			_ASSIGN_CMP("cmp", "arg1", "arg2")
 			// {{if _HAS_NULLS}}
-			isNull := nulls.NullAt(uint16(i))
+			isNull := nulls1.NullAt(uint16(i)) || nulls2.NullAt(uint16(i))
 			// {{else}}
 			isNull := false
 			// {{end}}
 			if cmp && !isNull {
-				sel[idx] = uint16(i)
+				sel[idx] = i
 				idx++
 			}
 		}
@@ -221,7 +221,9 @@ func (p *_OP_NAME) Next(ctx context.Context) coldata.Batch {
 
 		var idx uint16
 		if vec1.MaybeHasNulls() || vec2.MaybeHasNulls() {
-			nulls := vec1.Nulls().Or(vec2.Nulls())
+			// We look up the nulls of both vectors for every tuple instead of
+			// allocating the union of the null bitmaps on every batch.
+			nulls1, nulls2 := vec1.Nulls(), vec2.Nulls()
 			_SEL_LOOP(true)
 		} else {
 			_SEL_LOOP(false)
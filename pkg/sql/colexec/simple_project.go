@@ -27,6 +27,7 @@ type simpleProjectOp struct {
 }
 
 var _ Operator = &simpleProjectOp{}
+var _ Closer = &simpleProjectOp{}
 
 // projectingBatch is a Batch that applies a simple projection to another,
 // underlying batch, discarding all columns but the ones in its projection
@@ -95,3 +96,8 @@ func (d *simpleProjectOp) Next(ctx context.Context) coldata.Batch {
 
 	return d.batch
 }
+
+// Close is part of the Closer interface.
+func (d *simpleProjectOp) Close() error {
+	return closeInput(d.input)
+}
@@ -172,8 +172,8 @@ func (p *allSpooler) getPartitionsCol() []bool {
 }
 
 func (p *allSpooler) getWindowedBatch(startIdx, endIdx uint64) coldata.Batch {
-	for i, t := range p.inputTypes {
-		window := p.bufferedTuples.colVecs[i].Window(t, startIdx, endIdx)
+	for i := range p.inputTypes {
+		window := p.bufferedTuples.colVecs[i].Window(startIdx, endIdx)
 		p.windowedBatch.ReplaceCol(window, i)
 	}
 	p.windowedBatch.SetLength(uint16(endIdx - startIdx))
@@ -210,7 +210,7 @@ type sortOp struct {
 	// at index i in order is the ordinal value of the tuple in the input that
 	// belongs at index i. For example, if the input column to sort was
 	// [c,b,a,d], the order vector after sorting would be [2,1,0,3].
-	order []uint64
+	order []int
 	// emitted is the number of tuples emitted so far.
 	emitted uint64
 	// state is the current state of the sort.
@@ -228,12 +228,12 @@ type colSorter interface {
 	// init prepares this sorter, given a particular Vec and an order vector,
 	// which must be the same size as the input Vec and will be permuted with
 	// the same swaps as the column.
-	init(col coldata.Vec, order []uint64)
+	init(col coldata.Vec, order []int)
 	// sort globally sorts this sorter's column.
 	sort(ctx context.Context)
 	// sortPartitions sorts this sorter's column once for every partition in the
 	// partition slice.
-	sortPartitions(ctx context.Context, partitions []uint64)
+	sortPartitions(ctx context.Context, partitions []int)
 }
 
 func (p *sortOp) Init() {
@@ -290,8 +290,8 @@ func (p *sortOp) Next(ctx context.Context) coldata.Batch {
 							Src:         p.input.getValues(j),
 							SrcStartIdx: p.emitted,
 							SrcEndIdx:   newEmitted,
+							Sel:         p.order,
 						},
-						Sel64: p.order,
 					},
 				)
 			}
@@ -316,12 +316,12 @@ func (p *sortOp) sort(ctx context.Context) {
 	// Allocate p.order and p.workingSpace if it hasn't been allocated yet or the
 	// underlying memory is insufficient.
 	if p.order == nil || uint64(cap(p.order)) < spooledTuples {
-		p.order = make([]uint64, spooledTuples)
+		p.order = make([]int, spooledTuples)
 	}
 	p.order = p.order[:spooledTuples]
 
 	// Initialize the order vector to the ordinal positions within the input set.
-	for i := uint64(0); i < uint64(len(p.order)); i++ {
+	for i := range p.order {
 		p.order[i] = i
 	}
 
@@ -381,7 +381,7 @@ func (p *sortOp) sort(ctx context.Context) {
 	// 2 a
 	// 2 b
 
-	partitions := make([]uint64, 0, 16)
+	partitions := make([]int, 0, 16)
 	for i, sorter := range sorters {
 		if !omitNextPartitioning {
 			// We partition the previous column by running an ordered distinct operation
@@ -395,7 +395,7 @@ func (p *sortOp) sort(ctx context.Context) {
 		}
 		// Convert the distinct vector into a selection vector - a vector of indices
 		// that were true in the distinct vector.
-		partitions = boolVecToSel64(partitionsCol, partitions[:0])
+		partitions = boolVecToSel(partitionsCol, partitions[:0])
 		// For each partition (set of tuples that are identical in all of the sort
 		// columns we've seen so far), sort based on the new column.
 		sorter.sortPartitions(ctx, partitions)
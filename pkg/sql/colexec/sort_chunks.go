@@ -179,7 +179,7 @@ type chunker struct {
 	// found in the last read batch. Note: the first chunk might be a part of
 	// the chunk that is currently being buffered, and similarly the last chunk
 	// might include tuples from the batches to be read.
-	chunks []uint64
+	chunks []int
 	// chunksProcessedIdx indicates which chunk within s.chunks should be
 	// processed next.
 	chunksProcessedIdx int
@@ -228,7 +228,7 @@ func (s *chunker) init() {
 	s.input.Init()
 	s.bufferedTuples = newBufferedBatch(s.allocator, s.inputTypes, 0 /* initialSize */)
 	s.partitionCol = make([]bool, coldata.BatchSize())
-	s.chunks = make([]uint64, 0, 16)
+	s.chunks = make([]int, 0, 16)
 }
 
 // done indicates whether the chunker has fully consumed its input.
@@ -268,7 +268,7 @@ func (s *chunker) prepareNextChunks(ctx context.Context) chunkerReadingState {
 				s.partitioners[i].partition(s.batch.ColVec(int(orderedCol.ColIdx)), s.partitionCol,
 					uint64(s.batch.Length()))
 			}
-			s.chunks = boolVecToSel64(s.partitionCol, s.chunks[:0])
+			s.chunks = boolVecToSel(s.partitionCol, s.chunks[:0])
 
 			if s.bufferedTuples.length == 0 {
 				// There are no buffered tuples, so a new chunk starts in the current
@@ -391,9 +391,9 @@ func (s *chunker) spool(ctx context.Context) {
 func (s *chunker) getValues(i int) coldata.Vec {
 	switch s.readFrom {
 	case chunkerReadFromBuffer:
-		return s.bufferedTuples.colVecs[i].Window(s.inputTypes[i], 0 /* start */, s.bufferedTuples.length)
+		return s.bufferedTuples.colVecs[i].Window(0 /* start */, s.bufferedTuples.length)
 	case chunkerReadFromBatch:
-		return s.batch.ColVec(i).Window(s.inputTypes[i], s.chunks[s.chunksStartIdx], s.chunks[len(s.chunks)-1])
+		return s.batch.ColVec(i).Window(uint64(s.chunks[s.chunksStartIdx]), uint64(s.chunks[len(s.chunks)-1]))
 	default:
 		execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected chunkerReadingState in getValues: %v", s.state))
 		// This code is unreachable, but the compiler cannot infer that.
@@ -406,7 +406,7 @@ func (s *chunker) getNumTuples() uint64 {
 	case chunkerReadFromBuffer:
 		return s.bufferedTuples.length
 	case chunkerReadFromBatch:
-		return s.chunks[len(s.chunks)-1] - s.chunks[s.chunksStartIdx]
+		return uint64(s.chunks[len(s.chunks)-1] - s.chunks[s.chunksStartIdx])
 	case inputDone:
 		return 0
 	default:
@@ -160,7 +160,7 @@ func TestSortRandomized(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	rng, _ := randutil.NewPseudoRand()
 	nTups := int(coldata.BatchSize()*2 + 1)
-	k := uint16(rng.Intn(nTups)) + 1
+	k := uint64(rng.Intn(nTups)) + 1
 	maxCols := 3
 	// TODO(yuzefovich): randomize types as well.
 	typs := make([]coltypes.T, maxCols)
@@ -290,7 +290,7 @@ func TestAllSpooler(t *testing.T) {
 func BenchmarkSort(b *testing.B) {
 	rng, _ := randutil.NewPseudoRand()
 	ctx := context.Background()
-	k := uint16(128)
+	k := uint64(128)
 
 	for _, nBatches := range []int{1 << 1, 1 << 4, 1 << 8} {
 		for _, nCols := range []int{1, 2, 4} {
@@ -136,11 +136,11 @@ func newSingleSorter(
 type sort_TYPE_DIR_HANDLES_NULLSOp struct {
 	sortCol       _GOTYPESLICE
 	nulls         *coldata.Nulls
-	order         []uint64
+	order         []int
 	cancelChecker CancelChecker
 }
 
-func (s *sort_TYPE_DIR_HANDLES_NULLSOp) init(col coldata.Vec, order []uint64) {
+func (s *sort_TYPE_DIR_HANDLES_NULLSOp) init(col coldata.Vec, order []int) {
 	s.sortCol = col._TemplateType()
 	s.nulls = col.Nulls()
 	s.order = order
@@ -151,28 +151,28 @@ func (s *sort_TYPE_DIR_HANDLES_NULLSOp) sort(ctx context.Context) {
 	s.quickSort(ctx, 0, n, maxDepth(n))
 }
 
-func (s *sort_TYPE_DIR_HANDLES_NULLSOp) sortPartitions(ctx context.Context, partitions []uint64) {
+func (s *sort_TYPE_DIR_HANDLES_NULLSOp) sortPartitions(ctx context.Context, partitions []int) {
 	if len(partitions) < 1 {
 		execerror.VectorizedInternalPanic(fmt.Sprintf("invalid partitions list %v", partitions))
 	}
 	order := s.order
 	for i, partitionStart := range partitions {
-		var partitionEnd uint64
+		var partitionEnd int
 		if i == len(partitions)-1 {
-			partitionEnd = uint64(len(order))
+			partitionEnd = len(order)
 		} else {
 			partitionEnd = partitions[i+1]
 		}
 		s.order = order[partitionStart:partitionEnd]
-		n := int(partitionEnd - partitionStart)
+		n := partitionEnd - partitionStart
 		s.quickSort(ctx, 0, n, maxDepth(n))
 	}
 }
 
 func (s *sort_TYPE_DIR_HANDLES_NULLSOp) Less(i, j int) bool {
 	// {{ if eq .Nulls true }}
-	n1 := s.nulls.MaybeHasNulls() && s.nulls.NullAt64(s.order[i])
-	n2 := s.nulls.MaybeHasNulls() && s.nulls.NullAt64(s.order[j])
+	n1 := s.nulls.MaybeHasNulls() && s.nulls.NullAt64(uint64(s.order[i]))
+	n2 := s.nulls.MaybeHasNulls() && s.nulls.NullAt64(uint64(s.order[j]))
 	// {{ if eq .DirString "Asc" }}
 	// If ascending, nulls always sort first, so we encode that logic here.
 	if n1 && n2 {
@@ -195,8 +195,8 @@ func (s *sort_TYPE_DIR_HANDLES_NULLSOp) Less(i, j int) bool {
 	// {{end}}
 	var lt bool
 	// We always indirect via the order vector.
-	arg1 := execgen.UNSAFEGET(s.sortCol, int(s.order[i]))
-	arg2 := execgen.UNSAFEGET(s.sortCol, int(s.order[j]))
+	arg1 := execgen.UNSAFEGET(s.sortCol, s.order[i])
+	arg2 := execgen.UNSAFEGET(s.sortCol, s.order[j])
 	_ASSIGN_LT("lt", "arg1", "arg2")
 	return lt
 }
@@ -21,10 +21,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 )
 
-const (
-	topKVecIdx  = 0
-	inputVecIdx = 1
-)
+// inputVecIdx is the index of the input batch in the comparators. The chunks
+// of the top K rows are stored at indices starting from 1.
+const inputVecIdx = 0
 
 // NewTopKSorter returns a new sort operator, which sorts its input on the
 // columns given in orderingCols and returns the first K rows. The inputTypes
@@ -34,7 +33,7 @@ func NewTopKSorter(
 	input Operator,
 	inputTypes []coltypes.T,
 	orderingCols []execinfrapb.Ordering_Column,
-	k uint16,
+	k uint64,
 ) Operator {
 	return &topKSorter{
 		allocator:    allocator,
@@ -65,32 +64,54 @@ type topKSorter struct {
 	allocator    *Allocator
 	orderingCols []execinfrapb.Ordering_Column
 	inputTypes   []coltypes.T
-	k            uint16 // TODO(solon): support larger k values
+	k            uint64
 
 	// state is the current state of the sort.
 	state topKSortState
 	// comparators stores one comparator per ordering column.
-	comparators []vecComparator
-	// topK stores the top K rows. It is not sorted internally.
-	topK coldata.Batch
-	// heap is a max heap which stores indices into topK.
-	heap []uint16
-	// sel is a selection vector which specifies an ordering on topK.
-	sel []uint16
+	comparators []*vecComparator
+	// numComparatorVecs is the number of vectors that the comparators have
+	// been created for.
+	numComparatorVecs int
+	// chunks store the top K rows in batches of coldata.BatchSize() rows each
+	// (only the last chunk can be partially filled). The chunks are allocated
+	// lazily, so a large K doesn't result in a large allocation when the input
+	// is small, and the last chunk is sized to fit the remaining rows, so a
+	// small K doesn't result in a large allocation either. The rows are not
+	// sorted internally.
+	chunks []coldata.Batch
+	// chunksVecs contains all vectors of all chunks.
+	chunksVecs []coldata.Vec
+	// topKLength is the number of rows stored in chunks.
+	topKLength uint64
+	// heap is a max heap which stores indices into chunks (see chunkAndRow).
+	heap []uint64
+	// sel specifies an ordering on the rows in chunks.
+	sel []uint64
 	// emitted is the count of rows which have been emitted so far.
-	emitted uint16
+	emitted uint64
+	// emitSel is the scratch space for the selection vector of a run of rows
+	// from the same chunk that are emitted together.
+	emitSel []int
 	output  coldata.Batch
 }
 
+// capacityForK returns the capacity of a batch that needs to hold up to k rows.
+func capacityForK(k uint64) int {
+	if k > uint64(coldata.BatchSize()) {
+		return int(coldata.BatchSize())
+	}
+	return coldata.CapacityFromHint(int(k))
+}
+
 func (t *topKSorter) Init() {
 	t.input.Init()
-	t.topK = t.allocator.NewMemBatchWithSize(t.inputTypes, int(t.k))
-	t.comparators = make([]vecComparator, len(t.inputTypes))
-	for i := range t.inputTypes {
-		typ := t.inputTypes[i]
-		t.comparators[i] = GetVecComparator(typ, 2)
-	}
-	t.output = t.allocator.NewMemBatchWithSize(t.inputTypes, int(coldata.BatchSize()))
+	t.comparators = make([]*vecComparator, len(t.inputTypes))
+	t.growComparators(1 /* minVecs */)
+	// At most K rows are emitted, so a small K doesn't need full-sized batches.
+	capacity := capacityForK(t.k)
+	t.output = t.allocator.NewMemBatchWithCapacity(t.inputTypes, capacity)
+	t.emitSel = make([]int, capacity)
 }
 
 func (t *topKSorter) Next(ctx context.Context) coldata.Batch {
@@ -107,57 +128,91 @@ func (t *topKSorter) Next(ctx context.Context) coldata.Batch {
 	return nil
 }
 
+// chunkAndRow returns the index of the vector of the chunk in the comparators
+// and the index of the row in that chunk for the idx'th row stored in chunks.
+func chunkAndRow(idx uint64) (vecIdx int, rowIdx uint16) {
+	return 1 + int(idx/uint64(coldata.BatchSize())), uint16(idx % uint64(coldata.BatchSize()))
+}
+
+// growComparators makes sure that the comparators can handle at least minVecs
+// vectors (the input batch and the chunks). The number of vectors is doubled
+// to amortize the cost of recreating the comparators.
+func (t *topKSorter) growComparators(minVecs int) {
+	if minVecs <= t.numComparatorVecs {
+		return
+	}
+	numVecs := 2 * t.numComparatorVecs
+	if numVecs < minVecs {
+		numVecs = minVecs
+	}
+	for i, typ := range t.inputTypes {
+		t.comparators[i] = GetVecComparator(typ, numVecs)
+	}
+	t.numComparatorVecs = numVecs
+	for chunkIdx, chunk := range t.chunks {
+		t.updateComparators(1+chunkIdx, chunk)
+	}
+}
+
 // spool reads in the entire input, always storing the top K rows it has seen so
-// far in o.topK. This is done by maintaining a max heap of indices into o.topK.
-// Whenever we encounter a row which is smaller than the max row in the heap,
-// we replace the max with that row.
+// far in t.chunks. This is done by maintaining a max heap of indices into
+// t.chunks. Whenever we encounter a row which is smaller than the max row in
+// the heap, we replace the max with that row.
 //
 // After all the input has been read, we pop everything off the heap to
 // determine the final output ordering. This is used in emit() to output the rows
 // in sorted order.
 func (t *topKSorter) spool(ctx context.Context) {
-	// Fill up t.topK by spooling up to K rows from the input.
+	// Fill up t.chunks by spooling up to K rows from the input.
 	inputBatch := t.input.Next(ctx)
 	inputBatchIdx := uint16(0)
-	spooledRows := uint16(0)
-	remainingRows := t.k
-	for remainingRows > 0 && inputBatch.Length() > 0 {
-		toLength := uint64(spooledRows)
-		fromLength := inputBatch.Length()
-		if remainingRows < inputBatch.Length() {
-			// t.topK will be full after this batch.
-			fromLength = remainingRows
-			inputBatchIdx = fromLength
+	for t.topKLength < t.k && inputBatch.Length() > 0 {
+		chunkLength := uint16(t.topKLength % uint64(coldata.BatchSize()))
+		if chunkLength == 0 {
+			// The last chunk is full (or there are no chunks yet), so we need to
+			// allocate a new one.
+			t.chunks = append(t.chunks, t.allocator.NewMemBatchWithCapacity(
+				t.inputTypes, capacityForK(t.k-t.topKLength),
+			))
+			t.chunksVecs = append(t.chunksVecs, t.chunks[len(t.chunks)-1].ColVecs()...)
+			t.growComparators(1 + len(t.chunks))
 		}
-		t.allocator.PerformOperation(t.topK.ColVecs(), func() {
+		chunk := t.chunks[len(t.chunks)-1]
+		toAppend := inputBatch.Length() - inputBatchIdx
+		if space := coldata.BatchSize() - chunkLength; toAppend > space {
+			toAppend = space
+		}
+		if remaining := t.k - t.topKLength; uint64(toAppend) > remaining {
+			toAppend = uint16(remaining)
+		}
+		t.allocator.PerformOperation(chunk.ColVecs(), func() {
 			for i := range t.inputTypes {
-				destVec := t.topK.ColVec(i)
-				vec := inputBatch.ColVec(i)
-				colType := t.inputTypes[i]
-				destVec.Append(
+				chunk.ColVec(i).Append(
 					coldata.SliceArgs{
-						ColType:   colType,
-						Src:       vec,
-						Sel:       inputBatch.Selection(),
-						DestIdx:   toLength,
-						SrcEndIdx: uint64(fromLength),
+						ColType:     t.inputTypes[i],
+						Src:         inputBatch.ColVec(i),
+						Sel:         inputBatch.Selection(),
+						DestIdx:     uint64(chunkLength),
+						SrcStartIdx: uint64(inputBatchIdx),
+						SrcEndIdx:   uint64(inputBatchIdx + toAppend),
 					},
 				)
 			}
-			spooledRows += fromLength
 		})
-		remainingRows -= fromLength
-		if fromLength == inputBatch.Length() {
+		chunk.SetLength(chunkLength + toAppend)
+		t.updateComparators(len(t.chunks), chunk)
+		t.topKLength += uint64(toAppend)
+		inputBatchIdx += toAppend
+		if inputBatchIdx == inputBatch.Length() {
 			inputBatch = t.input.Next(ctx)
+			inputBatchIdx = 0
 		}
 	}
-	t.topK.SetLength(spooledRows)
-	t.updateComparators(topKVecIdx, t.topK)
 
 	// Initialize the heap.
-	t.heap = make([]uint16, t.topK.Length())
+	t.heap = make([]uint64, t.topKLength)
 	for i := range t.heap {
-		t.heap[i] = uint16(i)
+		t.heap[i] = uint64(i)
 	}
 	heap.Init(t)
 
@@ -167,17 +222,17 @@ func (t *topKSorter) spool(ctx context.Context) {
 		t.updateComparators(inputVecIdx, inputBatch)
 		sel := inputBatch.Selection()
 		t.allocator.PerformOperation(
-			t.topK.ColVecs(),
+			t.chunksVecs,
 			func() {
 				for i := inputBatchIdx; i < inputBatch.Length(); i++ {
 					idx := i
 					if sel != nil {
-						idx = sel[i]
+						idx = uint16(sel[i])
 					}
-					maxIdx := t.heap[0]
-					if t.compareRow(inputVecIdx, topKVecIdx, idx, maxIdx) < 0 {
+					maxVecIdx, maxRowIdx := chunkAndRow(t.heap[0])
+					if t.compareRow(inputVecIdx, maxVecIdx, idx, maxRowIdx) < 0 {
 						for j := range t.inputTypes {
-							t.comparators[j].set(inputVecIdx, topKVecIdx, idx, maxIdx)
+							t.comparators[j].set(inputVecIdx, maxVecIdx, idx, maxRowIdx)
 						}
 						heap.Fix(t, 0)
 					}
@@ -188,43 +243,57 @@ func (t *topKSorter) spool(ctx context.Context) {
 		inputBatchIdx = 0
 	}
 
-	// t.topK now contains the top K rows unsorted. Create a selection vector
+	// t.chunks now contain the top K rows unsorted. Create a selection vector
 	// which specifies the rows in sorted order by popping everything off the
 	// heap. Note that it's a max heap so we need to fill the selection vector in
 	// reverse.
-	t.sel = make([]uint16, t.topK.Length())
-	for i := 0; i < int(t.topK.Length()); i++ {
-		t.sel[len(t.sel)-i-1] = heap.Pop(t).(uint16)
+	t.sel = make([]uint64, t.topKLength)
+	for i := range t.sel {
+		t.sel[len(t.sel)-i-1] = heap.Pop(t).(uint64)
 	}
 }
 
 func (t *topKSorter) emit() coldata.Batch {
 	t.output.ResetInternalBatch()
-	toEmit := t.topK.Length() - t.emitted
+	toEmit := t.topKLength - t.emitted
 	if toEmit == 0 {
 		// We're done.
 		return coldata.ZeroBatch
 	}
-	if toEmit > coldata.BatchSize() {
-		toEmit = coldata.BatchSize()
+	if toEmit > uint64(coldata.BatchSize()) {
+		toEmit = uint64(coldata.BatchSize())
 	}
 	t.allocator.PerformOperation(t.output.ColVecs(), func() {
-		for i := range t.inputTypes {
-			vec := t.output.ColVec(i)
-			vec.Copy(
-				coldata.CopySliceArgs{
-					SliceArgs: coldata.SliceArgs{
-						ColType:     t.inputTypes[i],
-						Src:         t.topK.ColVec(i),
-						Sel:         t.sel,
-						SrcStartIdx: uint64(t.emitted),
-						SrcEndIdx:   uint64(t.emitted + toEmit),
+		// The rows are copied in runs of consecutive (in the output order) rows
+		// that belong to the same chunk.
+		for runStart := uint64(0); runStart < toEmit; {
+			vecIdx, _ := chunkAndRow(t.sel[t.emitted+runStart])
+			runEnd := runStart
+			for ; runEnd < toEmit; runEnd++ {
+				runVecIdx, rowIdx := chunkAndRow(t.sel[t.emitted+runEnd])
+				if runVecIdx != vecIdx {
+					break
+				}
+				t.emitSel[runEnd] = int(rowIdx)
+			}
+			for i := range t.inputTypes {
+				t.output.ColVec(i).Copy(
+					coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							ColType:     t.inputTypes[i],
+							Src:         t.chunks[vecIdx-1].ColVec(i),
+							Sel:         t.emitSel,
+							DestIdx:     runStart,
+							SrcStartIdx: runStart,
+							SrcEndIdx:   runEnd,
+						},
 					},
-				},
-			)
+				)
+			}
+			runStart = runEnd
 		}
 	})
-	t.output.SetLength(toEmit)
+	t.output.SetLength(uint16(toEmit))
 	t.emitted += toEmit
 	return t.output
 }
@@ -260,7 +329,9 @@ func (t *topKSorter) Len() int {
 
 // Less is part of heap.Interface and is only meant to be used internally.
 func (t *topKSorter) Less(i, j int) bool {
-	return t.compareRow(topKVecIdx, topKVecIdx, t.heap[i], t.heap[j]) > 0
+	vecIdx1, rowIdx1 := chunkAndRow(t.heap[i])
+	vecIdx2, rowIdx2 := chunkAndRow(t.heap[j])
+	return t.compareRow(vecIdx1, vecIdx2, rowIdx1, rowIdx2) > 0
 }
 
 // Swap is part of heap.Interface and is only meant to be used internally.
@@ -270,7 +341,7 @@ func (t *topKSorter) Swap(i, j int) {
 
 // Push is part of heap.Interface and is only meant to be used internally.
 func (t *topKSorter) Push(x interface{}) {
-	t.heap = append(t.heap, x.(uint16))
+	t.heap = append(t.heap, x.(uint64))
 }
 
 // Pop is part of heap.Interface and is only meant to be used internally.
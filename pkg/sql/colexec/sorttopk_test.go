@@ -11,11 +11,14 @@
 package colexec
 
 import (
+	"context"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTopKSorter(t *testing.T) {
@@ -27,7 +30,7 @@ func TestTopKSorter(t *testing.T) {
 		expected tuples
 		ordCols  []execinfrapb.Ordering_Column
 		typ      []coltypes.T
-		k        uint16
+		k        uint64
 	}{
 		{
 			name:     "k < input length",
@@ -64,6 +67,14 @@ func TestTopKSorter(t *testing.T) {
 			},
 			k: 3,
 		},
+		{
+			name:     "bytes",
+			tuples:   tuples{{"e"}, {"bb"}, {"dddd"}, {"a"}, {nil}, {"ccc"}},
+			expected: tuples{{nil}, {"a"}, {"bb"}, {"ccc"}},
+			typ:      []coltypes.T{coltypes.Bytes},
+			ordCols:  []execinfrapb.Ordering_Column{{ColIdx: 0}},
+			k:        4,
+		},
 	}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
@@ -73,3 +84,23 @@ func TestTopKSorter(t *testing.T) {
 		})
 	}
 }
+
+// TestTopKSorterSmallK verifies that the top K sorter doesn't allocate
+// full-sized batches when K is small.
+func TestTopKSorterSmallK(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const k = 3
+	typs := []coltypes.T{coltypes.Int64}
+	input := newOpTestInput(coldata.BatchSize(), tuples{{5}, {4}, {3}, {2}, {1}}, typs)
+	sorter := NewTopKSorter(testAllocator, input, typs, []execinfrapb.Ordering_Column{{ColIdx: 0}}, k)
+	sorter.Init()
+	b := sorter.Next(context.Background())
+	require.Equal(t, uint16(k), b.Length())
+	require.Equal(t, []int64{1, 2, 3}, b.ColVec(0).Int64()[:k])
+
+	topK := sorter.(*topKSorter)
+	require.Equal(t, k, topK.output.(*coldata.MemBatch).Capacity())
+	require.Equal(t, 1, len(topK.chunks))
+	require.Equal(t, k, topK.chunks[0].(*coldata.MemBatch).Capacity())
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execpb"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
@@ -38,6 +39,11 @@ type VectorizedStatsCollector struct {
 	// wrapped Operator is feeding into. It must be started right before
 	// returning a batch when Nexted. It is used by the "output" Operator.
 	outputWatch *timeutil.StopWatch
+
+	// memMonitors are the memory monitors of the wrapped Operator (if it is a
+	// buffering one). They are used to record the maximum amount of memory
+	// allocated by the Operator.
+	memMonitors []*mon.BytesMonitor
 }
 
 var _ Operator = &VectorizedStatsCollector{}
@@ -45,9 +51,13 @@ var _ Operator = &VectorizedStatsCollector{}
 // NewVectorizedStatsCollector creates a new VectorizedStatsCollector which
 // wraps op that corresponds to a processor with ProcessorID id. isStall
 // indicates whether stall or execution time is being measured. inputWatch must
-// be non-nil.
+// be non-nil. memMonitors are the memory monitors used by op (if any).
 func NewVectorizedStatsCollector(
-	op Operator, id int32, isStall bool, inputWatch *timeutil.StopWatch,
+	op Operator,
+	id int32,
+	isStall bool,
+	inputWatch *timeutil.StopWatch,
+	memMonitors []*mon.BytesMonitor,
 ) *VectorizedStatsCollector {
 	if inputWatch == nil {
 		execerror.VectorizedInternalPanic("input watch for VectorizedStatsCollector is nil")
@@ -56,6 +66,7 @@ func NewVectorizedStatsCollector(
 		Operator:        op,
 		VectorizedStats: execpb.VectorizedStats{ID: id, Stall: isStall},
 		inputWatch:      inputWatch,
+		memMonitors:     memMonitors,
 	}
 }
 
@@ -97,7 +108,12 @@ func (vsc *VectorizedStatsCollector) Next(ctx context.Context) coldata.Batch {
 	return batch
 }
 
-// FinalizeStats records the time measured by the stop watch into the stats.
+// FinalizeStats records the time measured by the stop watch and the maximum
+// amount of memory allocated by the wrapped Operator into the stats.
 func (vsc *VectorizedStatsCollector) FinalizeStats() {
 	vsc.Time = vsc.inputWatch.Elapsed()
+	vsc.MaxAllocatedMem = 0
+	for _, memMon := range vsc.memMonitors {
+		vsc.MaxAllocatedMem += memMon.MaximumBytes()
+	}
 }
@@ -17,9 +17,12 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/stretchr/testify/require"
 )
@@ -29,7 +32,7 @@ func TestNumBatches(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	nBatches := 10
 	noop := NewNoop(makeFiniteChunksSourceWithBatchSize(nBatches, int(coldata.BatchSize())))
-	vsc := NewVectorizedStatsCollector(noop, 0 /* id */, true /* isStall */, timeutil.NewStopWatch())
+	vsc := NewVectorizedStatsCollector(noop, 0 /* id */, true /* isStall */, timeutil.NewStopWatch(), nil /* memMonitors */)
 	vsc.Init()
 	for {
 		b := vsc.Next(context.Background())
@@ -46,7 +49,7 @@ func TestNumTuples(t *testing.T) {
 	nBatches := 10
 	for _, batchSize := range []int{1, 16, 1024} {
 		noop := NewNoop(makeFiniteChunksSourceWithBatchSize(nBatches, batchSize))
-		vsc := NewVectorizedStatsCollector(noop, 0 /* id */, true /* isStall */, timeutil.NewStopWatch())
+		vsc := NewVectorizedStatsCollector(noop, 0 /* id */, true /* isStall */, timeutil.NewStopWatch(), nil /* memMonitors */)
 		vsc.Init()
 		for {
 			b := vsc.Next(context.Background())
@@ -58,6 +61,35 @@ func TestNumTuples(t *testing.T) {
 	}
 }
 
+// TestMaxAllocatedMem is a unit test for MaxAllocatedMem field of
+// VectorizedStats.
+func TestMaxAllocatedMem(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	memMonitor := execinfra.NewTestMemMonitor(ctx, cluster.MakeTestingClusterSettings())
+	defer memMonitor.Stop(ctx)
+	memAcc := memMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+
+	noop := NewNoop(makeFiniteChunksSourceWithBatchSize(1 /* nBatches */, int(coldata.BatchSize())))
+	vsc := NewVectorizedStatsCollector(
+		noop, 0 /* id */, true /* isStall */, timeutil.NewStopWatch(), []*mon.BytesMonitor{memMonitor},
+	)
+	vsc.Init()
+	// Emulate a buffering operator that allocates some memory and then
+	// releases some of it.
+	const maxAllocated = 1 << 20
+	require.NoError(t, memAcc.Grow(ctx, maxAllocated))
+	memAcc.Shrink(ctx, maxAllocated/2)
+	for b := vsc.Next(ctx); b.Length() > 0; b = vsc.Next(ctx) {
+	}
+	vsc.FinalizeStats()
+	// Note that the monitor reserves the memory in chunks, so the maximum can
+	// be larger than the amount actually allocated.
+	require.Equal(t, memMonitor.MaximumBytes(), vsc.MaxAllocatedMem)
+	require.True(t, vsc.MaxAllocatedMem >= maxAllocated)
+}
+
 // TestVectorizedStatsCollector is an integration test for the
 // VectorizedStatsCollector. It creates two inputs and feeds them into the
 // merge joiner and makes sure that all the stats measured on the latter are as
@@ -72,14 +104,14 @@ func TestVectorizedStatsCollector(t *testing.T) {
 			OneInputNode: NewOneInputNode(makeFiniteChunksSourceWithBatchSize(nBatches, int(coldata.BatchSize()))),
 			timeSource:   timeSource,
 		}
-		leftInput := NewVectorizedStatsCollector(leftSource, 0 /* id */, true /* isStall */, timeutil.NewTestStopWatch(timeSource.Now))
+		leftInput := NewVectorizedStatsCollector(leftSource, 0 /* id */, true /* isStall */, timeutil.NewTestStopWatch(timeSource.Now), nil /* memMonitors */)
 		leftInput.SetOutputWatch(mjInputWatch)
 
 		rightSource := &timeAdvancingOperator{
 			OneInputNode: NewOneInputNode(makeFiniteChunksSourceWithBatchSize(nBatches, int(coldata.BatchSize()))),
 			timeSource:   timeSource,
 		}
-		rightInput := NewVectorizedStatsCollector(rightSource, 1 /* id */, true /* isStall */, timeutil.NewTestStopWatch(timeSource.Now))
+		rightInput := NewVectorizedStatsCollector(rightSource, 1 /* id */, true /* isStall */, timeutil.NewTestStopWatch(timeSource.Now), nil /* memMonitors */)
 		rightInput.SetOutputWatch(mjInputWatch)
 
 		mergeJoiner, err := NewMergeJoinOp(
@@ -101,7 +133,7 @@ func TestVectorizedStatsCollector(t *testing.T) {
 			OneInputNode: NewOneInputNode(mergeJoiner),
 			timeSource:   timeSource,
 		}
-		mjStatsCollector := NewVectorizedStatsCollector(timeAdvancingMergeJoiner, 2 /* id */, false /* isStall */, mjInputWatch)
+		mjStatsCollector := NewVectorizedStatsCollector(timeAdvancingMergeJoiner, 2 /* id */, false /* isStall */, mjInputWatch, nil /* memMonitors */)
 
 		// The inputs are identical, so the merge joiner should output nBatches
 		// batches with each having coldata.BatchSize() tuples.
@@ -27,7 +27,12 @@ var allSupportedSQLTypes = []types.T{
 	*types.Float4,
 	*types.String,
 	*types.Uuid,
+	*types.INet,
 	*types.Timestamp,
 	*types.TimestampTZ,
 	*types.Interval,
+	*types.Jsonb,
+	*types.IntArray,
+	*types.StringArray,
+	*types.MakeCollatedString(types.String, "en"),
 }
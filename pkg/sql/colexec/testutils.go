@@ -53,7 +53,7 @@ type RepeatableBatchSource struct {
 	internalBatch coldata.Batch
 	batchLen      uint16
 	// sel specifies the desired selection vector for the batch.
-	sel []uint16
+	sel []int
 
 	batchesToReturn int
 	batchesReturned int
@@ -69,7 +69,7 @@ func NewRepeatableBatchSource(batch coldata.Batch) *RepeatableBatchSource {
 		batchLen:      batch.Length(),
 	}
 	if batch.Selection() != nil {
-		src.sel = make([]uint16, batch.Length())
+		src.sel = make([]int, batch.Length())
 		copy(src.sel, batch.Selection())
 	}
 	return src
@@ -0,0 +1,156 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// isTimestampType returns whether typ is either TIMESTAMP or TIMESTAMPTZ.
+// Both types are physically represented as time.Time, but the conversions
+// between them depend on the session timezone.
+func isTimestampType(typ *types.T) bool {
+	return typ.Family() == types.TimestampFamily || typ.Family() == types.TimestampTZFamily
+}
+
+// NewTimestampCastOp returns an Operator that projects into outputIdx Vec the
+// result of casting the timestamps in colIdx Vec from fromType to toType,
+// both of which must be either TIMESTAMP or TIMESTAMPTZ. The conversions
+// between TIMESTAMP and TIMESTAMPTZ are performed in the session timezone of
+// evalCtx, the same way as tree.PerformCast does.
+func NewTimestampCastOp(
+	allocator *Allocator,
+	evalCtx *tree.EvalContext,
+	input Operator,
+	colIdx int,
+	outputIdx int,
+	fromType *types.T,
+	toType *types.T,
+) (Operator, error) {
+	if !isTimestampType(fromType) || !isTimestampType(toType) {
+		return nil, errors.AssertionFailedf("unexpected timestamp cast %s -> %s", fromType, toType)
+	}
+	return &timestampCastOp{
+		OneInputNode: NewOneInputNode(input),
+		allocator:    allocator,
+		colIdx:       colIdx,
+		outputIdx:    outputIdx,
+		loc:          evalCtx.GetLocation(),
+		fromTZ:       fromType.Family() == types.TimestampTZFamily,
+		toTZ:         toType.Family() == types.TimestampTZFamily,
+		roundTo:      tree.TimeFamilyPrecisionToRoundDuration(toType.Precision()),
+	}, nil
+}
+
+// timestampCastOp is an Operator that projects into outputIdx Vec the
+// timestamps in colIdx Vec cast to TIMESTAMP or TIMESTAMPTZ.
+type timestampCastOp struct {
+	OneInputNode
+	allocator *Allocator
+	colIdx    int
+	outputIdx int
+
+	loc     *time.Location
+	fromTZ  bool
+	toTZ    bool
+	roundTo time.Duration
+}
+
+var _ Operator = &timestampCastOp{}
+
+func (o *timestampCastOp) Init() {
+	o.input.Init()
+}
+
+func (o *timestampCastOp) Next(ctx context.Context) coldata.Batch {
+	batch := o.input.Next(ctx)
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.allocator.MaybeAddColumn(batch, coltypes.Timestamp, o.outputIdx)
+	vec := batch.ColVec(o.colIdx)
+	projVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		col, projCol := vec.Timestamp(), projVec.Timestamp()
+		nulls, projNulls := vec.Nulls(), projVec.Nulls()
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				if nulls.NullAt(uint16(i)) {
+					projNulls.SetNull(uint16(i))
+				} else {
+					projCol[i] = o.cast(col[i])
+				}
+			}
+		} else {
+			col = col[:n]
+			for i := range col {
+				if nulls.NullAt(uint16(i)) {
+					projNulls.SetNull(uint16(i))
+				} else {
+					projCol[i] = o.cast(col[i])
+				}
+			}
+		}
+	})
+	return batch
+}
+
+// cast returns the result of casting t to the output type.
+func (o *timestampCastOp) cast(t time.Time) time.Time {
+	switch {
+	case !o.fromTZ && o.toTZ:
+		// Interpret the timestamp in the session timezone.
+		_, before := t.Zone()
+		_, after := t.In(o.loc).Zone()
+		t = t.Add(time.Duration(before-after) * time.Second)
+	case o.fromTZ && !o.toTZ:
+		// Strip the session timezone (see DTimestampTZ.EvalAtTimeZone).
+		_, offset := t.In(o.loc).Zone()
+		t = t.UTC().Add(time.Duration(offset) * time.Second).Round(time.Microsecond)
+	}
+	return t.Round(o.roundTo)
+}
+
+// isMixedTimestampComparison returns whether a binary operation compares a
+// TIMESTAMP with a TIMESTAMPTZ. Such comparisons depend on the session
+// timezone, so they can't be performed on the physical values directly.
+func isMixedTimestampComparison(op tree.Operator, left, right tree.TypedExpr) bool {
+	if _, ok := op.(tree.ComparisonOperator); !ok {
+		return false
+	}
+	lFamily, rFamily := left.ResolvedType().Family(), right.ResolvedType().Family()
+	return (lFamily == types.TimestampFamily && rFamily == types.TimestampTZFamily) ||
+		(lFamily == types.TimestampTZFamily && rFamily == types.TimestampFamily)
+}
+
+// timestampTZArg returns the TIMESTAMP argument of a mixed timestamp
+// comparison cast to TIMESTAMPTZ (as tree.compareTimestamps does), so that the
+// comparison of the returned arguments is the comparison of the physical
+// values. Constant arguments are cast during the planning.
+func timestampTZArg(evalCtx *tree.EvalContext, arg tree.TypedExpr) (tree.TypedExpr, error) {
+	if arg.ResolvedType().Family() != types.TimestampFamily {
+		return arg, nil
+	}
+	if d, ok := arg.(tree.Datum); ok {
+		castDatum, err := tree.PerformCast(evalCtx, d, types.TimestampTZ)
+		return castDatum, err
+	}
+	castExpr, err := tree.NewTypedCastExpr(arg, types.TimestampTZ)
+	return castExpr, err
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimestampCastOp verifies that the casts between TIMESTAMP and
+// TIMESTAMPTZ produce the same results as tree.PerformCast in several session
+// timezones.
+func TestTimestampCastOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+
+	const numTuples = 2048
+	typs := []coltypes.T{coltypes.Timestamp, coltypes.Timestamp}
+	input := make([]time.Time, numTuples)
+	for i := range input {
+		input[i] = time.Unix(rng.Int63n(4e9)-2e9, rng.Int63n(1e9)).UTC().Round(time.Microsecond)
+	}
+
+	for _, loc := range []*time.Location{
+		time.UTC,
+		time.FixedZone("UTC-5", -5*60*60),
+		time.FixedZone("UTC+5:45", 5*60*60+45*60),
+	} {
+		evalCtx.SessionData.DataConversion.Location = loc
+		for _, tc := range []struct {
+			fromType, toType *types.T
+		}{
+			{fromType: types.Timestamp, toType: types.TimestampTZ},
+			{fromType: types.TimestampTZ, toType: types.Timestamp},
+			{fromType: types.Timestamp, toType: types.MakeTimestamp(0)},
+			{fromType: types.TimestampTZ, toType: types.MakeTimestampTZ(3)},
+		} {
+			t.Run(fmt.Sprintf("%s/%s->%s", loc, tc.fromType.SQLString(), tc.toType.SQLString()), func(t *testing.T) {
+				b := testAllocator.NewMemBatchWithSize(typs, numTuples)
+				vec, projVec := b.ColVec(0), b.ColVec(1)
+				copy(vec.Timestamp(), input)
+				// Every seventh value is NULL.
+				for i := 0; i < numTuples; i += 7 {
+					vec.Nulls().SetNull(uint16(i))
+				}
+
+				source := newChunkingBatchSource(typs, []coldata.Vec{vec, projVec}, numTuples)
+				op, err := NewTimestampCastOp(testAllocator, &evalCtx, source, 0, 1, tc.fromType, tc.toType)
+				require.NoError(t, err)
+				op.Init()
+
+				var idx int
+				for batch := op.Next(ctx); batch.Length() > 0; batch = op.Next(ctx) {
+					out := batch.ColVec(1)
+					for i := uint16(0); i < batch.Length(); i++ {
+						if idx%7 == 0 {
+							require.True(t, out.Nulls().NullAt(i))
+							idx++
+							continue
+						}
+						var d tree.Datum
+						if tc.fromType.Family() == types.TimestampTZFamily {
+							d = tree.MakeDTimestampTZ(input[idx], time.Microsecond)
+						} else {
+							d = tree.MakeDTimestamp(input[idx], time.Microsecond)
+						}
+						expected, err := tree.PerformCast(&evalCtx, d, tc.toType)
+						require.NoError(t, err)
+						actual := out.Timestamp()[i]
+						var expectedTime time.Time
+						switch e := expected.(type) {
+						case *tree.DTimestamp:
+							expectedTime = e.Time
+						case *tree.DTimestampTZ:
+							expectedTime = e.Time
+						}
+						require.False(t, out.Nulls().NullAt(i))
+						require.True(
+							t, expectedTime.Equal(actual), "cast of %s: expected %s, found %s", d, expectedTime, actual,
+						)
+						idx++
+					}
+				}
+				require.Equal(t, numTuples, idx)
+			})
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// tupleElems returns the elements of the tuple expression e if e is either a
+// tuple of expressions or a constant tuple.
+func tupleElems(e tree.TypedExpr) ([]tree.TypedExpr, bool) {
+	switch t := e.(type) {
+	case *tree.Tuple:
+		elems := make([]tree.TypedExpr, len(t.Exprs))
+		for i := range t.Exprs {
+			elems[i] = t.Exprs[i].(tree.TypedExpr)
+		}
+		return elems, true
+	case *tree.DTuple:
+		elems := make([]tree.TypedExpr, len(t.D))
+		for i := range t.D {
+			elems[i] = t.D[i]
+		}
+		return elems, true
+	}
+	return nil, false
+}
+
+// isTupleComparison returns whether a binary operation compares two tuples
+// (for example, (a, b) < (c, d)).
+func isTupleComparison(op tree.Operator, left, right tree.TypedExpr) bool {
+	cmpOp, ok := op.(tree.ComparisonOperator)
+	if !ok {
+		return false
+	}
+	switch cmpOp {
+	case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE,
+		tree.IsDistinctFrom, tree.IsNotDistinctFrom:
+	default:
+		return false
+	}
+	return left.ResolvedType().Family() == types.TupleFamily &&
+		right.ResolvedType().Family() == types.TupleFamily
+}
+
+// expandTupleComparison returns an expression equivalent to the comparison
+// cmpOp of the tuples left and right that only contains the comparisons of
+// the elements of the tuples, so that it can be planned using the operators
+// that work on scalar values. The tuples are compared lexicographically, the
+// same way as tree.cmpOpTupleFn does. For example, (a, b) = (c, d) becomes
+// a = c AND b = d, (a, b) != (c, d) becomes a != c OR b != d, and
+// (a, b) < (c, d) becomes a < c OR (a = c AND b < d). These expressions have
+// the same semantics with respect to NULLs as the tuple comparisons.
+func expandTupleComparison(
+	cmpOp tree.ComparisonOperator, left, right tree.TypedExpr,
+) (tree.TypedExpr, error) {
+	leftElems, leftOk := tupleElems(left)
+	rightElems, rightOk := tupleElems(right)
+	if !leftOk || !rightOk || len(leftElems) != len(rightElems) {
+		return nil, errors.Errorf("unsupported tuple comparison %s %s %s", left, cmpOp, right)
+	}
+	for i := range leftElems {
+		if leftElems[i].ResolvedType().Family() == types.UnknownFamily ||
+			rightElems[i].ResolvedType().Family() == types.UnknownFamily {
+			return nil, errors.Errorf("tuple comparison %s %s %s with NULL elements is unsupported", left, cmpOp, right)
+		}
+	}
+	var expand func(i int) tree.TypedExpr
+	switch cmpOp {
+	case tree.EQ, tree.IsNotDistinctFrom, tree.NE, tree.IsDistinctFrom:
+		negated := cmpOp == tree.NE || cmpOp == tree.IsDistinctFrom
+		expand = func(i int) tree.TypedExpr {
+			elemCmp := tree.NewTypedComparisonExpr(cmpOp, leftElems[i], rightElems[i])
+			if i == len(leftElems)-1 {
+				return elemCmp
+			}
+			if negated {
+				return tree.NewTypedOrExpr(elemCmp, expand(i+1))
+			}
+			return tree.NewTypedAndExpr(elemCmp, expand(i+1))
+		}
+	case tree.LT, tree.LE, tree.GT, tree.GE:
+		// All of the elements but the last one are compared strictly.
+		strictOp := tree.LT
+		if cmpOp == tree.GT || cmpOp == tree.GE {
+			strictOp = tree.GT
+		}
+		expand = func(i int) tree.TypedExpr {
+			if i == len(leftElems)-1 {
+				return tree.NewTypedComparisonExpr(cmpOp, leftElems[i], rightElems[i])
+			}
+			return tree.NewTypedOrExpr(
+				tree.NewTypedComparisonExpr(strictOp, leftElems[i], rightElems[i]),
+				tree.NewTypedAndExpr(
+					tree.NewTypedComparisonExpr(tree.EQ, leftElems[i], rightElems[i]),
+					expand(i+1),
+				),
+			)
+		}
+	default:
+		return nil, errors.AssertionFailedf("unexpected tuple comparison %s", cmpOp)
+	}
+	if len(leftElems) == 0 {
+		// The empty tuples are equal.
+		switch cmpOp {
+		case tree.EQ, tree.IsNotDistinctFrom, tree.LE, tree.GE:
+			return tree.DBoolTrue, nil
+		}
+		return tree.DBoolFalse, nil
+	}
+	return expand(0), nil
+}
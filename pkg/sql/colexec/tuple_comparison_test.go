@@ -0,0 +1,135 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestTupleComparisonAndIsDistinctFrom(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	inputTuples := tuples{
+		{1, 2, 1, 3},
+		{1, 2, 1, 2},
+		{1, 2, 0, 5},
+		{1, nil, 1, 3},
+		{1, nil, 2, 3},
+		{nil, 2, 1, 2},
+		{nil, nil, nil, nil},
+		{1, nil, 1, nil},
+	}
+	testCases := []struct {
+		expr string
+		// expected contains the result of the expression for every input tuple.
+		expected []interface{}
+	}{
+		{
+			expr:     "(@1, @2) < (@3, @4)",
+			expected: []interface{}{true, false, false, nil, true, nil, nil, nil},
+		},
+		{
+			expr:     "(@1, @2) >= (1, 2)",
+			expected: []interface{}{true, true, true, nil, nil, nil, nil, nil},
+		},
+		{
+			expr:     "(@1, @2) = (@3, @4)",
+			expected: []interface{}{false, true, false, nil, false, nil, nil, nil},
+		},
+		{
+			expr:     "(@1, @2) != (@3, @4)",
+			expected: []interface{}{true, false, true, nil, true, nil, nil, nil},
+		},
+		{
+			expr:     "(@1, @2) IS NOT DISTINCT FROM (@3, @4)",
+			expected: []interface{}{false, true, false, false, false, false, true, true},
+		},
+		{
+			expr:     "(@1, @2) IS DISTINCT FROM (@3, @4)",
+			expected: []interface{}{true, false, true, true, true, true, false, false},
+		},
+		{
+			expr:     "@1 IS DISTINCT FROM @3",
+			expected: []interface{}{false, false, true, false, true, true, false, false},
+		},
+		{
+			expr:     "@1 IS NOT DISTINCT FROM 1",
+			expected: []interface{}{true, true, true, true, true, false, false, true},
+		},
+	}
+
+	typs := []types.T{*types.Int, *types.Int, *types.Int, *types.Int}
+	for _, tc := range testCases {
+		for _, filter := range []bool{false, true} {
+			// When the expression is used as a filter, only the tuples for
+			// which it is true are selected.
+			var expected tuples
+			post := execinfrapb.PostProcessSpec{}
+			if filter {
+				post.Filter = execinfrapb.Expression{Expr: tc.expr}
+				for i, res := range tc.expected {
+					if res == true {
+						expected = append(expected, inputTuples[i])
+					}
+				}
+			} else {
+				post.RenderExprs = []execinfrapb.Expression{{Expr: tc.expr}}
+				for _, res := range tc.expected {
+					expected = append(expected, tuple{res})
+				}
+			}
+			name := tc.expr
+			if filter {
+				name = "WHERE " + name
+			}
+			t.Run(name, func(t *testing.T) {
+				runTests(t, []tuples{inputTuples}, expected, orderedVerifier, func(input []Operator) (Operator, error) {
+					spec := &execinfrapb.ProcessorSpec{
+						Input: []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+						Core: execinfrapb.ProcessorCoreUnion{
+							Noop: &execinfrapb.NoopCoreSpec{},
+						},
+						Post: post,
+					}
+					args := NewColOperatorArgs{
+						Spec:                spec,
+						Inputs:              input,
+						StreamingMemAccount: testMemAcc,
+					}
+					args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+					result, err := NewColOperator(ctx, flowCtx, args)
+					if err != nil {
+						return nil, err
+					}
+					return result.Op, nil
+				})
+			})
+		}
+	}
+}
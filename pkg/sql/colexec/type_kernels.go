@@ -0,0 +1,80 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+)
+
+// TypeKernels is a set of kernels that operate on the values of a single
+// coltypes.T. The operators for which the per-value performance is not
+// critical look the kernels up by type instead of having a copy of the
+// type-specialized code generated for every type, which keeps the binary
+// smaller and allows for the types to be added without changing the
+// operators. None of the kernels handle NULL values, those are taken care of
+// by the callers. A kernel that a type doesn't support is left nil.
+type TypeKernels struct {
+	// Compare returns -1, 0, or 1 depending on whether the value at leftIdx in
+	// left is less than, equal to, or greater than the value at rightIdx in
+	// right.
+	Compare func(left coldata.Vec, leftIdx int, right coldata.Vec, rightIdx int) int
+	// Hash updates the hashes in buckets with the values of vec, one value per
+	// bucket (the values are taken from the indices in sel if it is non-nil).
+	// The NULL values are skipped.
+	Hash func(buckets []uint64, vec coldata.Vec, sel []int)
+	// Set sets the value at dstIdx in dst to the value at srcIdx in src. Note
+	// that for the variable-width types the values must be set in increasing
+	// order of dstIdx.
+	Set func(dst coldata.Vec, dstIdx int, src coldata.Vec, srcIdx int)
+}
+
+// typeKernels contains the registered kernels for each type.
+var typeKernels = make(map[coltypes.T]*TypeKernels)
+
+// RegisterTypeKernels registers the kernels for the given type. It must only
+// be called from init functions, and it panics if the kernels for the type
+// have already been registered.
+func RegisterTypeKernels(t coltypes.T, kernels TypeKernels) {
+	if _, ok := typeKernels[t]; ok {
+		panic(fmt.Sprintf("kernels for type %s have already been registered", t))
+	}
+	typeKernels[t] = &kernels
+}
+
+// getTypeKernels returns the kernels registered for the given type.
+func getTypeKernels(t coltypes.T) *TypeKernels {
+	kernels, ok := typeKernels[t]
+	if !ok {
+		execerror.VectorizedInternalPanic(fmt.Sprintf("no kernels registered for type %s", t))
+	}
+	return kernels
+}
+
+// The kernels of the types that don't have a template-generated implementation
+// are registered by hand.
+func init() {
+	RegisterTypeKernels(coltypes.Array, TypeKernels{
+		Set: func(dst coldata.Vec, dstIdx int, src coldata.Vec, srcIdx int) {
+			dst.Array().CopySlice(src.Array(), dstIdx, srcIdx, srcIdx+1)
+		},
+	})
+	RegisterTypeKernels(coltypes.Datum, TypeKernels{
+		// The datums can only be compared and hashed with an evaluation context,
+		// so these kernels are not provided.
+		Set: func(dst coldata.Vec, dstIdx int, src coldata.Vec, srcIdx int) {
+			dst.Datum().Set(dstIdx, src.Datum().Get(srcIdx))
+		},
+	})
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeKernels(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	n := int(coldata.BatchSize())
+
+	for _, typ := range coltypes.AllTypes {
+		t.Run(typ.String(), func(t *testing.T) {
+			kernels := getTypeKernels(typ)
+			require.NotNil(t, kernels.Compare)
+			require.NotNil(t, kernels.Hash)
+			require.NotNil(t, kernels.Set)
+
+			vec := testAllocator.NewMemColumn(typ, n)
+			coldata.RandomVec(rng, typ, 0 /* bytesFixedLength */, vec, n, 0.2 /* nullProbability */)
+
+			// The hash kernel must be consistent with the hash table.
+			var ht hashTable
+			ht.seed = defaultHashTableSeed
+			sel := make([]int, 0, n)
+			for i := 0; i < n; i += 1 + rng.Intn(3) {
+				sel = append(sel, i)
+			}
+			for _, sel := range [][]int{nil, sel} {
+				nKeys := n
+				if sel != nil {
+					nKeys = len(sel)
+				}
+				expected, actual := make([]uint64, nKeys), make([]uint64, nKeys)
+				ht.initHash(expected, uint64(nKeys))
+				ht.rehash(ctx, expected, 0 /* keyIdx */, typ, vec, uint64(nKeys), sel)
+				ht.initHash(actual, uint64(nKeys))
+				kernels.Hash(actual, vec, sel)
+				require.Equal(t, expected, actual)
+			}
+
+			// Set the values in the reverse order into another vector and make
+			// sure that they compare as equal to the original ones.
+			other := testAllocator.NewMemColumn(typ, n)
+			for i := 0; i < n; i++ {
+				kernels.Set(other, i, vec, n-1-i)
+			}
+			for i := 0; i < n; i++ {
+				if !vec.Nulls().NullAt(uint16(n - 1 - i)) {
+					require.Equal(t, 0, kernels.Compare(other, i, vec, n-1-i))
+					require.Equal(t, 0, kernels.Compare(vec, n-1-i, other, i))
+				}
+			}
+			// Compare must be antisymmetric.
+			for i := 0; i < 10; i++ {
+				l, r := rng.Intn(n), rng.Intn(n)
+				require.Equal(t, kernels.Compare(vec, l, vec, r), -kernels.Compare(vec, r, vec, l))
+			}
+		})
+	}
+
+	for _, typ := range []coltypes.T{coltypes.Array, coltypes.Datum} {
+		require.NotNil(t, getTypeKernels(typ).Set, "%s", typ)
+	}
+	require.Panics(t, func() { RegisterTypeKernels(coltypes.Int64, TypeKernels{}) })
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for type_kernels.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	// {{/*
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	// */}}
+)
+
+// {{/*
+
+// Declarations to make the template compile properly.
+
+// Dummy import to pull in "bytes" package.
+var _ bytes.Buffer
+
+// Dummy import to pull in "math" package.
+var _ = math.MaxInt64
+
+// Dummy import to pull in "reflect" package.
+var _ reflect.SliceHeader
+
+// Dummy import to pull in "unsafe" package.
+var _ unsafe.Pointer
+
+// _COMPARE is the template function for assigning the first input to the
+// result of comparing second and third inputs.
+func _COMPARE(_, _, _ string) bool {
+	execerror.VectorizedInternalPanic("")
+}
+
+// _ASSIGN_HASH is the template function for assigning the first input to the
+// result of the hash value of the second input.
+func _ASSIGN_HASH(_, _ interface{}) uint64 {
+	execerror.VectorizedInternalPanic("")
+}
+
+// */}}
+
+func init() {
+	// {{range .}}
+	RegisterTypeKernels(coltypes._TYPE, TypeKernels{
+		Compare: compare_TYPE,
+		Hash:    hash_TYPE,
+		Set:     set_TYPE,
+	})
+	// {{end}}
+}
+
+// {{range .}}
+
+func compare_TYPE(left coldata.Vec, leftIdx int, right coldata.Vec, rightIdx int) int {
+	leftCol, rightCol := left._TYPE(), right._TYPE()
+	l := execgen.UNSAFEGET(leftCol, leftIdx)
+	r := execgen.UNSAFEGET(rightCol, rightIdx)
+	var cmp int
+	_COMPARE("cmp", "l", "r")
+	return cmp
+}
+
+func hash_TYPE(buckets []uint64, vec coldata.Vec, sel []int) {
+	keys, nulls := vec._TYPE(), vec.Nulls()
+	hasNulls := vec.MaybeHasNulls()
+	for i := range buckets {
+		selIdx := i
+		if sel != nil {
+			selIdx = sel[i]
+		}
+		if hasNulls && nulls.NullAt(uint16(selIdx)) {
+			continue
+		}
+		v := execgen.UNSAFEGET(keys, selIdx)
+		p := uintptr(buckets[i])
+		_ASSIGN_HASH(p, v)
+		buckets[i] = uint64(p)
+	}
+}
+
+func set_TYPE(dst coldata.Vec, dstIdx int, src coldata.Vec, srcIdx int) {
+	dstCol, srcCol := dst._TYPE(), src._TYPE()
+	// {{if eq .LTyp.String "Bytes"}}
+	// Flat Bytes cannot be set at arbitrary indices, so we use CopySlice which
+	// moves the data after dstIdx around if necessary.
+	execgen.COPYSLICE(dstCol, srcCol, dstIdx, srcIdx, srcIdx+1)
+	// {{else}}
+	v := execgen.UNSAFEGET(srcCol, srcIdx)
+	execgen.SET(dstCol, dstIdx, v)
+	// {{end}}
+}
+
+// {{end}}
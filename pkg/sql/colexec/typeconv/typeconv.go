@@ -19,17 +19,28 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/lib/pq/oid"
 	"github.com/pkg/errors"
 )
 
-// FromColumnType returns the T that corresponds to the input ColumnType.
+// FromColumnType returns the T that corresponds to the input ColumnType. The
+// types that don't have a native columnar representation are represented by
+// coltypes.Datum, with the exception of the unknown type (of NULL values)
+// which is coltypes.Unhandled.
 // Note: if you're adding a new type here, add it to
 // colexec.allSupportedSQLTypes as well.
 func FromColumnType(ct *types.T) coltypes.T {
 	switch ct.Family() {
 	case types.BoolFamily:
 		return coltypes.Bool
-	case types.BytesFamily, types.StringFamily, types.UuidFamily:
+	case types.BytesFamily, types.StringFamily, types.UuidFamily, types.INetFamily,
+		types.JsonFamily, types.CollatedStringFamily:
+		// INET values are stored using the encoding of ipaddr.IPAddr.ToBuffer
+		// which sorts in the same order as the IP addresses. JSONB values are
+		// stored using their binary encoding. Collated strings are stored using
+		// their contents, so the operators that compare them need to use their
+		// collation keys instead.
 		return coltypes.Bytes
 	case types.DateFamily, types.OidFamily:
 		return coltypes.Int64
@@ -53,8 +64,22 @@ func FromColumnType(ct *types.T) coltypes.T {
 		return coltypes.Timestamp
 	case types.IntervalFamily:
 		return coltypes.Interval
+	case types.ArrayFamily:
+		if ct.Oid() == oid.T_int2vector || ct.Oid() == oid.T_oidvector {
+			// VECTOR types use 0-indexing, so they are not supported natively.
+			return coltypes.Datum
+		}
+		// Only the arrays of elements that have a non-nested physical
+		// representation are supported natively.
+		switch FromColumnType(ct.ArrayContents()) {
+		case coltypes.Unhandled, coltypes.Array, coltypes.Datum:
+			return coltypes.Datum
+		}
+		return coltypes.Array
+	case types.UnknownFamily:
+		return coltypes.Unhandled
 	}
-	return coltypes.Unhandled
+	return coltypes.Datum
 }
 
 // FromColumnTypes calls FromColumnType on each element of cts, returning the
@@ -113,6 +138,12 @@ func ToColumnTypes(typs []coltypes.T) []types.T {
 // GetDatumToPhysicalFn returns a function for converting a datum of the given
 // ColumnType to the corresponding Go type.
 func GetDatumToPhysicalFn(ct *types.T) func(tree.Datum) (interface{}, error) {
+	if FromColumnType(ct) == coltypes.Datum {
+		// The datums are stored as is.
+		return func(datum tree.Datum) (interface{}, error) {
+			return datum, nil
+		}
+	}
 	switch ct.Family() {
 	case types.BoolFamily:
 		return func(datum tree.Datum) (interface{}, error) {
@@ -196,6 +227,14 @@ func GetDatumToPhysicalFn(ct *types.T) func(tree.Datum) (interface{}, error) {
 			}
 			return encoding.UnsafeConvertStringToBytes(string(*d)), nil
 		}
+	case types.CollatedStringFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DCollatedString)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DCollatedString, found %s", reflect.TypeOf(datum))
+			}
+			return encoding.UnsafeConvertStringToBytes(d.Contents), nil
+		}
 	case types.DecimalFamily:
 		return func(datum tree.Datum) (interface{}, error) {
 			d, ok := datum.(*tree.DDecimal)
@@ -212,6 +251,22 @@ func GetDatumToPhysicalFn(ct *types.T) func(tree.Datum) (interface{}, error) {
 			}
 			return d.UUID.GetBytesMut(), nil
 		}
+	case types.INetFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DIPAddr)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DIPAddr, found %s", reflect.TypeOf(datum))
+			}
+			return d.ToBuffer(nil), nil
+		}
+	case types.JsonFamily:
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DJSON)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DJSON, found %s", reflect.TypeOf(datum))
+			}
+			return json.EncodeJSON(nil, d.JSON)
+		}
 	case types.TimestampFamily:
 		return func(datum tree.Datum) (interface{}, error) {
 			d, ok := datum.(*tree.DTimestamp)
@@ -236,6 +291,17 @@ func GetDatumToPhysicalFn(ct *types.T) func(tree.Datum) (interface{}, error) {
 			}
 			return d.Duration, nil
 		}
+	case types.ArrayFamily:
+		// Arrays don't have a single Go value as their physical representation,
+		// so the datum itself is returned, and it is up to the caller to set
+		// the elements of the array (see colexec.setArrayDatum).
+		return func(datum tree.Datum) (interface{}, error) {
+			d, ok := datum.(*tree.DArray)
+			if !ok {
+				return nil, errors.Errorf("expected *tree.DArray, found %s", reflect.TypeOf(datum))
+			}
+			return d, nil
+		}
 	}
 	// It would probably be more correct to return an error here, rather than a
 	// function which always returns an error. But since the function tends to be
@@ -0,0 +1,97 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"sync"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewUnionAllOp returns an operator that emits all of the tuples from inputs
+// (such as the streams of the two sides of a UNION ALL) with the column types
+// outputTypes. inputTypes[i] are the types of the ith input. The types of the
+// different inputs only have to be equivalent (for example, INT2 and INT8), so
+// the columns with a different physical type than the one of the output are
+// cast first. If wg is nil, the inputs are read one after another by a
+// SerialUnorderedSynchronizer, otherwise, they are read concurrently by a
+// ParallelUnorderedSynchronizer, which uses wg to track its goroutines.
+func NewUnionAllOp(
+	allocator *Allocator,
+	inputs []Operator,
+	inputTypes [][]types.T,
+	outputTypes []types.T,
+	wg *sync.WaitGroup,
+) (Operator, error) {
+	typs, err := typeconv.FromColumnTypes(outputTypes)
+	if err != nil {
+		return nil, err
+	}
+	castInputs := make([]Operator, len(inputs))
+	for i := range inputs {
+		castInputs[i], err = castToPhysicalTypes(allocator, inputs[i], inputTypes[i], outputTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if wg == nil {
+		return NewSerialUnorderedSynchronizer(castInputs, typs), nil
+	}
+	return NewParallelUnorderedSynchronizer(castInputs, typs, wg), nil
+}
+
+// castToPhysicalTypes returns an operator that outputs the columns of input,
+// which have the types inputTypes, with the physical types of outputTypes. The
+// columns that already have the right physical type are passed through, and
+// the other ones are cast to the respective type in outputTypes. If all of the
+// columns have the right physical type, input is returned.
+func castToPhysicalTypes(
+	allocator *Allocator, input Operator, inputTypes []types.T, outputTypes []types.T,
+) (Operator, error) {
+	if len(inputTypes) != len(outputTypes) {
+		return nil, errors.AssertionFailedf(
+			"mismatched number of columns: %d and %d", len(inputTypes), len(outputTypes),
+		)
+	}
+	var projection []uint32
+	op := input
+	numCols := len(inputTypes)
+	for i := range inputTypes {
+		fromType, toType := &inputTypes[i], &outputTypes[i]
+		if fromType.Family() != types.UnknownFamily &&
+			typeconv.FromColumnType(fromType) == typeconv.FromColumnType(toType) {
+			continue
+		}
+		if typ := typeconv.FromColumnType(toType); typ == coltypes.Unhandled || typ == coltypes.Datum {
+			return nil, errors.Errorf("unhandled type %s", toType)
+		}
+		if projection == nil {
+			projection = make([]uint32, len(inputTypes))
+			for j := range projection {
+				projection[j] = uint32(j)
+			}
+		}
+		var err error
+		op, err = GetCastOperator(allocator, op, i, numCols, fromType, toType)
+		if err != nil {
+			return nil, err
+		}
+		projection[i] = uint32(numCols)
+		numCols++
+	}
+	if projection == nil {
+		return input, nil
+	}
+	return NewSimpleProjectOp(op, numCols, projection), nil
+}
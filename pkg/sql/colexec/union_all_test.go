@@ -0,0 +1,54 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnionAllOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The first input has an INT8 and a STRING column, and the second one has
+	// an INT2 and a STRING column, so the INT2 column has to be cast.
+	inputTypes := [][]types.T{{*types.Int, *types.String}, {*types.Int2, *types.String}}
+	outputTypes := []types.T{*types.Int, *types.String}
+	tups := []tuples{
+		{{1, "a"}, {2, nil}, {nil, "c"}},
+		{{-3, "d"}, {nil, nil}, {5, "f"}},
+	}
+	expected := tuples{{1, "a"}, {2, nil}, {nil, "c"}, {-3, "d"}, {nil, nil}, {5, "f"}}
+	physTypes := [][]coltypes.T{
+		{coltypes.Int64, coltypes.Bytes}, {coltypes.Int16, coltypes.Bytes},
+	}
+
+	runTestsWithTyps(t, tups, physTypes, expected, orderedVerifier, func(inputs []Operator) (Operator, error) {
+		return NewUnionAllOp(testAllocator, inputs, inputTypes, outputTypes, nil /* wg */)
+	})
+
+	t.Run("Narrowing", func(t *testing.T) {
+		// The INT8 values might not fit into an INT2 column, so such casts are
+		// not supported.
+		inputs := []Operator{
+			newOpTestInput(1 /* batchSize */, tups[0], physTypes[0]),
+			newOpTestInput(1 /* batchSize */, tups[1], physTypes[1]),
+		}
+		_, err := NewUnionAllOp(
+			testAllocator, inputs, inputTypes, []types.T{*types.Int2, *types.String}, nil, /* wg */
+		)
+		require.Error(t, err)
+	})
+}
@@ -39,7 +39,6 @@ func NewUnorderedDistinct(
 		OneInputNode: NewOneInputNode(input),
 		allocator:    allocator,
 		ht:           ht,
-		output:       allocator.NewMemBatch(ht.outTypes),
 	}
 }
 
@@ -58,21 +57,26 @@ type unorderedDistinct struct {
 	buildFinished bool
 
 	// sel is a list of indices to select representing the distinct rows.
-	sel           []uint64
+	sel           []int
 	distinctCount uint64
 
 	output           coldata.Batch
 	outputBatchStart uint64
+
+	// exported is the number of buffered tuples that have already been exported
+	// by ExportBuffered.
+	exported      uint64
+	windowedBatch coldata.Batch
 }
 
-var _ Operator = &unorderedDistinct{}
+var _ bufferingInMemoryOperator = &unorderedDistinct{}
+var _ resettableOperator = &unorderedDistinct{}
 
 func (op *unorderedDistinct) Init() {
 	op.input.Init()
 }
 
 func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
-	op.output.ResetInternalBatch()
 	// First, build the hash table.
 	if !op.buildFinished {
 		op.buildFinished = true
@@ -80,13 +84,22 @@ func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
 		op.ht.findSameTuples(ctx)
 	}
 
+	// The output batch is allocated only once the hash table has been built so
+	// that hitting the memory limit while allocating it can still be handled
+	// by falling back to the disk-backed distinct.
+	if op.output == nil {
+		op.output = op.allocator.NewMemBatch(op.ht.outTypes)
+	} else {
+		op.output.ResetInternalBatch()
+	}
+
 	// The selection vector needs to be populated before any batching can be
 	// done.
 	if op.sel == nil {
-		// Since next is no longer useful and pre-allocated to the appropriate
-		// size, we can use it as the selection vector. This way we don't have to
-		// reallocate a huge array.
-		op.sel = op.ht.next
+		// Since next is no longer useful, we release it before allocating the
+		// selection vector of the same size.
+		op.ht.next = nil
+		op.sel = make([]int, op.ht.vals.length)
 		// We calculate keyID for tuple at index i as "i+1," so we start from
 		// position 1.
 		for i, isHead := range op.ht.head[1:] {
@@ -94,7 +107,7 @@ func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
 				// The tuple at index i is the "head" of the linked list of tuples that
 				// are the same on the distinct columns, so we will include it while
 				// all other tuples from the linked list will be skipped.
-				op.sel[op.distinctCount] = uint64(i)
+				op.sel[op.distinctCount] = i
 				op.distinctCount++
 			}
 		}
@@ -121,8 +134,8 @@ func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
 						Src:         fromCol,
 						SrcStartIdx: op.outputBatchStart,
 						SrcEndIdx:   batchEnd,
+						Sel:         op.sel,
 					},
-					Sel64: op.sel,
 				},
 			)
 		}
@@ -133,10 +146,40 @@ func (op *unorderedDistinct) Next(ctx context.Context) coldata.Batch {
 	return op.output
 }
 
+func (op *unorderedDistinct) ExportBuffered() coldata.Batch {
+	// The memory limit can only be reached before anything has been emitted
+	// (either while building the hash table or while allocating the output
+	// batch), and at that point ht.vals contains all of the tuples consumed from
+	// the input so far.
+	if op.exported == op.ht.vals.length {
+		return coldata.ZeroBatch
+	}
+	if op.windowedBatch == nil {
+		op.windowedBatch = op.allocator.NewMemBatchWithSize(op.ht.valTypes, 0 /* size */)
+	}
+	newExported := op.exported + uint64(coldata.BatchSize())
+	if newExported > op.ht.vals.length {
+		newExported = op.ht.vals.length
+	}
+	for i := range op.ht.valTypes {
+		window := op.ht.vals.colVecs[i].Window(op.exported, newExported)
+		op.windowedBatch.ReplaceCol(window, i)
+	}
+	op.windowedBatch.SetLength(uint16(newExported - op.exported))
+	op.exported = newExported
+	return op.windowedBatch
+}
+
 // Reset resets the unorderedDistinct for another run. Primarily used for
 // benchmarks.
 func (op *unorderedDistinct) reset() {
+	if r, ok := op.input.(resetter); ok {
+		r.reset()
+	}
 	op.outputBatchStart = 0
-	op.ht.vals.reset()
+	op.ht.reset()
+	op.sel = nil
+	op.distinctCount = 0
+	op.exported = 0
 	op.buildFinished = false
 }
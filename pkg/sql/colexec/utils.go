@@ -13,7 +13,6 @@ package colexec
 import (
 	"github.com/cockroachdb/apd"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
-	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 )
 
 var zeroBoolColumn = make([]bool, coldata.MaxBatchSize)
@@ -29,27 +28,3 @@ var zeroInt64Column = make([]int64, coldata.MaxBatchSize)
 var zeroFloat64Column = make([]float64, coldata.MaxBatchSize)
 
 var zeroUint64Column = make([]uint64, coldata.MaxBatchSize)
-
-// CopyBatch copies the original batch and returns that copy. However, note that
-// the underlying capacity might be different (a new batch is created only with
-// capacity original.Length()).
-func CopyBatch(allocator *Allocator, original coldata.Batch) coldata.Batch {
-	typs := make([]coltypes.T, original.Width())
-	for i, vec := range original.ColVecs() {
-		typs[i] = vec.Type()
-	}
-	b := allocator.NewMemBatchWithSize(typs, int(original.Length()))
-	b.SetLength(original.Length())
-	allocator.PerformOperation(b.ColVecs(), func() {
-		for colIdx, col := range original.ColVecs() {
-			b.ColVec(colIdx).Copy(coldata.CopySliceArgs{
-				SliceArgs: coldata.SliceArgs{
-					ColType:   typs[colIdx],
-					Src:       col,
-					SrcEndIdx: uint64(original.Length()),
-				},
-			})
-		}
-	})
-	return b
-}
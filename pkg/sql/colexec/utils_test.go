@@ -379,7 +379,7 @@ func runTestsWithFn(
 // function that takes a list of input Operators, which will give back the
 // tuples provided in batches.
 func runTestsWithFixedSel(
-	t *testing.T, tups []tuples, sel []uint16, test func(t *testing.T, inputs []Operator),
+	t *testing.T, tups []tuples, sel []int, test func(t *testing.T, inputs []Operator),
 ) {
 	for _, batchSize := range []uint16{1, 2, 3, 16, 1024} {
 		t.Run(fmt.Sprintf("batchSize=%d/fixedSel", batchSize), func(t *testing.T) {
@@ -434,7 +434,7 @@ type opTestInput struct {
 	batch     coldata.Batch
 	useSel    bool
 	rng       *rand.Rand
-	selection []uint16
+	selection []int
 
 	// injectAllNulls determines whether opTestInput will replace all values in
 	// the input tuples with nulls.
@@ -495,9 +495,9 @@ func (s *opTestInput) Init() {
 	}
 	s.batch = testAllocator.NewMemBatch(s.typs)
 
-	s.selection = make([]uint16, coldata.BatchSize())
+	s.selection = make([]int, coldata.BatchSize())
 	for i := range s.selection {
-		s.selection[i] = uint16(i)
+		s.selection[i] = i
 	}
 }
 
@@ -523,7 +523,7 @@ func (s *opTestInput) Next(context.Context) coldata.Batch {
 
 	if s.useSel {
 		for i := range s.selection {
-			s.selection[i] = uint16(i)
+			s.selection[i] = i
 		}
 		// We have populated s.selection vector with possibly more indices than we
 		// have actual tuples for, so some "default" tuples will be introduced but
@@ -548,7 +548,7 @@ func (s *opTestInput) Next(context.Context) coldata.Batch {
 		// than the max batch size, so the test will panic if this part of the slice
 		// is accidentally accessed.
 		for i := range s.selection[batchSize:] {
-			s.selection[int(batchSize)+i] = coldata.BatchSize() + 1
+			s.selection[int(batchSize)+i] = int(coldata.BatchSize()) + 1
 		}
 
 		s.batch.SetSelection(true)
@@ -576,7 +576,7 @@ func (s *opTestInput) Next(context.Context) coldata.Batch {
 			outputIdx := s.selection[j]
 			injectRandomNull := s.injectRandomNulls && rng.Float64() < 0.5
 			if tups[j][i] == nil || s.injectAllNulls || injectRandomNull {
-				vec.Nulls().SetNull(outputIdx)
+				vec.Nulls().SetNull(uint16(outputIdx))
 				if rng.Float64() < 0.5 {
 					// With 50% probability we set garbage data in the value to make sure
 					// that it doesn't affect the computation when the value is actually
@@ -589,19 +589,19 @@ func (s *opTestInput) Next(context.Context) coldata.Batch {
 						if err != nil {
 							execerror.VectorizedInternalPanic(fmt.Sprintf("%v", err))
 						}
-						col.Index(int(outputIdx)).Set(reflect.ValueOf(d))
+						col.Index(outputIdx).Set(reflect.ValueOf(d))
 					} else if typ == coltypes.Bytes {
 						newBytes := make([]byte, rng.Intn(16)+1)
 						rng.Read(newBytes)
-						setColVal(vec, int(outputIdx), newBytes)
+						setColVal(vec, outputIdx, newBytes)
 					} else if val, ok := quick.Value(reflect.TypeOf(vec.Col()).Elem(), rng); ok {
-						setColVal(vec, int(outputIdx), val.Interface())
+						setColVal(vec, outputIdx, val.Interface())
 					} else {
 						execerror.VectorizedInternalPanic(fmt.Sprintf("could not generate a random value of type %T\n.", vec.Type()))
 					}
 				}
 			} else {
-				setColVal(vec, int(outputIdx), tups[j][i])
+				setColVal(vec, outputIdx, tups[j][i])
 			}
 		}
 	}
@@ -618,7 +618,7 @@ type opFixedSelTestInput struct {
 	batchSize uint16
 	tuples    tuples
 	batch     coldata.Batch
-	sel       []uint16
+	sel       []int
 	// idx is the index of the tuple to be emitted next. We need to maintain it
 	// in case the provided selection vector or provided tuples (if sel is nil)
 	// is longer than requested batch size.
@@ -630,7 +630,7 @@ var _ Operator = &opFixedSelTestInput{}
 // newOpFixedSelTestInput returns a new opFixedSelTestInput with the given
 // input tuples and selection vector. The input tuples are translated into
 // types automatically, using simple rules (e.g. integers always become Int64).
-func newOpFixedSelTestInput(sel []uint16, batchSize uint16, tuples tuples) *opFixedSelTestInput {
+func newOpFixedSelTestInput(sel []int, batchSize uint16, tuples tuples) *opFixedSelTestInput {
 	ret := &opFixedSelTestInput{
 		batchSize: batchSize,
 		sel:       sel,
@@ -759,7 +759,7 @@ func getTupleFromBatch(batch coldata.Batch, tupleIdx uint16) tuple {
 	ret := make(tuple, batch.Width())
 	out := reflect.ValueOf(ret)
 	if sel := batch.Selection(); sel != nil {
-		tupleIdx = sel[tupleIdx]
+		tupleIdx = uint16(sel[tupleIdx])
 	}
 	for colIdx := range ret {
 		vec := batch.ColVec(colIdx)
@@ -790,6 +790,7 @@ func (r *opTestOutput) next(ctx context.Context) tuple {
 		if r.batch.Length() == 0 {
 			return nil
 		}
+		checkBatchInvariants(r.batch)
 		r.curIdx = 0
 	}
 	ret := getTupleFromBatch(r.batch, r.curIdx)
@@ -1148,7 +1149,7 @@ func (c *chunkingBatchSource) Next(context.Context) coldata.Batch {
 		lastIdx = c.len
 	}
 	for i, vec := range c.batch.ColVecs() {
-		vec.SetCol(c.cols[i].Window(c.typs[i], c.curIdx, lastIdx).Col())
+		vec.SetCol(c.cols[i].Window(c.curIdx, lastIdx).Col())
 		nullsSlice := c.cols[i].Nulls().Slice(c.curIdx, lastIdx)
 		vec.SetNulls(&nullsSlice)
 	}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// valuesOp is the Operator implementation of the Values processor. The
+// expressions of a VALUES clause are evaluated once during the planning, and
+// the resulting rows are encoded into the spec, so valuesOp only needs to
+// decode them directly into the output batches (as opposed to emitting them
+// one row at a time through a columnarizer).
+type valuesOp struct {
+	ZeroInputNode
+
+	allocator *Allocator
+	columns   []execinfrapb.DatumInfo
+	colTypes  []types.T
+	typs      []coltypes.T
+	// data contains the encoded rows that haven't been decoded yet. Every
+	// element can encode multiple rows.
+	data [][]byte
+	// numRows is the number of rows left to be emitted when there are no
+	// columns.
+	numRows uint64
+
+	output coldata.Batch
+	// rows contains the decoded rows of the current batch. The rows are reused
+	// between batches.
+	rows sqlbase.EncDatumRows
+	da   sqlbase.DatumAlloc
+}
+
+var _ Operator = &valuesOp{}
+
+// newValuesOp returns a new valuesOp that emits the rows encoded in spec.
+func newValuesOp(allocator *Allocator, spec *execinfrapb.ValuesCoreSpec) (Operator, error) {
+	colTypes := make([]types.T, len(spec.Columns))
+	for i := range colTypes {
+		colTypes[i] = spec.Columns[i].Type
+	}
+	typs, err := typeconv.FromColumnTypes(colTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &valuesOp{
+		allocator: allocator,
+		columns:   spec.Columns,
+		colTypes:  colTypes,
+		typs:      typs,
+		// The slice is copied so that advancing its elements while decoding
+		// doesn't modify the spec.
+		data:    append([][]byte(nil), spec.RawBytes...),
+		numRows: spec.NumRows,
+	}, nil
+}
+
+func (v *valuesOp) Init() {}
+
+func (v *valuesOp) Next(context.Context) coldata.Batch {
+	if len(v.columns) == 0 {
+		return v.nextWithoutColumns()
+	}
+	v.rows = v.rows[:0]
+	for len(v.rows) < int(coldata.BatchSize()) && len(v.data) > 0 {
+		if len(v.data[0]) == 0 {
+			v.data = v.data[1:]
+			continue
+		}
+		var row sqlbase.EncDatumRow
+		if len(v.rows) < cap(v.rows) {
+			row = v.rows[:len(v.rows)+1][len(v.rows)]
+		}
+		if row == nil {
+			row = make(sqlbase.EncDatumRow, len(v.columns))
+		}
+		for i := range row {
+			var err error
+			row[i], v.data[0], err = sqlbase.EncDatumFromBuffer(
+				&v.colTypes[i], v.columns[i].Encoding, v.data[0],
+			)
+			if err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+		}
+		v.rows = append(v.rows, row)
+	}
+	if len(v.rows) == 0 {
+		return coldata.ZeroBatch
+	}
+	if v.output == nil {
+		// If all of the rows fit into a single batch, the output batch is
+		// allocated with just enough capacity to hold them.
+		v.output = v.allocator.NewMemBatchWithSize(v.typs, len(v.rows))
+	}
+	v.output.ResetInternalBatch()
+	for colIdx := range v.colTypes {
+		if err := EncDatumRowsToColVec(
+			v.allocator, v.rows, v.output.ColVec(colIdx), colIdx, &v.colTypes[colIdx], &v.da,
+		); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+	}
+	v.output.SetLength(uint16(len(v.rows)))
+	return v.output
+}
+
+// nextWithoutColumns returns the next batch in the case when there are no
+// columns, so only the number of rows needs to be emitted.
+func (v *valuesOp) nextWithoutColumns() coldata.Batch {
+	if v.numRows == 0 {
+		return coldata.ZeroBatch
+	}
+	n := uint64(coldata.BatchSize())
+	if v.numRows < n {
+		n = v.numRows
+	}
+	if v.output == nil {
+		v.output = v.allocator.NewMemBatchWithSize(nil /* types */, int(n))
+	}
+	v.output.ResetInternalBatch()
+	v.output.SetLength(uint16(n))
+	v.numRows -= n
+	return v.output
+}
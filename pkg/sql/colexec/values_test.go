@@ -0,0 +1,86 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValuesOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	colTypes := []types.T{*types.Int, *types.String}
+	for _, numRows := range []int{0, 1, 13, int(coldata.BatchSize()) + 3} {
+		// Every fifth string is NULL.
+		rows := make(sqlbase.EncDatumRows, numRows)
+		expected := make(tuples, numRows)
+		for i := range rows {
+			var strDatum tree.Datum = tree.DNull
+			var strVal interface{}
+			if i%5 != 0 {
+				s := fmt.Sprintf("s%d", i)
+				strDatum, strVal = tree.NewDString(s), []byte(s)
+			}
+			rows[i] = sqlbase.EncDatumRow{
+				sqlbase.IntEncDatum(i), sqlbase.DatumToEncDatum(types.String, strDatum),
+			}
+			expected[i] = tuple{int64(i), strVal}
+		}
+		for _, rowsPerChunk := range []int{1, 5, 1000} {
+			t.Run(fmt.Sprintf("rows=%d/rowsPerChunk=%d", numRows, rowsPerChunk), func(t *testing.T) {
+				spec, err := execinfra.GenerateValuesSpec(colTypes, rows, rowsPerChunk)
+				require.NoError(t, err)
+				op, err := newValuesOp(testAllocator, &spec)
+				require.NoError(t, err)
+				op.Init()
+				actual := make(tuples, 0, numRows)
+				for {
+					b := op.Next(ctx)
+					if b.Length() == 0 {
+						break
+					}
+					for i := uint16(0); i < b.Length(); i++ {
+						actual = append(actual, getTupleFromBatch(b, i))
+					}
+				}
+				require.Equal(t, expected, actual)
+			})
+		}
+	}
+
+	t.Run("NoColumns", func(t *testing.T) {
+		numRows := 2*int(coldata.BatchSize()) + 1
+		op, err := newValuesOp(testAllocator, &execinfrapb.ValuesCoreSpec{NumRows: uint64(numRows)})
+		require.NoError(t, err)
+		op.Init()
+		actual := 0
+		for {
+			b := op.Next(ctx)
+			if b.Length() == 0 {
+				break
+			}
+			require.Equal(t, 0, b.Width())
+			actual += int(b.Length())
+		}
+		require.Equal(t, numRows, actual)
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+)
+
+// vecComparator is a helper for the ordered synchronizer and the top K sorter.
+// It stores multiple column vectors of a single type and facilitates comparing
+// values between them using the kernels registered for the type.
+type vecComparator struct {
+	kernels *TypeKernels
+	vecs    []coldata.Vec
+	nulls   []*coldata.Nulls
+}
+
+// GetVecComparator returns a vecComparator for numVecs vectors of type t.
+func GetVecComparator(t coltypes.T, numVecs int) *vecComparator {
+	kernels := getTypeKernels(t)
+	if kernels.Compare == nil || kernels.Set == nil {
+		execerror.VectorizedInternalPanic(fmt.Sprintf("type %s cannot be compared", t))
+	}
+	return &vecComparator{
+		kernels: kernels,
+		vecs:    make([]coldata.Vec, numVecs),
+		nulls:   make([]*coldata.Nulls, numVecs),
+	}
+}
+
+// compare compares values from two vectors. vecIdx is the index of the vector
+// and valIdx is the index of the value in that vector to compare. Returns -1,
+// 0, or 1. NULLs are ordered before all other values.
+func (c *vecComparator) compare(vecIdx1, vecIdx2 int, valIdx1, valIdx2 uint16) int {
+	n1 := c.nulls[vecIdx1].MaybeHasNulls() && c.nulls[vecIdx1].NullAt(valIdx1)
+	n2 := c.nulls[vecIdx2].MaybeHasNulls() && c.nulls[vecIdx2].NullAt(valIdx2)
+	if n1 && n2 {
+		return 0
+	} else if n1 {
+		return -1
+	} else if n2 {
+		return 1
+	}
+	return c.kernels.Compare(c.vecs[vecIdx1], int(valIdx1), c.vecs[vecIdx2], int(valIdx2))
+}
+
+// set sets the value of the vector at dstVecIdx at index dstValIdx to the value
+// at the vector at srcVecIdx at index srcValIdx.
+// NOTE: whenever set is used, the caller is responsible for updating the
+// memory accounts.
+func (c *vecComparator) set(srcVecIdx, dstVecIdx int, srcValIdx, dstValIdx uint16) {
+	if c.nulls[srcVecIdx].MaybeHasNulls() && c.nulls[srcVecIdx].NullAt(srcValIdx) {
+		c.nulls[dstVecIdx].SetNull(dstValIdx)
+	} else {
+		c.nulls[dstVecIdx].UnsetNull(dstValIdx)
+		c.kernels.Set(c.vecs[dstVecIdx], int(dstValIdx), c.vecs[srcVecIdx], int(srcValIdx))
+	}
+}
+
+// setVec updates the vector at idx.
+func (c *vecComparator) setVec(idx int, vec coldata.Vec) {
+	c.vecs[idx] = vec
+	c.nulls[idx] = vec.Nulls()
+}
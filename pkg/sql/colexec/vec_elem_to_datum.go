@@ -14,10 +14,13 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/lib/pq/oid"
@@ -27,13 +30,25 @@ import (
 // semtype ct. Note that this function handles nulls as well, so there is no
 // need for a separate null check.
 func PhysicalTypeColElemToDatum(
-	col coldata.Vec, rowIdx uint16, da sqlbase.DatumAlloc, ct *types.T,
+	col coldata.Vec, rowIdx uint16, da *sqlbase.DatumAlloc, ct *types.T,
+) tree.Datum {
+	return physicalTypeColElemToDatum(col, int(rowIdx), da, ct)
+}
+
+// physicalTypeColElemToDatum is the same as PhysicalTypeColElemToDatum but
+// supports the indices that don't fit into uint16 (which is the case for the
+// elements of arrays).
+func physicalTypeColElemToDatum(
+	col coldata.Vec, rowIdx int, da *sqlbase.DatumAlloc, ct *types.T,
 ) tree.Datum {
 	if col.MaybeHasNulls() {
-		if col.Nulls().NullAt(rowIdx) {
+		if col.Nulls().NullAt64(uint64(rowIdx)) {
 			return tree.DNull
 		}
 	}
+	if col.Type() == coltypes.Datum {
+		return col.Datum().Get(rowIdx).(tree.Datum)
+	}
 	switch ct.Family() {
 	case types.BoolFamily:
 		if col.Bool()[rowIdx] {
@@ -56,27 +71,60 @@ func PhysicalTypeColElemToDatum(
 	case types.DateFamily:
 		return tree.NewDDate(pgdate.MakeCompatibleDateFromDisk(col.Int64()[rowIdx]))
 	case types.StringFamily:
-		b := col.Bytes().Get(int(rowIdx))
+		b := col.Bytes().Get(rowIdx)
 		if ct.Oid() == oid.T_name {
 			return da.NewDName(tree.DString(string(b)))
 		}
 		return da.NewDString(tree.DString(string(b)))
 	case types.BytesFamily:
-		return da.NewDBytes(tree.DBytes(col.Bytes().Get(int(rowIdx))))
+		return da.NewDBytes(tree.DBytes(col.Bytes().Get(rowIdx)))
+	case types.CollatedStringFamily:
+		d, err := da.NewDCollatedString(string(col.Bytes().Get(rowIdx)), ct.Locale())
+		if err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		return d
 	case types.OidFamily:
 		return da.NewDOid(tree.MakeDOid(tree.DInt(col.Int64()[rowIdx])))
 	case types.UuidFamily:
-		id, err := uuid.FromBytes(col.Bytes().Get(int(rowIdx)))
+		id, err := uuid.FromBytes(col.Bytes().Get(rowIdx))
 		if err != nil {
 			execerror.VectorizedInternalPanic(err)
 		}
 		return da.NewDUuid(tree.DUuid{UUID: id})
+	case types.INetFamily:
+		var ipAddr ipaddr.IPAddr
+		if _, err := ipAddr.FromBuffer(col.Bytes().Get(rowIdx)); err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		return da.NewDIPAddr(tree.DIPAddr{IPAddr: ipAddr})
+	case types.JsonFamily:
+		// The decoded JSON might reference the bytes it was decoded from, so we
+		// need to make a copy since the vector can be reused.
+		b := col.Bytes().Get(rowIdx)
+		j, err := json.FromEncoding(append([]byte(nil), b...))
+		if err != nil {
+			execerror.VectorizedInternalPanic(err)
+		}
+		return tree.NewDJSON(j)
 	case types.TimestampFamily:
 		return da.NewDTimestamp(tree.DTimestamp{Time: col.Timestamp()[rowIdx]})
 	case types.TimestampTZFamily:
 		return da.NewDTimestampTZ(tree.DTimestampTZ{Time: col.Timestamp()[rowIdx]})
 	case types.IntervalFamily:
 		return da.NewDInterval(tree.DInterval{Duration: col.Interval()[rowIdx]})
+	case types.ArrayFamily:
+		arrays := col.Array()
+		start, end := arrays.Bounds(rowIdx)
+		elemTyp := ct.ArrayContents()
+		d := tree.NewDArray(elemTyp)
+		d.Array = make(tree.Datums, 0, end-start)
+		for i := start; i < end; i++ {
+			if err := d.Append(physicalTypeColElemToDatum(arrays.Child(), i, da, elemTyp)); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+		}
+		return d
 	default:
 		execerror.VectorizedInternalPanic(fmt.Sprintf("Unsupported column type %s", ct.String()))
 		// This code is unreachable, but the compiler cannot infer that.
@@ -0,0 +1,517 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cockroachdb/apd"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// isWindowAggregateSupported returns whether the aggregate function aggFn
+// that operates on a single column of type inputType can be computed as a
+// window function over frame (nil frame means the default one, RANGE BETWEEN
+// UNBOUNDED PRECEDING AND CURRENT ROW).
+func isWindowAggregateSupported(
+	aggFn execinfrapb.AggregatorSpec_Func, inputType *types.T, frame *execinfrapb.WindowerSpec_Frame,
+) (bool, error) {
+	switch aggFn {
+	case execinfrapb.AggregatorSpec_SUM, execinfrapb.AggregatorSpec_AVG:
+		switch typeconv.FromColumnType(inputType) {
+		case coltypes.Float64, coltypes.Decimal:
+		default:
+			return false, errors.Newf("window %s on %s is not supported", aggFn, inputType)
+		}
+	case execinfrapb.AggregatorSpec_SUM_INT, execinfrapb.AggregatorSpec_MIN, execinfrapb.AggregatorSpec_MAX:
+		switch typeconv.FromColumnType(inputType) {
+		case coltypes.Int64, coltypes.Float64, coltypes.Decimal:
+		default:
+			return false, errors.Newf("window %s on %s is not supported", aggFn, inputType)
+		}
+		if aggFn == execinfrapb.AggregatorSpec_SUM_INT && inputType.Width() != 64 {
+			return false, errors.Newf("window sum_int is only supported on Int64 through vectorized")
+		}
+	default:
+		return false, errors.Newf("aggregate function %s used as window function is not supported", aggFn)
+	}
+	_, retType, err := execinfrapb.GetAggregateInfo(aggFn, *inputType)
+	if err != nil {
+		return false, err
+	}
+	// Similarly to the regular columnar aggregates, the window aggregates return
+	// the same physical type as their input.
+	if typeconv.FromColumnType(retType) != typeconv.FromColumnType(inputType) {
+		return false, errors.Newf("aggregates with different input and output types are not supported")
+	}
+	if frame == nil {
+		return true, nil
+	}
+	if frame.Exclusion != execinfrapb.WindowerSpec_Frame_NO_EXCLUSION {
+		return false, errors.Newf("window frames with exclusion are not supported")
+	}
+	bounds := []*execinfrapb.WindowerSpec_Frame_Bound{&frame.Bounds.Start}
+	if frame.Bounds.End != nil {
+		bounds = append(bounds, frame.Bounds.End)
+	}
+	for _, b := range bounds {
+		switch frame.Mode {
+		case execinfrapb.WindowerSpec_Frame_ROWS:
+		case execinfrapb.WindowerSpec_Frame_RANGE:
+			if b.BoundType == execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING ||
+				b.BoundType == execinfrapb.WindowerSpec_Frame_OFFSET_FOLLOWING {
+				return false, errors.Newf("window frames in RANGE mode with offsets are not supported")
+			}
+		default:
+			return false, errors.Newf("window frames in %s mode are not supported", frame.Mode)
+		}
+	}
+	return true, nil
+}
+
+// NewWindowAggregateOperator creates a new Operator that computes the
+// aggregate function aggFn (SUM, SUM_INT, AVG, MIN, or MAX) as a window
+// function over the window frame frame (nil means the default frame, RANGE
+// BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW). input *must* already be
+// ordered on the partitioning columns (as marked by the partitionColIdx'th
+// column, -1 if there is no PARTITION BY clause) and orderingCols. inputTypes
+// must describe all of the columns of the input batches, and the output of the
+// function is appended at position outputColIdx (which must be equal to
+// len(inputTypes)).
+//
+// The frames of consecutive tuples can only move forward, so rather than
+// recomputing the aggregate for every tuple, the operator maintains it
+// incrementally: the tuples that enter the frame are added to the aggregate
+// while the tuples that leave the frame are removed from it.
+func NewWindowAggregateOperator(
+	allocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	argsIdxs []uint32,
+	frame *execinfrapb.WindowerSpec_Frame,
+	orderingCols []uint32,
+	outputColIdx int,
+	partitionColIdx int,
+) (Operator, error) {
+	if len(argsIdxs) != 1 {
+		return nil, errors.Errorf("window %s takes exactly one argument", aggFn)
+	}
+	w := &windowAggregateWindower{
+		mode:      execinfrapb.WindowerSpec_Frame_RANGE,
+		start:     execinfrapb.WindowerSpec_Frame_Bound{BoundType: execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING},
+		end:       execinfrapb.WindowerSpec_Frame_Bound{BoundType: execinfrapb.WindowerSpec_Frame_CURRENT_ROW},
+		argColIdx: int(argsIdxs[0]),
+	}
+	if frame != nil {
+		w.mode = frame.Mode
+		w.start = frame.Bounds.Start
+		if frame.Bounds.End != nil {
+			w.end = *frame.Bounds.End
+		}
+	}
+	argType := inputTypes[w.argColIdx]
+	switch aggFn {
+	case execinfrapb.AggregatorSpec_SUM_INT:
+		if argType == coltypes.Int64 {
+			w.agg = &sumIntWindowAgg{}
+		}
+	case execinfrapb.AggregatorSpec_SUM, execinfrapb.AggregatorSpec_AVG:
+		avg := aggFn == execinfrapb.AggregatorSpec_AVG
+		switch argType {
+		case coltypes.Float64:
+			w.agg = &sumFloat64WindowAgg{avg: avg}
+		case coltypes.Decimal:
+			w.agg = &sumDecimalWindowAgg{avg: avg}
+		}
+	case execinfrapb.AggregatorSpec_MIN, execinfrapb.AggregatorSpec_MAX:
+		switch argType {
+		case coltypes.Int64, coltypes.Float64, coltypes.Decimal:
+			w.agg = &minMaxWindowAgg{typ: argType, max: aggFn == execinfrapb.AggregatorSpec_MAX}
+		}
+	}
+	if w.agg == nil {
+		return nil, errors.Errorf("unsupported window %s on %s", aggFn, argType)
+	}
+	if w.mode == execinfrapb.WindowerSpec_Frame_ROWS {
+		// In ROWS mode the frame bounds don't depend on the peer groups.
+		orderingCols = nil
+	}
+	return newBufferedWindowOp(
+		allocator, input, inputTypes, argType, orderingCols, outputColIdx, partitionColIdx, w,
+	)
+}
+
+// windowAggregator is an aggregate function that can be maintained
+// incrementally over a window frame that slides forward through a partition.
+// Only the tuples with non-NULL argument values are added and removed, and
+// the tuples are removed in the same order in which they have been added.
+type windowAggregator interface {
+	// reset prepares the aggregator for a new partition with the argument
+	// values in vec.
+	reset(vec coldata.Vec)
+	// add adds the idx'th tuple of the partition to the aggregate.
+	add(idx uint64)
+	// remove removes the idx'th tuple of the partition from the aggregate.
+	remove(idx uint64)
+	// setResult sets the current value of the aggregate (or NULL if the frame
+	// contains no non-NULL values) at position outputIdx of outputVec.
+	setResult(outputVec coldata.Vec, outputIdx uint16)
+}
+
+// windowAggregateWindower computes an aggregate over the window frame of each
+// tuple of the partition. [frameStart, frameEnd) is the frame that the
+// aggregator currently reflects.
+type windowAggregateWindower struct {
+	mode      execinfrapb.WindowerSpec_Frame_Mode
+	start     execinfrapb.WindowerSpec_Frame_Bound
+	end       execinfrapb.WindowerSpec_Frame_Bound
+	argColIdx int
+	agg       windowAggregator
+
+	partition    *bufferedBatch
+	peerGroupEnd []uint64
+	// peerGroupStart[i] contains the index of the first peer of the ith tuple.
+	peerGroupStart []uint64
+	frameStart     uint64
+	frameEnd       uint64
+}
+
+var _ bufferedWindower = &windowAggregateWindower{}
+
+func (w *windowAggregateWindower) startPartition(partition *bufferedBatch, peerGroupEnd []uint64) {
+	w.partition = partition
+	w.peerGroupEnd = peerGroupEnd
+	if uint64(cap(w.peerGroupStart)) < partition.length {
+		w.peerGroupStart = make([]uint64, partition.length)
+	}
+	w.peerGroupStart = w.peerGroupStart[:partition.length]
+	for i := range w.peerGroupStart {
+		if i > 0 && peerGroupEnd[i-1] == peerGroupEnd[i] {
+			w.peerGroupStart[i] = w.peerGroupStart[i-1]
+		} else {
+			w.peerGroupStart[i] = uint64(i)
+		}
+	}
+	w.frameStart, w.frameEnd = 0, 0
+	w.agg.reset(partition.ColVec(w.argColIdx))
+}
+
+// boundIdx returns the index of the tuple of the partition that corresponds
+// to bound b for the idx'th tuple. If isStart is true, the frame starts at
+// the returned index, otherwise the frame ends right before it. The returned
+// index is within [0, partition length].
+func (w *windowAggregateWindower) boundIdx(
+	b *execinfrapb.WindowerSpec_Frame_Bound, idx uint64, isStart bool,
+) uint64 {
+	length := w.partition.length
+	var res uint64
+	switch b.BoundType {
+	case execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING:
+		return 0
+	case execinfrapb.WindowerSpec_Frame_UNBOUNDED_FOLLOWING:
+		return length
+	case execinfrapb.WindowerSpec_Frame_CURRENT_ROW:
+		if w.mode == execinfrapb.WindowerSpec_Frame_ROWS {
+			res = idx
+		} else if isStart {
+			return w.peerGroupStart[idx]
+		} else {
+			return w.peerGroupEnd[idx]
+		}
+	case execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING:
+		if b.IntOffset > idx {
+			// The bound is before the first tuple of the partition.
+			return 0
+		}
+		res = idx - b.IntOffset
+	case execinfrapb.WindowerSpec_Frame_OFFSET_FOLLOWING:
+		if b.IntOffset >= length-idx {
+			// The bound is after the last tuple of the partition.
+			return length
+		}
+		res = idx + b.IntOffset
+	default:
+		execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected window frame bound type %s", b.BoundType))
+	}
+	if !isStart {
+		// The end bound is inclusive while the returned index is exclusive.
+		res++
+	}
+	return res
+}
+
+func (w *windowAggregateWindower) compute(outputVec coldata.Vec, startIdx, endIdx uint64) {
+	argVec := w.partition.ColVec(w.argColIdx)
+	var argNulls *coldata.Nulls
+	if argVec.MaybeHasNulls() {
+		argNulls = argVec.Nulls()
+	}
+	for i := startIdx; i < endIdx; i++ {
+		frameStart := w.boundIdx(&w.start, i, true /* isStart */)
+		frameEnd := w.boundIdx(&w.end, i, false /* isStart */)
+		if frameEnd < frameStart {
+			// The frame is empty. Both ends of the frame are non-decreasing, so we
+			// can still slide the aggregator's frame forward.
+			frameEnd = frameStart
+		}
+		for ; w.frameEnd < frameEnd; w.frameEnd++ {
+			if argNulls == nil || !argNulls.NullAt64(w.frameEnd) {
+				w.agg.add(w.frameEnd)
+			}
+		}
+		for ; w.frameStart < frameStart; w.frameStart++ {
+			if argNulls == nil || !argNulls.NullAt64(w.frameStart) {
+				w.agg.remove(w.frameStart)
+			}
+		}
+		w.agg.setResult(outputVec, uint16(i-startIdx))
+	}
+}
+
+// sumIntWindowAgg computes SUM_INT of Int64 values.
+type sumIntWindowAgg struct {
+	col   []int64
+	sum   int64
+	count int64
+}
+
+var _ windowAggregator = &sumIntWindowAgg{}
+
+func (a *sumIntWindowAgg) reset(vec coldata.Vec) {
+	a.col = vec.Int64()
+	a.sum, a.count = 0, 0
+}
+
+func (a *sumIntWindowAgg) add(idx uint64) {
+	result := a.sum + a.col[idx]
+	if (result < a.sum) != (a.col[idx] < 0) {
+		execerror.NonVectorizedPanic(tree.ErrIntOutOfRange)
+	}
+	a.sum = result
+	a.count++
+}
+
+func (a *sumIntWindowAgg) remove(idx uint64) {
+	// The removed value has been added before, so the subtraction cannot
+	// overflow (the overflow would have been detected in add).
+	a.sum -= a.col[idx]
+	a.count--
+}
+
+func (a *sumIntWindowAgg) setResult(outputVec coldata.Vec, outputIdx uint16) {
+	if a.count == 0 {
+		outputVec.Nulls().SetNull(outputIdx)
+		return
+	}
+	outputVec.Int64()[outputIdx] = a.sum
+}
+
+// sumFloat64WindowAgg computes SUM or AVG of Float64 values.
+type sumFloat64WindowAgg struct {
+	avg   bool
+	col   []float64
+	sum   float64
+	count int64
+}
+
+var _ windowAggregator = &sumFloat64WindowAgg{}
+
+func (a *sumFloat64WindowAgg) reset(vec coldata.Vec) {
+	a.col = vec.Float64()
+	a.sum, a.count = 0, 0
+}
+
+func (a *sumFloat64WindowAgg) add(idx uint64) {
+	a.sum += a.col[idx]
+	a.count++
+}
+
+func (a *sumFloat64WindowAgg) remove(idx uint64) {
+	a.sum -= a.col[idx]
+	a.count--
+	if a.count == 0 {
+		// Reset the sum so that the rounding errors don't carry over.
+		a.sum = 0
+	}
+}
+
+func (a *sumFloat64WindowAgg) setResult(outputVec coldata.Vec, outputIdx uint16) {
+	if a.count == 0 {
+		outputVec.Nulls().SetNull(outputIdx)
+		return
+	}
+	if a.avg {
+		outputVec.Float64()[outputIdx] = a.sum / float64(a.count)
+		return
+	}
+	outputVec.Float64()[outputIdx] = a.sum
+}
+
+// sumDecimalWindowAgg computes SUM or AVG of Decimal values.
+type sumDecimalWindowAgg struct {
+	avg   bool
+	col   []apd.Decimal
+	sum   apd.Decimal
+	count int64
+}
+
+var _ windowAggregator = &sumDecimalWindowAgg{}
+
+func (a *sumDecimalWindowAgg) reset(vec coldata.Vec) {
+	a.col = vec.Decimal()
+	a.sum.SetInt64(0)
+	a.count = 0
+}
+
+func (a *sumDecimalWindowAgg) add(idx uint64) {
+	if err := addDecimals(&a.sum, &a.sum, &a.col[idx]); err != nil {
+		execerror.NonVectorizedPanic(err)
+	}
+	a.count++
+}
+
+func (a *sumDecimalWindowAgg) remove(idx uint64) {
+	if _, err := tree.ExactCtx.Sub(&a.sum, &a.sum, &a.col[idx]); err != nil {
+		execerror.NonVectorizedPanic(err)
+	}
+	a.count--
+}
+
+func (a *sumDecimalWindowAgg) setResult(outputVec coldata.Vec, outputIdx uint16) {
+	if a.count == 0 {
+		outputVec.Nulls().SetNull(outputIdx)
+		return
+	}
+	res := &outputVec.Decimal()[outputIdx]
+	if a.avg {
+		res.SetInt64(a.count)
+		if _, err := tree.DecimalCtx.Quo(res, &a.sum, res); err != nil {
+			execerror.NonVectorizedPanic(err)
+		}
+		return
+	}
+	res.Set(&a.sum)
+}
+
+// minMaxWindowAgg computes MIN or MAX of Int64, Float64, or Decimal values
+// using a monotonic deque of the indices of the tuples in the frame: the
+// values at the indices in the deque are strictly increasing (for MIN) or
+// decreasing (for MAX) in the order of the indices, so the front of the deque
+// is always the result. A tuple that is added pops all of the tuples from the
+// back of the deque that can no longer become the result (because they leave
+// the frame before the new tuple does), which makes every operation amortized
+// O(1).
+type minMaxWindowAgg struct {
+	typ coltypes.T
+	max bool
+	vec coldata.Vec
+	// deque[head:] contains the indices of the tuples that can become the
+	// result of the aggregate.
+	deque []uint64
+	head  int
+}
+
+var _ windowAggregator = &minMaxWindowAgg{}
+
+func (a *minMaxWindowAgg) reset(vec coldata.Vec) {
+	a.vec = vec
+	a.deque = a.deque[:0]
+	a.head = 0
+}
+
+// compare returns the result of comparison of the values at indices i and j
+// flipped for MAX so that the smaller value is always the preferred one.
+func (a *minMaxWindowAgg) compare(i, j uint64) int {
+	var cmp int
+	switch a.typ {
+	case coltypes.Int64:
+		col := a.vec.Int64()
+		if col[i] < col[j] {
+			cmp = -1
+		} else if col[i] > col[j] {
+			cmp = 1
+		}
+	case coltypes.Float64:
+		col := a.vec.Float64()
+		cmp = compareFloat64s(col[i], col[j])
+	case coltypes.Decimal:
+		col := a.vec.Decimal()
+		cmp = compareDecimals(&col[i], &col[j])
+	default:
+		execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %s", a.typ))
+	}
+	if a.max {
+		return -cmp
+	}
+	return cmp
+}
+
+func (a *minMaxWindowAgg) add(idx uint64) {
+	for len(a.deque) > a.head && a.compare(idx, a.deque[len(a.deque)-1]) <= 0 {
+		a.deque = a.deque[:len(a.deque)-1]
+	}
+	if a.head == len(a.deque) {
+		// Reuse the space of the deque once it becomes empty.
+		a.deque, a.head = a.deque[:0], 0
+	}
+	a.deque = append(a.deque, idx)
+}
+
+func (a *minMaxWindowAgg) remove(idx uint64) {
+	if a.head < len(a.deque) && a.deque[a.head] == idx {
+		a.head++
+	}
+}
+
+func (a *minMaxWindowAgg) setResult(outputVec coldata.Vec, outputIdx uint16) {
+	if a.head == len(a.deque) {
+		outputVec.Nulls().SetNull(outputIdx)
+		return
+	}
+	idx := a.deque[a.head]
+	switch a.typ {
+	case coltypes.Int64:
+		outputVec.Int64()[outputIdx] = a.vec.Int64()[idx]
+	case coltypes.Float64:
+		outputVec.Float64()[outputIdx] = a.vec.Float64()[idx]
+	case coltypes.Decimal:
+		outputVec.Decimal()[outputIdx].Set(&a.vec.Decimal()[idx])
+	default:
+		execerror.VectorizedInternalPanic(fmt.Sprintf("unhandled type %s", a.typ))
+	}
+}
+
+// compareFloat64s compares two floats the way SQL does it, i.e. NaN is
+// treated as smaller than all other values.
+func compareFloat64s(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	} else if a == b {
+		return 0
+	} else if math.IsNaN(a) {
+		if math.IsNaN(b) {
+			return 0
+		}
+		return -1
+	}
+	return 1
+}
@@ -12,14 +12,22 @@ package colexec
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
 )
 
 type windowFnTestCase struct {
@@ -127,6 +135,26 @@ func TestRank(t *testing.T) {
 				},
 			},
 		},
+		// Multiple window functions with the same PARTITION BY and ORDER BY.
+		{
+			tuples:   tuples{{3, 2}, {1, nil}, {2, 1}, {nil, nil}, {1, 2}, {nil, 1}, {nil, nil}, {3, 1}},
+			expected: tuples{{nil, nil, 1, 1}, {nil, nil, 1, 1}, {nil, 1, 3, 2}, {1, nil, 1, 1}, {1, 2, 2, 2}, {2, 1, 1, 1}, {3, 1, 1, 1}, {3, 2, 2, 2}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &rankFn},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 2,
+					},
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &denseRankFn},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 3,
+					},
+				},
+			},
+		},
 	} {
 		runTests(t, []tuples{tc.tuples}, tc.expected, unorderedVerifier, func(inputs []Operator) (Operator, error) {
 			ct := make([]types.T, len(tc.tuples[0]))
@@ -227,3 +255,549 @@ func TestRowNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestValueWindowFunctions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	lagFn := execinfrapb.WindowerSpec_LAG
+	leadFn := execinfrapb.WindowerSpec_LEAD
+	firstValueFn := execinfrapb.WindowerSpec_FIRST_VALUE
+	lastValueFn := execinfrapb.WindowerSpec_LAST_VALUE
+	for _, tc := range []windowFnTestCase{
+		// LAG with PARTITION BY and ORDER BY.
+		{
+			tuples:   tuples{{2, 20}, {1, 3}, {1, 1}, {2, 10}, {1, 2}},
+			expected: tuples{{1, 1, nil}, {1, 2, 1}, {1, 3, 2}, {2, 10, nil}, {2, 20, 10}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &lagFn},
+						ArgsIdxs:     []uint32{1},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// LEAD with an offset and a default value.
+		{
+			tuples: tuples{{2, 20, 2, -1}, {1, 3, 2, -1}, {1, 1, 2, -1}, {2, 10, 2, -1}, {1, 2, 2, -1}},
+			expected: tuples{
+				{1, 1, 2, -1, 3}, {1, 2, 2, -1, -1}, {1, 3, 2, -1, -1}, {2, 10, 2, -1, -1}, {2, 20, 2, -1, -1},
+			},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &leadFn},
+						ArgsIdxs:     []uint32{1, 2, 3},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 4,
+					},
+				},
+			},
+		},
+		// FIRST_VALUE without PARTITION BY.
+		{
+			tuples:   tuples{{3}, {1}, {2}},
+			expected: tuples{{1, 1}, {2, 1}, {3, 1}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &firstValueFn},
+						ArgsIdxs:     []uint32{0},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						OutputColIdx: 1,
+					},
+				},
+			},
+		},
+		// LAST_VALUE returns the value of the last peer of the current tuple.
+		{
+			tuples:   tuples{{1, 2, 30}, {2, 5, 40}, {1, 1, 10}, {1, 1, 10}},
+			expected: tuples{{1, 1, 10, 10}, {1, 1, 10, 10}, {1, 2, 30, 30}, {2, 5, 40, 40}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &lastValueFn},
+						ArgsIdxs:     []uint32{2},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 3,
+					},
+				},
+			},
+		},
+	} {
+		runTests(t, []tuples{tc.tuples}, tc.expected, unorderedVerifier, func(inputs []Operator) (Operator, error) {
+			ct := make([]types.T, len(tc.tuples[0]))
+			for i := range ct {
+				ct[i] = *types.Int
+			}
+			spec := &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: ct}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Windower: &tc.windowerSpec,
+				},
+			}
+			args := NewColOperatorArgs{
+				Spec:                spec,
+				Inputs:              inputs,
+				StreamingMemAccount: testMemAcc,
+			}
+			args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+			result, err := NewColOperator(ctx, flowCtx, args)
+			if err != nil {
+				return nil, err
+			}
+			return result.Op, nil
+		})
+	}
+}
+
+func TestWindowAggregates(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	sumIntFn := execinfrapb.AggregatorSpec_SUM_INT
+	minFn := execinfrapb.AggregatorSpec_MIN
+	maxFn := execinfrapb.AggregatorSpec_MAX
+	makeFrame := func(
+		mode execinfrapb.WindowerSpec_Frame_Mode,
+		start execinfrapb.WindowerSpec_Frame_BoundType,
+		startOffset uint64,
+		end execinfrapb.WindowerSpec_Frame_BoundType,
+		endOffset uint64,
+	) *execinfrapb.WindowerSpec_Frame {
+		return &execinfrapb.WindowerSpec_Frame{
+			Mode: mode,
+			Bounds: execinfrapb.WindowerSpec_Frame_Bounds{
+				Start: execinfrapb.WindowerSpec_Frame_Bound{BoundType: start, IntOffset: startOffset},
+				End:   &execinfrapb.WindowerSpec_Frame_Bound{BoundType: end, IntOffset: endOffset},
+			},
+		}
+	}
+	for _, tc := range []windowFnTestCase{
+		// SUM_INT over ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING with PARTITION BY.
+		{
+			tuples:   tuples{{2, 20}, {1, 3}, {1, 1}, {2, 10}, {1, 4}, {1, 2}},
+			expected: tuples{{1, 1, 3}, {1, 2, 6}, {1, 3, 9}, {1, 4, 7}, {2, 10, 30}, {2, 20, 30}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:     execinfrapb.WindowerSpec_Func{AggregateFunc: &sumIntFn},
+						ArgsIdxs: []uint32{1},
+						Ordering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						Frame: makeFrame(
+							execinfrapb.WindowerSpec_Frame_ROWS,
+							execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING, 1,
+							execinfrapb.WindowerSpec_Frame_OFFSET_FOLLOWING, 1,
+						),
+						FilterColIdx: -1,
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// MIN over ROWS BETWEEN CURRENT ROW AND 2 FOLLOWING skips NULLs.
+		{
+			tuples:   tuples{{3, 3}, {1, 5}, {5, 1}, {2, nil}, {4, 4}},
+			expected: tuples{{1, 5, 3}, {2, nil, 3}, {3, 3, 1}, {4, 4, 1}, {5, 1, 1}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:     execinfrapb.WindowerSpec_Func{AggregateFunc: &minFn},
+						ArgsIdxs: []uint32{1},
+						Ordering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						Frame: makeFrame(
+							execinfrapb.WindowerSpec_Frame_ROWS,
+							execinfrapb.WindowerSpec_Frame_CURRENT_ROW, 0,
+							execinfrapb.WindowerSpec_Frame_OFFSET_FOLLOWING, 2,
+						),
+						FilterColIdx: -1,
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// MAX over ROWS BETWEEN 2 PRECEDING AND 1 PRECEDING, the frame of the
+		// first tuple is empty.
+		{
+			tuples:   tuples{{3, 2}, {1, 3}, {4, 0}, {2, 1}},
+			expected: tuples{{1, 3, nil}, {2, 1, 3}, {3, 2, 3}, {4, 0, 2}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:     execinfrapb.WindowerSpec_Func{AggregateFunc: &maxFn},
+						ArgsIdxs: []uint32{1},
+						Ordering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						Frame: makeFrame(
+							execinfrapb.WindowerSpec_Frame_ROWS,
+							execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING, 2,
+							execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING, 1,
+						),
+						FilterColIdx: -1,
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// SUM_INT with the default frame includes all peers of the current tuple.
+		{
+			tuples:   tuples{{2, 3}, {1, 1}, {1, 2}},
+			expected: tuples{{1, 1, 3}, {1, 2, 3}, {2, 3, 6}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{AggregateFunc: &sumIntFn},
+						ArgsIdxs:     []uint32{1},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						FilterColIdx: -1,
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// MIN over RANGE BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING.
+		{
+			tuples:   tuples{{3, 7}, {1, 5}, {2, 4}, {1, 2}},
+			expected: tuples{{1, 5, 2}, {1, 2, 2}, {2, 4, 4}, {3, 7, 7}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:     execinfrapb.WindowerSpec_Func{AggregateFunc: &minFn},
+						ArgsIdxs: []uint32{1},
+						Ordering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						Frame: makeFrame(
+							execinfrapb.WindowerSpec_Frame_RANGE,
+							execinfrapb.WindowerSpec_Frame_CURRENT_ROW, 0,
+							execinfrapb.WindowerSpec_Frame_UNBOUNDED_FOLLOWING, 0,
+						),
+						FilterColIdx: -1,
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+	} {
+		runTests(t, []tuples{tc.tuples}, tc.expected, unorderedVerifier, func(inputs []Operator) (Operator, error) {
+			ct := make([]types.T, len(tc.tuples[0]))
+			for i := range ct {
+				ct[i] = *types.Int
+			}
+			spec := &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: ct}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Windower: &tc.windowerSpec,
+				},
+			}
+			args := NewColOperatorArgs{
+				Spec:                spec,
+				Inputs:              inputs,
+				StreamingMemAccount: testMemAcc,
+			}
+			args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+			result, err := NewColOperator(ctx, flowCtx, args)
+			if err != nil {
+				return nil, err
+			}
+			return result.Op, nil
+		})
+	}
+}
+
+func TestRelativeRank(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	percentRankFn := execinfrapb.WindowerSpec_PERCENT_RANK
+	cumeDistFn := execinfrapb.WindowerSpec_CUME_DIST
+	ntileFn := execinfrapb.WindowerSpec_NTILE
+	for _, tc := range []windowFnTestCase{
+		// PERCENT_RANK with PARTITION BY and ORDER BY.
+		{
+			tuples:   tuples{{1, 3}, {2, 1}, {1, 1}, {1, 4}, {1, 2}, {1, 2}},
+			expected: tuples{{1, 1, 0.0}, {1, 2, 0.25}, {1, 2, 0.25}, {1, 3, 0.75}, {1, 4, 1.0}, {2, 1, 0.0}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &percentRankFn},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// CUME_DIST with PARTITION BY and ORDER BY.
+		{
+			tuples:   tuples{{1, 3}, {2, 1}, {1, 1}, {1, 4}, {1, 2}, {1, 2}},
+			expected: tuples{{1, 1, 0.2}, {1, 2, 0.6}, {1, 2, 0.6}, {1, 3, 0.8}, {1, 4, 1.0}, {2, 1, 1.0}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &cumeDistFn},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// CUME_DIST without ORDER BY, so all tuples are peers.
+		{
+			tuples:   tuples{{3}, {1}, {2}},
+			expected: tuples{{1, 1.0}, {2, 1.0}, {3, 1.0}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &cumeDistFn},
+						OutputColIdx: 1,
+					},
+				},
+			},
+		},
+		// NTILE without PARTITION BY. The number of buckets is determined by the
+		// first tuple with a non-NULL argument, and the buckets are counted from
+		// that tuple.
+		{
+			tuples: tuples{{4, 3}, {0, nil}, {2, 3}, {7, 3}, {1, 3}, {6, 3}, {3, 3}, {5, 3}},
+			expected: tuples{
+				{0, nil, nil}, {1, 3, 1}, {2, 3, 1}, {3, 3, 1}, {4, 3, 2}, {5, 3, 2}, {6, 3, 2}, {7, 3, 3},
+			},
+			windowerSpec: execinfrapb.WindowerSpec{
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &ntileFn},
+						ArgsIdxs:     []uint32{1},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+						OutputColIdx: 2,
+					},
+				},
+			},
+		},
+		// NTILE with more buckets than tuples in a partition.
+		{
+			tuples:   tuples{{1, 1, 5}, {1, 2, 5}, {2, 1, 1}, {2, 2, 1}},
+			expected: tuples{{1, 1, 5, 1}, {1, 2, 5, 2}, {2, 1, 1, 1}, {2, 2, 1, 1}},
+			windowerSpec: execinfrapb.WindowerSpec{
+				PartitionBy: []uint32{0},
+				WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+					{
+						Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &ntileFn},
+						ArgsIdxs:     []uint32{2},
+						Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+						OutputColIdx: 3,
+					},
+				},
+			},
+		},
+	} {
+		runTests(t, []tuples{tc.tuples}, tc.expected, unorderedVerifier, func(inputs []Operator) (Operator, error) {
+			ct := make([]types.T, len(tc.tuples[0]))
+			for i := range ct {
+				ct[i] = *types.Int
+			}
+			spec := &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: ct}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Windower: &tc.windowerSpec,
+				},
+			}
+			args := NewColOperatorArgs{
+				Spec:                spec,
+				Inputs:              inputs,
+				StreamingMemAccount: testMemAcc,
+			}
+			args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+			result, err := NewColOperator(ctx, flowCtx, args)
+			if err != nil {
+				return nil, err
+			}
+			return result.Op, nil
+		})
+	}
+}
+
+func TestRelativeRankSpilling(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	defer diskMonitor.Stop(ctx)
+	flowCtx.Cfg.DiskMonitor = diskMonitor
+	queueCfg, cleanup := colcontainer.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	// The input tuples are (a, b, n), and we compute
+	// PERCENT_RANK(), CUME_DIST(), and NTILE(n) OVER (PARTITION BY a ORDER BY b)
+	// where n is the same for all tuples. The partitions span multiple batches.
+	rng, _ := randutil.NewPseudoRand()
+	nTups := int(coldata.BatchSize()*4 + 1)
+	const maxPartitionVal, maxOrderVal, numBuckets = 5, 50, 7
+	logTypes := []types.T{*types.Int, *types.Int, *types.Int}
+	tups := make(tuples, nTups)
+	for i := range tups {
+		tups[i] = tuple{rng.Int63n(maxPartitionVal), rng.Int63n(maxOrderVal), int64(numBuckets)}
+	}
+	expected := make(tuples, 0, nTups)
+	for a := int64(0); a < maxPartitionVal; a++ {
+		var partition tuples
+		for _, tup := range tups {
+			if tup[0] == a {
+				partition = append(partition, tup)
+			}
+		}
+		sort.SliceStable(partition, func(i, j int) bool {
+			return partition[i][1].(int64) < partition[j][1].(int64)
+		})
+		// The buckets of NTILE are computed the same way as the row engine
+		// does it.
+		n := int64(len(partition))
+		boundary, remainder := n/numBuckets, n%numBuckets
+		if boundary == 0 {
+			boundary, remainder = 1, 0
+		} else if remainder != 0 {
+			boundary++
+		}
+		bucket, bucketCount := int64(1), int64(0)
+		for _, tup := range partition {
+			var numPreceding, numPrecedingOrPeer int64
+			for _, other := range partition {
+				if other[1].(int64) < tup[1].(int64) {
+					numPreceding++
+				}
+				if other[1].(int64) <= tup[1].(int64) {
+					numPrecedingOrPeer++
+				}
+			}
+			percentRank := 0.0
+			if n > 1 {
+				percentRank = float64(numPreceding) / float64(n-1)
+			}
+			if bucketCount++; bucketCount > boundary {
+				if remainder != 0 && bucket == remainder {
+					remainder = 0
+					boundary--
+				}
+				bucket++
+				bucketCount = 1
+			}
+			expected = append(expected, tuple{
+				tup[0], tup[1], tup[2], percentRank, float64(numPrecedingOrPeer) / float64(n), bucket,
+			})
+		}
+	}
+	percentRankFn := execinfrapb.WindowerSpec_PERCENT_RANK
+	cumeDistFn := execinfrapb.WindowerSpec_CUME_DIST
+	ntileFn := execinfrapb.WindowerSpec_NTILE
+	ordering := execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}}
+	windowerSpec := execinfrapb.WindowerSpec{
+		PartitionBy: []uint32{0},
+		WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+			{
+				Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &percentRankFn},
+				Ordering:     ordering,
+				OutputColIdx: 3,
+			},
+			{
+				Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &cumeDistFn},
+				Ordering:     ordering,
+				OutputColIdx: 4,
+			},
+			{
+				Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &ntileFn},
+				ArgsIdxs:     []uint32{2},
+				Ordering:     ordering,
+				OutputColIdx: 5,
+			},
+		},
+	}
+
+	var (
+		memAccounts []*mon.BoundAccount
+		memMonitors []*mon.BytesMonitor
+	)
+	// Interesting memory limits:
+	// 0 - the default 64MiB value is used, so the partitions are buffered up
+	//     in memory.
+	// 1 - this will force the in-memory operators to hit the memory limit
+	//     right after they buffer the first batch which will trigger the
+	//     external operators.
+	for _, memoryLimit := range []int64{0, 1} {
+		flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = memoryLimit
+		// A nil temporary storage FS makes the external operators keep
+		// the tuples in memory.
+		for _, tempFS := range []engine.FS{nil, queueCfg.FS} {
+			flowCtx.Cfg.TempFS = tempFS
+			t.Run(fmt.Sprintf("MemoryLimit=%d/OnDisk=%t", memoryLimit, tempFS != nil), func(t *testing.T) {
+				var spilled bool
+				runTests(t, []tuples{tups}, expected, unorderedVerifier, func(input []Operator) (Operator, error) {
+					spec := &execinfrapb.ProcessorSpec{
+						Input: []execinfrapb.InputSyncSpec{{ColumnTypes: logTypes}},
+						Core: execinfrapb.ProcessorCoreUnion{
+							Windower: &windowerSpec,
+						},
+					}
+					args := NewColOperatorArgs{
+						Spec:                spec,
+						Inputs:              input,
+						StreamingMemAccount: testMemAcc,
+					}
+					args.TestingKnobs.SpillingCallbackFn = func() { spilled = true }
+					result, err := NewColOperator(ctx, flowCtx, args)
+					memAccounts = append(memAccounts, result.BufferingOpMemAccounts...)
+					memMonitors = append(memMonitors, result.BufferingOpMemMonitors...)
+					return result.Op, err
+				})
+				require.Equal(t, memoryLimit == 1, spilled)
+			})
+		}
+	}
+
+	for _, account := range memAccounts {
+		account.Close(ctx)
+	}
+	for _, monitor := range memMonitors {
+		monitor.Stop(ctx)
+	}
+}
@@ -0,0 +1,553 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/errors"
+)
+
+var errInvalidArgumentForNtile = pgerror.Newf(
+	pgcode.InvalidParameterValue, "argument of ntile() must be greater than zero")
+
+// NewRelativeRankOperator creates a new Operator that computes one of the
+// window functions PERCENT_RANK, CUME_DIST, or NTILE. All of them need to know
+// the number of tuples in the partition, so the whole partition is buffered
+// up in memory. input *must* already be ordered on the partitioning columns
+// (as marked by the partitionColIdx'th column, -1 if there is no PARTITION BY
+// clause) and orderingCols. inputTypes must describe all of the columns of the
+// input batches, and the output of the function is appended at position
+// outputColIdx (which must be equal to len(inputTypes)).
+//
+// NTILE takes a single INT argument (the number of buckets) at argsIdxs[0],
+// and the other two functions don't take any arguments.
+func NewRelativeRankOperator(
+	allocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	windowFn execinfrapb.WindowerSpec_WindowFunc,
+	argsIdxs []uint32,
+	orderingCols []uint32,
+	outputColIdx int,
+	partitionColIdx int,
+) (Operator, error) {
+	fn, outputType, err := makeRelativeRankFn(inputTypes, windowFn, argsIdxs)
+	if err != nil {
+		return nil, err
+	}
+	return newBufferedWindowOp(
+		allocator, input, inputTypes, outputType, orderingCols, outputColIdx, partitionColIdx,
+		&relativeRankWindower{fn: fn},
+	)
+}
+
+// relativeRankFn computes the values of a relative rank window function for
+// the tuples of a single partition in order.
+type relativeRankFn struct {
+	windowFn execinfrapb.WindowerSpec_WindowFunc
+	// argColIdx is the index of the column with the number of buckets of NTILE
+	// (-1 for the other functions).
+	argColIdx int
+
+	partitionSize uint64
+	// numBuckets is the number of buckets of NTILE in the current partition.
+	// It is determined by the first tuple that has a non-NULL argument (the
+	// output is NULL for the tuples preceding it), and it is zero until then.
+	// bucketsStartIdx is the index of that tuple.
+	numBuckets      int64
+	bucketsStartIdx uint64
+}
+
+func makeRelativeRankFn(
+	inputTypes []coltypes.T, windowFn execinfrapb.WindowerSpec_WindowFunc, argsIdxs []uint32,
+) (relativeRankFn, coltypes.T, error) {
+	fn := relativeRankFn{windowFn: windowFn, argColIdx: -1}
+	switch windowFn {
+	case execinfrapb.WindowerSpec_PERCENT_RANK, execinfrapb.WindowerSpec_CUME_DIST:
+		if len(argsIdxs) != 0 {
+			return fn, coltypes.Unhandled, errors.Errorf("%s takes no arguments", windowFn)
+		}
+		return fn, coltypes.Float64, nil
+	case execinfrapb.WindowerSpec_NTILE:
+		if len(argsIdxs) != 1 {
+			return fn, coltypes.Unhandled, errors.Errorf("%s takes exactly one argument", windowFn)
+		}
+		fn.argColIdx = int(argsIdxs[0])
+		if inputTypes[fn.argColIdx] != coltypes.Int64 {
+			return fn, coltypes.Unhandled, errors.Errorf(
+				"unsupported argument type %s for %s", inputTypes[fn.argColIdx], windowFn,
+			)
+		}
+		return fn, coltypes.Int64, nil
+	default:
+		return fn, coltypes.Unhandled, errors.AssertionFailedf("unexpected relative rank window function %s", windowFn)
+	}
+}
+
+// startPartition prepares the function to process a new partition with
+// partitionSize tuples.
+func (f *relativeRankFn) startPartition(partitionSize uint64) {
+	f.partitionSize = partitionSize
+	f.numBuckets = 0
+}
+
+// set sets the outputIdx'th value of outputVec to the result of the function
+// for the tupleIdx'th tuple of the current partition that belongs to the peer
+// group [peerGroupStart, peerGroupEnd). argVec, if the function takes an
+// argument, must contain the argument of the tuple at position argIdx.
+func (f *relativeRankFn) set(
+	outputVec coldata.Vec,
+	outputIdx int,
+	argVec coldata.Vec,
+	argIdx uint64,
+	tupleIdx, peerGroupStart, peerGroupEnd uint64,
+) {
+	switch f.windowFn {
+	case execinfrapb.WindowerSpec_PERCENT_RANK:
+		// (rank - 1) / (number of tuples - 1), or zero if there is only one
+		// tuple.
+		res := float64(0)
+		if f.partitionSize > 1 {
+			res = float64(peerGroupStart) / float64(f.partitionSize-1)
+		}
+		outputVec.Float64()[outputIdx] = res
+	case execinfrapb.WindowerSpec_CUME_DIST:
+		// (number of tuples preceding or peer with the current one) /
+		// (number of tuples)
+		outputVec.Float64()[outputIdx] = float64(peerGroupEnd) / float64(f.partitionSize)
+	case execinfrapb.WindowerSpec_NTILE:
+		if f.numBuckets == 0 {
+			if argVec.Nulls().NullAt64(argIdx) {
+				outputVec.Nulls().SetNull(uint16(outputIdx))
+				return
+			}
+			numBuckets := argVec.Int64()[argIdx]
+			if numBuckets <= 0 {
+				execerror.NonVectorizedPanic(errInvalidArgumentForNtile)
+			}
+			f.numBuckets, f.bucketsStartIdx = numBuckets, tupleIdx
+		}
+		outputVec.Int64()[outputIdx] = ntileBucket(
+			int64(f.partitionSize), f.numBuckets, int64(tupleIdx-f.bucketsStartIdx),
+		)
+	default:
+		execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected relative rank window function %s", f.windowFn))
+	}
+}
+
+// ntileBucket returns the bucket (starting from 1) of the idx'th tuple when
+// numTuples tuples are divided into numBuckets buckets as equally as possible
+// with the larger buckets first.
+func ntileBucket(numTuples, numBuckets, idx int64) int64 {
+	bucketSize := numTuples / numBuckets
+	if bucketSize == 0 {
+		// There are more buckets than tuples, so every tuple gets its own bucket.
+		return idx + 1
+	}
+	// The first numLargeBuckets buckets contain an additional tuple.
+	numLargeBuckets := numTuples % numBuckets
+	numTuplesInLargeBuckets := numLargeBuckets * (bucketSize + 1)
+	if idx < numTuplesInLargeBuckets {
+		return idx/(bucketSize+1) + 1
+	}
+	return numLargeBuckets + (idx-numTuplesInLargeBuckets)/bucketSize + 1
+}
+
+// relativeRankWindower is the bufferedWindower for the relative rank window
+// functions.
+type relativeRankWindower struct {
+	fn relativeRankFn
+
+	partition    *bufferedBatch
+	peerGroupEnd []uint64
+	// peerGroupStart is the index of the first tuple of the peer group of the
+	// last tuple for which the output has been computed.
+	peerGroupStart uint64
+}
+
+var _ bufferedWindower = &relativeRankWindower{}
+
+func (w *relativeRankWindower) startPartition(partition *bufferedBatch, peerGroupEnd []uint64) {
+	w.partition = partition
+	w.peerGroupEnd = peerGroupEnd
+	w.peerGroupStart = 0
+	w.fn.startPartition(partition.length)
+}
+
+func (w *relativeRankWindower) compute(outputVec coldata.Vec, startIdx, endIdx uint64) {
+	// The output vector is reused across batches, so the nulls set for the
+	// previous batch need to be unset.
+	outputVec.Nulls().UnsetNulls()
+	var argVec coldata.Vec
+	if w.fn.argColIdx != -1 {
+		argVec = w.partition.ColVec(w.fn.argColIdx)
+	}
+	for i := startIdx; i < endIdx; i++ {
+		if i > 0 && w.peerGroupEnd[i-1] == i {
+			w.peerGroupStart = i
+		}
+		w.fn.set(outputVec, int(i-startIdx), argVec, i, i, w.peerGroupStart, w.peerGroupEnd[i])
+	}
+}
+
+// externalRelativeRankState indicates the current state of the external
+// relative rank operator.
+type externalRelativeRankState int
+
+const (
+	// externalRelativeRankBuffering indicates that the input is being
+	// buffered up in the partitioner while the sizes of the partitions and of
+	// the peer groups are computed.
+	externalRelativeRankBuffering externalRelativeRankState = iota
+	// externalRelativeRankEmitting indicates that the input has been fully
+	// consumed, and the buffered tuples are being emitted along with the
+	// output of the window function.
+	externalRelativeRankEmitting
+	// externalRelativeRankFinished indicates that all tuples have been
+	// emitted. This state is also responsible for closing the partitioners.
+	externalRelativeRankFinished
+)
+
+const (
+	// partitionSizesIdx is the index of the partition of the sizes
+	// Partitioner of the external relative rank operator that contains the
+	// sizes of the window partitions.
+	partitionSizesIdx = 0
+	// peerGroupSizesIdx is the index of the partition of the sizes Partitioner
+	// of the external relative rank operator that contains the sizes of the
+	// peer groups.
+	peerGroupSizesIdx = 1
+)
+
+// externalRelativeRankOp is a disk-backed operator that computes one of the
+// relative rank window functions. It is used as the fallback of the in-memory
+// operator when the partition doesn't fit in memory. In the first pass it
+// buffers up all of the input tuples in a Partitioner while computing the
+// sizes of every window partition and every peer group (which are stored in
+// another Partitioner since there might be too many of them to fit in memory
+// too), and in the second pass it reads the tuples back along with the sizes
+// and computes the output.
+type externalRelativeRankOp struct {
+	OneInputNode
+	NonExplainable
+
+	inputTypes      []coltypes.T
+	outputColIdx    int
+	partitionColIdx int
+	// peersCol, if non-nil, is the output column of the chain of ordered
+	// distinct operators on the ordering columns, see bufferedWindowOp.
+	peersCol []bool
+	fn       relativeRankFn
+
+	state externalRelativeRankState
+	// tuples contains all of the input tuples in a single partition.
+	tuples Partitioner
+	// sizes contains the sizes of the window partitions and of the peer groups
+	// in the partitionSizesIdx'th and the peerGroupSizesIdx'th partitions,
+	// respectively.
+	sizes Partitioner
+	// numAlreadyEmitted returns the number of the input tuples for which the
+	// output has already been emitted by the in-memory operator.
+	numAlreadyEmitted func() uint64
+	// numToSkip is the number of the tuples that still need to be skipped
+	// because they have already been emitted by the in-memory operator.
+	numToSkip uint64
+
+	buffering struct {
+		// seenTuple indicates whether at least one tuple has been read.
+		seenTuple         bool
+		partitionSize     uint64
+		peerGroupSize     uint64
+		sizesScratchBatch [2]coldata.Batch
+	}
+
+	emitting struct {
+		tuplesInput *partitionerToOperator
+		sizesInputs [2]*partitionerToOperator
+		// sizesBatches are the last batches read from sizesInputs, and
+		// sizesIdxs are the indices of the next values to be read from them.
+		sizesBatches [2]coldata.Batch
+		sizesIdxs    [2]uint16
+		// tupleIdx is the index of the next tuple within its partition.
+		tupleIdx       uint64
+		peerGroupStart uint64
+		peerGroupEnd   uint64
+		output         coldata.Batch
+	}
+}
+
+var _ Operator = &externalRelativeRankOp{}
+var _ Closer = &externalRelativeRankOp{}
+
+// newExternalRelativeRankOp returns a disk-backed operator that computes the
+// relative rank window function windowFn. See NewRelativeRankOperator for the
+// description of most of the arguments.
+// - unlimitedAllocator must have been created with a memory account derived
+// from an unlimited memory monitor. It is used for the output batches and for
+// the scratch batches of the sizes.
+// - tuples (with inputTypes schema) and sizes (with a single INT column) are
+// used to store the input tuples and the sizes, respectively. They are closed
+// by the operator once all tuples have been emitted or when it is closed.
+// - numAlreadyEmitted returns the number of the first input tuples that must
+// not be emitted (because they have already been emitted by the in-memory
+// operator that spilled to disk). It is called on the first call to Next.
+// - diskQueuesUnlimitedAllocator is an unlimited allocator that is used for
+// the batches that the partitioners are read into.
+func newExternalRelativeRankOp(
+	unlimitedAllocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	windowFn execinfrapb.WindowerSpec_WindowFunc,
+	argsIdxs []uint32,
+	orderingCols []uint32,
+	outputColIdx int,
+	partitionColIdx int,
+	tuples Partitioner,
+	sizes Partitioner,
+	numAlreadyEmitted func() uint64,
+	diskQueuesUnlimitedAllocator *Allocator,
+) (Operator, error) {
+	fn, outputType, err := makeRelativeRankFn(inputTypes, windowFn, argsIdxs)
+	if err != nil {
+		return nil, err
+	}
+	var peersCol []bool
+	if len(orderingCols) > 0 {
+		input, peersCol, err = OrderedDistinctColsToOperators(input, orderingCols, inputTypes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	op := &externalRelativeRankOp{
+		OneInputNode:      NewOneInputNode(input),
+		inputTypes:        inputTypes,
+		outputColIdx:      outputColIdx,
+		partitionColIdx:   partitionColIdx,
+		peersCol:          peersCol,
+		fn:                fn,
+		tuples:            tuples,
+		sizes:             sizes,
+		numAlreadyEmitted: numAlreadyEmitted,
+	}
+	sizesTypes := []coltypes.T{coltypes.Int64}
+	op.emitting.tuplesInput = newPartitionerToOperator(
+		diskQueuesUnlimitedAllocator, inputTypes, tuples, 0, /* partitionIdx */
+	).(*partitionerToOperator)
+	for i := range op.emitting.sizesInputs {
+		op.buffering.sizesScratchBatch[i] = unlimitedAllocator.NewMemBatch(sizesTypes)
+		op.emitting.sizesInputs[i] = newPartitionerToOperator(
+			diskQueuesUnlimitedAllocator, sizesTypes, sizes, i, /* partitionIdx */
+		).(*partitionerToOperator)
+		op.emitting.sizesBatches[i] = coldata.ZeroBatch
+	}
+	op.emitting.output = coldata.NewMemBatchWithSize(nil /* types */, int(coldata.BatchSize()))
+	for i := range inputTypes {
+		op.emitting.output.AppendCol(unlimitedAllocator.NewMemColumn(inputTypes[i], 0 /* n */))
+	}
+	op.emitting.output.AppendCol(unlimitedAllocator.NewMemColumn(outputType, int(coldata.BatchSize())))
+	return op, nil
+}
+
+func (r *externalRelativeRankOp) Init() {
+	r.input.Init()
+}
+
+func (r *externalRelativeRankOp) Next(ctx context.Context) coldata.Batch {
+	for {
+		switch r.state {
+		case externalRelativeRankBuffering:
+			if !r.buffering.seenTuple {
+				r.numToSkip = r.numAlreadyEmitted()
+			}
+			b := r.input.Next(ctx)
+			if b.Length() == 0 {
+				r.finishBuffering()
+				r.state = externalRelativeRankEmitting
+				continue
+			}
+			r.buffer(b)
+		case externalRelativeRankEmitting:
+			b := r.emit(ctx)
+			if b.Length() == 0 {
+				r.state = externalRelativeRankFinished
+				continue
+			}
+			if uint64(b.Length()) <= r.numToSkip {
+				r.numToSkip -= uint64(b.Length())
+				continue
+			}
+			return b
+		case externalRelativeRankFinished:
+			if err := r.Close(); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+			return coldata.ZeroBatch
+		default:
+			execerror.VectorizedInternalPanic(fmt.Sprintf("unexpected externalRelativeRankState %d", r.state))
+		}
+	}
+}
+
+// buffer enqueues the tuples of b into the tuples partitioner and updates the
+// sizes of the current window partition and peer group.
+func (r *externalRelativeRankOp) buffer(b coldata.Batch) {
+	if err := r.tuples.Enqueue(0 /* partitionIdx */, b); err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	var partitionCol []bool
+	if r.partitionColIdx != -1 {
+		partitionCol = b.ColVec(r.partitionColIdx).Bool()
+	}
+	sel := b.Selection()
+	for i := 0; i < int(b.Length()); i++ {
+		idx := i
+		if sel != nil {
+			idx = sel[i]
+		}
+		// Note that the first tuple of a window partition always begins a new
+		// peer group.
+		if !r.buffering.seenTuple || (partitionCol != nil && partitionCol[idx]) {
+			if r.buffering.seenTuple {
+				r.appendSize(peerGroupSizesIdx, r.buffering.peerGroupSize)
+				r.appendSize(partitionSizesIdx, r.buffering.partitionSize)
+			}
+			r.buffering.seenTuple = true
+			r.buffering.partitionSize, r.buffering.peerGroupSize = 0, 0
+		} else if r.peersCol != nil && r.peersCol[idx] {
+			r.appendSize(peerGroupSizesIdx, r.buffering.peerGroupSize)
+			r.buffering.peerGroupSize = 0
+		}
+		r.buffering.partitionSize++
+		r.buffering.peerGroupSize++
+	}
+}
+
+// appendSize appends size to the sizesIdx'th partition of the sizes
+// partitioner.
+func (r *externalRelativeRankOp) appendSize(sizesIdx int, size uint64) {
+	batch := r.buffering.sizesScratchBatch[sizesIdx]
+	n := batch.Length()
+	batch.ColVec(0).Int64()[n] = int64(size)
+	batch.SetLength(n + 1)
+	if n+1 == coldata.BatchSize() {
+		r.flushSizes(sizesIdx)
+	}
+}
+
+// flushSizes enqueues the sizes accumulated in the sizesIdx'th scratch batch
+// into the sizes partitioner.
+func (r *externalRelativeRankOp) flushSizes(sizesIdx int) {
+	batch := r.buffering.sizesScratchBatch[sizesIdx]
+	if err := r.sizes.Enqueue(sizesIdx, batch); err != nil {
+		execerror.VectorizedInternalPanic(err)
+	}
+	batch.SetLength(0)
+}
+
+// finishBuffering records the sizes of the last window partition and peer
+// group.
+func (r *externalRelativeRankOp) finishBuffering() {
+	if r.buffering.seenTuple {
+		r.appendSize(peerGroupSizesIdx, r.buffering.peerGroupSize)
+		r.appendSize(partitionSizesIdx, r.buffering.partitionSize)
+	}
+	for sizesIdx := range r.buffering.sizesScratchBatch {
+		r.flushSizes(sizesIdx)
+	}
+}
+
+// nextSize returns the next size from the sizesIdx'th partition of the sizes
+// partitioner.
+func (r *externalRelativeRankOp) nextSize(ctx context.Context, sizesIdx int) uint64 {
+	if r.emitting.sizesIdxs[sizesIdx] == r.emitting.sizesBatches[sizesIdx].Length() {
+		r.emitting.sizesBatches[sizesIdx] = r.emitting.sizesInputs[sizesIdx].Next(ctx)
+		r.emitting.sizesIdxs[sizesIdx] = 0
+		if r.emitting.sizesBatches[sizesIdx].Length() == 0 {
+			execerror.VectorizedInternalPanic("unexpectedly ran out of sizes in external relative rank operator")
+		}
+	}
+	size := r.emitting.sizesBatches[sizesIdx].ColVec(0).Int64()[r.emitting.sizesIdxs[sizesIdx]]
+	r.emitting.sizesIdxs[sizesIdx]++
+	return uint64(size)
+}
+
+// emit reads the next batch of the buffered tuples and computes the output
+// of the window function for it. The tuples that have already been emitted by
+// the in-memory operator are deselected.
+func (r *externalRelativeRankOp) emit(ctx context.Context) coldata.Batch {
+	b := r.emitting.tuplesInput.Next(ctx)
+	n := b.Length()
+	if n == 0 {
+		return b
+	}
+	output := r.emitting.output
+	for i := range r.inputTypes {
+		output.ReplaceCol(b.ColVec(i), i)
+	}
+	outputVec := output.ColVec(r.outputColIdx)
+	outputVec.Nulls().UnsetNulls()
+	var argVec coldata.Vec
+	if r.fn.argColIdx != -1 {
+		argVec = b.ColVec(r.fn.argColIdx)
+	}
+	for i := uint16(0); i < n; i++ {
+		if r.emitting.tupleIdx == r.fn.partitionSize {
+			r.fn.startPartition(r.nextSize(ctx, partitionSizesIdx))
+			r.emitting.tupleIdx, r.emitting.peerGroupEnd = 0, 0
+		}
+		if r.emitting.tupleIdx == r.emitting.peerGroupEnd {
+			r.emitting.peerGroupStart = r.emitting.tupleIdx
+			r.emitting.peerGroupEnd += r.nextSize(ctx, peerGroupSizesIdx)
+		}
+		r.fn.set(
+			outputVec, int(i), argVec, uint64(i),
+			r.emitting.tupleIdx, r.emitting.peerGroupStart, r.emitting.peerGroupEnd,
+		)
+		r.emitting.tupleIdx++
+	}
+	output.SetSelection(false)
+	if r.numToSkip > 0 && r.numToSkip < uint64(n) {
+		output.SetSelection(true)
+		sel := output.Selection()[:0]
+		for i := int(r.numToSkip); i < int(n); i++ {
+			sel = append(sel, i)
+		}
+		n -= uint16(r.numToSkip)
+		r.numToSkip = 0
+	}
+	output.SetLength(n)
+	return output
+}
+
+// Close is part of the Closer interface. It is safe to call it multiple
+// times.
+func (r *externalRelativeRankOp) Close() error {
+	var retErr error
+	if r.tuples != nil {
+		retErr = r.tuples.Close()
+		r.tuples = nil
+	}
+	if r.sizes != nil {
+		if err := r.sizes.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+		r.sizes = nil
+	}
+	return retErr
+}
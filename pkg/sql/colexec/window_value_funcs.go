@@ -0,0 +1,231 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/errors"
+)
+
+// NewValueWindowFuncOperator creates a new Operator that computes one of the
+// window functions LAG, LEAD, FIRST_VALUE, or LAST_VALUE (with the default
+// window frame). input *must* already be ordered on the partitioning columns
+// (as marked by the partitionColIdx'th column, -1 if there is no PARTITION BY
+// clause) and orderingCols. inputTypes must describe all of the columns of the
+// input batches, and the output of the function is appended at position
+// outputColIdx (which must be equal to len(inputTypes)).
+//
+// argsIdxs are the indices of the arguments of the function:
+// - LAG and LEAD take the value column, an optional INT offset column (1 if
+//   omitted) and an optional default value column (NULL if omitted).
+// - FIRST_VALUE and LAST_VALUE take only the value column.
+func NewValueWindowFuncOperator(
+	allocator *Allocator,
+	input Operator,
+	inputTypes []coltypes.T,
+	windowFn execinfrapb.WindowerSpec_WindowFunc,
+	argsIdxs []uint32,
+	orderingCols []uint32,
+	outputColIdx int,
+	partitionColIdx int,
+) (Operator, error) {
+	if len(argsIdxs) == 0 {
+		return nil, errors.Errorf("%s requires at least one argument", windowFn)
+	}
+	w := &valueWindower{
+		windowFn:      windowFn,
+		argColIdx:     int(argsIdxs[0]),
+		argType:       inputTypes[argsIdxs[0]],
+		offsetColIdx:  -1,
+		defaultColIdx: -1,
+		srcIdx:        make([]int, coldata.BatchSize()),
+	}
+	switch windowFn {
+	case execinfrapb.WindowerSpec_LAG, execinfrapb.WindowerSpec_LEAD:
+		if len(argsIdxs) > 3 {
+			return nil, errors.Errorf("%s takes at most three arguments", windowFn)
+		}
+		if len(argsIdxs) > 1 {
+			w.offsetColIdx = int(argsIdxs[1])
+			if inputTypes[w.offsetColIdx] != coltypes.Int64 {
+				return nil, errors.Errorf(
+					"unsupported offset type %s for %s", inputTypes[w.offsetColIdx], windowFn,
+				)
+			}
+		}
+		if len(argsIdxs) > 2 {
+			w.defaultColIdx = int(argsIdxs[2])
+			if inputTypes[w.defaultColIdx] != w.argType {
+				return nil, errors.Errorf(
+					"default value of type %s doesn't match the value type %s for %s",
+					inputTypes[w.defaultColIdx], w.argType, windowFn,
+				)
+			}
+		}
+		// LAG and LEAD don't depend on the peer groups.
+		orderingCols = nil
+	case execinfrapb.WindowerSpec_FIRST_VALUE:
+		if len(argsIdxs) != 1 {
+			return nil, errors.Errorf("%s takes exactly one argument", windowFn)
+		}
+		// FIRST_VALUE with the default frame always refers to the first tuple of
+		// the partition, so it doesn't depend on the peer groups.
+		orderingCols = nil
+	case execinfrapb.WindowerSpec_LAST_VALUE:
+		if len(argsIdxs) != 1 {
+			return nil, errors.Errorf("%s takes exactly one argument", windowFn)
+		}
+	default:
+		return nil, errors.AssertionFailedf("unexpected value window function %s", windowFn)
+	}
+	return newBufferedWindowOp(
+		allocator, input, inputTypes, w.argType, orderingCols, outputColIdx, partitionColIdx, w,
+	)
+}
+
+// valueWindower computes the window functions that return a value of their
+// argument evaluated at some other tuple of the partition. With the default
+// window frame (RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) those are:
+// - LAG - the tuple that is offset tuples before the current one,
+// - LEAD - the tuple that is offset tuples after the current one,
+// - FIRST_VALUE - the first tuple of the partition,
+// - LAST_VALUE - the last peer of the current tuple.
+type valueWindower struct {
+	windowFn      execinfrapb.WindowerSpec_WindowFunc
+	argColIdx     int
+	argType       coltypes.T
+	offsetColIdx  int
+	defaultColIdx int
+
+	partition    *bufferedBatch
+	peerGroupEnd []uint64
+
+	// srcIdx is scratch space for the index of the tuple in the partition from
+	// which the value should be copied for each output tuple.
+	srcIdx []int
+	// nullIdxs and defaultIdxs are scratch space for the output positions that
+	// should be set to NULL and to the default value, respectively.
+	nullIdxs    []uint16
+	defaultIdxs []uint16
+}
+
+var _ bufferedWindower = &valueWindower{}
+
+func (w *valueWindower) startPartition(partition *bufferedBatch, peerGroupEnd []uint64) {
+	w.partition = partition
+	w.peerGroupEnd = peerGroupEnd
+}
+
+func (w *valueWindower) compute(outputVec coldata.Vec, startIdx, endIdx uint64) {
+	n := endIdx - startIdx
+	srcIdx := w.srcIdx[:n]
+	w.nullIdxs = w.nullIdxs[:0]
+	w.defaultIdxs = w.defaultIdxs[:0]
+	switch w.windowFn {
+	case execinfrapb.WindowerSpec_FIRST_VALUE:
+		for i := range srcIdx {
+			srcIdx[i] = 0
+		}
+	case execinfrapb.WindowerSpec_LAST_VALUE:
+		for i := range srcIdx {
+			srcIdx[i] = int(w.peerGroupEnd[startIdx+uint64(i)] - 1)
+		}
+	default:
+		var (
+			offsetCol   []int64
+			offsetNulls *coldata.Nulls
+		)
+		if w.offsetColIdx != -1 {
+			offsetVec := w.partition.ColVec(w.offsetColIdx)
+			offsetCol = offsetVec.Int64()
+			if offsetVec.MaybeHasNulls() {
+				offsetNulls = offsetVec.Nulls()
+			}
+		}
+		partitionLength := int64(w.partition.length)
+		for i := range srcIdx {
+			tupleIdx := startIdx + uint64(i)
+			// Tuples that result in a NULL or the default value still need a
+			// valid index to copy from; their values are overwritten below.
+			srcIdx[i] = int(tupleIdx)
+			offset := int64(1)
+			if offsetCol != nil {
+				if offsetNulls != nil && offsetNulls.NullAt64(tupleIdx) {
+					w.nullIdxs = append(w.nullIdxs, uint16(i))
+					continue
+				}
+				offset = offsetCol[tupleIdx]
+			}
+			if w.windowFn == execinfrapb.WindowerSpec_LAG {
+				offset = -offset
+			}
+			target := int64(tupleIdx) + offset
+			if target < 0 || target >= partitionLength {
+				if w.defaultColIdx == -1 {
+					w.nullIdxs = append(w.nullIdxs, uint16(i))
+				} else {
+					w.defaultIdxs = append(w.defaultIdxs, uint16(i))
+				}
+				continue
+			}
+			srcIdx[i] = int(target)
+		}
+	}
+
+	// Copy the values in the order of the output positions since Bytes vectors
+	// don't support out of order sets: the runs of tuples between the ones that
+	// need the default value are copied from the argument column while the
+	// default values are copied one at a time.
+	runStart := uint64(0)
+	for _, i := range w.defaultIdxs {
+		w.copyArgs(outputVec, srcIdx, runStart, uint64(i))
+		tupleIdx := startIdx + uint64(i)
+		outputVec.Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					ColType:     w.argType,
+					Src:         w.partition.ColVec(w.defaultColIdx),
+					DestIdx:     uint64(i),
+					SrcStartIdx: tupleIdx,
+					SrcEndIdx:   tupleIdx + 1,
+				},
+			},
+		)
+		runStart = uint64(i) + 1
+	}
+	w.copyArgs(outputVec, srcIdx, runStart, n)
+	nulls := outputVec.Nulls()
+	for _, i := range w.nullIdxs {
+		nulls.SetNull(i)
+	}
+}
+
+// copyArgs copies the values of the argument column at srcIdx[startIdx:endIdx]
+// into outputVec at positions [startIdx, endIdx).
+func (w *valueWindower) copyArgs(outputVec coldata.Vec, srcIdx []int, startIdx, endIdx uint64) {
+	if startIdx == endIdx {
+		return
+	}
+	outputVec.Copy(
+		coldata.CopySliceArgs{
+			SliceArgs: coldata.SliceArgs{
+				ColType:     w.argType,
+				Src:         w.partition.ColVec(w.argColIdx),
+				DestIdx:     startIdx,
+				SrcStartIdx: startIdx,
+				SrcEndIdx:   endIdx,
+				Sel:         srcIdx,
+			},
+		},
+	)
+}
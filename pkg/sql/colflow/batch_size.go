@@ -0,0 +1,51 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colflow
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+)
+
+// vectorizedBatchSize determines the batch size of the vectorized flows (see
+// execinfra.FlowCtx.ScanBatchSize).
+var vectorizedBatchSize = settings.RegisterValidatedIntSetting(
+	"sql.distsql.vectorized_batch_size",
+	"maximum number of rows in the batches produced by the scans of the "+
+		"vectorized engine (0 means the default); a new value takes effect for "+
+		"the flows that are set up after it is changed, and values larger than "+
+		"the default have no effect",
+	0,
+	func(v int64) error {
+		if v == 0 {
+			return nil
+		}
+		return coldata.ValidateBatchSize(v)
+	},
+)
+
+// flowBatchSize returns the batch size of a vectorized flow that is being set
+// up with the provided settings.
+//
+// The batch size of a flow never exceeds coldata.BatchSize(), which doesn't
+// change at runtime and bounds the batches that the inboxes of all nodes
+// accept, so it is fine for the flows on different nodes to pick up a new
+// value of the setting at different times.
+func flowBatchSize(st *cluster.Settings) uint16 {
+	batchSize := int64(coldata.BatchSize())
+	if st != nil {
+		if v := vectorizedBatchSize.Get(&st.SV); v != 0 && v < batchSize {
+			batchSize = v
+		}
+	}
+	return uint16(batchSize)
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colflow
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorizedBatchSizeSetting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	st := cluster.MakeTestingClusterSettings()
+
+	origBatchSize := coldata.BatchSize()
+	defer coldata.SetBatchSizeForTests(origBatchSize)
+	coldata.SetBatchSizeForTests(coldata.DefaultBatchSize)
+
+	require.Equal(t, uint16(coldata.DefaultBatchSize), flowBatchSize(nil /* st */))
+	require.Equal(t, uint16(coldata.DefaultBatchSize), flowBatchSize(st))
+
+	vectorizedBatchSize.Override(&st.SV, 128)
+	require.Equal(t, uint16(128), flowBatchSize(st))
+	// The setting never changes coldata.BatchSize().
+	require.Equal(t, uint16(coldata.DefaultBatchSize), coldata.BatchSize())
+
+	// The batch size of the flows is capped at coldata.BatchSize(), which is
+	// the largest batch that the inboxes accept.
+	vectorizedBatchSize.Override(&st.SV, coldata.MaxBatchSize)
+	require.Equal(t, coldata.BatchSize(), flowBatchSize(st))
+
+	// Resetting the setting restores the default batch size.
+	vectorizedBatchSize.Override(&st.SV, 0)
+	require.Equal(t, coldata.BatchSize(), flowBatchSize(st))
+
+	require.Error(t, vectorizedBatchSize.Validate(coldata.MaxBatchSize+1))
+}
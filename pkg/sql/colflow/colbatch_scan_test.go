@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
@@ -32,6 +33,150 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
 
+// TestColBatchScanCountRows verifies that the scans that don't need any of the
+// columns return the right number of rows, both for the tables with a single
+// key per row (which only count the kvs) and for the ones with several.
+func TestColBatchScanCountRows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 3000
+	for _, tc := range []struct {
+		tableName string
+		schema    string
+	}{
+		{tableName: "single_family", schema: "k INT PRIMARY KEY, v INT"},
+		{tableName: "two_families", schema: "k INT PRIMARY KEY, v INT, FAMILY (k), FAMILY (v)"},
+	} {
+		t.Run(tc.tableName, func(t *testing.T) {
+			sqlutils.CreateTable(
+				t, sqlDB, tc.tableName, tc.schema, numRows,
+				sqlutils.ToRowFn(sqlutils.RowIdxFn, sqlutils.RowModuloFn(42)),
+			)
+			tableDesc := sqlbase.GetTableDescriptor(kvDB, "test", tc.tableName)
+			spec := execinfrapb.ProcessorSpec{
+				Core: execinfrapb.ProcessorCoreUnion{
+					TableReader: &execinfrapb.TableReaderSpec{
+						Table: *tableDesc,
+						Spans: []execinfrapb.TableReaderSpan{{Span: tableDesc.PrimaryIndexSpan()}},
+					}},
+				Post: execinfrapb.PostProcessSpec{
+					Projection:    true,
+					OutputColumns: []uint32{},
+				},
+			}
+
+			evalCtx := tree.MakeTestingEvalContext(s.ClusterSettings())
+			defer evalCtx.Stop(ctx)
+			flowCtx := execinfra.FlowCtx{
+				EvalCtx: &evalCtx,
+				Cfg:     &execinfra.ServerConfig{Settings: s.ClusterSettings()},
+				Txn:     client.NewTxn(ctx, s.DB(), s.NodeID()),
+				NodeID:  s.NodeID(),
+			}
+			args := colexec.NewColOperatorArgs{
+				Spec:                &spec,
+				StreamingMemAccount: testMemAcc,
+			}
+			args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+			res, err := colexec.NewColOperator(ctx, &flowCtx, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Op.Init()
+			count := 0
+			for {
+				bat := res.Op.Next(ctx)
+				if bat.Length() == 0 {
+					break
+				}
+				count += int(bat.Length())
+			}
+			if count != numRows {
+				t.Fatalf("expected %d rows, found %d", numRows, count)
+			}
+		})
+	}
+}
+
+// TestColBatchScanGrowsBatches verifies that the scans start with small batches
+// (so that the point lookups don't pay for full-sized batches) which grow as
+// long as they are filled up, up to the batch size of the flow.
+func TestColBatchScanGrowsBatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 3000
+	sqlutils.CreateTable(
+		t, sqlDB, "t", "k INT PRIMARY KEY, v INT", numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn, sqlutils.RowModuloFn(42)),
+	)
+	tableDesc := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+	spec := execinfrapb.ProcessorSpec{
+		Core: execinfrapb.ProcessorCoreUnion{
+			TableReader: &execinfrapb.TableReaderSpec{
+				Table: *tableDesc,
+				Spans: []execinfrapb.TableReaderSpan{{Span: tableDesc.PrimaryIndexSpan()}},
+			}},
+		Post: execinfrapb.PostProcessSpec{
+			Projection:    true,
+			OutputColumns: []uint32{1},
+		},
+	}
+
+	evalCtx := tree.MakeTestingEvalContext(s.ClusterSettings())
+	defer evalCtx.Stop(ctx)
+	flowCtx := execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg:     &execinfra.ServerConfig{Settings: s.ClusterSettings()},
+		Txn:     client.NewTxn(ctx, s.DB(), s.NodeID()),
+		NodeID:  s.NodeID(),
+		// Use a batch size that is smaller than coldata.BatchSize() (unless
+		// the latter is randomized to be even smaller).
+		ScanBatchSize: 512,
+	}
+	maxLength := int(coldata.BatchSize())
+	if maxLength > int(flowCtx.ScanBatchSize) {
+		maxLength = int(flowCtx.ScanBatchSize)
+	}
+	args := colexec.NewColOperatorArgs{
+		Spec:                &spec,
+		StreamingMemAccount: testMemAcc,
+	}
+	args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+	res, err := colexec.NewColOperator(ctx, &flowCtx, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Op.Init()
+	count, expectedLength := 0, 64
+	if expectedLength > maxLength {
+		expectedLength = maxLength
+	}
+	for {
+		bat := res.Op.Next(ctx)
+		if bat.Length() == 0 {
+			break
+		}
+		if count+expectedLength <= numRows && int(bat.Length()) != expectedLength {
+			t.Fatalf("expected batch of length %d after %d rows, found %d", expectedLength, count, bat.Length())
+		}
+		count += int(bat.Length())
+		if expectedLength *= 2; expectedLength > maxLength {
+			expectedLength = maxLength
+		}
+	}
+	if count != numRows {
+		t.Fatalf("expected %d rows, found %d", numRows, count)
+	}
+}
+
 func BenchmarkColBatchScan(b *testing.B) {
 	defer leaktest.AfterTest(b)()
 	logScope := log.Scope(b)
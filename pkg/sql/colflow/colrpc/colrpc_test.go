@@ -182,8 +182,12 @@ func TestOutboxInbox(t *testing.T) {
 	serverStreamNotification := <-mockServer.InboundStreams
 	serverStream := serverStreamNotification.Stream
 
+	// Compression is only used for schemas with compressible types, so a Bytes
+	// column is added when it is enabled.
+	compress := rng.Float64() < 0.5
+
 	// Do the actual testing.
-	t.Run(fmt.Sprintf("cancellationScenario=%s", cancellationScenarioName), func(t *testing.T) {
+	t.Run(fmt.Sprintf("cancellationScenario=%s/compress=%t", cancellationScenarioName, compress), func(t *testing.T) {
 		var (
 			typs        = []coltypes.T{coltypes.Int64}
 			inputBuffer = colexec.NewBatchBuffer()
@@ -195,6 +199,10 @@ func TestOutboxInbox(t *testing.T) {
 			sleepTime               = time.Microsecond * time.Duration(rng.Intn(500))
 		)
 
+		if compress {
+			typs = append(typs, coltypes.Bytes)
+		}
+
 		// Test random selection as the Outbox should be deselecting before sending
 		// over data. Nulls and types are not worth testing as those are tested in
 		// colserde.
@@ -224,6 +232,12 @@ func TestOutboxInbox(t *testing.T) {
 			colexec.NewAllocator(ctx, &outboxMemAcc), input, typs, nil,
 		)
 		require.NoError(t, err)
+		if compress {
+			outbox.EnableCompression()
+			// The random data doesn't compress well, so the compressed batches are
+			// always used in order to exercise the decompression on the Inbox side.
+			outbox.testingKnobAlwaysCompress = true
+		}
 
 		inboxMemAcc := testMemMonitor.MakeBoundAccount()
 		defer inboxMemAcc.Close(ctx)
@@ -324,21 +338,14 @@ func TestOutboxInbox(t *testing.T) {
 
 			// If no cancellation happened, the output can be fully verified against
 			// the input.
-			for batchNum := 0; ; batchNum++ {
+			for {
 				outputBatch := outputBatches.Next(ctx)
 				inputBatch := inputBatches.Next(ctx)
 				require.Equal(t, outputBatch.Length(), inputBatch.Length())
 				if outputBatch.Length() == 0 {
 					break
 				}
-				for i := range typs {
-					require.Equal(
-						t,
-						inputBatch.ColVec(i).Window(typs[i], 0, uint64(inputBatch.Length())),
-						outputBatch.ColVec(i).Window(typs[i], 0, uint64(outputBatch.Length())),
-						"batchNum: %d", batchNum,
-					)
-				}
+				coldata.AssertEquivalentBatches(t, inputBatch, outputBatch)
 			}
 		case streamCtxCancel:
 			// If the stream context gets canceled, GRPC should take care of closing
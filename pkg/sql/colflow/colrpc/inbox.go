@@ -110,6 +110,9 @@ type Inbox struct {
 	scratch struct {
 		data []*array.Data
 		b    coldata.Batch
+		// decompressed is the buffer into which the compressed messages are
+		// decompressed.
+		decompressed []byte
 	}
 }
 
@@ -323,8 +326,16 @@ func (i *Inbox) Next(ctx context.Context) coldata.Batch {
 			// Protect against Deserialization panics by skipping empty messages.
 			continue
 		}
+		rawBytes := m.Data.RawBytes
+		if m.Data.RawBytesCompressed {
+			var err error
+			if rawBytes, err = colserde.Decompress(i.scratch.decompressed, rawBytes); err != nil {
+				execerror.VectorizedInternalPanic(err)
+			}
+			i.scratch.decompressed = rawBytes
+		}
 		i.scratch.data = i.scratch.data[:0]
-		if err := i.serializer.Deserialize(&i.scratch.data, m.Data.RawBytes); err != nil {
+		if err := i.serializer.Deserialize(&i.scratch.data, rawBytes); err != nil {
 			execerror.VectorizedInternalPanic(err)
 		}
 		if err := i.converter.ArrowToBatch(i.scratch.data, i.scratch.b); err != nil {
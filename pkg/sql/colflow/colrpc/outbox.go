@@ -58,11 +58,21 @@ type Outbox struct {
 
 	// draining is an atomic that represents whether the Outbox is draining.
 	draining        uint32
-	metadataSources []execinfrapb.MetadataSource
+	metadataSources execinfrapb.MetadataSources
+
+	// compress specifies whether the serialized batches are compressed (when
+	// the compression reduces their size sufficiently).
+	compress bool
+	// testingKnobAlwaysCompress, if true, makes the Outbox use the compressed
+	// batches even if the compression doesn't reduce their size sufficiently.
+	testingKnobAlwaysCompress bool
 
 	scratch struct {
 		buf *bytes.Buffer
 		msg *execinfrapb.ProducerMessage
+		// compressed is the buffer into which the serialized batches are
+		// compressed.
+		compressed []byte
 	}
 
 	// A copy of Run's caller ctx, with no StreamID tag.
@@ -99,6 +109,15 @@ func NewOutbox(
 	return o, nil
 }
 
+// EnableCompression makes the Outbox compress the serialized batches if the
+// schema contains any types that usually compress well (see
+// colserde.ShouldCompress). The consumer must be able to decompress them,
+// i.e. it must be running a version that understands
+// ProducerData.RawBytesCompressed.
+func (o *Outbox) EnableCompression() {
+	o.compress = colserde.ShouldCompress(o.typs)
+}
+
 // Run starts an outbox by connecting to the provided node and pushing
 // coldata.Batches over the stream after sending a header with the provided flow
 // and stream ID. Note that an extra goroutine is spawned so that Recv may be
@@ -225,6 +244,11 @@ func (o *Outbox) sendBatches(
 		}
 
 		if err := execerror.CatchVectorizedRuntimeError(nextBatch); err != nil {
+			batchLength, batchWidth := -1, 0
+			if o.batch != nil {
+				batchLength, batchWidth = int(o.batch.Length()), o.batch.Width()
+			}
+			err = execerror.WithOperatorContext(err, o.Input(), -1 /* processorID */, batchLength, batchWidth)
 			log.Warningf(ctx, "Outbox Next error: %+v", err)
 			return false, err
 		}
@@ -243,6 +267,16 @@ func (o *Outbox) sendBatches(
 			return false, err
 		}
 		o.scratch.msg.Data.RawBytes = o.scratch.buf.Bytes()
+		o.scratch.msg.Data.RawBytesCompressed = false
+		if o.compress {
+			compressed := colserde.Compress(o.scratch.compressed, o.scratch.msg.Data.RawBytes)
+			o.scratch.compressed = compressed
+			if o.testingKnobAlwaysCompress ||
+				colserde.CompressionWorthwhile(len(compressed), len(o.scratch.msg.Data.RawBytes)) {
+				o.scratch.msg.Data.RawBytes = compressed
+				o.scratch.msg.Data.RawBytesCompressed = true
+			}
+		}
 
 		// o.scratch.msg can be reused as soon as Send returns since it returns as
 		// soon as the message is written to the control buffer. The message is
@@ -264,10 +298,8 @@ func (o *Outbox) sendMetadata(ctx context.Context, stream flowStreamClient, errT
 			msg.Data.Metadata, execinfrapb.LocalMetaToRemoteProducerMeta(ctx, execinfrapb.ProducerMetadata{Err: errToSend}),
 		)
 	}
-	for _, src := range o.metadataSources {
-		for _, meta := range src.DrainMeta(ctx) {
-			msg.Data.Metadata = append(msg.Data.Metadata, execinfrapb.LocalMetaToRemoteProducerMeta(ctx, meta))
-		}
+	for _, meta := range o.metadataSources.DrainMeta(ctx) {
+		msg.Data.Metadata = append(msg.Data.Metadata, execinfrapb.LocalMetaToRemoteProducerMeta(ctx, meta))
 	}
 	if len(msg.Data.Metadata) == 0 {
 		return nil
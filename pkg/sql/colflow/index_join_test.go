@@ -0,0 +1,118 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Note that this file is not in pkg/sql/colexec because it instantiates a
+// server, and if it were moved into sql/colexec, that would create a cycle
+// with pkg/server.
+
+package colflow_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexJoin(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 100
+	sqlutils.CreateTable(
+		t, sqlDB, "t",
+		"k INT PRIMARY KEY, v INT",
+		numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn, sqlutils.RowModuloFn(42)),
+	)
+	tableDesc := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+
+	// The first column of the input contains the primary keys, and the second
+	// column is an extra column that the index join ignores. Only the tuples
+	// selected by the selection vector are looked up.
+	inputKeys := []int64{7, 3, 1000, 42, 0, 99}
+	sel := []uint16{0, 1, 3, 5}
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64, coltypes.Int64})
+	for i, key := range inputKeys {
+		batch.ColVec(0).Int64()[i] = key
+		batch.ColVec(1).Int64()[i] = -1
+	}
+	batch.SetSelection(true)
+	copy(batch.Selection(), sel)
+	batch.SetLength(uint16(len(sel)))
+
+	evalCtx := tree.MakeTestingEvalContext(s.ClusterSettings())
+	defer evalCtx.Stop(ctx)
+	flowCtx := execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg:     &execinfra.ServerConfig{Settings: s.ClusterSettings()},
+		Txn:     client.NewTxn(ctx, s.DB(), s.NodeID()),
+		NodeID:  s.NodeID(),
+	}
+
+	spec := execinfrapb.ProcessorSpec{
+		Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int, *types.Int}}},
+		Core: execinfrapb.ProcessorCoreUnion{
+			JoinReader: &execinfrapb.JoinReaderSpec{
+				Table: *tableDesc,
+			}},
+		Post: execinfrapb.PostProcessSpec{
+			Projection:    true,
+			OutputColumns: []uint32{0, 1},
+		},
+	}
+	args := colexec.NewColOperatorArgs{
+		Spec:                &spec,
+		Inputs:              []colexec.Operator{colexec.NewLimitOp(colexec.NewRepeatableBatchSource(batch), 1)},
+		StreamingMemAccount: testMemAcc,
+	}
+	res, err := colexec.NewColOperator(ctx, &flowCtx, args)
+	require.NoError(t, err)
+
+	var expected, actual []string
+	for _, idx := range sel {
+		if key := inputKeys[idx]; key < numRows {
+			expected = append(expected, fmt.Sprintf("[%d %d]", key, key%42))
+		}
+	}
+	res.Op.Init()
+	for {
+		b := res.Op.Next(ctx)
+		if b.Length() == 0 {
+			break
+		}
+		for i := 0; i < int(b.Length()); i++ {
+			actual = append(actual, fmt.Sprint([]int64{b.ColVec(0).Int64()[i], b.ColVec(1).Int64()[i]}))
+		}
+	}
+	// The index join doesn't preserve the order of the input tuples.
+	sort.Strings(expected)
+	sort.Strings(actual)
+	require.Equal(t, expected, actual)
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Note that this file is not in pkg/sql/colexec because it instantiates a
+// server, and if it were moved into sql/colexec, that would create a cycle
+// with pkg/server.
+
+package colflow_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupJoin(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 100
+	sqlutils.CreateTable(
+		t, sqlDB, "t",
+		"k INT PRIMARY KEY, v INT",
+		numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn, sqlutils.RowModuloFn(42)),
+	)
+	tableDesc := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+
+	// The input contains duplicate keys, a key that doesn't exist in the table,
+	// and a NULL key, and it is not sorted.
+	inputKeys := []int64{7, 3, 1000, 7, 42, 0, 99}
+	const nullKeyIdx = 2
+	batch := testAllocator.NewMemBatch([]coltypes.T{coltypes.Int64})
+	for i, key := range inputKeys {
+		batch.ColVec(0).Int64()[i] = key
+	}
+	batch.ColVec(0).Nulls().SetNull(nullKeyIdx)
+	batch.SetLength(uint16(len(inputKeys)))
+
+	evalCtx := tree.MakeTestingEvalContext(s.ClusterSettings())
+	defer evalCtx.Stop(ctx)
+	flowCtx := execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg:     &execinfra.ServerConfig{Settings: s.ClusterSettings()},
+		Txn:     client.NewTxn(ctx, s.DB(), s.NodeID()),
+		NodeID:  s.NodeID(),
+	}
+
+	for _, joinType := range []sqlbase.JoinType{sqlbase.JoinType_INNER, sqlbase.JoinType_LEFT_OUTER} {
+		t.Run(joinType.String(), func(t *testing.T) {
+			spec := execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: []types.T{*types.Int}}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					JoinReader: &execinfrapb.JoinReaderSpec{
+						Table:               *tableDesc,
+						LookupColumns:       []uint32{0},
+						LookupColumnsAreKey: true,
+						Type:                joinType,
+					}},
+				Post: execinfrapb.PostProcessSpec{
+					Projection:    true,
+					OutputColumns: []uint32{0, 1, 2},
+				},
+			}
+			args := colexec.NewColOperatorArgs{
+				Spec:                &spec,
+				Inputs:              []colexec.Operator{colexec.NewLimitOp(colexec.NewRepeatableBatchSource(batch), 1)},
+				StreamingMemAccount: testMemAcc,
+			}
+			args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+			res, err := colexec.NewColOperator(ctx, &flowCtx, args)
+			require.NoError(t, err)
+
+			var expected, actual []string
+			for i, key := range inputKeys {
+				switch {
+				case i != nullKeyIdx && key < numRows:
+					expected = append(expected, fmt.Sprintf("[%d %d %d]", key, key, key%42))
+				case joinType == sqlbase.JoinType_LEFT_OUTER && i == nullKeyIdx:
+					expected = append(expected, "[NULL NULL NULL]")
+				case joinType == sqlbase.JoinType_LEFT_OUTER:
+					expected = append(expected, fmt.Sprintf("[%d NULL NULL]", key))
+				}
+			}
+			res.Op.Init()
+			for {
+				b := res.Op.Next(ctx)
+				if b.Length() == 0 {
+					break
+				}
+				for i := 0; i < int(b.Length()); i++ {
+					row := make([]interface{}, 3)
+					for colIdx := range row {
+						vec := b.ColVec(colIdx)
+						if vec.Nulls().NullAt(uint16(i)) {
+							row[colIdx] = "NULL"
+						} else {
+							row[colIdx] = vec.Int64()[i]
+						}
+					}
+					actual = append(actual, fmt.Sprint(row))
+				}
+			}
+			// The lookup join preserves the order of the input tuples.
+			require.Equal(t, expected, actual)
+		})
+	}
+}
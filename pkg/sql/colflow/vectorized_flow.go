@@ -19,6 +19,8 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coltypes"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/typeconv"
@@ -28,6 +30,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/flowinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -36,6 +40,16 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 )
 
+// vectorizedStreamCompressionEnabled determines whether the outboxes compress
+// the batches sent over the network.
+var vectorizedStreamCompressionEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.vectorized_stream_compression.enabled",
+	"if set, the vectorized engine compresses the batches with variable-width "+
+		"columns before sending them to other nodes; it has no effect until the "+
+		"cluster version is upgraded",
+	false,
+)
+
 type vectorizedFlow struct {
 	*flowinfra.FlowBase
 	// operatorConcurrency is set if any operators are executed in parallel.
@@ -78,6 +92,7 @@ func (f *vectorizedFlow) Setup(
 		return ctx, err
 	}
 	log.VEventf(ctx, 1, "setting up vectorize flow %s", f.ID.Short())
+	f.GetFlowCtx().ScanBatchSize = flowBatchSize(f.GetFlowCtx().Cfg.Settings)
 	recordingStats := false
 	if sp := opentracing.SpanFromContext(ctx); sp != nil && tracing.IsRecording(sp) {
 		recordingStats = true
@@ -154,10 +169,13 @@ func (f *vectorizedFlow) Cleanup(ctx context.Context) {
 // corresponding to operators in inputs (the latter must have already been
 // wrapped).
 func wrapWithVectorizedStatsCollector(
-	op colexec.Operator, inputs []colexec.Operator, pspec *execinfrapb.ProcessorSpec,
+	op colexec.Operator,
+	inputs []colexec.Operator,
+	pspec *execinfrapb.ProcessorSpec,
+	memMonitors []*mon.BytesMonitor,
 ) (*colexec.VectorizedStatsCollector, error) {
 	inputWatch := timeutil.NewStopWatch()
-	vsc := colexec.NewVectorizedStatsCollector(op, pspec.ProcessorID, len(inputs) == 0, inputWatch)
+	vsc := colexec.NewVectorizedStatsCollector(op, pspec.ProcessorID, len(inputs) == 0, inputWatch, memMonitors)
 	for _, input := range inputs {
 		sc, ok := input.(*colexec.VectorizedStatsCollector)
 		if !ok {
@@ -231,6 +249,8 @@ type flowCreatorHelper interface {
 type opDAGWithMetaSources struct {
 	rootOperator    colexec.Operator
 	metadataSources []execinfrapb.MetadataSource
+	// outputTypes are the types of the columns output by rootOperator.
+	outputTypes []types.T
 }
 
 // remoteComponentCreator is an interface that abstracts the constructors for
@@ -351,6 +371,13 @@ func (s *vectorizedFlowCreator) setupRemoteOutputStream(
 	if err != nil {
 		return nil, err
 	}
+	// The inboxes of the nodes running an older version cannot decompress the
+	// batches, so compression is only used once all nodes have been upgraded.
+	if flowCtx.Cfg != nil && flowCtx.Cfg.Settings != nil &&
+		cluster.Version.IsActive(ctx, flowCtx.Cfg.Settings, cluster.VersionVectorizedStreamCompression) &&
+		vectorizedStreamCompressionEnabled.Get(&flowCtx.Cfg.Settings.SV) {
+		outbox.EnableCompression()
+	}
 	atomic.AddInt32(&s.numOutboxes, 1)
 	run := func(ctx context.Context, cancelFn context.CancelFunc) {
 		outbox.Run(ctx, s.nodeDialer, stream.TargetNodeID, s.flowID, stream.StreamID, cancelFn)
@@ -383,6 +410,7 @@ func (s *vectorizedFlowCreator) setupRouter(
 	ctx context.Context,
 	flowCtx *execinfra.FlowCtx,
 	input colexec.Operator,
+	outputColumnTypes []types.T,
 	outputTyps []coltypes.T,
 	output *execinfrapb.OutputRouterSpec,
 	metadataSourcesQueue []execinfrapb.MetadataSource,
@@ -399,13 +427,16 @@ func (s *vectorizedFlowCreator) setupRouter(
 	hashRouterMemMonitor := execinfra.NewLimitedMonitor(
 		ctx, flowCtx.EvalCtx.Mon, flowCtx.Cfg, "hash-router-limited",
 	)
-	hashRouterMemAccount := hashRouterMemMonitor.MakeBoundAccount()
 	s.bufferingMemMonitors = append(s.bufferingMemMonitors, hashRouterMemMonitor)
-	s.bufferingMemAccounts = append(s.bufferingMemAccounts, &hashRouterMemAccount)
-	router, outputs := colexec.NewHashRouter(
-		colexec.NewAllocator(ctx, &hashRouterMemAccount), input, outputTyps,
-		hashCols, len(output.Streams),
-	)
+	// Every output of the hash router gets its own memory account, so that the
+	// memory used by the batches buffered by each of them is tracked separately.
+	allocators := make([]*colexec.Allocator, len(output.Streams))
+	for i := range allocators {
+		acc := hashRouterMemMonitor.MakeBoundAccount()
+		s.bufferingMemAccounts = append(s.bufferingMemAccounts, &acc)
+		allocators[i] = colexec.NewNamedAllocator(ctx, &acc, fmt.Sprintf("hash-router-output-%d", i))
+	}
+	router, outputs := colexec.NewHashRouter(allocators, input, outputTyps, hashCols)
 	runRouter := func(ctx context.Context, _ context.CancelFunc) {
 		router.Run(ctx)
 	}
@@ -434,13 +465,15 @@ func (s *vectorizedFlowCreator) setupRouter(
 				// information (e.g. output stall time).
 				var err error
 				op, err = wrapWithVectorizedStatsCollector(
-					op, nil /* inputs */, &execinfrapb.ProcessorSpec{ProcessorID: -1},
+					op, nil /* inputs */, &execinfrapb.ProcessorSpec{ProcessorID: -1}, nil, /* memMonitors */
 				)
 				if err != nil {
 					return err
 				}
 			}
-			s.streamIDToInputOp[stream.StreamID] = opDAGWithMetaSources{rootOperator: op, metadataSources: metadataSourcesQueue}
+			s.streamIDToInputOp[stream.StreamID] = opDAGWithMetaSources{
+				rootOperator: op, metadataSources: metadataSourcesQueue, outputTypes: outputColumnTypes,
+			}
 		}
 		// Either the metadataSourcesQueue will be drained by an outbox or we
 		// created an opDAGWithMetaSources to pass along these metadataSources. We don't need to
@@ -468,12 +501,19 @@ func (s *vectorizedFlowCreator) setupInput(
 	opt flowinfra.FuseOpt,
 ) (op colexec.Operator, _ []execinfrapb.MetadataSource, _ error) {
 	inputStreamOps := make([]colexec.Operator, 0, len(input.Streams))
+	// inputStreamTypes contains the types of the columns of every input stream.
+	// They can differ from the types of the input (e.g. for the streams of the
+	// two sides of a UNION ALL), in which case they are reconciled by the
+	// unordered synchronizer. The remote streams always have the types of the
+	// input.
+	inputStreamTypes := make([][]types.T, 0, len(input.Streams))
 	metaSources := make([]execinfrapb.MetadataSource, 0, len(input.Streams))
 	for _, inputStream := range input.Streams {
 		switch inputStream.Type {
 		case execinfrapb.StreamEndpointSpec_LOCAL:
 			in := s.streamIDToInputOp[inputStream.StreamID]
 			inputStreamOps = append(inputStreamOps, in.rootOperator)
+			inputStreamTypes = append(inputStreamTypes, in.outputTypes)
 			metaSources = append(metaSources, in.metadataSources...)
 		case execinfrapb.StreamEndpointSpec_REMOTE:
 			// If the input is remote, the input operator does not exist in
@@ -505,12 +545,14 @@ func (s *vectorizedFlowCreator) setupInput(
 					&execinfrapb.ProcessorSpec{
 						ProcessorID: -1,
 					},
+					nil, /* memMonitors */
 				)
 				if err != nil {
 					return nil, nil, err
 				}
 			}
 			inputStreamOps = append(inputStreamOps, op)
+			inputStreamTypes = append(inputStreamTypes, input.ColumnTypes)
 		default:
 			return nil, nil, errors.Errorf("unsupported input stream type %s", inputStream.Type)
 		}
@@ -523,17 +565,36 @@ func (s *vectorizedFlowCreator) setupInput(
 			return nil, nil, err
 		}
 		if input.Type == execinfrapb.InputSyncSpec_ORDERED {
-			op = colexec.NewOrderedSynchronizer(
+			for _, col := range input.Ordering.Columns {
+				if typs[col.ColIdx] == coltypes.Array {
+					return nil, nil, errors.Errorf("ordered synchronizer on ARRAY columns is not supported")
+				}
+				if typs[col.ColIdx] == coltypes.Datum {
+					return nil, nil, errors.Errorf(
+						"ordered synchronizer on %s columns is not supported", &input.ColumnTypes[col.ColIdx],
+					)
+				}
+			}
+			op, err = colexec.PlanOrderedSynchronizer(
 				colexec.NewAllocator(ctx, s.newStreamingMemAccount(flowCtx)),
-				inputStreamOps, typs, execinfrapb.ConvertToColumnOrdering(input.Ordering),
+				inputStreamOps, input.ColumnTypes, input.Ordering,
 			)
+			if err != nil {
+				return nil, nil, err
+			}
 		} else {
-			if opt == flowinfra.FuseAggressively {
-				op = colexec.NewSerialUnorderedSynchronizer(inputStreamOps, typs)
-			} else {
-				op = colexec.NewParallelUnorderedSynchronizer(inputStreamOps, typs, s.waitGroup)
+			var wg *sync.WaitGroup
+			if opt != flowinfra.FuseAggressively {
+				wg = s.waitGroup
 				s.operatorConcurrency = true
 			}
+			op, err = colexec.NewUnionAllOp(
+				colexec.NewAllocator(ctx, s.newStreamingMemAccount(flowCtx)),
+				inputStreamOps, inputStreamTypes, input.ColumnTypes, wg,
+			)
+			if err != nil {
+				return nil, nil, err
+			}
 			// Don't use the unordered synchronizer's inputs for stats collection
 			// given that they run concurrently. The stall time will be collected
 			// instead.
@@ -543,7 +604,9 @@ func (s *vectorizedFlowCreator) setupInput(
 			// TODO(asubiotto): Once we have IDs for synchronizers, plumb them into
 			// this stats collector to display stats.
 			var err error
-			op, err = wrapWithVectorizedStatsCollector(op, statsInputs, &execinfrapb.ProcessorSpec{ProcessorID: -1})
+			op, err = wrapWithVectorizedStatsCollector(
+				op, statsInputs, &execinfrapb.ProcessorSpec{ProcessorID: -1}, nil, /* memMonitors */
+			)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -563,6 +626,7 @@ func (s *vectorizedFlowCreator) setupOutput(
 	flowCtx *execinfra.FlowCtx,
 	pspec *execinfrapb.ProcessorSpec,
 	op colexec.Operator,
+	opOutputColumnTypes []types.T,
 	opOutputTypes []coltypes.T,
 	metadataSourcesQueue []execinfrapb.MetadataSource,
 ) error {
@@ -572,6 +636,7 @@ func (s *vectorizedFlowCreator) setupOutput(
 			ctx,
 			flowCtx,
 			op,
+			opOutputColumnTypes,
 			opOutputTypes,
 			output,
 			// Pass in a copy of the queue to reset metadataSourcesQueue for
@@ -586,7 +651,9 @@ func (s *vectorizedFlowCreator) setupOutput(
 	outputStream := &output.Streams[0]
 	switch outputStream.Type {
 	case execinfrapb.StreamEndpointSpec_LOCAL:
-		s.streamIDToInputOp[outputStream.StreamID] = opDAGWithMetaSources{rootOperator: op, metadataSources: metadataSourcesQueue}
+		s.streamIDToInputOp[outputStream.StreamID] = opDAGWithMetaSources{
+			rootOperator: op, metadataSources: metadataSourcesQueue, outputTypes: opOutputColumnTypes,
+		}
 	case execinfrapb.StreamEndpointSpec_REMOTE:
 		// Set up an Outbox. Note that we pass in a copy of metadataSourcesQueue
 		// so that we can reset it below and keep on writing to it.
@@ -727,7 +794,8 @@ func (s *vectorizedFlowCreator) setupFlow(
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to vectorize execution plan")
 		}
-		if flowCtx.Cfg != nil && flowCtx.Cfg.TestingKnobs.EnableVectorizedInvariantsChecker {
+		if util.RaceEnabled ||
+			(flowCtx.Cfg != nil && flowCtx.Cfg.TestingKnobs.EnableVectorizedInvariantsChecker) {
 			result.Op = colexec.NewInvariantsChecker(result.Op, len(result.ColumnTypes))
 		}
 		if flowCtx.EvalCtx.SessionData.VectorizeMode == sessiondata.VectorizeAuto &&
@@ -744,7 +812,7 @@ func (s *vectorizedFlowCreator) setupFlow(
 
 		op := result.Op
 		if s.recordingStats {
-			vsc, err := wrapWithVectorizedStatsCollector(op, inputs, pspec)
+			vsc, err := wrapWithVectorizedStatsCollector(op, inputs, pspec, result.BufferingOpMemMonitors)
 			if err != nil {
 				return nil, err
 			}
@@ -760,12 +828,24 @@ func (s *vectorizedFlowCreator) setupFlow(
 			// when vectorize=auto.
 			return nil, errors.Errorf("hash router encountered when vectorize=auto")
 		}
+		if pspec.Output[0].Type == execinfrapb.OutputRouterSpec_BY_HASH {
+			for _, col := range pspec.Output[0].HashColumns {
+				// Collated strings are stored using their contents, so the equal
+				// strings could be routed to different outputs.
+				if result.ColumnTypes[col].Family() == types.CollatedStringFamily {
+					return nil, errors.Errorf("hash router on collated string columns is not supported")
+				}
+				if typeconv.FromColumnType(&result.ColumnTypes[col]) == coltypes.Datum {
+					return nil, errors.Errorf("hash router on %s columns is not supported", &result.ColumnTypes[col])
+				}
+			}
+		}
 		opOutputTypes, err := typeconv.FromColumnTypes(result.ColumnTypes)
 		if err != nil {
 			return nil, err
 		}
 		if err = s.setupOutput(
-			ctx, flowCtx, pspec, op, opOutputTypes, metadataSourcesQueue,
+			ctx, flowCtx, pspec, op, result.ColumnTypes, opOutputTypes, metadataSourcesQueue,
 		); err != nil {
 			return nil, err
 		}
@@ -156,10 +156,14 @@ func TestVectorizedFlowShutdown(t *testing.T) {
 				// Note that the components of the vectorized flow will run
 				// concurrently, so we cannot reuse testAllocator and/or testMemAcc in
 				// all of them, and we need to instantiate separate objects.
-				hashRouterMemAccount := testMemMonitor.MakeBoundAccount()
-				defer hashRouterMemAccount.Close(ctxRemote)
+				allocators := make([]*colexec.Allocator, numHashRouterOutputs)
+				for i := range allocators {
+					acc := testMemMonitor.MakeBoundAccount()
+					defer acc.Close(ctxRemote)
+					allocators[i] = colexec.NewAllocator(ctxRemote, &acc)
+				}
 				hashRouter, hashRouterOutputs := colexec.NewHashRouter(
-					colexec.NewAllocator(ctxRemote, &hashRouterMemAccount), hashRouterInput, typs, []int{0}, numHashRouterOutputs,
+					allocators, hashRouterInput, typs, []int{0},
 				)
 				for i := 0; i < numInboxes; i++ {
 					inboxMemAccount := testMemMonitor.MakeBoundAccount()
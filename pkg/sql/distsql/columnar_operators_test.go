@@ -56,12 +56,18 @@ func TestAggregatorAgainstProcessor(t *testing.T) {
 	}
 	var da sqlbase.DatumAlloc
 
-	deterministicAggFns := make([]execinfrapb.AggregatorSpec_Func, 0, len(colexec.SupportedAggFns)-1)
+	deterministicAggFns := make([]execinfrapb.AggregatorSpec_Func, 0, len(colexec.SupportedAggFns))
 	for _, aggFn := range colexec.SupportedAggFns {
-		if aggFn == execinfrapb.AggregatorSpec_ANY_NOT_NULL {
+		switch aggFn {
+		case execinfrapb.AggregatorSpec_ANY_NOT_NULL:
 			// We skip ANY_NOT_NULL aggregate function because it returns
 			// non-deterministic results.
 			continue
+		case execinfrapb.AggregatorSpec_CONCAT_AGG, execinfrapb.AggregatorSpec_STRING_AGG:
+			// We skip the string aggregates because their results depend on the
+			// order of the rows within the groups (and STRING_AGG takes two
+			// arguments).
+			continue
 		}
 		deterministicAggFns = append(deterministicAggFns, aggFn)
 	}
@@ -430,9 +436,8 @@ func TestHashJoinerAgainstProcessor(t *testing.T) {
 	seed := rand.Int()
 	rng := rand.New(rand.NewSource(int64(seed)))
 	nRuns := 3
-	nRows := 10
+	maxRows := 40
 	maxCols := 3
-	maxNum := 5
 	intTyps := make([]types.T, maxCols)
 	for i := range intTyps {
 		intTyps[i] = *types.Int
@@ -454,19 +459,30 @@ func TestHashJoinerAgainstProcessor(t *testing.T) {
 								inputTypes       []types.T
 								usingRandomTypes bool
 							)
+							// Vary the number of rows, the density of NULLs and the
+							// skew of the key distribution between the iterations.
+							nRows := 1 + rng.Intn(maxRows)
+							nullDensity := rng.Float64() / 2
 							if rng.Float64() < randTypesProbability {
 								inputTypes = generateRandomSupportedTypes(rng, nCols)
 								lRows = sqlbase.RandEncDatumRowsOfTypes(rng, nRows, inputTypes)
 								rRows = sqlbase.RandEncDatumRowsOfTypes(rng, nRows, inputTypes)
+								dupProbability := rng.Float64()
+								for _, rows := range []sqlbase.EncDatumRows{lRows, rRows} {
+									setRandomNulls(rng, rows, inputTypes, nullDensity)
+									addRandomDuplicates(rng, rows, dupProbability)
+								}
 								lEqCols = generateEqualityColumns(rng, nCols, nEqCols)
 								// Since random types might not be comparable, we use the same
 								// equality columns for both inputs.
 								rEqCols = lEqCols
 								usingRandomTypes = true
 							} else {
+								// The smaller maxNum is, the more rows share the same key.
+								maxNum := 1 + rng.Intn(2*nRows)
 								inputTypes = intTyps[:nCols]
-								lRows = sqlbase.MakeRandIntRowsInRange(rng, nRows, nCols, maxNum, nullProbability)
-								rRows = sqlbase.MakeRandIntRowsInRange(rng, nRows, nCols, maxNum, nullProbability)
+								lRows = sqlbase.MakeRandIntRowsInRange(rng, nRows, nCols, maxNum, nullDensity)
+								rRows = sqlbase.MakeRandIntRowsInRange(rng, nRows, nCols, maxNum, nullDensity)
 								lEqCols = generateEqualityColumns(rng, nCols, nEqCols)
 								rEqCols = generateEqualityColumns(rng, nCols, nEqCols)
 							}
@@ -507,16 +523,24 @@ func TestHashJoinerAgainstProcessor(t *testing.T) {
 								Core:  execinfrapb.ProcessorCoreUnion{HashJoiner: hjSpec},
 								Post:  execinfrapb.PostProcessSpec{Projection: true, OutputColumns: outputColumns, Filter: filter},
 							}
+							var batchSize uint16
+							if rng.Float64() < 0.5 {
+								// Use small batches so that the hash joiner has to resume
+								// the probing and the emitting of the unmatched rows
+								// across many of them.
+								batchSize = uint16(coldata.MinBatchSize + rng.Intn(8))
+							}
 							args := verifyColOperatorArgs{
 								anyOrder:    true,
 								inputTypes:  [][]types.T{inputTypes, inputTypes},
 								inputs:      []sqlbase.EncDatumRows{lRows, rRows},
 								outputTypes: outputTypes,
 								pspec:       pspec,
+								batchSize:   batchSize,
 							}
 							if err := verifyColOperator(args); err != nil {
-								fmt.Printf("--- join type = %s onExpr = %q filter = %q seed = %d run = %d ---\n",
-									testSpec.joinType.String(), onExpr.Expr, filter.Expr, seed, run)
+								fmt.Printf("--- join type = %s onExpr = %q filter = %q seed = %d run = %d batchSize = %d ---\n",
+									testSpec.joinType.String(), onExpr.Expr, filter.Expr, seed, run, batchSize)
 								fmt.Printf("--- lEqCols = %v rEqCols = %v ---\n", lEqCols, rEqCols)
 								prettyPrintTypes(inputTypes, "left" /* tableName */)
 								prettyPrintTypes(inputTypes, "right" /* tableName */)
@@ -537,6 +561,29 @@ func TestHashJoinerAgainstProcessor(t *testing.T) {
 	}
 }
 
+// setRandomNulls sets each value in rows to NULL with nullProbability.
+func setRandomNulls(
+	rng *rand.Rand, rows sqlbase.EncDatumRows, typs []types.T, nullProbability float64,
+) {
+	for _, row := range rows {
+		for i := range row {
+			if rng.Float64() < nullProbability {
+				row[i] = sqlbase.DatumToEncDatum(&typs[i], tree.DNull)
+			}
+		}
+	}
+}
+
+// addRandomDuplicates replaces each row with a random preceding one with
+// dupProbability, which skews the distribution of the values.
+func addRandomDuplicates(rng *rand.Rand, rows sqlbase.EncDatumRows, dupProbability float64) {
+	for i := 1; i < len(rows); i++ {
+		if rng.Float64() < dupProbability {
+			rows[i] = rows[rng.Intn(i)]
+		}
+	}
+}
+
 // generateEqualityColumns produces a random permutation of nEqCols random
 // columns on a table with nCols columns, so nEqCols must be not greater than
 // nCols.
@@ -870,7 +917,7 @@ func TestWindowFunctionsAgainstProcessor(t *testing.T) {
 
 func isSupportedType(typ *types.T) bool {
 	converted := typeconv.FromColumnType(typ)
-	return converted != coltypes.Unhandled
+	return converted != coltypes.Unhandled && converted != coltypes.Datum
 }
 
 // generateRandomSupportedTypes generates nCols random types that are supported
@@ -17,6 +17,7 @@ import (
 	"strconv"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
@@ -43,6 +44,10 @@ type verifyColOperatorArgs struct {
 	// memoryLimit specifies the desired memory limit on the testing knob (in
 	// bytes). If it is 0, then the default limit of 64MiB will be used.
 	memoryLimit int64
+	// batchSize (when non-zero) overrides coldata.BatchSize() while the
+	// columnar operators are set up and run, so that both the input and the
+	// output batches contain at most that many tuples.
+	batchSize uint16
 }
 
 // verifyColOperator passes inputs through both the processor defined by pspec
@@ -56,9 +61,14 @@ func verifyColOperator(args verifyColOperatorArgs) error {
 		}
 	}
 
+	if args.batchSize != 0 {
+		defer func(batchSize uint16) { coldata.SetBatchSizeForTests(batchSize) }(coldata.BatchSize())
+		coldata.SetBatchSizeForTests(args.batchSize)
+	}
+
 	ctx := context.Background()
 	st := cluster.MakeTestingClusterSettings()
-	tempEngine, err := engine.NewTempEngine(engine.DefaultStorageEngine, base.DefaultTestTempStorageConfig(st), base.DefaultTestStoreSpec)
+	tempEngine, tempFS, err := engine.NewTempEngine(engine.DefaultStorageEngine, base.DefaultTestTempStorageConfig(st), base.DefaultTestStoreSpec)
 	if err != nil {
 		return err
 	}
@@ -73,6 +83,7 @@ func verifyColOperator(args verifyColOperatorArgs) error {
 		Cfg: &execinfra.ServerConfig{
 			Settings:    st,
 			TempStorage: tempEngine,
+			TempFS:      tempFS,
 			DiskMonitor: diskMonitor,
 		},
 	}
@@ -880,6 +880,7 @@ func initTableReaderSpec(
 		Visibility:        n.colCfg.visibility.toDistSQLScanVisibility(),
 		LockingStrength:   n.lockingStrength,
 		LockingWaitPolicy: n.lockingWaitPolicy,
+		EstimatedRowCount: n.estimatedRowCount,
 
 		// Retain the capacity of the spans slice.
 		Spans: s.Spans[:0],
@@ -1626,6 +1627,7 @@ func (dsp *DistSQLPlanner) addAggregators(
 			Aggregations:     localAggs,
 			GroupCols:        groupCols,
 			OrderedGroupCols: orderedGroupCols,
+			Partial:          true,
 		}
 
 		p.AddNoGroupingStage(
@@ -59,6 +59,11 @@ type FlowCtx struct {
 
 	// Local is true if this flow is being run as part of a local-only query.
 	Local bool
+
+	// ScanBatchSize is the maximum number of tuples in the batches produced by
+	// the scans of a vectorized flow. It is determined once when the flow is
+	// set up. Zero means coldata.BatchSize().
+	ScanBatchSize uint16
 }
 
 // NewEvalCtx returns a modifiable copy of the FlowCtx's EvalContext.
@@ -27,6 +27,12 @@ type DistSQLMetrics struct {
 	QueueWaitHist *metric.Histogram
 	MaxBytesHist  *metric.Histogram
 	CurBytesCount *metric.Gauge
+
+	// VecHashJoinerMem, VecSorterMem, and VecAggregatorMem track the memory
+	// usage of the corresponding classes of the vectorized operators.
+	VecHashJoinerMem OpMemMetrics
+	VecSorterMem     OpMemMetrics
+	VecAggregatorMem OpMemMetrics
 }
 
 // MetricStruct implements the metrics.Struct interface.
@@ -34,6 +40,20 @@ func (DistSQLMetrics) MetricStruct() {}
 
 var _ metric.Struct = DistSQLMetrics{}
 
+// OpMemMetrics contains pointers to the metrics for monitoring the memory
+// usage of a class of operators.
+type OpMemMetrics struct {
+	// MaxBytesHist records the maximum memory usage of every operator.
+	MaxBytesHist *metric.Histogram
+	// CurBytesCount is the current memory usage of all operators.
+	CurBytesCount *metric.Gauge
+}
+
+// MetricStruct implements the metrics.Struct interface.
+func (OpMemMetrics) MetricStruct() {}
+
+var _ metric.Struct = OpMemMetrics{}
+
 var (
 	metaQueriesActive = metric.Metadata{
 		Name:        "sql.distsql.queries.active",
@@ -100,6 +120,32 @@ func MakeDistSQLMetrics(histogramWindow time.Duration) DistSQLMetrics {
 		QueueWaitHist: metric.NewLatency(metaQueueWaitHist, histogramWindow),
 		MaxBytesHist:  metric.NewHistogram(metaMemMaxBytes, histogramWindow, log10int64times1000, 3),
 		CurBytesCount: metric.NewGauge(metaMemCurBytes),
+
+		VecHashJoinerMem: makeOpMemMetrics("hash_joiner", histogramWindow),
+		VecSorterMem:     makeOpMemMetrics("sorter", histogramWindow),
+		VecAggregatorMem: makeOpMemMetrics("aggregator", histogramWindow),
+	}
+}
+
+// makeOpMemMetrics instantiates the metrics holder for monitoring the memory
+// usage of the vectorized operators of the given class.
+func makeOpMemMetrics(opClass string, histogramWindow time.Duration) OpMemMetrics {
+	prefix := "sql.mem.distsql.vectorized." + opClass
+	metaMemMaxBytes := metric.Metadata{
+		Name:        prefix + ".max",
+		Help:        "Memory usage per vectorized " + opClass + " operator",
+		Measurement: "Memory",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaMemCurBytes := metric.Metadata{
+		Name:        prefix + ".current",
+		Help:        "Current memory usage of vectorized " + opClass + " operators",
+		Measurement: "Memory",
+		Unit:        metric.Unit_BYTES,
+	}
+	return OpMemMetrics{
+		MaxBytesHist:  metric.NewHistogram(metaMemMaxBytes, histogramWindow, log10int64times1000, 3),
+		CurBytesCount: metric.NewGauge(metaMemCurBytes),
 	}
 }
 
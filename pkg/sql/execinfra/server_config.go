@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/storage/cloud"
 	"github.com/cockroachdb/cockroach/pkg/storage/diskmap"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
@@ -127,6 +128,12 @@ type ServerConfig struct {
 	// BulkAdder is used by some processors to bulk-ingest data as SSTs.
 	BulkAdder storagebase.BulkAdderFactory
 
+	// TempFS is used by the vectorized execution engine to store columns when
+	// the working set is larger than can be stored in memory. It is the FS of
+	// TempStorage, so the names passed to it are relative to the temporary
+	// storage directory. If nil, the spilled columns are kept in memory.
+	TempFS engine.FS
+
 	// DiskMonitor is used to monitor temporary storage disk usage. Actual disk
 	// space used will be a small multiple (~1.1) of this because of RocksDB
 	// space amplification.
@@ -209,7 +216,8 @@ type TestingKnobs struct {
 	Changefeed base.ModuleTestingKnobs
 
 	// EnableVectorizedInvariantsChecker, if enabled, will allow for planning
-	// the invariant checkers between all columnar operators.
+	// the invariant checkers between all columnar operators. Note that the
+	// invariant checkers are always planned in race builds.
 	EnableVectorizedInvariantsChecker bool
 
 	// Forces bulk adder flush every time a KV batch is processed.
@@ -345,3 +345,21 @@ type MetadataSource interface {
 	// calls after the first one.
 	DrainMeta(context.Context) []ProducerMetadata
 }
+
+// MetadataSources is a slice of MetadataSource which itself implements the
+// MetadataSource interface. It allows for a component that has several
+// metadata sources (for example, the inputs of a columnar operator) to expose
+// them as a single MetadataSource.
+type MetadataSources []MetadataSource
+
+var _ MetadataSource = MetadataSources{}
+
+// DrainMeta is part of the MetadataSource interface. It drains all of the
+// metadata sources in order and returns the accumulated metadata.
+func (s MetadataSources) DrainMeta(ctx context.Context) []ProducerMetadata {
+	var result []ProducerMetadata
+	for _, src := range s {
+		result = append(result, src.DrainMeta(ctx)...)
+	}
+	return result
+}
@@ -95,3 +95,38 @@ func (f *KVFetcher) NextKV(
 		f.bytesRead += int64(len(f.batchResponse))
 	}
 }
+
+// SkipKVs skips over at most n of the next kvs of this fetcher without decoding
+// them, and returns the number of kvs skipped. It returns zero if there are no
+// more kvs to fetch.
+func (f *KVFetcher) SkipKVs(ctx context.Context, n int) (skipped int, err error) {
+	for skipped < n {
+		if len(f.kvs) != 0 {
+			toSkip := n - skipped
+			if toSkip > len(f.kvs) {
+				toSkip = len(f.kvs)
+			}
+			f.kvs = f.kvs[toSkip:]
+			skipped += toSkip
+			continue
+		}
+		if len(f.batchResponse) > 0 {
+			var batchSkipped int
+			batchSkipped, f.batchResponse, err = enginepb.ScanSkipKeyValues(f.batchResponse, n-skipped)
+			if err != nil {
+				return skipped, err
+			}
+			skipped += batchSkipped
+			continue
+		}
+
+		var ok bool
+		ok, f.kvs, f.batchResponse, f.Span, err = f.nextBatch(ctx)
+		if err != nil || !ok {
+			return skipped, err
+		}
+		f.newSpan = true
+		f.bytesRead += int64(len(f.batchResponse))
+	}
+	return skipped, nil
+}
@@ -2526,6 +2526,14 @@ func ConvertLikeToRegexp(
 	return re, nil
 }
 
+// CompileRegexp compiles the specified POSIX regular expression as used by the
+// ~ and ~* comparison operators.
+func CompileRegexp(
+	ctx *EvalContext, pattern string, caseInsensitive bool,
+) (*regexp.Regexp, error) {
+	return ctx.ReCache.GetRegexp(regexpKey{s: pattern, caseInsensitive: caseInsensitive})
+}
+
 func matchLike(ctx *EvalContext, left, right Datum, caseInsensitive bool) (Datum, error) {
 	if left == DNull || right == DNull {
 		return DNull, nil
@@ -233,6 +233,14 @@ func SkipTableKey(valType *types.T, key []byte, dir IndexDescriptor_Direction) (
 		} else {
 			rkey, _, err = encoding.DecodeBitArrayDescending(key)
 		}
+	case types.JsonFamily:
+		// JSON values are only present in the keys of inverted indexes, and the
+		// length of an inverted index key can be determined without decoding it.
+		var l int
+		l, err = encoding.PeekLength(key)
+		if err == nil {
+			rkey = key[l:]
+		}
 	default:
 		// Tuples and arrays aren't indexable types right now, so we don't have cases for them.
 		return key, errors.AssertionFailedf("unsupported type %+v", log.Safe(valType))
@@ -1152,6 +1160,18 @@ func decodeArrayHeader(b []byte) (arrayHeader, []byte, error) {
 	}, b, nil
 }
 
+// DecodeArrayHeader decodes the header at the beginning of the value encoding
+// of an array (without the MarshalColumnValue prefix). It returns the number of
+// elements in the array, a function reporting whether the ith element is NULL,
+// and the remaining bytes that contain the encodings of the non-NULL elements.
+func DecodeArrayHeader(b []byte) (length uint64, isNull func(i uint64) bool, _ []byte, _ error) {
+	header, b, err := decodeArrayHeader(b)
+	if err != nil {
+		return 0, nil, b, err
+	}
+	return header.length, header.isNull, b, nil
+}
+
 // datumTypeToArrayElementEncodingType decides an encoding type to
 // place in the array header given a datum type. The element encoding
 // type is then used to encode/decode array elements.
@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/prop"
 )
@@ -206,6 +207,30 @@ func TestSkipTableKey(t *testing.T) {
 	properties.TestingRun(t)
 }
 
+func TestSkipInvertedIndexKey(t *testing.T) {
+	for _, s := range []string{`null`, `"a"`, `[]`, `[1, 2]`, `{"a": {"b": [true, "c"]}}`} {
+		j, err := json.ParseJSON(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := json.EncodeInvertedIndexKeys(nil /* b */, j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			// The inverted index key is followed by the primary key.
+			suffix := encoding.EncodeVarintAscending(nil, 42)
+			res, err := SkipTableKey(types.Jsonb, append(key, suffix...), IndexDescriptor_ASC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(res, suffix) {
+				t.Fatalf("%s: expected %v remaining, found %v", s, suffix, res)
+			}
+		}
+	}
+}
+
 func TestMarshalColumnValueRoundtrip(t *testing.T) {
 	a := &DatumAlloc{}
 	ctx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
@@ -263,3 +263,12 @@ func (a *DatumAlloc) NewDOid(v tree.DOid) tree.Datum {
 	*buf = (*buf)[1:]
 	return r
 }
+
+// NewDCollatedString creates a DCollatedString with the given contents and
+// locale. The collation environment of the DatumAlloc is reused between the
+// calls, so the collators for the locales are created only once.
+func (a *DatumAlloc) NewDCollatedString(
+	contents string, locale string,
+) (*tree.DCollatedString, error) {
+	return tree.NewDCollatedString(contents, locale, &a.env)
+}
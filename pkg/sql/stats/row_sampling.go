@@ -131,6 +131,13 @@ func (sr *SampleReservoir) SampleRow(
 	return nil
 }
 
+// WouldSample returns whether a row with the given rank would be added to the
+// reservoir by SampleRow. It allows the callers that need to construct the
+// row first to skip the rows that would be dropped anyway.
+func (sr *SampleReservoir) WouldSample(rank uint64) bool {
+	return len(sr.samples) < cap(sr.samples) || (len(sr.samples) > 0 && rank < sr.samples[0].Rank)
+}
+
 // Get returns the sampled rows.
 func (sr *SampleReservoir) Get() []SampledRow {
 	return sr.samples
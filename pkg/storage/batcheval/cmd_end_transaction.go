@@ -96,15 +96,16 @@ func declareKeysEndTxn(
 				// across the entire LHS to block all concurrent writes to the
 				// LHS because their stat deltas will interfere with the
 				// non-delta stats computed as a part of the split. Splits
-				// declare non-MVCC write access across the entire RHS to block
+				// declare exclusive read access across the entire RHS to block
 				// all concurrent reads and writes to the RHS because they will
-				// fail if applied after the split. (see
+				// fail if applied after the split, while only reading from it
+				// themselves. (see
 				// https://github.com/cockroachdb/cockroach/issues/14881)
 				spans.AddNonMVCC(spanset.SpanReadOnly, roachpb.Span{
 					Key:    st.LeftDesc.StartKey.AsRawKey(),
 					EndKey: st.LeftDesc.EndKey.AsRawKey(),
 				})
-				spans.AddNonMVCC(spanset.SpanReadWrite, roachpb.Span{
+				spans.AddNonMVCC(spanset.SpanReadExclusive, roachpb.Span{
 					Key:    st.RightDesc.StartKey.AsRawKey(),
 					EndKey: st.RightDesc.EndKey.AsRawKey(),
 				})
@@ -81,10 +81,11 @@ type Request interface {
 
 	// A SpanAccess of SpanReadWrite allows the requester to acquire an Exclusive
 	// lock for a key contained in the corresponding Span (when evaluating).
-	// SpanReadOnly spans do not permit any lock acquisition for their contained
-	// keys but are sequenced by the lockTable. A key must not appear in more than
-	// one span, i.e., it must be either SpanReadOnly or SpanReadWrite but not
-	// both.
+	// SpanReadOnly and SpanReadExclusive spans do not permit any lock
+	// acquisition for their contained keys but are sequenced by the lockTable.
+	// A key must not appear in more than one span, i.e., it must be either
+	// SpanReadOnly, SpanReadExclusive or SpanReadWrite but not several of
+	// them.
 	spans() *spanset.SpanSet
 
 	// The timestamp of the request. This must be equal to the Span.Timestamp in
@@ -949,13 +950,14 @@ func (l *lockState) tryActiveWait(
 		reservedBySelfTxn = g.txn.ID == waitForTxn.ID
 	}
 
-	if sa == spanset.SpanReadOnly {
+	if sa != spanset.SpanReadWrite {
 		if !l.holder.locked {
 			// Reads only care about locker, not a reservation.
 			return false, nil
 		}
-		// Locked by some other txn.
-		if g.ts.Less(waitForTs) {
+		// Locked by some other txn. Exclusive reads are non-MVCC, so they
+		// conflict with the lock regardless of its timestamp.
+		if sa == spanset.SpanReadOnly && g.ts.Less(waitForTs) {
 			return false, nil
 		}
 	}
@@ -118,3 +118,24 @@ func ScanDecodeKeyValueNoTS(repr []byte) (key []byte, value []byte, orepr []byte
 	}
 	return key, value, ret, err
 }
+
+// ScanSkipKeyValues skips over at most max key/value pairs of a binary stream,
+// such as in an MVCCScan "batch" (this is not the RocksDB batch repr format),
+// returning the number of pairs skipped and the suffix of data remaining in the
+// batch. Only the length prefixes of the pairs are read, so neither the keys nor
+// the values are decoded.
+func ScanSkipKeyValues(repr []byte, max int) (n int, orepr []byte, err error) {
+	for ; n < max && len(repr) > 0; n++ {
+		if len(repr) < kvLenSize {
+			return n, repr, errors.Errorf("unexpected batch EOF")
+		}
+		valSize := binary.LittleEndian.Uint32(repr)
+		keyEnd := binary.LittleEndian.Uint32(repr[4:kvLenSize]) + kvLenSize
+		if len(repr) < int(keyEnd+valSize) {
+			return n, nil, errors.Errorf("expected %d bytes, but only %d remaining",
+				keyEnd+valSize, len(repr))
+		}
+		repr = repr[keyEnd+valSize:]
+	}
+	return n, repr, nil
+}
@@ -48,3 +48,42 @@ func BenchmarkScanDecodeKeyValue(b *testing.B) {
 		}
 	})
 }
+
+func TestScanSkipKeyValues(t *testing.T) {
+	var rep []byte
+	for _, kv := range []struct{ key, value string }{{"a", "foo"}, {"bb", ""}, {"ccc", "bar baz"}} {
+		keyBytes := engine.EncodeKey(engine.MVCCKey{Key: roachpb.Key(kv.key), Timestamp: hlc.Timestamp{WallTime: 1}})
+		var lens [8]byte
+		binary.LittleEndian.PutUint64(lens[:], uint64(len(keyBytes)<<32)|uint64(len(kv.value)))
+		rep = append(rep, lens[:]...)
+		rep = append(rep, keyBytes...)
+		rep = append(rep, kv.value...)
+	}
+
+	n, rest, err := enginepb.ScanSkipKeyValues(rep, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected to skip 2 key/value pairs, skipped %d", n)
+	}
+	key, value, _, err := enginepb.ScanDecodeKeyValueNoTS(rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != "ccc" || string(value) != "bar baz" {
+		t.Fatalf("unexpected key/value pair after skipping: %s -> %s", key, value)
+	}
+
+	n, rest, err = enginepb.ScanSkipKeyValues(rep, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || len(rest) != 0 {
+		t.Fatalf("expected to skip all 3 key/value pairs, skipped %d with %d bytes remaining", n, len(rest))
+	}
+
+	if _, _, err := enginepb.ScanSkipKeyValues(rep[:len(rep)-1], 10); err == nil {
+		t.Fatal("expected an error on a truncated batch")
+	}
+}
@@ -13,21 +13,24 @@ package engine
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/diskmap"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
-	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/pebble"
 	"github.com/cockroachdb/pebble/vfs"
 )
 
 // NewTempEngine creates a new engine for DistSQL processors to use when
-// the working set is larger than can be stored in memory.
+// the working set is larger than can be stored in memory. It also returns the
+// FS of the engine, which the vectorized execution engine uses to spill to
+// disk. The names passed to the FS are relative to the temporary storage
+// directory.
 func NewTempEngine(
 	engine enginepb.EngineType, tempStorage base.TempStorageConfig, storeSpec base.StoreSpec,
-) (diskmap.Factory, error) {
+) (diskmap.Factory, FS, error) {
 	switch engine {
 	case enginepb.EngineTypeTeePebbleRocksDB:
 		fallthrough
@@ -59,15 +62,16 @@ func (r *rocksDBTempEngine) NewSortedDiskMultiMap() diskmap.SortedDiskMap {
 }
 
 // NewRocksDBTempEngine creates a new RocksDB engine for DistSQL processors to use when
-// the working set is larger than can be stored in memory.
+// the working set is larger than can be stored in memory. It also returns the
+// FS of the engine (see NewTempEngine).
 func NewRocksDBTempEngine(
 	tempStorage base.TempStorageConfig, storeSpec base.StoreSpec,
-) (diskmap.Factory, error) {
+) (diskmap.Factory, FS, error) {
 	if tempStorage.InMemory {
 		// TODO(arjun): Limit the size of the store once #16750 is addressed.
 		// Technically we do not pass any attributes to temporary store.
 		db := newRocksDBInMem(roachpb.Attributes{} /* attrs */, 0 /* cacheSize */)
-		return &rocksDBTempEngine{db: db}, nil
+		return &rocksDBTempEngine{db: db}, &tempFS{fs: db}, nil
 	}
 
 	cfg := RocksDBConfig{
@@ -86,22 +90,21 @@ func NewRocksDBTempEngine(
 	defer rocksDBCache.Release()
 	db, err := NewRocksDB(cfg, rocksDBCache)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &rocksDBTempEngine{db: db}, nil
+	return &rocksDBTempEngine{db: db}, &tempFS{fs: db, dir: tempStorage.Path}, nil
 }
 
 type pebbleTempEngine struct {
 	db *pebble.DB
+	// p is the Pebble instance that db belongs to.
+	p *Pebble
 }
 
 // Close implements the diskmap.Factory interface.
 func (r *pebbleTempEngine) Close() {
-	err := r.db.Close()
-	if err != nil {
-		log.Fatal(context.TODO(), err)
-	}
+	r.p.Close()
 }
 
 // NewSortedDiskMap implements the diskmap.Factory interface.
@@ -115,10 +118,11 @@ func (r *pebbleTempEngine) NewSortedDiskMultiMap() diskmap.SortedDiskMap {
 }
 
 // NewPebbleTempEngine creates a new Pebble engine for DistSQL processors to use
-// when the working set is larger than can be stored in memory.
+// when the working set is larger than can be stored in memory. It also returns
+// the FS of the engine (see NewTempEngine).
 func NewPebbleTempEngine(
 	tempStorage base.TempStorageConfig, storeSpec base.StoreSpec,
-) (diskmap.Factory, error) {
+) (diskmap.Factory, FS, error) {
 	// Default options as copied over from pebble/cmd/pebble/db.go
 	opts := DefaultPebbleOptions()
 	// Pebble doesn't currently support 0-size caches, so use a 128MB cache for
@@ -138,10 +142,79 @@ func NewPebbleTempEngine(
 		path = ""
 	}
 
-	p, err := pebble.Open(path, opts)
+	p, err := NewPebble(context.Background(), PebbleConfig{
+		StorageConfig: base.StorageConfig{Dir: path},
+		Opts:          opts,
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &pebbleTempEngine{db: p}, nil
+	return &pebbleTempEngine{db: p.db, p: p}, &tempFS{fs: p, dir: path}, nil
+}
+
+// tempFS is the FS of a temp engine. The names passed to it are relative to
+// the directory of the engine, so that its users don't need to know where the
+// temporary storage is located.
+type tempFS struct {
+	fs FS
+	// dir is the directory of the engine. It is empty for in-memory engines.
+	dir string
+}
+
+var _ FS = &tempFS{}
+
+// path returns the name that the wrapped FS knows the named file by.
+func (t *tempFS) path(name string) string {
+	return filepath.Join(t.dir, name)
+}
+
+// CreateFile implements the FS interface.
+func (t *tempFS) CreateFile(name string) (File, error) {
+	return t.fs.CreateFile(t.path(name))
+}
+
+// CreateFileWithSync implements the FS interface.
+func (t *tempFS) CreateFileWithSync(name string, bytesPerSync int) (File, error) {
+	return t.fs.CreateFileWithSync(t.path(name), bytesPerSync)
+}
+
+// LinkFile implements the FS interface.
+func (t *tempFS) LinkFile(oldname, newname string) error {
+	return t.fs.LinkFile(t.path(oldname), t.path(newname))
+}
+
+// OpenFile implements the FS interface.
+func (t *tempFS) OpenFile(name string) (File, error) {
+	return t.fs.OpenFile(t.path(name))
+}
+
+// OpenDir implements the FS interface.
+func (t *tempFS) OpenDir(name string) (File, error) {
+	return t.fs.OpenDir(t.path(name))
+}
+
+// DeleteFile implements the FS interface.
+func (t *tempFS) DeleteFile(name string) error {
+	return t.fs.DeleteFile(t.path(name))
+}
+
+// RenameFile implements the FS interface.
+func (t *tempFS) RenameFile(oldname, newname string) error {
+	return t.fs.RenameFile(t.path(oldname), t.path(newname))
+}
+
+// CreateDir implements the FS interface.
+func (t *tempFS) CreateDir(name string) error {
+	return t.fs.CreateDir(t.path(name))
+}
+
+// DeleteDir implements the FS interface.
+func (t *tempFS) DeleteDir(name string) error {
+	return t.fs.DeleteDir(t.path(name))
+}
+
+// ListDir implements the FS interface.
+func (t *tempFS) ListDir(name string) ([]string, error) {
+	return t.fs.ListDir(t.path(name))
 }
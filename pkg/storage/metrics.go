@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanlatch"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"go.etcd.io/etcd/raft/raftpb"
@@ -1181,6 +1182,9 @@ type StoreMetrics struct {
 	// RangeFeed counts.
 	RangeFeedMetrics *rangefeed.Metrics
 
+	// Latch manager metrics.
+	LatchMetrics *spanlatch.Metrics
+
 	// Closed timestamp metrics.
 	ClosedTimestampMaxBehindNanos *metric.Gauge
 }
@@ -1382,6 +1386,9 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		// RangeFeed counters.
 		RangeFeedMetrics: rangefeed.NewMetrics(),
 
+		// Latch manager metrics.
+		LatchMetrics: spanlatch.NewMetrics(histogramWindow),
+
 		// Closed timestamp metrics.
 		ClosedTimestampMaxBehindNanos: metric.NewGauge(metaClosedTimestampMaxBehindNanos),
 	}
@@ -93,6 +93,100 @@ var UseAtomicReplicationChanges = settings.RegisterBoolSetting(
 	true,
 )
 
+// maxLatchWait bounds the time that a request waits on conflicting latches
+// before giving up, which limits how long requests queue up behind a stuck
+// command.
+var maxLatchWait = settings.RegisterNonNegativeDurationSetting(
+	"kv.latch.max_wait",
+	"maximum duration that a request waits on conflicting latches before failing; "+
+		"set to 0 to wait indefinitely",
+	0,
+)
+
+// poisonSlowProposalLatches determines whether the latches of commands that
+// are stuck in Raft are poisoned, which makes the requests that wait on them
+// fail fast instead of queueing up behind the stuck command.
+var poisonSlowProposalLatches = settings.RegisterBoolSetting(
+	"kv.latch.poison_slow_proposals.enabled",
+	"if enabled, requests that wait on the latches of a command that has been stuck in Raft "+
+		"for longer than the slow request threshold fail instead of waiting",
+	false,
+)
+
+// slowLatchThreshold is the time that a request waits on a single conflicting
+// latch before the wait is logged along with the details of the latch that it
+// is blocked on.
+var slowLatchThreshold = settings.RegisterNonNegativeDurationSetting(
+	"kv.latch.slow_wait_threshold",
+	"duration that a request waits on a conflicting latch before the wait is logged as slow",
+	base.SlowRequestThreshold,
+)
+
+// maxLatchesPerRange bounds the number of latches tracked by the latch manager
+// of a range, beyond which requests wait for latches to be released before
+// acquiring their own, which keeps pathological workloads from growing the
+// latch manager without bound.
+var maxLatchesPerRange = settings.RegisterNonNegativeIntSetting(
+	"kv.latch.max_per_range",
+	"maximum number of latches held or waited on in a range beyond which requests wait "+
+		"for latches to be released before acquiring their own; set to 0 to disable",
+	0,
+)
+
+// boundedLatchSnapshots determines whether requests capture snapshots of only
+// the latches that overlap with their spans when acquiring latches, instead of
+// cloning the latch manager's interval trees. Comparing the
+// latch.snapshot.nodes_cloned metric with and without it quantifies the
+// copy-on-write cost of the snapshots.
+var boundedLatchSnapshots = settings.RegisterBoolSetting(
+	"kv.latch.bounded_snapshots.enabled",
+	"if enabled, requests acquiring latches only snapshot the latches that overlap with "+
+		"their spans instead of sharing the latch manager's interval trees",
+	false,
+)
+
+// maxLatchHoldDuration is the time for which a request can hold its latches
+// before the store's latch watchdog flags it, which catches requests that are
+// stuck while holding latches and are making the keys they cover unavailable.
+var maxLatchHoldDuration = settings.RegisterNonNegativeDurationSetting(
+	"kv.latch.max_hold_duration",
+	"duration for which a request can hold its latches before it is reported as stuck; "+
+		"set to 0 to disable",
+	0,
+)
+
+// Actions taken by the latch watchdog on latches held for longer than
+// kv.latch.max_hold_duration.
+const (
+	latchHoldActionLog = iota
+	latchHoldActionPoison
+	latchHoldActionCrash
+)
+
+// maxLatchHoldAction determines what the latch watchdog does with latches that
+// are held for longer than kv.latch.max_hold_duration.
+var maxLatchHoldAction = settings.RegisterEnumSetting(
+	"kv.latch.max_hold_action",
+	"action taken on latches held for longer than kv.latch.max_hold_duration: log them, "+
+		"poison them so that requests waiting on them fail, or crash the node",
+	"log",
+	map[int64]string{
+		latchHoldActionLog:    "log",
+		latchHoldActionPoison: "poison",
+		latchHoldActionCrash:  "crash",
+	},
+)
+
+// captureLatchStacks determines whether requests capture their stack when
+// acquiring latches, which the latch watchdog reports along with the latches
+// that are held for too long.
+var captureLatchStacks = settings.RegisterBoolSetting(
+	"kv.latch.capture_stacks.enabled",
+	"if enabled, requests record their stack when acquiring latches so that it can be "+
+		"reported if they hold them for longer than kv.latch.max_hold_duration",
+	false,
+)
+
 // MaxCommandSizeFloor is the minimum allowed value for the MaxCommandSize
 // cluster setting.
 const MaxCommandSizeFloor = 4 << 20 // 4MB
@@ -1190,7 +1284,19 @@ func (r *Replica) beginCmds(
 	// protected access and to avoid interacting requests from operating at
 	// the same time. The latches will be held for the duration of request.
 	log.Event(ctx, "acquire latches")
-	lg, err := r.latchMgr.Acquire(ctx, spans)
+	opts := spanlatch.AcquireOptions{
+		Timeout:         maxLatchWait.Get(&r.store.cfg.Settings.SV),
+		Holder:          makeLatchHolder(ba),
+		SlowThreshold:   slowLatchThreshold.Get(&r.store.cfg.Settings.SV),
+		Priority:        latchPriority(ba),
+		MaxLatches:      int(maxLatchesPerRange.Get(&r.store.cfg.Settings.SV)),
+		BoundedSnapshot: boundedLatchSnapshots.Get(&r.store.cfg.Settings.SV),
+		CaptureStack:    captureLatchStacks.Get(&r.store.cfg.Settings.SV),
+	}
+	if poisonSlowProposalLatches.Get(&r.store.cfg.Settings.SV) {
+		opts.PoisonPolicy = spanlatch.PoisonPolicyError
+	}
+	lg, err := r.latchMgr.AcquireWithOptions(ctx, spans, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -1210,6 +1316,84 @@ func (r *Replica) beginCmds(
 	return lg, nil
 }
 
+// latchPriority returns the priority with which the latches of the provided
+// batch are acquired. Lease transfers and node liveness updates are important
+// to the health of the cluster, so they are allowed to skip ahead of regular
+// traffic that is waiting on the same latches.
+func latchPriority(ba *roachpb.BatchRequest) spanlatch.Priority {
+	if _, ok := ba.GetArg(roachpb.TransferLease); ok {
+		return spanlatch.PriorityHigh
+	}
+	if len(ba.Requests) == 0 {
+		return spanlatch.PriorityNormal
+	}
+	for _, union := range ba.Requests {
+		if !keys.NodeLivenessSpan.Contains(union.GetInner().Header().Span()) {
+			return spanlatch.PriorityNormal
+		}
+	}
+	return spanlatch.PriorityHigh
+}
+
+// latchHolder describes the request that holds a set of latches. It is
+// attached to the latches so that requests waiting on them, as well as latch
+// introspection, can report who is blocking whom. It captures the fields it
+// needs up front because it can be formatted concurrently with the request's
+// evaluation, and it defers the formatting itself until it is needed.
+type latchHolder struct {
+	txnID   uuid.UUID
+	gateway roachpb.NodeID
+	method  roachpb.Method
+	numReqs int
+}
+
+// latchWaitingTxns returns the transactions whose requests are waiting on the
+// latches held by the requests of the provided transaction. It is used by the
+// Replica's txnwait.Queue to report these transactions as dependents of the
+// transaction, so that deadlocks that involve latch waits on the Replica are
+// detected.
+func (r *Replica) latchWaitingTxns(txnID uuid.UUID) []uuid.UUID {
+	var txns []uuid.UUID
+	for _, w := range r.latchMgr.Waits() {
+		blocker, ok := w.BlockingHolder.(*latchHolder)
+		if !ok || blocker.txnID != txnID {
+			continue
+		}
+		if waiter, ok := w.Waiter.(*latchHolder); ok && waiter.txnID != (uuid.UUID{}) {
+			txns = append(txns, waiter.txnID)
+		}
+	}
+	return txns
+}
+
+func makeLatchHolder(ba *roachpb.BatchRequest) *latchHolder {
+	h := &latchHolder{gateway: ba.GatewayNodeID, numReqs: len(ba.Requests)}
+	if ba.Txn != nil {
+		h.txnID = ba.Txn.ID
+	}
+	if len(ba.Requests) > 0 {
+		h.method = ba.Requests[0].GetInner().Method()
+	}
+	return h
+}
+
+func (h *latchHolder) String() string {
+	var buf strings.Builder
+	buf.WriteString(h.method.String())
+	if h.numReqs > 1 {
+		fmt.Fprintf(&buf, " (+%d more)", h.numReqs-1)
+	}
+	if h.txnID != (uuid.UUID{}) {
+		fmt.Fprintf(&buf, " txn=%s", h.txnID.Short())
+	} else {
+		buf.WriteString(" non-txn")
+	}
+	if h.gateway != 0 {
+		fmt.Fprintf(&buf, " gateway=n%d", h.gateway)
+	}
+	return buf.String()
+}
+
 // maybeWatchForMerge checks whether a merge of this replica into its left
 // neighbor is in its critical phase and, if so, arranges to block all requests
 // until the merge completes.
@@ -80,7 +80,10 @@ func newUnloadedReplica(
 	split.Init(&r.loadBasedSplitter, rand.Intn, func() float64 {
 		return float64(SplitByLoadQPSThreshold.Get(&store.cfg.Settings.SV))
 	})
-	r.latchMgr = spanlatch.Make(r.store.stopper, r.store.metrics.SlowLatchRequests)
+	r.latchMgr = spanlatch.Make(
+		r.store.stopper, r.store.metrics.SlowLatchRequests, r.store.metrics.LatchMetrics,
+	)
+	r.txnWaitQueue.SetWaitingTxnsFunc(r.latchWaitingTxns)
 	r.mu.proposals = map[storagebase.CmdIDKey]*ProposalData{}
 	r.mu.checksums = map[uuid.UUID]ReplicaChecksum{}
 	r.mu.proposalBuf.Init((*replicaProposer)(r))
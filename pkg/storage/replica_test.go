@@ -2728,12 +2728,12 @@ func TestReplicaLatchingTimestampNonInterference(t *testing.T) {
 	}
 }
 
-// TestReplicaLatchingSplitDeclaresWrites verifies that split operations declare
-// non-MVCC read access to the LHS and non-MVCC write access to the RHS of the
-// split. This is necessary to avoid conflicting changes to the range's stats,
-// even though splits do not actually write to their data span (and therefore a
-// failure to declare writes are not caught directly by any other test).
-func TestReplicaLatchingSplitDeclaresWrites(t *testing.T) {
+// TestReplicaLatchingSplitDeclaresExclusiveReads verifies that split operations
+// declare non-MVCC read access to the LHS and exclusive read access to the RHS
+// of the split. This is necessary to avoid conflicting changes to the range's
+// stats, even though splits do not actually write to their data span (see
+// TestSplitTriggerWritesNoRHSData).
+func TestReplicaLatchingSplitDeclaresExclusiveReads(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
 	var spans spanset.SpanSet
@@ -2764,7 +2764,9 @@ func TestReplicaLatchingSplitDeclaresWrites(t *testing.T) {
 		{spanset.SpanReadOnly, roachpb.Key("b"), true},
 		{spanset.SpanReadOnly, roachpb.Key("d"), true},
 		{spanset.SpanReadWrite, roachpb.Key("b"), false},
-		{spanset.SpanReadWrite, roachpb.Key("d"), true},
+		{spanset.SpanReadWrite, roachpb.Key("d"), false},
+		{spanset.SpanReadExclusive, roachpb.Key("b"), false},
+		{spanset.SpanReadExclusive, roachpb.Key("d"), true},
 	} {
 		err := spans.CheckAllowed(tc.access, roachpb.Span{Key: tc.key})
 		if tc.expectAccess {
@@ -2776,6 +2778,55 @@ func TestReplicaLatchingSplitDeclaresWrites(t *testing.T) {
 	}
 }
 
+// TestSplitTriggerWritesNoRHSData verifies that the split trigger only reads
+// from the data span of the RHS, which splits declare exclusive read access to
+// (see TestReplicaLatchingSplitDeclaresExclusiveReads), by running it on a
+// batch that asserts that all accesses are declared.
+func TestSplitTriggerWritesNoRHSData(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(t, stopper)
+
+	// Write to both sides of the split, so that the split trigger has data to
+	// read from the RHS.
+	for _, key := range []roachpb.Key{roachpb.Key("b"), roachpb.Key("d")} {
+		pArgs := putArgs(key, []byte("value"))
+		if _, pErr := tc.SendWrapped(&pArgs); pErr != nil {
+			t.Fatal(pErr)
+		}
+	}
+
+	desc := tc.repl.Desc()
+	leftDesc, rightDesc := *desc, *desc
+	leftDesc.EndKey = roachpb.RKey("c")
+	rightDesc.StartKey = roachpb.RKey("c")
+	rightDesc.RangeID = desc.RangeID + 1
+	args := roachpb.EndTxnRequest{
+		Commit: true,
+		InternalCommitTrigger: &roachpb.InternalCommitTrigger{
+			SplitTrigger: &roachpb.SplitTrigger{
+				LeftDesc:  leftDesc,
+				RightDesc: rightDesc,
+			},
+		},
+	}
+	var spans spanset.SpanSet
+	cmd, _ := batcheval.LookupCommand(roachpb.EndTxn)
+	cmd.DeclareKeys(desc, roachpb.Header{}, &args, &spans)
+
+	batch := tc.engine.NewBatch()
+	defer batch.Close()
+	txn := newTransaction("split", roachpb.Key("c"), 1, tc.Clock())
+	var ms enginepb.MVCCStats
+	_, err := batcheval.RunCommitTrigger(
+		ctx, NewReplicaEvalContext(tc.repl, &spans), spanset.NewBatch(batch, &spans), &ms, &args, txn,
+	)
+	require.NoError(t, err)
+}
+
 // TestReplicaUseTSCache verifies that write timestamps are upgraded
 // based on the timestamp cache.
 func TestReplicaUseTSCache(t *testing.T) {
@@ -205,6 +205,11 @@ func (r *Replica) executeWriteBatch(
 		case <-slowTimer.C:
 			slowTimer.Read = true
 			r.store.metrics.SlowRaftRequests.Inc(1)
+			if lg != nil && poisonSlowProposalLatches.Get(&r.store.cfg.Settings.SV) {
+				// Don't let the stuck command block the requests that overlap
+				// with it indefinitely.
+				r.latchMgr.Poison(lg)
+			}
 			log.Warningf(ctx, `have been waiting %.2fs for proposing command %s.
 This range is likely unavailable.
 Please submit this message at
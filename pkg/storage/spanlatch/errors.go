@@ -0,0 +1,149 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package spanlatch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// TimeoutError is returned by Manager.AcquireWithTimeout when the timeout
+// expires while the latch acquisition attempt is waiting on a conflicting
+// latch.
+type TimeoutError struct {
+	// Timeout is the timeout of the latch acquisition attempt.
+	Timeout time.Duration
+	// Span is the span of the latch that was being acquired.
+	Span roachpb.Span
+	// BlockingSpan, BlockingAccess, and BlockingTimestamp describe the latch
+	// that the attempt was waiting on when the timeout expired, and
+	// BlockingHolder, if non-nil, describes its holder.
+	BlockingSpan      roachpb.Span
+	BlockingAccess    spanset.SpanAccess
+	BlockingTimestamp hlc.Timestamp
+	BlockingHolder    fmt.Stringer
+}
+
+var _ error = &TimeoutError{}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s acquiring latch %s, waiting on %s latch %s@%s%s",
+		e.Timeout, e.Span, e.BlockingAccess, e.BlockingSpan, e.BlockingTimestamp,
+		formatHolder(e.BlockingHolder))
+}
+
+// PoisonedError is returned by latch acquisition attempts with the
+// PoisonPolicyError policy when a latch that they are waiting on is poisoned.
+type PoisonedError struct {
+	// Span is the span of the latch that was being acquired.
+	Span roachpb.Span
+	// BlockingSpan, BlockingAccess, and BlockingTimestamp describe the
+	// poisoned latch, and BlockingHolder, if non-nil, describes its holder.
+	BlockingSpan      roachpb.Span
+	BlockingAccess    spanset.SpanAccess
+	BlockingTimestamp hlc.Timestamp
+	BlockingHolder    fmt.Stringer
+}
+
+var _ error = &PoisonedError{}
+
+func (e *PoisonedError) Error() string {
+	return fmt.Sprintf("acquiring latch %s encountered poisoned %s latch %s@%s%s",
+		e.Span, e.BlockingAccess, e.BlockingSpan, e.BlockingTimestamp,
+		formatHolder(e.BlockingHolder))
+}
+
+// CanceledError is returned by latch acquisition attempts whose context is
+// canceled, or whose deadline expires, while they are waiting on a conflicting
+// latch. Unlike the bare context error, it identifies the latch that the
+// attempt was blocked on.
+type CanceledError struct {
+	// Err is the error of the context, which is the cause of the error.
+	Err error
+	// Span and Timestamp are the span and timestamp of the latch that was
+	// being acquired.
+	Span      roachpb.Span
+	Timestamp hlc.Timestamp
+	// BlockingSpan, BlockingAccess, and BlockingTimestamp describe the latch
+	// that the attempt was waiting on, and BlockingHolder, if non-nil,
+	// describes its holder.
+	BlockingSpan      roachpb.Span
+	BlockingAccess    spanset.SpanAccess
+	BlockingTimestamp hlc.Timestamp
+	BlockingHolder    fmt.Stringer
+}
+
+var _ error = &CanceledError{}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("%s while acquiring latch %s@%s, waiting on %s latch %s@%s%s",
+		e.Err, e.Span, e.Timestamp, e.BlockingAccess, e.BlockingSpan, e.BlockingTimestamp,
+		formatHolder(e.BlockingHolder))
+}
+
+// Cause returns the error of the context, for use with errors.Cause.
+func (e *CanceledError) Cause() error { return e.Err }
+
+// Unwrap returns the error of the context, for use with errors.Is and
+// errors.As.
+func (e *CanceledError) Unwrap() error { return e.Err }
+
+// OverloadedError is returned by latch acquisition attempts that are
+// backpressured because the Manager tracks more latches than their MaxLatches,
+// either immediately with the OverloadPolicyError policy or once their timeout
+// expires with the OverloadPolicyWait policy. The condition is transient, so
+// the attempt can be retried.
+type OverloadedError struct {
+	// Latches is the number of latches tracked by the Manager and MaxLatches
+	// is the limit of the attempt.
+	Latches    int
+	MaxLatches int
+}
+
+var _ error = &OverloadedError{}
+
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("latch manager overloaded: %d latches outstanding, limit is %d",
+		e.Latches, e.MaxLatches)
+}
+
+// BatchConflictError is returned by Manager.AcquireBatch when one of the span
+// sets in the batch conflicts with a span set that precedes it. The later span
+// set would have to wait on latches that can't be released until the whole
+// batch is acquired, so the batch is rejected before it is sequenced.
+type BatchConflictError struct {
+	// Index and Span identify the span set in the batch and its span that
+	// conflicts with the span ConflictSpan of the earlier span set at
+	// ConflictIndex.
+	Index         int
+	Span          roachpb.Span
+	ConflictIndex int
+	ConflictSpan  roachpb.Span
+}
+
+var _ error = &BatchConflictError{}
+
+func (e *BatchConflictError) Error() string {
+	return fmt.Sprintf("span %s of span set %d conflicts with span %s of span set %d in latch batch",
+		e.Span, e.Index, e.ConflictSpan, e.ConflictIndex)
+}
+
+// formatHolder formats the holder of a latch as a suffix of an error message.
+func formatHolder(holder fmt.Stringer) string {
+	if holder == nil {
+		return ""
+	}
+	return fmt.Sprintf(" held by %s", holder)
+}
@@ -0,0 +1,248 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package spanlatch
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// The read fast path allows for point read latch acquisitions to avoid
+// serializing on the Manager's mutex while the scope of their key holds no
+// write latches, which is the common case for read-heavy workloads. Instead of
+// being inserted into the read set under the Manager's mutex, their latch is
+// inserted into one of the scopedManager's read shards, which is picked based
+// on the latch's key and has its own mutex. Such latches can't conflict with
+// anything, since read latches only conflict with write latches, so they are
+// acquired immediately. Acquisitions subject to AcquireOptions.MaxLatches
+// don't use the fast path, since they are admitted based on the latches
+// tracked under the Manager's mutex. Conversely, the latches in the read
+// shards aren't counted against MaxLatches, so that their release doesn't
+// need to lock the Manager to notify the backpressured attempts.
+//
+// The fast path of a scope is closed by the first write latch acquisition that
+// sequences its latches in the scope, which flushes the read shards into the
+// read set under the Manager's mutex before capturing its snapshot. Read fast
+// path acquisitions check whether the fast path is closed while holding their
+// shard's mutex, so they are either flushed and waited on by the write or see
+// that the fast path is closed and fall back to sequencing their latch under
+// the Manager's mutex, after the write. The fast path is reopened once the
+// scope no longer holds any write latches.
+//
+// Operations other than Release on a Guard acquired through the fast path, as
+// well as the operations that inspect all of the Manager's latches, first
+// flush the latch out of its read shard, after which the Guard behaves like
+// any other Guard.
+
+// numReadShards is the number of read shards of a scopedManager.
+const numReadShards = 16
+
+// readShard holds read latches acquired through the read fast path.
+type readShard struct {
+	mu    syncutil.Mutex
+	scope spanset.SpanScope
+	reads latchList
+}
+
+// readShards are the read shards of a scopedManager.
+type readShards [numReadShards]readShard
+
+// shard returns the read shard of latches over the provided key.
+func (rs *readShards) shard(key []byte) *readShard {
+	// FNV-1a.
+	h := uint32(2166136261)
+	for _, c := range key {
+		h ^= uint32(c)
+		h *= 16777619
+	}
+	return &rs[h%numReadShards]
+}
+
+// loadReadShards returns the read shards of the scopedManager, or nil if
+// they haven't been allocated.
+func (sm *scopedManager) loadReadShards() *readShards {
+	return (*readShards)(atomic.LoadPointer(&sm.shards))
+}
+
+// readShards returns the read shards of the scopedManager, which has the
+// provided scope, allocating them if needed. They are only allocated on the
+// first read fast path acquisition to keep Managers small.
+func (sm *scopedManager) readShards(s spanset.SpanScope) *readShards {
+	if rs := sm.loadReadShards(); rs != nil {
+		return rs
+	}
+	rs := new(readShards)
+	for i := range rs {
+		rs[i].scope = s
+	}
+	if atomic.CompareAndSwapPointer(&sm.shards, nil, unsafe.Pointer(rs)) {
+		return rs
+	}
+	return sm.loadReadShards()
+}
+
+// fastPathClosed returns whether the read fast path of the scopedManager is
+// closed.
+func (sm *scopedManager) fastPathClosed() bool {
+	return atomic.LoadInt32(&sm.fastClosed) == 1
+}
+
+// closeFastPathLocked closes the read fast path of the scopedManager and
+// flushes its read shards. Must be called with the Manager's mu held.
+func (sm *scopedManager) closeFastPathLocked() {
+	// The fast path must be closed before the read shards are flushed so that
+	// the acquisitions that lock a shard after it is flushed observe it.
+	atomic.StoreInt32(&sm.fastClosed, 1)
+	sm.flushReadShardsLocked()
+}
+
+// maybeReopenFastPathLocked reopens the read fast path of the scopedManager
+// if it doesn't hold any write latches. Must be called with the Manager's mu
+// held.
+func (sm *scopedManager) maybeReopenFastPathLocked() {
+	if sm.fastPathClosed() && sm.trees[spanset.SpanReadWrite].Len() == 0 {
+		atomic.StoreInt32(&sm.fastClosed, 0)
+	}
+}
+
+// flushReadShardsLocked moves the latches in the read shards of the
+// scopedManager to its read set. Must be called with the Manager's mu held.
+func (sm *scopedManager) flushReadShardsLocked() {
+	rs := sm.loadReadShards()
+	if rs == nil {
+		return
+	}
+	for i := range rs {
+		sh := &rs[i]
+		sh.mu.Lock()
+		for sh.reads.len > 0 {
+			la := sh.reads.front()
+			sh.reads.remove(la)
+			la.g.inShard = false
+			sm.addReadLocked(la)
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// flushAllReadShardsLocked flushes the read shards of all scopes, which is
+// needed before inspecting all of the Manager's latches. Must be called with
+// mu held.
+func (m *Manager) flushAllReadShardsLocked() {
+	for s := range m.scopes {
+		m.scopes[s].flushReadShardsLocked()
+	}
+}
+
+// pointRead returns the scope of the span declared by the provided spans if
+// they consist of a single point read.
+func pointRead(spans *spanset.SpanSet) (spanset.SpanScope, bool) {
+	if spans.Len() != 1 {
+		return 0, false
+	}
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		if ss := spans.GetSpans(spanset.SpanReadOnly, s); len(ss) == 1 {
+			return s, len(ss[0].EndKey) == 0
+		}
+	}
+	return 0, false
+}
+
+// acquireFastRead attempts to acquire the latch of the provided spans through
+// the read fast path. It returns nil if the spans don't consist of a single
+// point read or if the fast path of their scope is closed, in which case the
+// latch must be acquired through the Manager's mutex instead.
+func (m *Manager) acquireFastRead(
+	spans *spanset.SpanSet, holder fmt.Stringer, priority Priority,
+) *Guard {
+	s, ok := pointRead(spans)
+	if !ok {
+		return nil
+	}
+	sm := &m.scopes[s]
+	if sm.fastPathClosed() {
+		return nil
+	}
+	lg := newSequencedGuard(spans, holder, priority, timeutil.Now())
+	la := &lg.latches(s, spanset.SpanReadOnly)[0]
+	sh := sm.readShards(s).shard(la.span.Key)
+
+	sh.mu.Lock()
+	if sm.fastPathClosed() {
+		sh.mu.Unlock()
+		return nil
+	}
+	lg.id = m.nextID()
+	lg.seq = atomic.AddUint64(&m.seqAlloc, 1)
+	la.id = m.nextID()
+	lg.shard = sh
+	lg.inShard = true
+	// The latch is acquired as soon as it is in the shard, from which a write
+	// can flush it and find it, so the Guard must be closed to bumps by then
+	// for higher-priority writes not to skip ahead of it.
+	lg.bumps.Lock()
+	lg.bumps.closed = true
+	lg.bumps.Unlock()
+	lg.markAcquired()
+	sh.reads.pushBack(la)
+	sh.mu.Unlock()
+
+	if m.metrics != nil {
+		m.metrics.latchesHeld(s, spanset.SpanReadOnly).Inc(1)
+		m.metrics.recordAcquisition(0 /* waits */, 0 /* dur */)
+		m.metrics.FastPathAcquisitions.Inc(1)
+	}
+	return lg
+}
+
+// releaseFastRead releases the latch of the provided Guard if it is still in
+// its read shard, and returns whether it did so. Otherwise, the latch was
+// flushed and must be released through the Manager's mutex.
+func (m *Manager) releaseFastRead(lg *Guard) bool {
+	sh := lg.shard
+	if sh == nil {
+		return false
+	}
+	sh.mu.Lock()
+	released := lg.inShard
+	if released {
+		sh.reads.remove(&lg.latches(sh.scope, spanset.SpanReadOnly)[0])
+		lg.inShard = false
+	}
+	sh.mu.Unlock()
+	if released && m.metrics != nil {
+		m.metrics.latchesHeld(sh.scope, spanset.SpanReadOnly).Dec(1)
+	}
+	return released
+}
+
+// unshardLocked flushes the latch of the provided Guard out of its read shard,
+// if it was acquired through the read fast path and is still there. Must be
+// called with mu held.
+func (m *Manager) unshardLocked(lg *Guard) {
+	sh := lg.shard
+	if sh == nil {
+		return
+	}
+	sh.mu.Lock()
+	if lg.inShard {
+		la := &lg.latches(sh.scope, spanset.SpanReadOnly)[0]
+		sh.reads.remove(la)
+		lg.inShard = false
+		m.scopes[sh.scope].addReadLocked(la)
+	}
+	sh.mu.Unlock()
+}
@@ -13,6 +13,10 @@ package spanlatch
 import (
 	"context"
 	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -56,27 +60,60 @@ import (
 //
 // Manager's zero value can be used directly.
 type Manager struct {
-	mu      syncutil.Mutex
-	idAlloc uint64
-	scopes  [spanset.NumSpanScope]scopedManager
+	mu syncutil.Mutex
+	// idAlloc and seqAlloc are accessed atomically (see nextID).
+	idAlloc  uint64
+	seqAlloc uint64
+	scopes   [spanset.NumSpanScope]scopedManager
+
+	// waits maps the Guards of the latch acquisition attempts that are waiting
+	// on a conflicting latch to the latch that they are waiting on, which
+	// makes up the waits-for graph returned by Waits. It is maintained by the
+	// waiting attempts themselves and has its own mutex, so Waits doesn't need
+	// to lock mu.
+	waits struct {
+		syncutil.Mutex
+		m map[*Guard]latchWait
+	}
+
+	// capacityC, if non-nil, is closed when latches are removed from the
+	// Manager to notify the attempts waiting for it to track fewer latches
+	// than their MaxLatches. It is protected by mu.
+	capacityC chan struct{}
 
 	stopper  *stop.Stopper
 	slowReqs *metric.Gauge
+	metrics  *Metrics
 }
 
 // scopedManager is a latch manager scoped to either local or global keys.
 // See spanset.SpanScope.
 type scopedManager struct {
 	readSet latchList
-	trees   [spanset.NumSpanAccess]btree
+	trees   [numTreeAccess]btree
+	// shards is the *readShards of the read fast path, which is allocated
+	// lazily, and fastClosed is set to 1 while the read fast path is closed.
+	// Both are accessed atomically. See fastpath.go.
+	shards     unsafe.Pointer
+	fastClosed int32
 }
 
+// maxReadSetLen is the number of read latches that the read set of a
+// scopedManager holds before the read latch acquisition that fills it up
+// flushes it into the read interval tree. Otherwise, the read set of a
+// read-heavy range would grow without bound between write latch acquisitions,
+// and the first write to come along would pay for flushing all of it while
+// holding the Manager's lock.
+const maxReadSetLen = 256
+
 // Make returns an initialized Manager. Using this constructor is optional as
-// the type's zero value is valid to use directly.
-func Make(stopper *stop.Stopper, slowReqs *metric.Gauge) Manager {
+// the type's zero value is valid to use directly. The metrics may be shared
+// between multiple Managers.
+func Make(stopper *stop.Stopper, slowReqs *metric.Gauge, metrics *Metrics) Manager {
 	return Manager{
 		stopper:  stopper,
 		slowReqs: slowReqs,
+		metrics:  metrics,
 	}
 }
 
@@ -86,14 +123,63 @@ type latch struct {
 	id         uint64
 	span       roachpb.Span
 	ts         hlc.Timestamp
-	done       *signal
+	g          *Guard
 	next, prev *latch // readSet linked-list.
+	// state is the latchState of the latch, accessed atomically. It only
+	// changes before the Guard is released through ReleaseSome and
+	// Downgrade.
+	state int32
+	// split is set if the latch is owned by a Guard returned by Split rather
+	// than by its Guard. It is protected by the Manager's mu.
+	split bool
 }
 
+// latchState is the state of a latch whose Guard hasn't been released.
+type latchState int32
+
+const (
+	// latchHeld is the state of a latch that is held by its Guard.
+	latchHeld latchState = iota
+	// latchDowngraded is the state of a write latch that was downgraded to a
+	// read latch.
+	latchDowngraded
+	// latchReleased is the state of a latch that was released individually.
+	latchReleased
+)
+
 func (la *latch) inReadSet() bool {
 	return la.next != nil
 }
 
+func (la *latch) loadState() latchState {
+	return latchState(atomic.LoadInt32(&la.state))
+}
+
+// releasedFor returns whether the latch no longer needs to be waited on by
+// latches with the provided access, even though its Guard hasn't been
+// released.
+func (la *latch) releasedFor(waitAccess spanset.SpanAccess) bool {
+	switch la.loadState() {
+	case latchReleased:
+		return true
+	case latchDowngraded:
+		return waitAccess == spanset.SpanReadOnly
+	default:
+		return false
+	}
+}
+
+// heldLatch formats a latch along with the holder of its Guard, if known. It
+// is used to describe the latches that are being waited on.
+type heldLatch latch
+
+func (la *heldLatch) String() string {
+	if h := la.g.holder; h != nil {
+		return fmt.Sprintf("%s (held by %s)", (*latch)(la), h)
+	}
+	return (*latch)(la).String()
+}
+
 //go:generate ../../util/interval/generic/gen.sh *latch spanlatch
 
 // Methods required by util/interval/generic type contract.
@@ -110,11 +196,197 @@ func (la *latch) SetEndKey(v []byte) { la.span.EndKey = v }
 // Manager.Acquire and accepted by Manager.Release.
 type Guard struct {
 	done signal
+	// poison is signaled when the Guard is poisoned (see Manager.Poison).
+	poison idempotentSignal
+	// holder describes the owner of the latches. It can be nil.
+	holder fmt.Stringer
+	// priority is the priority of the latch acquisition attempt.
+	priority Priority
+	// bumps tracks the higher-priority attempts that were sequenced after
+	// this one but that skipped ahead of it while it was still waiting (see
+	// Priority). Once closed, i.e. once the latches are acquired, the Guard
+	// can no longer be skipped.
+	bumps struct {
+		syncutil.Mutex
+		closed bool
+		by     []bump
+	}
+	// id identifies the Guard among the Guards of its Manager.
+	id uint64
+	// seq is the sequence number of the Guard, which counts the Guards that
+	// were sequenced by its Manager before it.
+	seq uint64
+	// sequenced is the time at which the latches were inserted into the
+	// Manager, and acquired is set to 1 once they have been acquired (i.e.
+	// once the acquisition attempt is done waiting on conflicting latches),
+	// at acquiredAt.
+	sequenced  time.Time
+	acquired   int32
+	acquiredAt time.Time
+	// stack is the stack of the goroutine that acquired the latches, if
+	// requested through AcquireOptions.CaptureStack, and reported is set to 1
+	// once the Guard has been returned by LongHeld.
+	stack    []uintptr
+	reported int32
+	// snap is the snapshot captured when the latches were sequenced by
+	// AcquireOptimistic. It is closed by Release.
+	snap *snapshot
+	// parts is set for the Guards returned by Split and Merge and for the
+	// Guards that were split. It is nil for most Guards.
+	parts *guardParts
+	// bumped are the read latches inserted by BumpReadTimestamp, which is
+	// protected by the Manager's mu, and bumpedTS is their timestamp.
+	bumped   []ownedLatch
+	bumpedTS hlc.Timestamp
+	// shard is the read shard that the Guard's latch was inserted into if it
+	// was acquired through the read fast path, and inShard is set while the
+	// latch is still in the shard. inShard is protected by the shard's mutex.
+	shard   *readShard
+	inShard bool
+	// changed is closed, and then replaced, each time that the state of some
+	// of the latches changes before the Guard is released. It is created
+	// lazily by the first waiter that needs it.
+	changed struct {
+		syncutil.Mutex
+		c chan struct{}
+	}
 	// latches [spanset.NumSpanScope][spanset.NumSpanAccess][]latch, but half the size.
 	latchesPtrs [spanset.NumSpanScope][spanset.NumSpanAccess]unsafe.Pointer
 	latchesLens [spanset.NumSpanScope][spanset.NumSpanAccess]int32
 }
 
+// markAcquired records that the Guard's latches were acquired.
+func (lg *Guard) markAcquired() {
+	lg.acquiredAt = timeutil.Now()
+	atomic.StoreInt32(&lg.acquired, 1)
+}
+
+// latchWait describes a conflicting latch that a latch acquisition attempt is
+// waiting on, and the time at which it started waiting on it.
+type latchWait struct {
+	held   *latch
+	access spanset.SpanAccess
+	start  time.Time
+}
+
+// startWaiting records that the acquisition attempt of the provided Guard is
+// waiting on the provided conflicting latch, until stopWaiting is called.
+func (m *Manager) startWaiting(lg *Guard, w latchWait) {
+	m.waits.Lock()
+	defer m.waits.Unlock()
+	if m.waits.m == nil {
+		m.waits.m = make(map[*Guard]latchWait)
+	}
+	m.waits.m[lg] = w
+}
+
+// stopWaiting is the counterpart of startWaiting.
+func (m *Manager) stopWaiting(lg *Guard) {
+	m.waits.Lock()
+	defer m.waits.Unlock()
+	delete(m.waits.m, lg)
+}
+
+// bump describes a latch of a higher-priority attempt that skipped ahead of a
+// conflicting latch of a Guard whose acquisition was still waiting.
+type bump struct {
+	// held is the latch of the higher-priority attempt and access is its
+	// access.
+	held   *latch
+	access spanset.SpanAccess
+	// wait is the latch of the bumped Guard that it conflicted with and
+	// waitAccess is its access.
+	wait       *latch
+	waitAccess spanset.SpanAccess
+}
+
+// maxSkipAhead is the number of latch acquisition attempts sequenced after a
+// Guard that can skip ahead of it. Attempts sequenced later wait on the Guard
+// regardless of their priority. This bounds the number of times that a
+// low-priority attempt can be skipped ahead of, so a wide write that conflicts
+// with a continuous stream of high-priority requests isn't starved.
+const maxSkipAhead = 256
+
+// canSkipAhead returns whether the higher-priority attempt owning the provided
+// Guard is allowed to skip ahead of the Guard's latches (see maxSkipAhead).
+func (lg *Guard) canSkipAhead(other *Guard) bool {
+	return other.seq-lg.seq <= maxSkipAhead
+}
+
+// tryBump records that the provided latch of another attempt skips ahead of
+// the wait latch of the Guard. It returns false if the Guard's latches have
+// already been acquired, in which case the other attempt must wait on them as
+// usual.
+func (lg *Guard) tryBump(b bump) bool {
+	lg.bumps.Lock()
+	defer lg.bumps.Unlock()
+	if lg.bumps.closed {
+		return false
+	}
+	lg.bumps.by = append(lg.bumps.by, b)
+	return true
+}
+
+// takeBumpsOrClose returns the bumps recorded since the last call, if any.
+// Otherwise, it closes the Guard to further bumps.
+func (lg *Guard) takeBumpsOrClose() []bump {
+	lg.bumps.Lock()
+	defer lg.bumps.Unlock()
+	by := lg.bumps.by
+	lg.bumps.by = nil
+	if len(by) == 0 {
+		lg.bumps.closed = true
+	}
+	return by
+}
+
+// guardParts tracks the relationship between a Guard and the Guards that were
+// split from it or merged into it.
+type guardParts struct {
+	// root is the Guard that the latches owned by a Guard returned by Split
+	// belong to, and owned are these latches. They are protected by the
+	// Manager's mu.
+	root  *Guard
+	owned []ownedLatch
+	// merged are the Guards combined by a Guard returned by Merge.
+	merged []*Guard
+	// splits is the number of unreleased Guards that own some of the latches
+	// of a Guard that was split, and released is set once the Guard itself is
+	// released. The Guard is only fully released once both are true. They
+	// are protected by the Manager's mu.
+	splits   int
+	released bool
+}
+
+// ownedLatch is a latch owned by a Guard returned by Split.
+type ownedLatch struct {
+	s  spanset.SpanScope
+	a  spanset.SpanAccess
+	la *latch
+}
+
+// changedChan returns a channel that is closed the next time that the state of
+// some of the Guard's latches changes.
+func (lg *Guard) changedChan() <-chan struct{} {
+	lg.changed.Lock()
+	defer lg.changed.Unlock()
+	if lg.changed.c == nil {
+		lg.changed.c = make(chan struct{})
+	}
+	return lg.changed.c
+}
+
+// notifyChanged notifies the waiters on the Guard's latches that the state of
+// some of them changed.
+func (lg *Guard) notifyChanged() {
+	lg.changed.Lock()
+	defer lg.changed.Unlock()
+	if lg.changed.c != nil {
+		close(lg.changed.c)
+		lg.changed.c = nil
+	}
+}
+
 func (lg *Guard) latches(s spanset.SpanScope, a spanset.SpanAccess) []latch {
 	len := lg.latchesLens[s][a]
 	if len == 0 {
@@ -133,8 +405,8 @@ func allocGuardAndLatches(nLatches int) (*Guard, []latch) {
 	// Guard would be an ideal candidate for object pooling, but without
 	// reference counting its latches we can't know whether they're still
 	// referenced by other tree snapshots. The latches hold a reference to
-	// the signal living on the Guard, so the guard can't be recycled while
-	// latches still point to it.
+	// the Guard, so the guard can't be recycled while latches still point
+	// to it.
 	if nLatches <= 1 {
 		alloc := new(struct {
 			g       Guard
@@ -178,7 +450,7 @@ func newGuard(spans *spanset.SpanSet) *Guard {
 			for i := range ssLatches {
 				latch := &latches[i]
 				latch.span = ss[i].Span
-				latch.done = &guard.done
+				latch.g = guard
 				latch.ts = ss[i].Timestamp
 				// latch.setID() in Manager.insert, under lock.
 			}
@@ -201,10 +473,429 @@ func newGuard(spans *spanset.SpanSet) *Guard {
 //
 // It returns a Guard which must be provided to Release.
 func (m *Manager) Acquire(ctx context.Context, spans *spanset.SpanSet) (*Guard, error) {
-	lg, snap := m.sequence(spans)
+	return m.AcquireWithOptions(ctx, spans, AcquireOptions{})
+}
+
+// AcquireWithTimeout is like Acquire, except that it also gives up waiting for
+// overlapping latches to be released once the provided timeout expires,
+// independently of the provided context. In that case, it releases all latches
+// that it has already acquired and returns a *TimeoutError identifying the
+// latch that it was waiting on. A zero timeout means that there is no timeout.
+func (m *Manager) AcquireWithTimeout(
+	ctx context.Context, spans *spanset.SpanSet, timeout time.Duration,
+) (*Guard, error) {
+	return m.AcquireWithOptions(ctx, spans, AcquireOptions{Timeout: timeout})
+}
+
+// PoisonPolicy determines how a latch acquisition attempt reacts to a latch
+// that it is waiting on being poisoned (see Manager.Poison).
+type PoisonPolicy int
+
+const (
+	// PoisonPolicyWait ignores the poisoning and keeps waiting for the latch
+	// to be released.
+	PoisonPolicyWait PoisonPolicy = iota
+	// PoisonPolicyError stops waiting and fails the attempt with a
+	// *PoisonedError.
+	PoisonPolicyError
+	// PoisonPolicyProceed stops waiting on the poisoned latch as if it had
+	// been released. The caller proceeds at its own risk since the owner of
+	// the poisoned latch might still be running.
+	PoisonPolicyProceed
+)
+
+// OverloadPolicy determines how a latch acquisition attempt reacts to the
+// Manager tracking too many latches (see AcquireOptions.MaxLatches).
+type OverloadPolicy int
+
+const (
+	// OverloadPolicyWait waits for enough latches to be released before
+	// sequencing the attempt's latches.
+	OverloadPolicyWait OverloadPolicy = iota
+	// OverloadPolicyError fails the attempt with an *OverloadedError without
+	// sequencing its latches.
+	OverloadPolicyError
+)
+
+// Priority is the priority of a latch acquisition attempt. By default, latch
+// acquisitions are ordered strictly by arrival: an attempt waits on all of the
+// conflicting latches that were sequenced before it. A higher-priority attempt
+// instead skips ahead of the conflicting latches of lower-priority attempts
+// that are still waiting themselves. Those attempts then wait on the latches
+// of the higher-priority attempt before acquiring their own, so latches
+// continue to provide mutual exclusion. To avoid starving lower-priority
+// attempts, only the maxSkipAhead attempts sequenced right after an attempt
+// can skip ahead of it.
+//
+// Attempts can't wait on each other in a cycle. If normal-priority attempts
+// are ranked by their sequence number plus maxSkipAhead and high-priority
+// attempts by their sequence number, no attempt waits on a higher-ranked one,
+// and every wait of a high-priority attempt or on a normal-priority attempt is
+// on a strictly lower-ranked one. This relies on there being two priorities.
+type Priority int
+
+const (
+	// PriorityNormal is the priority of most latch acquisitions.
+	PriorityNormal Priority = iota
+	// PriorityHigh is the priority of latch acquisitions that are important
+	// to the health of the cluster (e.g. node liveness heartbeats and lease
+	// requests) and should not queue behind regular traffic.
+	PriorityHigh
+)
+
+// AcquireOptions configures a latch acquisition attempt. The zero value
+// results in the behavior of Acquire.
+type AcquireOptions struct {
+	// Timeout, if non-zero, bounds the time spent waiting on conflicting
+	// latches. See AcquireWithTimeout.
+	Timeout time.Duration
+	// PoisonPolicy determines how poisoned conflicting latches are handled.
+	PoisonPolicy PoisonPolicy
+	// Holder, if set, is an opaque annotation describing the owner of the
+	// latches (e.g. the request and its transaction). It is reported to the
+	// latch acquisition attempts that wait on the latches and by Latches,
+	// which allows for them to say who is blocking whom. It is only
+	// formatted when needed.
+	Holder fmt.Stringer
+	// SlowThreshold is the time spent waiting on a single conflicting latch
+	// after which the attempt is considered slow and is logged. Defaults to
+	// base.SlowRequestThreshold if zero.
+	SlowThreshold time.Duration
+	// Priority is the priority of the attempt. See Priority.
+	Priority Priority
+	// MaxLatches, if non-zero, is the number of latches tracked by the
+	// Manager, both acquired and waiting, beyond which the attempt is
+	// backpressured before its latches are sequenced, which keeps pathological
+	// workloads from growing the Manager's trees without bound. The attempt is
+	// always admitted into an empty Manager, even if it declares more latches
+	// than that. The limit isn't strict since concurrent attempts can be
+	// admitted at the same time, and the point reads held through the read
+	// fast path aren't counted against it (see fastpath.go).
+	MaxLatches int
+	// OverloadPolicy determines how the attempt is backpressured. With
+	// OverloadPolicyWait, Timeout also bounds the time spent waiting for
+	// latches to be released, after which an *OverloadedError is returned.
+	OverloadPolicy OverloadPolicy
+	// BoundedSnapshot, if set, makes the attempt capture a snapshot of only
+	// the latches that overlap with its spans instead of cloning the Manager's
+	// interval trees. This is more expensive up front, since the latches are
+	// copied while holding the Manager's lock, but avoids the copy-on-write
+	// cost that the snapshot otherwise imposes on the Manager while the
+	// attempt is waiting (see Metrics.SnapshotNodesCloned).
+	BoundedSnapshot bool
+	// CaptureStack, if set, records the stack of the goroutine acquiring the
+	// latches, which is reported by LongHeld to identify the code holding on
+	// to latches for too long. Capturing the stack is relatively expensive.
+	CaptureStack bool
+}
+
+// AcquireWithOptions is like Acquire, except that the latch acquisition
+// attempt is configured by the provided options.
+func (m *Manager) AcquireWithOptions(
+	ctx context.Context, spans *spanset.SpanSet, opts AcquireOptions,
+) (*Guard, error) {
+	if opts.MaxLatches > 0 {
+		if err := m.admit(ctx, spans.Len(), opts); err != nil {
+			return nil, err
+		}
+	} else if lg := m.acquireFastRead(spans, opts.Holder, opts.Priority); lg != nil {
+		if opts.CaptureStack {
+			lg.stack = captureStack()
+		}
+		return lg, nil
+	}
+	lg, snap := m.sequence(spans, opts)
 	defer snap.close()
+	if opts.CaptureStack {
+		lg.stack = captureStack()
+	}
+
+	err := m.wait(ctx, lg, snap, opts)
+	if err != nil {
+		m.Release(lg)
+		return nil, err
+	}
+	return lg, nil
+}
+
+// AcquireBatch is like AcquireWithOptions, except that it acquires latches for
+// each of the provided span sets, which are sequenced atomically and in order
+// under a single acquisition of the Manager's lock instead of paying for the
+// lock and a snapshot for each of them. The latches of each span set are owned
+// by a separate Guard, returned in the same order, which must be provided to
+// Release. None of the latches could be released before all of them are
+// acquired, so a span set can't wait on the latches of the span sets that
+// precede it: a batch in which span sets conflict with each other is rejected
+// with a *BatchConflictError. opts.Timeout bounds the time spent waiting for
+// the whole batch rather than for each of its span sets. If waiting for any of
+// the latches fails, all of the Guards are released and the error is returned.
+func (m *Manager) AcquireBatch(
+	ctx context.Context, spanSets []*spanset.SpanSet, opts AcquireOptions,
+) ([]*Guard, error) {
+	if err := checkBatchConflicts(spanSets); err != nil {
+		return nil, err
+	}
+	if opts.MaxLatches > 0 {
+		var n int
+		for _, spans := range spanSets {
+			n += spans.Len()
+		}
+		if err := m.admit(ctx, n, opts); err != nil {
+			return nil, err
+		}
+	}
+	lgs, snaps := m.sequenceBatch(spanSets, opts)
+	defer func() {
+		for i := range snaps {
+			snaps[i].close()
+		}
+	}()
+	if opts.CaptureStack {
+		stack := captureStack()
+		for _, lg := range lgs {
+			lg.stack = stack
+		}
+	}
+
+	ws := newWaitState(opts)
+	defer ws.stop()
+	for i, lg := range lgs {
+		if err := m.waitWithState(ctx, ws, lg, snaps[i]); err != nil {
+			for _, lg := range lgs {
+				m.Release(lg)
+			}
+			return nil, err
+		}
+	}
+	return lgs, nil
+}
+
+// checkBatchConflicts returns a *BatchConflictError if any of the provided span
+// sets conflicts with one of the span sets that precede it.
+func checkBatchConflicts(spanSets []*spanset.SpanSet) error {
+	for j := 1; j < len(spanSets); j++ {
+		for i := 0; i < j; i++ {
+			if sp, other, ok := spanSetsConflict(spanSets[j], spanSets[i]); ok {
+				return &BatchConflictError{
+					Index:         j,
+					Span:          sp,
+					ConflictIndex: i,
+					ConflictSpan:  other,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// spanSetsConflict returns a pair of conflicting spans of the provided span
+// sets, if any. Spans conflict if latches on them would have to wait on each
+// other, following the same rules as wait.
+func spanSetsConflict(a, b *spanset.SpanSet) (roachpb.Span, roachpb.Span, bool) {
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		for aa := spanset.SpanAccess(0); aa < spanset.NumSpanAccess; aa++ {
+			for ba := spanset.SpanAccess(0); ba < spanset.NumSpanAccess; ba++ {
+				for _, sp := range a.GetSpans(aa, s) {
+					for _, other := range b.GetSpans(ba, s) {
+						if accessesConflict(aa, sp.Timestamp, ba, other.Timestamp) &&
+							sp.Overlaps(other.Span) {
+							return sp.Span, other.Span, true
+						}
+					}
+				}
+			}
+		}
+	}
+	return roachpb.Span{}, roachpb.Span{}, false
+}
+
+// accessesConflict returns whether overlapping latches with the provided
+// accesses and timestamps conflict. Reads only conflict with writes and
+// exclusive reads at equal or lower timestamps, which in turn conflict with
+// everything else.
+func accessesConflict(
+	a spanset.SpanAccess, ts hlc.Timestamp, b spanset.SpanAccess, otherTS hlc.Timestamp,
+) bool {
+	switch {
+	case a == spanset.SpanReadOnly && b == spanset.SpanReadOnly:
+		return false
+	case a == spanset.SpanReadOnly:
+		return !ignoreLater(ts, otherTS)
+	case b == spanset.SpanReadOnly:
+		return !ignoreLater(otherTS, ts)
+	default:
+		return true
+	}
+}
+
+// admit waits until the Manager tracks few enough latches for n more latches
+// to be sequenced without exceeding opts.MaxLatches, as configured by the
+// provided options.
+func (m *Manager) admit(ctx context.Context, n int, opts AcquireOptions) error {
+	var timer *timeutil.Timer
+	var timeoutC <-chan time.Time
+	for backpressured := false; ; backpressured = true {
+		m.mu.Lock()
+		count := m.latchCountLocked()
+		if count == 0 || count+n <= opts.MaxLatches {
+			m.mu.Unlock()
+			return nil
+		}
+		if !backpressured {
+			if m.metrics != nil {
+				m.metrics.Backpressured.Inc(1)
+			}
+			if opts.OverloadPolicy == OverloadPolicyError {
+				m.mu.Unlock()
+				return &OverloadedError{Latches: count, MaxLatches: opts.MaxLatches}
+			}
+			if opts.Timeout != 0 {
+				timer = timeutil.NewTimer()
+				defer timer.Stop()
+				timer.Reset(opts.Timeout)
+				timeoutC = timer.C
+			}
+			log.Eventf(ctx, "waiting for latch manager tracking %d latches to drop below %d",
+				count, opts.MaxLatches)
+		}
+		if m.capacityC == nil {
+			m.capacityC = make(chan struct{})
+		}
+		capacityC := m.capacityC
+		m.mu.Unlock()
+
+		select {
+		case <-capacityC:
+		case <-timeoutC:
+			timer.Read = true
+			return &OverloadedError{Latches: count, MaxLatches: opts.MaxLatches}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.stopper.ShouldQuiesce():
+			return &roachpb.NodeUnavailableError{}
+		}
+	}
+}
+
+// latchCountLocked returns the number of latches tracked under the Manager's
+// mu, which excludes the latches still in the read shards of the read fast
+// path. Must be called with mu held.
+func (m *Manager) latchCountLocked() int {
+	var count int
+	for s := range m.scopes {
+		sm := &m.scopes[s]
+		count += sm.readSet.len + sm.trees[spanset.SpanReadOnly].Len() +
+			sm.trees[spanset.SpanReadWrite].Len()
+	}
+	return count
+}
+
+// signalCapacityLocked notifies the attempts waiting for latches to be removed
+// from the Manager, if any. Must be called with mu held.
+func (m *Manager) signalCapacityLocked() {
+	if m.capacityC != nil {
+		close(m.capacityC)
+		m.capacityC = nil
+	}
+}
+
+// AcquireOptimistic is like Acquire, except that it doesn't wait for latches
+// over overlapping spans to be released. Instead, it retains the snapshot of
+// the conflicting latches captured while sequencing the latches, which allows
+// for the caller to optimistically proceed while declaring wide spans (e.g. a
+// limited scan) and to only wait if the spans that it actually ended up
+// touching conflict with other latches, as determined by
+// CheckOptimisticNoConflicts. If that is the case, the caller must call
+// WaitUntilAcquired before relying on the latches.
+//
+// It returns a Guard which must be provided to Release.
+func (m *Manager) AcquireOptimistic(spans *spanset.SpanSet) *Guard {
+	lg, snap := m.sequence(spans, AcquireOptions{})
+	lg.snap = &snap
+	return lg
+}
+
+// CheckOptimisticNoConflicts returns whether the provided spans, which must be
+// a subset of the spans provided to AcquireOptimistic, don't conflict with any
+// of the latches in the snapshot captured by AcquireOptimistic. If it returns
+// true, the latches are effectively acquired and the caller doesn't need to
+// call WaitUntilAcquired. Otherwise, the caller typically calls
+// WaitUntilAcquired, although it can also call Release directly, in which case
+// it never held the latches. It also returns false if a higher-priority
+// attempt skipped ahead of the latches (see Priority).
+func (m *Manager) CheckOptimisticNoConflicts(lg *Guard, spans *spanset.SpanSet) bool {
+	if lg.snap == nil {
+		panic("CheckOptimisticNoConflicts called without AcquireOptimistic")
+	}
+	lg.bumps.Lock()
+	defer lg.bumps.Unlock()
+	if len(lg.bumps.by) > 0 {
+		return false
+	}
+	var search latch
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		tr := &lg.snap.trees[s]
+		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
+			for _, sp := range spans.GetSpans(a, s) {
+				search.span = sp.Span
+				search.ts = sp.Timestamp
+				switch a {
+				case spanset.SpanReadOnly:
+					// Search for writes at equal or lower timestamps.
+					it := tr[spanset.SpanReadWrite].MakeIter()
+					if overlaps(&it, &search, ignoreLater) {
+						return false
+					}
+				case spanset.SpanReadExclusive, spanset.SpanReadWrite:
+					// Search for all other writes.
+					it := tr[spanset.SpanReadWrite].MakeIter()
+					if overlaps(&it, &search, ignoreNothing) {
+						return false
+					}
+					// Search for reads at equal or higher timestamps.
+					it = tr[spanset.SpanReadOnly].MakeIter()
+					if overlaps(&it, &search, ignoreEarlier) {
+						return false
+					}
+				default:
+					panic("unknown access")
+				}
+			}
+		}
+	}
+	lg.bumps.closed = true
+	lg.markAcquired()
+	return true
+}
+
+// overlaps returns whether the provided iterator contains a latch that
+// overlaps with the search latch and that should not be ignored given its
+// timestamp and the supplied ignoreFn.
+func overlaps(it *iterator, search *latch, ignore ignoreFn) bool {
+	for it.FirstOverlap(search); it.Valid(); it.NextOverlap() {
+		// The latch may have been released already, but that doesn't allow
+		// for it to be ignored: it might have been held while the caller was
+		// optimistically proceeding, in which case the caller might not have
+		// observed the effects of the latch's owner.
+		if !ignore(search.ts, it.Cur().ts) {
+			return true
+		}
+	}
+	return false
+}
 
-	err := m.wait(ctx, lg, snap)
+// WaitUntilAcquired waits for the latches of a Guard returned by
+// AcquireOptimistic to be acquired, i.e. for all overlapping latches in the
+// snapshot captured by AcquireOptimistic to be released. It is meant to be
+// called when CheckOptimisticNoConflicts returned false or when the caller
+// needs to switch to pessimistic latching for some other reason. If the
+// provided context is canceled before the method is done waiting, it stops
+// waiting and releases all of the latches.
+func (m *Manager) WaitUntilAcquired(ctx context.Context, lg *Guard) (*Guard, error) {
+	if lg.snap == nil {
+		panic("WaitUntilAcquired called without AcquireOptimistic")
+	}
+	err := m.wait(ctx, lg, *lg.snap, AcquireOptions{})
 	if err != nil {
 		m.Release(lg)
 		return nil, err
@@ -212,54 +903,350 @@ func (m *Manager) Acquire(ctx context.Context, spans *spanset.SpanSet) (*Guard,
 	return lg, nil
 }
 
+// WaitFor waits for the latches that conflict with the provided spans to be
+// released, without acquiring latches of its own. Unlike with Acquire, latch
+// acquisitions that are sequenced after the call don't wait for it, so it is
+// only suitable for requests that need to observe a consistent point in the
+// history of the spans but not to exclude others from them afterwards (e.g.
+// non-transactional reads or QueryIntent). If the provided context is canceled
+// before the method is done waiting, it stops waiting and returns the error.
+func (m *Manager) WaitFor(ctx context.Context, spans *spanset.SpanSet) error {
+	// The Guard is only used to hold the latches to search for, which are not
+	// inserted into the Manager.
+	lg := newGuard(spans)
+
+	m.mu.Lock()
+	snap := m.snapshotLocked(spans, false /* bounded */)
+	m.mu.Unlock()
+	defer snap.close()
+
+	return m.wait(ctx, lg, snap, AcquireOptions{})
+}
+
+// Conflicts invokes the provided function on each latch currently tracked by
+// the Manager that conflicts with the provided spans, at the timestamps that
+// they were declared at, until the function returns false. Unlike WaitFor, it
+// doesn't wait for the latches to be released, which allows for the caller to
+// decide how to react to them instead (e.g. push their holder or reschedule
+// the request). Each conflicting latch is reported once, along with whether it
+// is acquired or its acquisition is still waiting itself. Like Latches, it
+// only locks the Manager to capture a snapshot of its state, so the latches
+// can be released concurrently.
+func (m *Manager) Conflicts(spans *spanset.SpanSet, fn func(LatchInfo) bool) {
+	m.mu.Lock()
+	snap := m.snapshotLocked(spans, false /* bounded */)
+	m.mu.Unlock()
+	defer snap.close()
+
+	now := timeutil.Now()
+	seen := make(map[*latch]struct{})
+	var search latch
+	// visit reports the latches in the provided tree that conflict with the
+	// search latch, which has the provided access. It returns false once the
+	// function asks for the iteration to stop.
+	visit := func(
+		s spanset.SpanScope, a, heldAccess spanset.SpanAccess, tr *btree, ignore ignoreFn,
+	) bool {
+		it := tr.MakeIter()
+		for it.FirstOverlap(&search); it.Valid(); it.NextOverlap() {
+			held := it.Cur()
+			if held.g.done.signaled() || held.releasedFor(a) || ignore(search.ts, held.ts) {
+				continue
+			}
+			if _, ok := seen[held]; ok {
+				continue
+			}
+			seen[held] = struct{}{}
+			if !fn(LatchInfo{
+				Span:      held.span,
+				Scope:     s,
+				Access:    heldAccess,
+				Timestamp: held.ts,
+				HolderID:  held.g.id,
+				Holder:    held.g.holder,
+				Waiting:   atomic.LoadInt32(&held.g.acquired) == 0,
+				Duration:  now.Sub(held.g.sequenced),
+			}) {
+				return false
+			}
+		}
+		return true
+	}
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		tr := &snap.trees[s]
+		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
+			for _, sp := range spans.GetSpans(a, s) {
+				search.span = sp.Span
+				search.ts = sp.Timestamp
+				switch a {
+				case spanset.SpanReadOnly:
+					// Writes at equal or lower timestamps conflict.
+					if !visit(s, a, spanset.SpanReadWrite, &tr[spanset.SpanReadWrite], ignoreLater) {
+						return
+					}
+				case spanset.SpanReadExclusive, spanset.SpanReadWrite:
+					// All other writes and reads at equal or higher
+					// timestamps conflict.
+					if !visit(s, a, spanset.SpanReadWrite, &tr[spanset.SpanReadWrite], ignoreNothing) ||
+						!visit(s, a, spanset.SpanReadOnly, &tr[spanset.SpanReadOnly], ignoreEarlier) {
+						return
+					}
+				default:
+					panic("unknown access")
+				}
+			}
+		}
+	}
+}
+
+// BumpReadTimestamp raises the timestamp of the MVCC read latches held by the
+// provided Guard to the provided timestamp, which allows for a read to be
+// retried at a higher timestamp without releasing its latches and losing its
+// place in the sequencing order. It waits for the conflicting writes between
+// the old and new timestamps to release their latches. If the provided context
+// is canceled before the method is done waiting, it stops waiting and returns
+// the error, in which case the Guard must still be released. The latches must
+// have been acquired.
+//
+// The latches aren't updated in place. Instead, read latches at the new
+// timestamp are added to the Guard, while the original latches are retained
+// until the Guard is released. The new latches only conflict with writes above
+// the timestamp of the original latches, since the writes below it were either
+// sequenced earlier and already waited on or are waiting on the original
+// latches. Of these writes, the new latches only wait on the ones that are
+// already acquired. The others, which might be waiting on the original
+// latches, are made to wait on the new latches instead, as if the new latches
+// had skipped ahead of them (see Priority).
+func (m *Manager) BumpReadTimestamp(ctx context.Context, lg *Guard, ts hlc.Timestamp) error {
+	if lg.parts != nil {
+		panic("BumpReadTimestamp called with a split or merged Guard")
+	}
+	if atomic.LoadInt32(&lg.acquired) == 0 {
+		panic("BumpReadTimestamp called before the latches were acquired")
+	}
+	type bumpedLatch struct {
+		ol    ownedLatch
+		oldTS hlc.Timestamp
+	}
+	var toBump []bumpedLatch
+	var spans spanset.SpanSet
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		latches := lg.latches(s, spanset.SpanReadOnly)
+		for i := range latches {
+			old := &latches[i]
+			oldTS := old.ts
+			if oldTS.Less(lg.bumpedTS) {
+				oldTS = lg.bumpedTS
+			}
+			if old.ts.IsEmpty() || !oldTS.Less(ts) {
+				// Non-MVCC reads conflict with all writes already.
+				continue
+			}
+			la := &latch{span: old.span, ts: ts, g: lg}
+			toBump = append(toBump, bumpedLatch{
+				ol:    ownedLatch{s: s, a: spanset.SpanReadOnly, la: la},
+				oldTS: oldTS,
+			})
+			spans.AddMVCC(spanset.SpanReadOnly, old.span, ts)
+		}
+	}
+	if len(toBump) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	// The Guard's bumped latches are removed along with its original latches,
+	// so they can't stay in the read fast path.
+	m.unshardLocked(lg)
+	snap := m.snapshotLocked(&spans, false /* bounded */)
+	for _, b := range toBump {
+		b.ol.la.id = m.nextID()
+		m.scopes[b.ol.s].addReadLocked(b.ol.la)
+		if m.metrics != nil {
+			m.metrics.latchesHeld(b.ol.s, b.ol.a).Inc(1)
+		}
+		lg.bumped = append(lg.bumped, b.ol)
+	}
+	lg.bumpedTS = ts
+	m.mu.Unlock()
+	defer snap.close()
+
+	ws := newWaitState(AcquireOptions{Holder: lg.holder})
+	defer ws.stop()
+	for _, b := range toBump {
+		wait := b.ol.la
+		it := snap.trees[b.ol.s][spanset.SpanReadWrite].MakeIter()
+		for it.FirstOverlap(wait); it.Valid(); it.NextOverlap() {
+			held := it.Cur()
+			if held.g == lg || held.g.done.signaled() || held.releasedFor(spanset.SpanReadOnly) {
+				continue
+			}
+			// Ignore the writes at or below the old timestamp (including the
+			// non-MVCC ones) and the ones above the new timestamp.
+			if !b.oldTS.Less(held.ts) || ignoreLater(wait.ts, held.ts) {
+				continue
+			}
+			if held.g.tryBump(bump{
+				held: wait, access: spanset.SpanReadOnly, wait: held, waitAccess: spanset.SpanReadWrite,
+			}) {
+				continue
+			}
+			if err := m.waitOn(
+				ctx, ws, spanset.SpanReadOnly, spanset.SpanReadWrite, wait, held,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // sequence locks the manager, captures an immutable snapshot, inserts latches
 // for each of the specified spans into the manager's interval trees, and
 // unlocks the manager. The role of the method is to sequence latch acquisition
-// attempts.
-func (m *Manager) sequence(spans *spanset.SpanSet) (*Guard, snapshot) {
-	lg := newGuard(spans)
+// attempts. The Holder, Priority and BoundedSnapshot options apply.
+func (m *Manager) sequence(spans *spanset.SpanSet, opts AcquireOptions) (*Guard, snapshot) {
+	lg := newSequencedGuard(spans, opts.Holder, opts.Priority, timeutil.Now())
 
 	m.mu.Lock()
-	snap := m.snapshotLocked(spans)
+	snap := m.snapshotLocked(spans, opts.BoundedSnapshot)
 	m.insertLocked(lg)
 	m.mu.Unlock()
 	return lg, snap
 }
 
+// sequenceBatch is like sequence, except that it sequences the latches of
+// each of the provided span sets under a single acquisition of the manager's
+// lock. All of the snapshots are captured before any of the latches are
+// inserted, so they don't include each other's latches.
+func (m *Manager) sequenceBatch(
+	spanSets []*spanset.SpanSet, opts AcquireOptions,
+) ([]*Guard, []snapshot) {
+	now := timeutil.Now()
+	lgs := make([]*Guard, len(spanSets))
+	for i, spans := range spanSets {
+		lgs[i] = newSequencedGuard(spans, opts.Holder, opts.Priority, now)
+	}
+	snaps := make([]snapshot, len(spanSets))
+
+	m.mu.Lock()
+	for i, spans := range spanSets {
+		snaps[i] = m.snapshotLocked(spans, opts.BoundedSnapshot)
+	}
+	for _, lg := range lgs {
+		m.insertLocked(lg)
+	}
+	m.mu.Unlock()
+	return lgs, snaps
+}
+
+// newSequencedGuard returns a Guard for the provided spans that is about to
+// be sequenced at the provided time.
+func newSequencedGuard(
+	spans *spanset.SpanSet, holder fmt.Stringer, priority Priority, now time.Time,
+) *Guard {
+	lg := newGuard(spans)
+	lg.sequenced = now
+	lg.holder = holder
+	lg.priority = priority
+	return lg
+}
+
 // snapshot is an immutable view into the latch manager's state.
 type snapshot struct {
-	trees [spanset.NumSpanScope][spanset.NumSpanAccess]btree
+	trees [spanset.NumSpanScope][numTreeAccess]btree
+	// metrics, if non-nil, records the cost of the snapshot when it is
+	// closed.
+	metrics *Metrics
 }
 
 // close closes the snapshot and releases any associated resources.
+//
+// Capturing a snapshot clones the Manager's interval trees in constant time,
+// but the nodes of the trees are then shared with the snapshot, so the
+// Manager's trees have to copy each node that they modify while the snapshot
+// is open. The nodes that are only referenced by the snapshot by the time that
+// it is closed are the ones that were copied (or removed) because of it, so
+// close records their number as the cost of the snapshot. If multiple
+// snapshots shared the nodes, the cost is attributed to the last one closed.
+// Bounded snapshots don't share any nodes, and their cost is the number of
+// nodes allocated for them instead.
 func (sn *snapshot) close() {
+	var cloned int
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
-		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
-			sn.trees[s][a].Reset()
+		for a := spanset.SpanAccess(0); a < numTreeAccess; a++ {
+			cloned += sn.trees[s][a].Reset()
 		}
 	}
+	if sn.metrics != nil {
+		sn.metrics.SnapshotNodesCloned.RecordValue(int64(cloned))
+		sn.metrics = nil
+	}
 }
 
 // snapshotLocked captures an immutable snapshot of the latch manager. It takes
-// a spanset to limit the amount of state captured.
-func (m *Manager) snapshotLocked(spans *spanset.SpanSet) snapshot {
-	var snap snapshot
+// a spanset to limit the amount of state captured. If bounded is set, the
+// snapshot only holds the latches that overlap with the spans, which are
+// copied into new trees, instead of cloning the Manager's trees (see
+// AcquireOptions.BoundedSnapshot).
+func (m *Manager) snapshotLocked(spans *spanset.SpanSet, bounded bool) snapshot {
+	snap := snapshot{metrics: m.metrics}
+	capture := func(s spanset.SpanScope, a spanset.SpanAccess) btree {
+		tr := &m.scopes[s].trees[a]
+		if bounded {
+			return boundedClone(tr, spans, s)
+		}
+		return tr.Clone()
+	}
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
 		sm := &m.scopes[s]
 		reading := len(spans.GetSpans(spanset.SpanReadOnly, s)) > 0
-		writing := len(spans.GetSpans(spanset.SpanReadWrite, s)) > 0
+		writing := len(spans.GetSpans(spanset.SpanReadWrite, s)) > 0 ||
+			len(spans.GetSpans(spanset.SpanReadExclusive, s)) > 0
 
 		if writing {
+			sm.closeFastPathLocked()
+			if sm.readSet.len > 0 && m.metrics != nil {
+				m.metrics.ReadSetFlushes.Inc(1)
+			}
 			sm.flushReadSetLocked()
-			snap.trees[s][spanset.SpanReadOnly] = sm.trees[spanset.SpanReadOnly].Clone()
+			snap.trees[s][spanset.SpanReadOnly] = capture(s, spanset.SpanReadOnly)
 		}
 		if writing || reading {
-			snap.trees[s][spanset.SpanReadWrite] = sm.trees[spanset.SpanReadWrite].Clone()
+			snap.trees[s][spanset.SpanReadWrite] = capture(s, spanset.SpanReadWrite)
 		}
 	}
 	return snap
 }
 
+// boundedClone returns a new tree holding the latches of the provided tree
+// that overlap with the provided spans in the provided scope.
+func boundedClone(tr *btree, spans *spanset.SpanSet, s spanset.SpanScope) btree {
+	var c btree
+	var search latch
+	it := tr.MakeIter()
+	for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
+		for _, sp := range spans.GetSpans(a, s) {
+			search.span = sp.Span
+			for it.FirstOverlap(&search); it.Valid(); it.NextOverlap() {
+				// Latches overlapping with multiple spans are only added
+				// once, since the tree replaces equal latches.
+				c.Set(it.Cur())
+			}
+		}
+	}
+	return c
+}
+
+// addReadLocked adds the provided read latch to the read set, which is flushed
+// into the read interval tree once it is full (see maxReadSetLen).
+func (sm *scopedManager) addReadLocked(la *latch) {
+	sm.readSet.pushBack(la)
+	if sm.readSet.len >= maxReadSetLen {
+		sm.flushReadSetLocked()
+	}
+}
+
 // flushReadSetLocked flushes the read set into the read interval tree.
 func (sm *scopedManager) flushReadSetLocked() {
 	for sm.readSet.len > 0 {
@@ -272,33 +1259,59 @@ func (sm *scopedManager) flushReadSetLocked() {
 // insertLocked inserts the latches owned by the provided Guard into the
 // Manager.
 func (m *Manager) insertLocked(lg *Guard) {
+	lg.id = m.nextID()
+	lg.seq = atomic.AddUint64(&m.seqAlloc, 1)
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
 		sm := &m.scopes[s]
 		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
 			latches := lg.latches(s, a)
+			if len(latches) > 0 && m.metrics != nil {
+				m.metrics.latchesHeld(s, a).Inc(int64(len(latches)))
+			}
 			for i := range latches {
 				latch := &latches[i]
-				latch.id = m.nextIDLocked()
+				latch.id = m.nextID()
 				switch a {
 				case spanset.SpanReadOnly:
 					// Add reads to the readSet. They only need to enter
 					// the read tree if they're flushed by a write capturing
-					// a snapshot.
-					sm.readSet.pushBack(latch)
-				case spanset.SpanReadWrite:
-					// Add writes directly to the write tree.
+					// a snapshot or once the readSet is full.
+					sm.addReadLocked(latch)
+				case spanset.SpanReadExclusive, spanset.SpanReadWrite:
+					// Add writes directly to the write tree, along with
+					// exclusive reads (see treeAccess).
 					sm.trees[spanset.SpanReadWrite].Set(latch)
 				default:
 					panic("unknown access")
 				}
 			}
 		}
+		// The fast path may have been closed by a snapshot that didn't
+		// insert any write latches, e.g. by WaitFor.
+		sm.maybeReopenFastPathLocked()
 	}
 }
 
-func (m *Manager) nextIDLocked() uint64 {
-	m.idAlloc++
-	return m.idAlloc
+// numTreeAccess is the number of interval trees of a scopedManager. There is
+// no tree for SpanReadExclusive, since exclusive reads are stored in the write
+// tree (see treeAccess).
+const numTreeAccess = spanset.SpanReadWrite + 1
+
+// treeAccess returns the access of the interval tree that latches with the
+// provided access are stored in. Exclusive reads conflict with the same latches
+// as non-MVCC writes, so they are stored in the write tree and are reported as
+// writes to the latch acquisitions that wait on them.
+func treeAccess(a spanset.SpanAccess) spanset.SpanAccess {
+	if a == spanset.SpanReadExclusive {
+		return spanset.SpanReadWrite
+	}
+	return a
+}
+
+// nextID allocates an ID. IDs are allocated atomically, since the read fast
+// path allocates them without holding mu.
+func (m *Manager) nextID() uint64 {
+	return atomic.AddUint64(&m.idAlloc, 1)
 }
 
 // ignoreFn is used for non-interference of earlier reads with later writes.
@@ -317,12 +1330,72 @@ func ignoreLater(ts, other hlc.Timestamp) bool   { return !ts.IsEmpty() && ts.Le
 func ignoreEarlier(ts, other hlc.Timestamp) bool { return !other.IsEmpty() && other.Less(ts) }
 func ignoreNothing(ts, other hlc.Timestamp) bool { return false }
 
+// waitState is the state of a latch acquisition attempt that is waiting on
+// the latches in its snapshot.
+type waitState struct {
+	// start is the time at which the attempt started waiting.
+	start time.Time
+	// slowTimer fires when the attempt has been waiting on a single latch for
+	// slowThreshold.
+	slowTimer     *timeutil.Timer
+	slowThreshold time.Duration
+	// timeoutTimer fires when the attempt has been waiting for longer than
+	// opts.Timeout. It is nil if the attempt has no timeout.
+	timeoutTimer *timeutil.Timer
+	opts         AcquireOptions
+	// waits is the number of latches waited on so far.
+	waits int64
+}
+
+// newWaitState returns the state of an attempt that starts waiting now, as
+// configured by the provided options. stop must be called once the attempt is
+// done waiting.
+func newWaitState(opts AcquireOptions) *waitState {
+	ws := &waitState{
+		start:         timeutil.Now(),
+		slowTimer:     timeutil.NewTimer(),
+		slowThreshold: opts.SlowThreshold,
+		opts:          opts,
+	}
+	if ws.slowThreshold == 0 {
+		ws.slowThreshold = base.SlowRequestThreshold
+	}
+	ws.slowTimer.Reset(ws.slowThreshold)
+	if opts.Timeout != 0 {
+		ws.timeoutTimer = timeutil.NewTimer()
+		ws.timeoutTimer.Reset(opts.Timeout)
+	}
+	return ws
+}
+
+// stop releases the resources associated with the waitState.
+func (ws *waitState) stop() {
+	ws.slowTimer.Stop()
+	if ws.timeoutTimer != nil {
+		ws.timeoutTimer.Stop()
+	}
+}
+
 // wait waits for all interfering latches in the provided snapshot to complete
-// before returning.
-func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
-	timer := timeutil.NewTimer()
-	timer.Reset(base.SlowRequestThreshold)
-	defer timer.Stop()
+// before returning, as configured by the provided options.
+func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot, opts AcquireOptions) error {
+	ws := newWaitState(opts)
+	defer ws.stop()
+	return m.waitWithState(ctx, ws, lg, snap)
+}
+
+// waitWithState is like wait, except that the attempt shares the provided
+// waitState, and hence its timeout, with the other attempts that use it.
+func (m *Manager) waitWithState(
+	ctx context.Context, ws *waitState, lg *Guard, snap snapshot,
+) error {
+	if m.metrics != nil {
+		waits := ws.waits
+		start := timeutil.Now()
+		defer func() {
+			m.metrics.recordAcquisition(ws.waits-waits, timeutil.Since(start))
+		}()
+	}
 
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
 		tr := &snap.trees[s]
@@ -334,23 +1407,31 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 				case spanset.SpanReadOnly:
 					// Wait for writes at equal or lower timestamps.
 					it := tr[spanset.SpanReadWrite].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreLater); err != nil {
+					if err := m.iterAndWait(
+						ctx, ws, &it, a, spanset.SpanReadWrite, latch, ignoreLater,
+					); err != nil {
 						return err
 					}
-				case spanset.SpanReadWrite:
-					// Wait for all other writes.
+				case spanset.SpanReadExclusive, spanset.SpanReadWrite:
+					// Wait for all other writes. Exclusive reads are
+					// non-MVCC, so they wait on all writes and reads just
+					// like non-MVCC writes.
 					//
 					// It is cheaper to wait on an already released latch than
 					// it is an unreleased latch so we prefer waiting on longer
 					// latches first. We expect writes to take longer than reads
 					// to release their latches, so we wait on them first.
 					it := tr[spanset.SpanReadWrite].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreNothing); err != nil {
+					if err := m.iterAndWait(
+						ctx, ws, &it, a, spanset.SpanReadWrite, latch, ignoreNothing,
+					); err != nil {
 						return err
 					}
 					// Wait for reads at equal or higher timestamps.
 					it = tr[spanset.SpanReadOnly].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreEarlier); err != nil {
+					if err := m.iterAndWait(
+						ctx, ws, &it, a, spanset.SpanReadOnly, latch, ignoreEarlier,
+					); err != nil {
 						return err
 					}
 				default:
@@ -359,49 +1440,174 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 			}
 		}
 	}
+	// Wait on the higher-priority attempts that skipped ahead of the latches
+	// while they were waiting, if any. Doing so may allow for others to skip
+	// ahead, so repeat until there are none left. This terminates because only
+	// a bounded number of attempts can skip ahead (see maxSkipAhead).
+	for by := lg.takeBumpsOrClose(); len(by) > 0; by = lg.takeBumpsOrClose() {
+		for _, b := range by {
+			if b.held.g.done.signaled() || b.held.releasedFor(b.waitAccess) {
+				continue
+			}
+			log.Eventf(ctx, "higher-priority %s latch %s skipped ahead of latch %s",
+				b.access, (*heldLatch)(b.held), b.wait)
+			if err := m.waitOn(ctx, ws, b.waitAccess, b.access, b.wait, b.held); err != nil {
+				return err
+			}
+		}
+	}
+	lg.markAcquired()
 	return nil
 }
 
 // iterAndWait uses the provided iterator to wait on all latches that overlap
 // with the search latch and which should not be ignored given their timestamp
-// and the supplied ignoreFn.
+// and the supplied ignoreFn. waitAccess is the access of the search latch and
+// heldAccess is the access of the latches in the iterator's tree. The latches
+// of lower-priority attempts that are still waiting are skipped (see
+// Priority). The time spent waiting on each latch is recorded in the trace of
+// the context.
 func (m *Manager) iterAndWait(
-	ctx context.Context, t *timeutil.Timer, it *iterator, wait *latch, ignore ignoreFn,
+	ctx context.Context,
+	ws *waitState,
+	it *iterator,
+	waitAccess, heldAccess spanset.SpanAccess,
+	wait *latch,
+	ignore ignoreFn,
 ) error {
 	for it.FirstOverlap(wait); it.Valid(); it.NextOverlap() {
 		held := it.Cur()
-		if held.done.signaled() {
+		if held.g.done.signaled() || held.releasedFor(waitAccess) {
 			continue
 		}
 		if ignore(wait.ts, held.ts) {
 			continue
 		}
-		if err := m.waitForSignal(ctx, t, wait, held); err != nil {
+		if held.g.priority < wait.g.priority && held.g.canSkipAhead(wait.g) && held.g.tryBump(bump{
+			held: wait, access: waitAccess, wait: held, waitAccess: heldAccess,
+		}) {
+			log.Eventf(ctx, "skipping ahead of lower-priority %s latch %s",
+				heldAccess, (*heldLatch)(held))
+			continue
+		}
+		if err := m.waitOn(ctx, ws, waitAccess, heldAccess, wait, held); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// waitForSignal waits for the latch that is currently held to be signaled.
-func (m *Manager) waitForSignal(ctx context.Context, t *timeutil.Timer, wait, held *latch) error {
+// waitOn waits on a single conflicting latch that is currently held, unless
+// it is poisoned and the attempt's PoisonPolicy allows for it to be ignored.
+func (m *Manager) waitOn(
+	ctx context.Context,
+	ws *waitState,
+	waitAccess, heldAccess spanset.SpanAccess,
+	wait, held *latch,
+) error {
+	if held.g.poison.signaled() && ws.opts.PoisonPolicy == PoisonPolicyProceed {
+		log.Eventf(ctx, "ignoring poisoned %s latch %s", heldAccess, (*heldLatch)(held))
+		return nil
+	}
+	ws.waits++
+	start := timeutil.Now()
+	if err := m.waitForSignal(ctx, ws, waitAccess, heldAccess, wait, held); err != nil {
+		return err
+	}
+	log.Eventf(ctx, "waited %s on %s latch %s",
+		timeutil.Since(start), heldAccess, (*heldLatch)(held))
+	return nil
+}
+
+// waitForSignal waits for the latch that is currently held to be signaled,
+// either by the release of its Guard or by the latch being released for the
+// waiting latch individually (see ReleaseSome and Downgrade).
+func (m *Manager) waitForSignal(
+	ctx context.Context,
+	ws *waitState,
+	waitAccess, heldAccess spanset.SpanAccess,
+	wait, held *latch,
+) error {
+	// Grab the channel before checking the state of the latch so that a
+	// concurrent change to it isn't missed.
+	changedC := held.g.changedChan()
+	if held.releasedFor(waitAccess) {
+		return nil
+	}
+	m.startWaiting(wait.g, latchWait{held: held, access: heldAccess, start: timeutil.Now()})
+	defer m.stopWaiting(wait.g)
+	var timeoutC <-chan time.Time
+	if ws.timeoutTimer != nil {
+		timeoutC = ws.timeoutTimer.C
+	}
+	var poisonC <-chan struct{}
+	if ws.opts.PoisonPolicy != PoisonPolicyWait {
+		poisonC = held.g.poison.signalChan()
+	}
 	for {
 		select {
-		case <-held.done.signalChan():
+		case <-held.g.done.signalChan():
 			return nil
-		case <-t.C:
-			t.Read = true
-			defer t.Reset(base.SlowRequestThreshold)
+		case <-changedC:
+			changedC = held.g.changedChan()
+			if held.releasedFor(waitAccess) {
+				return nil
+			}
+		case <-poisonC:
+			switch ws.opts.PoisonPolicy {
+			case PoisonPolicyError:
+				log.VEventf(ctx, 2, "latch %s held by %s was poisoned", wait, (*heldLatch)(held))
+				return &PoisonedError{
+					Span:              wait.span,
+					BlockingSpan:      held.span,
+					BlockingAccess:    heldAccess,
+					BlockingTimestamp: held.ts,
+					BlockingHolder:    held.g.holder,
+				}
+			case PoisonPolicyProceed:
+				log.Eventf(ctx, "proceeding past poisoned %s latch %s", heldAccess, (*heldLatch)(held))
+				return nil
+			default:
+				panic("unknown poison policy")
+			}
+		case <-ws.slowTimer.C:
+			ws.slowTimer.Read = true
+			defer ws.slowTimer.Reset(ws.slowThreshold)
 
-			log.Warningf(ctx, "have been waiting %s to acquire latch %s, held by %s",
-				base.SlowRequestThreshold, wait, held)
+			total := timeutil.Since(ws.start).Round(time.Millisecond)
+			log.Warningf(ctx, "have been waiting %s (%s in total) to acquire latch %s, "+
+				"blocked by %s latch %s", ws.slowThreshold, total, wait, heldAccess, (*heldLatch)(held))
+			if m.metrics != nil {
+				m.metrics.SlowWaits.Inc(1)
+			}
 			if m.slowReqs != nil {
 				m.slowReqs.Inc(1)
 				defer m.slowReqs.Dec(1)
 			}
+		case <-timeoutC:
+			ws.timeoutTimer.Read = true
+			log.VEventf(ctx, 2, "timed out after %s while acquiring latch %s, held by %s",
+				ws.opts.Timeout, wait, (*heldLatch)(held))
+			return &TimeoutError{
+				Timeout:           ws.opts.Timeout,
+				Span:              wait.span,
+				BlockingSpan:      held.span,
+				BlockingAccess:    heldAccess,
+				BlockingTimestamp: held.ts,
+				BlockingHolder:    held.g.holder,
+			}
 		case <-ctx.Done():
-			log.VEventf(ctx, 2, "%s while acquiring latch %s, held by %s", ctx.Err(), wait, held)
-			return ctx.Err()
+			log.VEventf(ctx, 2, "%s while acquiring latch %s, held by %s",
+				ctx.Err(), wait, (*heldLatch)(held))
+			return &CanceledError{
+				Err:               ctx.Err(),
+				Span:              wait.span,
+				Timestamp:         wait.ts,
+				BlockingSpan:      held.span,
+				BlockingAccess:    heldAccess,
+				BlockingTimestamp: held.ts,
+				BlockingHolder:    held.g.holder,
+			}
 		case <-m.stopper.ShouldQuiesce():
 			// While shutting down, requests may acquire
 			// latches and never release them.
@@ -410,17 +1616,230 @@ func (m *Manager) waitForSignal(ctx context.Context, t *timeutil.Timer, wait, he
 	}
 }
 
+// eachLatchLocked invokes the provided function on each latch owned by the
+// provided Guard, taking into account the Guards that were split from it or
+// merged into it. Must be called with mu held.
+func (m *Manager) eachLatchLocked(
+	lg *Guard, fn func(s spanset.SpanScope, a spanset.SpanAccess, la *latch),
+) {
+	if lg.parts != nil {
+		for _, g := range lg.parts.merged {
+			m.eachLatchLocked(g, fn)
+		}
+		if lg.parts.root != nil {
+			for _, ol := range lg.parts.owned {
+				fn(ol.s, ol.a, ol.la)
+			}
+			return
+		}
+	}
+	m.unshardLocked(lg)
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
+			latches := lg.latches(s, a)
+			for i := range latches {
+				if la := &latches[i]; !la.split {
+					fn(s, a, la)
+				}
+			}
+		}
+	}
+	for _, ol := range lg.bumped {
+		if !ol.la.split {
+			fn(ol.s, ol.a, ol.la)
+		}
+	}
+}
+
+// Split splits the latches held by the provided Guard over the provided spans,
+// which must have been declared with the same access and scope when the
+// latches were acquired, into a new Guard. The two Guards are then released
+// independently of each other. This allows for a command whose evaluation
+// forks into phases that finish at different times to release the latches of
+// each phase as soon as it is done. The latches must have been acquired.
+func (m *Manager) Split(lg *Guard, spans *spanset.SpanSet) *Guard {
+	root := lg
+	if lg.parts != nil {
+		if lg.parts.merged != nil {
+			panic("Split called with a merged Guard")
+		}
+		if lg.parts.root != nil {
+			root = lg.parts.root
+		}
+	}
+	if atomic.LoadInt32(&root.acquired) == 0 {
+		panic("Split called before the latches were acquired")
+	}
+	if root.parts == nil {
+		root.parts = &guardParts{}
+	}
+	sg := &Guard{parts: &guardParts{root: root}}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eachLatchLocked(lg, func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		if declares(spans.GetSpans(a, s), la) {
+			la.split = true
+			sg.parts.owned = append(sg.parts.owned, ownedLatch{s: s, a: a, la: la})
+		}
+	})
+	if lg != root {
+		// Transfer the latches from the Guard that was split to the new one.
+		owned := lg.parts.owned[:0]
+		for _, ol := range lg.parts.owned {
+			if !declares(spans.GetSpans(ol.a, ol.s), ol.la) {
+				owned = append(owned, ol)
+			}
+		}
+		lg.parts.owned = owned
+	}
+	root.parts.splits++
+	return sg
+}
+
+// Merge returns a Guard that holds the latches of all of the provided Guards,
+// which must no longer be used directly. Releasing it releases all of them.
+func (m *Manager) Merge(lgs ...*Guard) *Guard {
+	return &Guard{parts: &guardParts{merged: lgs}}
+}
+
+// Poison marks the latches held by the provided Guard as poisoned, which
+// indicates that their owner is failed or stalled and might not release them
+// in a timely manner. Latch acquisition attempts waiting on poisoned latches
+// react according to their PoisonPolicy. This allows for a command that is
+// stuck (e.g. in Raft) to not indefinitely block all requests that overlap
+// with it. Poison can be called multiple times, and also after the Guard has
+// been released, in which case it has no effect.
+//
+// Poisoning a Guard returned by Split poisons all of the latches of the Guard
+// that it was split from.
+func (m *Manager) Poison(lg *Guard) {
+	if lg.parts != nil && (lg.parts.merged != nil || lg.parts.root != nil) {
+		for _, g := range lg.parts.merged {
+			m.Poison(g)
+		}
+		if lg.parts.root != nil {
+			m.Poison(lg.parts.root)
+		}
+		return
+	}
+	lg.poison.signal()
+}
+
 // Release releases the latches held by the provided Guard. After being called,
 // dependent latch acquisition attempts can complete if not blocked on any other
 // owned latches.
 func (m *Manager) Release(lg *Guard) {
+	if lg.parts != nil {
+		for _, g := range lg.parts.merged {
+			m.Release(g)
+		}
+		if lg.parts.merged != nil {
+			return
+		}
+		if lg = m.releasePart(lg); lg == nil {
+			return
+		}
+	}
 	lg.done.signal()
+	if lg.snap != nil {
+		lg.snap.close()
+		lg.snap = nil
+	}
+	if m.releaseFastRead(lg) {
+		return
+	}
 
 	m.mu.Lock()
 	m.removeLocked(lg)
 	m.mu.Unlock()
 }
 
+// releasePart releases the latches owned by the provided Guard, which is
+// either a Guard returned by Split or a Guard that was split. It returns the
+// Guard that the latches belong to if it can now be released in full, or nil
+// otherwise.
+func (m *Manager) releasePart(lg *Guard) *Guard {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	root := lg
+	if lg.parts.root != nil {
+		root = lg.parts.root
+		root.parts.splits--
+	} else {
+		root.parts.released = true
+	}
+	if root.parts.splits == 0 && root.parts.released {
+		return root
+	}
+	m.eachLatchLocked(lg, func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		m.releaseLatchLocked(s, a, la)
+	})
+	return nil
+}
+
+// ReleaseSome releases the latches held by the provided Guard over the
+// provided spans, which must have been declared with the same access and
+// scope when the latches were acquired. Latch acquisition attempts waiting on
+// these latches no longer need to wait on them, while the Guard's other
+// latches remain held. This allows for long-running commands to drop the
+// latches that they no longer need before they complete. The Guard must still
+// be released by Release.
+func (m *Manager) ReleaseSome(lg *Guard, spans *spanset.SpanSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eachLatchLocked(lg, func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		if declares(spans.GetSpans(a, s), la) {
+			m.releaseLatchLocked(s, a, la)
+		}
+	})
+}
+
+// releaseLatchLocked releases the provided latch individually, unless it was
+// released already, and notifies the attempts waiting on it. Must be called
+// with mu held.
+func (m *Manager) releaseLatchLocked(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+	if la.loadState() == latchReleased {
+		return
+	}
+	m.scopes[s].removeLatchLocked(a, la)
+	if m.metrics != nil {
+		m.metrics.latchesHeld(s, a).Dec(1)
+	}
+	atomic.StoreInt32(&la.state, int32(latchReleased))
+	la.g.notifyChanged()
+	m.signalCapacityLocked()
+}
+
+// Downgrade downgrades the write latches held by the provided Guard over the
+// provided spans, which must have been declared as writes when the latches
+// were acquired, to read latches. Latch acquisition attempts for reads no
+// longer need to wait on these latches, while attempts for writes still do.
+// The latches continue to be reported as write latches by Info and Latches.
+func (m *Manager) Downgrade(lg *Guard, spans *spanset.SpanSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eachLatchLocked(lg, func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		if a != spanset.SpanReadWrite || !declares(spans.GetSpans(a, s), la) {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&la.state, int32(latchHeld), int32(latchDowngraded)) {
+			la.g.notifyChanged()
+		}
+	})
+}
+
+// declares returns whether the provided latch's span is one of the provided
+// spans.
+func declares(spans []spanset.Span, la *latch) bool {
+	for _, sp := range spans {
+		if sp.EqualValue(la.span) {
+			return true
+		}
+	}
+	return false
+}
+
 // removeLocked removes the latches owned by the provided Guard from the
 // Manager. Must be called with mu held.
 func (m *Manager) removeLocked(lg *Guard) {
@@ -428,22 +1847,49 @@ func (m *Manager) removeLocked(lg *Guard) {
 		sm := &m.scopes[s]
 		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
 			latches := lg.latches(s, a)
+			removed := 0
 			for i := range latches {
 				latch := &latches[i]
-				if latch.inReadSet() {
-					sm.readSet.remove(latch)
-				} else {
-					sm.trees[a].Delete(latch)
+				if latch.loadState() == latchReleased {
+					// Already removed by ReleaseSome.
+					continue
 				}
+				sm.removeLatchLocked(a, latch)
+				removed++
+			}
+			if removed > 0 && m.metrics != nil {
+				m.metrics.latchesHeld(s, a).Dec(int64(removed))
 			}
 		}
 	}
+	for _, ol := range lg.bumped {
+		if ol.la.loadState() == latchReleased {
+			continue
+		}
+		m.scopes[ol.s].removeLatchLocked(ol.a, ol.la)
+		if m.metrics != nil {
+			m.metrics.latchesHeld(ol.s, ol.a).Dec(1)
+		}
+	}
+	m.signalCapacityLocked()
+}
+
+// removeLatchLocked removes the provided latch, which has the provided access,
+// from the scopedManager. Must be called with the Manager's mu held.
+func (sm *scopedManager) removeLatchLocked(a spanset.SpanAccess, latch *latch) {
+	if latch.inReadSet() {
+		sm.readSet.remove(latch)
+	} else {
+		sm.trees[treeAccess(a)].Delete(latch)
+		sm.maybeReopenFastPathLocked()
+	}
 }
 
 // Info returns information about the state of the Manager.
 func (m *Manager) Info() (global, local storagepb.LatchManagerInfo) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.flushAllReadShardsLocked()
 	global = m.scopes[spanset.SpanGlobal].infoLocked()
 	local = m.scopes[spanset.SpanLocal].infoLocked()
 	return global, local
@@ -455,3 +1901,211 @@ func (sm *scopedManager) infoLocked() storagepb.LatchManagerInfo {
 	info.WriteCount = int64(sm.trees[spanset.SpanReadWrite].Len())
 	return info
 }
+
+// LatchInfo describes a latch tracked by a Manager.
+type LatchInfo struct {
+	Span      roachpb.Span
+	Scope     spanset.SpanScope
+	Access    spanset.SpanAccess
+	Timestamp hlc.Timestamp
+	// HolderID identifies the Guard that the latch belongs to. The latches
+	// with the same HolderID were acquired together.
+	HolderID uint64
+	// Holder is the annotation describing the owner of the latch provided
+	// in AcquireOptions. It can be nil.
+	Holder fmt.Stringer
+	// Waiting is true if the acquisition of the latch is still waiting on
+	// conflicting latches.
+	Waiting bool
+	// Duration is the time since the latch was inserted into the Manager,
+	// including the time spent waiting.
+	Duration time.Duration
+}
+
+// Latches returns a description of the latches currently tracked by the
+// Manager, both the acquired ones and the ones whose acquisition is still
+// waiting. The Manager is only locked to capture an immutable snapshot of its
+// state, which is then scanned without holding the lock, so latch
+// acquisitions aren't blocked for the duration of the scan.
+func (m *Manager) Latches() []LatchInfo {
+	now := timeutil.Now()
+	var infos []LatchInfo
+	m.scanLatches(func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		infos = append(infos, LatchInfo{
+			Span:      la.span,
+			Scope:     s,
+			Access:    a,
+			Timestamp: la.ts,
+			HolderID:  la.g.id,
+			Holder:    la.g.holder,
+			Waiting:   atomic.LoadInt32(&la.g.acquired) == 0,
+			Duration:  now.Sub(la.g.sequenced),
+		})
+	})
+	return infos
+}
+
+// LatchWait is an edge of the waits-for graph of a Manager: it describes a
+// latch acquisition attempt that is waiting on a conflicting latch.
+type LatchWait struct {
+	// WaiterID identifies the Guard of the waiting attempt, like
+	// LatchInfo.HolderID, and Waiter describes its owner. It can be nil.
+	WaiterID uint64
+	Waiter   fmt.Stringer
+	// BlockingSpan, BlockingAccess, and BlockingTimestamp describe the latch
+	// that is waited on, BlockingHolderID identifies its Guard, and
+	// BlockingHolder, if non-nil, describes its owner.
+	BlockingSpan      roachpb.Span
+	BlockingAccess    spanset.SpanAccess
+	BlockingTimestamp hlc.Timestamp
+	BlockingHolderID  uint64
+	BlockingHolder    fmt.Stringer
+	// Duration is the time spent waiting on the latch so far.
+	Duration time.Duration
+}
+
+// Waits returns the waits-for graph of the latches currently tracked by the
+// Manager, i.e. the conflicting latch that each latch acquisition attempt that
+// is still waiting is waiting on. Combined with the waits of transactions on
+// each other's locks, this allows for deadlocks that involve latch waits to be
+// detected. The waiting attempts maintain the graph as they start and stop
+// waiting, so unlike Latches, Waits doesn't lock the Manager or scan its
+// latches, which allows it to be called each time that a transaction's
+// dependents are polled.
+func (m *Manager) Waits() []LatchWait {
+	now := timeutil.Now()
+	m.waits.Lock()
+	defer m.waits.Unlock()
+	var waits []LatchWait
+	for lg, w := range m.waits.m {
+		waits = append(waits, LatchWait{
+			WaiterID:          lg.id,
+			Waiter:            lg.holder,
+			BlockingSpan:      w.held.span,
+			BlockingAccess:    w.access,
+			BlockingTimestamp: w.held.ts,
+			BlockingHolderID:  w.held.g.id,
+			BlockingHolder:    w.held.g.holder,
+			Duration:          now.Sub(w.start),
+		})
+	}
+	return waits
+}
+
+// HeldGuard describes a Guard whose latches have been held for a long time.
+type HeldGuard struct {
+	// Guard is the Guard, which can be poisoned.
+	Guard *Guard
+	// HolderID identifies the Guard, like LatchInfo.HolderID, and Holder
+	// describes its owner. It can be nil.
+	HolderID uint64
+	Holder   fmt.Stringer
+	// Held is the time since the latches were acquired.
+	Held time.Duration
+	// Stack is the stack of the goroutine that acquired the latches, if it
+	// was captured (see AcquireOptions.CaptureStack).
+	Stack string
+}
+
+// LongHeld returns the Guards whose latches have been held for at least the
+// provided duration, which usually indicates that their owner is stuck and is
+// making the keys that they cover unavailable. Each Guard is only returned by
+// the first call that finds it held for too long, so that callers can report
+// (or poison) it once. Like Latches, it only locks the Manager to capture a
+// snapshot of its state.
+func (m *Manager) LongHeld(threshold time.Duration) []HeldGuard {
+	now := timeutil.Now()
+	var held []HeldGuard
+	seen := make(map[*Guard]struct{})
+	m.scanLatches(func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		lg := la.g
+		if _, ok := seen[lg]; ok {
+			return
+		}
+		seen[lg] = struct{}{}
+		if atomic.LoadInt32(&lg.acquired) == 0 || now.Sub(lg.acquiredAt) < threshold {
+			return
+		}
+		if !atomic.CompareAndSwapInt32(&lg.reported, 0, 1) {
+			return
+		}
+		if m.metrics != nil {
+			m.metrics.HeldTooLong.Inc(1)
+		}
+		held = append(held, HeldGuard{
+			Guard:    lg,
+			HolderID: lg.id,
+			Holder:   lg.holder,
+			Held:     now.Sub(lg.acquiredAt),
+			Stack:    formatStack(lg.stack),
+		})
+	})
+	return held
+}
+
+// captureStack captures the stack of the calling goroutine, starting with the
+// caller of captureStack's caller.
+func captureStack() []uintptr {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return append([]uintptr(nil), pcs[:n]...)
+}
+
+// formatStack formats a stack captured by captureStack like a stack trace.
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var buf strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// scanLatches invokes the provided function on each latch tracked by the
+// Manager that hasn't been released. The Manager is only locked to capture an
+// immutable snapshot of its state, which is then scanned without holding the
+// lock.
+func (m *Manager) scanLatches(fn func(s spanset.SpanScope, a spanset.SpanAccess, la *latch)) {
+	var snap snapshot
+	var readSets [spanset.NumSpanScope][]*latch
+	m.mu.Lock()
+	m.flushAllReadShardsLocked()
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		sm := &m.scopes[s]
+		for a := spanset.SpanAccess(0); a < numTreeAccess; a++ {
+			snap.trees[s][a] = sm.trees[a].Clone()
+		}
+		readSets[s] = make([]*latch, 0, sm.readSet.len)
+		for i, la := 0, sm.readSet.front(); i < sm.readSet.len; i, la = i+1, la.next {
+			readSets[s] = append(readSets[s], la)
+		}
+	}
+	m.mu.Unlock()
+	defer snap.close()
+
+	add := func(s spanset.SpanScope, a spanset.SpanAccess, la *latch) {
+		if la.g.done.signaled() || la.loadState() == latchReleased {
+			// The latch has been released since the snapshot was captured.
+			return
+		}
+		fn(s, a, la)
+	}
+	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
+		for a := spanset.SpanAccess(0); a < numTreeAccess; a++ {
+			it := snap.trees[s][a].MakeIter()
+			for it.First(); it.Valid(); it.Next() {
+				add(s, a, it.Cur())
+			}
+		}
+		for _, la := range readSets[s] {
+			add(s, spanset.SpanReadOnly, la)
+		}
+	}
+}
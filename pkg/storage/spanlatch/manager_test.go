@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -25,6 +26,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,9 +114,9 @@ func (m *Manager) MustAcquireCh(spans *spanset.SpanSet) <-chan *Guard {
 // MustAcquireChCtx is like MustAcquireCh, except it accepts a context.
 func (m *Manager) MustAcquireChCtx(ctx context.Context, spans *spanset.SpanSet) <-chan *Guard {
 	ch := make(chan *Guard)
-	lg, snap := m.sequence(spans)
+	lg, snap := m.sequence(spans, AcquireOptions{})
 	go func() {
-		err := m.wait(ctx, lg, snap)
+		err := m.wait(ctx, lg, snap, AcquireOptions{})
 		if err != nil {
 			m.Release(lg)
 			lg = nil
@@ -509,6 +513,108 @@ func TestLatchManagerDependentLatches(t *testing.T) {
 	}
 }
 
+func TestLatchManagerAcquireBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+
+	lg1 := m.MustAcquire(spans("a", "", write, zeroTS))
+	lgsC := make(chan []*Guard)
+	go func() {
+		lgs, err := m.AcquireBatch(ctx, []*spanset.SpanSet{
+			spans("b", "", write, zeroTS),
+			spans("a", "b", read, zeroTS),
+			spans("c", "", read, zeroTS),
+		}, AcquireOptions{})
+		if err != nil {
+			panic(err)
+		}
+		lgsC <- lgs
+	}()
+
+	// The batch waits on the conflicting latches sequenced before it.
+	select {
+	case <-lgsC:
+		t.Fatal("batch acquisition should block")
+	case <-time.After(3 * time.Millisecond):
+	}
+	lg2C := m.MustAcquireCh(spans("a", "d", write, zeroTS))
+	testLatchBlocks(t, lg2C)
+	m.Release(lg1)
+	var lgs []*Guard
+	select {
+	case lgs = <-lgsC:
+	case <-time.After(testutils.DefaultSucceedsSoonDuration):
+		t.Fatal("batch acquisition should succeed")
+	}
+	require.Len(t, lgs, 3)
+	require.Len(t, m.Latches(), 4)
+
+	// The attempts sequenced after the batch wait on all of its latches.
+	for _, lg := range lgs {
+		testLatchBlocks(t, lg2C)
+		m.Release(lg)
+	}
+	m.Release(testLatchSucceeds(t, lg2C))
+
+	// If waiting fails, none of the latches are held.
+	lg3 := m.MustAcquire(spans("c", "", write, zeroTS))
+	_, err := m.AcquireBatch(ctx, []*spanset.SpanSet{
+		spans("b", "", write, zeroTS),
+		spans("c", "", write, zeroTS),
+	}, AcquireOptions{Timeout: time.Millisecond})
+	require.IsType(t, &TimeoutError{}, err)
+	m.Release(lg3)
+	require.Empty(t, m.Latches())
+
+	// Span sets that conflict with each other can't be acquired in a batch.
+	_, err = m.AcquireBatch(ctx, []*spanset.SpanSet{
+		spans("a", "c", read, zeroTS),
+		spans("d", "", write, zeroTS),
+		spans("b", "", write, zeroTS),
+	}, AcquireOptions{})
+	var conflictErr *BatchConflictError
+	require.True(t, errors.As(err, &conflictErr), err)
+	require.Equal(t, 2, conflictErr.Index)
+	require.Equal(t, 0, conflictErr.ConflictIndex)
+	require.Empty(t, m.Latches())
+
+	// Unless the reads are at lower timestamps than the writes.
+	lgs, err = m.AcquireBatch(ctx, []*spanset.SpanSet{
+		spans("a", "c", read, hlc.Timestamp{WallTime: 1}),
+		spans("b", "", write, hlc.Timestamp{WallTime: 2}),
+	}, AcquireOptions{})
+	require.NoError(t, err)
+	for _, lg := range lgs {
+		m.Release(lg)
+	}
+}
+
+func TestLatchManagerAcquireBatchTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+
+	// The timeout bounds the time spent waiting for the whole batch, so
+	// waiting on the latches of the second span set doesn't start a new
+	// timeout once the first span set is done waiting.
+	const timeout = 100 * time.Millisecond
+	lg1 := m.MustAcquire(spans("a", "", write, zeroTS))
+	lg2 := m.MustAcquire(spans("b", "", write, zeroTS))
+	time.AfterFunc(timeout*3/4, func() { m.Release(lg1) })
+	start := timeutil.Now()
+	_, err := m.AcquireBatch(ctx, []*spanset.SpanSet{
+		spans("a", "", write, zeroTS),
+		spans("b", "", write, zeroTS),
+	}, AcquireOptions{Timeout: timeout})
+	require.IsType(t, &TimeoutError{}, err)
+	if elapsed := timeutil.Since(start); elapsed >= timeout*7/4 {
+		t.Fatalf("batch acquisition timed out after %s, expected %s", elapsed, timeout)
+	}
+	m.Release(lg2)
+	require.Empty(t, m.Latches())
+}
+
 func TestLatchManagerContextCancellation(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	var m Manager
@@ -536,6 +642,1044 @@ func TestLatchManagerContextCancellation(t *testing.T) {
 	testLatchSucceeds(t, lg3C)
 }
 
+func TestLatchManagerContextCancellationError(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ts1, ts2 := hlc.Timestamp{WallTime: 1}, hlc.Timestamp{WallTime: 2}
+	holder := testHolder("Put txn=1234")
+	lg1, err := m.AcquireWithOptions(
+		context.Background(), spans("a", "c", write, ts1), AcquireOptions{Holder: holder},
+	)
+	require.NoError(t, err)
+
+	// The error returned when the context is canceled identifies the latch
+	// that the attempt was waiting on.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = m.Acquire(ctx, spans("b", "", read, ts2))
+	cErr, ok := err.(*CanceledError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("b")}, cErr.Span)
+	require.Equal(t, ts2, cErr.Timestamp)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")}, cErr.BlockingSpan)
+	require.Equal(t, spanset.SpanReadWrite, cErr.BlockingAccess)
+	require.Equal(t, ts1, cErr.BlockingTimestamp)
+	require.Equal(t, holder, cErr.BlockingHolder)
+	require.Contains(t, cErr.Error(), "held by Put txn=1234")
+	m.Release(lg1)
+}
+
+func TestLatchManagerAcquireWithTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+
+	// Without conflicting latches, the timeout doesn't matter.
+	lg1, err := m.AcquireWithTimeout(ctx, spans("a", "b", write, zeroTS), time.Nanosecond)
+	require.NoError(t, err)
+
+	// A conflicting acquisition times out and reports the blocking latch.
+	_, err = m.AcquireWithTimeout(ctx, spans("a", "", read, zeroTS), time.Millisecond)
+	require.Error(t, err)
+	tErr, ok := err.(*TimeoutError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, time.Millisecond, tErr.Timeout)
+	require.Equal(t, roachpb.Key("a"), tErr.Span.Key)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}, tErr.BlockingSpan)
+	require.Equal(t, spanset.SpanReadWrite, tErr.BlockingAccess)
+
+	// The latches of the attempt that timed out were released, so they don't
+	// block later acquisitions.
+	lg2C := m.MustAcquireCh(spans("a", "", write, zeroTS))
+	testLatchBlocks(t, lg2C)
+	m.Release(lg1)
+	m.Release(testLatchSucceeds(t, lg2C))
+}
+
+func TestLatchManagerPoison(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	// acquireCh sequences the latch acquisition synchronously and delivers
+	// its result on the returned channels once it is done waiting.
+	acquireCh := func(policy PoisonPolicy) (<-chan *Guard, <-chan error) {
+		lgC, errC := make(chan *Guard, 1), make(chan error, 1)
+		lg, snap := m.sequence(spans("a", "", write, zeroTS), AcquireOptions{})
+		go func() {
+			defer snap.close()
+			err := m.wait(context.Background(), lg, snap, AcquireOptions{PoisonPolicy: policy})
+			if err != nil {
+				m.Release(lg)
+				lg = nil
+			}
+			lgC <- lg
+			errC <- err
+		}()
+		return lgC, errC
+	}
+
+	lg1 := m.MustAcquire(spans("a", "b", write, zeroTS))
+	errLgC, errC := acquireCh(PoisonPolicyError)
+	waitLgC, _ := acquireCh(PoisonPolicyWait)
+	testLatchBlocks(t, errLgC)
+	testLatchBlocks(t, waitLgC)
+
+	// Poisoning the first Guard fails the acquisition with the error policy,
+	// while the one with the wait policy keeps waiting.
+	m.Poison(lg1)
+	m.Poison(lg1)
+	require.Nil(t, <-errLgC)
+	pErr, ok := (<-errC).(*PoisonedError)
+	require.True(t, ok)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}, pErr.BlockingSpan)
+	require.Equal(t, spanset.SpanReadWrite, pErr.BlockingAccess)
+	testLatchBlocks(t, waitLgC)
+
+	// The acquisition with the proceed policy ignores the poisoned latch but
+	// waits on the latch of the acquisition with the wait policy.
+	proceedLgC, proceedErrC := acquireCh(PoisonPolicyProceed)
+	testLatchBlocks(t, proceedLgC)
+	m.Release(lg1)
+	m.Poison(lg1)
+	lg2 := testLatchSucceeds(t, waitLgC)
+	testLatchBlocks(t, proceedLgC)
+
+	// Once that latch is poisoned too, it proceeds while the latch is held.
+	m.Poison(lg2)
+	lg3 := testLatchSucceeds(t, proceedLgC)
+	require.NoError(t, <-proceedErrC)
+	m.Release(lg2)
+	m.Release(lg3)
+}
+
+func TestLatchManagerWaitFor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	waitForCh := func(ss *spanset.SpanSet) <-chan error {
+		ch := make(chan error, 1)
+		go func() {
+			ch <- m.WaitFor(context.Background(), ss)
+		}()
+		return ch
+	}
+
+	// Without conflicting latches, WaitFor returns immediately.
+	require.NoError(t, m.WaitFor(context.Background(), spans("a", "b", write, zeroTS)))
+
+	oneTS, twoTS := hlc.Timestamp{WallTime: 1}, hlc.Timestamp{WallTime: 2}
+	lg1 := m.MustAcquire(spans("a", "", write, twoTS))
+
+	// A read below the write doesn't wait for it.
+	require.NoError(t, m.WaitFor(context.Background(), spans("a", "c", read, oneTS)))
+
+	// A read above the write waits for it.
+	errC := waitForCh(spans("a", "c", read, twoTS))
+	select {
+	case err := <-errC:
+		t.Fatalf("WaitFor should block, returned %v", err)
+	case <-time.After(3 * time.Millisecond):
+	}
+
+	// WaitFor doesn't insert latches, so later acquisitions don't wait on it.
+	require.Len(t, m.Latches(), 1)
+	lg2 := m.MustAcquire(spans("b", "", write, oneTS))
+	m.Release(lg2)
+
+	m.Release(lg1)
+	select {
+	case err := <-errC:
+		require.NoError(t, err)
+	case <-time.After(testutils.DefaultSucceedsSoonDuration):
+		t.Fatal("WaitFor should succeed")
+	}
+}
+
+func TestLatchManagerConflicts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ts1, ts2, ts3 := hlc.Timestamp{WallTime: 1}, hlc.Timestamp{WallTime: 2}, hlc.Timestamp{WallTime: 3}
+	conflicts := func(spans *spanset.SpanSet) []LatchInfo {
+		var infos []LatchInfo
+		m.Conflicts(spans, func(info LatchInfo) bool {
+			infos = append(infos, info)
+			return true
+		})
+		return infos
+	}
+
+	lg1 := m.MustAcquire(spans("a", "c", write, ts2))
+	lg2 := m.MustAcquire(spans("b", "", read, ts2))
+	lg3C := m.MustAcquireCh(spans("b", "", write, ts3))
+	testLatchBlocks(t, lg3C)
+
+	// Reads only conflict with writes at equal or lower timestamps.
+	require.Empty(t, conflicts(spans("b", "", read, ts1)))
+	infos := conflicts(spans("b", "", read, ts2))
+	require.Len(t, infos, 1)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")}, infos[0].Span)
+	require.Equal(t, spanset.SpanReadWrite, infos[0].Access)
+	require.False(t, infos[0].Waiting)
+	infos = conflicts(spans("b", "", read, ts3))
+	require.Len(t, infos, 2)
+	require.True(t, infos[1].Waiting)
+
+	// Writes conflict with all writes and with reads at equal or higher
+	// timestamps, and each latch is only reported once.
+	ss := spans("b", "", write, ts1)
+	add(ss, "a", "d", write, ts1)
+	require.Len(t, conflicts(ss), 3)
+	require.Len(t, conflicts(spans("b", "", write, ts3)), 2)
+	require.Empty(t, conflicts(spans("d", "", write, ts1)))
+
+	// The iteration stops once the function returns false.
+	var n int
+	m.Conflicts(ss, func(LatchInfo) bool {
+		n++
+		return false
+	})
+	require.Equal(t, 1, n)
+
+	// Released latches don't conflict.
+	m.Release(lg1)
+	m.Release(lg2)
+	lg3 := testLatchSucceeds(t, lg3C)
+	require.Len(t, conflicts(ss), 1)
+	m.Release(lg3)
+	require.Empty(t, conflicts(ss))
+}
+
+func TestLatchManagerOptimistic(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+	waitUntilAcquiredCh := func(lg *Guard) <-chan *Guard {
+		ch := make(chan *Guard)
+		go func() {
+			// A nil Guard is delivered on error.
+			lg, _ := m.WaitUntilAcquired(ctx, lg)
+			ch <- lg
+		}()
+		return ch
+	}
+
+	// Without conflicting latches, there are no conflicts.
+	lg1 := m.AcquireOptimistic(spans("d", "f", write, zeroTS))
+	require.True(t, m.CheckOptimisticNoConflicts(lg1, spans("d", "f", write, zeroTS)))
+	lg1, err := m.WaitUntilAcquired(ctx, lg1)
+	require.NoError(t, err)
+
+	// The optimistic acquisition only conflicts if the checked spans overlap
+	// with the conflicting latch.
+	lg2 := m.AcquireOptimistic(spans("a", "e", read, zeroTS))
+	require.False(t, m.CheckOptimisticNoConflicts(lg2, spans("a", "e", read, zeroTS)))
+	require.True(t, m.CheckOptimisticNoConflicts(lg2, spans("a", "d", read, zeroTS)))
+	lg2C := waitUntilAcquiredCh(lg2)
+	testLatchBlocks(t, lg2C)
+	m.Release(lg1)
+	lg2 = testLatchSucceeds(t, lg2C)
+	require.NotNil(t, lg2)
+
+	// A conflict is still detected after the conflicting latch is released.
+	lg3 := m.AcquireOptimistic(spans("a", "e", write, zeroTS))
+	require.False(t, m.CheckOptimisticNoConflicts(lg3, spans("a", "e", write, zeroTS)))
+	m.Release(lg2)
+	require.False(t, m.CheckOptimisticNoConflicts(lg3, spans("a", "e", write, zeroTS)))
+	lg3, err = m.WaitUntilAcquired(ctx, lg3)
+	require.NoError(t, err)
+	m.Release(lg3)
+
+	// Reads below a write don't conflict with it.
+	oneTS, twoTS := hlc.Timestamp{WallTime: 1}, hlc.Timestamp{WallTime: 2}
+	lg4 := m.MustAcquire(spans("c", "e", write, twoTS))
+	lg5 := m.AcquireOptimistic(spans("a", "e", read, oneTS))
+	require.True(t, m.CheckOptimisticNoConflicts(lg5, spans("a", "e", read, oneTS)))
+	require.True(t, m.CheckOptimisticNoConflicts(lg5, spans("a", "c", read, oneTS)))
+	lg5, err = m.WaitUntilAcquired(ctx, lg5)
+	require.NoError(t, err)
+	m.Release(lg5)
+
+	// The Guard of an optimistic acquisition can be released without waiting.
+	lg6 := m.AcquireOptimistic(spans("c", "", write, zeroTS))
+	require.False(t, m.CheckOptimisticNoConflicts(lg6, spans("c", "", write, zeroTS)))
+	m.Release(lg6)
+	m.Release(lg4)
+	require.Empty(t, m.Latches())
+}
+
+func TestLatchManagerWaitTracing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+
+	lg1 := m.MustAcquire(spans("a", "b", write, zeroTS))
+	ctx, getRec, cancel := tracing.ContextWithRecordingSpan(context.Background(), "test")
+	defer cancel()
+	lg2C := m.MustAcquireChCtx(ctx, spans("a", "", read, zeroTS))
+	testLatchBlocks(t, lg2C)
+	m.Release(lg1)
+	m.Release(testLatchSucceeds(t, lg2C))
+
+	// The wait is recorded in the trace of the acquisition that waited.
+	rec := getRec()
+	require.NotEqual(t, -1, tracing.FindMsgInRecording(rec, "on write latch"), "%s", rec)
+}
+
+func TestLatchManagerLatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	require.Empty(t, m.Latches())
+
+	ts := hlc.Timestamp{WallTime: 1}
+	lg1 := m.MustAcquire(spans("a", "b", write, ts))
+	lg2 := m.MustAcquire(spans("localC", "", read, zeroTS))
+	lg3C := m.MustAcquireCh(spans("a", "", write, zeroTS))
+	testLatchBlocks(t, lg3C)
+
+	infos := m.Latches()
+	require.Len(t, infos, 3)
+	byKey := make(map[string]LatchInfo)
+	for _, info := range infos {
+		byKey[info.Span.String()] = info
+	}
+	require.Len(t, byKey, 2)
+	local := byKey[roachpb.Span{Key: append(keys.LocalRangePrefix, "localC"...)}.String()]
+	require.Equal(t, spanset.SpanLocal, local.Scope)
+	require.Equal(t, spanset.SpanReadOnly, local.Access)
+	require.False(t, local.Waiting)
+
+	var held, waiting LatchInfo
+	for _, info := range infos {
+		if info.Scope != spanset.SpanGlobal {
+			continue
+		}
+		if info.Waiting {
+			waiting = info
+		} else {
+			held = info
+		}
+	}
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}, held.Span)
+	require.Equal(t, spanset.SpanReadWrite, held.Access)
+	require.Equal(t, ts, held.Timestamp)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a")}, waiting.Span)
+	require.True(t, waiting.Waiting)
+	require.NotEqual(t, held.HolderID, waiting.HolderID)
+	require.NotEqual(t, held.HolderID, local.HolderID)
+	require.True(t, held.Duration >= waiting.Duration)
+
+	m.Release(lg1)
+	m.Release(lg2)
+	lg3 := testLatchSucceeds(t, lg3C)
+	infos = m.Latches()
+	require.Len(t, infos, 1)
+	require.False(t, infos[0].Waiting)
+	m.Release(lg3)
+	require.Empty(t, m.Latches())
+}
+
+type testHolder string
+
+func (h testHolder) String() string { return string(h) }
+
+func TestLatchManagerHolder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx, getRec, cancel := tracing.ContextWithRecordingSpan(context.Background(), "test")
+	defer cancel()
+
+	holder := testHolder("Put txn=1234")
+	lg1, err := m.AcquireWithOptions(ctx, spans("a", "b", write, zeroTS), AcquireOptions{Holder: holder})
+	require.NoError(t, err)
+
+	// The holder is reported by introspection.
+	infos := m.Latches()
+	require.Len(t, infos, 1)
+	require.Equal(t, holder, infos[0].Holder)
+
+	// The holder is reported to the acquisitions that are blocked.
+	_, err = m.AcquireWithTimeout(ctx, spans("a", "", read, zeroTS), time.Millisecond)
+	tErr, ok := err.(*TimeoutError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, holder, tErr.BlockingHolder)
+	require.Contains(t, tErr.Error(), "held by Put txn=1234")
+
+	lg2C := m.MustAcquireChCtx(ctx, spans("a", "", read, zeroTS))
+	testLatchBlocks(t, lg2C)
+	m.Release(lg1)
+	lg2 := testLatchSucceeds(t, lg2C)
+	rec := getRec()
+	require.NotEqual(t, -1, tracing.FindMsgInRecording(rec, "held by Put txn=1234"), "%s", rec)
+
+	// Latches acquired without a holder don't report one.
+	infos = m.Latches()
+	require.Len(t, infos, 1)
+	require.Nil(t, infos[0].Holder)
+	m.Release(lg2)
+}
+
+func TestLatchManagerWaits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+	require.Empty(t, m.Waits())
+
+	h1, h2 := testHolder("Put txn=1"), testHolder("Put txn=2")
+	lg1, err := m.AcquireWithOptions(ctx, spans("a", "c", write, zeroTS), AcquireOptions{Holder: h1})
+	require.NoError(t, err)
+	lg2 := m.MustAcquire(spans("d", "", read, zeroTS))
+	require.Empty(t, m.Waits())
+
+	lg3C := make(chan *Guard)
+	go func() {
+		lg3, err := m.AcquireWithOptions(ctx, spans("b", "", write, zeroTS), AcquireOptions{Holder: h2})
+		if err != nil {
+			panic(err)
+		}
+		lg3C <- lg3
+	}()
+
+	// The waiting attempt is reported along with the latch that it waits on.
+	var waits []LatchWait
+	testutils.SucceedsSoon(t, func() error {
+		if waits = m.Waits(); len(waits) == 0 {
+			return errors.New("attempt not waiting yet")
+		}
+		return nil
+	})
+	require.Len(t, waits, 1)
+	w := waits[0]
+	require.Equal(t, h2, w.Waiter)
+	require.Equal(t, h1, w.BlockingHolder)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")}, w.BlockingSpan)
+	require.Equal(t, spanset.SpanReadWrite, w.BlockingAccess)
+	require.NotEqual(t, w.WaiterID, w.BlockingHolderID)
+	for _, info := range m.Latches() {
+		switch info.Holder {
+		case h1:
+			require.Equal(t, info.HolderID, w.BlockingHolderID)
+		case h2:
+			require.Equal(t, info.HolderID, w.WaiterID)
+		}
+	}
+
+	m.Release(lg1)
+	lg3 := <-lg3C
+	require.Empty(t, m.Waits())
+	m.Release(lg2)
+	m.Release(lg3)
+
+	// The waits-for graph is maintained by the waiting attempts, so reporting
+	// it doesn't flush the read fast path.
+	lg4 := m.MustAcquire(spans("a", "", read, zeroTS))
+	require.True(t, lg4.inShard)
+	require.Empty(t, m.Waits())
+	require.True(t, lg4.inShard)
+	m.Release(lg4)
+}
+
+func TestLatchManagerPriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	// acquireCh sequences the latch acquisition synchronously and delivers
+	// the Guard on the returned channel once it is done waiting.
+	acquireCh := func(ss *spanset.SpanSet, priority Priority) <-chan *Guard {
+		lgC := make(chan *Guard)
+		lg, snap := m.sequence(ss, AcquireOptions{Priority: priority})
+		go func() {
+			defer snap.close()
+			if err := m.wait(context.Background(), lg, snap, AcquireOptions{}); err != nil {
+				panic(err)
+			}
+			lgC <- lg
+		}()
+		return lgC
+	}
+
+	// High-priority attempts don't skip ahead of acquired latches.
+	ts1, ts2 := hlc.Timestamp{WallTime: 1}, hlc.Timestamp{WallTime: 2}
+	lg1 := m.MustAcquire(spans("a", "b", write, ts2))
+	_, err := m.AcquireWithOptions(context.Background(), spans("a", "", write, zeroTS),
+		AcquireOptions{Timeout: time.Millisecond, Priority: PriorityHigh})
+	require.IsType(t, &TimeoutError{}, err)
+	lg2C := acquireCh(spans("a", "", write, zeroTS), PriorityNormal)
+	testLatchBlocks(t, lg2C)
+
+	// A high-priority read below the timestamp of the first latch skips ahead
+	// of the waiting normal-priority write, which then waits on it.
+	lg3 := testLatchSucceeds(t, acquireCh(spans("a", "", read, ts1), PriorityHigh))
+	m.Release(lg1)
+	testLatchBlocks(t, lg2C)
+	m.Release(lg3)
+	lg2 := testLatchSucceeds(t, lg2C)
+
+	// Optimistic latches that were skipped ahead of are reported as
+	// conflicting, even if they don't conflict with their snapshot.
+	lg4C := acquireCh(spans("a", "", write, zeroTS), PriorityNormal)
+	testLatchBlocks(t, lg4C)
+	lg5 := m.AcquireOptimistic(spans("a", "c", read, zeroTS))
+	lg6 := testLatchSucceeds(t, acquireCh(spans("b", "", write, zeroTS), PriorityHigh))
+	require.False(t, m.CheckOptimisticNoConflicts(lg5, spans("b", "", read, zeroTS)))
+	m.Release(lg6)
+	m.Release(lg2)
+	m.Release(testLatchSucceeds(t, lg4C))
+	lg5, err = m.WaitUntilAcquired(context.Background(), lg5)
+	require.NoError(t, err)
+	m.Release(lg5)
+}
+
+func TestLatchManagerPriorityNoStarvation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+	lg1 := m.MustAcquire(spans("a", "", write, zeroTS))
+	lg2C := m.MustAcquireCh(spans("a", "z", write, zeroTS))
+	testLatchBlocks(t, lg2C)
+
+	// A stream of high-priority reads that don't conflict with the first
+	// latch skips ahead of the wide write, but only up to a point.
+	opts := AcquireOptions{Priority: PriorityHigh}
+	for i := 0; i < maxSkipAhead; i++ {
+		lg, err := m.AcquireWithOptions(ctx, spans("b", "", read, zeroTS), opts)
+		require.NoError(t, err)
+		m.Release(lg)
+	}
+	opts.Timeout = time.Millisecond
+	_, err := m.AcquireWithOptions(ctx, spans("b", "", read, zeroTS), opts)
+	require.IsType(t, &TimeoutError{}, err)
+
+	m.Release(lg1)
+	m.Release(testLatchSucceeds(t, lg2C))
+}
+
+func TestLatchManagerReleaseSome(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+
+	var ss spanset.SpanSet
+	add(&ss, "a", "", write, zeroTS)
+	add(&ss, "b", "", write, zeroTS)
+	lg1 := m.MustAcquire(&ss)
+	lg2C := m.MustAcquireCh(spans("a", "", write, zeroTS))
+	lg3C := m.MustAcquireCh(spans("b", "", read, zeroTS))
+	testLatchBlocks(t, lg2C)
+	testLatchBlocks(t, lg3C)
+
+	// Releasing some of the latches unblocks the attempts that were waiting
+	// on them, but not the ones waiting on the others.
+	m.ReleaseSome(lg1, spans("a", "", write, zeroTS))
+	lg2 := testLatchSucceeds(t, lg2C)
+	testLatchBlocks(t, lg3C)
+	require.Equal(t, int64(2), metrics.LatchesHeldGlobalWrite.Value())
+	require.Len(t, m.Latches(), 3)
+
+	// Releasing them again has no effect.
+	m.ReleaseSome(lg1, spans("a", "", write, zeroTS))
+	require.Equal(t, int64(2), metrics.LatchesHeldGlobalWrite.Value())
+
+	m.Release(lg1)
+	lg3 := testLatchSucceeds(t, lg3C)
+	m.Release(lg2)
+	m.Release(lg3)
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalWrite.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalRead.Value())
+}
+
+func TestLatchManagerDowngrade(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+
+	lg1 := m.MustAcquire(spans("a", "c", write, zeroTS))
+	lg2C := m.MustAcquireCh(spans("b", "", read, zeroTS))
+	testLatchBlocks(t, lg2C)
+
+	// Downgrading the write latch unblocks the waiting read, and later reads
+	// don't wait on it.
+	m.Downgrade(lg1, spans("a", "c", write, zeroTS))
+	m.Release(testLatchSucceeds(t, lg2C))
+	m.Release(m.MustAcquire(spans("a", "", read, zeroTS)))
+
+	// Writes still wait on it.
+	_, err := m.AcquireWithTimeout(ctx, spans("a", "", write, zeroTS), time.Millisecond)
+	tErr, ok := err.(*TimeoutError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, roachpb.Span{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")}, tErr.BlockingSpan)
+
+	m.Release(lg1)
+}
+
+func TestLatchManagerSplitMerge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+
+	var ss spanset.SpanSet
+	add(&ss, "a", "", write, zeroTS)
+	add(&ss, "b", "", write, zeroTS)
+	add(&ss, "c", "", write, zeroTS)
+
+	// Each part of a Guard that was split can be released independently.
+	for _, releaseSplitFirst := range []bool{true, false} {
+		lg1 := m.MustAcquire(&ss)
+		lg2 := m.Split(lg1, spans("b", "", write, zeroTS))
+		lg3 := m.Split(lg2, spans("b", "", write, zeroTS))
+		lg4C := m.MustAcquireCh(spans("a", "", read, zeroTS))
+		lg5C := m.MustAcquireCh(spans("b", "", read, zeroTS))
+		testLatchBlocks(t, lg4C)
+		testLatchBlocks(t, lg5C)
+
+		m.Release(lg2) // owns no latches after the second split
+		testLatchBlocks(t, lg4C)
+		testLatchBlocks(t, lg5C)
+		if releaseSplitFirst {
+			m.Release(lg3)
+			lg5 := testLatchSucceeds(t, lg5C)
+			testLatchBlocks(t, lg4C)
+			m.Release(lg1)
+			m.Release(testLatchSucceeds(t, lg4C))
+			m.Release(lg5)
+		} else {
+			m.Release(lg1)
+			lg4 := testLatchSucceeds(t, lg4C)
+			testLatchBlocks(t, lg5C)
+			m.Release(lg3)
+			m.Release(testLatchSucceeds(t, lg5C))
+			m.Release(lg4)
+		}
+		require.Equal(t, int64(0), metrics.LatchesHeldGlobalWrite.Value())
+		require.Equal(t, int64(0), metrics.LatchesHeldGlobalRead.Value())
+	}
+
+	// Releasing a merged Guard releases all of its Guards.
+	lg1 := m.MustAcquire(spans("a", "", write, zeroTS))
+	lg2 := m.MustAcquire(spans("b", "", write, zeroTS))
+	lg3 := m.Merge(lg1, lg2)
+	lg4C := m.MustAcquireCh(spans("a", "c", read, zeroTS))
+	testLatchBlocks(t, lg4C)
+	m.ReleaseSome(lg3, spans("a", "", write, zeroTS))
+	testLatchBlocks(t, lg4C)
+	m.Release(lg3)
+	m.Release(testLatchSucceeds(t, lg4C))
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalWrite.Value())
+}
+
+func TestLatchManagerExclusiveRead(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+	exclusive := func(key string) *spanset.SpanSet {
+		var ss spanset.SpanSet
+		ss.AddMVCC(spanset.SpanReadExclusive, roachpb.Span{Key: roachpb.Key(key)}, hlc.Timestamp{WallTime: 2})
+		return &ss
+	}
+
+	// Exclusive reads conflict with reads and writes at any timestamp, as
+	// well as with each other.
+	lg1 := m.MustAcquire(exclusive("a"))
+	for _, ss := range []*spanset.SpanSet{
+		spans("a", "", read, hlc.Timestamp{WallTime: 1}),
+		spans("a", "", read, hlc.Timestamp{WallTime: 3}),
+		spans("a", "", write, hlc.Timestamp{WallTime: 3}),
+		exclusive("a"),
+	} {
+		_, err := m.AcquireWithTimeout(ctx, ss, time.Millisecond)
+		require.IsType(t, &TimeoutError{}, err)
+	}
+	m.Release(lg1)
+
+	// Exclusive reads wait on reads and writes.
+	lg2 := m.MustAcquire(spans("a", "", read, hlc.Timestamp{WallTime: 3}))
+	lg3C := m.MustAcquireCh(exclusive("a"))
+	testLatchBlocks(t, lg3C)
+	m.Release(lg2)
+	m.Release(testLatchSucceeds(t, lg3C))
+}
+
+func TestLatchManagerBumpReadTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+	ts := func(w int64) hlc.Timestamp { return hlc.Timestamp{WallTime: w} }
+	bump := func(lg *Guard, ts hlc.Timestamp) <-chan error {
+		errC := make(chan error, 1)
+		go func() { errC <- m.BumpReadTimestamp(ctx, lg, ts) }()
+		return errC
+	}
+
+	// A write above the read's timestamp doesn't wait on it, but bumping the
+	// read's timestamp past the write's waits on the write.
+	lg1 := m.MustAcquire(spans("a", "", read, ts(1)))
+	lg2 := m.MustAcquire(spans("a", "", write, ts(2)))
+	errC := bump(lg1, ts(3))
+	select {
+	case err := <-errC:
+		t.Fatalf("bump unexpectedly returned: %v", err)
+	case <-time.After(3 * time.Millisecond):
+	}
+	m.Release(lg2)
+	require.NoError(t, <-errC)
+
+	// The bumped read conflicts with later writes at or below its new
+	// timestamp, but not with the ones above it.
+	_, err := m.AcquireWithTimeout(ctx, spans("a", "", write, ts(3)), time.Millisecond)
+	require.IsType(t, &TimeoutError{}, err)
+	m.Release(m.MustAcquire(spans("a", "", write, ts(4))))
+	require.Len(t, m.Latches(), 2)
+	m.Release(lg1)
+	require.Len(t, m.Latches(), 0)
+
+	// A conflicting write that is still waiting doesn't block the bump, but
+	// waits on the bumped read instead.
+	lg3 := m.MustAcquire(spans("b", "", write, zeroTS))
+	lg4 := m.MustAcquire(spans("a", "", read, ts(1)))
+	lg5C := m.MustAcquireCh(spans("a", "c", write, ts(3)))
+	testLatchBlocks(t, lg5C)
+	require.NoError(t, m.BumpReadTimestamp(ctx, lg4, ts(5)))
+	m.Release(lg3)
+	testLatchBlocks(t, lg5C)
+	m.Release(lg4)
+	m.Release(testLatchSucceeds(t, lg5C))
+}
+
+func TestLatchManagerMaxLatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+	ctx := context.Background()
+	acquireCh := func(spans *spanset.SpanSet) <-chan *Guard {
+		ch := make(chan *Guard)
+		go func() {
+			lg, err := m.AcquireWithOptions(ctx, spans, AcquireOptions{MaxLatches: 2})
+			if err != nil {
+				panic(err)
+			}
+			ch <- lg
+		}()
+		return ch
+	}
+
+	// An attempt is admitted into an empty Manager even if it declares more
+	// latches than the limit.
+	ss := spans("a", "", read, zeroTS)
+	add(ss, "b", "", read, zeroTS)
+	add(ss, "c", "", read, zeroTS)
+	lg1 := testLatchSucceeds(t, acquireCh(ss))
+
+	// Non-conflicting attempts are backpressured until enough latches are
+	// released.
+	lg2C := acquireCh(spans("d", "", write, zeroTS))
+	testLatchBlocks(t, lg2C)
+	_, err := m.AcquireWithOptions(ctx, spans("e", "", write, zeroTS), AcquireOptions{
+		MaxLatches: 2, OverloadPolicy: OverloadPolicyError,
+	})
+	require.Equal(t, &OverloadedError{Latches: 3, MaxLatches: 2}, err)
+	_, err = m.AcquireWithOptions(ctx, spans("e", "", write, zeroTS), AcquireOptions{
+		MaxLatches: 2, Timeout: time.Millisecond,
+	})
+	require.IsType(t, &OverloadedError{}, err)
+	require.Equal(t, int64(3), metrics.Backpressured.Count())
+
+	// Releasing some of the latches makes room for the waiting attempt.
+	m.ReleaseSome(lg1, spans("a", "", read, zeroTS))
+	testLatchBlocks(t, lg2C)
+	m.ReleaseSome(lg1, spans("b", "", read, zeroTS))
+	lg2 := testLatchSucceeds(t, lg2C)
+	m.Release(lg1)
+	m.Release(lg2)
+
+	// Attempts without a limit are never backpressured.
+	lg3 := m.MustAcquire(ss)
+	m.Release(m.MustAcquire(spans("d", "", write, zeroTS)))
+	m.Release(lg3)
+
+	// Point reads held through the read fast path don't count against the
+	// limit, since their release doesn't notify the backpressured attempts.
+	lg4 := m.MustAcquire(spans("a", "", read, zeroTS))
+	require.True(t, lg4.inShard)
+	lg5, err := m.AcquireWithOptions(ctx, spans("localA", "", write, zeroTS), AcquireOptions{
+		MaxLatches: 1, OverloadPolicy: OverloadPolicyError,
+	})
+	require.NoError(t, err)
+	require.True(t, lg4.inShard)
+	require.Equal(t, int64(3), metrics.Backpressured.Count())
+	m.Release(lg4)
+	m.Release(lg5)
+}
+
+func TestLatchManagerReadSetFlush(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+	sm := &m.scopes[spanset.SpanGlobal]
+
+	// Reads are added to the read set until it is full, at which point they
+	// are flushed into the read tree without waiting for a write to do so.
+	// The reads are over ranges so as not to go through the read fast path.
+	var lgs []*Guard
+	for i := 0; i < maxReadSetLen-1; i++ {
+		lgs = append(lgs, m.MustAcquire(spans("a", "b", read, zeroTS)))
+	}
+	require.Equal(t, maxReadSetLen-1, sm.readSet.len)
+	require.Equal(t, 0, sm.trees[spanset.SpanReadOnly].Len())
+	lgs = append(lgs, m.MustAcquire(spans("a", "b", read, zeroTS)))
+	require.Equal(t, 0, sm.readSet.len)
+	require.Equal(t, maxReadSetLen, sm.trees[spanset.SpanReadOnly].Len())
+	lgs = append(lgs, m.MustAcquire(spans("a", "b", read, zeroTS)))
+	require.Equal(t, 1, sm.readSet.len)
+
+	// Flushed reads still conflict with writes.
+	lgC := m.MustAcquireCh(spans("a", "", write, zeroTS))
+	testLatchBlocks(t, lgC)
+	for _, lg := range lgs {
+		m.Release(lg)
+	}
+	m.Release(testLatchSucceeds(t, lgC))
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalRead.Value())
+}
+
+func TestLatchManagerLongHeld(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+
+	lg1, err := m.AcquireWithOptions(context.Background(), spans("a", "", write, zeroTS),
+		AcquireOptions{CaptureStack: true})
+	require.NoError(t, err)
+	lg2C := m.MustAcquireCh(spans("a", "", write, zeroTS))
+	testLatchBlocks(t, lg2C)
+
+	// Guards aren't reported before they have been held for the threshold.
+	require.Empty(t, m.LongHeld(time.Hour))
+
+	// Held Guards are reported once, along with the stack that acquired them.
+	// Guards that are still waiting on their latches aren't reported.
+	held := m.LongHeld(0)
+	require.Len(t, held, 1)
+	require.Equal(t, lg1, held[0].Guard)
+	require.Contains(t, held[0].Stack, "TestLatchManagerLongHeld")
+	require.Empty(t, m.LongHeld(0))
+	require.Equal(t, int64(1), metrics.HeldTooLong.Count())
+
+	// Guards acquired without capturing their stack are reported without one.
+	m.Release(lg1)
+	lg2 := testLatchSucceeds(t, lg2C)
+	held = m.LongHeld(0)
+	require.Len(t, held, 1)
+	require.Equal(t, lg2, held[0].Guard)
+	require.Empty(t, held[0].Stack)
+	m.Release(lg2)
+	require.Empty(t, m.LongHeld(0))
+}
+
+func TestLatchManagerReadFastPath(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+
+	// Point reads go through the fast path while there are no writes.
+	lg1 := m.MustAcquire(spans("a", "", read, zeroTS))
+	require.True(t, lg1.inShard)
+	lg2 := m.MustAcquire(spans("localA", "", read, zeroTS))
+	require.True(t, lg2.inShard)
+	require.Equal(t, int64(2), metrics.FastPathAcquisitions.Count())
+	require.Equal(t, int64(2), metrics.Acquisitions.Count())
+
+	// A fast path latch that is still in its shard is released without
+	// locking the Manager.
+	m.Release(lg2)
+	require.Equal(t, int64(0), metrics.LatchesHeldLocalRead.Value())
+
+	// A write flushes the fast path reads of its scope and closes the fast
+	// path, after which point reads are sequenced by the Manager again and
+	// wait on conflicting writes.
+	lg3 := m.MustAcquire(spans("b", "", write, zeroTS))
+	require.False(t, lg1.inShard)
+	lg4 := m.MustAcquire(spans("c", "", read, zeroTS))
+	require.False(t, lg4.inShard)
+	require.Equal(t, int64(2), metrics.FastPathAcquisitions.Count())
+	lg5C := m.MustAcquireCh(spans("b", "", read, zeroTS))
+	testLatchBlocks(t, lg5C)
+
+	// Writes wait on the flushed fast path reads.
+	lg6C := m.MustAcquireCh(spans("a", "", write, zeroTS))
+	testLatchBlocks(t, lg6C)
+	m.Release(lg1)
+	lg6 := testLatchSucceeds(t, lg6C)
+	m.Release(lg3)
+	m.Release(testLatchSucceeds(t, lg5C))
+	m.Release(lg4)
+	m.Release(lg6)
+
+	// The fast path reopens once there are no writes left. Fast path latches
+	// are flushed before being inspected.
+	lg7 := m.MustAcquire(spans("a", "", read, zeroTS))
+	require.True(t, lg7.inShard)
+	require.Equal(t, int64(3), metrics.FastPathAcquisitions.Count())
+	require.Len(t, m.Latches(), 1)
+	require.False(t, lg7.inShard)
+	m.Release(lg7)
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalRead.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalWrite.Value())
+}
+
+func TestLatchManagerReadFastPathHighPriorityWrites(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+	ctx := context.Background()
+
+	// Point reads acquired through the fast path are acquired as soon as they
+	// are in their read shard, so the high-priority writes that flush them
+	// must never skip ahead of them. Readers and writers check that they
+	// never hold their latches at the same time.
+	const readers, writers, iters = 4, 2, 500
+	var reading, writing int32
+	errC := make(chan error, readers+writers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			for j := 0; j < iters; j++ {
+				lg := m.MustAcquire(spans("a", "", read, zeroTS))
+				atomic.AddInt32(&reading, 1)
+				conflict := atomic.LoadInt32(&writing) != 0
+				atomic.AddInt32(&reading, -1)
+				m.Release(lg)
+				if conflict {
+					errC <- errors.New("read latch acquired while a write latch is held")
+					return
+				}
+			}
+			errC <- nil
+		}()
+	}
+	for i := 0; i < writers; i++ {
+		go func() {
+			for j := 0; j < iters; j++ {
+				lg, err := m.AcquireWithOptions(ctx, spans("a", "", write, zeroTS),
+					AcquireOptions{Priority: PriorityHigh})
+				if err != nil {
+					errC <- err
+					return
+				}
+				atomic.AddInt32(&writing, 1)
+				conflict := atomic.LoadInt32(&reading) != 0
+				atomic.AddInt32(&writing, -1)
+				m.Release(lg)
+				if conflict {
+					errC <- errors.New("write latch acquired while a read latch is held")
+					return
+				}
+			}
+			errC <- nil
+		}()
+	}
+	for i := 0; i < readers+writers; i++ {
+		require.NoError(t, <-errC)
+	}
+}
+
+func TestLatchManagerSnapshotCost(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+	lg1 := m.MustAcquire(spans("a", "", write, zeroTS))
+
+	// A snapshot shares the nodes of the Manager's trees, so the Manager has
+	// to copy the nodes that it modifies while the snapshot is open. The
+	// acquisitions also record the cost of their own snapshots, which don't
+	// cost anything here.
+	m.mu.Lock()
+	snap := m.snapshotLocked(spans("a", "", write, zeroTS), false /* bounded */)
+	m.mu.Unlock()
+	lg2 := m.MustAcquire(spans("b", "", write, zeroTS))
+	require.Equal(t, int64(0), metrics.SnapshotNodesCloned.Snapshot().Max())
+	snap.close()
+	require.Equal(t, int64(3), metrics.SnapshotNodesCloned.TotalCount())
+	require.Equal(t, int64(1), metrics.SnapshotNodesCloned.Snapshot().Max())
+
+	// Bounded snapshots only hold the latches that overlap with the spans,
+	// which are still waited on.
+	lg3, snap := m.sequence(spans("a", "", write, zeroTS), AcquireOptions{BoundedSnapshot: true})
+	require.Equal(t, 1, snap.trees[spanset.SpanGlobal][spanset.SpanReadWrite].Len())
+	require.Equal(t, 3, m.scopes[spanset.SpanGlobal].trees[spanset.SpanReadWrite].Len())
+	m.Release(lg1)
+	require.NoError(t, m.wait(context.Background(), lg3, snap, AcquireOptions{}))
+	snap.close()
+	m.Release(lg2)
+	m.Release(lg3)
+}
+
+func TestLatchManagerMetrics(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+
+	// Acquire a few latches that don't conflict with each other.
+	lg1 := m.MustAcquire(spans("a", "", read, zeroTS))
+	lg2 := m.MustAcquire(spans("b", "c", read, zeroTS))
+	lg3 := m.MustAcquire(spans("localA", "", write, zeroTS))
+	require.Equal(t, int64(2), metrics.LatchesHeldGlobalRead.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalWrite.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldLocalRead.Value())
+	require.Equal(t, int64(1), metrics.LatchesHeldLocalWrite.Value())
+	require.Equal(t, int64(3), metrics.Acquisitions.Count())
+	require.Equal(t, int64(0), metrics.AcquisitionsWaited.Count())
+	require.Equal(t, int64(0), metrics.ReadSetFlushes.Count())
+
+	// A write that conflicts with both reads flushes the read set and waits on
+	// both of them.
+	lg4C := m.MustAcquireCh(spans("a", "d", write, zeroTS))
+	testLatchBlocks(t, lg4C)
+	require.Equal(t, int64(1), metrics.ReadSetFlushes.Count())
+	require.Equal(t, int64(1), metrics.LatchesHeldGlobalWrite.Value())
+	m.Release(lg1)
+	m.Release(lg2)
+	lg4 := testLatchSucceeds(t, lg4C)
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalRead.Value())
+	require.Equal(t, int64(4), metrics.Acquisitions.Count())
+	require.Equal(t, int64(1), metrics.AcquisitionsWaited.Count())
+	require.Equal(t, int64(1), metrics.WaitDuration.TotalCount())
+	require.Equal(t, int64(1), metrics.WaitsPerAcquisition.TotalCount())
+	require.Equal(t, int64(2), metrics.WaitsPerAcquisition.Min())
+
+	// Once all latches are released, none are held.
+	m.Release(lg3)
+	m.Release(lg4)
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalRead.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldGlobalWrite.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldLocalRead.Value())
+	require.Equal(t, int64(0), metrics.LatchesHeldLocalWrite.Value())
+}
+
+func TestLatchManagerSlowWait(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	metrics := NewMetrics(time.Hour)
+	m := Make(nil /* stopper */, nil /* slowReqs */, metrics)
+	ctx := context.Background()
+
+	lg1 := m.MustAcquire(spans("a", "b", write, zeroTS))
+
+	// An attempt that waits on the conflicting latch for longer than its slow
+	// threshold is counted as a slow wait.
+	lg2C := make(chan *Guard)
+	go func() {
+		opts := AcquireOptions{SlowThreshold: time.Millisecond}
+		lg, err := m.AcquireWithOptions(ctx, spans("a", "", read, zeroTS), opts)
+		if err != nil {
+			panic(err)
+		}
+		lg2C <- lg
+	}()
+	testutils.SucceedsSoon(t, func() error {
+		if n := metrics.SlowWaits.Count(); n == 0 {
+			return fmt.Errorf("expected a slow wait, found %d", n)
+		}
+		return nil
+	})
+
+	m.Release(lg1)
+	m.Release(testLatchSucceeds(t, lg2C))
+}
+
 func BenchmarkLatchManagerReadOnlyMix(b *testing.B) {
 	for _, size := range []int{1, 4, 16, 64, 128, 256} {
 		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
@@ -545,6 +1689,7 @@ func BenchmarkLatchManagerReadOnlyMix(b *testing.B) {
 				_ = m.MustAcquire(ss)
 			}
 
+			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
 				_ = m.MustAcquire(ss)
@@ -575,9 +1720,10 @@ func BenchmarkLatchManagerReadWriteMix(b *testing.B) {
 				spans[i].AddNonMVCC(access, span)
 			}
 
+			b.ReportAllocs()
 			b.ResetTimer()
 			for i := range spans {
-				lg, snap := m.sequence(&spans[i])
+				lg, snap := m.sequence(&spans[i], AcquireOptions{})
 				snap.close()
 				if len(lgBuf) == cap(lgBuf) {
 					m.Release(<-lgBuf)
@@ -0,0 +1,191 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package spanlatch
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+)
+
+var (
+	metaLatchesHeldGlobalRead = metric.Metadata{
+		Name:        "latch.held.global.read",
+		Help:        "Number of read latches on global keys currently held or waiting to be acquired",
+		Measurement: "Latches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchesHeldGlobalWrite = metric.Metadata{
+		Name:        "latch.held.global.write",
+		Help:        "Number of write latches on global keys currently held or waiting to be acquired",
+		Measurement: "Latches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchesHeldLocalRead = metric.Metadata{
+		Name:        "latch.held.local.read",
+		Help:        "Number of read latches on local keys currently held or waiting to be acquired",
+		Measurement: "Latches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchesHeldLocalWrite = metric.Metadata{
+		Name:        "latch.held.local.write",
+		Help:        "Number of write latches on local keys currently held or waiting to be acquired",
+		Measurement: "Latches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchAcquisitions = metric.Metadata{
+		Name:        "latch.acquisitions",
+		Help:        "Number of latch acquisitions",
+		Measurement: "Acquisitions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchAcquisitionsWaited = metric.Metadata{
+		Name:        "latch.acquisitions.waited",
+		Help:        "Number of latch acquisitions that had to wait on conflicting latches",
+		Measurement: "Acquisitions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchWaitDuration = metric.Metadata{
+		Name:        "latch.wait.duration",
+		Help:        "Histogram of durations spent waiting on conflicting latches by latch acquisitions that had to wait",
+		Measurement: "Latch wait time",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaLatchWaitsPerAcquisition = metric.Metadata{
+		Name:        "latch.wait.count",
+		Help:        "Histogram of the number of conflicting latches waited on by latch acquisitions that had to wait",
+		Measurement: "Latches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchSlowWaits = metric.Metadata{
+		Name:        "latch.wait.slow",
+		Help:        "Number of times a latch acquisition waited on a conflicting latch for longer than the slow latch threshold",
+		Measurement: "Waits",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchBackpressured = metric.Metadata{
+		Name:        "latch.backpressured",
+		Help:        "Number of latch acquisitions that were delayed or rejected because the latch manager tracked too many latches",
+		Measurement: "Acquisitions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchHeldTooLong = metric.Metadata{
+		Name:        "latch.held.too_long",
+		Help:        "Number of times latches were found to be held for longer than the maximum latch hold duration",
+		Measurement: "Guards",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchSnapshotNodesCloned = metric.Metadata{
+		Name:        "latch.snapshot.nodes_cloned",
+		Help:        "Histogram of the number of interval tree nodes copied because of each snapshot of a latch manager",
+		Measurement: "Nodes",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchFastPathAcquisitions = metric.Metadata{
+		Name:        "latch.acquisitions.fast_path",
+		Help:        "Number of latch acquisitions that went through the read fast path without locking the latch manager",
+		Measurement: "Acquisitions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLatchReadSetFlushes = metric.Metadata{
+		Name:        "latch.readset.flushes",
+		Help:        "Number of times the read set was flushed into the read interval tree by a write latch acquisition",
+		Measurement: "Flushes",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// Metrics contains the metrics of the latch managers of a store.
+type Metrics struct {
+	LatchesHeldGlobalRead  *metric.Gauge
+	LatchesHeldGlobalWrite *metric.Gauge
+	LatchesHeldLocalRead   *metric.Gauge
+	LatchesHeldLocalWrite  *metric.Gauge
+
+	Acquisitions        *metric.Counter
+	AcquisitionsWaited  *metric.Counter
+	WaitDuration        *metric.Histogram
+	WaitsPerAcquisition *metric.Histogram
+	SlowWaits           *metric.Counter
+	Backpressured       *metric.Counter
+	HeldTooLong         *metric.Counter
+
+	ReadSetFlushes       *metric.Counter
+	FastPathAcquisitions *metric.Counter
+	SnapshotNodesCloned  *metric.Histogram
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (*Metrics) MetricStruct() {}
+
+// NewMetrics creates a new Metrics instance with all related metric fields.
+func NewMetrics(histogramWindowInterval time.Duration) *Metrics {
+	return &Metrics{
+		LatchesHeldGlobalRead:  metric.NewGauge(metaLatchesHeldGlobalRead),
+		LatchesHeldGlobalWrite: metric.NewGauge(metaLatchesHeldGlobalWrite),
+		LatchesHeldLocalRead:   metric.NewGauge(metaLatchesHeldLocalRead),
+		LatchesHeldLocalWrite:  metric.NewGauge(metaLatchesHeldLocalWrite),
+
+		Acquisitions:       metric.NewCounter(metaLatchAcquisitions),
+		AcquisitionsWaited: metric.NewCounter(metaLatchAcquisitionsWaited),
+		WaitDuration: metric.NewHistogram(
+			metaLatchWaitDuration, histogramWindowInterval, time.Hour.Nanoseconds(), 1,
+		),
+		WaitsPerAcquisition: metric.NewHistogram(
+			metaLatchWaitsPerAcquisition, histogramWindowInterval, 10000, 1,
+		),
+		SlowWaits:     metric.NewCounter(metaLatchSlowWaits),
+		Backpressured: metric.NewCounter(metaLatchBackpressured),
+		HeldTooLong:   metric.NewCounter(metaLatchHeldTooLong),
+
+		ReadSetFlushes:       metric.NewCounter(metaLatchReadSetFlushes),
+		FastPathAcquisitions: metric.NewCounter(metaLatchFastPathAcquisitions),
+		SnapshotNodesCloned: metric.NewHistogram(
+			metaLatchSnapshotNodesCloned, histogramWindowInterval, 1000000, 1,
+		),
+	}
+}
+
+// latchesHeld returns the gauge tracking the number of latches with the given
+// scope and access. Exclusive reads are counted as writes.
+func (m *Metrics) latchesHeld(s spanset.SpanScope, a spanset.SpanAccess) *metric.Gauge {
+	a = treeAccess(a)
+	switch s {
+	case spanset.SpanGlobal:
+		switch a {
+		case spanset.SpanReadOnly:
+			return m.LatchesHeldGlobalRead
+		case spanset.SpanReadWrite:
+			return m.LatchesHeldGlobalWrite
+		}
+	case spanset.SpanLocal:
+		switch a {
+		case spanset.SpanReadOnly:
+			return m.LatchesHeldLocalRead
+		case spanset.SpanReadWrite:
+			return m.LatchesHeldLocalWrite
+		}
+	}
+	panic("unknown scope or access")
+}
+
+// recordAcquisition records a latch acquisition that waited on the given
+// number of conflicting latches for the given duration.
+func (m *Metrics) recordAcquisition(waits int64, dur time.Duration) {
+	m.Acquisitions.Inc(1)
+	if waits == 0 {
+		return
+	}
+	m.AcquisitionsWaited.Inc(1)
+	m.WaitDuration.RecordValue(dur.Nanoseconds())
+	m.WaitsPerAcquisition.RecordValue(waits)
+}
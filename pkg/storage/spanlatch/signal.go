@@ -96,3 +96,24 @@ func init() {
 	closedC = make(chan struct{})
 	close(closedC)
 }
+
+// idempotentSignal is like signal, but its signal method can be called
+// multiple times.
+type idempotentSignal struct {
+	sig  signal
+	once int32
+}
+
+func (s *idempotentSignal) signal() {
+	if atomic.CompareAndSwapInt32(&s.once, 0, 1) {
+		s.sig.signal()
+	}
+}
+
+func (s *idempotentSignal) signaled() bool {
+	return s.sig.signaled()
+}
+
+func (s *idempotentSignal) signalChan() <-chan struct{} {
+	return s.sig.signalChan()
+}
@@ -0,0 +1,225 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package spanlatch
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+// spanSizeDist is a distribution of the number of keys covered by the spans
+// of a simulated latch workload. A size of 1 is a point span.
+type spanSizeDist struct {
+	name string
+	next func(rng *rand.Rand) int
+}
+
+func pointSpans() spanSizeDist {
+	return spanSizeDist{name: "point", next: func(*rand.Rand) int { return 1 }}
+}
+
+func uniformSpans(max int) spanSizeDist {
+	return spanSizeDist{
+		name: fmt.Sprintf("uniform(%d)", max),
+		next: func(rng *rand.Rand) int { return 1 + rng.Intn(max) },
+	}
+}
+
+// zipfSpans returns a distribution of mostly short spans with a long tail of
+// spans covering up to max keys.
+func zipfSpans(max int) spanSizeDist {
+	return spanSizeDist{
+		name: fmt.Sprintf("zipf(%d)", max),
+		next: func(rng *rand.Rand) int {
+			// NB: the generator only holds onto rng, so it is cheap to create.
+			return 1 + int(rand.NewZipf(rng, 1.1, 1, uint64(max-1)).Uint64())
+		},
+	}
+}
+
+// latchWorkload configures a simulated latch workload, which acquires and
+// releases latches from a single Manager from concurrent workers. Each latch
+// acquisition declares a single non-MVCC span over keys picked uniformly at
+// random from the key space.
+type latchWorkload struct {
+	workers  int
+	keySpace int
+	spanSize spanSizeDist
+	// readFrac is the fraction of latch acquisitions that are reads.
+	readFrac float64
+	// holdTime is the time for which latches are held once acquired.
+	holdTime time.Duration
+}
+
+func (w latchWorkload) String() string {
+	return fmt.Sprintf("workers=%d/keys=%d/spans=%s/reads=%.2f/hold=%s",
+		w.workers, w.keySpace, w.spanSize.name, w.readFrac, w.holdTime)
+}
+
+// latchWorkloadResult summarizes a run of a latch workload.
+type latchWorkloadResult struct {
+	elapsed  time.Duration
+	metrics  *Metrics
+	leftover int
+}
+
+// throughput returns the number of latch acquisitions per second.
+func (r latchWorkloadResult) throughput() float64 {
+	return float64(r.metrics.Acquisitions.Count()) / r.elapsed.Seconds()
+}
+
+// waitedFrac returns the fraction of latch acquisitions that had to wait.
+func (r latchWorkloadResult) waitedFrac() float64 {
+	return float64(r.metrics.AcquisitionsWaited.Count()) / float64(r.metrics.Acquisitions.Count())
+}
+
+// waitPercentile returns the provided percentile of the time spent waiting
+// by the latch acquisitions that had to wait.
+func (r latchWorkloadResult) waitPercentile(p float64) time.Duration {
+	return time.Duration(r.metrics.WaitDuration.Snapshot().ValueAtQuantile(p))
+}
+
+// generate returns the span sets of the latch acquisitions of each worker,
+// which are generated up front so that they don't count towards the
+// measurements.
+func (w latchWorkload) generate(ops int) [][]spanset.SpanSet {
+	keys := make([]roachpb.Key, w.keySpace+1)
+	for i := range keys {
+		keys[i] = roachpb.Key(fmt.Sprintf("k%08d", i))
+	}
+	sets := make([][]spanset.SpanSet, w.workers)
+	for i := range sets {
+		rng := rand.New(rand.NewSource(int64(i)))
+		sets[i] = make([]spanset.SpanSet, (ops+w.workers-1-i)/w.workers)
+		for j := range sets[i] {
+			size := w.spanSize.next(rng)
+			if size > w.keySpace {
+				size = w.keySpace
+			}
+			start := rng.Intn(w.keySpace - size + 1)
+			span := roachpb.Span{Key: keys[start]}
+			if size > 1 {
+				span.EndKey = keys[start+size]
+			}
+			access := spanset.SpanReadWrite
+			if rng.Float64() < w.readFrac {
+				access = spanset.SpanReadOnly
+			}
+			sets[i][j].AddNonMVCC(access, span)
+		}
+	}
+	return sets
+}
+
+// run runs the provided latch acquisitions, as returned by generate, and
+// reports on them. If the provided function is non-nil, it is invoked right
+// before the workers start.
+func (w latchWorkload) run(sets [][]spanset.SpanSet, start func()) latchWorkloadResult {
+	ctx := context.Background()
+	m := Make(nil /* stopper */, nil /* slowReqs */, NewMetrics(time.Hour))
+
+	var wg sync.WaitGroup
+	wg.Add(len(sets))
+	if start != nil {
+		start()
+	}
+	begin := timeutil.Now()
+	for i := range sets {
+		go func(sets []spanset.SpanSet) {
+			defer wg.Done()
+			for j := range sets {
+				lg, err := m.Acquire(ctx, &sets[j])
+				if err != nil {
+					panic(err)
+				}
+				if w.holdTime > 0 {
+					time.Sleep(w.holdTime)
+				}
+				m.Release(lg)
+			}
+		}(sets[i])
+	}
+	wg.Wait()
+	return latchWorkloadResult{
+		elapsed:  timeutil.Since(begin),
+		metrics:  m.metrics,
+		leftover: len(m.Latches()),
+	}
+}
+
+// latchWorkloads returns the latch workloads simulated by
+// BenchmarkLatchManagerWorkload.
+func latchWorkloads() []latchWorkload {
+	var ws []latchWorkload
+	for _, spanSize := range []spanSizeDist{pointSpans(), uniformSpans(16), zipfSpans(1024)} {
+		for _, readFrac := range []float64{1, 0.9, 0.5} {
+			for _, holdTime := range []time.Duration{0, 100 * time.Microsecond} {
+				ws = append(ws, latchWorkload{
+					workers:  16,
+					keySpace: 10000,
+					spanSize: spanSize,
+					readFrac: readFrac,
+					holdTime: holdTime,
+				})
+			}
+		}
+	}
+	return ws
+}
+
+// BenchmarkLatchManagerWorkload simulates latch workloads with various span
+// sizes, read/write ratios and hold times. Alongside the time and allocations
+// per latch acquisition, it reports the throughput, the fraction of latch
+// acquisitions that had to wait and the wait time percentiles of those that
+// did, which allows for changes to the Manager (e.g. to the tuning of its read
+// set) to be evaluated without a cluster.
+func BenchmarkLatchManagerWorkload(b *testing.B) {
+	for _, w := range latchWorkloads() {
+		w := w
+		b.Run(w.String(), func(b *testing.B) {
+			sets := w.generate(b.N)
+			b.ReportAllocs()
+			res := w.run(sets, b.ResetTimer)
+			b.StopTimer()
+			b.ReportMetric(res.throughput(), "acquisitions/s")
+			b.ReportMetric(res.waitedFrac(), "waited/op")
+			b.ReportMetric(float64(res.waitPercentile(50)), "p50-wait-ns")
+			b.ReportMetric(float64(res.waitPercentile(99)), "p99-wait-ns")
+		})
+	}
+}
+
+func TestLatchManagerWorkload(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	const ops = 1000
+	for _, w := range latchWorkloads() {
+		if w.holdTime > 0 {
+			// Holding latches only slows down the test.
+			continue
+		}
+		t.Run(w.String(), func(t *testing.T) {
+			res := w.run(w.generate(ops), nil /* start */)
+			require.Equal(t, int64(ops), res.metrics.Acquisitions.Count())
+			require.Zero(t, res.leftover)
+			require.True(t, res.waitedFrac() >= 0 && res.waitedFrac() <= 1)
+		})
+	}
+}
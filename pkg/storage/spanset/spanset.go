@@ -25,18 +25,32 @@ import (
 // SpanAccess records the intended mode of access in a SpanSet.
 type SpanAccess int
 
-// Constants for SpanAccess. Higher-valued accesses imply lower-level ones.
+// Constants for SpanAccess. All other accesses imply SpanReadOnly (see
+// implies).
 const (
 	SpanReadOnly SpanAccess = iota
 	SpanReadWrite
+	// SpanReadExclusive permits reading a span, like SpanReadOnly, while
+	// excluding all other reads and writes to it, like a non-MVCC
+	// SpanReadWrite. It allows for operations that need to observe a span
+	// without any concurrent access (e.g. the RHS of a split) to not declare
+	// write access to it. SpanReadExclusive accesses are always non-MVCC.
+	SpanReadExclusive
 	NumSpanAccess
 )
 
+// implies returns whether access a permits access b.
+func (a SpanAccess) implies(b SpanAccess) bool {
+	return a == b || b == SpanReadOnly
+}
+
 // String returns a string representation of the SpanAccess.
 func (a SpanAccess) String() string {
 	switch a {
 	case SpanReadOnly:
 		return "read"
+	case SpanReadExclusive:
+		return "exclusive-read"
 	case SpanReadWrite:
 		return "write"
 	default:
@@ -123,7 +137,12 @@ func (s *SpanSet) AddNonMVCC(access SpanAccess, span roachpb.Span) {
 
 // AddMVCC adds an MVCC span to the span set to be accessed at the given
 // timestamp. This should typically be used for MVCC keys, user keys for e.g.
+// The timestamp is ignored for SpanReadExclusive accesses, which are always
+// non-MVCC.
 func (s *SpanSet) AddMVCC(access SpanAccess, span roachpb.Span, timestamp hlc.Timestamp) {
+	if access == SpanReadExclusive {
+		timestamp = hlc.Timestamp{}
+	}
 	scope := SpanGlobal
 	if keys.IsLocal(span.Key) {
 		scope = SpanLocal
@@ -192,7 +211,10 @@ func (s *SpanSet) checkAllowed(access SpanAccess, span roachpb.Span, reversed bo
 		scope = SpanLocal
 	}
 
-	for ac := access; ac < NumSpanAccess; ac++ {
+	for ac := SpanAccess(0); ac < NumSpanAccess; ac++ {
+		if !ac.implies(access) {
+			continue
+		}
 		for _, cur := range s.spans[ac][scope] {
 			if cur.Contains(span) &&
 				(!reversed || cur.EndKey != nil && !cur.Key.Equal(span.Key)) ||
@@ -223,7 +245,10 @@ func (s *SpanSet) checkAllowedAt(
 		scope = SpanLocal
 	}
 
-	for ac := access; ac < NumSpanAccess; ac++ {
+	for ac := SpanAccess(0); ac < NumSpanAccess; ac++ {
+		if !ac.implies(access) {
+			continue
+		}
 		for _, cur := range s.spans[ac][scope] {
 			if (cur.Contains(span) &&
 				(!reversed || (cur.EndKey != nil && !cur.Key.Equal(span.Key)))) ||
@@ -307,3 +307,22 @@ func TestSpanSetWriteImpliesRead(t *testing.T) {
 		t.Errorf("expected to be allowed to read rwSpan, error: %+v", err)
 	}
 }
+
+func TestSpanSetExclusiveRead(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var ss SpanSet
+	exSpan := roachpb.Span{Key: roachpb.Key("exclusive-read")}
+	ss.AddMVCC(SpanReadExclusive, exSpan, hlc.Timestamp{WallTime: 2})
+
+	// Exclusive reads are always non-MVCC.
+	if ts := ss.GetSpans(SpanReadExclusive, SpanGlobal)[0].Timestamp; !ts.IsEmpty() {
+		t.Errorf("expected an empty timestamp, found %s", ts)
+	}
+	if err := ss.CheckAllowedAt(SpanReadOnly, exSpan, hlc.Timestamp{WallTime: 3}); err != nil {
+		t.Errorf("expected to be allowed to read exSpan, error: %+v", err)
+	}
+	if err := ss.CheckAllowed(SpanReadWrite, exSpan); err == nil {
+		t.Errorf("expected not to be allowed to write exSpan")
+	}
+}
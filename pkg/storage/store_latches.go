@@ -0,0 +1,198 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// StoreLatchDump is a serializable snapshot of the latches held or waited on
+// in the latch managers of the replicas of a store. It is meant to diagnose
+// stuck requests after the fact.
+type StoreLatchDump struct {
+	StoreID roachpb.StoreID  `json:"store_id"`
+	Ranges  []RangeLatchDump `json:"ranges"`
+}
+
+// RangeLatchDump is a snapshot of the latch manager of a replica.
+type RangeLatchDump struct {
+	RangeID roachpb.RangeID `json:"range_id"`
+	Latches []LatchDump     `json:"latches"`
+	Waits   []LatchWaitDump `json:"waits,omitempty"`
+}
+
+// LatchDump describes a latch, which is either held or whose acquisition is
+// still waiting on conflicting latches. See spanlatch.LatchInfo.
+type LatchDump struct {
+	Span      string `json:"span"`
+	Scope     string `json:"scope"`
+	Access    string `json:"access"`
+	Timestamp string `json:"timestamp"`
+	HolderID  uint64 `json:"holder_id"`
+	Holder    string `json:"holder,omitempty"`
+	Waiting   bool   `json:"waiting"`
+	Duration  string `json:"duration"`
+}
+
+// LatchWaitDump describes a latch acquisition that is waiting on a
+// conflicting latch. See spanlatch.LatchWait.
+type LatchWaitDump struct {
+	WaiterID          uint64 `json:"waiter_id"`
+	Waiter            string `json:"waiter,omitempty"`
+	BlockingSpan      string `json:"blocking_span"`
+	BlockingAccess    string `json:"blocking_access"`
+	BlockingTimestamp string `json:"blocking_timestamp"`
+	BlockingHolderID  uint64 `json:"blocking_holder_id"`
+	BlockingHolder    string `json:"blocking_holder,omitempty"`
+	Duration          string `json:"duration"`
+}
+
+// LatchDump returns a snapshot of the latches held or waited on in the latch
+// managers of the store's replicas. Replicas without latches are omitted.
+func (s *Store) LatchDump() StoreLatchDump {
+	dump := StoreLatchDump{StoreID: s.StoreID()}
+	newStoreReplicaVisitor(s).InOrder().Visit(func(r *Replica) bool {
+		if rd, ok := r.latchDump(); ok {
+			dump.Ranges = append(dump.Ranges, rd)
+		}
+		return true
+	})
+	return dump
+}
+
+// latchDump returns a snapshot of the replica's latch manager, or false if it
+// doesn't track any latches.
+func (r *Replica) latchDump() (RangeLatchDump, bool) {
+	latches := r.latchMgr.Latches()
+	if len(latches) == 0 {
+		return RangeLatchDump{}, false
+	}
+	rd := RangeLatchDump{
+		RangeID: r.RangeID,
+		Latches: make([]LatchDump, 0, len(latches)),
+	}
+	for _, la := range latches {
+		rd.Latches = append(rd.Latches, LatchDump{
+			Span:      la.Span.String(),
+			Scope:     formatSpanScope(la.Scope),
+			Access:    la.Access.String(),
+			Timestamp: la.Timestamp.String(),
+			HolderID:  la.HolderID,
+			Holder:    formatLatchHolder(la.Holder),
+			Waiting:   la.Waiting,
+			Duration:  la.Duration.String(),
+		})
+	}
+	for _, w := range r.latchMgr.Waits() {
+		rd.Waits = append(rd.Waits, LatchWaitDump{
+			WaiterID:          w.WaiterID,
+			Waiter:            formatLatchHolder(w.Waiter),
+			BlockingSpan:      w.BlockingSpan.String(),
+			BlockingAccess:    w.BlockingAccess.String(),
+			BlockingTimestamp: w.BlockingTimestamp.String(),
+			BlockingHolderID:  w.BlockingHolderID,
+			BlockingHolder:    formatLatchHolder(w.BlockingHolder),
+			Duration:          w.Duration.String(),
+		})
+	}
+	return rd, true
+}
+
+func formatSpanScope(s spanset.SpanScope) string {
+	switch s {
+	case spanset.SpanGlobal:
+		return "global"
+	case spanset.SpanLocal:
+		return "local"
+	default:
+		panic("unknown scope")
+	}
+}
+
+func formatLatchHolder(h fmt.Stringer) string {
+	if h == nil {
+		return ""
+	}
+	return h.String()
+}
+
+// latchWatchdogInterval is the interval at which the latch watchdog checks for
+// latches held for longer than kv.latch.max_hold_duration.
+const latchWatchdogInterval = 10 * time.Second
+
+// startLatchWatchdog runs a loop in a goroutine which regularly checks the
+// replicas of the store for latches held for longer than
+// kv.latch.max_hold_duration, and handles them according to
+// kv.latch.max_hold_action.
+func (s *Store) startLatchWatchdog(ctx context.Context) {
+	s.stopper.RunWorker(ctx, func(ctx context.Context) {
+		timer := timeutil.NewTimer()
+		defer timer.Stop()
+		for {
+			timer.Reset(latchWatchdogInterval)
+			select {
+			case <-timer.C:
+				timer.Read = true
+			case <-s.stopper.ShouldStop():
+				return
+			}
+
+			maxHold := maxLatchHoldDuration.Get(&s.cfg.Settings.SV)
+			if maxHold == 0 {
+				continue
+			}
+			action := maxLatchHoldAction.Get(&s.cfg.Settings.SV)
+			newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+				r.checkLatchHoldDuration(ctx, maxHold, action)
+				return true
+			})
+		}
+	})
+}
+
+// checkLatchHoldDuration handles the latches of the replica that have been
+// held for longer than maxHold according to the provided action (see
+// kv.latch.max_hold_action). Each Guard is only handled once.
+func (r *Replica) checkLatchHoldDuration(
+	ctx context.Context, maxHold time.Duration, action int64,
+) {
+	held := r.latchMgr.LongHeld(maxHold)
+	if len(held) == 0 {
+		return
+	}
+	ctx = r.AnnotateCtx(ctx)
+	for _, h := range held {
+		holder := formatLatchHolder(h.Holder)
+		stack := h.Stack
+		if stack == "" {
+			stack = "<not captured; see kv.latch.capture_stacks.enabled>"
+		}
+		switch action {
+		case latchHoldActionPoison:
+			log.Warningf(ctx, "poisoning latches of %q (id %d) held for %s; acquired at:\n%s",
+				holder, h.HolderID, h.Held, stack)
+			r.latchMgr.Poison(h.Guard)
+		case latchHoldActionCrash:
+			log.Fatalf(ctx, "latches of %q (id %d) held for %s; acquired at:\n%s",
+				holder, h.HolderID, h.Held, stack)
+		default:
+			log.Warningf(ctx, "latches of %q (id %d) held for %s; acquired at:\n%s",
+				holder, h.HolderID, h.Held, stack)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanlatch"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreLatchDump(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc.Start(t, stopper)
+
+	require.Empty(t, tc.store.LatchDump().Ranges)
+
+	span := roachpb.Span{Key: roachpb.Key("a")}
+	put := putArgs(span.Key, []byte("value"))
+	var ba roachpb.BatchRequest
+	ba.Add(&put)
+	var spans spanset.SpanSet
+	spans.AddMVCC(spanset.SpanReadWrite, span, tc.Clock().Now())
+	lg, err := tc.repl.latchMgr.AcquireWithOptions(ctx, &spans, spanlatch.AcquireOptions{
+		Holder: makeLatchHolder(&ba),
+	})
+	require.NoError(t, err)
+
+	dump := tc.store.LatchDump()
+	require.Equal(t, tc.store.StoreID(), dump.StoreID)
+	require.Len(t, dump.Ranges, 1)
+	rd := dump.Ranges[0]
+	require.Equal(t, tc.repl.RangeID, rd.RangeID)
+	require.Len(t, rd.Latches, 1)
+	require.Equal(t, span.String(), rd.Latches[0].Span)
+	require.Equal(t, "global", rd.Latches[0].Scope)
+	require.Equal(t, spanset.SpanReadWrite.String(), rd.Latches[0].Access)
+	require.Equal(t, "Put non-txn", rd.Latches[0].Holder)
+	require.False(t, rd.Latches[0].Waiting)
+	require.Empty(t, rd.Waits)
+
+	// The dump is serializable.
+	_, err = json.Marshal(dump)
+	require.NoError(t, err)
+
+	tc.repl.latchMgr.Release(lg)
+	require.Empty(t, tc.store.LatchDump().Ranges)
+}
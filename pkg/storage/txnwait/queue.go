@@ -186,7 +186,10 @@ type TestingKnobs struct {
 // Queue is thread safe.
 type Queue struct {
 	store StoreInterface
-	mu    struct {
+	// waitingTxns, if set, reports the transactions that wait on a
+	// transaction outside of the Queue. See SetWaitingTxnsFunc.
+	waitingTxns WaitingTxnsFunc
+	mu          struct {
 		syncutil.Mutex
 		txns    map[uuid.UUID]*pendingTxn
 		queries map[uuid.UUID]*waitingQueries
@@ -200,6 +203,19 @@ func NewQueue(store StoreInterface) *Queue {
 	}
 }
 
+// WaitingTxnsFunc returns the transactions that wait on the specified txn
+// outside of a Queue, for instance on the latches held by its requests.
+type WaitingTxnsFunc func(txnID uuid.UUID) []uuid.UUID
+
+// SetWaitingTxnsFunc configures the Queue to report the transactions returned
+// by the provided function as dependents of a txn, in addition to the ones
+// waiting in the Queue, so that deadlocks that involve waits outside of the
+// Queue are detected as well. It must be called before the Queue is used
+// concurrently.
+func (q *Queue) SetWaitingTxnsFunc(fn WaitingTxnsFunc) {
+	q.waitingTxns = fn
+}
+
 // Enable allows transactions to be enqueued and waiting pushers
 // added. This method must be idempotent as it can be invoked multiple
 // times as range leases are updated for the same replica.
@@ -338,23 +354,40 @@ func (q *Queue) UpdateTxn(ctx context.Context, txn *roachpb.Transaction) {
 }
 
 // GetDependents returns a slice of transactions waiting on the specified
-// txn either directly or indirectly.
+// txn either directly or indirectly, including the ones reported by the
+// WaitingTxnsFunc of the Queue.
 func (q *Queue) GetDependents(txnID uuid.UUID) []uuid.UUID {
 	q.mu.Lock()
-	defer q.mu.Unlock()
 	if q.mu.txns == nil {
 		// Not enabled; do nothing.
+		q.mu.Unlock()
 		return nil
 	}
+	var set map[uuid.UUID]struct{}
 	if pending, ok := q.mu.txns[txnID]; ok {
-		set := pending.getDependentsSet()
-		dependents := make([]uuid.UUID, 0, len(set))
-		for txnID := range set {
-			dependents = append(dependents, txnID)
+		set = pending.getDependentsSet()
+	}
+	q.mu.Unlock()
+
+	if q.waitingTxns != nil {
+		for _, id := range q.waitingTxns(txnID) {
+			if id == txnID {
+				continue
+			}
+			if set == nil {
+				set = map[uuid.UUID]struct{}{}
+			}
+			set[id] = struct{}{}
 		}
-		return dependents
 	}
-	return nil
+	if len(set) == 0 {
+		return nil
+	}
+	dependents := make([]uuid.UUID, 0, len(set))
+	for txnID := range set {
+		dependents = append(dependents, txnID)
+	}
+	return dependents
 }
 
 // isTxnUpdated returns whether the transaction specified in
@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -293,3 +294,27 @@ func TestPushersReleasedAfterAnyQueryTxnFindsAbortedTxn(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// TestGetDependentsWaitingTxnsFunc verifies that the transactions reported by
+// the WaitingTxnsFunc of a Queue are returned as dependents of a txn, even if
+// no pushers are waiting on the txn in the Queue.
+func TestGetDependentsWaitingTxnsFunc(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ms := newMockStore(nil)
+	defer ms.Stopper().Stop(context.Background())
+	q := NewQueue(ms)
+
+	pushee, waiter := uuid.MakeV4(), uuid.MakeV4()
+	q.SetWaitingTxnsFunc(func(txnID uuid.UUID) []uuid.UUID {
+		if txnID != pushee {
+			return nil
+		}
+		return []uuid.UUID{waiter, waiter, pushee}
+	})
+
+	// The Queue only reports dependents while enabled.
+	require.Nil(t, q.GetDependents(pushee))
+	q.Enable()
+	require.Equal(t, []uuid.UUID{waiter}, q.GetDependents(pushee))
+	require.Nil(t, q.GetDependents(waiter))
+}
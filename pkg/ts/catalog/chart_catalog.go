@@ -627,6 +627,69 @@ var charts = []sectionDescription{
 			},
 		},
 	},
+	{
+		Organization: [][]string{{ReplicationLayer, "Requests", "Latches"}},
+		Charts: []chartDescription{
+			{
+				Title: "Held",
+				Metrics: []string{
+					"latch.held.global.read",
+					"latch.held.global.write",
+					"latch.held.local.read",
+					"latch.held.local.write",
+				},
+				AxisLabel: "Latches",
+			},
+			{
+				Title: "Acquisitions",
+				Metrics: []string{
+					"latch.acquisitions",
+					"latch.acquisitions.waited",
+				},
+				AxisLabel: "Acquisitions",
+			},
+			{
+				Title:     "Wait Time",
+				Metrics:   []string{"latch.wait.duration"},
+				AxisLabel: "Wait Time",
+			},
+			{
+				Title:     "Fast Path Acquisitions",
+				Metrics:   []string{"latch.acquisitions.fast_path"},
+				AxisLabel: "Acquisitions",
+			},
+			{
+				Title:     "Latches Waited On",
+				Metrics:   []string{"latch.wait.count"},
+				AxisLabel: "Latches",
+			},
+			{
+				Title:     "Slow Waits",
+				Metrics:   []string{"latch.wait.slow"},
+				AxisLabel: "Waits",
+			},
+			{
+				Title:     "Backpressured Acquisitions",
+				Metrics:   []string{"latch.backpressured"},
+				AxisLabel: "Acquisitions",
+			},
+			{
+				Title:     "Latches Held Too Long",
+				Metrics:   []string{"latch.held.too_long"},
+				AxisLabel: "Guards",
+			},
+			{
+				Title:     "Snapshot Nodes Cloned",
+				Metrics:   []string{"latch.snapshot.nodes_cloned"},
+				AxisLabel: "Nodes",
+			},
+			{
+				Title:     "Read Set Flushes",
+				Metrics:   []string{"latch.readset.flushes"},
+				AxisLabel: "Flushes",
+			},
+		},
+	},
 	{
 		Organization: [][]string{{KVTransactionLayer, "Storage"}},
 		Charts: []chartDescription{
@@ -1378,6 +1441,27 @@ var charts = []sectionDescription{
 			},
 		},
 	},
+	{
+		Organization: [][]string{{SQLLayer, "DistSQL", "Vectorized"}},
+		Charts: []chartDescription{
+			{
+				Title: "Current Memory Usage per Operator Class",
+				Metrics: []string{
+					"sql.mem.distsql.vectorized.aggregator.current",
+					"sql.mem.distsql.vectorized.hash_joiner.current",
+					"sql.mem.distsql.vectorized.sorter.current",
+				},
+			},
+			{
+				Title: "Memory Usage per Operator",
+				Metrics: []string{
+					"sql.mem.distsql.vectorized.aggregator.max",
+					"sql.mem.distsql.vectorized.hash_joiner.max",
+					"sql.mem.distsql.vectorized.sorter.max",
+				},
+			},
+		},
+	},
 	{
 		Organization: [][]string{{SQLLayer, "Bulk"}},
 		Charts: []chartDescription{
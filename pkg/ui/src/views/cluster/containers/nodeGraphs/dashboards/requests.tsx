@@ -11,7 +11,7 @@
 import React from "react";
 
 import { LineGraph } from "src/views/cluster/components/linegraph";
-import { Metric, Axis } from "src/views/shared/components/metricQuery";
+import { Metric, Axis, AxisUnits } from "src/views/shared/components/metricQuery";
 
 import { GraphDashboardProps } from "./dashboardUtils";
 
@@ -36,5 +36,28 @@ export default function (props: GraphDashboardProps) {
         <Metric name="cr.store.requests.slow.latch" title="Slow Latch Acquisitions" downsampleMax />
       </Axis>
     </LineGraph>,
+
+    <LineGraph title="Latches Held" sources={storeSources}>
+      <Axis label="latches">
+        <Metric name="cr.store.latch.held.global.read" title="Global Read" />
+        <Metric name="cr.store.latch.held.global.write" title="Global Write" />
+        <Metric name="cr.store.latch.held.local.read" title="Local Read" />
+        <Metric name="cr.store.latch.held.local.write" title="Local Write" />
+      </Axis>
+    </LineGraph>,
+
+    <LineGraph title="Latch Acquisitions" sources={storeSources}>
+      <Axis label="latch acquisitions">
+        <Metric name="cr.store.latch.acquisitions" title="Acquisitions" nonNegativeRate />
+        <Metric name="cr.store.latch.acquisitions.waited" title="Waited" nonNegativeRate />
+        <Metric name="cr.store.latch.readset.flushes" title="Read Set Flushes" nonNegativeRate />
+      </Axis>
+    </LineGraph>,
+
+    <LineGraph title="Latch Wait Time: 99th Percentile" sources={storeSources}>
+      <Axis units={AxisUnits.Duration} label="wait time">
+        <Metric name="cr.store.latch.wait.duration-p99" title="Wait Time" downsampleMax />
+      </Axis>
+    </LineGraph>,
   ];
 }
@@ -217,6 +217,9 @@ export default function Debug() {
         <DebugTableRow title="Stopper">
           <DebugTableLink name="Active Tasks" url="/debug/stopper" />
         </DebugTableRow>
+        <DebugTableRow title="Latches">
+          <DebugTableLink name="Held and Waiting Latches" url="/debug/latches" />
+        </DebugTableRow>
         <DebugTableRow title="Profiling UI/pprof">
           <DebugTableLink name="Heap" url="/debug/pprof/ui/heap/" />
           <DebugTableLink name="Profile" url="/debug/pprof/ui/profile/?seconds=5" />
@@ -175,12 +175,14 @@ func (n *node) incRef() {
 
 // decRef releases a reference to the node. If requested, the method
 // will recurse into child nodes and decrease their refcounts as well.
-func (n *node) decRef(recursive bool) {
+// It returns the number of nodes that were released into the memory pool.
+func (n *node) decRef(recursive bool) int {
 	if atomic.AddInt32(&n.ref, -1) > 0 {
 		// Other references remain. Can't free.
-		return
+		return 0
 	}
 	// Clear and release node into memory pool.
+	freed := 1
 	if n.leaf {
 		ln := nodeToLeaf(n)
 		*ln = leafNode{}
@@ -189,12 +191,13 @@ func (n *node) decRef(recursive bool) {
 		// Release child references first, if requested.
 		if recursive {
 			for i := int16(0); i <= n.count; i++ {
-				n.children[i].decRef(true /* recursive */)
+				freed += n.children[i].decRef(true /* recursive */)
 			}
 		}
 		*n = node{}
 		nodePool.Put(n)
 	}
+	return freed
 }
 
 // clone creates a clone of the receiver with a single reference count.
@@ -655,12 +658,18 @@ type btree struct {
 // held by the btree to be recycled. Failure to call this method before
 // letting a btree be GCed is safe in that it won't cause a memory leak,
 // but it will prevent btree nodes from being efficiently re-used.
-func (t *btree) Reset() {
+//
+// It returns the number of nodes that were recycled, i.e. that were not
+// shared with any other btree. For a btree returned by Clone, these are the
+// nodes that the other btrees copied (or dropped) when they were modified.
+func (t *btree) Reset() int {
+	var freed int
 	if t.root != nil {
-		t.root.decRef(true /* recursive */)
+		freed = t.root.decRef(true /* recursive */)
 		t.root = nil
 	}
 	t.length = 0
+	return freed
 }
 
 // Clone clones the btree, lazily. It does so in constant time.